@@ -0,0 +1,288 @@
+package colorext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"slices"
+	"sync"
+)
+
+// ErrNotAccelerated is returned by an Accelerator method that cannot
+// perform an operation, such as one on an unsupported border mode. Convert,
+// Convolve, Resize and ApplyLUT then fall back to the CPU implementation.
+var ErrNotAccelerated = errors.New("colorext: operation not accelerated")
+
+// Kernel is a convolution kernel of W×H weights in row-major order,
+// centered on weight (W/2, H/2).
+type Kernel struct {
+	W, H    int
+	Weights []float32
+}
+
+// Accelerator performs bulk operations on behalf of Convert, Convolve,
+// Resize and ApplyLUT. Implementations may offload the work to a GPU or
+// other device and must be safe for concurrent use. A method may return
+// ErrNotAccelerated to have the operation run on the CPU instead.
+type Accelerator interface {
+	// Convert copies src into dst over the intersection of their bounds,
+	// converting each pixel through dst's color model.
+	Convert(dst draw.Image, src image.Image) error
+	// Convolve sets each pixel of dst to the sum of the pixels of src
+	// around it weighted by k, reading pixels outside src according to
+	// border. As is usual in image processing, k is not flipped. dst and
+	// src must not share pixels.
+	Convolve(dst, src *GrayF32Image, k Kernel, border Border) error
+	// Resize scales src to fill dst's bounds, sampling src with interp.
+	Resize(dst, src *GrayF32Image, interp Interpolation) error
+	// LUT sets each pixel v of dst to table[v-math.MinInt16], reading v
+	// from src, over the intersection of their bounds.
+	LUT(dst, src *GrayS16Image, table *[65536]int16) error
+}
+
+// CPU is the Accelerator that runs every operation on the CPU, splitting
+// the work into bands processed in parallel. It is always registered, as
+// "cpu", and is used until another accelerator is registered.
+var CPU Accelerator = cpuAccelerator{}
+
+var (
+	acceleratorsMu sync.RWMutex
+	accelerators   = map[string]Accelerator{"cpu": CPU}
+	accelerator    = CPU
+)
+
+// RegisterAccelerator makes a available under name, replacing any previous
+// registration, and selects it. A backend package can therefore call it
+// from an init function so that importing it for its side effects is
+// enough to enable it.
+func RegisterAccelerator(name string, a Accelerator) {
+	acceleratorsMu.Lock()
+	defer acceleratorsMu.Unlock()
+	accelerators[name] = a
+	accelerator = a
+}
+
+// UseAccelerator selects the accelerator registered under name.
+func UseAccelerator(name string) error {
+	acceleratorsMu.Lock()
+	defer acceleratorsMu.Unlock()
+	a, ok := accelerators[name]
+	if !ok {
+		return fmt.Errorf("colorext: accelerator %q is not registered", name)
+	}
+	accelerator = a
+	return nil
+}
+
+// Accelerators returns the names of the registered accelerators in
+// sorted order.
+func Accelerators() []string {
+	acceleratorsMu.RLock()
+	defer acceleratorsMu.RUnlock()
+	names := make([]string, 0, len(accelerators))
+	for name := range accelerators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// currentAccelerator returns the selected accelerator.
+func currentAccelerator() Accelerator {
+	acceleratorsMu.RLock()
+	defer acceleratorsMu.RUnlock()
+	return accelerator
+}
+
+// accelerate runs op on the selected accelerator, falling back to the CPU
+// if it returns ErrNotAccelerated.
+func accelerate(op func(a Accelerator) error) error {
+	a := currentAccelerator()
+	err := op(a)
+	if errors.Is(err, ErrNotAccelerated) && a != CPU {
+		err = op(CPU)
+	}
+	return err
+}
+
+// accelerateContext runs op on the selected accelerator and reports all
+// rows done to progress, or runs cpu if the CPU is selected or op returns
+// ErrNotAccelerated. Nothing is run if ctx is already done.
+func accelerateContext(ctx context.Context, rows int, progress ProgressFunc, op func(a Accelerator) error, cpu func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if a := currentAccelerator(); a != CPU {
+		err := op(a)
+		if !errors.Is(err, ErrNotAccelerated) {
+			if err == nil && progress != nil {
+				progress(rows, rows)
+			}
+			return err
+		}
+	}
+	return cpu()
+}
+
+// Convert copies src into dst over the intersection of their bounds,
+// converting each pixel through dst's color model, using the selected
+// Accelerator.
+func Convert(dst draw.Image, src image.Image) error {
+	return accelerate(func(a Accelerator) error { return a.Convert(dst, src) })
+}
+
+// Convolve sets each pixel of dst to the sum of the pixels of src around
+// it weighted by k, reading pixels outside src according to border, using
+// the selected Accelerator. k is not flipped, and both of its dimensions
+// must be odd. dst and src must not share pixels.
+func Convolve(dst, src *GrayF32Image, k Kernel, border Border) error {
+	if err := k.check(); err != nil {
+		return err
+	}
+	return accelerate(func(a Accelerator) error { return a.Convolve(dst, src, k, border) })
+}
+
+// ConvolveContext is like Convolve but can be canceled through ctx and
+// reports the rows of dst done to progress, which may be nil. Work on the
+// CPU stops between bands of rows once ctx is done, leaving dst partially
+// written, and ctx.Err() is returned. Other accelerators are only checked
+// before they start and report progress when they finish.
+func ConvolveContext(ctx context.Context, dst, src *GrayF32Image, k Kernel, border Border, progress ProgressFunc) error {
+	if err := k.check(); err != nil {
+		return err
+	}
+	return accelerateContext(ctx, dst.Rect.Dy(), progress,
+		func(a Accelerator) error { return a.Convolve(dst, src, k, border) },
+		func() error { return cpuConvolve(ctx, dst, src, k, border, progress) })
+}
+
+// check reports whether k is a valid convolution kernel.
+func (k Kernel) check() error {
+	if k.W <= 0 || k.H <= 0 || k.W%2 == 0 || k.H%2 == 0 {
+		return fmt.Errorf("colorext: kernel size %d×%d is not odd", k.W, k.H)
+	}
+	if len(k.Weights) != k.W*k.H {
+		return fmt.Errorf("colorext: kernel has %d weights, want %d", len(k.Weights), k.W*k.H)
+	}
+	return nil
+}
+
+// Resize scales src to fill dst's bounds, sampling src with interp at the
+// position of each pixel center of dst, using the selected Accelerator.
+// Edge pixels are clamped. No prefilter is applied; reduce by integer
+// factors with Decimate first to avoid aliasing.
+func Resize(dst, src *GrayF32Image, interp Interpolation) error {
+	return accelerate(func(a Accelerator) error { return a.Resize(dst, src, interp) })
+}
+
+// ResizeContext is like Resize but can be canceled through ctx and reports
+// the rows of dst done to progress, as for ConvolveContext.
+func ResizeContext(ctx context.Context, dst, src *GrayF32Image, interp Interpolation, progress ProgressFunc) error {
+	return accelerateContext(ctx, dst.Rect.Dy(), progress,
+		func(a Accelerator) error { return a.Resize(dst, src, interp) },
+		func() error { return cpuResize(ctx, dst, src, interp, progress) })
+}
+
+// ApplyLUT sets each pixel v of dst to table[v-math.MinInt16], reading v
+// from src, over the intersection of their bounds, using the selected
+// Accelerator. src and dst may be the same image.
+func ApplyLUT(dst, src *GrayS16Image, table *[65536]int16) error {
+	return accelerate(func(a Accelerator) error { return a.LUT(dst, src, table) })
+}
+
+// ApplyLUTContext is like ApplyLUT but can be canceled through ctx and
+// reports the rows done to progress, as for ConvolveContext.
+func ApplyLUTContext(ctx context.Context, dst, src *GrayS16Image, table *[65536]int16, progress ProgressFunc) error {
+	return accelerateContext(ctx, src.Rect.Intersect(dst.Rect).Dy(), progress,
+		func(a Accelerator) error { return a.LUT(dst, src, table) },
+		func() error { return cpuLUT(ctx, dst, src, table, progress) })
+}
+
+// cpuAccelerator is the Accelerator behind CPU.
+type cpuAccelerator struct{}
+
+func (cpuAccelerator) Convert(dst draw.Image, src image.Image) error {
+	r := dst.Bounds().Intersect(src.Bounds())
+	if d, ok := dst.(*GrayS16Image); ok {
+		if s, ok := src.(*GrayS16Image); ok && !r.Empty() {
+			copyRows16(d.Pix, d.Stride, d.PixOffset(r.Min.X, r.Min.Y),
+				s.Pix, s.Stride, s.PixOffset(r.Min.X, r.Min.Y), r, r.Min)
+			return nil
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.Set(x, y, src.At(x, y))
+		}
+	}
+	return nil
+}
+
+func (cpuAccelerator) Convolve(dst, src *GrayF32Image, k Kernel, border Border) error {
+	return cpuConvolve(context.Background(), dst, src, k, border, nil)
+}
+
+func (cpuAccelerator) Resize(dst, src *GrayF32Image, interp Interpolation) error {
+	return cpuResize(context.Background(), dst, src, interp, nil)
+}
+
+func (cpuAccelerator) LUT(dst, src *GrayS16Image, table *[65536]int16) error {
+	return cpuLUT(context.Background(), dst, src, table, nil)
+}
+
+func cpuConvolve(ctx context.Context, dst, src *GrayF32Image, k Kernel, border Border, progress ProgressFunc) error {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	cx, cy := k.W/2, k.H/2
+	workers, band := (*ParallelOptions)(nil).defaults(dst.Rect, 4)
+	return forBandsContext(ctx, dst.Rect, workers, band, progress, func(b image.Rectangle) {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				v := math.NaN()
+				if w > 0 && h > 0 {
+					v = 0
+					for j := 0; j < k.H; j++ {
+						sy, oky := border.index(y+j-cy-src.Rect.Min.Y, h)
+						for i := 0; i < k.W; i++ {
+							wt := k.Weights[j*k.W+i]
+							if wt == 0 {
+								continue
+							}
+							sx, okx := border.index(x+i-cx-src.Rect.Min.X, w)
+							if !okx || !oky {
+								v = math.NaN()
+								continue
+							}
+							v += float64(wt) * float64(getF32(src.Pix[sy*src.Stride+4*sx:]))
+						}
+					}
+				}
+				putF32(dst.Pix[dst.PixOffset(x, y):], float32(v))
+			}
+		}
+	})
+}
+
+func cpuResize(ctx context.Context, dst, src *GrayF32Image, interp Interpolation, progress ProgressFunc) error {
+	sx := float64(src.Rect.Dx()) / float64(max(1, dst.Rect.Dx()))
+	sy := float64(src.Rect.Dy()) / float64(max(1, dst.Rect.Dy()))
+	workers, band := (*ParallelOptions)(nil).defaults(dst.Rect, 4)
+	return forBandsContext(ctx, dst.Rect, workers, band, progress, func(b image.Rectangle) {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			fy := float64(src.Rect.Min.Y) + (float64(y-dst.Rect.Min.Y)+0.5)*sy
+			for x := b.Min.X; x < b.Max.X; x++ {
+				fx := float64(src.Rect.Min.X) + (float64(x-dst.Rect.Min.X)+0.5)*sx
+				v := src.SampleF(fx, fy, interp, BorderClamp)
+				putF32(dst.Pix[dst.PixOffset(x, y):], float32(v))
+			}
+		}
+	})
+}
+
+func cpuLUT(ctx context.Context, dst, src *GrayS16Image, table *[65536]int16, progress ProgressFunc) error {
+	return MapContext(ctx, src, dst, func(v int16) int16 {
+		return table[uint16(v)^0x8000]
+	}, &ParallelOptions{Progress: progress})
+}
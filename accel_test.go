@@ -0,0 +1,254 @@
+package colorext
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+	"slices"
+	"testing"
+)
+
+// rampF32 returns an image whose pixel (x, y) holds x + 100*y.
+func rampF32(r image.Rectangle) *GrayF32Image {
+	img := NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGrayF32(x, y, GrayF32{Y: float32(x + 100*y)})
+		}
+	}
+	return img
+}
+
+func TestConvolve(t *testing.T) {
+	src := rampF32(image.Rect(-2, 3, 8, 11))
+	tests := []struct {
+		name   string
+		k      Kernel
+		border Border
+		x, y   int
+		want   float64
+	}{
+		{"identity", Kernel{1, 1, []float32{1}}, BorderClamp, 4, 6, 604},
+		{"shift right", Kernel{3, 1, []float32{0, 0, 1}}, BorderClamp, 4, 6, 605},
+		{"box interior", Kernel{3, 3, []float32{1, 1, 1, 1, 1, 1, 1, 1, 1}}, BorderClamp, 0, 5, 9 * 500},
+		{"clamped corner", Kernel{3, 1, []float32{1, 0, 0}}, BorderClamp, -2, 3, 298},
+		{"reflected corner", Kernel{1, 3, []float32{1, 0, 0}}, BorderReflect, -2, 3, 298},
+		{"wrapped corner", Kernel{3, 1, []float32{1, 0, 0}}, BorderWrap, -2, 3, 307},
+		{"NaN border", Kernel{3, 1, []float32{1, 0, 0}}, BorderNaN, -2, 3, math.NaN()},
+		{"zero weight ignores border", Kernel{3, 1, []float32{0, 1, 0}}, BorderNaN, -2, 3, 298},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewGrayF32Image(src.Rect)
+			if err := Convolve(dst, src, tt.k, tt.border); err != nil {
+				t.Fatal(err)
+			}
+			got := float64(dst.GrayF32At(tt.x, tt.y).Y)
+			if got != tt.want && !(math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvolveContext(t *testing.T) {
+	src := rampF32(image.Rect(0, 0, 16, 300))
+	k := Kernel{3, 3, []float32{0, 0, 0, 0, 1, 0, 0, 0, 0}}
+
+	dst := NewGrayF32Image(src.Rect)
+	var last int
+	err := ConvolveContext(context.Background(), dst, src, k, BorderClamp, func(done, total int) {
+		if done <= last || total != 300 {
+			t.Errorf("progress(%d, %d) after %d", done, total, last)
+		}
+		last = done
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 300 || !Equal(dst, src) {
+		t.Errorf("progress reached %d of 300 rows or the result is wrong", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst = NewGrayF32Image(src.Rect)
+	if err := ConvolveContext(ctx, dst, src, k, BorderClamp, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if dst.GrayF32At(5, 5).Y != 0 {
+		t.Error("canceled ConvolveContext wrote to dst")
+	}
+}
+
+func TestConvolveInvalidKernel(t *testing.T) {
+	src := rampF32(image.Rect(0, 0, 4, 4))
+	for _, k := range []Kernel{
+		{2, 1, []float32{1, 1}},
+		{0, 0, nil},
+		{3, 1, []float32{1, 1}},
+	} {
+		if err := Convolve(NewGrayF32Image(src.Rect), src, k, BorderClamp); err == nil {
+			t.Errorf("Convolve with %d×%d kernel of %d weights succeeded", k.W, k.H, len(k.Weights))
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := rampF32(image.Rect(10, 10, 14, 14))
+	tests := []struct {
+		name   string
+		dst    image.Rectangle
+		interp Interpolation
+		x, y   int
+		want   float32
+	}{
+		{"same size", image.Rect(0, 0, 4, 4), Bilinear, 2, 1, 1112},
+		{"double nearest", image.Rect(0, 0, 8, 8), Nearest, 3, 5, 1211},
+		{"double bilinear", image.Rect(0, 0, 8, 8), Bilinear, 3, 3, 1136.25},
+		{"half", image.Rect(0, 0, 2, 2), Bilinear, 1, 0, 1012.5 + 50},
+		{"clamped edge", image.Rect(0, 0, 8, 8), Bilinear, 0, 0, 1010},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewGrayF32Image(tt.dst)
+			if err := Resize(dst, src, tt.interp); err != nil {
+				t.Fatal(err)
+			}
+			if got := dst.GrayF32At(tt.x, tt.y).Y; got != tt.want {
+				t.Errorf("(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLUT(t *testing.T) {
+	var table [65536]int16
+	for i := range table {
+		table[i] = int16(i+math.MinInt16) / 2
+	}
+	src := randomGrayS16(image.Rect(0, 0, 17, 9), 4)
+	dst := NewGrayS16Image(src.Rect)
+	if err := ApplyLUT(dst, src, &table); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 17; x++ {
+			if got, want := dst.GrayS16At(x, y).Y, src.GrayS16At(x, y).Y/2; got != want {
+				t.Fatalf("(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 6, 5), 7)
+	s16 := NewGrayS16Image(image.Rect(2, 2, 10, 10))
+	if err := Convert(s16, src); err != nil {
+		t.Fatal(err)
+	}
+	f32 := NewGrayF32Image(image.Rect(2, 2, 10, 10))
+	if err := Convert(f32, src); err != nil {
+		t.Fatal(err)
+	}
+	for y := 2; y < 10; y++ {
+		for x := 2; x < 10; x++ {
+			if !(image.Point{X: x, Y: y}).In(src.Rect) {
+				if s16.GrayS16At(x, y).Y != 0 || f32.GrayF32At(x, y).Y != 0 {
+					t.Errorf("(%d, %d) outside the source was written", x, y)
+				}
+				continue
+			}
+			if got, want := s16.GrayS16At(x, y), src.GrayS16At(x, y); got != want {
+				t.Errorf("GrayS16 (%d, %d) = %v, want %v", x, y, got, want)
+			}
+			if got, want := f32.At(x, y), GrayF32Model.Convert(src.At(x, y)); got != want {
+				t.Errorf("GrayF32 (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+// countingAccelerator counts its calls, handles LUT itself and defers
+// everything else to the CPU.
+type countingAccelerator struct {
+	calls *int
+}
+
+func (a countingAccelerator) Convert(dst draw.Image, src image.Image) error {
+	*a.calls++
+	return ErrNotAccelerated
+}
+
+func (a countingAccelerator) Convolve(dst, src *GrayF32Image, k Kernel, border Border) error {
+	*a.calls++
+	return ErrNotAccelerated
+}
+
+func (a countingAccelerator) Resize(dst, src *GrayF32Image, interp Interpolation) error {
+	*a.calls++
+	return ErrNotAccelerated
+}
+
+func (a countingAccelerator) LUT(dst, src *GrayS16Image, table *[65536]int16) error {
+	*a.calls++
+	fillRows16(dst, dst.Rect, GrayS16{Y: 42})
+	return nil
+}
+
+func TestRegisterAccelerator(t *testing.T) {
+	defer func() {
+		acceleratorsMu.Lock()
+		delete(accelerators, "counting")
+		accelerator = CPU
+		acceleratorsMu.Unlock()
+	}()
+
+	var calls int
+	RegisterAccelerator("counting", countingAccelerator{&calls})
+	if got, want := Accelerators(), []string{"counting", "cpu"}; !slices.Equal(got, want) {
+		t.Errorf("Accelerators() = %q, want %q", got, want)
+	}
+
+	src := rampF32(image.Rect(0, 0, 4, 4))
+	dst := NewGrayF32Image(src.Rect)
+	if err := Resize(dst, src, Nearest); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || !Equal(dst, src) {
+		t.Errorf("Resize made %d accelerator calls and fell back incorrectly", calls)
+	}
+
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 3))
+	if err := ApplyLUT(img, img, new([65536]int16)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || img.GrayS16At(1, 1).Y != 42 {
+		t.Errorf("ApplyLUT was not run by the registered accelerator")
+	}
+
+	var reports [][2]int
+	if err := ApplyLUTContext(context.Background(), img, img, new([65536]int16), func(done, total int) {
+		reports = append(reports, [2]int{done, total})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(reports) != 1 || reports[0] != [2]int{3, 3} {
+		t.Errorf("ApplyLUTContext on the accelerator reported %v", reports)
+	}
+
+	if err := UseAccelerator("cpu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyLUT(img, img, new([65536]int16)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || img.GrayS16At(1, 1).Y != 0 {
+		t.Errorf("ApplyLUT did not run on the CPU after UseAccelerator(%q)", "cpu")
+	}
+	if err := UseAccelerator("missing"); err == nil {
+		t.Error("UseAccelerator of an unregistered name succeeded")
+	}
+}
@@ -0,0 +1,180 @@
+package colorext
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+)
+
+// Projection selects how an Accumulator combines its frames.
+type Projection int
+
+const (
+	// ProjectionMean averages the frames, reducing noise by the square
+	// root of their number.
+	ProjectionMean Projection = iota
+	// ProjectionMedian takes the per-pixel median, which also rejects
+	// transients such as cosmic ray hits or passing objects. It requires
+	// an Accumulator that keeps its frames.
+	ProjectionMedian
+	// ProjectionMax takes the per-pixel maximum, as used for star trails
+	// and fluorescence stacks.
+	ProjectionMax
+	// ProjectionMin takes the per-pixel minimum.
+	ProjectionMin
+)
+
+// Accumulator stacks GrayS16 frames of a fixed size, such as consecutive
+// frames of a video stream, and produces projections of the stack. Sums are
+// kept in 64-bit integers, so the mean is exact for any practical number of
+// frames. The zero value is not usable; create one with NewAccumulator.
+type Accumulator struct {
+	rect     image.Rectangle
+	n        int
+	sum      []int64
+	min, max []int16
+	// frames holds a copy of every frame if keep is set.
+	frames [][]int16
+	keep   bool
+}
+
+// NewAccumulator returns an empty Accumulator for frames covering r. If
+// keepFrames is true it stores a copy of every frame, which costs two
+// bytes per pixel per frame but enables ProjectionMedian.
+func NewAccumulator(r image.Rectangle, keepFrames bool) *Accumulator {
+	n := r.Dx() * r.Dy()
+	a := &Accumulator{
+		rect: r,
+		sum:  make([]int64, n),
+		min:  make([]int16, n),
+		max:  make([]int16, n),
+		keep: keepFrames,
+	}
+	a.Reset()
+	return a
+}
+
+// Bounds returns the region the accumulator covers.
+func (a *Accumulator) Bounds() image.Rectangle {
+	return a.rect
+}
+
+// Count returns the number of frames added since the last Reset.
+func (a *Accumulator) Count() int {
+	return a.n
+}
+
+// Reset discards all frames.
+func (a *Accumulator) Reset() {
+	a.n = 0
+	clear(a.sum)
+	for i := range a.min {
+		a.min[i], a.max[i] = math.MaxInt16, math.MinInt16
+	}
+	a.frames = nil
+}
+
+// Add adds the pixels of frame within the accumulator's bounds to the
+// stack. frame must cover the bounds.
+func (a *Accumulator) Add(frame *GrayS16Image) error {
+	if !a.rect.In(frame.Rect) {
+		return fmt.Errorf("%w: frame bounds %v do not cover accumulator bounds %v", ErrBoundsMismatch, frame.Rect, a.rect)
+	}
+	var kept []int16
+	if a.keep {
+		kept = make([]int16, len(a.sum))
+	}
+	w := a.rect.Dx()
+	for y := 0; y < a.rect.Dy(); y++ {
+		off := frame.PixOffset(a.rect.Min.X, a.rect.Min.Y+y)
+		row := frame.Pix[off : off+2*w]
+		for x := 0; x < w; x++ {
+			v := int16(uint16(row[2*x])<<8 | uint16(row[2*x+1]))
+			i := y*w + x
+			a.sum[i] += int64(v)
+			a.min[i] = min(a.min[i], v)
+			a.max[i] = max(a.max[i], v)
+			if kept != nil {
+				kept[i] = v
+			}
+		}
+	}
+	if kept != nil {
+		a.frames = append(a.frames, kept)
+	}
+	a.n++
+	return nil
+}
+
+// errNoFrames is returned when projecting an empty Accumulator.
+var errNoFrames = errors.New("colorext: accumulator has no frames")
+
+// Project returns the projection p of the stack as float values, so the
+// mean keeps its fractional part. The median of an even number of frames
+// is the mean of the middle two.
+func (a *Accumulator) Project(p Projection) (*GrayF32Image, error) {
+	vals, err := a.project(p)
+	if err != nil {
+		return nil, err
+	}
+	dst := NewGrayF32Image(a.rect)
+	w := a.rect.Dx()
+	for i, v := range vals {
+		dst.SetGrayF32(a.rect.Min.X+i%w, a.rect.Min.Y+i/w, GrayF32{Y: float32(v)})
+	}
+	return dst, nil
+}
+
+// ProjectS16 is like Project, rounding the mean and median to the nearest
+// integer.
+func (a *Accumulator) ProjectS16(p Projection) (*GrayS16Image, error) {
+	vals, err := a.project(p)
+	if err != nil {
+		return nil, err
+	}
+	dst := NewGrayS16Image(a.rect)
+	w := a.rect.Dx()
+	for i, v := range vals {
+		dst.SetGrayS16(a.rect.Min.X+i%w, a.rect.Min.Y+i/w, GrayS16{Y: int16(math.Round(v))})
+	}
+	return dst, nil
+}
+
+// project returns the projection p of each pixel in row-major order.
+func (a *Accumulator) project(p Projection) ([]float64, error) {
+	if a.n == 0 {
+		return nil, errNoFrames
+	}
+	out := make([]float64, len(a.sum))
+	switch p {
+	case ProjectionMean:
+		for i, s := range a.sum {
+			out[i] = float64(s) / float64(a.n)
+		}
+	case ProjectionMedian:
+		if !a.keep {
+			return nil, errors.New("colorext: median projection requires an accumulator that keeps frames")
+		}
+		col := make([]float64, a.n)
+		for i := range out {
+			for j, f := range a.frames {
+				col[j] = float64(f[i])
+			}
+			slices.Sort(col)
+			out[i] = percentile(col, 50)
+		}
+	case ProjectionMax:
+		for i, v := range a.max {
+			out[i] = float64(v)
+		}
+	case ProjectionMin:
+		for i, v := range a.min {
+			out[i] = float64(v)
+		}
+	default:
+		return nil, fmt.Errorf("colorext: unknown projection %d", p)
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAccumulator(t *testing.T) {
+	r := image.Rect(1, 1, 3, 2)
+	acc := NewAccumulator(r, true)
+	if _, err := acc.Project(ProjectionMean); err == nil {
+		t.Error("empty accumulator: expected an error")
+	}
+
+	// Frames are larger than the accumulator; only r is stacked.
+	for _, vals := range [][2]int16{{10, -5}, {20, -5}, {90, -6}, {-4, 32767}} {
+		f := NewGrayS16Image(image.Rect(0, 0, 4, 3))
+		f.SetGrayS16(1, 1, GrayS16{Y: vals[0]})
+		f.SetGrayS16(2, 1, GrayS16{Y: vals[1]})
+		if err := acc.Add(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acc.Count() != 4 {
+		t.Errorf("Count = %d, want 4", acc.Count())
+	}
+
+	tests := []struct {
+		p       Projection
+		want    [2]float32
+		wantS16 [2]int16
+	}{
+		{ProjectionMean, [2]float32{29, 8187.75}, [2]int16{29, 8188}},
+		{ProjectionMedian, [2]float32{15, -5}, [2]int16{15, -5}},
+		{ProjectionMax, [2]float32{90, 32767}, [2]int16{90, 32767}},
+		{ProjectionMin, [2]float32{-4, -6}, [2]int16{-4, -6}},
+	}
+	for _, tt := range tests {
+		f, err := acc.Project(tt.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := acc.ProjectS16(tt.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Rect != r || s.Rect != r {
+			t.Fatalf("projection %d bounds = %v, %v, want %v", tt.p, f.Rect, s.Rect, r)
+		}
+		for i := range 2 {
+			if got := f.GrayF32At(1+i, 1).Y; got != tt.want[i] {
+				t.Errorf("projection %d pixel %d = %v, want %v", tt.p, i, got, tt.want[i])
+			}
+			if got := s.GrayS16At(1+i, 1).Y; got != tt.wantS16[i] {
+				t.Errorf("projection %d S16 pixel %d = %d, want %d", tt.p, i, got, tt.wantS16[i])
+			}
+		}
+	}
+
+	if err := acc.Add(NewGrayS16Image(image.Rect(0, 0, 2, 2))); err == nil {
+		t.Error("small frame: expected an error")
+	}
+
+	acc.Reset()
+	if acc.Count() != 0 {
+		t.Errorf("Count after Reset = %d", acc.Count())
+	}
+}
+
+func TestAccumulatorMedianRequiresFrames(t *testing.T) {
+	acc := NewAccumulator(image.Rect(0, 0, 1, 1), false)
+	if err := acc.Add(NewGrayS16Image(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acc.Project(ProjectionMedian); err == nil {
+		t.Error("median without kept frames: expected an error")
+	}
+	if _, err := acc.Project(ProjectionMean); err != nil {
+		t.Errorf("mean: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// mat3 is a 3×3 matrix acting on column vectors.
+type mat3 [3][3]float64
+
+// apply returns m × (x, y, z).
+func (m mat3) apply(x, y, z float64) (float64, float64, float64) {
+	return m[0][0]*x + m[0][1]*y + m[0][2]*z,
+		m[1][0]*x + m[1][1]*y + m[1][2]*z,
+		m[2][0]*x + m[2][1]*y + m[2][2]*z
+}
+
+// mul returns the matrix product m × n.
+func (m mat3) mul(n mat3) mat3 {
+	var p mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			p[i][j] = m[i][0]*n[0][j] + m[i][1]*n[1][j] + m[i][2]*n[2][j]
+		}
+	}
+	return p
+}
+
+// inverse returns the inverse of m. m must be non-singular.
+func (m mat3) inverse() mat3 {
+	c00 := m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	c01 := m[1][2]*m[2][0] - m[1][0]*m[2][2]
+	c02 := m[1][0]*m[2][1] - m[1][1]*m[2][0]
+	det := m[0][0]*c00 + m[0][1]*c01 + m[0][2]*c02
+	return mat3{
+		{c00 / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{c01 / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{c02 / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}
+}
+
+// bradfordCone maps XYZ to the sharpened cone responses of the Bradford
+// transform.
+var bradfordCone = mat3{
+	{0.8951, 0.2664, -0.1614},
+	{-0.7502, 1.7135, 0.0367},
+	{0.0389, -0.0685, 1.0296},
+}
+
+// srgbToXYZMatrix and xyzToSRGBMatrix are the matrices of linearSRGBToXYZ
+// and xyzToLinearSRGB.
+var (
+	srgbToXYZMatrix = mat3{
+		{0.4124564, 0.3575761, 0.1804375},
+		{0.2126729, 0.7151522, 0.0721750},
+		{0.0193339, 0.1191920, 0.9503041},
+	}
+	xyzToSRGBMatrix = mat3{
+		{3.2404542, -1.5371385, -0.4985314},
+		{-0.9692660, 1.8760108, 0.0415560},
+		{0.0556434, -0.2040259, 1.0572252},
+	}
+)
+
+// bradford returns the XYZ to XYZ matrix adapting colors seen under from to
+// their corresponding colors under to.
+func bradford(from, to WhitePoint) mat3 {
+	fr, fg, fb := bradfordCone.apply(from.X, from.Y, from.Z)
+	tr, tg, tb := bradfordCone.apply(to.X, to.Y, to.Z)
+	scale := mat3{{tr / fr, 0, 0}, {0, tg / fg, 0}, {0, 0, tb / fb}}
+	return bradfordCone.inverse().mul(scale).mul(bradfordCone)
+}
+
+// Adapt returns the color under the white point to that corresponds to c
+// seen under the white point from, using the Bradford chromatic adaptation
+// transform. The XYZ coordinates of c are those given by XYZModel.
+//
+// Adapting from a scene illuminant to D65 white balances a color for sRGB
+// display; adapting between D65 and D50 moves between sRGB and the ICC
+// profile connection space.
+func Adapt(c color.Color, from, to WhitePoint) XYZ {
+	xyz := toXYZ(c)
+	x, y, z := bradford(from, to).apply(xyz.X, xyz.Y, xyz.Z)
+	return XYZ{x, y, z}
+}
+
+// AdaptImage returns a copy of src with every pixel adapted from the white
+// point from to the white point to, as with Adapt. Pixels are treated as
+// sRGB; adapted colors are clipped to the sRGB gamut and alpha is preserved.
+func AdaptImage(src image.Image, from, to WhitePoint) *image.NRGBA64 {
+	m := xyzToSRGBMatrix.mul(bradford(from, to)).mul(srgbToXYZMatrix)
+	r := src.Bounds()
+	dst := image.NewNRGBA64(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(src.At(x, y)).(color.NRGBA64)
+			lr, lg, lb := m.apply(
+				srgbToLinear(float64(c.R)/0xffff),
+				srgbToLinear(float64(c.G)/0xffff),
+				srgbToLinear(float64(c.B)/0xffff),
+			)
+			dst.SetNRGBA64(x, y, color.NRGBA64{
+				R: uint16(unit16(linearToSRGB(clamp01(lr)))),
+				G: uint16(unit16(linearToSRGB(clamp01(lg)))),
+				B: uint16(unit16(linearToSRGB(clamp01(lb)))),
+				A: c.A,
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,83 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBradford_D65ToD50(t *testing.T) {
+	// Reference matrix from Lindbloom's chromatic adaptation tables.
+	want := mat3{
+		{1.0478112, 0.0228866, -0.0501270},
+		{0.0295424, 0.9904844, -0.0170491},
+		{-0.0092345, 0.0150436, 0.7521316},
+	}
+	got := bradford(D65, D50)
+	for i := range want {
+		for j := range want[i] {
+			if !approxEqual(got[i][j], want[i][j], 1e-4) {
+				t.Errorf("bradford(D65, D50)[%d][%d] = %v, want %v", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestMat3_Inverse(t *testing.T) {
+	p := bradfordCone.mul(bradfordCone.inverse())
+	for i := range p {
+		for j := range p[i] {
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if !approxEqual(p[i][j], want, 1e-12) {
+				t.Errorf("M × M⁻¹ [%d][%d] = %v, want %v", i, j, p[i][j], want)
+			}
+		}
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	tests := []struct {
+		name     string
+		c        color.Color
+		from, to WhitePoint
+		want     XYZ
+	}{
+		{"white point maps to white point", IlluminantA.XYZ(), IlluminantA, D65, D65.XYZ()},
+		{"D50 to D65", D50.XYZ(), D50, D65, D65.XYZ()},
+		{"identity", XYZ{0.3, 0.2, 0.1}, F11, F11, XYZ{0.3, 0.2, 0.1}},
+		{"black", color.Black, D65, IlluminantA, XYZ{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Adapt(tt.c, tt.from, tt.to)
+			if !approxEqual(got.X, tt.want.X, 1e-6) || !approxEqual(got.Y, tt.want.Y, 1e-6) || !approxEqual(got.Z, tt.want.Z, 1e-6) {
+				t.Errorf("Adapt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0x80, 0x80, 0x80, 0xff})
+	src.SetNRGBA(1, 0, color.NRGBA{0xff, 0xff, 0xff, 0x40})
+
+	same := AdaptImage(src, D65, D65)
+	if got := same.NRGBA64At(0, 0); got != (color.NRGBA64{0x8080, 0x8080, 0x8080, 0xffff}) {
+		t.Errorf("identity adaptation = %v, want unchanged", got)
+	}
+
+	// A gray that is neutral under D65 is bluish relative to tungsten light.
+	dst := AdaptImage(src, IlluminantA, D65)
+	gray := dst.NRGBA64At(0, 0)
+	if !(gray.B > gray.R) {
+		t.Errorf("adapted gray = %v, want B > R", gray)
+	}
+	if a := dst.NRGBA64At(1, 0).A; a != 0x4040 {
+		t.Errorf("adapted alpha = %#x, want 0x4040", a)
+	}
+}
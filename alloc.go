@@ -0,0 +1,43 @@
+package colorext
+
+import "fmt"
+
+// Allocator provides the memory for image pixel buffers, such as an arena,
+// a memory-mapped file or pinned memory shared with C code. It is passed
+// to a New*Image function with WithAllocator, so that each call, such as
+// each request of a server, can use its own; buffers of images made
+// internally by the package always come from the Go heap.
+//
+// Alloc must return a slice of length n. Constructors do not clear it:
+// its contents become the initial pixels of the image, unless WithFill is
+// given. An allocator reusing memory must therefore zero it for a blank
+// image, while one mapping an existing raster hands over its samples.
+type Allocator interface {
+	Alloc(n int) []byte
+}
+
+// AllocatorFunc adapts an ordinary function to the Allocator interface.
+type AllocatorFunc func(n int) []byte
+
+// Alloc returns f(n).
+func (f AllocatorFunc) Alloc(n int) []byte {
+	return f(n)
+}
+
+// HeapAllocator allocates pixel buffers on the Go heap. It is the default.
+var HeapAllocator Allocator = heapAllocator{}
+
+type heapAllocator struct{}
+
+func (heapAllocator) Alloc(n int) []byte {
+	return make([]byte, n)
+}
+
+// allocFrom returns a pixel buffer of n bytes from a.
+func allocFrom(a Allocator, n int) []uint8 {
+	buf := a.Alloc(n)
+	if len(buf) != n {
+		panic(fmt.Sprintf("colorext: Allocator returned %d bytes, want %d", len(buf), n))
+	}
+	return buf
+}
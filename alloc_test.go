@@ -0,0 +1,79 @@
+package colorext
+
+import (
+	"image"
+	"sync"
+	"testing"
+)
+
+// countingAllocator hands out slices of one backing array, like an arena.
+type countingAllocator struct {
+	mu    sync.Mutex
+	arena []byte
+	calls int
+}
+
+func (a *countingAllocator) Alloc(n int) []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	buf := a.arena[:n:n]
+	a.arena = a.arena[n:]
+	return buf
+}
+
+func TestAllocator(t *testing.T) {
+	arena := &countingAllocator{arena: make([]byte, 1<<16)}
+	r := image.Rect(0, 0, 8, 4)
+	opt := WithAllocator(arena)
+	constructors := []func(){
+		func() { NewGrayS16Image(r, opt) },
+		func() { NewGrayF32Image(r, opt) },
+		func() { NewGrayU32Image(r, opt) },
+		func() { NewGrayC64Image(r, opt) },
+		func() { NewBGRImage(r, opt) },
+		func() { NewRGB565Image(r, opt) },
+		func() { NewLinearRGBAF32Image(r, opt) },
+		func() { NewGray10PackedImage(r, opt) },
+		func() { NewBayerImage(r, RGGB, 16, opt) },
+	}
+	for _, f := range constructors {
+		f()
+	}
+	if arena.calls != len(constructors) {
+		t.Errorf("allocator called %d times for %d constructors", arena.calls, len(constructors))
+	}
+
+	// Planar images take one buffer per plane.
+	before := arena.calls
+	NewNV12Image(r, BT601, false, opt)
+	NewYCbCr48Image(r, image.YCbCrSubsampleRatio422, BT709, opt)
+	if got := arena.calls - before; got != 5 {
+		t.Errorf("allocator called %d times for 5 planes", got)
+	}
+
+	left := len(arena.arena)
+	img := NewGrayS16Image(r, opt)
+	if len(arena.arena) != left-len(img.Pix) {
+		t.Error("image not backed by the arena")
+	}
+
+	// Images made without the option, including the package's own
+	// temporaries, never use the arena.
+	before = arena.calls
+	NewGrayS16Image(r)
+	img.Compact()
+	Decimate(img, 2, FilterBox)
+	if arena.calls != before {
+		t.Errorf("allocator called %d times without WithAllocator", arena.calls-before)
+	}
+}
+
+func TestAllocator_WrongLength(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("short allocation did not panic")
+		}
+	}()
+	NewGrayS16Image(image.Rect(0, 0, 4, 4), WithAllocator(AllocatorFunc(func(n int) []byte { return make([]byte, n/2) })))
+}
@@ -0,0 +1,197 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync/atomic"
+)
+
+// AtomicGrayS64Image is an image of int64 counters that any number of
+// goroutines may update at once, for scatter-accumulating events such as
+// detector hits or heatmap samples without locks. Unlike the other image
+// types its pixels live in a plane of atomic integers rather than a byte
+// slice, so it has no Pix or Stride; use Snapshot to obtain a
+// GrayS64Image for further processing.
+type AtomicGrayS64Image struct {
+	pix  []atomic.Int64
+	rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Snapshots
+	// share it.
+	Meta Metadata
+}
+
+// NewAtomicGrayS64Image returns a new AtomicGrayS64Image with the given
+// bounds and every counter zero, or set by WithFill.
+func NewAtomicGrayS64Image(r image.Rectangle, opts ...ImageOption) *AtomicGrayS64Image {
+	o := newImageOptions(opts)
+	p := &AtomicGrayS64Image{pix: make([]atomic.Int64, r.Dx()*r.Dy()), rect: r, Meta: o.meta}
+	if o.fill != nil {
+		v := GrayS64Model.Convert(o.fill).(GrayS64).Y
+		for i := range p.pix {
+			p.pix[i].Store(v)
+		}
+	}
+	return p
+}
+
+func (p *AtomicGrayS64Image) metadata() Metadata {
+	return p.Meta
+}
+
+func (p *AtomicGrayS64Image) index(x, y int) int {
+	return (y-p.rect.Min.Y)*p.rect.Dx() + (x - p.rect.Min.X)
+}
+
+// AddAtomic atomically adds delta to the counter at (x, y) and returns the
+// new value. Points outside the bounds are ignored and yield 0.
+func (p *AtomicGrayS64Image) AddAtomic(x, y int, delta int64) int64 {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return 0
+	}
+	return p.pix[p.index(x, y)].Add(delta)
+}
+
+// Load atomically reads the counter at (x, y), or 0 outside the bounds.
+func (p *AtomicGrayS64Image) Load(x, y int) int64 {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return 0
+	}
+	return p.pix[p.index(x, y)].Load()
+}
+
+// Store atomically sets the counter at (x, y). Points outside the bounds
+// are ignored.
+func (p *AtomicGrayS64Image) Store(x, y int, v int64) {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return
+	}
+	p.pix[p.index(x, y)].Store(v)
+}
+
+// ColorModel returns GrayS64Model.
+func (p *AtomicGrayS64Image) ColorModel() color.Model {
+	return GrayS64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *AtomicGrayS64Image) Bounds() image.Rectangle {
+	return p.rect
+}
+
+// At returns the current value of the counter at (x, y) as a GrayS64.
+func (p *AtomicGrayS64Image) At(x, y int) color.Color {
+	return GrayS64{Y: p.Load(x, y)}
+}
+
+// Snapshot returns a GrayS64Image of the counters. Each counter is read
+// atomically, but updates racing with Snapshot may or may not be included.
+func (p *AtomicGrayS64Image) Snapshot() *GrayS64Image {
+	dst := NewGrayS64Image(p.rect, WithMeta(p.Meta))
+	for y := p.rect.Min.Y; y < p.rect.Max.Y; y++ {
+		for x := p.rect.Min.X; x < p.rect.Max.X; x++ {
+			dst.SetGrayS64(x, y, GrayS64{Y: p.pix[p.index(x, y)].Load()})
+		}
+	}
+	return dst
+}
+
+// Reset atomically zeroes every counter, one at a time.
+func (p *AtomicGrayS64Image) Reset() {
+	for i := range p.pix {
+		p.pix[i].Store(0)
+	}
+}
+
+// AtomicGrayS32Image is like AtomicGrayS64Image with int32 counters, which
+// halves the memory of large count maps whose totals stay below 2³¹.
+// Additions wrap on overflow.
+type AtomicGrayS32Image struct {
+	pix  []atomic.Int32
+	rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Snapshots
+	// share it.
+	Meta Metadata
+}
+
+// NewAtomicGrayS32Image returns a new AtomicGrayS32Image with the given
+// bounds and every counter zero, or set by WithFill.
+func NewAtomicGrayS32Image(r image.Rectangle, opts ...ImageOption) *AtomicGrayS32Image {
+	o := newImageOptions(opts)
+	p := &AtomicGrayS32Image{pix: make([]atomic.Int32, r.Dx()*r.Dy()), rect: r, Meta: o.meta}
+	if o.fill != nil {
+		v := max(math.MinInt32, min(math.MaxInt32, GrayS64Model.Convert(o.fill).(GrayS64).Y))
+		for i := range p.pix {
+			p.pix[i].Store(int32(v))
+		}
+	}
+	return p
+}
+
+func (p *AtomicGrayS32Image) metadata() Metadata {
+	return p.Meta
+}
+
+func (p *AtomicGrayS32Image) index(x, y int) int {
+	return (y-p.rect.Min.Y)*p.rect.Dx() + (x - p.rect.Min.X)
+}
+
+// AddAtomic atomically adds delta to the counter at (x, y) and returns the
+// new value. Points outside the bounds are ignored and yield 0.
+func (p *AtomicGrayS32Image) AddAtomic(x, y int, delta int32) int32 {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return 0
+	}
+	return p.pix[p.index(x, y)].Add(delta)
+}
+
+// Load atomically reads the counter at (x, y), or 0 outside the bounds.
+func (p *AtomicGrayS32Image) Load(x, y int) int32 {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return 0
+	}
+	return p.pix[p.index(x, y)].Load()
+}
+
+// Store atomically sets the counter at (x, y). Points outside the bounds
+// are ignored.
+func (p *AtomicGrayS32Image) Store(x, y int, v int32) {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return
+	}
+	p.pix[p.index(x, y)].Store(v)
+}
+
+// ColorModel returns GrayS64Model.
+func (p *AtomicGrayS32Image) ColorModel() color.Model {
+	return GrayS64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *AtomicGrayS32Image) Bounds() image.Rectangle {
+	return p.rect
+}
+
+// At returns the current value of the counter at (x, y) as a GrayS64.
+func (p *AtomicGrayS32Image) At(x, y int) color.Color {
+	return GrayS64{Y: int64(p.Load(x, y))}
+}
+
+// Snapshot returns a GrayS64Image of the counters. Each counter is read
+// atomically, but updates racing with Snapshot may or may not be included.
+func (p *AtomicGrayS32Image) Snapshot() *GrayS64Image {
+	dst := NewGrayS64Image(p.rect, WithMeta(p.Meta))
+	for y := p.rect.Min.Y; y < p.rect.Max.Y; y++ {
+		for x := p.rect.Min.X; x < p.rect.Max.X; x++ {
+			dst.SetGrayS64(x, y, GrayS64{Y: int64(p.pix[p.index(x, y)].Load())})
+		}
+	}
+	return dst
+}
+
+// Reset atomically zeroes every counter, one at a time.
+func (p *AtomicGrayS32Image) Reset() {
+	for i := range p.pix {
+		p.pix[i].Store(0)
+	}
+}
@@ -0,0 +1,93 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestAtomicGrayS64ImageScatter(t *testing.T) {
+	r := image.Rect(-5, 3, 27, 19)
+	img := NewAtomicGrayS64Image(r)
+	img32 := NewAtomicGrayS32Image(r)
+	want := NewGrayS64Image(r)
+	const workers, events = 8, 2000
+
+	// Precompute each worker's events so the expected counts are known.
+	pts := make([][]image.Point, workers)
+	for w := range pts {
+		rng := rand.New(rand.NewSource(int64(w)))
+		for range events {
+			p := image.Pt(r.Min.X-2+rng.Intn(r.Dx()+4), r.Min.Y-2+rng.Intn(r.Dy()+4))
+			pts[w] = append(pts[w], p)
+			if p.In(r) {
+				want.SetGrayS64(p.X, p.Y, GrayS64{Y: want.GrayS64At(p.X, p.Y).Y + int64(w+1)})
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, p := range pts[w] {
+				img.AddAtomic(p.X, p.Y, int64(w+1))
+				img32.AddAtomic(p.X, p.Y, int32(w+1))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := img.Snapshot(); !Equal(got, want) {
+		t.Error("int64 counts differ from the sequential count")
+	}
+	if got := img32.Snapshot(); !Equal(got, want) {
+		t.Error("int32 counts differ from the sequential count")
+	}
+	if img.Bounds() != r || img32.Bounds() != r {
+		t.Errorf("bounds %v, %v, want %v", img.Bounds(), img32.Bounds(), r)
+	}
+	if got := img.At(0, 10); got != want.At(0, 10) {
+		t.Errorf("At = %v, want %v", got, want.At(0, 10))
+	}
+}
+
+func TestAtomicGrayImageOps(t *testing.T) {
+	img := NewAtomicGrayS64Image(image.Rect(0, 0, 3, 2))
+	img32 := NewAtomicGrayS32Image(image.Rect(0, 0, 3, 2))
+	tests := []struct {
+		name string
+		x, y int
+		in   bool
+	}{
+		{"inside", 2, 1, true},
+		{"left", -1, 0, false},
+		{"below", 0, 2, false},
+	}
+	for _, tt := range tests {
+		img.Store(tt.x, tt.y, 40)
+		img32.Store(tt.x, tt.y, 40)
+		got, got32 := img.AddAtomic(tt.x, tt.y, 2), img32.AddAtomic(tt.x, tt.y, 2)
+		want := int64(0)
+		if tt.in {
+			want = 42
+		}
+		if got != want || int64(got32) != want || img.Load(tt.x, tt.y) != want || int64(img32.Load(tt.x, tt.y)) != want {
+			t.Errorf("%s: got %d, %d, want %d", tt.name, got, got32, want)
+		}
+	}
+
+	img.Reset()
+	img32.Reset()
+	if img.Load(2, 1) != 0 || img32.Load(2, 1) != 0 {
+		t.Error("Reset left counts")
+	}
+
+	// int32 counters wrap.
+	img32.Store(0, 0, math.MaxInt32)
+	if got := img32.AddAtomic(0, 0, 1); got != math.MinInt32 {
+		t.Errorf("overflow: got %d, want %d", got, math.MinInt32)
+	}
+}
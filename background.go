@@ -0,0 +1,126 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// RunningBackground models a static scene as a per-pixel exponential
+// moving average and variance of its frames, and segments moving objects
+// as the pixels that deviate from it. Frames are read as raw values, as by
+// Contours, so GrayS16 frames from thermal cameras and GrayF32 depth maps
+// are used without conversion. NaN and invalid pixels neither update the
+// model nor count as foreground.
+type RunningBackground struct {
+	// Alpha is the learning rate in (0, 1]: the weight of each new frame.
+	// Smaller values adapt more slowly and tolerate longer stops of
+	// foreground objects.
+	Alpha float64
+	// MinStdDev is the smallest standard deviation assumed for a pixel, in
+	// raw units. It keeps pixels that have not varied yet from being
+	// flagged on the slightest change.
+	MinStdDev float64
+
+	rect     image.Rectangle
+	mean, vr []float64
+	seen     []bool
+}
+
+// NewRunningBackground returns an empty model for frames covering r with
+// learning rate alpha.
+func NewRunningBackground(r image.Rectangle, alpha float64) *RunningBackground {
+	n := r.Dx() * r.Dy()
+	return &RunningBackground{
+		Alpha: alpha,
+		rect:  r,
+		mean:  make([]float64, n),
+		vr:    make([]float64, n),
+		seen:  make([]bool, n),
+	}
+}
+
+// Update blends frame into the model. The first valid sample of each pixel
+// initializes its mean. frame must cover the model's bounds.
+func (b *RunningBackground) Update(frame image.Image) error {
+	if !b.rect.In(frame.Bounds()) {
+		return fmt.Errorf("%w: frame bounds %v do not cover background bounds %v", ErrBoundsMismatch, frame.Bounds(), b.rect)
+	}
+	at := scalarSampler(frame)
+	a := b.Alpha
+	b.each(func(i, x, y int) {
+		v := at(x, y)
+		if math.IsNaN(v) {
+			return
+		}
+		if !b.seen[i] {
+			b.mean[i], b.vr[i], b.seen[i] = v, 0, true
+			return
+		}
+		d := v - b.mean[i]
+		b.mean[i] += a * d
+		b.vr[i] = (1 - a) * (b.vr[i] + a*d*d)
+	})
+	return nil
+}
+
+// Foreground returns a mask that is opaque where frame deviates from the
+// background mean by more than k standard deviations. Pixels the model has
+// not seen yet are not foreground. frame must cover the model's bounds.
+func (b *RunningBackground) Foreground(frame image.Image, k float64) (*image.Alpha, error) {
+	if !b.rect.In(frame.Bounds()) {
+		return nil, fmt.Errorf("%w: frame bounds %v do not cover background bounds %v", ErrBoundsMismatch, frame.Bounds(), b.rect)
+	}
+	at := scalarSampler(frame)
+	mask := image.NewAlpha(b.rect)
+	b.each(func(i, x, y int) {
+		v := at(x, y)
+		if !b.seen[i] || math.IsNaN(v) {
+			return
+		}
+		sd := max(math.Sqrt(b.vr[i]), b.MinStdDev)
+		if math.Abs(v-b.mean[i]) > k*sd {
+			mask.Pix[mask.PixOffset(x, y)] = 0xff
+		}
+	})
+	return mask, nil
+}
+
+// Mean returns the background mean. Pixels the model has not seen are NaN.
+func (b *RunningBackground) Mean() *GrayF32Image {
+	dst := NewGrayF32Image(b.rect)
+	b.each(func(i, x, y int) {
+		v := float32(math.NaN())
+		if b.seen[i] {
+			v = float32(b.mean[i])
+		}
+		dst.SetGrayF32(x, y, GrayF32{Y: v})
+	})
+	return dst
+}
+
+// StdDev returns the per-pixel standard deviation of the background,
+// without the MinStdDev floor. Pixels the model has not seen are NaN.
+func (b *RunningBackground) StdDev() *GrayF32Image {
+	dst := NewGrayF32Image(b.rect)
+	b.each(func(i, x, y int) {
+		v := float32(math.NaN())
+		if b.seen[i] {
+			v = float32(math.Sqrt(b.vr[i]))
+		}
+		dst.SetGrayF32(x, y, GrayF32{Y: v})
+	})
+	return dst
+}
+
+// each calls f with the buffer index and coordinates of every pixel of the
+// model, in row-major order.
+func (b *RunningBackground) each(f func(i, x, y int)) {
+	i := 0
+	for y := b.rect.Min.Y; y < b.rect.Max.Y; y++ {
+		for x := b.rect.Min.X; x < b.rect.Max.X; x++ {
+			f(i, x, y)
+			i++
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestRunningBackground(t *testing.T) {
+	r := image.Rect(0, 0, 8, 8)
+	bg := NewRunningBackground(r, 0.1)
+	bg.MinStdDev = 2
+	for i := 0; i < 50; i++ {
+		frame := NoiseGrayS16(r, &NoiseOptions{Amplitude: 5, Seed: uint64(i)})
+		if err := bg.Update(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mean := bg.Mean().GrayF32At(3, 3).Y
+	if math.Abs(float64(mean)) > 5 {
+		t.Errorf("background mean = %v, want near 0", mean)
+	}
+	if sd := bg.StdDev().GrayF32At(3, 3).Y; sd < 2 || sd > 9 {
+		t.Errorf("background stddev = %v, want about 5", sd)
+	}
+
+	// An object at 200 stands out; background noise does not.
+	frame := NoiseGrayS16(r, &NoiseOptions{Amplitude: 5, Seed: 99})
+	frame.SetGrayS16(4, 5, GrayS16{Y: 200})
+	mask, err := bg.Foreground(frame, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := x == 4 && y == 5
+			if got := mask.AlphaAt(x, y).A != 0; got != want {
+				t.Errorf("foreground at (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	if _, err := bg.Foreground(NewGrayS16Image(image.Rect(0, 0, 4, 4)), 3); err == nil {
+		t.Error("small frame: expected an error")
+	}
+}
+
+func TestRunningBackgroundNaN(t *testing.T) {
+	r := image.Rect(0, 0, 2, 1)
+	bg := NewRunningBackground(r, 0.5)
+	f := NewGrayF32Image(r)
+	f.SetGrayF32(0, 0, GrayF32{Y: 1.5})
+	f.SetGrayF32(1, 0, GrayF32{Y: float32(math.NaN())})
+	if err := bg.Update(f); err != nil {
+		t.Fatal(err)
+	}
+	m := bg.Mean()
+	if got := m.GrayF32At(0, 0).Y; got != 1.5 {
+		t.Errorf("initial mean = %v, want 1.5", got)
+	}
+	if got := m.GrayF32At(1, 0).Y; !math.IsNaN(float64(got)) {
+		t.Errorf("unseen pixel mean = %v, want NaN", got)
+	}
+
+	f.SetGrayF32(0, 0, GrayF32{Y: 3.5})
+	f.SetGrayF32(1, 0, GrayF32{Y: 100})
+	mask, _ := bg.Foreground(f, 1)
+	if mask.AlphaAt(0, 0).A == 0 {
+		t.Error("changed pixel with zero variance should be foreground")
+	}
+	if mask.AlphaAt(1, 0).A != 0 {
+		t.Error("unseen pixel should not be foreground")
+	}
+	bg.Update(f)
+	if got := bg.Mean().GrayF32At(0, 0).Y; got != 2.5 {
+		t.Errorf("updated mean = %v, want 2.5", got)
+	}
+}
@@ -0,0 +1,207 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// CFAPattern identifies the layout of a Bayer color filter array by the
+// colors of its 2×2 tile, read left to right and top to bottom.
+type CFAPattern uint8
+
+const (
+	// RGGB tiles have red at the top left and blue at the bottom right.
+	RGGB CFAPattern = iota
+	// BGGR tiles have blue at the top left and red at the bottom right.
+	BGGR
+	// GRBG tiles have green at the top left and red at the top right.
+	GRBG
+	// GBRG tiles have green at the top left and blue at the top right.
+	GBRG
+)
+
+// Color channels of a CFA site.
+const (
+	cfaRed = iota
+	cfaGreen
+	cfaBlue
+)
+
+// channel returns the color channel of the site at (x, y), with (0, 0) the
+// top left of a tile.
+func (p CFAPattern) channel(x, y int) int {
+	i := (y&1)<<1 | x&1
+	switch p {
+	case BGGR:
+		return [4]int{cfaBlue, cfaGreen, cfaGreen, cfaRed}[i]
+	case GRBG:
+		return [4]int{cfaGreen, cfaRed, cfaBlue, cfaGreen}[i]
+	case GBRG:
+		return [4]int{cfaGreen, cfaBlue, cfaRed, cfaGreen}[i]
+	}
+	return [4]int{cfaRed, cfaGreen, cfaGreen, cfaBlue}[i]
+}
+
+func (p CFAPattern) String() string {
+	switch p {
+	case RGGB:
+		return "RGGB"
+	case BGGR:
+		return "BGGR"
+	case GRBG:
+		return "GRBG"
+	case GBRG:
+		return "GBRG"
+	}
+	return "CFAPattern(invalid)"
+}
+
+// BayerImage is a raw sensor image behind a Bayer color filter array: each
+// pixel holds a single red, green or blue sample. At returns the raw sample
+// as a color.Gray or color.Gray16; use Demosaic to reconstruct color.
+type BayerImage struct {
+	// Pix holds the image's samples, one or two bytes each depending on
+	// Depth. 16-bit samples are in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*Depth/8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Pattern is the filter layout of the tile whose top left pixel has even
+	// x and y coordinates. Because it is anchored to absolute coordinates,
+	// sub-images keep the correct layout.
+	Pattern CFAPattern
+	// Depth is the number of bits per sample, either 8 or 16.
+	Depth int
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the BayerImage's color model.
+func (p *BayerImage) ColorModel() color.Model {
+	if p.Depth == 8 {
+		return color.GrayModel
+	}
+	return color.Gray16Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *BayerImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the raw sample of the pixel at (x, y).
+func (p *BayerImage) At(x, y int) color.Color {
+	if p.Depth == 8 {
+		return color.Gray{Y: uint8(p.SampleAt(x, y))}
+	}
+	return color.Gray16{Y: p.SampleAt(x, y)}
+}
+
+// SampleAt returns the raw sample of the pixel at (x, y).
+func (p *BayerImage) SampleAt(x, y int) uint16 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i := p.PixOffset(x, y)
+	if p.Depth == 8 {
+		return uint16(p.Pix[i])
+	}
+	s := p.Pix[i : i+2 : i+2]
+	return uint16(s[0])<<8 | uint16(s[1])
+}
+
+// ChannelAt returns the color filter over the pixel at (x, y): 0 for red,
+// 1 for green and 2 for blue.
+func (p *BayerImage) ChannelAt(x, y int) int {
+	return p.Pattern.channel(x, y)
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *BayerImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*(p.Depth/8)
+}
+
+// Set sets the sample of the pixel at (x, y) to the gray level of c.
+func (p *BayerImage) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	v := color.Gray16Model.Convert(c).(color.Gray16).Y
+	if p.Depth == 8 {
+		v >>= 8
+	}
+	p.SetSample(x, y, v)
+}
+
+// SetSample sets the raw sample of the pixel at (x, y). For 8-bit images,
+// values above 255 are clamped.
+func (p *BayerImage) SetSample(x, y int, v uint16) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	if p.Depth == 8 {
+		p.Pix[i] = uint8(min(v, 0xff))
+		return
+	}
+	s := p.Pix[i : i+2 : i+2]
+	s[0] = uint8(v >> 8)
+	s[1] = uint8(v)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *BayerImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &BayerImage{Pattern: p.Pattern, Depth: p.Depth, Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &BayerImage{
+		Pix:     p.Pix[i:],
+		Stride:  p.Stride,
+		Rect:    r,
+		Pattern: p.Pattern,
+		Depth:   p.Depth,
+		Meta:    p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// BayerImage is always fully opaque since its gray color models have no transparency.
+func (p *BayerImage) Opaque() bool {
+	return true
+}
+
+// NewBayerImage returns a new BayerImage with the given bounds, pattern and
+// sample depth. depth must be 8 or 16.
+func NewBayerImage(r image.Rectangle, pattern CFAPattern, depth int, opts ...ImageOption) *BayerImage {
+	if depth != 8 && depth != 16 {
+		panic("colorext: BayerImage depth must be 8 or 16")
+	}
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	bpp := depth / 8
+	p := &BayerImage{
+		Pix:     o.pix(bpp*w*h, bpp),
+		Stride:  bpp * w,
+		Rect:    r,
+		Pattern: pattern,
+		Depth:   depth,
+		Meta:    o.meta,
+	}
+	// The color filter repeats every two pixels and rows.
+	o.fillBlocks(p, p.Pix, p.Stride, 2*bpp, 2, 2)
+	return p
+}
+
+func (p *BayerImage) metadata() Metadata {
+	return p.Meta
+}
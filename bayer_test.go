@@ -0,0 +1,146 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCFAPattern_Channel(t *testing.T) {
+	tests := []struct {
+		p    CFAPattern
+		want string
+	}{
+		{RGGB, "RGGB"},
+		{BGGR, "BGGR"},
+		{GRBG, "GRBG"},
+		{GBRG, "GBRG"},
+	}
+	names := "RGB"
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			got := []byte{
+				names[tt.p.channel(0, 0)], names[tt.p.channel(1, 0)],
+				names[tt.p.channel(0, 1)], names[tt.p.channel(1, 1)],
+			}
+			if string(got) != tt.want {
+				t.Errorf("tile = %s, want %s", got, tt.want)
+			}
+			// The pattern repeats every two pixels, including negative coordinates.
+			if tt.p.channel(-2, 3) != tt.p.channel(0, 1) {
+				t.Error("pattern does not repeat at (-2, 3)")
+			}
+		})
+	}
+}
+
+func TestBayerImage(t *testing.T) {
+	img := NewBayerImage(image.Rect(0, 0, 4, 2), GRBG, 16)
+	if img.Stride != 8 || len(img.Pix) != 16 {
+		t.Fatalf("Stride = %d, len(Pix) = %d, want 8, 16", img.Stride, len(img.Pix))
+	}
+	img.SetSample(3, 1, 0x1234)
+	if got := img.SampleAt(3, 1); got != 0x1234 {
+		t.Errorf("SampleAt(3, 1) = %#x, want 0x1234", got)
+	}
+	if got := img.At(3, 1); got != (color.Gray16{Y: 0x1234}) {
+		t.Errorf("At(3, 1) = %v", got)
+	}
+	if got := img.ChannelAt(1, 0); got != cfaRed {
+		t.Errorf("ChannelAt(1, 0) = %d, want red", got)
+	}
+
+	sub := img.SubImage(image.Rect(1, 1, 4, 2)).(*BayerImage)
+	if sub.ChannelAt(1, 1) != img.ChannelAt(1, 1) || sub.SampleAt(3, 1) != 0x1234 {
+		t.Error("SubImage does not share pixels and layout with the original")
+	}
+
+	img8 := NewBayerImage(image.Rect(0, 0, 2, 2), RGGB, 8)
+	img8.Set(1, 1, color.Gray{Y: 0x80})
+	img8.SetSample(0, 0, 0x1ff)
+	if got := img8.At(1, 1); got != (color.Gray{Y: 0x80}) {
+		t.Errorf("8-bit At(1, 1) = %v", got)
+	}
+	if got := img8.SampleAt(0, 0); got != 0xff {
+		t.Errorf("8-bit SampleAt(0, 0) = %#x, want clamped 0xff", got)
+	}
+	if img8.ColorModel() != color.GrayModel {
+		t.Error("8-bit ColorModel is not GrayModel")
+	}
+}
+
+// mosaic samples an RGB field through the given pattern.
+func mosaic(r image.Rectangle, p CFAPattern, depth int, field func(x, y int) [3]uint16) *BayerImage {
+	img := NewBayerImage(r, p, depth)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetSample(x, y, field(x, y)[p.channel(x, y)])
+		}
+	}
+	return img
+}
+
+func TestDemosaic_FlatColor(t *testing.T) {
+	flat := func(x, y int) [3]uint16 { return [3]uint16{1000, 2000, 3000} }
+	want := color.RGBA64{1000, 2000, 3000, 0xffff}
+
+	for _, p := range []CFAPattern{RGGB, BGGR, GRBG, GBRG} {
+		for _, m := range []DemosaicMethod{DemosaicNearest, DemosaicBilinear, DemosaicMalvar} {
+			dst := Demosaic(mosaic(image.Rect(-3, 1, 4, 6), p, 16, flat), m)
+			for y := 1; y < 6; y++ {
+				for x := -3; x < 4; x++ {
+					if got := dst.RGBA64At(x, y); got != want {
+						t.Fatalf("%v method %d: pixel (%d, %d) = %v, want %v", p, m, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDemosaic_Gradient(t *testing.T) {
+	// Linear ramps are reproduced exactly away from the border by both
+	// interpolating methods.
+	ramp := func(x, y int) [3]uint16 {
+		return [3]uint16{uint16(100 * x), uint16(50*x + 20*y), uint16(300 + 10*y)}
+	}
+	src := mosaic(image.Rect(0, 0, 10, 10), BGGR, 16, ramp)
+
+	for _, m := range []DemosaicMethod{DemosaicBilinear, DemosaicMalvar} {
+		dst := Demosaic(src, m)
+		for y := 2; y < 8; y++ {
+			for x := 2; x < 8; x++ {
+				w := ramp(x, y)
+				if got := dst.RGBA64At(x, y); got != (color.RGBA64{w[0], w[1], w[2], 0xffff}) {
+					t.Errorf("method %d: pixel (%d, %d) = %v, want %v", m, x, y, got, w)
+				}
+			}
+		}
+	}
+}
+
+func TestDemosaic_8Bit(t *testing.T) {
+	src := mosaic(image.Rect(0, 0, 2, 2), RGGB, 8, func(x, y int) [3]uint16 { return [3]uint16{0xff, 0x80, 0} })
+	got := Demosaic(src, DemosaicNearest).RGBA64At(1, 1)
+	if want := (color.RGBA64{0xffff, 0x8080, 0, 0xffff}); got != want {
+		t.Errorf("pixel = %v, want %v", got, want)
+	}
+}
+
+func TestDemosaic_MalvarClamps(t *testing.T) {
+	// A single bright site produces negative filter lobes around it.
+	src := NewBayerImage(image.Rect(0, 0, 6, 6), RGGB, 16)
+	src.SetSample(2, 2, 0xffff)
+	dst := Demosaic(src, DemosaicMalvar)
+	if got := dst.RGBA64At(2, 2); got.R != 0xffff {
+		t.Errorf("bright site red = %#x, want 0xffff", got.R)
+	}
+	// Green at the red site two rows below has a negative weight.
+	if got := dst.RGBA64At(2, 4); got.G != 0 {
+		t.Errorf("negative lobe green = %#x, want clamped 0", got.G)
+	}
+}
@@ -0,0 +1,659 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// BGR represents a fully opaque 24-bit color stored in blue, green, red
+// order, as used by OpenCV and many camera SDKs.
+type BGR struct {
+	B, G, R uint8
+}
+
+// RGBA returns the red, green, blue and alpha components of the BGR color.
+// This implements the color.Color interface.
+func (c BGR) RGBA() (r, g, b, a uint32) {
+	r = uint32(c.R) * 0x101
+	g = uint32(c.G) * 0x101
+	b = uint32(c.B) * 0x101
+	return r, g, b, 0xffff
+}
+
+// BGRA represents a 32-bit color with alpha stored in blue, green, red,
+// alpha order. As with color.NRGBA, the color components are not
+// premultiplied by alpha.
+type BGRA struct {
+	B, G, R, A uint8
+}
+
+// RGBA returns the red, green, blue and alpha components of the BGRA color.
+// This implements the color.Color interface.
+func (c BGRA) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}.RGBA()
+}
+
+// BGR48 represents a fully opaque 48-bit color stored in blue, green, red
+// order.
+type BGR48 struct {
+	B, G, R uint16
+}
+
+// RGBA returns the red, green, blue and alpha components of the BGR48
+// color. This implements the color.Color interface.
+func (c BGR48) RGBA() (r, g, b, a uint32) {
+	return uint32(c.R), uint32(c.G), uint32(c.B), 0xffff
+}
+
+// BGRA64 represents a 64-bit color with alpha stored in blue, green, red,
+// alpha order. As with color.NRGBA64, the color components are not
+// premultiplied by alpha.
+type BGRA64 struct {
+	B, G, R, A uint16
+}
+
+// RGBA returns the red, green, blue and alpha components of the BGRA64
+// color. This implements the color.Color interface.
+func (c BGRA64) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA64{R: c.R, G: c.G, B: c.B, A: c.A}.RGBA()
+}
+
+// Models for the BGR color types. The BGR and BGR48 models discard alpha,
+// as color.YCbCrModel does.
+var (
+	BGRModel    color.Model = color.ModelFunc(bgrModel)
+	BGRAModel   color.Model = color.ModelFunc(bgraModel)
+	BGR48Model  color.Model = color.ModelFunc(bgr48Model)
+	BGRA64Model color.Model = color.ModelFunc(bgra64Model)
+)
+
+// bgrModel converts any color.Color to a BGR.
+func bgrModel(c color.Color) color.Color {
+	if _, ok := c.(BGR); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+	return BGR{B: uint8(b >> 8), G: uint8(g >> 8), R: uint8(r >> 8)}
+}
+
+// bgraModel converts any color.Color to a BGRA.
+func bgraModel(c color.Color) color.Color {
+	if _, ok := c.(BGRA); ok {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return BGRA{B: n.B, G: n.G, R: n.R, A: n.A}
+}
+
+// bgr48Model converts any color.Color to a BGR48.
+func bgr48Model(c color.Color) color.Color {
+	if _, ok := c.(BGR48); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+	return BGR48{B: uint16(b), G: uint16(g), R: uint16(r)}
+}
+
+// bgra64Model converts any color.Color to a BGRA64.
+func bgra64Model(c color.Color) color.Color {
+	if _, ok := c.(BGRA64); ok {
+		return c
+	}
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	return BGRA64{B: n.B, G: n.G, R: n.R, A: n.A}
+}
+
+// BGRImage is an in-memory image whose At method returns BGR values.
+// An existing buffer can be wrapped without copying by setting Pix, Stride
+// and Rect directly.
+type BGRImage struct {
+	// Pix holds the image's pixels, in B, G, R order, one byte per sample.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*3].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the BGRImage's color model.
+func (p *BGRImage) ColorModel() color.Model {
+	return BGRModel
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *BGRImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *BGRImage) At(x, y int) color.Color {
+	return p.BGRAt(x, y)
+}
+
+// BGRAt returns the BGR color of the pixel at (x, y).
+func (p *BGRImage) BGRAt(x, y int) BGR {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return BGR{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+3 : i+3]
+	return BGR{B: s[0], G: s[1], R: s[2]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *BGRImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *BGRImage) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetBGR(x, y, BGRModel.Convert(c).(BGR))
+}
+
+// SetBGR sets the pixel at (x, y) to a given BGR color.
+func (p *BGRImage) SetBGR(x, y int, c BGR) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+3 : i+3]
+	s[0] = c.B
+	s[1] = c.G
+	s[2] = c.R
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *BGRImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &BGRImage{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &BGRImage{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// BGRImage is always fully opaque since the BGR color model has no transparency.
+func (p *BGRImage) Opaque() bool {
+	return true
+}
+
+// SwapRB swaps the red and blue samples of every pixel in place, converting
+// the buffer between BGR and RGB channel order.
+func (p *BGRImage) SwapRB() {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x, i = x+1, i+3 {
+			s := p.Pix[i : i+3 : i+3]
+			s[0], s[2] = s[2], s[0]
+		}
+	}
+}
+
+// NewBGRImage returns a new BGRImage with the given bounds.
+func NewBGRImage(r image.Rectangle, opts ...ImageOption) *BGRImage {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &BGRImage{
+		Pix:    o.pix(3*w*h, 1),
+		Stride: 3 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 3)
+	return p
+}
+
+func (p *BGRImage) metadata() Metadata {
+	return p.Meta
+}
+
+// BGRAImage is an in-memory image whose At method returns BGRA values.
+// An existing buffer can be wrapped without copying by setting Pix, Stride
+// and Rect directly.
+type BGRAImage struct {
+	// Pix holds the image's pixels, in B, G, R, A order, one byte per sample.
+	// Color samples are not premultiplied by alpha.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the BGRAImage's color model.
+func (p *BGRAImage) ColorModel() color.Model {
+	return BGRAModel
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *BGRAImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *BGRAImage) At(x, y int) color.Color {
+	return p.BGRAAt(x, y)
+}
+
+// BGRAAt returns the BGRA color of the pixel at (x, y).
+func (p *BGRAImage) BGRAAt(x, y int) BGRA {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return BGRA{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	return BGRA{B: s[0], G: s[1], R: s[2], A: s[3]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *BGRAImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *BGRAImage) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetBGRA(x, y, BGRAModel.Convert(c).(BGRA))
+}
+
+// SetBGRA sets the pixel at (x, y) to a given BGRA color.
+func (p *BGRAImage) SetBGRA(x, y int, c BGRA) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	s[0] = c.B
+	s[1] = c.G
+	s[2] = c.R
+	s[3] = c.A
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *BGRAImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &BGRAImage{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &BGRAImage{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *BGRAImage) Opaque() bool {
+	if p.Rect.Empty() {
+		return true
+	}
+	i0, i1 := 3, p.Rect.Dx()*4
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for i := i0; i < i1; i += 4 {
+			if p.Pix[i] != 0xff {
+				return false
+			}
+		}
+		i0 += p.Stride
+		i1 += p.Stride
+	}
+	return true
+}
+
+// SwapRB swaps the red and blue samples of every pixel in place, converting
+// the buffer between BGR and RGB channel order.
+func (p *BGRAImage) SwapRB() {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x, i = x+1, i+4 {
+			s := p.Pix[i : i+4 : i+4]
+			s[0], s[2] = s[2], s[0]
+		}
+	}
+}
+
+// NewBGRAImage returns a new BGRAImage with the given bounds.
+func NewBGRAImage(r image.Rectangle, opts ...ImageOption) *BGRAImage {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &BGRAImage{
+		Pix:    o.pix(4*w*h, 1),
+		Stride: 4 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 4)
+	return p
+}
+
+func (p *BGRAImage) metadata() Metadata {
+	return p.Meta
+}
+
+// BGR48Image is an in-memory image whose At method returns BGR48 values.
+// An existing buffer can be wrapped without copying by setting Pix, Stride
+// and Rect directly.
+type BGR48Image struct {
+	// Pix holds the image's pixels, in B, G, R order, as 16-bit samples in
+	// little-endian format, the native layout of OpenCV on x86 and ARM.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*6].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the BGR48Image's color model.
+func (p *BGR48Image) ColorModel() color.Model {
+	return BGR48Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *BGR48Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *BGR48Image) At(x, y int) color.Color {
+	return p.BGR48At(x, y)
+}
+
+// BGR48At returns the BGR48 color of the pixel at (x, y).
+func (p *BGR48Image) BGR48At(x, y int) BGR48 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return BGR48{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+6 : i+6]
+	return BGR48{
+		B: uint16(s[0]) | uint16(s[1])<<8,
+		G: uint16(s[2]) | uint16(s[3])<<8,
+		R: uint16(s[4]) | uint16(s[5])<<8,
+	}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *BGR48Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*6
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *BGR48Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetBGR48(x, y, BGR48Model.Convert(c).(BGR48))
+}
+
+// SetBGR48 sets the pixel at (x, y) to a given BGR48 color.
+func (p *BGR48Image) SetBGR48(x, y int, c BGR48) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+6 : i+6]
+	s[0] = uint8(c.B)
+	s[1] = uint8(c.B >> 8)
+	s[2] = uint8(c.G)
+	s[3] = uint8(c.G >> 8)
+	s[4] = uint8(c.R)
+	s[5] = uint8(c.R >> 8)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *BGR48Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &BGR48Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &BGR48Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// BGR48Image is always fully opaque since the BGR48 color model has no transparency.
+func (p *BGR48Image) Opaque() bool {
+	return true
+}
+
+// SwapRB swaps the red and blue samples of every pixel in place, converting
+// the buffer between BGR and RGB channel order.
+func (p *BGR48Image) SwapRB() {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x, i = x+1, i+6 {
+			s := p.Pix[i : i+6 : i+6]
+			s[0], s[1], s[4], s[5] = s[4], s[5], s[0], s[1]
+		}
+	}
+}
+
+// NewBGR48Image returns a new BGR48Image with the given bounds.
+func NewBGR48Image(r image.Rectangle, opts ...ImageOption) *BGR48Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &BGR48Image{
+		Pix:    o.pix(6*w*h, 2),
+		Stride: 6 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 6)
+	return p
+}
+
+func (p *BGR48Image) metadata() Metadata {
+	return p.Meta
+}
+
+// BGRA64Image is an in-memory image whose At method returns BGRA64 values.
+// An existing buffer can be wrapped without copying by setting Pix, Stride
+// and Rect directly.
+type BGRA64Image struct {
+	// Pix holds the image's pixels, in B, G, R, A order, as 16-bit samples in
+	// little-endian format, the native layout of OpenCV on x86 and ARM.
+	// Color samples are not premultiplied by alpha.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the BGRA64Image's color model.
+func (p *BGRA64Image) ColorModel() color.Model {
+	return BGRA64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *BGRA64Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *BGRA64Image) At(x, y int) color.Color {
+	return p.BGRA64At(x, y)
+}
+
+// BGRA64At returns the BGRA64 color of the pixel at (x, y).
+func (p *BGRA64Image) BGRA64At(x, y int) BGRA64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return BGRA64{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	return BGRA64{
+		B: uint16(s[0]) | uint16(s[1])<<8,
+		G: uint16(s[2]) | uint16(s[3])<<8,
+		R: uint16(s[4]) | uint16(s[5])<<8,
+		A: uint16(s[6]) | uint16(s[7])<<8,
+	}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *BGRA64Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*8
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *BGRA64Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetBGRA64(x, y, BGRA64Model.Convert(c).(BGRA64))
+}
+
+// SetBGRA64 sets the pixel at (x, y) to a given BGRA64 color.
+func (p *BGRA64Image) SetBGRA64(x, y int, c BGRA64) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	s[0] = uint8(c.B)
+	s[1] = uint8(c.B >> 8)
+	s[2] = uint8(c.G)
+	s[3] = uint8(c.G >> 8)
+	s[4] = uint8(c.R)
+	s[5] = uint8(c.R >> 8)
+	s[6] = uint8(c.A)
+	s[7] = uint8(c.A >> 8)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *BGRA64Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &BGRA64Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &BGRA64Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *BGRA64Image) Opaque() bool {
+	if p.Rect.Empty() {
+		return true
+	}
+	i0, i1 := 6, p.Rect.Dx()*8
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for i := i0; i < i1; i += 8 {
+			if p.Pix[i] != 0xff || p.Pix[i+1] != 0xff {
+				return false
+			}
+		}
+		i0 += p.Stride
+		i1 += p.Stride
+	}
+	return true
+}
+
+// SwapRB swaps the red and blue samples of every pixel in place, converting
+// the buffer between BGR and RGB channel order.
+func (p *BGRA64Image) SwapRB() {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x, i = x+1, i+8 {
+			s := p.Pix[i : i+8 : i+8]
+			s[0], s[1], s[4], s[5] = s[4], s[5], s[0], s[1]
+		}
+	}
+}
+
+// NewBGRA64Image returns a new BGRA64Image with the given bounds.
+func NewBGRA64Image(r image.Rectangle, opts ...ImageOption) *BGRA64Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &BGRA64Image{
+		Pix:    o.pix(8*w*h, 2),
+		Stride: 8 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 8)
+	return p
+}
+
+func (p *BGRA64Image) metadata() Metadata {
+	return p.Meta
+}
+
+// BGRAToNRGBA converts p to RGBA channel order in place and returns an
+// image.NRGBA sharing its pixels. p must not be used afterwards.
+func BGRAToNRGBA(p *BGRAImage) *image.NRGBA {
+	p.SwapRB()
+	return &image.NRGBA{Pix: p.Pix, Stride: p.Stride, Rect: p.Rect}
+}
+
+// BGRA64ToNRGBA64 converts p to RGBA channel order and big-endian samples
+// in place and returns an image.NRGBA64 sharing its pixels. p must not be
+// used afterwards.
+func BGRA64ToNRGBA64(p *BGRA64Image) *image.NRGBA64 {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		i := p.PixOffset(p.Rect.Min.X, y)
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x, i = x+1, i+8 {
+			s := p.Pix[i : i+8 : i+8]
+			s[0], s[1], s[2], s[3], s[4], s[5], s[6], s[7] = s[5], s[4], s[3], s[2], s[1], s[0], s[7], s[6]
+		}
+	}
+	return &image.NRGBA64{Pix: p.Pix, Stride: p.Stride, Rect: p.Rect}
+}
@@ -0,0 +1,133 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBGRColors_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want color.RGBA64
+	}{
+		{"BGR", BGR{B: 0x10, G: 0x20, R: 0x30}, color.RGBA64{0x3030, 0x2020, 0x1010, 0xffff}},
+		{"BGRA", BGRA{B: 0xff, G: 0, R: 0x80, A: 0x80}, color.RGBA64{0x4080, 0, 0x8080, 0x8080}},
+		{"BGR48", BGR48{B: 1, G: 2, R: 3}, color.RGBA64{3, 2, 1, 0xffff}},
+		{"BGRA64", BGRA64{B: 0xffff, G: 0, R: 0, A: 0x8000}, color.RGBA64{0, 0, 0x8000, 0x8000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if got := (color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}); got != tt.want {
+				t.Errorf("RGBA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBGRModels(t *testing.T) {
+	in := color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0x80}
+	if got := BGRAModel.Convert(in); got != (BGRA{B: 0x56, G: 0x34, R: 0x12, A: 0x80}) {
+		t.Errorf("BGRAModel.Convert = %v", got)
+	}
+	if got := BGRModel.Convert(color.RGBA{R: 1, G: 2, B: 3, A: 0xff}); got != (BGR{B: 3, G: 2, R: 1}) {
+		t.Errorf("BGRModel.Convert = %v", got)
+	}
+	if got := BGR48Model.Convert(color.RGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0xffff}); got != (BGR48{B: 0x9abc, G: 0x5678, R: 0x1234}) {
+		t.Errorf("BGR48Model.Convert = %v", got)
+	}
+	if got := BGRA64Model.Convert(color.NRGBA64{R: 1, G: 2, B: 3, A: 4}); got != (BGRA64{B: 3, G: 2, R: 1, A: 4}) {
+		t.Errorf("BGRA64Model.Convert = %v", got)
+	}
+}
+
+func TestBGRImage_WrapsBuffer(t *testing.T) {
+	// A 2×2 OpenCV CV_8UC3 buffer with one byte of row padding.
+	buf := []uint8{
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0,
+		0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0,
+	}
+	img := &BGRImage{Pix: buf, Stride: 7, Rect: image.Rect(0, 0, 2, 2)}
+	if got := img.BGRAt(1, 1); got != (BGR{B: 0x0a, G: 0x0b, R: 0x0c}) {
+		t.Errorf("BGRAt(1, 1) = %v", got)
+	}
+	img.SetBGR(0, 1, BGR{B: 0xaa, G: 0xbb, R: 0xcc})
+	if buf[7] != 0xaa || buf[9] != 0xcc {
+		t.Errorf("SetBGR did not write through to the buffer: %v", buf[7:10])
+	}
+	if !img.Opaque() {
+		t.Error("BGRImage not Opaque")
+	}
+
+	img.SwapRB()
+	if got := img.BGRAt(1, 0); got != (BGR{B: 0x06, G: 0x05, R: 0x04}) {
+		t.Errorf("after SwapRB, BGRAt(1, 0) = %v", got)
+	}
+	if buf[6] != 0 || buf[13] != 0 {
+		t.Error("SwapRB modified row padding")
+	}
+}
+
+func TestBGRAImage(t *testing.T) {
+	img := NewBGRAImage(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 0xff, G: 0x80, B: 0, A: 0xff})
+	img.Set(1, 0, color.NRGBA{R: 0, G: 0, B: 0xff, A: 0xff})
+	if !img.Opaque() {
+		t.Error("opaque BGRAImage reported not Opaque")
+	}
+	img.SetBGRA(1, 0, BGRA{A: 0x7f})
+	if img.Opaque() {
+		t.Error("translucent BGRAImage reported Opaque")
+	}
+	sub := img.SubImage(image.Rect(0, 0, 1, 1)).(*BGRAImage)
+	if !sub.Opaque() {
+		t.Error("opaque SubImage reported not Opaque")
+	}
+
+	n := BGRAToNRGBA(img)
+	if got := n.NRGBAAt(0, 0); got != (color.NRGBA{R: 0xff, G: 0x80, B: 0, A: 0xff}) {
+		t.Errorf("BGRAToNRGBA pixel = %v", got)
+	}
+	if &n.Pix[0] != &img.Pix[0] {
+		t.Error("BGRAToNRGBA copied the buffer")
+	}
+}
+
+func TestBGR48Image(t *testing.T) {
+	img := NewBGR48Image(image.Rect(0, 0, 1, 1))
+	img.SetBGR48(0, 0, BGR48{B: 0x0102, G: 0x0304, R: 0x0506})
+	if want := []uint8{0x02, 0x01, 0x04, 0x03, 0x06, 0x05}; string(img.Pix) != string(want) {
+		t.Errorf("Pix = %x, want little-endian %x", img.Pix, want)
+	}
+	img.SwapRB()
+	if got := img.BGR48At(0, 0); got != (BGR48{B: 0x0506, G: 0x0304, R: 0x0102}) {
+		t.Errorf("after SwapRB, BGR48At = %v", got)
+	}
+	if got := img.BGR48At(1, 0); got != (BGR48{}) {
+		t.Errorf("out of bounds BGR48At = %v, want zero", got)
+	}
+}
+
+func TestBGRA64Image(t *testing.T) {
+	img := NewBGRA64Image(image.Rect(0, 0, 2, 1))
+	want := []color.NRGBA64{{0x1234, 0x5678, 0x9abc, 0xffff}, {0xffff, 0, 0x0001, 0x8000}}
+	for x, c := range want {
+		img.Set(x, 0, c)
+	}
+	if img.Opaque() {
+		t.Error("translucent BGRA64Image reported Opaque")
+	}
+	if got := img.BGRA64At(0, 0); got != (BGRA64{B: 0x9abc, G: 0x5678, R: 0x1234, A: 0xffff}) {
+		t.Errorf("BGRA64At(0, 0) = %v", got)
+	}
+
+	n := BGRA64ToNRGBA64(img)
+	for x, c := range want {
+		if got := n.NRGBA64At(x, 0); got != c {
+			t.Errorf("BGRA64ToNRGBA64 pixel %d = %v, want %v", x, got, c)
+		}
+	}
+}
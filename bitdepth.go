@@ -0,0 +1,183 @@
+package colorext
+
+import (
+	"image"
+	"sync"
+)
+
+// Rounding selects how ToGray8 reduces 16-bit levels to 8 bits.
+type Rounding int
+
+const (
+	// RoundTruncate keeps the high byte, as the stdlib color models do. It
+	// biases levels downward by half a step on average.
+	RoundTruncate Rounding = iota
+	// RoundNearest rounds to the nearest 8-bit level.
+	RoundNearest
+	// RoundStochastic rounds up with probability equal to the fraction
+	// discarded, so averages over an area keep the 16-bit mean and smooth
+	// gradients do not band. The noise depends only on pixel coordinates,
+	// so the output is deterministic.
+	RoundStochastic
+)
+
+// ToGray16 converts src to an *image.Gray16, mapping each signed value to
+// an unsigned level with mapping. With the OffsetBinary mapping this matches
+// GrayS16ToGray16.
+func ToGray16(src *GrayS16Image, mapping MappingPolicy) *image.Gray16 {
+	if mapping.Kind == OffsetBinary {
+		return GrayS16ToGray16(src)
+	}
+	dst := image.NewGray16(src.Rect)
+	w := src.Rect.Dx()
+	for y := 0; y < src.Rect.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+2*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+2*w]
+		for i := 0; i < len(s); i += 2 {
+			u := mapping.Unsigned(int16(uint16(s[i])<<8 | uint16(s[i+1])))
+			d[i], d[i+1] = uint8(u>>8), uint8(u)
+		}
+	}
+	return dst
+}
+
+// ToGray8 converts src to an *image.Gray, mapping each signed value to a
+// 16-bit level with mapping and reducing it to 8 bits with rounding.
+func ToGray8(src *GrayS16Image, mapping MappingPolicy, rounding Rounding) *image.Gray {
+	dst := image.NewGray(src.Rect)
+	w := src.Rect.Dx()
+	for y := 0; y < src.Rect.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+2*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+		for x := range d {
+			u := uint32(mapping.Unsigned(int16(uint16(s[2*x])<<8 | uint16(s[2*x+1]))))
+			switch rounding {
+			case RoundNearest:
+				d[x] = uint8((u*0xff + 0x7fff) / 0xffff)
+			case RoundStochastic:
+				// Scale to 8.16 fixed point and add noise below one step.
+				f := u * 0xff
+				noise := coordHash(src.Rect.Min.X+x, src.Rect.Min.Y+y) % 0xffff
+				d[x] = uint8((f + noise) / 0xffff)
+			default:
+				d[x] = uint8(u >> 8)
+			}
+		}
+	}
+	return dst
+}
+
+// coordHash returns a well-mixed 32-bit hash of a pixel position.
+func coordHash(x, y int) uint32 {
+	h := uint32(x)*0x9e3779b1 ^ uint32(y)*0x85ebca77
+	h ^= h >> 16
+	h *= 0x7feb352d
+	h ^= h >> 15
+	h *= 0x846ca68b
+	h ^= h >> 16
+	return h
+}
+
+// Depth selects the sample format produced by From8Bit and Calibrate.
+type Depth int
+
+const (
+	// Depth16 produces an *image.Gray16.
+	Depth16 Depth = iota
+	// DepthS16 produces a *GrayS16Image. From8Bit uses the OffsetBinary
+	// mapping.
+	DepthS16
+	// DepthF32 produces a *GrayF32Image. From8Bit scales values to [0, 1].
+	DepthF32
+)
+
+// From8Bit converts src to a deeper image type. Integer targets replicate
+// each 8-bit value into both bytes (v<<8 | v) rather than shifting it, so
+// that white maps to white instead of 0xff00 and every level keeps its
+// exact proportion of full scale. DepthF32 divides by 255 for the same
+// reason. The gray levels are not linearized.
+func From8Bit(src *image.Gray, target Depth) image.Image {
+	switch target {
+	case DepthS16:
+		return GrayToGrayS16(src)
+	case DepthF32:
+		dst := NewGrayF32Image(src.Rect)
+		w := src.Rect.Dx()
+		for y := 0; y < src.Rect.Dy(); y++ {
+			s := src.Pix[y*src.Stride : y*src.Stride+w]
+			d := dst.Pix[y*dst.Stride : y*dst.Stride+4*w]
+			for x, v := range s {
+				putF32(d[4*x:4*x+4], float32(v)/0xff)
+			}
+		}
+		return dst
+	}
+	dst := image.NewGray16(src.Rect)
+	w := src.Rect.Dx()
+	for y := 0; y < src.Rect.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+2*w]
+		for x, v := range s {
+			d[2*x], d[2*x+1] = v, v
+		}
+	}
+	return dst
+}
+
+// srgb8LUT maps 8-bit sRGB-encoded values to linear light.
+var srgb8LUT = sync.OnceValue(func() *[256]float32 {
+	var lut [256]float32
+	for i := range lut {
+		lut[i] = float32(srgbToLinear(float64(i) / 0xff))
+	}
+	return &lut
+})
+
+// From8BitRGBA converts an 8-bit sRGB image to linear light, decoding the
+// sRGB transfer function from the exact 8-bit levels. *image.NRGBA and
+// *image.RGBA sources take a fast path; premultiplied RGBA components are
+// unpremultiplied before decoding, so translucent pixels keep their hue.
+// Other sources are converted with SRGBToLinear.
+func From8BitRGBA(src image.Image) *LinearRGBAF32Image {
+	var pix []uint8
+	var stride int
+	premul := false
+	switch m := src.(type) {
+	case *image.NRGBA:
+		pix, stride = m.Pix, m.Stride
+	case *image.RGBA:
+		pix, stride, premul = m.Pix, m.Stride, true
+	default:
+		return SRGBToLinear(src)
+	}
+	r := src.Bounds()
+	dst := NewLinearRGBAF32Image(r)
+	lut := srgb8LUT()
+	w := r.Dx()
+	for y := 0; y < r.Dy(); y++ {
+		s := pix[y*stride : y*stride+4*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+16*w]
+		for x := 0; x < w; x++ {
+			c := s[4*x : 4*x+4 : 4*x+4]
+			if c[3] == 0 {
+				continue
+			}
+			a := float32(c[3]) / 0xff
+			var lr, lg, lb float32
+			if premul && c[3] != 0xff {
+				ua := float64(c[3])
+				lr = float32(srgbToLinear(min(float64(c[0])/ua, 1)))
+				lg = float32(srgbToLinear(min(float64(c[1])/ua, 1)))
+				lb = float32(srgbToLinear(min(float64(c[2])/ua, 1)))
+			} else {
+				lr, lg, lb = lut[c[0]], lut[c[1]], lut[c[2]]
+			}
+			o := d[16*x : 16*x+16 : 16*x+16]
+			putF32(o[0:4], lr*a)
+			putF32(o[4:8], lg*a)
+			putF32(o[8:12], lb*a)
+			putF32(o[12:16], a)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,158 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestToGray16(t *testing.T) {
+	src := randomGrayS16(image.Rect(-2, 1, 9, 6), 4)
+	sub := src.SubImage(image.Rect(0, 2, 7, 5)).(*GrayS16Image)
+	for _, m := range []MappingPolicy{{}, {Kind: TwosComplementClamp}, {Kind: AbsoluteValue}, {Kind: CustomRange, Min: -100, Max: 5000}} {
+		for _, s := range []*GrayS16Image{src, sub} {
+			dst := ToGray16(s, m)
+			if dst.Rect != s.Rect {
+				t.Fatalf("bounds = %v, want %v", dst.Rect, s.Rect)
+			}
+			for y := s.Rect.Min.Y; y < s.Rect.Max.Y; y++ {
+				for x := s.Rect.Min.X; x < s.Rect.Max.X; x++ {
+					if got, want := dst.Gray16At(x, y).Y, m.Unsigned(s.GrayS16At(x, y).Y); got != want {
+						t.Fatalf("%+v (%d, %d) = %d, want %d", m, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestToGray8(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 4, 1))
+	// Levels 0x0000, 0x00c0, 0x80ff and 0xffff with offset binary.
+	for x, v := range []int16{-32768, -32576, 255, 32767} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	tests := []struct {
+		rounding Rounding
+		want     []uint8
+	}{
+		{RoundTruncate, []uint8{0, 0, 0x80, 0xff}},
+		{RoundNearest, []uint8{0, 1, 0x80, 0xff}},
+	}
+	for _, tt := range tests {
+		got := ToGray8(img, MappingPolicy{}, tt.rounding).Pix
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("rounding %d = %v, want %v", tt.rounding, got, tt.want)
+				break
+			}
+		}
+	}
+	if got := ToGray8(img, MappingPolicy{Kind: TwosComplementClamp}, RoundNearest).Pix; got[1] != 0 || got[2] != 2 {
+		t.Errorf("clamped mapping = %v", got)
+	}
+}
+
+func TestToGray8Stochastic(t *testing.T) {
+	// A level a quarter of the way between two 8-bit levels averages to
+	// the 16-bit level instead of rounding away.
+	img := NewGrayS16Image(image.Rect(0, 0, 64, 64))
+	u := uint32(100*257 + 64)
+	for i := 0; i < len(img.Pix); i += 2 {
+		v := uint16(u) ^ 0x8000
+		img.Pix[i], img.Pix[i+1] = uint8(v>>8), uint8(v)
+	}
+	dst := ToGray8(img, MappingPolicy{}, RoundStochastic)
+	sum := 0
+	for _, v := range dst.Pix {
+		if v != 100 && v != 101 {
+			t.Fatalf("stochastic level %d, want 100 or 101", v)
+		}
+		sum += int(v)
+	}
+	mean := float64(sum) / float64(len(dst.Pix))
+	if want := float64(u) * 255 / 65535; mean < want-0.02 || mean > want+0.02 {
+		t.Errorf("mean = %v, want %v", mean, want)
+	}
+	again := ToGray8(img, MappingPolicy{}, RoundStochastic)
+	if !Equal(dst, again) {
+		t.Error("stochastic rounding is not deterministic")
+	}
+	white := NewGrayS16Image(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			white.SetGrayS16(x, y, GrayS16{Y: 32767})
+		}
+	}
+	for _, v := range ToGray8(white, MappingPolicy{}, RoundStochastic).Pix {
+		if v != 0xff {
+			t.Fatalf("white pixel rounded to %d", v)
+		}
+	}
+}
+
+func TestFrom8Bit(t *testing.T) {
+	src := image.NewGray(image.Rect(1, 2, 5, 3))
+	copy(src.Pix, []uint8{0, 1, 128, 255})
+	g16 := From8Bit(src, Depth16).(*image.Gray16)
+	s16 := From8Bit(src, DepthS16).(*GrayS16Image)
+	f32 := From8Bit(src, DepthF32).(*GrayF32Image)
+	want16 := []uint16{0, 0x0101, 0x8080, 0xffff}
+	wantF := []float32{0, 1.0 / 255, 128.0 / 255, 1}
+	for i := range want16 {
+		x := 1 + i
+		if got := g16.Gray16At(x, 2).Y; got != want16[i] {
+			t.Errorf("Depth16 %d = %#x, want %#x", i, got, want16[i])
+		}
+		if got := s16.GrayS16At(x, 2).Y; got != int16(int32(want16[i])-32768) {
+			t.Errorf("DepthS16 %d = %d", i, got)
+		}
+		if got := f32.GrayF32At(x, 2).Y; got != wantF[i] {
+			t.Errorf("DepthF32 %d = %v, want %v", i, got, wantF[i])
+		}
+	}
+}
+
+func TestFrom8BitRGBA(t *testing.T) {
+	n := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	copy(n.Pix, []uint8{255, 128, 0, 255, 10, 200, 60, 128, 9, 9, 9, 0})
+	// The same colors premultiplied.
+	p := image.NewRGBA(n.Rect)
+	for x := 0; x < 3; x++ {
+		p.Set(x, 0, n.At(x, 0))
+	}
+	for _, src := range []image.Image{n, p} {
+		got := From8BitRGBA(src)
+		for x := 0; x < 3; x++ {
+			// Decode the exact 8-bit levels. Going through the 16-bit
+			// premultiplied RGBA method, as SRGBToLinear does, loses
+			// precision for translucent pixels.
+			c := n.Pix[4*x : 4*x+4]
+			a := float64(c[3]) / 255
+			want := LinearRGBAF32{
+				R: float32(srgbToLinear(float64(c[0])/255) * a),
+				G: float32(srgbToLinear(float64(c[1])/255) * a),
+				B: float32(srgbToLinear(float64(c[2])/255) * a),
+				A: float32(a),
+			}
+			tol := float32(1e-6)
+			if _, ok := src.(*image.RGBA); ok && x == 1 {
+				// Premultiplying to 8 bits loses precision.
+				tol = 4e-3
+			}
+			g := got.LinearRGBAF32At(x, 0)
+			if d := max(abs32(g.R-want.R), abs32(g.G-want.G), abs32(g.B-want.B), abs32(g.A-want.A)); d > tol {
+				t.Errorf("%T pixel %d = %+v, want %+v", src, x, g, want)
+			}
+		}
+	}
+	if got := From8BitRGBA(image.NewGray(image.Rect(0, 0, 1, 1))).LinearRGBAF32At(0, 0); got.A != 1 || got.R != 0 {
+		t.Errorf("gray fallback = %+v", got)
+	}
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestBlendRGBAF32(t *testing.T) {
+	dst := RGBAF32{0.2, 0.4, 0.6, 1}
+	src := RGBAF32{0.25, 0, 0.5, 0.5}
+	tests := []struct {
+		mode BlendMode
+		want RGBAF32
+	}{
+		{BlendOver, RGBAF32{0.35, 0.2, 0.8, 1}},
+		{BlendAdd, RGBAF32{0.45, 0.4, 1.1, 1.5}},
+		{BlendScreen, RGBAF32{0.4, 0.4, 0.8, 1}},
+		{BlendMultiply, RGBAF32{0.15, 0.2, 0.6, 1}},
+	}
+	for _, tt := range tests {
+		got := tt.mode.BlendRGBAF32(dst, src)
+		if !closeRGBAF32(got, tt.want) {
+			t.Errorf("mode %d = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+
+	// Blending with a transparent source leaves the destination unchanged.
+	for _, mode := range []BlendMode{BlendOver, BlendAdd, BlendScreen, BlendMultiply} {
+		if got := mode.BlendRGBAF32(dst, RGBAF32{}); !closeRGBAF32(got, dst) {
+			t.Errorf("mode %d with transparent source = %v, want %v", mode, got, dst)
+		}
+	}
+}
+
+func TestBlendGrayS16(t *testing.T) {
+	tests := []struct {
+		mode     BlendMode
+		dst, src int16
+		want     int16
+	}{
+		{BlendOver, 100, -5, -5},
+		{BlendAdd, 30000, 10000, 32767},
+		{BlendAdd, -30000, -10000, -32768},
+		{BlendMultiply, 32767, 1234, 1234},
+		{BlendMultiply, 16384, 16384, 8192},
+		{BlendScreen, 0, 1234, 1234},
+		{BlendScreen, 32767, 1234, 32767},
+	}
+	for _, tt := range tests {
+		got := tt.mode.BlendGrayS16(GrayS16{Y: tt.dst}, GrayS16{Y: tt.src})
+		if got.Y != tt.want {
+			t.Errorf("mode %d (%d, %d) = %d, want %d", tt.mode, tt.dst, tt.src, got.Y, tt.want)
+		}
+	}
+}
+
+func TestComposite(t *testing.T) {
+	dst := NewRGBAF32Image(image.Rect(0, 0, 4, 1))
+	for x := 0; x < 4; x++ {
+		dst.SetRGBAF32(x, 0, RGBAF32{0.5, 0.5, 0.5, 1})
+	}
+	src := NewRGBAF32Image(image.Rect(10, 10, 13, 11))
+	for x := 10; x < 13; x++ {
+		src.SetRGBAF32(x, 10, RGBAF32{2, 0, 0, 1})
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, 2, 1))
+	mask.SetAlpha(0, 0, color.Alpha{A: 0xff})
+	mask.SetAlpha(1, 0, color.Alpha{A: 0})
+
+	// The region is clipped to the two mask pixels starting at x = 1.
+	Composite(dst, image.Rect(1, 0, 4, 1), src, image.Pt(10, 10), mask, image.Point{}, BlendAdd)
+	tests := []struct {
+		x    int
+		want RGBAF32
+	}{
+		{0, RGBAF32{0.5, 0.5, 0.5, 1}},
+		{1, RGBAF32{2.5, 0.5, 0.5, 2}},
+		{2, RGBAF32{0.5, 0.5, 0.5, 1}},
+		{3, RGBAF32{0.5, 0.5, 0.5, 1}},
+	}
+	for _, tt := range tests {
+		if got := dst.RGBAF32At(tt.x, 0); !closeRGBAF32(got, tt.want) {
+			t.Errorf("pixel %d = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+
+	// Half coverage interpolates halfway to the blended color.
+	lin := NewLinearRGBAF32Image(image.Rect(0, 0, 1, 1))
+	lsrc := NewLinearRGBAF32Image(image.Rect(0, 0, 1, 1))
+	lsrc.SetLinearRGBAF32(0, 0, LinearRGBAF32{1, 1, 1, 1})
+	half := image.NewUniform(color.Alpha16{A: 0x8000})
+	CompositeLinear(lin, lin.Rect, lsrc, image.Point{}, half, image.Point{}, BlendOver)
+	if got := lin.LinearRGBAF32At(0, 0); math.Abs(float64(got.R)-0.5) > 1e-4 || math.Abs(float64(got.A)-0.5) > 1e-4 {
+		t.Errorf("half coverage = %v, want 0.5", got)
+	}
+}
+
+func TestCompositeGrayS16(t *testing.T) {
+	dst := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	dst.SetGrayS16(0, 0, GrayS16{Y: -100})
+	dst.SetGrayS16(1, 0, GrayS16{Y: -100})
+	dst.SetGrayS16(2, 0, GrayS16{Y: -100})
+	src := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	for x := 0; x < 3; x++ {
+		src.SetGrayS16(x, 0, GrayS16{Y: 101})
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, 3, 1))
+	mask.Pix = []uint8{0xff, 0x80, 0}
+
+	CompositeGrayS16(dst, dst.Rect, src, image.Point{}, mask, image.Point{}, BlendOver)
+	want := []int16{101, 1, -100}
+	for x, w := range want {
+		if got := dst.GrayS16At(x, 0).Y; got != w {
+			t.Errorf("pixel %d = %d, want %d", x, got, w)
+		}
+	}
+}
+
+func closeRGBAF32(a, b RGBAF32) bool {
+	const eps = 1e-6
+	return math.Abs(float64(a.R-b.R)) < eps && math.Abs(float64(a.G-b.G)) < eps &&
+		math.Abs(float64(a.B-b.B)) < eps && math.Abs(float64(a.A-b.A)) < eps
+}
@@ -0,0 +1,109 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Calibrate applies dark-frame and flat-field correction to raw, computing
+// (raw - dark) / flat for each pixel, the standard correction for
+// scientific sensors. A nil dark or flat skips that step. dark and flat
+// must cover raw's bounds; flat should be normalized to a mean of 1, as
+// NormalizeFlat does, so that the result stays in raw units.
+//
+// The output type is selected by out. Values are stored in raw units,
+// rounded to the nearest integer and saturated for integer outputs:
+// Depth16 clamps to [0, 65535] and DepthS16 to the int16 range, which
+// preserves the negative values left by dark subtraction. Pixels with a
+// non-positive or NaN flat value have no valid correction and hold NaN in
+// a DepthF32 output and 0 otherwise.
+func Calibrate(raw, dark *GrayS16Image, flat *GrayF32Image, out Depth) (image.Image, error) {
+	r := raw.Rect
+	if dark != nil && !r.In(dark.Rect) {
+		return nil, fmt.Errorf("%w: dark frame bounds %v do not cover %v", ErrBoundsMismatch, dark.Rect, r)
+	}
+	if flat != nil && !r.In(flat.Rect) {
+		return nil, fmt.Errorf("%w: flat field bounds %v do not cover %v", ErrBoundsMismatch, flat.Rect, r)
+	}
+
+	var set func(x, y int, v float64)
+	var dst image.Image
+	switch out {
+	case DepthS16:
+		m := NewGrayS16Image(r)
+		set = func(x, y int, v float64) {
+			if math.IsNaN(v) {
+				v = 0
+			}
+			m.SetGrayS16(x, y, GrayS16{Y: int16(math.Round(math.Max(math.MinInt16, math.Min(math.MaxInt16, v))))})
+		}
+		dst = m
+	case DepthF32:
+		m := NewGrayF32Image(r)
+		set = func(x, y int, v float64) { m.SetGrayF32(x, y, GrayF32{Y: float32(v)}) }
+		dst = m
+	default:
+		m := image.NewGray16(r)
+		set = func(x, y int, v float64) {
+			if math.IsNaN(v) {
+				v = 0
+			}
+			i := m.PixOffset(x, y)
+			u := uint16(math.Round(math.Max(0, math.Min(0xffff, v))))
+			m.Pix[i], m.Pix[i+1] = uint8(u>>8), uint8(u)
+		}
+		dst = m
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := float64(raw.GrayS16At(x, y).Y)
+			if dark != nil {
+				v -= float64(dark.GrayS16At(x, y).Y)
+			}
+			if flat != nil {
+				f := float64(flat.GrayF32At(x, y).Y)
+				if !(f > 0) {
+					v = math.NaN()
+				} else {
+					v /= f
+				}
+			}
+			set(x, y, v)
+		}
+	}
+	return dst, nil
+}
+
+// NormalizeFlat returns a copy of flat divided by the mean of its positive
+// values, so that it can be passed to Calibrate. Non-positive and NaN
+// values are copied unchanged. A flat without positive values is returned
+// as an unchanged copy.
+func NormalizeFlat(flat *GrayF32Image) *GrayF32Image {
+	r := flat.Rect
+	sum, n := 0.0, 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if v := float64(flat.GrayF32At(x, y).Y); v > 0 && !math.IsInf(v, 1) {
+				sum += v
+				n++
+			}
+		}
+	}
+	mean := 1.0
+	if n > 0 {
+		mean = sum / float64(n)
+	}
+	dst := NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := flat.GrayF32At(x, y).Y
+			if v > 0 {
+				v = float32(float64(v) / mean)
+			}
+			dst.SetGrayF32(x, y, GrayF32{Y: v})
+		}
+	}
+	return dst
+}
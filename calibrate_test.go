@@ -0,0 +1,80 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestCalibrate(t *testing.T) {
+	r := image.Rect(0, 0, 4, 1)
+	raw := NewGrayS16Image(r)
+	dark := NewGrayS16Image(image.Rect(-1, -1, 5, 2))
+	flat := NewGrayF32Image(r)
+	for x, v := range []struct {
+		raw, dark int16
+		flat      float32
+	}{{1100, 100, 0.5}, {90, 100, 1}, {30000, 0, 0.25}, {500, 0, 0}} {
+		raw.SetGrayS16(x, 0, GrayS16{Y: v.raw})
+		dark.SetGrayS16(x, 0, GrayS16{Y: v.dark})
+		flat.SetGrayF32(x, 0, GrayF32{Y: v.flat})
+	}
+
+	tests := []struct {
+		name string
+		out  Depth
+		want []float64
+	}{
+		{"float", DepthF32, []float64{2000, -10, 120000, math.NaN()}},
+		{"signed", DepthS16, []float64{2000, -10, 32767, 0}},
+		{"unsigned", Depth16, []float64{2000, 0, 65535, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Calibrate(raw, dark, flat, tt.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := scalarSampler(img)
+			for x, want := range tt.want {
+				got := at(x, 0)
+				if got != want && !(math.IsNaN(got) && math.IsNaN(want)) {
+					t.Errorf("pixel %d = %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+
+	// Without dark and flat frames the raw values pass through.
+	img, err := Calibrate(raw, nil, nil, DepthS16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.(*GrayS16Image).GrayS16At(1, 0).Y; got != 90 {
+		t.Errorf("uncorrected pixel = %d, want 90", got)
+	}
+
+	if _, err := Calibrate(raw, NewGrayS16Image(image.Rect(0, 0, 2, 1)), nil, DepthF32); err == nil {
+		t.Error("small dark frame: expected an error")
+	}
+	if _, err := Calibrate(raw, nil, NewGrayF32Image(image.Rect(1, 0, 4, 1)), DepthF32); err == nil {
+		t.Error("small flat field: expected an error")
+	}
+}
+
+func TestNormalizeFlat(t *testing.T) {
+	flat := NewGrayF32Image(image.Rect(0, 0, 4, 1))
+	for x, v := range []float32{1000, 3000, 0, float32(math.NaN())} {
+		flat.SetGrayF32(x, 0, GrayF32{Y: v})
+	}
+	n := NormalizeFlat(flat)
+	want := []float32{0.5, 1.5, 0}
+	for x, w := range want {
+		if got := n.GrayF32At(x, 0).Y; got != w {
+			t.Errorf("pixel %d = %v, want %v", x, got, w)
+		}
+	}
+	if got := n.GrayF32At(3, 0).Y; !math.IsNaN(float64(got)) {
+		t.Errorf("NaN pixel = %v, want NaN", got)
+	}
+}
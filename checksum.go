@@ -0,0 +1,84 @@
+package colorext
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"image"
+)
+
+// Checksum writes the canonical content of img to h and returns h.Sum(nil).
+// h is not reset first, so several images can be combined in one sum.
+//
+// The canonical content is the image's Go type and size, followed by its
+// pixel rows without stride padding. Images with a plain Pix layout
+// contribute their stored bytes, which this package always keeps in
+// big-endian order; other images contribute the big-endian RGBA64 value
+// of each pixel. Neither the stride nor the origin of the bounds is
+// included, so a sub-image and a compact copy of it translated to (0, 0)
+// have the same checksum. A FrozenImage has the checksum of the image it
+// wraps.
+func Checksum(img image.Image, h hash.Hash) []byte {
+	if f, ok := img.(*FrozenImage); ok && f.rect == f.img.Bounds() {
+		img = f.img
+	}
+	r := img.Bounds()
+	fmt.Fprintf(h, "%T %dx%d\n", img, r.Dx(), r.Dy())
+	if pix, stride, bpp, ok := pixelLayout(img); ok {
+		n := r.Dx() * bpp
+		for y := 0; y < r.Dy(); y++ {
+			h.Write(pix[y*stride : y*stride+n])
+		}
+		return h.Sum(nil)
+	}
+	row := make([]byte, 8*r.Dx())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := row[8*(x-r.Min.X):]
+			cr, cg, cb, ca := img.At(x, y).RGBA()
+			binary.BigEndian.PutUint16(c[0:], uint16(cr))
+			binary.BigEndian.PutUint16(c[2:], uint16(cg))
+			binary.BigEndian.PutUint16(c[4:], uint16(cb))
+			binary.BigEndian.PutUint16(c[6:], uint16(ca))
+		}
+		h.Write(row)
+	}
+	return h.Sum(nil)
+}
+
+// ContentHash returns the SHA-256 of the canonical content of img, as
+// written by Checksum, for use as a cache or deduplication key.
+func ContentHash(img image.Image) [sha256.Size]byte {
+	var sum [sha256.Size]byte
+	copy(sum[:], Checksum(img, sha256.New()))
+	return sum
+}
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayS16Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayF32Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayU32Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayU64Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayS64Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayC64Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
+
+// Checksum writes the canonical content of p to h and returns h.Sum(nil).
+// See the package-level Checksum.
+func (p *GrayC128Image) Checksum(h hash.Hash) []byte { return Checksum(p, h) }
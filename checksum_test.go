@@ -0,0 +1,67 @@
+package colorext
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 12, 9), 4)
+	sub := src.SubImage(image.Rect(2, 3, 10, 8)).(*GrayS16Image)
+	compact := NewGrayS16Image(image.Rect(0, 0, 8, 5))
+	copyRows16(compact.Pix, compact.Stride, 0, sub.Pix, sub.Stride, 0, compact.Rect, sub.Rect.Min)
+	changed := NewGrayS16Image(compact.Rect)
+	copy(changed.Pix, compact.Pix)
+	changed.SetGrayS16(7, 4, GrayS16{Y: changed.GrayS16At(7, 4).Y + 1})
+	asGray16 := GrayS16ToGray16(compact)
+	f32 := NewGrayF32Image(image.Rect(0, 0, 4, 5))
+	copy(f32.Pix, compact.Pix)
+	transposed := NewGrayS16Image(image.Rect(0, 0, 5, 8))
+	copy(transposed.Pix, compact.Pix)
+
+	want := ContentHash(sub)
+	tests := []struct {
+		name string
+		img  image.Image
+		same bool
+	}{
+		{"compact copy at origin", compact, true},
+		{"one pixel changed", changed, false},
+		{"other type, same bytes", f32, false},
+		{"other shape, same bytes", transposed, false},
+		{"unsigned view", asGray16, false},
+	}
+	for _, tt := range tests {
+		if got := ContentHash(tt.img) == want; got != tt.same {
+			t.Errorf("%s: equal hash = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+
+	// The method form and other hashes agree with the package function.
+	if got := sub.Checksum(sha256.New()); !bytes.Equal(got, want[:]) {
+		t.Errorf("method checksum %x, want %x", got, want)
+	}
+	if a, b := Checksum(sub, crc32.NewIEEE()), compact.Checksum(crc32.NewIEEE()); !bytes.Equal(a, b) {
+		t.Errorf("CRC-32 %x and %x differ", a, b)
+	}
+}
+
+func TestChecksumGeneric(t *testing.T) {
+	// Images without a plain layout are hashed by their RGBA64 values.
+	pal := image.NewPaletted(image.Rect(0, 0, 3, 3), color.Palette{color.RGBA{R: 10, A: 255}, color.Black})
+	other := image.NewPaletted(pal.Rect, color.Palette{color.Black, color.RGBA{R: 10, A: 255}})
+	for i := range other.Pix {
+		other.Pix[i] = 1
+	}
+	if ContentHash(pal) != ContentHash(other) {
+		t.Error("paletted images with the same colors but different indices hash differently")
+	}
+	other.Pix[4] = 0
+	if ContentHash(pal) == ContentHash(other) {
+		t.Error("paletted images with different colors hash the same")
+	}
+}
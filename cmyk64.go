@@ -0,0 +1,176 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// CMYK64 represents a fully opaque CMYK color, having 16 bits for each of
+// cyan, magenta, yellow and black.
+//
+// It is not associated with any particular color profile, matching the
+// behavior of color.CMYK.
+type CMYK64 struct {
+	C, M, Y, K uint16
+}
+
+// RGBA returns the red, green, blue and alpha components of the CMYK64 color.
+// This implements the color.Color interface.
+// The conversion is the 16-bit equivalent of color.CMYK's.
+func (c CMYK64) RGBA() (r, g, b, a uint32) {
+	w := 0xffff - uint32(c.K)
+	r = (0xffff - uint32(c.C)) * w / 0xffff
+	g = (0xffff - uint32(c.M)) * w / 0xffff
+	b = (0xffff - uint32(c.Y)) * w / 0xffff
+	return r, g, b, 0xffff
+}
+
+// CMYK64Model is the color model for 16-bit CMYK colors.
+var CMYK64Model color.Model = color.ModelFunc(cmyk64Model)
+
+// cmyk64Model converts any color.Color to a CMYK64.
+func cmyk64Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case CMYK64:
+		return c
+	case color.CMYK:
+		// Widen exactly rather than round-tripping through RGB.
+		return CMYK64{
+			C: uint16(c.C) * 0x101,
+			M: uint16(c.M) * 0x101,
+			Y: uint16(c.Y) * 0x101,
+			K: uint16(c.K) * 0x101,
+		}
+	}
+	r, g, b, _ := c.RGBA()
+	cc, mm, yy, kk := RGBToCMYK64(uint16(r), uint16(g), uint16(b))
+	return CMYK64{cc, mm, yy, kk}
+}
+
+// RGBToCMYK64 converts a 16-bit RGB triple to a CMYK64 quadruple.
+// It is the 16-bit equivalent of color.RGBToCMYK.
+func RGBToCMYK64(r, g, b uint16) (c, m, y, k uint16) {
+	rr, gg, bb := uint32(r), uint32(g), uint32(b)
+	w := max(rr, gg, bb)
+	if w == 0 {
+		return 0, 0, 0, 0xffff
+	}
+	c = uint16((w - rr) * 0xffff / w)
+	m = uint16((w - gg) * 0xffff / w)
+	y = uint16((w - bb) * 0xffff / w)
+	return c, m, y, uint16(0xffff - w)
+}
+
+// CMYK64Image is an in-memory image whose At method returns CMYK64 values.
+type CMYK64Image struct {
+	// Pix holds the image's pixels, in C, M, Y, K order and big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the CMYK64Image's color model.
+func (p *CMYK64Image) ColorModel() color.Model {
+	return CMYK64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *CMYK64Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *CMYK64Image) At(x, y int) color.Color {
+	return p.CMYK64At(x, y)
+}
+
+// CMYK64At returns the CMYK64 color of the pixel at (x, y).
+func (p *CMYK64Image) CMYK64At(x, y int) CMYK64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return CMYK64{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	return CMYK64{
+		C: uint16(s[0])<<8 | uint16(s[1]),
+		M: uint16(s[2])<<8 | uint16(s[3]),
+		Y: uint16(s[4])<<8 | uint16(s[5]),
+		K: uint16(s[6])<<8 | uint16(s[7]),
+	}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *CMYK64Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*8
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *CMYK64Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetCMYK64(x, y, CMYK64Model.Convert(c).(CMYK64))
+}
+
+// SetCMYK64 sets the pixel at (x, y) to a given CMYK64 color.
+func (p *CMYK64Image) SetCMYK64(x, y int, c CMYK64) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	s[0], s[1] = uint8(c.C>>8), uint8(c.C)
+	s[2], s[3] = uint8(c.M>>8), uint8(c.M)
+	s[4], s[5] = uint8(c.Y>>8), uint8(c.Y)
+	s[6], s[7] = uint8(c.K>>8), uint8(c.K)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *CMYK64Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &CMYK64Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &CMYK64Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// CMYK64Image is always fully opaque since the CMYK64 color model has no transparency.
+func (p *CMYK64Image) Opaque() bool {
+	return true
+}
+
+// NewCMYK64Image returns a new CMYK64Image with the given bounds.
+func NewCMYK64Image(r image.Rectangle, opts ...ImageOption) *CMYK64Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &CMYK64Image{
+		Pix:    o.pix(8*w*h, 2),
+		Stride: 8 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 8)
+	return p
+}
+
+func (p *CMYK64Image) metadata() Metadata {
+	return p.Meta
+}
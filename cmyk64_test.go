@@ -0,0 +1,121 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCMYK64_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    CMYK64
+		want [3]uint32
+	}{
+		{"white", CMYK64{}, [3]uint32{0xffff, 0xffff, 0xffff}},
+		{"black", CMYK64{K: 0xffff}, [3]uint32{0, 0, 0}},
+		{"cyan", CMYK64{C: 0xffff}, [3]uint32{0, 0xffff, 0xffff}},
+		{"half black", CMYK64{K: 0x8000}, [3]uint32{0x7fff, 0x7fff, 0x7fff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if [3]uint32{r, g, b} != tt.want || a != 0xffff {
+				t.Errorf("%+v.RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, 65535)",
+					tt.c, r, g, b, a, tt.want[0], tt.want[1], tt.want[2])
+			}
+		})
+	}
+}
+
+func TestCMYK64_MatchesStdlibCMYK(t *testing.T) {
+	// Widened 8-bit CMYK colors must render exactly like color.CMYK.
+	for _, c8 := range []color.CMYK{
+		{0, 0, 0, 0}, {255, 0, 0, 0}, {12, 34, 56, 78}, {200, 100, 50, 25}, {0, 0, 0, 255},
+	} {
+		c16 := CMYK64Model.Convert(c8).(CMYK64)
+		r1, g1, b1, _ := c8.RGBA()
+		r2, g2, b2, _ := c16.RGBA()
+		if r1 != r2 || g1 != g2 || b1 != b2 {
+			t.Errorf("CMYK64 %+v renders (%d, %d, %d), color.CMYK %+v renders (%d, %d, %d)",
+				c16, r2, g2, b2, c8, r1, g1, b1)
+		}
+	}
+}
+
+func TestCMYK64Model(t *testing.T) {
+	original := CMYK64{1, 2, 3, 4}
+	if got := CMYK64Model.Convert(original); got != original {
+		t.Errorf("CMYK64Model.Convert(%v) = %v, want unchanged", original, got)
+	}
+
+	tests := []struct {
+		name  string
+		input color.Color
+		want  CMYK64
+	}{
+		{"white", color.White, CMYK64{0, 0, 0, 0}},
+		{"black", color.Black, CMYK64{0, 0, 0, 0xffff}},
+		{"red", color.RGBA64{R: 0xffff, A: 0xffff}, CMYK64{0, 0xffff, 0xffff, 0}},
+		{"dark gray", color.Gray16{Y: 0x4000}, CMYK64{0, 0, 0, 0xbfff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CMYK64Model.Convert(tt.input); got != tt.want {
+				t.Errorf("CMYK64Model.Convert(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRGBToCMYK64_RoundTrip(t *testing.T) {
+	for _, rgb := range [][3]uint16{{0, 0, 0}, {0xffff, 0x8000, 0x1234}, {100, 200, 300}, {0xffff, 0xffff, 0xffff}} {
+		c, m, y, k := RGBToCMYK64(rgb[0], rgb[1], rgb[2])
+		r, g, b, _ := CMYK64{c, m, y, k}.RGBA()
+		for i, got := range []uint32{r, g, b} {
+			if diff := int(got) - int(rgb[i]); diff < -1 || diff > 1 {
+				t.Errorf("RGB %v round-tripped to (%d, %d, %d)", rgb, r, g, b)
+				break
+			}
+		}
+	}
+}
+
+func TestCMYK64Image(t *testing.T) {
+	img := NewCMYK64Image(image.Rect(1, 1, 4, 3))
+	if img.Stride != 24 || len(img.Pix) != 48 {
+		t.Fatalf("Stride = %d, len(Pix) = %d, want 24, 48", img.Stride, len(img.Pix))
+	}
+	if img.ColorModel() != CMYK64Model || !img.Opaque() {
+		t.Error("unexpected ColorModel or Opaque")
+	}
+
+	want := CMYK64{0x0102, 0x0304, 0x0506, 0x0708}
+	img.SetCMYK64(1, 1, want)
+	if got := img.CMYK64At(1, 1); got != want {
+		t.Errorf("CMYK64At(1, 1) = %v, want %v", got, want)
+	}
+	for i, b := range []uint8{1, 2, 3, 4, 5, 6, 7, 8} {
+		if img.Pix[i] != b {
+			t.Fatalf("Pix[:8] = % x, want big-endian C, M, Y, K", img.Pix[:8])
+		}
+	}
+
+	img.Set(3, 2, color.Black)
+	if got := img.At(3, 2); got != (CMYK64{K: 0xffff}) {
+		t.Errorf("At(3, 2) = %v, want black", got)
+	}
+	if got := img.CMYK64At(0, 0); got != (CMYK64{}) {
+		t.Errorf("CMYK64At(0, 0) = %v, want zero for out of bounds", got)
+	}
+
+	sub := img.SubImage(image.Rect(3, 2, 10, 10)).(*CMYK64Image)
+	if sub.Bounds() != image.Rect(3, 2, 4, 3) || sub.CMYK64At(3, 2) != (CMYK64{K: 0xffff}) {
+		t.Errorf("SubImage did not share pixels: %v", sub.CMYK64At(3, 2))
+	}
+	if empty := img.SubImage(image.Rect(10, 10, 20, 20)); !empty.Bounds().Empty() {
+		t.Errorf("Non-intersecting SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
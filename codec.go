@@ -0,0 +1,192 @@
+package colorext
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies one of the file formats the package reads or writes.
+type Format int
+
+// Formats. Decode recognizes FormatPNG, FormatQS16, FormatCompressed,
+// FormatBinary and FormatGeoTIFF by their signatures and, for GeoTIFF, its
+// tags; FormatFarbfeld, FormatBMP and FormatCSV can only be written.
+const (
+	// FormatUnknown is a format handled by another package registered with
+	// image.RegisterFormat, such as JPEG or a TIFF without GeoTIFF tags.
+	FormatUnknown Format = iota
+	// FormatPNG is PNG, with an siNT chunk for GrayS16 images.
+	FormatPNG
+	// FormatQS16 is a single QS16 frame.
+	FormatQS16
+	// FormatCompressed is a compressed raster, as written by
+	// WriteCompressed.
+	FormatCompressed
+	// FormatBinary is the binary encoding of MarshalBinary.
+	FormatBinary
+	// FormatGeoTIFF is a single-band GeoTIFF elevation model.
+	FormatGeoTIFF
+	// FormatFarbfeld is farbfeld, 16-bit RGBA.
+	FormatFarbfeld
+	// FormatBMP is an 8-bit grayscale BMP.
+	FormatBMP
+	// FormatCSV is delimited text, as written by WriteCSV.
+	FormatCSV
+)
+
+var formatNames = [...]string{
+	FormatUnknown:    "unknown",
+	FormatPNG:        "png",
+	FormatQS16:       "qs16",
+	FormatCompressed: "cxrz",
+	FormatBinary:     "cxim",
+	FormatGeoTIFF:    "geotiff",
+	FormatFarbfeld:   "farbfeld",
+	FormatBMP:        "bmp",
+	FormatCSV:        "csv",
+}
+
+// String returns the short name of f, such as "png". The names of the
+// formats registered with image.RegisterFormat match the names image.Decode
+// reports for them.
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// formatExtensions maps lower-case file name extensions to formats.
+var formatExtensions = map[string]Format{
+	".png":      FormatPNG,
+	".qs16":     FormatQS16,
+	".cxrz":     FormatCompressed,
+	".cxim":     FormatBinary,
+	".tif":      FormatGeoTIFF,
+	".tiff":     FormatGeoTIFF,
+	".ff":       FormatFarbfeld,
+	".farbfeld": FormatFarbfeld,
+	".bmp":      FormatBMP,
+	".csv":      FormatCSV,
+}
+
+// FormatFromExtension returns the format conventionally stored in files
+// named like name, judging by its extension, or FormatUnknown.
+func FormatFromExtension(name string) Format {
+	return formatExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// tiffSignatures start little- and big-endian TIFF files.
+var tiffSignatures = []string{"II*\x00", "MM\x00*"}
+
+// Decode reads an image from r, recognizing its format by its signature,
+// and returns the image along with its format. PNG files are read with
+// DecodePNG and TIFF files carrying GeoTIFF or GDAL tags with
+// DecodeGeoTIFFWithMeta, so signed values and georeferencing survive. Other
+// formats registered with image.RegisterFormat, including ordinary TIFF
+// pictures, are decoded by image.Decode and reported as FormatUnknown.
+// Unrecognized data yields an error wrapping image.ErrFormat.
+func Decode(r io.Reader) (image.Image, Format, error) {
+	br := bufio.NewReader(r)
+	// A short stream is not an error here; it cannot match a signature.
+	sig, _ := br.Peek(len(pngSignature))
+	has := func(magic string) bool { return bytes.HasPrefix(sig, []byte(magic)) }
+	switch {
+	case has(pngSignature):
+		img, err := DecodePNG(br)
+		return img, FormatPNG, err
+	case has(qs16Magic):
+		img, err := DecodeQS16(br)
+		return img, FormatQS16, err
+	case has(compressedMagic):
+		img, err := ReadCompressed(br)
+		return img, FormatCompressed, err
+	case has(binaryMagic):
+		img, err := decodeBinaryImage(br)
+		return img, FormatBinary, err
+	}
+	var rest io.Reader = br
+	if has(tiffSignatures[0]) || has(tiffSignatures[1]) {
+		// The tags may be anywhere in the file.
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, FormatUnknown, err
+		}
+		if isGeoTIFF(data) {
+			img, err := DecodeGeoTIFFWithMeta(bytes.NewReader(data))
+			if err != nil {
+				return nil, FormatGeoTIFF, err
+			}
+			return img, FormatGeoTIFF, nil
+		}
+		rest = bytes.NewReader(data)
+	}
+	img, _, err := image.Decode(rest)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, FormatUnknown, fmt.Errorf("colorext: %w", err)
+		}
+		return nil, FormatUnknown, err
+	}
+	return img, FormatUnknown, nil
+}
+
+// EncodeOptions controls Encode. Each field applies to one format and is
+// ignored by the others. The zero value suits every format.
+type EncodeOptions struct {
+	// Compression is the compressor of FormatCompressed rasters. The zero
+	// value, CompressionNone, stores the rows as they are.
+	Compression Compression
+	// Mapping maps signed values to unsigned levels for FormatFarbfeld.
+	Mapping MappingPolicy
+	// CSV controls FormatCSV output.
+	CSV CSVOptions
+}
+
+// Encode writes img to w in format f. FormatQS16 requires a
+// *GrayS16Image, FormatCompressed and FormatBinary one of the gray image
+// types, and FormatBMP an *image.Gray; other images yield an error wrapping
+// ErrUnsupportedDType. FormatGeoTIFF and FormatUnknown cannot be written.
+// opts may be nil.
+func Encode(w io.Writer, img image.Image, f Format, opts *EncodeOptions) error {
+	var o EncodeOptions
+	if opts != nil {
+		o = *opts
+	}
+	switch f {
+	case FormatPNG:
+		return EncodePNG(w, img)
+	case FormatQS16:
+		m, ok := img.(*GrayS16Image)
+		if !ok {
+			return fmt.Errorf("%w: cannot encode %T as QS16", ErrUnsupportedDType, img)
+		}
+		return EncodeQS16(w, m)
+	case FormatCompressed:
+		return WriteCompressed(w, img, o.Compression)
+	case FormatBinary:
+		d, pix, stride, ok := grayLayout(img)
+		if !ok {
+			return fmt.Errorf("%w: cannot encode %T as a binary image", ErrUnsupportedDType, img)
+		}
+		_, err := w.Write(marshalPix(d, img.Bounds(), pix, stride))
+		return err
+	case FormatFarbfeld:
+		return EncodeFarbfeld(w, img, o.Mapping)
+	case FormatBMP:
+		m, ok := img.(*image.Gray)
+		if !ok {
+			return fmt.Errorf("%w: cannot encode %T as BMP", ErrUnsupportedDType, img)
+		}
+		return EncodeBMP(w, m)
+	case FormatCSV:
+		return WriteCSV(w, img, &o.CSV)
+	}
+	return fmt.Errorf("colorext: cannot encode format %v", f)
+}
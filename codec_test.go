@@ -0,0 +1,154 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func TestFormatFromExtension(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+	}{
+		{"dem.tif", FormatGeoTIFF},
+		{"DEM.TIFF", FormatGeoTIFF},
+		{"frames/0001.qs16", FormatQS16},
+		{"raster.cxrz", FormatCompressed},
+		{"raster.cxim", FormatBinary},
+		{"preview.png", FormatPNG},
+		{"preview.ff", FormatFarbfeld},
+		{"slice.bmp", FormatBMP},
+		{"values.csv", FormatCSV},
+		{"photo.jpg", FormatUnknown},
+		{"noextension", FormatUnknown},
+	}
+	for _, tt := range tests {
+		if got := FormatFromExtension(tt.name); got != tt.want {
+			t.Errorf("FormatFromExtension(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	s16 := randomGrayS16(image.Rect(0, 0, 9, 6), 8)
+	f32 := rampF32(image.Rect(-2, 1, 5, 4))
+	tests := []struct {
+		name   string
+		img    image.Image
+		format Format
+		opts   *EncodeOptions
+	}{
+		{"png", s16, FormatPNG, nil},
+		{"qs16", s16, FormatQS16, nil},
+		{"cxrz", f32, FormatCompressed, &EncodeOptions{Compression: CompressionDeflate}},
+		{"cxrz default", s16, FormatCompressed, nil},
+		{"cxim", f32, FormatBinary, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.img, tt.format, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			img, format, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %v, want %v", format, tt.format)
+			}
+			if !Equal(img, tt.img) {
+				t.Errorf("decoded %T differs from %T", img, tt.img)
+			}
+		})
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	// A TIFF is decoded as a GeoTIFF only if it carries GeoTIFF or GDAL
+	// tags; other TIFFs are left to the decoders registered with the image
+	// package, here a stand-in for golang.org/x/image/tiff that only reads
+	// little-endian files.
+	image.RegisterFormat("tiff-test", "II*\x00", func(io.Reader) (image.Image, error) {
+		return image.NewGray(image.Rect(0, 0, 1, 1)), nil
+	}, nil)
+	tiff := func(bo tiffByteOrder, geo bool) []byte {
+		entries := []tiffEntry{
+			{tag: tagImageWidth, shorts: []uint16{1}},
+			{tag: tagImageLength, shorts: []uint16{1}},
+			{tag: tagBitsPerSample, shorts: []uint16{16}},
+			{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+			{tag: tagRowsPerStrip, shorts: []uint16{1}},
+		}
+		if geo {
+			entries = append(entries, tiffEntry{tag: tagModelPixelScale, doubles: []float64{30, 30, 0}})
+		}
+		return buildTIFF(bo, entries, tagStripOffsets, [][]byte{bo.AppendUint16(nil, 0xfff6)})
+	}
+	for _, bo := range []tiffByteOrder{binary.BigEndian, binary.LittleEndian} {
+		m, format, err := Decode(bytes.NewReader(tiff(bo, true)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wm, ok := m.(*ImageWithMeta); !ok || format != FormatGeoTIFF {
+			t.Errorf("%v GeoTIFF decoded as %T, format %v", bo, m, format)
+		} else if got := wm.Image.(*GrayS16Image).GrayS16At(0, 0).Y; got != -10 {
+			t.Errorf("%v GeoTIFF pixel = %d, want -10", bo, got)
+		}
+	}
+	if m, format, err := Decode(bytes.NewReader(tiff(binary.LittleEndian, false))); err != nil || format != FormatUnknown {
+		t.Errorf("plain TIFF: format %v, err %v", format, err)
+	} else if _, ok := m.(*image.Gray); !ok {
+		t.Errorf("plain TIFF decoded as %T, want the registered decoder's *image.Gray", m)
+	}
+	if _, _, err := Decode(bytes.NewReader(tiff(binary.BigEndian, false))); !errors.Is(err, image.ErrFormat) {
+		t.Errorf("plain TIFF without a decoder: err = %v, want an error wrapping %v", err, image.ErrFormat)
+	}
+
+	var buf bytes.Buffer
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	if err := png.Encode(&buf, rgba); err != nil {
+		t.Fatal(err)
+	}
+	if _, format, err := Decode(&buf); err != nil || format != FormatPNG {
+		t.Errorf("8-bit PNG: format %v, err %v", format, err)
+	}
+
+	buf.Reset()
+	if err := Encode(&buf, rgba, FormatFarbfeld, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Decode(&buf); !errors.Is(err, image.ErrFormat) {
+		t.Errorf("farbfeld: err = %v, want an error wrapping %v", err, image.ErrFormat)
+	}
+	if _, _, err := Decode(bytes.NewReader(nil)); !errors.Is(err, image.ErrFormat) {
+		t.Errorf("empty input: err = %v, want an error wrapping %v", err, image.ErrFormat)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	tests := []struct {
+		name   string
+		img    image.Image
+		format Format
+		want   error
+	}{
+		{"qs16 float32", NewGrayF32Image(rgba.Rect), FormatQS16, ErrUnsupportedDType},
+		{"cxim rgba", rgba, FormatBinary, ErrUnsupportedDType},
+		{"bmp int16", NewGrayS16Image(rgba.Rect), FormatBMP, ErrUnsupportedDType},
+		{"geotiff", NewGrayS16Image(rgba.Rect), FormatGeoTIFF, nil},
+		{"unknown", rgba, FormatUnknown, nil},
+	}
+	for _, tt := range tests {
+		err := Encode(&bytes.Buffer{}, tt.img, tt.format, nil)
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want an error wrapping %v", tt.name, err, tt.want)
+		}
+	}
+}
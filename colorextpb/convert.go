@@ -0,0 +1,140 @@
+package colorextpb
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// sampleSizes holds the size in bytes of a sample of each type, and the size
+// of each byte-swapped word within it.
+var sampleSizes = map[DType]struct{ size, word int }{
+	DTypeInt16:      {2, 2},
+	DTypeFloat32:    {4, 4},
+	DTypeUint32:     {4, 4},
+	DTypeUint64:     {8, 8},
+	DTypeInt64:      {8, 8},
+	DTypeComplex64:  {8, 4},
+	DTypeComplex128: {16, 8},
+}
+
+// ToProto returns a big-endian Image message holding img, which must be one
+// of the colorext gray image types: GrayS16Image, GrayF32Image,
+// GrayU32Image, GrayU64Image, GrayS64Image, GrayC64Image or GrayC128Image.
+// Other images yield an error wrapping colorext.ErrUnsupportedDType.
+func ToProto(img image.Image) (*Image, error) {
+	var (
+		t      DType
+		pix    []uint8
+		stride int
+	)
+	switch m := img.(type) {
+	case *colorext.GrayS16Image:
+		t, pix, stride = DTypeInt16, m.Pix, m.Stride
+	case *colorext.GrayF32Image:
+		t, pix, stride = DTypeFloat32, m.Pix, m.Stride
+	case *colorext.GrayU32Image:
+		t, pix, stride = DTypeUint32, m.Pix, m.Stride
+	case *colorext.GrayU64Image:
+		t, pix, stride = DTypeUint64, m.Pix, m.Stride
+	case *colorext.GrayS64Image:
+		t, pix, stride = DTypeInt64, m.Pix, m.Stride
+	case *colorext.GrayC64Image:
+		t, pix, stride = DTypeComplex64, m.Pix, m.Stride
+	case *colorext.GrayC128Image:
+		t, pix, stride = DTypeComplex128, m.Pix, m.Stride
+	default:
+		return nil, fmt.Errorf("%w: no proto dtype for %T", colorext.ErrUnsupportedDType, img)
+	}
+	r := img.Bounds()
+	n := sampleSizes[t].size * r.Dx()
+	data := make([]byte, 0, n*r.Dy())
+	for y := 0; y < r.Dy(); y++ {
+		data = append(data, pix[y*stride:y*stride+n]...)
+	}
+	return &Image{
+		DType: t,
+		Shape: []int64{int64(r.Dy()), int64(r.Dx())},
+		Data:  data,
+		MinX:  int64(r.Min.X),
+		MinY:  int64(r.Min.Y),
+	}, nil
+}
+
+// FromProto returns the colorext image held by m, of the type ToProto maps
+// to m.DType. Little-endian data is converted. The returned image does not
+// share memory with m. An unknown dtype yields an error wrapping
+// colorext.ErrUnsupportedDType, and a shape, origin or endianness that does
+// not describe m.Data one wrapping colorext.ErrInvalidLayout.
+func FromProto(m *Image) (image.Image, error) {
+	s, ok := sampleSizes[m.DType]
+	if !ok {
+		return nil, fmt.Errorf("%w: proto dtype %d", colorext.ErrUnsupportedDType, m.DType)
+	}
+	if m.Endianness != BigEndian && m.Endianness != LittleEndian {
+		return nil, fmt.Errorf("%w: proto endianness %d", colorext.ErrInvalidLayout, m.Endianness)
+	}
+	if len(m.Shape) != 2 {
+		return nil, fmt.Errorf("%w: proto shape has %d dimensions, want 2", colorext.ErrInvalidLayout, len(m.Shape))
+	}
+	h, w := m.Shape[0], m.Shape[1]
+	n := int64(len(m.Data))
+	if h < 0 || w < 0 || (w == 0 || h == 0) && n != 0 ||
+		w > 0 && h > 0 && (w > n || h > n/w || w*h*int64(s.size) != n) {
+		return nil, fmt.Errorf("%w: %d proto data bytes do not match shape %v of %d-byte samples", colorext.ErrInvalidLayout, len(m.Data), m.Shape, s.size)
+	}
+	x0, y0 := int(m.MinX), int(m.MinY)
+	if int64(x0) != m.MinX || int64(y0) != m.MinY || x0 > x0+int(w) || y0 > y0+int(h) {
+		return nil, fmt.Errorf("%w: proto origin (%d, %d) out of range", colorext.ErrInvalidLayout, m.MinX, m.MinY)
+	}
+	r := image.Rect(x0, y0, x0+int(w), y0+int(h))
+
+	var img image.Image
+	var pix []uint8
+	switch m.DType {
+	case DTypeInt16:
+		p := colorext.NewGrayS16Image(r)
+		img, pix = p, p.Pix
+	case DTypeFloat32:
+		p := colorext.NewGrayF32Image(r)
+		img, pix = p, p.Pix
+	case DTypeUint32:
+		p := colorext.NewGrayU32Image(r)
+		img, pix = p, p.Pix
+	case DTypeUint64:
+		p := colorext.NewGrayU64Image(r)
+		img, pix = p, p.Pix
+	case DTypeInt64:
+		p := colorext.NewGrayS64Image(r)
+		img, pix = p, p.Pix
+	case DTypeComplex64:
+		p := colorext.NewGrayC64Image(r)
+		img, pix = p, p.Pix
+	case DTypeComplex128:
+		p := colorext.NewGrayC128Image(r)
+		img, pix = p, p.Pix
+	}
+	copy(pix, m.Data)
+	if m.Endianness == LittleEndian {
+		swapWords(pix, s.word)
+	}
+	return img, nil
+}
+
+// swapWords reverses the bytes of each size-byte word in b.
+func swapWords(b []byte, size int) {
+	switch size {
+	case 2:
+		colorext.SwapBytes16(b)
+	case 4:
+		colorext.SwapBytes32(b)
+	default:
+		for i := 0; i+size <= len(b); i += size {
+			w := b[i : i+size]
+			for j, k := 0, size-1; j < k; j, k = j+1, k-1 {
+				w[j], w[k] = w[k], w[j]
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package colorextpb
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	r := image.Rect(-2, 3, 4, 7)
+	s16 := colorext.NewGrayS16Image(r)
+	f32 := colorext.NewGrayF32Image(r)
+	c128 := colorext.NewGrayC128Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s16.SetGrayS16(x, y, colorext.GrayS16{Y: int16(x*1000 - y*77)})
+			f32.SetGrayF32(x, y, colorext.GrayF32{Y: float32(x) / float32(y)})
+			c128.SetGrayC128(x, y, colorext.GrayC128{Y: complex(float64(x), -float64(y))})
+		}
+	}
+	tests := []image.Image{
+		s16,
+		s16.SubImage(image.Rect(0, 4, 3, 6)),
+		f32,
+		colorext.NewGrayU32Image(r),
+		colorext.NewGrayU64Image(r),
+		colorext.NewGrayS64Image(r),
+		colorext.NewGrayC64Image(r),
+		c128,
+		colorext.NewGrayS16Image(image.Rectangle{}),
+	}
+	for _, img := range tests {
+		m, err := ToProto(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := m.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Image
+		if err := back.Unmarshal(b); err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromProto(&back)
+		if err != nil {
+			t.Fatalf("%T: %v", img, err)
+		}
+		if got.Bounds() != img.Bounds() || !colorext.Equal(got, img) {
+			t.Errorf("%T %v: round trip mismatch", img, img.Bounds())
+		}
+		if len(back.Data) > 0 {
+			back.Data[0]++
+			if !colorext.Equal(got, img) {
+				t.Errorf("%T: result shares memory with the message", img)
+			}
+		}
+	}
+}
+
+func TestFromProtoLittleEndian(t *testing.T) {
+	tests := []struct {
+		dtype DType
+		data  []byte
+		want  func(image.Image) bool
+	}{
+		{DTypeInt16, []byte{0xfe, 0xff}, func(img image.Image) bool {
+			return img.(*colorext.GrayS16Image).GrayS16At(0, 0).Y == -2
+		}},
+		{DTypeFloat32, []byte{0, 0, 0xc0, 0x3f}, func(img image.Image) bool {
+			return img.(*colorext.GrayF32Image).GrayF32At(0, 0).Y == 1.5
+		}},
+		{DTypeUint64, []byte{1, 2, 0, 0, 0, 0, 0, 0}, func(img image.Image) bool {
+			return img.(*colorext.GrayU64Image).GrayU64At(0, 0).Y == 0x0201
+		}},
+		{DTypeComplex64, []byte{0, 0, 0x80, 0x3f, 0, 0, 0, 0xc0}, func(img image.Image) bool {
+			return img.(*colorext.GrayC64Image).GrayC64At(0, 0).Y == complex(1, -2)
+		}},
+		{DTypeComplex128, append(le64(math.Float64bits(3)), le64(math.Float64bits(-0.5))...), func(img image.Image) bool {
+			return img.(*colorext.GrayC128Image).GrayC128At(0, 0).Y == complex(3, -0.5)
+		}},
+	}
+	for _, tt := range tests {
+		m := &Image{DType: tt.dtype, Shape: []int64{1, 1}, Endianness: LittleEndian, Data: tt.data}
+		img, err := FromProto(m)
+		if err != nil {
+			t.Fatalf("dtype %d: %v", tt.dtype, err)
+		}
+		if !tt.want(img) {
+			t.Errorf("dtype %d: wrong pixel value decoded from % x", tt.dtype, tt.data)
+		}
+	}
+}
+
+func le64(u uint64) []byte {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = byte(u >> (8 * i))
+	}
+	return b
+}
+
+func TestProtoErrors(t *testing.T) {
+	if _, err := ToProto(image.NewGray(image.Rect(0, 0, 1, 1))); !errors.Is(err, colorext.ErrUnsupportedDType) {
+		t.Errorf("ToProto(*image.Gray) = %v, want %v", err, colorext.ErrUnsupportedDType)
+	}
+	tests := []struct {
+		name string
+		m    Image
+		is   error
+	}{
+		{"dtype", Image{Shape: []int64{1, 1}, Data: []byte{0, 0}}, colorext.ErrUnsupportedDType},
+		{"endianness", Image{DType: DTypeInt16, Endianness: 2, Shape: []int64{1, 1}, Data: []byte{0, 0}}, colorext.ErrInvalidLayout},
+		{"rank", Image{DType: DTypeInt16, Shape: []int64{2}, Data: []byte{0, 0, 0, 0}}, colorext.ErrInvalidLayout},
+		{"short data", Image{DType: DTypeInt16, Shape: []int64{1, 2}, Data: []byte{0, 0}}, colorext.ErrInvalidLayout},
+		{"negative", Image{DType: DTypeInt16, Shape: []int64{-1, -1}, Data: []byte{0, 0}}, colorext.ErrInvalidLayout},
+		{"overflow", Image{DType: DTypeInt16, Shape: []int64{1 << 62, 1 << 62}}, colorext.ErrInvalidLayout},
+		{"origin", Image{DType: DTypeInt16, Shape: []int64{1, 1}, Data: []byte{0, 0}, MinX: math.MaxInt64}, colorext.ErrInvalidLayout},
+	}
+	for _, tt := range tests {
+		if _, err := FromProto(&tt.m); !errors.Is(err, tt.is) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.is)
+		}
+	}
+	if img, err := FromProto(&Image{DType: DTypeFloat32, Shape: []int64{0, 5}}); err != nil || !img.Bounds().Empty() {
+		t.Errorf("empty image: got %v, %v", img, err)
+	}
+}
+
+func TestSwapWords(t *testing.T) {
+	b := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	swapWords(b, 8)
+	want := []byte{8, 7, 6, 5, 4, 3, 2, 1, 16, 15, 14, 13, 12, 11, 10, 9}
+	if !bytes.Equal(b, want) {
+		t.Errorf("swapWords = %v, want %v", b, want)
+	}
+}
@@ -0,0 +1,194 @@
+// Package colorextpb implements the protocol buffer wire format defined in
+// image.proto, so that services exchanging colorext images agree on a
+// single encoding.
+//
+// The message type is written by hand rather than generated, which keeps the
+// module free of dependencies. Its encoding is compatible with code
+// generated from image.proto in any language.
+package colorextpb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// DType is the sample type of an image's pixels.
+type DType int32
+
+// Sample types. Complex types hold the real part before the imaginary part.
+const (
+	DTypeUnspecified DType = iota
+	DTypeInt16
+	DTypeFloat32
+	DTypeUint32
+	DTypeUint64
+	DTypeInt64
+	DTypeComplex64
+	DTypeComplex128
+)
+
+// Endianness is the byte order of each sample in an image's data.
+type Endianness int32
+
+// Byte orders.
+const (
+	BigEndian Endianness = iota
+	LittleEndian
+)
+
+// Image is a single-plane image message.
+type Image struct {
+	DType DType
+	// Shape is the height and width in pixels.
+	Shape      []int64
+	Endianness Endianness
+	// Data holds the rows of samples, top to bottom, without padding.
+	Data []byte
+	// MinX and MinY are the coordinates of the top-left pixel.
+	MinX, MinY int64
+}
+
+// Field numbers and wire types from image.proto.
+const (
+	fieldDType      = 1
+	fieldShape      = 2
+	fieldEndianness = 3
+	fieldData       = 4
+	fieldMinX       = 5
+	fieldMinY       = 6
+
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Marshal returns the wire encoding of m. As in proto3, fields holding zero
+// values are omitted.
+func (m *Image) Marshal() ([]byte, error) {
+	b := make([]byte, 0, len(m.Data)+16+binary.MaxVarintLen64*(len(m.Shape)+3))
+	if m.DType != 0 {
+		b = appendTag(b, fieldDType, wireVarint)
+		b = binary.AppendUvarint(b, uint64(int64(m.DType)))
+	}
+	if len(m.Shape) > 0 {
+		var packed []byte
+		for _, v := range m.Shape {
+			packed = binary.AppendUvarint(packed, uint64(v))
+		}
+		b = appendTag(b, fieldShape, wireBytes)
+		b = binary.AppendUvarint(b, uint64(len(packed)))
+		b = append(b, packed...)
+	}
+	if m.Endianness != 0 {
+		b = appendTag(b, fieldEndianness, wireVarint)
+		b = binary.AppendUvarint(b, uint64(int64(m.Endianness)))
+	}
+	if len(m.Data) > 0 {
+		b = appendTag(b, fieldData, wireBytes)
+		b = binary.AppendUvarint(b, uint64(len(m.Data)))
+		b = append(b, m.Data...)
+	}
+	if m.MinX != 0 {
+		b = appendTag(b, fieldMinX, wireVarint)
+		b = binary.AppendUvarint(b, zigzag(m.MinX))
+	}
+	if m.MinY != 0 {
+		b = appendTag(b, fieldMinY, wireVarint)
+		b = binary.AppendUvarint(b, zigzag(m.MinY))
+	}
+	return b, nil
+}
+
+var errTruncated = errors.New("colorextpb: truncated message")
+
+// Unmarshal decodes the wire encoding b into m, replacing its contents.
+// Unknown fields are skipped. Data aliases b.
+func (m *Image) Unmarshal(b []byte) error {
+	*m = Image{}
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		field, wire := tag>>3, int(tag&7)
+		if field == 0 {
+			return errors.New("colorextpb: invalid field number 0")
+		}
+
+		var u uint64
+		var payload []byte
+		switch wire {
+		case wireVarint:
+			if u, n = binary.Uvarint(b); n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wire == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return errTruncated
+			}
+			b = b[size:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return errTruncated
+			}
+			payload, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return fmt.Errorf("colorextpb: unsupported wire type %d", wire)
+		}
+
+		want := wireVarint
+		switch field {
+		case fieldDType:
+			m.DType = DType(u)
+		case fieldShape:
+			// Repeated scalars may arrive packed or one per tag.
+			if wire == wireBytes {
+				for len(payload) > 0 {
+					v, n := binary.Uvarint(payload)
+					if n <= 0 {
+						return errTruncated
+					}
+					m.Shape = append(m.Shape, int64(v))
+					payload = payload[n:]
+				}
+				continue
+			}
+			m.Shape = append(m.Shape, int64(u))
+		case fieldEndianness:
+			m.Endianness = Endianness(u)
+		case fieldData:
+			m.Data, want = payload, wireBytes
+		case fieldMinX:
+			m.MinX = unzigzag(u)
+		case fieldMinY:
+			m.MinY = unzigzag(u)
+		default:
+			continue
+		}
+		if wire != want {
+			return fmt.Errorf("colorextpb: field %d has wire type %d, want %d", field, wire, want)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package colorextpb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestImageMarshal(t *testing.T) {
+	m := &Image{
+		DType:      DTypeInt16,
+		Shape:      []int64{2, 3},
+		Endianness: LittleEndian,
+		Data:       []byte{0xab},
+		MinX:       -1,
+		MinY:       2,
+	}
+	got, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x08, 0x01, // dtype
+		0x12, 0x02, 0x02, 0x03, // packed shape
+		0x18, 0x01, // endianness
+		0x22, 0x01, 0xab, // data
+		0x28, 0x01, // min_x, zigzag encoded
+		0x30, 0x04, // min_y
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal = % x, want % x", got, want)
+	}
+
+	var back Image
+	if err := back.Unmarshal(got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&back, m) {
+		t.Errorf("Unmarshal = %+v, want %+v", back, *m)
+	}
+
+	// The zero message encodes to nothing.
+	if b, _ := new(Image).Marshal(); len(b) != 0 {
+		t.Errorf("zero Marshal = % x, want empty", b)
+	}
+}
+
+func TestImageUnmarshalCompat(t *testing.T) {
+	// Unpacked shape entries and unknown fields of every wire type, as
+	// written by other encoders or newer schema versions.
+	b := []byte{
+		0x10, 0x04, // shape, unpacked
+		0x10, 0x05,
+		0x48, 0x96, 0x01, // field 9, varint
+		0x51, 1, 2, 3, 4, 5, 6, 7, 8, // field 10, fixed64
+		0x5a, 0x02, 0xff, 0xff, // field 11, bytes
+		0x65, 1, 2, 3, 4, // field 12, fixed32
+		0x08, 0x02, // dtype
+	}
+	var m Image
+	if err := m.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Image{DType: DTypeFloat32, Shape: []int64{4, 5}}); !reflect.DeepEqual(m, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", m, want)
+	}
+}
+
+func TestImageUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"truncated tag", []byte{0x80}},
+		{"truncated varint", []byte{0x08, 0x80}},
+		{"truncated bytes", []byte{0x22, 0x05, 0x00}},
+		{"truncated fixed64", []byte{0x51, 0x00}},
+		{"group", []byte{0x4b}},
+		{"field zero", []byte{0x00, 0x00}},
+		{"wrong wire type", []byte{0x0a, 0x00}},
+		{"bad packed shape", []byte{0x12, 0x01, 0x80}},
+	}
+	for _, tt := range tests {
+		var m Image
+		if err := m.Unmarshal(tt.b); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
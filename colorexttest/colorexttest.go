@@ -0,0 +1,199 @@
+// Package colorexttest provides assertions for tests comparing images,
+// including golden-file tests.
+//
+// Pixels are compared by their 16-bit RGBA values, so a tolerance is a
+// maximum per-channel difference in the range [0, 65535]. For GrayS16 images
+// this is the same as a difference in raw signed units.
+//
+// When an assertion fails, the expected and actual images and a heatmap of
+// their differences are written as PNG files to the directory named by the
+// COLOREXTTEST_ARTIFACTS environment variable, or to a colorexttest
+// directory under os.TempDir if it is unset. Setting COLOREXTTEST_UPDATE to
+// a non-empty value makes AssertGolden rewrite golden files instead of
+// comparing against them.
+package colorexttest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Environment variables controlling the package.
+const (
+	// ArtifactsEnv names the directory failure artifacts are written to.
+	ArtifactsEnv = "COLOREXTTEST_ARTIFACTS"
+	// UpdateEnv makes AssertGolden rewrite golden files when non-empty.
+	UpdateEnv = "COLOREXTTEST_UPDATE"
+)
+
+// AssertImagesEqual reports a test error if got does not match want within
+// tolerance, and writes failure artifacts. It returns whether the images
+// matched.
+func AssertImagesEqual(t testing.TB, want, got image.Image, tolerance int) bool {
+	t.Helper()
+	if want.Bounds() != got.Bounds() {
+		t.Errorf("image bounds = %v, want %v", got.Bounds(), want.Bounds())
+		return false
+	}
+	if tolerance <= 0 && colorext.Equal(want, got) {
+		return true
+	}
+	d := diff(want, got, tolerance)
+	if d.count == 0 {
+		return true
+	}
+	t.Errorf("%d of %d pixels differ by more than %d; max difference %d, first at %v",
+		d.count, want.Bounds().Dx()*want.Bounds().Dy(), tolerance, d.max, d.first)
+	writeArtifacts(t, want, got, d.heatmap)
+	return false
+}
+
+// AssertGolden compares got against the PNG image at path like
+// AssertImagesEqual. PNG files do not record an image origin, so only the
+// size of got must match. If the UpdateEnv environment variable is set, it
+// writes got to path instead, creating parent directories as needed.
+func AssertGolden(t testing.TB, path string, got image.Image, tolerance int) bool {
+	t.Helper()
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("colorexttest: %v", err)
+			return false
+		}
+		if err := writePNG(path, got); err != nil {
+			t.Fatalf("colorexttest: %v", err)
+			return false
+		}
+		t.Logf("colorexttest: updated golden file %s", path)
+		return true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("colorexttest: %v (set %s=1 to create it)", err, UpdateEnv)
+		return false
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("colorexttest: decoding golden file %s: %v", path, err)
+		return false
+	}
+	return AssertImagesEqual(t, moved{want, got.Bounds().Min}, got, tolerance)
+}
+
+// moved is an image translated so that its bounds start at min.
+type moved struct {
+	image.Image
+	min image.Point
+}
+
+func (m moved) Bounds() image.Rectangle {
+	r := m.Image.Bounds()
+	return r.Add(m.min.Sub(r.Min))
+}
+
+func (m moved) At(x, y int) color.Color {
+	p := image.Point{X: x, Y: y}.Sub(m.min).Add(m.Image.Bounds().Min)
+	return m.Image.At(p.X, p.Y)
+}
+
+// difference summarizes the pixels of two images differing by more than a
+// tolerance.
+type difference struct {
+	count   int
+	max     int
+	first   image.Point
+	heatmap *image.RGBA
+}
+
+// diff compares two images with equal bounds.
+func diff(want, got image.Image, tolerance int) difference {
+	r := want.Bounds()
+	errs := make([]int, 0, r.Dx()*r.Dy())
+	var d difference
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, a1 := want.At(x, y).RGBA()
+			r2, g2, b2, a2 := got.At(x, y).RGBA()
+			e := max(absDiff(r1, r2), absDiff(g1, g2), absDiff(b1, b2), absDiff(a1, a2))
+			if e > tolerance {
+				if d.count == 0 {
+					d.first = image.Point{X: x, Y: y}
+				}
+				d.count++
+			}
+			d.max = max(d.max, e)
+			errs = append(errs, e)
+		}
+	}
+
+	// Scale the heatmap to the largest difference so small errors show.
+	d.heatmap = image.NewRGBA(r)
+	for i, e := range errs {
+		t := 0.0
+		if d.max > 0 {
+			t = float64(e) / float64(d.max)
+		}
+		d.heatmap.SetRGBA(r.Min.X+i%r.Dx(), r.Min.Y+i/r.Dx(), colorext.Magma.Map(t))
+	}
+	return d
+}
+
+func absDiff(a, b uint32) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// writeArtifacts writes want, got and the heatmap to the artifacts
+// directory, logging their location.
+func writeArtifacts(t testing.TB, want, got, heatmap image.Image) {
+	t.Helper()
+	dir := os.Getenv(ArtifactsEnv)
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "colorexttest")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Logf("colorexttest: cannot write artifacts: %v", err)
+		return
+	}
+	base := filepath.Join(dir, sanitize(t.Name()))
+	for suffix, img := range map[string]image.Image{"want": want, "got": got, "diff": heatmap} {
+		if err := writePNG(base+"."+suffix+".png", img); err != nil {
+			t.Logf("colorexttest: cannot write artifacts: %v", err)
+			return
+		}
+	}
+	t.Logf("colorexttest: wrote %s.{want,got,diff}.png", base)
+}
+
+// sanitize makes a test name usable as a file name.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	return f.Close()
+}
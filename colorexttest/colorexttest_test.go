@@ -0,0 +1,124 @@
+package colorexttest
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	name   string
+	failed bool
+	fatal  bool
+	logs   []string
+}
+
+func (r *recorder) Helper()      {}
+func (r *recorder) Name() string { return r.name }
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+func (r *recorder) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func ramp(r image.Rectangle, offset int16) *colorext.GrayS16Image {
+	img := colorext.NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGrayS16(x, y, colorext.GrayS16{Y: int16(100*x-50*y) + offset})
+		}
+	}
+	return img
+}
+
+func TestAssertImagesEqual(t *testing.T) {
+	t.Setenv(ArtifactsEnv, t.TempDir())
+	r := image.Rect(0, 0, 4, 3)
+	off := ramp(r, 0)
+	off.SetGrayS16(2, 1, colorext.GrayS16{Y: off.GrayS16At(2, 1).Y + 5})
+
+	tests := []struct {
+		name      string
+		want, got image.Image
+		tolerance int
+		pass      bool
+	}{
+		{"identical", ramp(r, 0), ramp(r, 0), 0, true},
+		{"within tolerance", ramp(r, 0), ramp(r, 3), 3, true},
+		{"beyond tolerance", ramp(r, 0), ramp(r, 3), 2, false},
+		{"one pixel off", ramp(r, 0), off, 4, false},
+		{"different bounds", ramp(r, 0), ramp(image.Rect(0, 0, 4, 4), 0), 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{TB: t, name: t.Name()}
+			if got := AssertImagesEqual(rec, tt.want, tt.got, tt.tolerance); got != tt.pass || rec.failed == tt.pass {
+				t.Errorf("AssertImagesEqual = %v with failed = %v, want %v; logs: %q", got, rec.failed, tt.pass, rec.logs)
+			}
+		})
+	}
+}
+
+func TestAssertImagesEqual_Artifacts(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ArtifactsEnv, dir)
+	r := image.Rect(0, 0, 3, 2)
+	rec := &recorder{TB: t, name: "TestSomething/sub case"}
+	AssertImagesEqual(rec, ramp(r, 0), ramp(r, 10), 0)
+
+	for _, suffix := range []string{"want", "got", "diff"} {
+		path := filepath.Join(dir, "TestSomething_sub_case."+suffix+".png")
+		f, err := os.Open(path)
+		if err != nil {
+			t.Errorf("missing artifact: %v", err)
+			continue
+		}
+		m, _, err := image.Decode(f)
+		f.Close()
+		if err != nil || m.Bounds() != r {
+			t.Errorf("artifact %s: bounds %v, err %v", suffix, m.Bounds(), err)
+		}
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	t.Setenv(ArtifactsEnv, t.TempDir())
+	path := filepath.Join(t.TempDir(), "testdata", "ramp.png")
+	img := ramp(image.Rect(-2, 1, 3, 4), -1000)
+
+	// A missing golden file is fatal.
+	t.Setenv(UpdateEnv, "")
+	rec := &recorder{TB: t, name: t.Name()}
+	if AssertGolden(rec, path, img, 0) || !rec.fatal {
+		t.Errorf("missing golden file did not fail fatally; logs: %q", rec.logs)
+	}
+
+	// Updating writes the file, which then matches exactly.
+	t.Setenv(UpdateEnv, "1")
+	rec = &recorder{TB: t, name: t.Name()}
+	if !AssertGolden(rec, path, img, 0) || rec.failed {
+		t.Fatalf("updating golden file failed; logs: %q", rec.logs)
+	}
+	t.Setenv(UpdateEnv, "")
+	rec = &recorder{TB: t, name: t.Name()}
+	if !AssertGolden(rec, path, img, 0) || rec.failed {
+		t.Errorf("golden file does not match the image it was written from; logs: %q", rec.logs)
+	}
+
+	rec = &recorder{TB: t, name: t.Name()}
+	if AssertGolden(rec, path, ramp(img.Rect, -990), 5) || !rec.failed {
+		t.Errorf("changed image matched golden file; logs: %q", rec.logs)
+	}
+}
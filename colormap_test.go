@@ -0,0 +1,130 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLinearColormap_Map(t *testing.T) {
+	m := LinearColormap{{0, 0, 0, 255}, {200, 100, 0, 255}, {200, 200, 200, 255}}
+
+	tests := []struct {
+		name string
+		t    float64
+		want color.RGBA
+	}{
+		{"start", 0, color.RGBA{0, 0, 0, 255}},
+		{"quarter", 0.25, color.RGBA{100, 50, 0, 255}},
+		{"middle stop", 0.5, color.RGBA{200, 100, 0, 255}},
+		{"end", 1, color.RGBA{200, 200, 200, 255}},
+		{"below range clamps", -4, color.RGBA{0, 0, 0, 255}},
+		{"above range clamps", 7, color.RGBA{200, 200, 200, 255}},
+		{"NaN", math.NaN(), color.RGBA{0, 0, 0, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Map(tt.t); got != tt.want {
+				t.Errorf("Map(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+
+	single := LinearColormap{{1, 2, 3, 4}}
+	if got := single.Map(0.7); got != single[0] {
+		t.Errorf("single-stop Map = %v, want %v", got, single[0])
+	}
+}
+
+func TestBuiltinColormaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		cmap       Colormap
+		start, end color.RGBA
+	}{
+		{"Viridis", Viridis, color.RGBA{0x44, 0x01, 0x54, 0xff}, color.RGBA{0xfd, 0xe7, 0x25, 0xff}},
+		{"Magma", Magma, color.RGBA{0x00, 0x00, 0x04, 0xff}, color.RGBA{0xfc, 0xfd, 0xbf, 0xff}},
+		{"Jet", Jet, color.RGBA{0, 0, 128, 0xff}, color.RGBA{128, 0, 0, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmap.Map(0); got != tt.start {
+				t.Errorf("Map(0) = %v, want %v", got, tt.start)
+			}
+			if got := tt.cmap.Map(1); got != tt.end {
+				t.Errorf("Map(1) = %v, want %v", got, tt.end)
+			}
+		})
+	}
+
+	// Turbo runs from dark blue through green to dark red.
+	if c := Turbo.Map(0.5); c.G < 200 || c.R > c.G || c.B > c.G {
+		t.Errorf("Turbo.Map(0.5) = %v, want green-dominated", c)
+	}
+	if c := Turbo.Map(1); c.R < 100 || c.G > 30 || c.B > 30 {
+		t.Errorf("Turbo.Map(1) = %v, want dark red", c)
+	}
+	for i := 0; i <= 100; i++ {
+		if c := Turbo.Map(float64(i) / 100); c.A != 0xff {
+			t.Fatalf("Turbo.Map(%v) alpha = %d, want 255", float64(i)/100, c.A)
+		}
+	}
+}
+
+func TestRenderColormap(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(-1, 0, 3, 1))
+	src.SetGrayS16(-1, 0, GrayS16{Y: -32768})
+	src.SetGrayS16(0, 0, GrayS16{Y: -100})
+	src.SetGrayS16(1, 0, GrayS16{Y: 0})
+	src.SetGrayS16(2, 0, GrayS16{Y: 32767})
+
+	gray := LinearColormap{{0, 0, 0, 255}, {255, 255, 255, 255}}
+	dst := RenderColormap(src, gray, -100, 100)
+
+	if dst.Bounds() != src.Bounds() {
+		t.Fatalf("Bounds() = %v, want %v", dst.Bounds(), src.Bounds())
+	}
+	want := []uint8{0, 0, 128, 255}
+	for i, w := range want {
+		if got := dst.RGBAAt(i-1, 0).R; got != w {
+			t.Errorf("RGBAAt(%d, 0).R = %d, want %d", i-1, got, w)
+		}
+	}
+
+	// A degenerate range maps everything to the start of the colormap.
+	flat := RenderColormap(src, gray, 5, 5)
+	if got := flat.RGBAAt(2, 0).R; got != 0 {
+		t.Errorf("degenerate range RGBAAt(2, 0).R = %d, want 0", got)
+	}
+
+	// Invalid pixels stay transparent.
+	masked := NewMaskedGrayS16Image(src, -100)
+	masked.SetValid(2, 0, false)
+	dst = RenderColormap(masked, gray, -100, 100)
+	for x, want := range map[int]color.RGBA{-1: {0, 0, 0, 255}, 0: {}, 1: {128, 128, 128, 255}, 2: {}} {
+		if got := dst.RGBAAt(x, 0); got != want {
+			t.Errorf("masked RGBAAt(%d, 0) = %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestRenderColormapF32(t *testing.T) {
+	src := NewGrayF32Image(image.Rect(0, 0, 3, 1))
+	src.SetGrayF32(0, 0, GrayF32{Y: -1})
+	src.SetGrayF32(1, 0, GrayF32{Y: 0.5})
+	src.SetGrayF32(2, 0, GrayF32{Y: float32(math.NaN())})
+
+	dst := RenderColormapF32(src, Viridis, 0, 1)
+	if got := dst.RGBAAt(0, 0); got != Viridis.Map(0) {
+		t.Errorf("RGBAAt(0, 0) = %v, want %v", got, Viridis.Map(0))
+	}
+	if got := dst.RGBAAt(1, 0); got != Viridis.Map(0.5) {
+		t.Errorf("RGBAAt(1, 0) = %v, want %v", got, Viridis.Map(0.5))
+	}
+	if got := dst.RGBAAt(2, 0); got != Viridis.Map(0) {
+		t.Errorf("RGBAAt(2, 0) = %v, want %v for NaN", got, Viridis.Map(0))
+	}
+}
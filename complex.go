@@ -0,0 +1,351 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/cmplx"
+)
+
+// GrayC64 represents a complex grayscale value with float32 real and
+// imaginary parts, such as a SAR sample or a frequency-domain coefficient.
+type GrayC64 struct {
+	Y complex64
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayC64 color.
+// This implements the color.Color interface.
+// The magnitude of Y is displayed as a GrayF32 would display it: clamped to
+// [0, 1] and scaled to the range [0, 65535].
+func (c GrayC64) RGBA() (r, g, b, a uint32) {
+	return GrayC128{Y: complex128(c.Y)}.RGBA()
+}
+
+// GrayC64Model is the color model for GrayC64 colors. Other colors are
+// converted to a real value equal to their GrayF32 luminance.
+var GrayC64Model color.Model = color.ModelFunc(grayC64Model)
+
+// grayC64Model converts any color.Color to a GrayC64.
+func grayC64Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case GrayC64:
+		return c
+	case GrayC128:
+		return GrayC64{Y: complex64(c.Y)}
+	}
+	return GrayC64{Y: complex(GrayF32Model.Convert(c).(GrayF32).Y, 0)}
+}
+
+// GrayC128 represents a complex grayscale value with float64 real and
+// imaginary parts.
+type GrayC128 struct {
+	Y complex128
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayC128 color.
+// This implements the color.Color interface.
+// The magnitude of Y is clamped to [0, 1] and scaled to the range [0, 65535].
+// NaN values are treated as 0.
+func (c GrayC128) RGBA() (r, g, b, a uint32) {
+	v := unit16(cmplx.Abs(c.Y))
+	return v, v, v, 0xffff
+}
+
+// GrayC128Model is the color model for GrayC128 colors. Other colors are
+// converted to a real value equal to their GrayF32 luminance.
+var GrayC128Model color.Model = color.ModelFunc(grayC128Model)
+
+// grayC128Model converts any color.Color to a GrayC128.
+func grayC128Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case GrayC128:
+		return c
+	case GrayC64:
+		return GrayC128{Y: complex128(c.Y)}
+	}
+	return GrayC128{Y: complex(float64(GrayF32Model.Convert(c).(GrayF32).Y), 0)}
+}
+
+// GrayC64Image is an in-memory image whose At method returns GrayC64 values.
+type GrayC64Image struct {
+	// Pix holds the image's pixels, as pairs of big-endian float32 values
+	// holding the real and then the imaginary part.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the GrayC64Image's color model.
+func (p *GrayC64Image) ColorModel() color.Model {
+	return GrayC64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayC64Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayC64Image) At(x, y int) color.Color {
+	return p.GrayC64At(x, y)
+}
+
+// GrayC64At returns the GrayC64 color of the pixel at (x, y).
+func (p *GrayC64Image) GrayC64At(x, y int) GrayC64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayC64{}
+	}
+	i := p.PixOffset(x, y)
+	return GrayC64{Y: complex(getF32(p.Pix[i:]), getF32(p.Pix[i+4:]))}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayC64Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*8
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayC64Image) Set(x, y int, c color.Color) {
+	p.SetGrayC64(x, y, GrayC64Model.Convert(c).(GrayC64))
+}
+
+// SetGrayC64 sets the pixel at (x, y) to a given GrayC64 color.
+func (p *GrayC64Image) SetGrayC64(x, y int, c GrayC64) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	putF32(p.Pix[i:], real(c.Y))
+	putF32(p.Pix[i+4:], imag(c.Y))
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayC64Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayC64Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &GrayC64Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayC64Image is always fully opaque since the GrayC64 color model has no transparency.
+func (p *GrayC64Image) Opaque() bool {
+	return true
+}
+
+// Magnitude returns a new GrayF32Image holding the magnitude of each pixel.
+func (p *GrayC64Image) Magnitude() *GrayF32Image {
+	return complexView(p.Rect, p.at, cmplx.Abs)
+}
+
+// Phase returns a new GrayF32Image holding the phase of each pixel in
+// radians, in the range [-Pi, Pi].
+func (p *GrayC64Image) Phase() *GrayF32Image {
+	return complexView(p.Rect, p.at, cmplx.Phase)
+}
+
+// Real returns a new GrayF32Image holding the real part of each pixel.
+func (p *GrayC64Image) Real() *GrayF32Image {
+	return complexView(p.Rect, p.at, func(v complex128) float64 { return real(v) })
+}
+
+// Imag returns a new GrayF32Image holding the imaginary part of each pixel.
+func (p *GrayC64Image) Imag() *GrayF32Image {
+	return complexView(p.Rect, p.at, func(v complex128) float64 { return imag(v) })
+}
+
+func (p *GrayC64Image) at(x, y int) complex128 {
+	return complex128(p.GrayC64At(x, y).Y)
+}
+
+// NewGrayC64Image returns a new GrayC64Image with the given bounds.
+func NewGrayC64Image(r image.Rectangle, opts ...ImageOption) *GrayC64Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayC64Image{
+		Pix:    o.pix(8*w*h, 4),
+		Stride: 8 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 8)
+	return p
+}
+
+func (p *GrayC64Image) metadata() Metadata {
+	return p.Meta
+}
+
+// GrayC128Image is an in-memory image whose At method returns GrayC128 values.
+type GrayC128Image struct {
+	// Pix holds the image's pixels, as pairs of big-endian float64 values
+	// holding the real and then the imaginary part.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*16].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the GrayC128Image's color model.
+func (p *GrayC128Image) ColorModel() color.Model {
+	return GrayC128Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayC128Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayC128Image) At(x, y int) color.Color {
+	return p.GrayC128At(x, y)
+}
+
+// GrayC128At returns the GrayC128 color of the pixel at (x, y).
+func (p *GrayC128Image) GrayC128At(x, y int) GrayC128 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayC128{}
+	}
+	i := p.PixOffset(x, y)
+	return GrayC128{Y: complex(getF64(p.Pix[i:]), getF64(p.Pix[i+8:]))}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayC128Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*16
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayC128Image) Set(x, y int, c color.Color) {
+	p.SetGrayC128(x, y, GrayC128Model.Convert(c).(GrayC128))
+}
+
+// SetGrayC128 sets the pixel at (x, y) to a given GrayC128 color.
+func (p *GrayC128Image) SetGrayC128(x, y int, c GrayC128) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	putF64(p.Pix[i:], real(c.Y))
+	putF64(p.Pix[i+8:], imag(c.Y))
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayC128Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayC128Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &GrayC128Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayC128Image is always fully opaque since the GrayC128 color model has no transparency.
+func (p *GrayC128Image) Opaque() bool {
+	return true
+}
+
+// Magnitude returns a new GrayF32Image holding the magnitude of each pixel.
+func (p *GrayC128Image) Magnitude() *GrayF32Image {
+	return complexView(p.Rect, p.at, cmplx.Abs)
+}
+
+// Phase returns a new GrayF32Image holding the phase of each pixel in
+// radians, in the range [-Pi, Pi].
+func (p *GrayC128Image) Phase() *GrayF32Image {
+	return complexView(p.Rect, p.at, cmplx.Phase)
+}
+
+// Real returns a new GrayF32Image holding the real part of each pixel.
+func (p *GrayC128Image) Real() *GrayF32Image {
+	return complexView(p.Rect, p.at, func(v complex128) float64 { return real(v) })
+}
+
+// Imag returns a new GrayF32Image holding the imaginary part of each pixel.
+func (p *GrayC128Image) Imag() *GrayF32Image {
+	return complexView(p.Rect, p.at, func(v complex128) float64 { return imag(v) })
+}
+
+func (p *GrayC128Image) at(x, y int) complex128 {
+	return p.GrayC128At(x, y).Y
+}
+
+// NewGrayC128Image returns a new GrayC128Image with the given bounds.
+func NewGrayC128Image(r image.Rectangle, opts ...ImageOption) *GrayC128Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayC128Image{
+		Pix:    o.pix(16*w*h, 8),
+		Stride: 16 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 16)
+	return p
+}
+
+func (p *GrayC128Image) metadata() Metadata {
+	return p.Meta
+}
+
+// complexView returns a GrayF32Image covering r holding f applied to each
+// complex pixel read by at.
+func complexView(r image.Rectangle, at func(x, y int) complex128, f func(complex128) float64) *GrayF32Image {
+	dst := NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			putF32(dst.Pix[dst.PixOffset(x, y):], float32(f(at(x, y))))
+		}
+	}
+	return dst
+}
+
+// getF64 reads a big-endian float64 from b.
+func getF64(b []uint8) float64 {
+	_ = b[7] // bounds check hint to compiler
+	return math.Float64frombits(uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7]))
+}
+
+// putF64 writes v to b as a big-endian float64.
+func putF64(b []uint8, v float64) {
+	_ = b[7] // bounds check hint to compiler
+	bits := math.Float64bits(v)
+	for i := range 8 {
+		b[i] = uint8(bits >> (56 - 8*i))
+	}
+}
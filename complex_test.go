@@ -0,0 +1,157 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestGrayC_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want uint32
+	}{
+		{"c64 zero", GrayC64{}, 0},
+		{"c64 real half", GrayC64{Y: 0.5}, 0x8000},
+		{"c64 magnitude", GrayC64{Y: complex(0.3, 0.4)}, 0x8000},
+		{"c64 saturates", GrayC64{Y: complex(3, -4)}, 0xffff},
+		{"c128 imaginary", GrayC128{Y: complex(0, -1)}, 0xffff},
+		{"c128 NaN", GrayC128{Y: complex(math.NaN(), 0)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if r != tt.want || g != tt.want || b != tt.want || a != 0xffff {
+				t.Errorf("RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, 65535)", r, g, b, a, tt.want, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayCModels(t *testing.T) {
+	tests := []struct {
+		name  string
+		model color.Model
+		in    color.Color
+		want  color.Color
+	}{
+		{"c64 passthrough", GrayC64Model, GrayC64{Y: complex(1, 2)}, GrayC64{Y: complex(1, 2)}},
+		{"c64 from c128", GrayC64Model, GrayC128{Y: complex(-1, 0.5)}, GrayC64{Y: complex(-1, 0.5)}},
+		{"c64 from white", GrayC64Model, color.White, GrayC64{Y: 1}},
+		{"c128 passthrough", GrayC128Model, GrayC128{Y: complex(3, -4)}, GrayC128{Y: complex(3, -4)}},
+		{"c128 from c64", GrayC128Model, GrayC64{Y: complex(0.25, 2)}, GrayC128{Y: complex(0.25, 2)}},
+		{"c128 from black", GrayC128Model, color.Black, GrayC128{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Convert(tt.in); got != tt.want {
+				t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayC64Image(t *testing.T) {
+	r := image.Rect(1, 1, 4, 3)
+	img := NewGrayC64Image(r)
+	if img.Stride != 24 || len(img.Pix) != 48 {
+		t.Fatalf("Stride, len(Pix) = %d, %d, want 24, 48", img.Stride, len(img.Pix))
+	}
+	if img.Bounds() != r || img.ColorModel() != GrayC64Model || !img.Opaque() {
+		t.Error("unexpected Bounds, ColorModel or Opaque")
+	}
+
+	img.SetGrayC64(2, 2, GrayC64{Y: complex(-1.5, 2)})
+	if got := img.GrayC64At(2, 2); got.Y != complex(-1.5, 2) {
+		t.Errorf("GrayC64At = %v, want (-1.5+2i)", got.Y)
+	}
+	if i := img.PixOffset(2, 2); getF32(img.Pix[i+4:]) != 2 {
+		t.Errorf("imaginary part not stored after real part: % x", img.Pix[i:i+8])
+	}
+	img.Set(1, 1, color.White)
+	if got := img.At(1, 1); got != (GrayC64{Y: 1}) {
+		t.Errorf("At after Set = %v, want {(1+0i)}", got)
+	}
+
+	img.SetGrayC64(0, 0, GrayC64{Y: 5})
+	if got := img.GrayC64At(0, 0); got.Y != 0 {
+		t.Errorf("out of bounds GrayC64At = %v, want 0", got.Y)
+	}
+
+	sub := img.SubImage(image.Rect(2, 2, 4, 3)).(*GrayC64Image)
+	if got := sub.GrayC64At(2, 2); got.Y != complex(-1.5, 2) {
+		t.Errorf("SubImage GrayC64At = %v, want (-1.5+2i)", got.Y)
+	}
+	if empty := img.SubImage(image.Rect(9, 9, 10, 10)); !empty.Bounds().Empty() {
+		t.Errorf("disjoint SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
+
+func TestGrayC128Image(t *testing.T) {
+	r := image.Rect(0, 0, 2, 2)
+	img := NewGrayC128Image(r)
+	if img.Stride != 32 || len(img.Pix) != 64 {
+		t.Fatalf("Stride, len(Pix) = %d, %d, want 32, 64", img.Stride, len(img.Pix))
+	}
+	if img.Bounds() != r || img.ColorModel() != GrayC128Model || !img.Opaque() {
+		t.Error("unexpected Bounds, ColorModel or Opaque")
+	}
+
+	v := complex(math.Pi, -1e-300)
+	img.SetGrayC128(1, 0, GrayC128{Y: v})
+	if got := img.GrayC128At(1, 0); got.Y != v {
+		t.Errorf("GrayC128At = %v, want %v", got.Y, v)
+	}
+	img.Set(0, 1, GrayC64{Y: complex(0.5, 0.25)})
+	if got := img.At(0, 1); got != (GrayC128{Y: complex(0.5, 0.25)}) {
+		t.Errorf("At after Set = %v, want {(0.5+0.25i)}", got)
+	}
+
+	sub := img.SubImage(image.Rect(1, 0, 2, 1)).(*GrayC128Image)
+	sub.SetGrayC128(1, 0, GrayC128{Y: 7i})
+	if got := img.GrayC128At(1, 0); got.Y != 7i {
+		t.Errorf("SubImage does not share pixels: got %v, want 7i", got.Y)
+	}
+}
+
+func TestGrayCViews(t *testing.T) {
+	r := image.Rect(0, 0, 2, 1)
+	c64 := NewGrayC64Image(r)
+	c64.SetGrayC64(0, 0, GrayC64{Y: complex(3, 4)})
+	c64.SetGrayC64(1, 0, GrayC64{Y: complex(0, -2)})
+	c128 := NewGrayC128Image(r)
+	c128.SetGrayC128(0, 0, GrayC128{Y: complex(3, 4)})
+	c128.SetGrayC128(1, 0, GrayC128{Y: complex(0, -2)})
+
+	type views interface {
+		Magnitude() *GrayF32Image
+		Phase() *GrayF32Image
+		Real() *GrayF32Image
+		Imag() *GrayF32Image
+	}
+	tests := []struct {
+		name string
+		view func(views) *GrayF32Image
+		want [2]float64
+	}{
+		{"Magnitude", views.Magnitude, [2]float64{5, 2}},
+		{"Phase", views.Phase, [2]float64{math.Atan2(4, 3), -math.Pi / 2}},
+		{"Real", views.Real, [2]float64{3, 0}},
+		{"Imag", views.Imag, [2]float64{4, -2}},
+	}
+	for _, img := range []views{c64, c128} {
+		for _, tt := range tests {
+			got := tt.view(img)
+			if got.Rect != r {
+				t.Errorf("%T.%s bounds = %v, want %v", img, tt.name, got.Rect, r)
+			}
+			for x, want := range tt.want {
+				if v := float64(got.GrayF32At(x, 0).Y); !approxEqual(v, want, 1e-6) {
+					t.Errorf("%T.%s at x=%d = %v, want %v", img, tt.name, x, v, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package colorext
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"sync"
+)
+
+// Compression identifies the compressor of a compressed raster.
+type Compression uint8
+
+// Compressions. Only CompressionNone and CompressionDeflate are built in;
+// others must be registered with RegisterCompression before use.
+const (
+	CompressionNone Compression = iota
+	CompressionDeflate
+	// CompressionZstd is reserved for Zstandard, which is not in the
+	// standard library. Register an implementation to use it.
+	CompressionZstd
+)
+
+// compressor creates the streams of a Compression.
+type compressor struct {
+	newWriter func(io.Writer) (io.WriteCloser, error)
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[Compression]compressor{
+		CompressionNone: {
+			newWriter: func(w io.Writer) (io.WriteCloser, error) { return nopWriteCloser{w}, nil },
+			newReader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil },
+		},
+		CompressionDeflate: {
+			newWriter: func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.DefaultCompression) },
+			newReader: func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+		},
+	}
+)
+
+// RegisterCompression makes c available to WriteCompressed and
+// ReadCompressed, replacing any previous registration. newWriter and
+// newReader wrap a stream with the compressor and decompressor; Close on
+// the writer must flush all data without closing the underlying writer.
+func RegisterCompression(c Compression, newWriter func(io.Writer) (io.WriteCloser, error), newReader func(io.Reader) (io.ReadCloser, error)) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c] = compressor{newWriter: newWriter, newReader: newReader}
+}
+
+func lookupCompression(c Compression) (compressor, error) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	comp, ok := compressors[c]
+	if !ok {
+		return compressor{}, fmt.Errorf("colorext: compression %d is not registered", c)
+	}
+	return comp, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressedMagic starts every compressed raster.
+const compressedMagic = "CXRZ"
+
+// compressedVersion is the version of the compressed raster format.
+const compressedVersion = 1
+
+// WriteCompressed writes img, which must be one of the gray image types,
+// to w as a compressed raster: the magic "CXRZ", a version byte, the
+// DType, the byte order (always big-endian), the Compression, the four
+// coordinates of the bounds and the row stride in bytes as varints, and
+// then the compressed pixel rows. Rows are stored without stride padding,
+// so the stride is always the width times the sample size.
+func WriteCompressed(w io.Writer, img image.Image, c Compression) error {
+	d, pix, stride, ok := grayLayout(img)
+	if !ok {
+		return fmt.Errorf("%w: cannot write %T as a compressed raster", ErrUnsupportedDType, img)
+	}
+	comp, err := lookupCompression(c)
+	if err != nil {
+		return err
+	}
+	r := img.Bounds()
+	n := d.Size() * r.Dx()
+
+	hdr := append([]byte(compressedMagic), compressedVersion, byte(d), bigEndian, byte(c))
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y, n} {
+		hdr = binary.AppendVarint(hdr, int64(v))
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write(hdr)
+	cw, err := comp.newWriter(bw)
+	if err != nil {
+		return err
+	}
+	for y := 0; y < r.Dy(); y++ {
+		if _, err := cw.Write(pix[y*stride : y*stride+n]); err != nil {
+			return err
+		}
+	}
+	if err := cw.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// MaxCompressedPixels is the largest raster, in pixels, that ReadCompressed
+// decodes: 256 Mi pixels. A header of a few bytes can declare far larger
+// rasters, so they are rejected before their pixels are allocated.
+const MaxCompressedPixels = 1 << 28
+
+// ReadCompressed reads a raster written by WriteCompressed, returning an
+// image of the type it was written from. Malformed input, and rasters of
+// more than MaxCompressedPixels pixels or 2 GiB, yield a *DecodeError.
+func ReadCompressed(r io.Reader) (image.Image, error) {
+	buf := bufio.NewReader(r)
+	br := &offsetReader{r: buf}
+	d, comp, rect, err := readCompressedHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	// Offsets within the compressed payload are not meaningful, so errors
+	// in it are reported at its start.
+	data := br.off
+	cr, err := comp.newReader(buf)
+	if err != nil {
+		return nil, decodeError("compressed raster", data, err)
+	}
+	defer cr.Close()
+	img, pix := newGrayImage(d, rect)
+	if _, err := io.ReadFull(cr, pix); err != nil {
+		return nil, decodeError("compressed raster", data, err)
+	}
+	return img, nil
+}
+
+// readCompressedHeader reads the header of a compressed raster from br,
+// returning its sample type, compressor and bounds, which may be empty.
+func readCompressedHeader(br *offsetReader) (DType, compressor, image.Rectangle, error) {
+	fail := func(off int64, err error) (DType, compressor, image.Rectangle, error) {
+		return 0, compressor{}, image.Rectangle{}, decodeError("compressed raster", off, err)
+	}
+	var hdr [len(compressedMagic) + 4]byte
+	for i := range hdr {
+		c, err := br.ReadByte()
+		if err != nil {
+			return fail(br.off, err)
+		}
+		hdr[i] = c
+	}
+	if string(hdr[:len(compressedMagic)]) != compressedMagic {
+		return fail(0, errors.New("not a compressed raster"))
+	}
+	h := hdr[len(compressedMagic):]
+	off := int64(len(compressedMagic))
+	if h[0] != compressedVersion {
+		return fail(off, fmt.Errorf("unsupported version %d", h[0]))
+	}
+	d := DType(h[1])
+	if d.Size() == 0 {
+		return fail(off+1, fmt.Errorf("%w %d", ErrUnsupportedDType, h[1]))
+	}
+	if h[2] != bigEndian {
+		return fail(off+2, fmt.Errorf("unsupported byte order %d", h[2]))
+	}
+	comp, err := lookupCompression(Compression(h[3]))
+	if err != nil {
+		return fail(off+3, err)
+	}
+	var c [5]int
+	for i := range c {
+		v, err := binary.ReadVarint(br)
+		if err != nil || v != int64(int(v)) {
+			return fail(br.off, err)
+		}
+		c[i] = int(v)
+	}
+	if c[2] < c[0] || c[3] < c[1] {
+		return fail(br.off, fmt.Errorf("%w: malformed bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, c[0], c[1], c[2], c[3]))
+	}
+	w, ht := uint64(c[2])-uint64(c[0]), uint64(c[3])-uint64(c[1])
+	if w != 0 && (ht > MaxCompressedPixels/w || ht > math.MaxInt32/(w*uint64(d.Size()))) {
+		return fail(br.off, fmt.Errorf("raster of %d×%d pixels is too large", w, ht))
+	}
+	if uint64(c[4]) != w*uint64(d.Size()) {
+		return fail(br.off, fmt.Errorf("%w: stride %d for width %d", ErrStrideMismatch, c[4], w))
+	}
+	if w == 0 || ht == 0 {
+		return d, comp, image.Rectangle{}, nil
+	}
+	return d, comp, image.Rect(c[0], c[1], c[2], c[3]), nil
+}
@@ -0,0 +1,166 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	r := image.Rect(-2, 3, 9, 10)
+	rng := rand.New(rand.NewSource(5))
+	s16 := randomGrayS16(image.Rect(0, 0, 20, 10), 2)
+	images := []image.Image{
+		NewGrayS16Image(r),
+		s16.SubImage(image.Rect(3, 2, 15, 9)),
+		NewGrayF32Image(r),
+		NewGrayU32Image(r),
+		NewGrayU64Image(r),
+		NewGrayS64Image(r),
+		NewGrayC64Image(r),
+		NewGrayC128Image(r),
+		NewGrayS16Image(image.Rectangle{}),
+	}
+	for _, img := range images {
+		_, pix, _, _ := grayLayout(img)
+		rng.Read(pix)
+		for _, c := range []Compression{CompressionNone, CompressionDeflate} {
+			var buf bytes.Buffer
+			if err := WriteCompressed(&buf, img, c); err != nil {
+				t.Fatalf("%T compression %d: %v", img, c, err)
+			}
+			got, err := ReadCompressed(&buf)
+			if err != nil {
+				t.Fatalf("%T compression %d: %v", img, c, err)
+			}
+			if !Equal(got, img) {
+				t.Errorf("%T compression %d: round trip mismatch", img, c)
+			}
+		}
+	}
+}
+
+func TestCompressedDeflateShrinks(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: int16(x + y)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteCompressed(&buf, img, CompressionDeflate); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > len(img.Pix)/4 {
+		t.Errorf("compressed to %d bytes from %d", buf.Len(), len(img.Pix))
+	}
+}
+
+// xorStream is a trivial reversible transform standing in for a
+// registered compressor.
+type xorStream struct {
+	w io.Writer
+	r io.Reader
+}
+
+func (s xorStream) Write(p []byte) (int, error) {
+	q := bytes.Clone(p)
+	for i := range q {
+		q[i] ^= 0x5a
+	}
+	return s.w.Write(q)
+}
+
+func (s xorStream) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= 0x5a
+	}
+	return n, err
+}
+
+func (xorStream) Close() error { return nil }
+
+func TestRegisterCompression(t *testing.T) {
+	const xor Compression = 200
+	img := randomGrayS16(image.Rect(0, 0, 7, 5), 8)
+	var buf bytes.Buffer
+	if err := WriteCompressed(&buf, img, xor); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("unregistered compression: got %v", err)
+	}
+
+	RegisterCompression(xor,
+		func(w io.Writer) (io.WriteCloser, error) { return xorStream{w: w}, nil },
+		func(r io.Reader) (io.ReadCloser, error) { return xorStream{r: r}, nil })
+	defer func() {
+		compressorsMu.Lock()
+		delete(compressors, xor)
+		compressorsMu.Unlock()
+	}()
+	if err := WriteCompressed(&buf, img, xor); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadCompressed(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(got, img) {
+		t.Error("round trip mismatch")
+	}
+}
+
+func TestReadCompressedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCompressed(&buf, randomGrayS16(image.Rect(0, 0, 8, 8), 1), CompressionDeflate); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	patch := func(i int, v byte) []byte {
+		b := bytes.Clone(good)
+		b[i] = v
+		return b
+	}
+	// A header declaring a huge raster, with no pixels following it.
+	huge := append([]byte(compressedMagic), compressedVersion, byte(DTypeInt16), bigEndian, byte(CompressionNone))
+	for _, v := range []int64{0, 0, 1 << 20, 1 << 20, 2 << 20} {
+		huge = binary.AppendVarint(huge, v)
+	}
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		is     error
+		offset int64
+	}{
+		{"magic", patch(0, 'X'), "not a compressed raster", nil, 0},
+		{"version", patch(4, 9), "version", nil, 4},
+		{"dtype", patch(5, 99), "pixel type", ErrUnsupportedDType, 5},
+		{"compression", patch(7, 77), "not registered", nil, 7},
+		{"stride", patch(12, 4), "stride", ErrStrideMismatch, 13},
+		{"truncated", good[:len(good)-10], "unexpected EOF", io.ErrUnexpectedEOF, 13},
+		{"empty", nil, "unexpected EOF", io.ErrUnexpectedEOF, 0},
+		{"too large", huge, "too large", nil, int64(len(huge))},
+	}
+	for _, tt := range tests {
+		_, err := ReadCompressed(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+			continue
+		}
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Offset != tt.offset {
+			t.Errorf("%s: got %#v, want a DecodeError at offset %d", tt.name, err, tt.offset)
+		}
+		if tt.is != nil && !errors.Is(err, tt.is) {
+			t.Errorf("%s: %v does not wrap %v", tt.name, err, tt.is)
+		}
+	}
+	if err := WriteCompressed(io.Discard, image.NewGray(image.Rect(0, 0, 1, 1)), CompressionNone); !errors.Is(err, ErrUnsupportedDType) {
+		t.Errorf("*image.Gray: got %v, want %v", err, ErrUnsupportedDType)
+	}
+}
@@ -0,0 +1,216 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// Polyline is a sequence of connected points. A closed polyline repeats its
+// first point at the end.
+type Polyline []PointF
+
+// Closed reports whether the polyline ends where it starts.
+func (l Polyline) Closed() bool {
+	return len(l) > 2 && l[0] == l[len(l)-1]
+}
+
+// Contours extracts isolines from img using marching squares. The result
+// holds one slice of polylines per entry in levels, in the same order.
+//
+// Samples are read as raw values: GrayS16Image and GrayF32Image pixels use
+// their stored values, stdlib gray images their unsigned values, and other
+// images their GrayF32 luminance. Vertices use pixel-center coordinates.
+// Cells touching a NaN or invalid pixel are skipped. Saddle cells are
+// disambiguated using the average of their four corners.
+func Contours(img image.Image, levels []float64) [][]Polyline {
+	r := img.Bounds()
+	at := scalarSampler(img)
+
+	// Read the grid once; it is visited once per level.
+	w, h := r.Dx(), r.Dy()
+	grid := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			grid[y*w+x] = at(r.Min.X+x, r.Min.Y+y)
+		}
+	}
+
+	out := make([][]Polyline, len(levels))
+	for i, level := range levels {
+		segs := marchingSquares(grid, w, h, level)
+		lines := joinSegments(segs)
+		for _, l := range lines {
+			for j := range l {
+				l[j].X += float64(r.Min.X) + 0.5
+				l[j].Y += float64(r.Min.Y) + 0.5
+			}
+		}
+		out[i] = lines
+	}
+	return out
+}
+
+// gridEdge identifies the edge between grid sample (x, y) and its right
+// neighbor (vertical == false) or its lower neighbor (vertical == true).
+type gridEdge struct {
+	x, y     int
+	vertical bool
+}
+
+// contourSegment connects the isoline crossings on two cell edges.
+type contourSegment struct {
+	e    [2]gridEdge
+	p    [2]PointF
+	used bool
+}
+
+// marchingSquares returns the isoline segments of a w×h grid at level.
+// Coordinates are relative to the grid origin.
+func marchingSquares(grid []float64, w, h int, level float64) []contourSegment {
+	var segs []contourSegment
+	for y := 0; y+1 < h; y++ {
+		for x := 0; x+1 < w; x++ {
+			a, b := grid[y*w+x], grid[y*w+x+1]
+			c, d := grid[(y+1)*w+x+1], grid[(y+1)*w+x]
+			if math.IsNaN(a) || math.IsNaN(b) || math.IsNaN(c) || math.IsNaN(d) {
+				continue
+			}
+
+			idx := 0
+			if a >= level {
+				idx |= 8
+			}
+			if b >= level {
+				idx |= 4
+			}
+			if c >= level {
+				idx |= 2
+			}
+			if d >= level {
+				idx |= 1
+			}
+			if idx == 0 || idx == 15 {
+				continue
+			}
+
+			top := gridEdge{x, y, false}
+			right := gridEdge{x + 1, y, true}
+			bottom := gridEdge{x, y + 1, false}
+			left := gridEdge{x, y, true}
+			cross := func(e gridEdge) PointF {
+				v0, v1 := grid[e.y*w+e.x], grid[e.y*w+e.x+1]
+				if e.vertical {
+					v1 = grid[(e.y+1)*w+e.x]
+				}
+				t := 0.5
+				if v1 != v0 {
+					t = (level - v0) / (v1 - v0)
+				}
+				if e.vertical {
+					return PointF{float64(e.x), float64(e.y) + t}
+				}
+				return PointF{float64(e.x) + t, float64(e.y)}
+			}
+			add := func(e0, e1 gridEdge) {
+				segs = append(segs, contourSegment{e: [2]gridEdge{e0, e1}, p: [2]PointF{cross(e0), cross(e1)}})
+			}
+
+			centerHigh := (a+b+c+d)/4 >= level
+			switch idx {
+			case 1, 14:
+				add(left, bottom)
+			case 2, 13:
+				add(bottom, right)
+			case 3, 12:
+				add(left, right)
+			case 4, 11:
+				add(top, right)
+			case 6, 9:
+				add(top, bottom)
+			case 7, 8:
+				add(top, left)
+			case 5:
+				if centerHigh {
+					add(top, left)
+					add(right, bottom)
+				} else {
+					add(top, right)
+					add(left, bottom)
+				}
+			case 10:
+				if centerHigh {
+					add(top, right)
+					add(left, bottom)
+				} else {
+					add(top, left)
+					add(right, bottom)
+				}
+			}
+		}
+	}
+	return segs
+}
+
+// joinSegments links segments that share an edge crossing into polylines.
+func joinSegments(segs []contourSegment) []Polyline {
+	byEdge := make(map[gridEdge][]int, 2*len(segs))
+	for i, s := range segs {
+		byEdge[s.e[0]] = append(byEdge[s.e[0]], i)
+		byEdge[s.e[1]] = append(byEdge[s.e[1]], i)
+	}
+
+	// next returns an unused segment touching e, and the index of its other end.
+	next := func(e gridEdge) (int, int) {
+		for _, j := range byEdge[e] {
+			if segs[j].used {
+				continue
+			}
+			if segs[j].e[0] == e {
+				return j, 1
+			}
+			return j, 0
+		}
+		return -1, 0
+	}
+
+	var lines []Polyline
+	for i := range segs {
+		if segs[i].used {
+			continue
+		}
+		segs[i].used = true
+		start, end := segs[i].e[0], segs[i].e[1]
+		fwd := Polyline{segs[i].p[0], segs[i].p[1]}
+
+		// Extend forward from the end.
+		for {
+			j, k := next(end)
+			if j < 0 {
+				break
+			}
+			segs[j].used = true
+			fwd = append(fwd, segs[j].p[k])
+			end = segs[j].e[k]
+		}
+		// Extend backward from the start unless the line closed on itself.
+		var back Polyline
+		if end != start {
+			for {
+				j, k := next(start)
+				if j < 0 {
+					break
+				}
+				segs[j].used = true
+				back = append(back, segs[j].p[k])
+				start = segs[j].e[k]
+			}
+		}
+
+		line := make(Polyline, 0, len(back)+len(fwd))
+		for k := len(back) - 1; k >= 0; k-- {
+			line = append(line, back[k])
+		}
+		lines = append(lines, append(line, fwd...))
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestContours_ClosedLoop(t *testing.T) {
+	// A single raised pixel in the middle of a 3x3 grid produces a closed
+	// diamond around its center.
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 3))
+	img.SetGrayS16(1, 1, GrayS16{Y: 100})
+
+	got := Contours(img, []float64{50})
+	if len(got) != 1 || len(got[0]) != 1 {
+		t.Fatalf("Contours returned %v, want one polyline for one level", got)
+	}
+	line := got[0][0]
+	if !line.Closed() {
+		t.Fatalf("polyline %v is not closed", line)
+	}
+	if len(line) != 5 {
+		t.Fatalf("len(polyline) = %d, want 5", len(line))
+	}
+
+	want := map[PointF]bool{
+		{1.5, 1}: true, {2, 1.5}: true, {1.5, 2}: true, {1, 1.5}: true,
+	}
+	for _, p := range line[:4] {
+		if !want[p] {
+			t.Errorf("unexpected vertex %v", p)
+		}
+		delete(want, p)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing vertices %v", want)
+	}
+}
+
+func TestContours_OpenLineWithInterpolation(t *testing.T) {
+	// A horizontal ramp crosses level 25 a quarter of the way between the
+	// first two columns.
+	img := NewGrayF32Image(image.Rect(10, 20, 13, 23))
+	for y := 20; y < 23; y++ {
+		img.SetGrayF32(10, y, GrayF32{Y: 0})
+		img.SetGrayF32(11, y, GrayF32{Y: 100})
+		img.SetGrayF32(12, y, GrayF32{Y: 200})
+	}
+
+	got := Contours(img, []float64{25, 1000})
+	if len(got) != 2 {
+		t.Fatalf("len(Contours) = %d, want 2", len(got))
+	}
+	if len(got[1]) != 0 {
+		t.Errorf("level above all samples produced %v", got[1])
+	}
+	if len(got[0]) != 1 {
+		t.Fatalf("level 25 produced %d polylines, want 1", len(got[0]))
+	}
+	line := got[0][0]
+	if line.Closed() || len(line) != 3 {
+		t.Fatalf("polyline = %v, want an open line of 3 points", line)
+	}
+	for _, p := range line {
+		if math.Abs(p.X-10.75) > 1e-9 {
+			t.Errorf("vertex %v has X = %v, want 10.75", p, p.X)
+		}
+	}
+}
+
+func TestContours_SkipsInvalid(t *testing.T) {
+	m := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 3, 3)), -9999)
+	m.SetGrayS16(1, 1, GrayS16{Y: 100})
+	// Invalidating every corner ring removes all cells.
+	m.Invalidate(0, 0)
+	m.Invalidate(2, 2)
+	m.Invalidate(0, 2)
+	m.Invalidate(2, 0)
+
+	got := Contours(m, []float64{50})
+	if len(got[0]) != 0 {
+		t.Errorf("Contours over invalid cells = %v, want none", got[0])
+	}
+}
+
+func TestContours_Saddle(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 2, 2))
+	img.SetGrayS16(0, 0, GrayS16{Y: 10})
+	img.SetGrayS16(1, 1, GrayS16{Y: 10})
+
+	// Center average is 5: below 6 the diagonal connects, above it separates.
+	for _, level := range []float64{4, 6} {
+		got := Contours(img, []float64{level})
+		if len(got[0]) != 2 {
+			t.Errorf("level %v produced %d polylines, want 2", level, len(got[0]))
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package colorext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+)
+
+// CSVOptions controls WriteCSV. The zero value writes every pixel as
+// comma-separated values without headers.
+type CSVOptions struct {
+	// Comma is the field delimiter, such as '\t' for TSV. Zero means ','.
+	Comma rune
+	// Header adds a first row of x coordinates and a first column of y
+	// coordinates, so the cells can be located in a spreadsheet. The corner
+	// cell holds "y\x".
+	Header bool
+	// Region limits the output to the part of the image inside it. The zero
+	// Rectangle means the whole image.
+	Region image.Rectangle
+}
+
+// csvCorner labels the corner cell of a CSV header.
+const csvCorner = `y\x`
+
+// WriteCSV writes the pixels of img to w as delimited text, one row of the
+// image per line. Values are the raw samples scalarSampler reads, so signed
+// images write their signed values: integer images as integers and others
+// in the shortest form that round-trips a float32. NaN and invalid pixels
+// are written as empty cells. WriteCSV is meant for small images. opts may
+// be nil.
+func WriteCSV(w io.Writer, img image.Image, opts *CSVOptions) error {
+	var o CSVOptions
+	if opts != nil {
+		o = *opts
+	}
+	r := img.Bounds()
+	if o.Region != (image.Rectangle{}) {
+		r = r.Intersect(o.Region)
+	}
+	at := scalarSampler(img)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 32)
+	}
+	switch img.(type) {
+	case *GrayS16Image, *MaskedGrayS16Image, *image.Gray, *image.Gray16:
+		format = func(v float64) string {
+			return strconv.FormatInt(int64(v), 10)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if o.Comma != 0 {
+		cw.Comma = o.Comma
+	}
+	var rec []string
+	if o.Header {
+		rec = append(rec, csvCorner)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			rec = append(rec, strconv.Itoa(x))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		rec = rec[:0]
+		if o.Header {
+			rec = append(rec, strconv.Itoa(y))
+		}
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := at(x, y)
+			if math.IsNaN(v) {
+				rec = append(rec, "")
+				continue
+			}
+			rec = append(rec, format(v))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSVGrayS16 reads a GrayS16Image from delimited text as written by
+// WriteCSV. The delimiter is a tab if the first line contains one and a
+// comma otherwise. If the first cell is "y\x" the first row and column are
+// read as coordinates and set the image's origin; otherwise the image starts
+// at (0, 0). Every row must have the same number of cells, each holding an
+// integer in the int16 range. Malformed input yields a *DecodeError.
+func ReadCSVGrayS16(r io.Reader) (*GrayS16Image, error) {
+	br := bufio.NewReader(r)
+	cr := csv.NewReader(br)
+	if first, err := br.Peek(br.Size()); len(first) > 0 {
+		if i := bytes.IndexByte(first, '\n'); i >= 0 {
+			first = first[:i]
+		}
+		if bytes.IndexByte(first, '\t') >= 0 {
+			cr.Comma = '\t'
+		}
+	} else if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var (
+		origin image.Point
+		header bool
+		rows   [][]int16
+		// data is the offset of the first row of pixels.
+		data int64
+	)
+	for n := 0; ; n++ {
+		start := cr.InputOffset()
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				return nil, decodeError("CSV", start, err)
+			}
+			return nil, err
+		}
+		if n == 0 && rec[0] == csvCorner {
+			header = true
+			if len(rec) > 1 {
+				if origin.X, err = strconv.Atoi(rec[1]); err != nil {
+					return nil, decodeError("CSV", csvFieldOffset(cr, start, 1), fmt.Errorf("header: %w", err))
+				}
+				if origin.X > math.MaxInt-(len(rec)-1) {
+					return nil, decodeError("CSV", csvFieldOffset(cr, start, 1), fmt.Errorf("%w: x coordinates out of range", ErrInvalidLayout))
+				}
+			}
+			continue
+		}
+		skip := 0
+		if header {
+			if len(rows) == 0 {
+				if origin.Y, err = strconv.Atoi(rec[0]); err != nil {
+					return nil, decodeError("CSV", start, fmt.Errorf("header: %w", err))
+				}
+				data = start
+			}
+			skip = 1
+		}
+		row := make([]int16, len(rec)-skip)
+		for x, s := range rec[skip:] {
+			v, err := strconv.ParseInt(s, 10, 16)
+			if err != nil {
+				return nil, decodeError("CSV", csvFieldOffset(cr, start, skip+x), fmt.Errorf("cell (%d, %d): %w", origin.X+x, origin.Y+len(rows), err))
+			}
+			row[x] = int16(v)
+		}
+		rows = append(rows, row)
+	}
+
+	// WriteCSV writes only the header for an empty region.
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return NewGrayS16Image(image.Rectangle{}), nil
+	}
+	w, h := len(rows[0]), len(rows)
+	if origin.Y > math.MaxInt-h {
+		return nil, decodeError("CSV", data, fmt.Errorf("%w: y coordinates out of range", ErrInvalidLayout))
+	}
+	img := NewGrayS16Image(image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))})
+	for y, row := range rows {
+		for x, v := range row {
+			img.SetGrayS16(origin.X+x, origin.Y+y, GrayS16{Y: v})
+		}
+	}
+	return img, nil
+}
+
+// csvFieldOffset returns the input offset of field i of the record cr last
+// read, which started at offset start. A field after a quoted line break is
+// reported at the start of the record.
+func csvFieldOffset(cr *csv.Reader, start int64, i int) int64 {
+	line0, _ := cr.FieldPos(0)
+	line, col := cr.FieldPos(i)
+	if line != line0 {
+		return start
+	}
+	return start + int64(col-1)
+}
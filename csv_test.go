@@ -0,0 +1,101 @@
+package colorext
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(-1, 2, 2, 4))
+	for i := range 6 {
+		img.SetGrayS16(-1+i%3, 2+i/3, GrayS16{Y: int16(i*11000 - 30000)})
+	}
+	f32 := NewGrayF32Image(image.Rect(0, 0, 3, 1))
+	f32.SetGrayF32(0, 0, GrayF32{Y: 0.1})
+	f32.SetGrayF32(1, 0, GrayF32{Y: float32(math.NaN())})
+	f32.SetGrayF32(2, 0, GrayF32{Y: 1e10})
+
+	tests := []struct {
+		name string
+		img  image.Image
+		opts *CSVOptions
+		want string
+	}{
+		{"plain", img, nil, "-30000,-19000,-8000\n3000,14000,25000\n"},
+		{"header", img, &CSVOptions{Header: true}, "y\\x,-1,0,1\n2,-30000,-19000,-8000\n3,3000,14000,25000\n"},
+		{"tsv region", img, &CSVOptions{Comma: '\t', Header: true, Region: image.Rect(0, 3, 9, 9)}, "y\\x\t0\t1\n3\t14000\t25000\n"},
+		{"float", f32, nil, "0.1,,1e+10\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, tt.img, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSVGrayS16(t *testing.T) {
+	src := randomGrayS16(image.Rect(-3, 5, 4, 9), 2)
+	for _, opts := range []*CSVOptions{nil, {Header: true}, {Comma: '\t', Header: true}, {Comma: '\t'}} {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, src, opts); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadCSVGrayS16(&buf)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		want := src
+		if opts == nil || !opts.Header {
+			want = shift(src)
+		}
+		if got.Rect != want.Rect || !Equal(got, want) {
+			t.Errorf("%+v: round trip mismatch, bounds %v", opts, got.Rect)
+		}
+	}
+
+	// A region of one pixel or none leaves the header with one data row or
+	// none.
+	for _, region := range []image.Rectangle{image.Rect(0, 6, 1, 7), image.Rect(20, 20, 21, 21)} {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, src, &CSVOptions{Header: true, Region: region}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadCSVGrayS16(&buf)
+		if err != nil {
+			t.Fatalf("region %v: %v", region, err)
+		}
+		want := src.SubImage(region)
+		if got.Rect != want.Bounds() && !(got.Rect.Empty() && want.Bounds().Empty()) || !got.Rect.Empty() && !Equal(got, want) {
+			t.Errorf("region %v: round trip gave %v", region, got.Rect)
+		}
+	}
+
+	if img, err := ReadCSVGrayS16(strings.NewReader("")); err != nil || !img.Rect.Empty() {
+		t.Errorf("empty input = %v, %v", img, err)
+	}
+	for _, tt := range []struct {
+		in     string
+		offset int64
+	}{
+		{"1,2\n3\n", 4},
+		{"1,x\n", 2},
+		{"40000\n", 0},
+		{"y\\x,a\n0,1\n", 4},
+		{"y\\x,0\nb,1\n", 6},
+		{"y\\x,0\n0,1\n1,\"2\n\"\n", 12},
+	} {
+		_, err := ReadCSVGrayS16(strings.NewReader(tt.in))
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Offset != tt.offset {
+			t.Errorf("%q: got %v, want a DecodeError at offset %d", tt.in, err, tt.offset)
+		}
+	}
+}
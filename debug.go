@@ -0,0 +1,362 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// String returns a compact representation of the color, such as
+// "GrayS16(-1234)". It implements fmt.Stringer.
+func (c GrayS16) String() string {
+	return fmt.Sprintf("GrayS16(%d)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayF32) String() string {
+	return fmt.Sprintf("GrayF32(%g)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayU32) String() string {
+	return fmt.Sprintf("GrayU32(%d)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayU64) String() string {
+	return fmt.Sprintf("GrayU64(%d)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayS64) String() string {
+	return fmt.Sprintf("GrayS64(%d)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayC64) String() string {
+	return fmt.Sprintf("GrayC64%g", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c GrayC128) String() string {
+	return fmt.Sprintf("GrayC128%g", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c MappedGrayS16) String() string {
+	return fmt.Sprintf("MappedGrayS16(%d)", c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c RGBAF32) String() string {
+	return fmt.Sprintf("RGBAF32(%g, %g, %g, %g)", c.R, c.G, c.B, c.A)
+}
+
+// String implements fmt.Stringer.
+func (c NRGBAF32) String() string {
+	return fmt.Sprintf("NRGBAF32(%g, %g, %g, %g)", c.R, c.G, c.B, c.A)
+}
+
+// String implements fmt.Stringer.
+func (c LinearRGBF32) String() string {
+	return fmt.Sprintf("LinearRGBF32(%g, %g, %g)", c.R, c.G, c.B)
+}
+
+// String implements fmt.Stringer.
+func (c LinearRGBAF32) String() string {
+	return fmt.Sprintf("LinearRGBAF32(%g, %g, %g, %g)", c.R, c.G, c.B, c.A)
+}
+
+// String implements fmt.Stringer.
+func (c BGR) String() string {
+	return fmt.Sprintf("BGR(%d, %d, %d)", c.B, c.G, c.R)
+}
+
+// String implements fmt.Stringer.
+func (c BGRA) String() string {
+	return fmt.Sprintf("BGRA(%d, %d, %d, %d)", c.B, c.G, c.R, c.A)
+}
+
+// String implements fmt.Stringer.
+func (c BGR48) String() string {
+	return fmt.Sprintf("BGR48(%d, %d, %d)", c.B, c.G, c.R)
+}
+
+// String implements fmt.Stringer.
+func (c BGRA64) String() string {
+	return fmt.Sprintf("BGRA64(%d, %d, %d, %d)", c.B, c.G, c.R, c.A)
+}
+
+// String implements fmt.Stringer.
+func (c CMYK64) String() string {
+	return fmt.Sprintf("CMYK64(%d, %d, %d, %d)", c.C, c.M, c.Y, c.K)
+}
+
+// String implements fmt.Stringer.
+func (c YCbCr48) String() string {
+	return fmt.Sprintf("YCbCr48(%d, %d, %d, %v)", c.Y, c.Cb, c.Cr, c.Matrix)
+}
+
+// String implements fmt.Stringer.
+func (c HSV) String() string {
+	return fmt.Sprintf("HSV(%g, %g, %g)", c.H, c.S, c.V)
+}
+
+// String implements fmt.Stringer.
+func (c HSL) String() string {
+	return fmt.Sprintf("HSL(%g, %g, %g)", c.H, c.S, c.L)
+}
+
+// String implements fmt.Stringer.
+func (c Lab) String() string {
+	return fmt.Sprintf("Lab(%g, %g, %g)", c.L, c.A, c.B)
+}
+
+// String implements fmt.Stringer.
+func (c LCh) String() string {
+	return fmt.Sprintf("LCh(%g, %g, %g)", c.L, c.C, c.H)
+}
+
+// String implements fmt.Stringer.
+func (c Luv) String() string {
+	return fmt.Sprintf("Luv(%g, %g, %g)", c.L, c.U, c.V)
+}
+
+// String implements fmt.Stringer.
+func (c HCL) String() string {
+	return fmt.Sprintf("HCL(%g, %g, %g)", c.H, c.C, c.L)
+}
+
+// String implements fmt.Stringer.
+func (c XYZ) String() string {
+	return fmt.Sprintf("XYZ(%g, %g, %g)", c.X, c.Y, c.Z)
+}
+
+// String implements fmt.Stringer.
+func (c XyY) String() string {
+	return fmt.Sprintf("XyY(%g, %g, %g)", c.Cx, c.Cy, c.Y)
+}
+
+// String implements fmt.Stringer.
+func (c DisplayP3) String() string {
+	return fmt.Sprintf("DisplayP3(%g, %g, %g)", c.R, c.G, c.B)
+}
+
+// String implements fmt.Stringer.
+func (c AdobeRGB) String() string {
+	return fmt.Sprintf("AdobeRGB(%g, %g, %g)", c.R, c.G, c.B)
+}
+
+// String implements fmt.Stringer.
+func (c Rec2020) String() string {
+	return fmt.Sprintf("Rec2020(%g, %g, %g)", c.R, c.G, c.B)
+}
+
+// String returns the color's red, green and blue fields, such as
+// "RGB565(31, 63, 0)".
+func (c RGB565) String() string {
+	return fmt.Sprintf("RGB565(%d, %d, %d)", c>>11, c>>5&0x3f, c&0x1f)
+}
+
+// String returns the color's red, green and blue fields, such as
+// "RGB555(31, 31, 0)".
+func (c RGB555) String() string {
+	return fmt.Sprintf("RGB555(%d, %d, %d)", c>>10&0x1f, c>>5&0x1f, c&0x1f)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayS16Image) String() string {
+	return imageString("GrayS16Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayF32Image) String() string {
+	return imageString("GrayF32Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayU32Image) String() string {
+	return imageString("GrayU32Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayU64Image) String() string {
+	return imageString("GrayU64Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayS64Image) String() string {
+	return imageString("GrayS64Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayC64Image) String() string {
+	return imageString("GrayC64Image", p.Rect, p.Stride)
+}
+
+// String returns the image's type, bounds and stride without its pixels.
+func (p *GrayC128Image) String() string {
+	return imageString("GrayC128Image", p.Rect, p.Stride)
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayS16Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayF32Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayU32Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayU64Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayS64Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayC64Image) GoString() string {
+	return p.String()
+}
+
+// GoString is like String, so that %#v does not dump Pix either.
+func (p *GrayC128Image) GoString() string {
+	return p.String()
+}
+
+// imageString formats an image's type, bounds and stride.
+func imageString(name string, r image.Rectangle, stride int) string {
+	if r.Empty() {
+		return fmt.Sprintf("&colorext.%s{Rect: %v}", name, r)
+	}
+	return fmt.Sprintf("&colorext.%s{Rect: %v, Stride: %d}", name, r, stride)
+}
+
+// DebugString returns a human-readable summary of img for logs and test
+// failures: its type, bounds and sample type, value statistics, and a
+// matrix of at most maxW by maxH values. Larger images are sampled at
+// evenly spaced rows and columns, labeled with their coordinates.
+//
+// Scalar images, such as GrayS16Image and GrayF32Image, report raw
+// values with NaN and invalid pixels counted separately; other images
+// report their colors. Non-positive maxW or maxH omit the matrix.
+func DebugString(img image.Image, maxW, maxH int) string {
+	var sb strings.Builder
+	r := img.Bounds()
+	fmt.Fprintf(&sb, "%T %v", img, r)
+	if d, _, _, ok := grayLayout(unwrapMeta(img)); ok {
+		fmt.Fprintf(&sb, " %v", d)
+	}
+	sb.WriteByte('\n')
+	if r.Empty() {
+		return sb.String()
+	}
+
+	scalar := isScalar(img)
+	var at func(x, y int) float64
+	if scalar {
+		at = scalarSampler(img)
+		lo, hi, sum, n, invalid := math.Inf(1), math.Inf(-1), 0.0, 0, 0
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				v := at(x, y)
+				if math.IsNaN(v) {
+					invalid++
+					continue
+				}
+				lo, hi = min(lo, v), max(hi, v)
+				sum += v
+				n++
+			}
+		}
+		if n > 0 {
+			fmt.Fprintf(&sb, "min %g max %g mean %g", lo, hi, sum/float64(n))
+		} else {
+			sb.WriteString("no valid pixels")
+		}
+		if invalid > 0 {
+			fmt.Fprintf(&sb, " invalid %d", invalid)
+		}
+		sb.WriteByte('\n')
+	}
+	if maxW <= 0 || maxH <= 0 {
+		return sb.String()
+	}
+
+	xs := sampleCoords(r.Min.X, r.Dx(), maxW)
+	ys := sampleCoords(r.Min.Y, r.Dy(), maxH)
+	cells := make([][]string, len(ys)+1)
+	cells[0] = make([]string, len(xs)+1)
+	for i, x := range xs {
+		cells[0][i+1] = fmt.Sprintf("x=%d", x)
+	}
+	for j, y := range ys {
+		row := make([]string, len(xs)+1)
+		row[0] = fmt.Sprintf("y=%d", y)
+		for i, x := range xs {
+			if scalar {
+				row[i+1] = fmt.Sprintf("%g", at(x, y))
+			} else {
+				row[i+1] = fmt.Sprint(img.At(x, y))
+			}
+		}
+		cells[j+1] = row
+	}
+
+	widths := make([]int, len(xs)+1)
+	for _, row := range cells {
+		for i, c := range row {
+			widths[i] = max(widths[i], len(c))
+		}
+	}
+	for _, row := range cells {
+		for i, c := range row {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			fmt.Fprintf(&sb, "%*s", widths[i], c)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// sampleCoords returns at most max evenly spaced coordinates in
+// [start, start+n), including both ends.
+func sampleCoords(start, n, max int) []int {
+	if n <= max {
+		out := make([]int, n)
+		for i := range out {
+			out[i] = start + i
+		}
+		return out
+	}
+	out := make([]int, max)
+	for i := range out {
+		if max == 1 {
+			out[i] = start
+			break
+		}
+		out[i] = start + i*(n-1)/(max-1)
+	}
+	return out
+}
+
+// unwrapMeta returns the image annotated by an *ImageWithMeta, or img.
+func unwrapMeta(img image.Image) image.Image {
+	if m, ok := img.(*ImageWithMeta); ok {
+		return m.Unwrap()
+	}
+	return img
+}
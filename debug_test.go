@@ -0,0 +1,82 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestColorString(t *testing.T) {
+	tests := []struct {
+		c    color.Color
+		want string
+	}{
+		{GrayS16{Y: -1234}, "GrayS16(-1234)"},
+		{GrayF32{Y: 0.1}, "GrayF32(0.1)"},
+		{GrayU64{Y: 1 << 40}, "GrayU64(1099511627776)"},
+		{GrayC64{Y: complex(1, -2)}, "GrayC64(1-2i)"},
+		{RGBAF32{0.5, 0.25, 0, 1}, "RGBAF32(0.5, 0.25, 0, 1)"},
+		{BGR{B: 1, G: 2, R: 3}, "BGR(1, 2, 3)"},
+		{RGB565(0xf81f), "RGB565(31, 0, 31)"},
+		{RGB555(0x7c00), "RGB555(31, 0, 0)"},
+		{Lab{L: 50, A: -2.5, B: 10}, "Lab(50, -2.5, 10)"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(tt.c); got != tt.want {
+			t.Errorf("Sprint(%#v) = %q, want %q", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestImageString(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(1, 2, 5, 6))
+	want := "&colorext.GrayS16Image{Rect: (1,2)-(5,6), Stride: 8}"
+	if got := fmt.Sprintf("%v", img); got != want {
+		t.Errorf("%%v = %q, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%#v", img); got != want {
+		t.Errorf("%%#v = %q, want %q", got, want)
+	}
+	if got := fmt.Sprint(&GrayF32Image{}); got != "&colorext.GrayF32Image{Rect: (0,0)-(0,0)}" {
+		t.Errorf("empty image = %q", got)
+	}
+}
+
+func TestDebugString(t *testing.T) {
+	img := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 10, 3)), -1)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 10; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: int16(10*y + x)})
+		}
+	}
+	img.SetGrayS16(9, 2, GrayS16{Y: -1})
+
+	got := DebugString(img, 3, 5)
+	want := strings.Join([]string{
+		"*colorext.MaskedGrayS16Image (0,0)-(10,3)",
+		"min 0 max 28 mean 14 invalid 1",
+		"    x=0 x=4 x=9",
+		"y=0   0   4   9",
+		"y=1  10  14  19",
+		"y=2  20  24 NaN",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("DebugString =\n%s\nwant\n%s", got, want)
+	}
+
+	f := NewGrayF32Image(image.Rect(0, 0, 1, 1))
+	f.SetGrayF32(0, 0, GrayF32{Y: float32(math.NaN())})
+	if got := DebugString(f, 0, 0); !strings.HasSuffix(got, "float32\nno valid pixels invalid 1\n") {
+		t.Errorf("all-NaN summary = %q", got)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.Set(0, 0, color.RGBA{1, 2, 3, 4})
+	if got := DebugString(rgba, 4, 4); !strings.Contains(got, "{1 2 3 4}") {
+		t.Errorf("color matrix missing pixel: %q", got)
+	}
+}
@@ -0,0 +1,183 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// Filter selects the low-pass filter Decimate applies before subsampling.
+type Filter int
+
+const (
+	// FilterBox averages each factor×factor block. It is the cheapest
+	// filter but leaves the most aliasing.
+	FilterBox Filter = iota
+	// FilterGaussian applies a Gaussian with a standard deviation of half
+	// an output pixel.
+	FilterGaussian
+	// FilterLanczos applies a three-lobed Lanczos window, the sharpest of
+	// the three. Its negative lobes can ring near edges.
+	FilterLanczos
+)
+
+// support returns the filter radius in output pixels.
+func (f Filter) support() float64 {
+	switch f {
+	case FilterGaussian:
+		return 1.5
+	case FilterLanczos:
+		return 3
+	}
+	return 0.5
+}
+
+// weight returns the filter response at a distance of d output pixels.
+func (f Filter) weight(d float64) float64 {
+	switch f {
+	case FilterGaussian:
+		return math.Exp(-2 * d * d)
+	case FilterLanczos:
+		if d == 0 {
+			return 1
+		}
+		if math.Abs(d) >= 3 {
+			return 0
+		}
+		pd := math.Pi * d
+		return 3 * math.Sin(pd) * math.Sin(pd/3) / (pd * pd)
+	}
+	if math.Abs(d) < 0.5 {
+		return 1
+	}
+	return 0
+}
+
+// decimateBits is the fixed-point precision of filter weights.
+const decimateBits = 14
+
+// decimateTap is one weighted source sample of an output pixel.
+type decimateTap struct {
+	i int
+	w int32
+}
+
+// Decimate shrinks src by an integer factor, low-pass filtering with
+// prefilter before subsampling so that 16-bit data does not alias.
+// Filtering is separable and runs in int32 fixed point; results are
+// rounded and saturate to the int16 range.
+//
+// Output pixel (x, y) covers source pixels [x·factor, (x+1)·factor) on
+// each axis, so the output bounds are src.Rect divided by factor, rounded
+// outward. Because the grid is anchored at the origin rather than at
+// src.Rect.Min, decimating adjacent tiles of a mosaic yields aligned
+// tiles. Samples beyond src.Rect are clamped to the nearest edge. A factor
+// below 2 returns a copy of src.
+func Decimate(src *GrayS16Image, factor int, prefilter Filter) *GrayS16Image {
+	if factor < 2 {
+		dst := NewGrayS16Image(src.Rect)
+		copyRows16(dst.Pix, dst.Stride, 0, src.Pix, src.Stride, 0, src.Rect, src.Rect.Min)
+		return dst
+	}
+	r := image.Rect(
+		floorDiv(src.Rect.Min.X, factor), floorDiv(src.Rect.Min.Y, factor),
+		-floorDiv(-src.Rect.Max.X, factor), -floorDiv(-src.Rect.Max.Y, factor),
+	)
+	if src.Rect.Empty() {
+		r = image.Rectangle{}
+	}
+	dst := NewGrayS16Image(r)
+	if r.Empty() {
+		return dst
+	}
+	xTaps := decimateTaps(r.Min.X, r.Max.X, src.Rect.Min.X, src.Rect.Max.X, factor, prefilter)
+	yTaps := decimateTaps(r.Min.Y, r.Max.Y, src.Rect.Min.Y, src.Rect.Max.Y, factor, prefilter)
+
+	// Filter rows horizontally into an intermediate buffer with the same
+	// fixed-point scale as the input, then filter its columns.
+	sw, sh, dw := src.Rect.Dx(), src.Rect.Dy(), r.Dx()
+	tmp := make([]int32, sh*dw)
+	row := make([]int32, sw)
+	const half = 1 << (decimateBits - 1)
+	for y := 0; y < sh; y++ {
+		s := src.Pix[y*src.Stride:]
+		for x := range row {
+			row[x] = int32(int16(uint16(s[2*x])<<8 | uint16(s[2*x+1])))
+		}
+		t := tmp[y*dw : (y+1)*dw]
+		for x, taps := range xTaps {
+			var acc int32
+			for _, tp := range taps {
+				acc += tp.w * row[tp.i]
+			}
+			t[x] = (acc + half) >> decimateBits
+		}
+	}
+	for y, taps := range yTaps {
+		d := dst.Pix[y*dst.Stride:]
+		for x := 0; x < dw; x++ {
+			var acc int32
+			for _, tp := range taps {
+				acc += tp.w * tmp[tp.i*dw+x]
+			}
+			v := uint16(int16(max(math.MinInt16, min(math.MaxInt16, (acc+half)>>decimateBits))))
+			d[2*x], d[2*x+1] = uint8(v>>8), uint8(v)
+		}
+	}
+	return dst
+}
+
+// decimateTaps returns, for each output coordinate in [lo, hi), the source
+// samples and fixed-point weights of filter f, as indices relative to
+// srcLo. Samples outside [srcLo, srcHi) are clamped to the nearest edge.
+// The weights of each output coordinate sum to exactly 1<<decimateBits.
+func decimateTaps(lo, hi, srcLo, srcHi, factor int, f Filter) [][]decimateTap {
+	taps := make([][]decimateTap, hi-lo)
+	scale := float64(factor)
+	reach := int(math.Ceil(f.support() * scale))
+	ws := make([]float64, 0, 2*reach+factor)
+	for o := lo; o < hi; o++ {
+		center := (float64(o) + 0.5) * scale
+		first := o*factor + factor/2 - reach
+		last := o*factor + factor/2 + reach
+		ws = ws[:0]
+		var sum float64
+		for i := first; i <= last; i++ {
+			w := f.weight((float64(i) + 0.5 - center) / scale)
+			ws = append(ws, w)
+			sum += w
+		}
+		var t []decimateTap
+		var total int32
+		peak := -1
+		for k, w := range ws {
+			q := int32(math.Round(w / sum * (1 << decimateBits)))
+			if q == 0 {
+				continue
+			}
+			i := max(srcLo, min(srcHi-1, first+k)) - srcLo
+			if n := len(t); n > 0 && t[n-1].i == i {
+				t[n-1].w += q
+			} else {
+				t = append(t, decimateTap{i: i, w: q})
+			}
+			total += q
+			if peak < 0 || t[len(t)-1].w > t[peak].w {
+				peak = len(t) - 1
+			}
+		}
+		// Give any rounding error to the largest tap so flat regions
+		// stay flat.
+		t[peak].w += 1<<decimateBits - total
+		taps[o-lo] = t
+	}
+	return taps
+}
+
+// floorDiv returns a/b rounded toward negative infinity, for b > 0.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
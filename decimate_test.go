@@ -0,0 +1,136 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestDecimate(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 6, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			src.SetGrayS16(x, y, GrayS16{Y: int16(100*x - 1000*y)})
+		}
+	}
+	tests := []struct {
+		name   string
+		src    *GrayS16Image
+		factor int
+		want   image.Rectangle
+	}{
+		{"even", src, 2, image.Rect(0, 0, 3, 2)},
+		{"partial block", src, 4, image.Rect(0, 0, 2, 1)},
+		{"negative origin", NewGrayS16Image(image.Rect(-5, -3, 4, 3)), 2, image.Rect(-3, -2, 2, 2)},
+		{"copy", src, 1, src.Rect},
+		{"empty", NewGrayS16Image(image.Rectangle{}), 3, image.Rectangle{}},
+	}
+	for _, tt := range tests {
+		for _, f := range []Filter{FilterBox, FilterGaussian, FilterLanczos} {
+			if got := Decimate(tt.src, tt.factor, f).Rect; got != tt.want {
+				t.Errorf("%s filter %d: bounds %v, want %v", tt.name, f, got, tt.want)
+			}
+		}
+	}
+
+	// A box filter averages each block exactly, rounding half up.
+	box := Decimate(src, 2, FilterBox)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			want := int16(100*(2*x) + 50 - 1000*(2*y) - 500)
+			if got := box.GrayS16At(x, y).Y; got != want {
+				t.Errorf("box (%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDecimateFlat(t *testing.T) {
+	for _, v := range []int16{-32768, -1, 0, 12345, 32767} {
+		src := NewGrayS16Image(image.Rect(1, 2, 40, 37))
+		fillRows16(src, src.Rect, GrayS16{Y: v})
+		for _, f := range []Filter{FilterBox, FilterGaussian, FilterLanczos} {
+			for _, factor := range []int{2, 3, 5} {
+				dst := Decimate(src, factor, f)
+				for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+					for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+						if got := dst.GrayS16At(x, y).Y; got != v {
+							t.Fatalf("value %d filter %d factor %d: (%d, %d) = %d", v, f, factor, x, y, got)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecimateAntiAliasing(t *testing.T) {
+	// A stripe pattern at the input Nyquist frequency aliases to a constant
+	// under nearest decimation; the filters must suppress it.
+	src := NewGrayS16Image(image.Rect(0, 0, 64, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 64; x++ {
+			v := int16(20000)
+			if x%4 >= 2 {
+				v = -20000
+			}
+			src.SetGrayS16(x, y, GrayS16{Y: v})
+		}
+	}
+	tests := []struct {
+		f      Filter
+		maxAbs int16
+	}{
+		{FilterBox, 1},
+		{FilterGaussian, 1000},
+		{FilterLanczos, 1000},
+	}
+	for _, tt := range tests {
+		dst := Decimate(src, 4, tt.f)
+		// Skip the clamped edge columns.
+		for x := 2; x < 14; x++ {
+			if v := dst.GrayS16At(x, 1).Y; v > tt.maxAbs || v < -tt.maxAbs {
+				t.Errorf("filter %d: x=%d residual %d, want within ±%d", tt.f, x, v, tt.maxAbs)
+			}
+		}
+	}
+}
+
+func TestDecimateTiles(t *testing.T) {
+	// Decimating a box-filtered mosaic tile by tile matches decimating it
+	// whole when the tiles are aligned to the factor.
+	src := randomGrayS16(image.Rect(0, 0, 24, 12), 3)
+	whole := Decimate(src, 3, FilterBox)
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 12, 12), image.Rect(12, 0, 24, 12)} {
+		tile := Decimate(src.SubImage(r).(*GrayS16Image), 3, FilterBox)
+		for y := tile.Rect.Min.Y; y < tile.Rect.Max.Y; y++ {
+			for x := tile.Rect.Min.X; x < tile.Rect.Max.X; x++ {
+				if a, b := tile.GrayS16At(x, y), whole.GrayS16At(x, y); a != b {
+					t.Errorf("tile %v: (%d, %d) = %d, want %d", r, x, y, a.Y, b.Y)
+				}
+			}
+		}
+	}
+}
+
+func TestDecimateSaturates(t *testing.T) {
+	// Lanczos overshoot on a hard edge must not wrap around.
+	src := NewGrayS16Image(image.Rect(0, 0, 32, 1))
+	for x := 0; x < 32; x++ {
+		v := int16(math.MinInt16)
+		if x >= 15 {
+			v = math.MaxInt16
+		}
+		src.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	dst := Decimate(src, 2, FilterLanczos)
+	for x := 0; x < 16; x++ {
+		v := dst.GrayS16At(x, 0).Y
+		if x > 9 && v < 30000 {
+			t.Errorf("x=%d: %d, want near 32767 (wrapped?)", x, v)
+		}
+		if x < 5 && v > -30000 {
+			t.Errorf("x=%d: %d, want near -32768 (wrapped?)", x, v)
+		}
+	}
+}
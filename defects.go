@@ -0,0 +1,162 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// DetectDefects finds hot and dead pixels in img: pixels that differ from
+// the median of their eight neighbors by more than sigma times the robust
+// standard deviation of that difference across the image. The deviation is
+// estimated from the median absolute difference, so the defects themselves
+// do not inflate it. The returned mask is opaque at defective pixels.
+//
+// Samples are read as raw values, as by Contours. NaN and invalid pixels
+// are neither flagged nor used as neighbors. A non-positive sigma means 5.
+func DetectDefects(img image.Image, sigma float64) *image.Alpha {
+	if sigma <= 0 {
+		sigma = 5
+	}
+	r := img.Bounds()
+	at := scalarSampler(img)
+	resid := make([]float64, r.Dx()*r.Dy())
+	var devs []float64
+	var nb []float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i := (y-r.Min.Y)*r.Dx() + x - r.Min.X
+			resid[i] = math.NaN()
+			v := at(x, y)
+			if math.IsNaN(v) {
+				continue
+			}
+			nb = neighbors8(nb[:0], at, x, y, nil)
+			if len(nb) == 0 {
+				continue
+			}
+			slices.Sort(nb)
+			resid[i] = v - percentile(nb, 50)
+			devs = append(devs, math.Abs(resid[i]))
+		}
+	}
+
+	mask := image.NewAlpha(r)
+	if len(devs) == 0 {
+		return mask
+	}
+	slices.Sort(devs)
+	// 1.4826 scales the median absolute deviation of normally distributed
+	// data to its standard deviation.
+	limit := sigma * 1.4826 * percentile(devs, 50)
+	for i, d := range resid {
+		if math.Abs(d) > limit {
+			mask.Pix[(i/r.Dx())*mask.Stride+i%r.Dx()] = 0xff
+		}
+	}
+	return mask
+}
+
+// RepairMethod selects how RepairDefects estimates a defective pixel.
+type RepairMethod int
+
+const (
+	// RepairMedian uses the median of the usable neighbors, which keeps
+	// edges sharp.
+	RepairMedian RepairMethod = iota
+	// RepairMean uses the mean of the usable neighbors, which is smoother
+	// in noisy regions.
+	RepairMean
+)
+
+// RepairDefects replaces each pixel of img where mask is non-zero with an
+// estimate from its eight neighbors, ignoring neighbors that are
+// themselves masked. Pixels whose neighbors are all masked use the ring
+// of sixteen pixels around them instead, and are left unchanged if that
+// is masked too. Estimates are computed from the original values, so the
+// result does not depend on scan order. It returns the number of pixels
+// repaired.
+func RepairDefects(img *GrayS16Image, mask *image.Alpha, method RepairMethod) int {
+	r := img.Rect.Intersect(mask.Rect)
+	usable := func(x, y int) bool {
+		return image.Point{X: x, Y: y}.In(img.Rect) && mask.AlphaAt(x, y).A == 0
+	}
+	at := func(x, y int) float64 {
+		return float64(img.GrayS16At(x, y).Y)
+	}
+
+	type fix struct {
+		x, y int
+		v    int16
+	}
+	var fixes []fix
+	var nb []float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if mask.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			nb = neighbors8(nb[:0], at, x, y, usable)
+			if len(nb) == 0 {
+				nb = ring16(nb, at, x, y, usable)
+			}
+			if len(nb) == 0 {
+				continue
+			}
+			var v float64
+			if method == RepairMean {
+				for _, n := range nb {
+					v += n
+				}
+				v /= float64(len(nb))
+			} else {
+				slices.Sort(nb)
+				v = percentile(nb, 50)
+			}
+			fixes = append(fixes, fix{x, y, int16(math.Round(v))})
+		}
+	}
+	for _, f := range fixes {
+		img.SetGrayS16(f.x, f.y, GrayS16{Y: f.v})
+	}
+	return len(fixes)
+}
+
+// neighbors8 appends the non-NaN values of the eight neighbors of (x, y)
+// to dst. If usable is non-nil, only neighbors it accepts are included.
+func neighbors8(dst []float64, at func(x, y int) float64, x, y int, usable func(x, y int) bool) []float64 {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			dst = appendNeighbor(dst, at, x+dx, y+dy, usable)
+		}
+	}
+	return dst
+}
+
+// ring16 appends the values of the sixteen pixels at Chebyshev distance two
+// from (x, y) to dst, like neighbors8.
+func ring16(dst []float64, at func(x, y int) float64, x, y int, usable func(x, y int) bool) []float64 {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			if dx == -2 || dx == 2 || dy == -2 || dy == 2 {
+				dst = appendNeighbor(dst, at, x+dx, y+dy, usable)
+			}
+		}
+	}
+	return dst
+}
+
+// appendNeighbor appends the value at (x, y) to dst if it is usable and
+// not NaN.
+func appendNeighbor(dst []float64, at func(x, y int) float64, x, y int, usable func(x, y int) bool) []float64 {
+	if usable != nil && !usable(x, y) {
+		return dst
+	}
+	if v := at(x, y); !math.IsNaN(v) {
+		dst = append(dst, v)
+	}
+	return dst
+}
@@ -0,0 +1,87 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDetectDefects(t *testing.T) {
+	img := NoiseGrayS16(image.Rect(0, 0, 32, 32), &NoiseOptions{Amplitude: 20, Seed: 5})
+	hot, dead := image.Pt(10, 12), image.Pt(31, 0)
+	img.SetGrayS16(hot.X, hot.Y, GrayS16{Y: 4000})
+	img.SetGrayS16(dead.X, dead.Y, GrayS16{Y: -3000})
+
+	mask := DetectDefects(img, 0)
+	var found []image.Point
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			if mask.AlphaAt(x, y).A != 0 {
+				found = append(found, image.Pt(x, y))
+			}
+		}
+	}
+	if len(found) != 2 || found[0] != dead || found[1] != hot {
+		t.Errorf("defects = %v, want [%v %v]", found, dead, hot)
+	}
+
+	// Masked-out pixels are not reported.
+	m := NewMaskedGrayS16Image(img, 4000)
+	if DetectDefects(m, 0).AlphaAt(hot.X, hot.Y).A != 0 {
+		t.Error("invalid pixel reported as a defect")
+	}
+}
+
+func TestRepairDefects(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 5, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: int16(10 * x)})
+		}
+	}
+	mask := image.NewAlpha(img.Rect)
+	set := func(x, y int, v int16) {
+		img.SetGrayS16(x, y, GrayS16{Y: v})
+		mask.SetAlpha(x, y, color.Alpha{A: 0xff})
+	}
+	set(2, 2, 9999)
+	// Masked neighbors are skipped.
+	set(3, 2, -9999)
+
+	if n := RepairDefects(img, mask, RepairMedian); n != 2 {
+		t.Errorf("repaired %d pixels, want 2", n)
+	}
+	if got := img.GrayS16At(2, 2).Y; got != 20 {
+		t.Errorf("median repair = %d, want 20", got)
+	}
+	if got := img.GrayS16At(3, 2).Y; got != 30 {
+		t.Errorf("median repair next to a defect = %d, want 30", got)
+	}
+
+	// The mean of the column neighbors of a pixel on a ramp is its value.
+	img.SetGrayS16(1, 1, GrayS16{Y: 500})
+	mask = image.NewAlpha(img.Rect)
+	mask.SetAlpha(1, 1, color.Alpha{A: 0xff})
+	RepairDefects(img, mask, RepairMean)
+	if got := img.GrayS16At(1, 1).Y; got != 10 {
+		t.Errorf("mean repair = %d, want 10", got)
+	}
+
+	// A pixel surrounded by defects falls back to the outer ring.
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: 7})
+		}
+	}
+	mask = image.NewAlpha(img.Rect)
+	for y := 1; y < 4; y++ {
+		for x := 1; x < 4; x++ {
+			mask.SetAlpha(x, y, color.Alpha{A: 0xff})
+			img.SetGrayS16(x, y, GrayS16{Y: 1000})
+		}
+	}
+	RepairDefects(img, mask, RepairMedian)
+	if got := img.GrayS16At(2, 2).Y; got != 7 {
+		t.Errorf("ring repair = %d, want 7", got)
+	}
+}
@@ -0,0 +1,184 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// DemosaicMethod selects the interpolation used by Demosaic.
+type DemosaicMethod int
+
+const (
+	// DemosaicNearest copies the missing samples from the same 2×2 tile.
+	// It is fast but produces blocky color edges.
+	DemosaicNearest DemosaicMethod = iota
+	// DemosaicBilinear averages the nearest samples of each missing color.
+	DemosaicBilinear
+	// DemosaicMalvar uses the gradient-corrected linear interpolation of
+	// Malvar, He and Cutler (2004), which reduces color fringing at edges.
+	DemosaicMalvar
+)
+
+// malvarKernels holds the 5×5 Malvar-He-Cutler filters, scaled by 16. The
+// kernels estimate green at red and blue sites, red or blue at green sites
+// whose row holds that color, red or blue at green sites whose column holds
+// it, and red at blue sites or blue at red sites.
+var malvarKernels = [4][5][5]float64{
+	{
+		{0, 0, -2, 0, 0},
+		{0, 0, 4, 0, 0},
+		{-2, 4, 8, 4, -2},
+		{0, 0, 4, 0, 0},
+		{0, 0, -2, 0, 0},
+	},
+	{
+		{0, 0, 1, 0, 0},
+		{0, -2, 0, -2, 0},
+		{-2, 8, 10, 8, -2},
+		{0, -2, 0, -2, 0},
+		{0, 0, 1, 0, 0},
+	},
+	{
+		{0, 0, -2, 0, 0},
+		{0, -2, 8, -2, 0},
+		{1, 0, 10, 0, 1},
+		{0, -2, 8, -2, 0},
+		{0, 0, -2, 0, 0},
+	},
+	{
+		{0, 0, -3, 0, 0},
+		{0, 4, 0, 4, 0},
+		{-3, 0, 12, 0, -3},
+		{0, 4, 0, 4, 0},
+		{0, 0, -3, 0, 0},
+	},
+}
+
+// Demosaic reconstructs a full color image from the raw samples of src
+// using the interpolation method m. 8-bit samples are scaled to 16 bits.
+// Pixels near the border are interpolated from mirrored samples.
+func Demosaic(src *BayerImage, m DemosaicMethod) *image.RGBA64 {
+	r := src.Rect
+	dst := image.NewRGBA64(r)
+	scale := 1.0
+	if src.Depth == 8 {
+		scale = 0x101
+	}
+
+	// at reads the sample at (x, y), mirroring coordinates outside the image
+	// so that the mirrored site has the same color.
+	at := func(x, y int) float64 {
+		x = mirrorCoord(x, r.Min.X, r.Max.X)
+		y = mirrorCoord(y, r.Min.Y, r.Max.Y)
+		return float64(src.SampleAt(x, y))
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var rgb [3]float64
+			switch m {
+			case DemosaicNearest:
+				rgb = demosaicNearest(src.Pattern, x, y, at)
+			case DemosaicMalvar:
+				rgb = demosaicMalvar(src.Pattern, x, y, at)
+			default:
+				rgb = demosaicBilinear(src.Pattern, x, y, at)
+			}
+			ch := func(v float64) uint16 {
+				v *= scale
+				if !(v > 0) {
+					return 0
+				}
+				if v >= 0xffff {
+					return 0xffff
+				}
+				return uint16(v + 0.5)
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: ch(rgb[cfaRed]), G: ch(rgb[cfaGreen]), B: ch(rgb[cfaBlue]), A: 0xffff})
+		}
+	}
+	return dst
+}
+
+// mirrorCoord reflects v into [lo, hi) about the edge samples, preserving
+// its parity where the range allows.
+func mirrorCoord(v, lo, hi int) int {
+	if v < lo {
+		v = 2*lo - v
+	}
+	if v >= hi {
+		v = 2*(hi-1) - v
+	}
+	return max(lo, min(v, hi-1))
+}
+
+// demosaicNearest takes each color from the 2×2 tile containing (x, y),
+// preferring the green sample in the same row.
+func demosaicNearest(p CFAPattern, x, y int, at func(x, y int) float64) [3]float64 {
+	var rgb [3]float64
+	tx, ty := x&^1, y&^1
+	for dy := 1; dy >= 0; dy-- {
+		for dx := 0; dx < 2; dx++ {
+			c := p.channel(tx+dx, ty+dy)
+			if c == cfaGreen && ty+dy != y {
+				continue
+			}
+			rgb[c] = at(tx+dx, ty+dy)
+		}
+	}
+	rgb[p.channel(x, y)] = at(x, y)
+	return rgb
+}
+
+// demosaicBilinear averages the samples of each missing color in the 3×3
+// neighborhood of (x, y).
+func demosaicBilinear(p CFAPattern, x, y int, at func(x, y int) float64) [3]float64 {
+	var sum [3]float64
+	var n [3]int
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			c := p.channel(x+dx, y+dy)
+			sum[c] += at(x+dx, y+dy)
+			n[c]++
+		}
+	}
+	own := p.channel(x, y)
+	var rgb [3]float64
+	for c := range rgb {
+		if c == own {
+			rgb[c] = at(x, y)
+		} else {
+			rgb[c] = sum[c] / float64(n[c])
+		}
+	}
+	return rgb
+}
+
+// demosaicMalvar applies the Malvar-He-Cutler filters at (x, y).
+func demosaicMalvar(p CFAPattern, x, y int, at func(x, y int) float64) [3]float64 {
+	filter := func(k int) float64 {
+		s := 0.0
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				if w := malvarKernels[k][dy+2][dx+2]; w != 0 {
+					s += w * at(x+dx, y+dy)
+				}
+			}
+		}
+		return s / 16
+	}
+
+	var rgb [3]float64
+	own := p.channel(x, y)
+	rgb[own] = at(x, y)
+	if own == cfaGreen {
+		// The colors of the horizontal and vertical neighbors.
+		h, v := p.channel(x+1, y), p.channel(x, y+1)
+		rgb[h] = filter(1)
+		rgb[v] = filter(2)
+		return rgb
+	}
+	rgb[cfaGreen] = filter(0)
+	rgb[cfaRed+cfaBlue-own] = filter(3)
+	return rgb
+}
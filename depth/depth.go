@@ -0,0 +1,130 @@
+// Package depth provides conversions and clean-up operations for stereo
+// disparity and depth maps.
+//
+// Depth maps are stored as colorext.GrayF32Image values in metric units.
+// Pixels without a valid measurement hold NaN.
+package depth
+
+import (
+	"math"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Stereo describes a rectified stereo camera pair.
+type Stereo struct {
+	// Baseline is the distance between the two camera centers, in the
+	// units the depth should be expressed in (typically meters).
+	Baseline float64
+	// FocalLength is the focal length of the rectified cameras in pixels.
+	FocalLength float64
+	// DisparityScale is the number of disparity units per pixel. Fixed
+	// point disparity maps such as OpenCV's StereoSGBM output use 16.
+	// Zero is treated as 1.
+	DisparityScale float64
+}
+
+// DisparityToDepth converts a disparity map to metric depth using
+// depth = FocalLength * Baseline / disparity. Non-positive disparities are
+// invalid and produce NaN.
+func (s Stereo) DisparityToDepth(disp *colorext.GrayS16Image) *colorext.GrayF32Image {
+	scale := s.DisparityScale
+	if scale == 0 {
+		scale = 1
+	}
+	fb := s.FocalLength * s.Baseline
+	dst := colorext.NewGrayF32Image(disp.Rect)
+	for y := disp.Rect.Min.Y; y < disp.Rect.Max.Y; y++ {
+		for x := disp.Rect.Min.X; x < disp.Rect.Max.X; x++ {
+			d := float64(disp.GrayS16At(x, y).Y) / scale
+			z := math.NaN()
+			if d > 0 {
+				z = fb / d
+			}
+			dst.SetGrayF32(x, y, colorext.GrayF32{Y: float32(z)})
+		}
+	}
+	return dst
+}
+
+// Valid reports whether v holds a depth measurement.
+func Valid(v float32) bool {
+	return !math.IsNaN(float64(v))
+}
+
+// ClampRange invalidates, in place, every pixel of depth that lies outside
+// [near, far]. It returns the number of pixels invalidated.
+func ClampRange(depth *colorext.GrayF32Image, near, far float64) int {
+	n := 0
+	nan := colorext.GrayF32{Y: float32(math.NaN())}
+	for y := depth.Rect.Min.Y; y < depth.Rect.Max.Y; y++ {
+		for x := depth.Rect.Min.X; x < depth.Rect.Max.X; x++ {
+			v := depth.GrayF32At(x, y).Y
+			if Valid(v) && (float64(v) < near || float64(v) > far) {
+				depth.SetGrayF32(x, y, nan)
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// FillHoles fills invalid pixels of depth in place with the mean of their
+// valid 8-connected neighbors. Each iteration grows the filled region by one
+// pixel; at most maxIterations are run. It returns the number of pixels that
+// remain invalid.
+func FillHoles(depth *colorext.GrayF32Image, maxIterations int) int {
+	r := depth.Rect
+	type fill struct {
+		x, y int
+		v    float32
+	}
+	for iter := 0; iter < maxIterations; iter++ {
+		var fills []fill
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if Valid(depth.GrayF32At(x, y).Y) {
+					continue
+				}
+				var sum float64
+				var count int
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if dx == 0 && dy == 0 {
+							continue
+						}
+						// Out of bounds reads return zero, which must not count.
+						px, py := x+dx, y+dy
+						if px < r.Min.X || px >= r.Max.X || py < r.Min.Y || py >= r.Max.Y {
+							continue
+						}
+						if v := depth.GrayF32At(px, py).Y; Valid(v) {
+							sum += float64(v)
+							count++
+						}
+					}
+				}
+				if count > 0 {
+					fills = append(fills, fill{x, y, float32(sum / float64(count))})
+				}
+			}
+		}
+		if len(fills) == 0 {
+			break
+		}
+		// Apply after scanning so each iteration only uses the previous state.
+		for _, f := range fills {
+			depth.SetGrayF32(f.x, f.y, colorext.GrayF32{Y: f.v})
+		}
+	}
+
+	remaining := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if !Valid(depth.GrayF32At(x, y).Y) {
+				remaining++
+			}
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,91 @@
+package depth
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func TestStereo_DisparityToDepth(t *testing.T) {
+	disp := colorext.NewGrayS16Image(image.Rect(0, 0, 4, 1))
+	for i, d := range []int16{160, 320, 0, -16} {
+		disp.SetGrayS16(i, 0, colorext.GrayS16{Y: d})
+	}
+
+	s := Stereo{Baseline: 0.1, FocalLength: 500, DisparityScale: 16}
+	got := s.DisparityToDepth(disp)
+
+	if v := got.GrayF32At(0, 0).Y; math.Abs(float64(v)-5) > 1e-6 {
+		t.Errorf("depth at disparity 10px = %v, want 5", v)
+	}
+	if v := got.GrayF32At(1, 0).Y; math.Abs(float64(v)-2.5) > 1e-6 {
+		t.Errorf("depth at disparity 20px = %v, want 2.5", v)
+	}
+	for x := 2; x < 4; x++ {
+		if v := got.GrayF32At(x, 0).Y; Valid(v) {
+			t.Errorf("depth at non-positive disparity (x=%d) = %v, want NaN", x, v)
+		}
+	}
+
+	// A zero scale is treated as whole-pixel disparity.
+	s.DisparityScale = 0
+	if v := s.DisparityToDepth(disp).GrayF32At(0, 0).Y; math.Abs(float64(v)-50.0/160) > 1e-6 {
+		t.Errorf("unscaled depth = %v, want %v", v, 50.0/160)
+	}
+}
+
+func TestClampRange(t *testing.T) {
+	d := colorext.NewGrayF32Image(image.Rect(0, 0, 4, 1))
+	for i, v := range []float32{0.1, 1, 5, float32(math.NaN())} {
+		d.SetGrayF32(i, 0, colorext.GrayF32{Y: v})
+	}
+
+	if n := ClampRange(d, 0.5, 4); n != 2 {
+		t.Errorf("ClampRange invalidated %d pixels, want 2", n)
+	}
+	want := []bool{false, true, false, false}
+	for x, w := range want {
+		if got := Valid(d.GrayF32At(x, 0).Y); got != w {
+			t.Errorf("Valid at x=%d is %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestFillHoles(t *testing.T) {
+	d := colorext.NewGrayF32Image(image.Rect(0, 0, 5, 1))
+	nan := float32(math.NaN())
+	for i, v := range []float32{1, nan, nan, nan, 3} {
+		d.SetGrayF32(i, 0, colorext.GrayF32{Y: v})
+	}
+
+	// One iteration fills only the pixels adjacent to valid data.
+	if remaining := FillHoles(d, 1); remaining != 1 {
+		t.Fatalf("FillHoles(1) left %d holes, want 1", remaining)
+	}
+	if v := d.GrayF32At(1, 0).Y; v != 1 {
+		t.Errorf("filled pixel x=1 = %v, want 1", v)
+	}
+	if v := d.GrayF32At(3, 0).Y; v != 3 {
+		t.Errorf("filled pixel x=3 = %v, want 3", v)
+	}
+
+	if remaining := FillHoles(d, 10); remaining != 0 {
+		t.Fatalf("FillHoles(10) left %d holes, want 0", remaining)
+	}
+	if v := d.GrayF32At(2, 0).Y; v != 2 {
+		t.Errorf("filled pixel x=2 = %v, want 2", v)
+	}
+
+	// An image without any valid pixel cannot be filled.
+	empty := colorext.NewGrayF32Image(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			empty.SetGrayF32(x, y, colorext.GrayF32{Y: nan})
+		}
+	}
+	if remaining := FillHoles(empty, 5); remaining != 4 {
+		t.Errorf("FillHoles on all-invalid image left %d holes, want 4", remaining)
+	}
+}
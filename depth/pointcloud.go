@@ -0,0 +1,105 @@
+package depth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Vec3 is a point in camera space. X points right, Y down and Z forward
+// along the optical axis.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// Intrinsics holds pinhole camera parameters in pixels. The principal point
+// uses the same pixel-index convention as OpenCV, so the center of the pixel
+// at column x lies at x, not x + 0.5.
+type Intrinsics struct {
+	Fx, Fy float64
+	Cx, Cy float64
+}
+
+// PointCloudOptions controls ToPointCloud. The zero value samples every
+// pixel and uses the stored depth values unscaled.
+type PointCloudOptions struct {
+	// Stride samples every Stride-th pixel in both directions.
+	// Values below 1 are treated as 1.
+	Stride int
+	// DepthScale multiplies stored depth values, e.g. 0.001 to convert
+	// millimeter GrayS16 depth to meters. Zero is treated as 1.
+	DepthScale float64
+}
+
+// ToPointCloud back-projects a depth image into camera space. img must be a
+// *colorext.GrayF32Image or a *colorext.GrayS16Image; other images yield
+// an error wrapping colorext.ErrUnsupportedDType. Pixels with a
+// non-positive or NaN depth are skipped. opts may be nil.
+func ToPointCloud(img image.Image, k Intrinsics, opts *PointCloudOptions) ([]Vec3, error) {
+	var depthAt func(x, y int) float64
+	switch m := img.(type) {
+	case *colorext.GrayF32Image:
+		depthAt = func(x, y int) float64 { return float64(m.GrayF32At(x, y).Y) }
+	case *colorext.GrayS16Image:
+		depthAt = func(x, y int) float64 { return float64(m.GrayS16At(x, y).Y) }
+	default:
+		return nil, fmt.Errorf("%w: depth from %T", colorext.ErrUnsupportedDType, img)
+	}
+	if k.Fx == 0 || k.Fy == 0 {
+		return nil, errors.New("depth: focal length must be non-zero")
+	}
+
+	stride, scale := 1, 1.0
+	if opts != nil {
+		stride = max(opts.Stride, 1)
+		if opts.DepthScale != 0 {
+			scale = opts.DepthScale
+		}
+	}
+
+	r := img.Bounds()
+	var pts []Vec3
+	for y := r.Min.Y; y < r.Max.Y; y += stride {
+		for x := r.Min.X; x < r.Max.X; x += stride {
+			z := depthAt(x, y) * scale
+			if !(z > 0) || math.IsInf(z, 0) {
+				continue
+			}
+			pts = append(pts, Vec3{
+				X: (float64(x) - k.Cx) * z / k.Fx,
+				Y: (float64(y) - k.Cy) * z / k.Fy,
+				Z: z,
+			})
+		}
+	}
+	return pts, nil
+}
+
+// WriteXYZ writes pts as whitespace-separated "x y z" lines.
+func WriteXYZ(w io.Writer, pts []Vec3) error {
+	bw := bufio.NewWriter(w)
+	for _, p := range pts {
+		if _, err := fmt.Fprintf(bw, "%g %g %g\n", p.X, p.Y, p.Z); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WritePLY writes pts as an ASCII PLY file with float vertex properties.
+func WritePLY(w io.Writer, pts []Vec3) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat ascii 1.0\nelement vertex %d\n", len(pts))
+	fmt.Fprint(bw, "property float x\nproperty float y\nproperty float z\nend_header\n")
+	for _, p := range pts {
+		if _, err := fmt.Fprintf(bw, "%g %g %g\n", p.X, p.Y, p.Z); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
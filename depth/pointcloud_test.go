@@ -0,0 +1,99 @@
+package depth
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func TestToPointCloud(t *testing.T) {
+	d := colorext.NewGrayF32Image(image.Rect(0, 0, 3, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			d.SetGrayF32(x, y, colorext.GrayF32{Y: 2})
+		}
+	}
+	d.SetGrayF32(0, 0, colorext.GrayF32{Y: float32(math.NaN())})
+	d.SetGrayF32(1, 0, colorext.GrayF32{Y: 0})
+
+	k := Intrinsics{Fx: 100, Fy: 200, Cx: 1, Cy: 1}
+	pts, err := ToPointCloud(d, k, nil)
+	if err != nil {
+		t.Fatalf("ToPointCloud: %v", err)
+	}
+	if len(pts) != 7 {
+		t.Fatalf("len(pts) = %d, want 7", len(pts))
+	}
+	// The principal point projects onto the optical axis.
+	found := false
+	for _, p := range pts {
+		if p == (Vec3{0, 0, 2}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("missing point on the optical axis")
+	}
+	// The bottom-right pixel is one pixel right and down of the center.
+	if last := pts[len(pts)-1]; last != (Vec3{X: 0.02, Y: 0.01, Z: 2}) {
+		t.Errorf("last point = %+v, want {0.02 0.01 2}", last)
+	}
+}
+
+func TestToPointCloud_OptionsAndS16(t *testing.T) {
+	d := colorext.NewGrayS16Image(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			d.SetGrayS16(x, y, colorext.GrayS16{Y: 1500})
+		}
+	}
+
+	pts, err := ToPointCloud(d, Intrinsics{Fx: 1, Fy: 1}, &PointCloudOptions{Stride: 2, DepthScale: 0.001})
+	if err != nil {
+		t.Fatalf("ToPointCloud: %v", err)
+	}
+	if len(pts) != 4 {
+		t.Fatalf("len(pts) = %d, want 4 with stride 2", len(pts))
+	}
+	if pts[0].Z != 1.5 {
+		t.Errorf("Z = %v, want 1.5", pts[0].Z)
+	}
+}
+
+func TestToPointCloud_Errors(t *testing.T) {
+	if _, err := ToPointCloud(image.NewGray(image.Rect(0, 0, 1, 1)), Intrinsics{Fx: 1, Fy: 1}, nil); !errors.Is(err, colorext.ErrUnsupportedDType) {
+		t.Errorf("ToPointCloud(Gray) = %v, want %v", err, colorext.ErrUnsupportedDType)
+	}
+	if _, err := ToPointCloud(colorext.NewGrayF32Image(image.Rect(0, 0, 1, 1)), Intrinsics{}, nil); err == nil {
+		t.Error("ToPointCloud with zero focal length returned nil error")
+	}
+}
+
+func TestWriteXYZAndPLY(t *testing.T) {
+	pts := []Vec3{{1, 2, 3}, {-0.5, 0, 1e3}}
+
+	var buf bytes.Buffer
+	if err := WriteXYZ(&buf, pts); err != nil {
+		t.Fatalf("WriteXYZ: %v", err)
+	}
+	if got, want := buf.String(), "1 2 3\n-0.5 0 1000\n"; got != want {
+		t.Errorf("WriteXYZ = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := WritePLY(&buf, pts); err != nil {
+		t.Fatalf("WritePLY: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "ply\nformat ascii 1.0\nelement vertex 2\n") {
+		t.Errorf("WritePLY header = %q", out)
+	}
+	if !strings.HasSuffix(out, "end_header\n1 2 3\n-0.5 0 1000\n") {
+		t.Errorf("WritePLY body = %q", out)
+	}
+}
@@ -0,0 +1,106 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// diffLegendHeight is the height in pixels of the legend strip drawn by
+// DiffHeatmap.
+const diffLegendHeight = 8
+
+// DiffImage returns the per-pixel error between a and b over the bounds of
+// a. Scalar images, such as GrayS16Image, GrayF32Image and the stdlib gray
+// types, are compared by the absolute difference of their raw values, so
+// errors are in signed units. Other images are compared by the Euclidean
+// distance between their premultiplied linear-light RGBA values. Pixels
+// outside b, NaN pixels and invalid pixels of a Validator yield NaN.
+func DiffImage(a, b image.Image) *GrayF32Image {
+	r := a.Bounds()
+	dst := NewGrayF32Image(r)
+	var errAt func(x, y int) float64
+	if isScalar(a) && isScalar(b) {
+		sa, sb := scalarSampler(a), scalarSampler(b)
+		errAt = func(x, y int) float64 { return math.Abs(sa(x, y) - sb(x, y)) }
+	} else {
+		rb := b.Bounds()
+		errAt = func(x, y int) float64 {
+			if !(image.Point{X: x, Y: y}.In(rb)) {
+				return math.NaN()
+			}
+			ca := LinearRGBAF32Model.Convert(a.At(x, y)).(LinearRGBAF32)
+			cb := LinearRGBAF32Model.Convert(b.At(x, y)).(LinearRGBAF32)
+			dr, dg := float64(ca.R-cb.R), float64(ca.G-cb.G)
+			db, da := float64(ca.B-cb.B), float64(ca.A-cb.A)
+			return math.Sqrt(dr*dr + dg*dg + db*db + da*da)
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetGrayF32(x, y, GrayF32{Y: float32(errAt(x, y))})
+		}
+	}
+	return dst
+}
+
+// isScalar reports whether scalarSampler reads img's stored values rather
+// than its luminance.
+func isScalar(img image.Image) bool {
+	switch m := img.(type) {
+	case *ImageWithMeta:
+		return isScalar(m.Image)
+	case *GrayS16Image, *GrayF32Image, *MaskedGrayS16Image, *PhysicalGrayS16Image, *image.Gray, *image.Gray16:
+		return true
+	}
+	return false
+}
+
+// DiffHeatmap renders the error between a and b, as computed by DiffImage,
+// through cmap for visual regression reports. Errors are scaled so that the
+// largest maps to the end of the colormap, and a legend strip running from
+// zero error on the left to the largest on the right is added below the
+// image. Pixels without an error value are transparent. A nil cmap means
+// Magma.
+func DiffHeatmap(a, b image.Image, cmap Colormap) *image.RGBA {
+	if cmap == nil {
+		cmap = Magma
+	}
+	d := DiffImage(a, b)
+	r := d.Rect
+	peak := 0.0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if v := float64(d.GrayF32At(x, y).Y); v > peak {
+				peak = v
+			}
+		}
+	}
+
+	out := r
+	out.Max.Y += diffLegendHeight
+	dst := image.NewRGBA(out)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := float64(d.GrayF32At(x, y).Y)
+			if math.IsNaN(v) {
+				continue
+			}
+			t := 0.0
+			if peak > 0 {
+				t = v / peak
+			}
+			dst.SetRGBA(x, y, cmap.Map(t))
+		}
+	}
+	for x := r.Min.X; x < r.Max.X; x++ {
+		t := 0.0
+		if r.Dx() > 1 {
+			t = float64(x-r.Min.X) / float64(r.Dx()-1)
+		}
+		c := cmap.Map(t)
+		for y := r.Max.Y; y < out.Max.Y; y++ {
+			dst.SetRGBA(x, y, c)
+		}
+	}
+	return dst
+}
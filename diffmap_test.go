@@ -0,0 +1,89 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDiffImage(t *testing.T) {
+	a := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	b := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	a.SetGrayS16(0, 0, GrayS16{Y: -100})
+	b.SetGrayS16(0, 0, GrayS16{Y: 200})
+	a.SetGrayS16(1, 0, GrayS16{Y: 7})
+	b.SetGrayS16(1, 0, GrayS16{Y: 7})
+
+	d := DiffImage(a, b)
+	if d.Rect != a.Rect {
+		t.Fatalf("bounds = %v, want %v", d.Rect, a.Rect)
+	}
+	tests := []struct {
+		x    int
+		want float32
+	}{
+		{0, 300},
+		{1, 0},
+	}
+	for _, tt := range tests {
+		if got := d.GrayF32At(tt.x, 0).Y; got != tt.want {
+			t.Errorf("diff at %d = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+	if got := d.GrayF32At(2, 0).Y; !math.IsNaN(float64(got)) {
+		t.Errorf("diff outside b = %v, want NaN", got)
+	}
+
+	// Color images are compared in linear light.
+	c1 := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	c2 := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	c1.SetRGBA(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	c2.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	c1.SetRGBA(1, 0, color.RGBA{0x80, 0x80, 0x80, 0xff})
+	c2.SetRGBA(1, 0, color.RGBA{0x80, 0x80, 0x80, 0xff})
+	d = DiffImage(c1, c2)
+	if got := d.GrayF32At(0, 0).Y; math.Abs(float64(got)-1) > 1e-6 {
+		t.Errorf("red vs black = %v, want 1", got)
+	}
+	if got := d.GrayF32At(1, 0).Y; got != 0 {
+		t.Errorf("equal colors = %v, want 0", got)
+	}
+}
+
+func TestDiffHeatmap(t *testing.T) {
+	a := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(2, 3, 6, 5)), -1)
+	b := NewGrayS16Image(image.Rect(2, 3, 6, 5))
+	a.SetGrayS16(3, 3, GrayS16{Y: 10})
+	a.SetGrayS16(4, 3, GrayS16{Y: 20})
+	a.SetGrayS16(5, 3, GrayS16{Y: -1})
+
+	m := DiffHeatmap(a, b, nil)
+	want := image.Rect(2, 3, 6, 5+diffLegendHeight)
+	if m.Rect != want {
+		t.Fatalf("bounds = %v, want %v", m.Rect, want)
+	}
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"no error", 2, 3, Magma.Map(0)},
+		{"half error", 3, 3, Magma.Map(0.5)},
+		{"max error", 4, 3, Magma.Map(1)},
+		{"invalid", 5, 3, color.RGBA{}},
+		{"legend start", 2, 5, Magma.Map(0)},
+		{"legend end", 5, 5 + diffLegendHeight - 1, Magma.Map(1)},
+	}
+	for _, tt := range tests {
+		if got := m.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Identical images map entirely to the start of the colormap.
+	m = DiffHeatmap(b, b, Viridis)
+	if got := m.RGBAAt(4, 4); got != Viridis.Map(0) {
+		t.Errorf("identical images: got %v, want %v", got, Viridis.Map(0))
+	}
+}
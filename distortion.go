@@ -0,0 +1,74 @@
+package colorext
+
+import "image"
+
+// Distortion is a Brown–Conrady lens model, as produced by OpenCV and most
+// camera calibration tools. K1, K2 and K3 are the radial coefficients and
+// P1 and P2 the tangential ones. Fx and Fy are the focal lengths and Cx
+// and Cy the principal point, all in pixels.
+//
+// The intrinsics follow the calibration convention in which the center of
+// pixel (0, 0) is at (0, 0), so published values can be used unchanged;
+// methods taking or returning a PointF convert to this package's
+// pixel-center coordinates.
+type Distortion struct {
+	K1, K2, K3 float64
+	P1, P2     float64
+	Fx, Fy     float64
+	Cx, Cy     float64
+}
+
+// Distort maps a point of the ideal, undistorted image to the point of the
+// captured image at which it appears.
+func (d Distortion) Distort(p PointF) PointF {
+	x := (p.X - 0.5 - d.Cx) / d.Fx
+	y := (p.Y - 0.5 - d.Cy) / d.Fy
+	xd, yd := d.distortNormalized(x, y)
+	return PointF{X: d.Fx*xd + d.Cx + 0.5, Y: d.Fy*yd + d.Cy + 0.5}
+}
+
+// Undistort is the inverse of Distort, mapping a point of the captured
+// image to the undistorted image. It solves iteratively and is accurate to
+// a small fraction of a pixel for the moderate distortion of typical
+// lenses.
+func (d Distortion) Undistort(p PointF) PointF {
+	xd := (p.X - 0.5 - d.Cx) / d.Fx
+	yd := (p.Y - 0.5 - d.Cy) / d.Fy
+	x, y := xd, yd
+	for range 20 {
+		r2 := x*x + y*y
+		radial := 1 + r2*(d.K1+r2*(d.K2+r2*d.K3))
+		dx := 2*d.P1*x*y + d.P2*(r2+2*x*x)
+		dy := d.P1*(r2+2*y*y) + 2*d.P2*x*y
+		x = (xd - dx) / radial
+		y = (yd - dy) / radial
+	}
+	return PointF{X: d.Fx*x + d.Cx + 0.5, Y: d.Fy*y + d.Cy + 0.5}
+}
+
+// distortNormalized applies the distortion to normalized image
+// coordinates.
+func (d Distortion) distortNormalized(x, y float64) (xd, yd float64) {
+	r2 := x*x + y*y
+	radial := 1 + r2*(d.K1+r2*(d.K2+r2*d.K3))
+	xd = x*radial + 2*d.P1*x*y + d.P2*(r2+2*x*x)
+	yd = y*radial + d.P1*(r2+2*y*y) + 2*d.P2*x*y
+	return xd, yd
+}
+
+// BuildRemapTables returns coordinate maps over r for Remap that undistort
+// an image captured through the lens, keeping the same intrinsics. Each
+// entry holds the position in the captured image, in pixel-center
+// coordinates, that the output pixel samples. Building the tables once
+// and reusing them for every frame avoids recomputing the model.
+func (d Distortion) BuildRemapTables(r image.Rectangle) (mapX, mapY *GrayF32Image) {
+	mapX, mapY = NewGrayF32Image(r), NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := d.Distort(PointF{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+			mapX.SetGrayF32(x, y, GrayF32{Y: float32(p.X)})
+			mapY.SetGrayF32(x, y, GrayF32{Y: float32(p.Y)})
+		}
+	}
+	return mapX, mapY
+}
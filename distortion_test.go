@@ -0,0 +1,89 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestDistortion(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Distortion
+	}{
+		{"barrel", Distortion{K1: -0.25, K2: 0.08, Fx: 500, Fy: 500, Cx: 319.5, Cy: 239.5}},
+		{"pincushion", Distortion{K1: 0.12, K3: 0.01, Fx: 640, Fy: 620, Cx: 321, Cy: 238}},
+		{"tangential", Distortion{K1: -0.1, P1: 0.002, P2: -0.001, Fx: 500, Fy: 510, Cx: 330, Cy: 245}},
+	}
+	pts := []PointF{{320, 240}, {10.5, 20.25}, {600, 60}, {200, 470}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range pts {
+				q := tt.d.Undistort(tt.d.Distort(p))
+				if math.Hypot(q.X-p.X, q.Y-p.Y) > 1e-3 {
+					t.Errorf("Undistort(Distort(%v)) = %v", p, q)
+				}
+			}
+			// The principal point is a fixed point.
+			c := PointF{X: tt.d.Cx + 0.5, Y: tt.d.Cy + 0.5}
+			if got := tt.d.Distort(c); math.Hypot(got.X-c.X, got.Y-c.Y) > 1e-9 {
+				t.Errorf("Distort(%v) = %v, want unchanged", c, got)
+			}
+		})
+	}
+}
+
+func TestDistortionKnownValue(t *testing.T) {
+	// Normalized point (0.2, 0.1): r² = 0.05, radial = 1 + 0.1·0.05 = 1.005,
+	// tangential x = 2·0.01·0.02 + 0.02·(0.05+0.08) = 0.0030,
+	// tangential y = 0.01·(0.05+0.02) + 2·0.02·0.02 = 0.0015.
+	d := Distortion{K1: 0.1, P1: 0.01, P2: 0.02, Fx: 100, Fy: 200, Cx: 50, Cy: 60}
+	got := d.Distort(PointF{X: 50 + 20 + 0.5, Y: 60 + 20 + 0.5})
+	want := PointF{X: 50 + 100*(0.2*1.005+0.003) + 0.5, Y: 60 + 200*(0.1*1.005+0.0015) + 0.5}
+	if math.Abs(got.X-want.X) > 1e-9 || math.Abs(got.Y-want.Y) > 1e-9 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBuildRemapTables(t *testing.T) {
+	d := Distortion{K1: -0.2, K2: 0.05, Fx: 40, Fy: 40, Cx: 31.5, Cy: 23.5}
+	r := image.Rect(0, 0, 64, 48)
+	mapX, mapY := d.BuildRemapTables(r)
+	if mapX.Rect != r || mapY.Rect != r {
+		t.Fatalf("bounds %v, %v, want %v", mapX.Rect, mapY.Rect, r)
+	}
+	for _, p := range []image.Point{{0, 0}, {10, 30}, {63, 47}} {
+		want := d.Distort(PointF{X: float64(p.X) + 0.5, Y: float64(p.Y) + 0.5})
+		gx, gy := float64(mapX.GrayF32At(p.X, p.Y).Y), float64(mapY.GrayF32At(p.X, p.Y).Y)
+		if math.Abs(gx-want.X) > 1e-4 || math.Abs(gy-want.Y) > 1e-4 {
+			t.Errorf("%v: map (%v, %v), want %v", p, gx, gy, want)
+		}
+	}
+
+	// Undistorting a distorted grid of dots restores the dot positions.
+	dots := []PointF{{12.5, 10.5}, {50.5, 12.5}, {32.5, 24.5}, {8.5, 40.5}}
+	var distorted []PointF
+	for _, p := range dots {
+		distorted = append(distorted, d.Distort(p))
+	}
+	captured := gaussSpots(r, distorted, 1000, 1.2)
+	out, err := Remap(captured, mapX, mapY, Bicubic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peaks := FindPeaks(out, 3, 200)
+	if len(peaks) != len(dots) {
+		t.Fatalf("got %d peaks, want %d: %v", len(peaks), len(dots), peaks)
+	}
+	for _, p := range dots {
+		found := false
+		for _, q := range peaks {
+			if math.Hypot(q.Center.X-p.X, q.Center.Y-p.Y) < 0.3 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no undistorted dot near %v: %v", p, peaks)
+		}
+	}
+}
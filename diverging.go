@@ -0,0 +1,92 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// Built-in diverging colormaps. Their neutral color sits at t = 0.5.
+var (
+	// CoolWarm is Kenneth Moreland's blue-gray-red diverging map.
+	CoolWarm Colormap = LinearColormap{
+		{59, 76, 192, 0xff}, {98, 130, 234, 0xff}, {141, 176, 254, 0xff},
+		{184, 208, 249, 0xff}, {221, 221, 221, 0xff}, {245, 196, 173, 0xff},
+		{244, 154, 123, 0xff}, {222, 96, 77, 0xff}, {180, 4, 38, 0xff},
+	}
+	// RdBu is the ColorBrewer red-white-blue diverging map, running from
+	// red at t = 0 to blue at t = 1.
+	RdBu Colormap = LinearColormap{
+		{0x67, 0x00, 0x1f, 0xff}, {0xb2, 0x18, 0x2b, 0xff}, {0xd6, 0x60, 0x4d, 0xff},
+		{0xf4, 0xa5, 0x82, 0xff}, {0xfd, 0xdb, 0xc7, 0xff}, {0xf7, 0xf7, 0xf7, 0xff},
+		{0xd1, 0xe5, 0xf0, 0xff}, {0x92, 0xc5, 0xde, 0xff}, {0x43, 0x93, 0xc3, 0xff},
+		{0x21, 0x66, 0xac, 0xff}, {0x05, 0x30, 0x61, 0xff},
+	}
+)
+
+// RenderDiverging renders src through a diverging colormap with zero anchored
+// at the colormap's midpoint. Negative values are scaled independently of
+// positive ones: lo maps to the start of the colormap and hi to its end, so
+// an asymmetric range such as [-100, 4000] still places zero at the neutral
+// color. Values beyond the range are clamped. A non-negative lo or a
+// non-positive hi collapses that half of the colormap onto the midpoint.
+// Invalid pixels of a Validator are left transparent, as by RenderColormap.
+func RenderDiverging(src GrayS16Source, cmap Colormap, lo, hi int16) *image.RGBA {
+	r := src.Bounds()
+	dst := image.NewRGBA(r)
+	v, hasValidity := src.(Validator)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if hasValidity && !v.Valid(x, y) {
+				continue
+			}
+			dst.SetRGBA(x, y, divergingColor(cmap, src.GrayS16At(x, y).Y, lo, hi))
+		}
+	}
+	return dst
+}
+
+// RenderDivergingSymmetric renders src through a diverging colormap using the
+// symmetric range [-limit, limit]. If limit is zero or negative, the largest
+// absolute value of the valid pixels in src is used so that the full
+// colormap is exercised.
+func RenderDivergingSymmetric(src GrayS16Source, cmap Colormap, limit int16) *image.RGBA {
+	l := int32(limit)
+	if l <= 0 {
+		l = maxAbsS16(src)
+	}
+	// -32768 has no positive counterpart; saturate the range instead.
+	l = min(l, 32767)
+	return RenderDiverging(src, cmap, int16(-l), int16(l))
+}
+
+// divergingColor maps v onto cmap with zero at t = 0.5.
+func divergingColor(cmap Colormap, v, lo, hi int16) color.RGBA {
+	t := 0.5
+	switch {
+	case v < 0 && lo < 0:
+		t = 0.5 - 0.5*float64(v)/float64(lo)
+	case v > 0 && hi > 0:
+		t = 0.5 + 0.5*float64(v)/float64(hi)
+	}
+	return cmap.Map(t)
+}
+
+// maxAbsS16 returns the largest absolute value of the valid pixels in img.
+func maxAbsS16(img GrayS16Source) int32 {
+	var m int32
+	r := img.Bounds()
+	valid, hasValidity := img.(Validator)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if hasValidity && !valid.Valid(x, y) {
+				continue
+			}
+			v := int32(img.GrayS16At(x, y).Y)
+			if v < 0 {
+				v = -v
+			}
+			m = max(m, v)
+		}
+	}
+	return m
+}
@@ -0,0 +1,101 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDivergingColormaps_Midpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		cmap Colormap
+		want color.RGBA
+	}{
+		{"CoolWarm", CoolWarm, color.RGBA{221, 221, 221, 0xff}},
+		{"RdBu", RdBu, color.RGBA{0xf7, 0xf7, 0xf7, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmap.Map(0.5); got != tt.want {
+				t.Errorf("Map(0.5) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDiverging_Asymmetric(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 5, 1))
+	for i, v := range []int16{-100, -50, 0, 2000, 4000} {
+		src.SetGrayS16(i, 0, GrayS16{Y: v})
+	}
+
+	dst := RenderDiverging(src, CoolWarm, -100, 4000)
+	want := []float64{0, 0.25, 0.5, 0.75, 1}
+	for i, w := range want {
+		if got := dst.RGBAAt(i, 0); got != CoolWarm.Map(w) {
+			t.Errorf("RGBAAt(%d, 0) = %v, want Map(%v) = %v", i, got, w, CoolWarm.Map(w))
+		}
+	}
+}
+
+func TestRenderDiverging_Clamps(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	src.SetGrayS16(0, 0, GrayS16{Y: -32768})
+	src.SetGrayS16(1, 0, GrayS16{Y: 32767})
+
+	dst := RenderDiverging(src, RdBu, -10, 10)
+	if got := dst.RGBAAt(0, 0); got != RdBu.Map(0) {
+		t.Errorf("RGBAAt(0, 0) = %v, want %v", got, RdBu.Map(0))
+	}
+	if got := dst.RGBAAt(1, 0); got != RdBu.Map(1) {
+		t.Errorf("RGBAAt(1, 0) = %v, want %v", got, RdBu.Map(1))
+	}
+
+	// With no negative range, negative values collapse onto the midpoint.
+	dst = RenderDiverging(src, RdBu, 0, 10)
+	if got := dst.RGBAAt(0, 0); got != RdBu.Map(0.5) {
+		t.Errorf("RGBAAt(0, 0) with lo = 0 is %v, want %v", got, RdBu.Map(0.5))
+	}
+}
+
+func TestRenderDivergingSymmetric(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	src.SetGrayS16(0, 0, GrayS16{Y: -20})
+	src.SetGrayS16(1, 0, GrayS16{Y: 10})
+	src.SetGrayS16(2, 0, GrayS16{Y: 5})
+
+	// The automatic limit is max(|v|) = 20.
+	dst := RenderDivergingSymmetric(src, CoolWarm, 0)
+	if got := dst.RGBAAt(0, 0); got != CoolWarm.Map(0) {
+		t.Errorf("RGBAAt(0, 0) = %v, want %v", got, CoolWarm.Map(0))
+	}
+	if got := dst.RGBAAt(1, 0); got != CoolWarm.Map(0.75) {
+		t.Errorf("RGBAAt(1, 0) = %v, want %v", got, CoolWarm.Map(0.75))
+	}
+
+	dst = RenderDivergingSymmetric(src, CoolWarm, 10)
+	if got := dst.RGBAAt(0, 0); got != CoolWarm.Map(0) {
+		t.Errorf("RGBAAt(0, 0) with limit 10 = %v, want %v", got, CoolWarm.Map(0))
+	}
+	if got := dst.RGBAAt(2, 0); got != CoolWarm.Map(0.75) {
+		t.Errorf("RGBAAt(2, 0) with limit 10 = %v, want %v", got, CoolWarm.Map(0.75))
+	}
+
+	// The most negative value must not overflow the automatic limit.
+	src.SetGrayS16(0, 0, GrayS16{Y: -32768})
+	dst = RenderDivergingSymmetric(src, CoolWarm, 0)
+	if got := dst.RGBAAt(0, 0); got != CoolWarm.Map(0) {
+		t.Errorf("RGBAAt(0, 0) for -32768 = %v, want %v", got, CoolWarm.Map(0))
+	}
+
+	// A NoData sentinel neither widens the automatic limit nor is drawn.
+	dst = RenderDivergingSymmetric(NewMaskedGrayS16Image(src, -32768), CoolWarm, 0)
+	if got := dst.RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("RGBAAt(0, 0) for NoData = %v, want transparent", got)
+	}
+	if got := dst.RGBAAt(1, 0); got != CoolWarm.Map(1) {
+		t.Errorf("masked RGBAAt(1, 0) = %v, want %v", got, CoolWarm.Map(1))
+	}
+}
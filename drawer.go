@@ -0,0 +1,96 @@
+package colorext
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Drawer is a draw.Drawer using the given Porter-Duff operator. It copies
+// GrayS16Image sources onto GrayS16Image destinations row by row, and
+// converts *image.Gray16 sources with a row kernel, instead of going through
+// At and Set for every pixel. A UniformImage source fills rows with a
+// single value. As opaque sources replace the destination, draw.Over and
+// draw.Src take the same fast path. Other combinations, and any
+// non-nil mask, are passed to draw.DrawMask.
+type Drawer draw.Op
+
+// Draw implements the draw.Drawer interface.
+func (d Drawer) Draw(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	d.DrawMask(dst, r, src, sp, nil, image.Point{})
+}
+
+// DrawMask is like draw.DrawMask with the fast paths described on Drawer.
+func (d Drawer) DrawMask(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point, mask image.Image, mp image.Point) {
+	if mask == nil {
+		if dst, ok := dst.(*GrayS16Image); ok {
+			switch src := src.(type) {
+			case *GrayS16Image:
+				r, sp, _ = clipComposite(dst.Rect, r, src.Rect, sp, nil, mp)
+				copyRows16(dst.Pix, dst.Stride, dst.PixOffset(r.Min.X, r.Min.Y),
+					src.Pix, src.Stride, src.PixOffset(sp.X, sp.Y), r, sp)
+				return
+			case *image.Gray16:
+				r, sp, _ = clipComposite(dst.Rect, r, src.Rect, sp, nil, mp)
+				if r.Empty() {
+					return
+				}
+				di, si := dst.PixOffset(r.Min.X, r.Min.Y), src.PixOffset(sp.X, sp.Y)
+				convertRows(dst.Pix[di:], dst.Stride, src.Pix[si:], src.Stride, 2*r.Dx(), r.Dy(), flipSign16)
+				return
+			case *UniformImage:
+				if draw.Op(d) == draw.Src || src.Opaque() {
+					fillRows16(dst, r.Intersect(dst.Rect), src.GrayS16At(0, 0))
+					return
+				}
+			}
+		}
+	}
+	draw.DrawMask(dst, r, src, sp, mask, mp, draw.Op(d))
+}
+
+// copyRows16 copies the 16-bit pixels of r from src, starting at byte si,
+// to dst, starting at byte di. When dst and src are the same image, rows
+// are copied in the order that preserves overlapping data.
+func copyRows16(dst []uint8, dstStride, di int, src []uint8, srcStride, si int, r image.Rectangle, sp image.Point) {
+	if r.Empty() {
+		return
+	}
+	n, h := 2*r.Dx(), r.Dy()
+	if r.Min.Y > sp.Y {
+		// Copying downwards within one buffer must start at the bottom.
+		for y := h - 1; y >= 0; y-- {
+			copy(dst[di+y*dstStride:di+y*dstStride+n], src[si+y*srcStride:si+y*srcStride+n])
+		}
+		return
+	}
+	for y := 0; y < h; y++ {
+		copy(dst[di+y*dstStride:di+y*dstStride+n], src[si+y*srcStride:si+y*srcStride+n])
+	}
+}
+
+// fillRows16 sets every pixel of dst within r to c.
+func fillRows16(dst *GrayS16Image, r image.Rectangle, c GrayS16) {
+	if r.Empty() {
+		return
+	}
+	n := 2 * r.Dx()
+	i := dst.PixOffset(r.Min.X, r.Min.Y)
+	row := dst.Pix[i : i+n]
+	for j := 0; j < n; j += 2 {
+		row[j], row[j+1] = uint8(uint16(c.Y)>>8), uint8(uint16(c.Y))
+	}
+	for y := 1; y < r.Dy(); y++ {
+		copy(dst.Pix[i+y*dst.Stride:i+y*dst.Stride+n], row)
+	}
+}
+
+// Draw calls DrawMask with a nil mask.
+func Draw(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point, op draw.Op) {
+	Drawer(op).DrawMask(dst, r, src, sp, nil, image.Point{})
+}
+
+// DrawMask is a drop-in replacement for draw.DrawMask that takes the fast
+// paths described on Drawer.
+func DrawMask(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point, mask image.Image, mp image.Point, op draw.Op) {
+	Drawer(op).DrawMask(dst, r, src, sp, mask, mp)
+}
@@ -0,0 +1,88 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDrawer(t *testing.T) {
+	src := randomGrayS16(image.Rect(-3, 2, 13, 12), 21)
+	g16 := GrayS16ToGray16(src)
+	tests := []struct {
+		name string
+		src  image.Image
+		r    image.Rectangle
+		sp   image.Point
+		op   draw.Op
+	}{
+		{"S16 src", src, image.Rect(0, 0, 8, 8), image.Pt(-3, 2), draw.Src},
+		{"S16 over clipped", src, image.Rect(-4, -4, 20, 20), image.Pt(0, 4), draw.Over},
+		{"Gray16 src", g16, image.Rect(1, 1, 9, 7), image.Pt(2, 3), draw.Src},
+		{"Gray16 clipped", g16, image.Rect(2, 0, 20, 5), image.Pt(8, 9), draw.Over},
+		{"fallback", image.NewUniform(color.Gray16{Y: 0x1234}), image.Rect(0, 0, 4, 4), image.Point{}, draw.Src},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := randomGrayS16(image.Rect(0, 0, 10, 10), 22)
+			got := NewGrayS16Image(want.Rect)
+			copy(got.Pix, want.Pix)
+			draw.Draw(want, tt.r, tt.src, tt.sp, tt.op)
+			Drawer(tt.op).Draw(got, tt.r, tt.src, tt.sp)
+			if !bytes.Equal(got.Pix, want.Pix) {
+				t.Error("result differs from draw.Draw")
+			}
+		})
+	}
+}
+
+func TestDrawerOverlap(t *testing.T) {
+	// Scrolling an image within itself must match drawing from a copy.
+	for _, d := range []image.Point{{0, 2}, {0, -2}, {3, 0}, {-3, 1}} {
+		img := randomGrayS16(image.Rect(0, 0, 8, 8), 23)
+		want := NewGrayS16Image(img.Rect)
+		copy(want.Pix, img.Pix)
+		draw.Draw(want, want.Rect.Add(d), GrayS16ToGray16(img), image.Point{}, draw.Src)
+
+		Draw(img, img.Rect.Add(d), img, image.Point{}, draw.Src)
+		if !bytes.Equal(img.Pix, want.Pix) {
+			t.Errorf("scroll by %v differs from drawing a copy", d)
+		}
+	}
+}
+
+func TestDrawMaskFallback(t *testing.T) {
+	dst := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	src := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	src.SetGrayS16(0, 0, GrayS16{Y: 100})
+	src.SetGrayS16(1, 0, GrayS16{Y: 200})
+	mask := image.NewAlpha(dst.Rect)
+	mask.SetAlpha(1, 0, color.Alpha{A: 0xff})
+
+	DrawMask(dst, dst.Rect, src, image.Point{}, mask, image.Point{}, draw.Over)
+	if got := dst.GrayS16At(0, 0).Y; got != 0 {
+		t.Errorf("masked pixel = %d, want 0", got)
+	}
+	if got := dst.GrayS16At(1, 0).Y; got != 200 {
+		t.Errorf("unmasked pixel = %d, want 200", got)
+	}
+}
+
+func BenchmarkDrawer(b *testing.B) {
+	src := randomGrayS16(image.Rect(0, 0, 1920, 1080), 24)
+	dst := NewGrayS16Image(src.Rect)
+	b.Run("Drawer", func(b *testing.B) {
+		b.SetBytes(int64(len(src.Pix)))
+		for b.Loop() {
+			Drawer(draw.Src).Draw(dst, dst.Rect, src, image.Point{})
+		}
+	})
+	b.Run("Stdlib", func(b *testing.B) {
+		b.SetBytes(int64(len(src.Pix)))
+		for b.Loop() {
+			draw.Draw(dst, dst.Rect, src, image.Point{}, draw.Src)
+		}
+	})
+}
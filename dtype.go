@@ -0,0 +1,102 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+)
+
+// DType identifies the sample type of a single-plane image.
+type DType uint8
+
+// Sample types of the gray image types. Complex types hold the real part
+// before the imaginary part.
+const (
+	DTypeInt16      DType = iota + 1 // GrayS16Image
+	DTypeFloat32                     // GrayF32Image
+	DTypeUint32                      // GrayU32Image
+	DTypeUint64                      // GrayU64Image
+	DTypeInt64                       // GrayS64Image
+	DTypeComplex64                   // GrayC64Image
+	DTypeComplex128                  // GrayC128Image
+)
+
+var dtypes = [...]struct {
+	name string
+	size int
+}{
+	DTypeInt16:      {"int16", 2},
+	DTypeFloat32:    {"float32", 4},
+	DTypeUint32:     {"uint32", 4},
+	DTypeUint64:     {"uint64", 8},
+	DTypeInt64:      {"int64", 8},
+	DTypeComplex64:  {"complex64", 8},
+	DTypeComplex128: {"complex128", 16},
+}
+
+// Size returns the size of a sample in bytes, or 0 if d is not a valid
+// DType.
+func (d DType) Size() int {
+	if int(d) < len(dtypes) {
+		return dtypes[d].size
+	}
+	return 0
+}
+
+// String returns the name of the Go type holding a sample, such as "int16".
+func (d DType) String() string {
+	if d != 0 && int(d) < len(dtypes) {
+		return dtypes[d].name
+	}
+	return fmt.Sprintf("DType(%d)", uint8(d))
+}
+
+// grayLayout returns the sample type, pixel buffer and stride of img if it is
+// one of the gray image types.
+func grayLayout(img image.Image) (d DType, pix []uint8, stride int, ok bool) {
+	switch m := img.(type) {
+	case *GrayS16Image:
+		return DTypeInt16, m.Pix, m.Stride, true
+	case *GrayF32Image:
+		return DTypeFloat32, m.Pix, m.Stride, true
+	case *GrayU32Image:
+		return DTypeUint32, m.Pix, m.Stride, true
+	case *GrayU64Image:
+		return DTypeUint64, m.Pix, m.Stride, true
+	case *GrayS64Image:
+		return DTypeInt64, m.Pix, m.Stride, true
+	case *GrayC64Image:
+		return DTypeComplex64, m.Pix, m.Stride, true
+	case *GrayC128Image:
+		return DTypeComplex128, m.Pix, m.Stride, true
+	}
+	return 0, nil, 0, false
+}
+
+// newGrayImage returns a new image of the gray type holding samples of type
+// d, along with its pixel buffer.
+func newGrayImage(d DType, r image.Rectangle) (image.Image, []uint8) {
+	switch d {
+	case DTypeInt16:
+		m := NewGrayS16Image(r)
+		return m, m.Pix
+	case DTypeFloat32:
+		m := NewGrayF32Image(r)
+		return m, m.Pix
+	case DTypeUint32:
+		m := NewGrayU32Image(r)
+		return m, m.Pix
+	case DTypeUint64:
+		m := NewGrayU64Image(r)
+		return m, m.Pix
+	case DTypeInt64:
+		m := NewGrayS64Image(r)
+		return m, m.Pix
+	case DTypeComplex64:
+		m := NewGrayC64Image(r)
+		return m, m.Pix
+	case DTypeComplex128:
+		m := NewGrayC128Image(r)
+		return m, m.Pix
+	}
+	return nil, nil
+}
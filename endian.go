@@ -0,0 +1,30 @@
+package colorext
+
+// SwapEndianness reverses the byte order of every pixel of img in place.
+// It fixes up an image whose Pix was filled from a little-endian source,
+// such as a raw camera buffer, without converting pixel by pixel. Only the
+// pixels inside img's bounds are touched, so sub-images swap only their own
+// region.
+func SwapEndianness(img *GrayS16Image) {
+	swapRows(img.Pix, img.Stride, 2*img.Rect.Dx(), img.Rect.Dy(), SwapBytes16)
+}
+
+// SwapEndiannessF32 is like SwapEndianness for GrayF32Image.
+func SwapEndiannessF32(img *GrayF32Image) {
+	swapRows(img.Pix, img.Stride, 4*img.Rect.Dx(), img.Rect.Dy(), SwapBytes32)
+}
+
+// swapRows applies swap to h rows of n bytes, as a single span when the rows
+// are contiguous.
+func swapRows(pix []uint8, stride, n, h int, swap func([]uint8)) {
+	if n == 0 || h == 0 {
+		return
+	}
+	if stride == n {
+		swap(pix[:n*h])
+		return
+	}
+	for y := 0; y < h; y++ {
+		swap(pix[y*stride : y*stride+n])
+	}
+}
@@ -0,0 +1,54 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestSwapEndianness(t *testing.T) {
+	// Fill a buffer the way a little-endian source would.
+	r := image.Rect(0, 0, 5, 3)
+	img := NewGrayS16Image(r)
+	for i := 0; i < 15; i++ {
+		binary.LittleEndian.PutUint16(img.Pix[2*i:], uint16(int16(i*1000-7000)))
+	}
+	SwapEndianness(img)
+	for i := 0; i < 15; i++ {
+		if got, want := img.GrayS16At(i%5, i/5).Y, int16(i*1000-7000); got != want {
+			t.Errorf("pixel %d = %d, want %d", i, got, want)
+		}
+	}
+
+	// A sub-image swaps only its own pixels.
+	before := append([]uint8(nil), img.Pix...)
+	sub := img.SubImage(image.Rect(1, 1, 3, 3)).(*GrayS16Image)
+	SwapEndianness(sub)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			i := img.PixOffset(x, y)
+			inside := image.Pt(x, y).In(sub.Rect)
+			swapped := img.Pix[i] == before[i+1] && img.Pix[i+1] == before[i]
+			unchanged := img.Pix[i] == before[i] && img.Pix[i+1] == before[i+1]
+			if inside && !swapped || !inside && !unchanged {
+				t.Errorf("pixel (%d, %d) inside=%v: got % x, was % x", x, y, inside, img.Pix[i:i+2], before[i:i+2])
+			}
+		}
+	}
+	SwapEndianness(NewGrayS16Image(image.Rectangle{}))
+}
+
+func TestSwapEndiannessF32(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 3, 2))
+	want := []float32{1.5, -2, 0, float32(math.Inf(1)), 1e-20, 3}
+	for i, v := range want {
+		binary.LittleEndian.PutUint32(img.Pix[4*i:], math.Float32bits(v))
+	}
+	SwapEndiannessF32(img)
+	for i, v := range want {
+		if got := img.GrayF32At(i%3, i/3).Y; got != v {
+			t.Errorf("pixel %d = %v, want %v", i, got, v)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"reflect"
+)
+
+// Equal reports whether a and b have the same bounds and the same pixels.
+//
+// Images of the same concrete type with a plain Pix layout, including the
+// stdlib's, are compared by their stored samples. Float samples compare by
+// value, except that NaN equals NaN, so +0 matches -0 and NaN pixels match
+// each other. The unused top bit of RGB555 pixels is ignored. Other images
+// are compared by the RGBA values of their pixels.
+func Equal(a, b image.Image) bool {
+	r := a.Bounds()
+	if r != b.Bounds() {
+		return false
+	}
+	if reflect.TypeOf(a) == reflect.TypeOf(b) {
+		pa, sa, bpp, okA := pixelLayout(a)
+		pb, sb, _, okB := pixelLayout(b)
+		if okA && okB {
+			n := r.Dx() * bpp
+			eq := samplesEqual(a)
+			for y := 0; y < r.Dy(); y++ {
+				if !eq(pa[y*sa:y*sa+n], pb[y*sb:y*sb+n]) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EqualWithinTolerance reports whether a and b have the same bounds and no
+// pixels differing by more than tol. It also returns an image holding a-b
+// for each pixel, saturated to the int16 range, for debugging. If the bounds
+// differ, it returns false and a nil image.
+func EqualWithinTolerance(a, b *GrayS16Image, tol int16) (bool, *GrayS16Image) {
+	if a.Rect != b.Rect {
+		return false, nil
+	}
+	diff := NewGrayS16Image(a.Rect)
+	ok := true
+	n := 2 * a.Rect.Dx()
+	for y := 0; y < a.Rect.Dy(); y++ {
+		ra := a.Pix[y*a.Stride : y*a.Stride+n]
+		rb := b.Pix[y*b.Stride : y*b.Stride+n]
+		rd := diff.Pix[y*diff.Stride : y*diff.Stride+n]
+		for i := 0; i+1 < len(ra); i += 2 {
+			va := int32(int16(uint16(ra[i])<<8 | uint16(ra[i+1])))
+			vb := int32(int16(uint16(rb[i])<<8 | uint16(rb[i+1])))
+			d := va - vb
+			if d > int32(tol) || -d > int32(tol) {
+				ok = false
+			}
+			d = max(-32768, min(d, 32767))
+			rd[i], rd[i+1] = uint8(uint16(d)>>8), uint8(d)
+		}
+	}
+	return ok, diff
+}
+
+// samplesEqual returns the function Equal uses to compare runs of the
+// stored samples of two images of img's type.
+func samplesEqual(img image.Image) func(a, b []uint8) bool {
+	switch img.(type) {
+	case *GrayF32Image, *GrayC64Image, *LinearRGBAF32Image, *RGBAF32Image, *NRGBAF32Image:
+		return func(a, b []uint8) bool {
+			for i := 0; i+4 <= len(a); i += 4 {
+				if x, y := getF32(a[i:]), getF32(b[i:]); x != y && !(math.IsNaN(float64(x)) && math.IsNaN(float64(y))) {
+					return false
+				}
+			}
+			return true
+		}
+	case *GrayC128Image:
+		return func(a, b []uint8) bool {
+			for i := 0; i+8 <= len(a); i += 8 {
+				if x, y := getF64(a[i:]), getF64(b[i:]); x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+					return false
+				}
+			}
+			return true
+		}
+	case *RGB555Image:
+		return func(a, b []uint8) bool {
+			for i := 0; i+2 <= len(a); i += 2 {
+				if a[i]&0x7f != b[i]&0x7f || a[i+1] != b[i+1] {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return bytes.Equal
+}
+
+// pixelLayout returns the pixel buffer of img, positioned at its first pixel,
+// along with the stride and the number of bytes per pixel, if img stores
+// every pixel in a fixed number of bytes and has no other state affecting
+// its colors.
+func pixelLayout(img image.Image) (pix []uint8, stride, bpp int, ok bool) {
+	switch m := img.(type) {
+	case *GrayS16Image:
+		return m.Pix, m.Stride, 2, true
+	case *GrayF32Image:
+		return m.Pix, m.Stride, 4, true
+	case *GrayU32Image:
+		return m.Pix, m.Stride, 4, true
+	case *GrayU64Image:
+		return m.Pix, m.Stride, 8, true
+	case *GrayS64Image:
+		return m.Pix, m.Stride, 8, true
+	case *GrayC64Image:
+		return m.Pix, m.Stride, 8, true
+	case *GrayC128Image:
+		return m.Pix, m.Stride, 16, true
+	case *LinearRGBAF32Image:
+		return m.Pix, m.Stride, 16, true
+	case *RGBAF32Image:
+		return m.Pix, m.Stride, 16, true
+	case *NRGBAF32Image:
+		return m.Pix, m.Stride, 16, true
+	case *CMYK64Image:
+		return m.Pix, m.Stride, 8, true
+	case *BGRImage:
+		return m.Pix, m.Stride, 3, true
+	case *BGRAImage:
+		return m.Pix, m.Stride, 4, true
+	case *BGR48Image:
+		return m.Pix, m.Stride, 6, true
+	case *BGRA64Image:
+		return m.Pix, m.Stride, 8, true
+	case *RGB565Image:
+		return m.Pix, m.Stride, 2, true
+	case *RGB555Image:
+		return m.Pix, m.Stride, 2, true
+	case *image.Gray:
+		return m.Pix, m.Stride, 1, true
+	case *image.Gray16:
+		return m.Pix, m.Stride, 2, true
+	case *image.Alpha:
+		return m.Pix, m.Stride, 1, true
+	case *image.Alpha16:
+		return m.Pix, m.Stride, 2, true
+	case *image.RGBA:
+		return m.Pix, m.Stride, 4, true
+	case *image.RGBA64:
+		return m.Pix, m.Stride, 8, true
+	case *image.NRGBA:
+		return m.Pix, m.Stride, 4, true
+	case *image.NRGBA64:
+		return m.Pix, m.Stride, 8, true
+	case *image.CMYK:
+		return m.Pix, m.Stride, 4, true
+	}
+	return nil, 0, 0, false
+}
@@ -0,0 +1,144 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	s16 := func(vals ...int16) *GrayS16Image {
+		img := NewGrayS16Image(r)
+		for i, v := range vals {
+			img.SetGrayS16(i%3, i/3, GrayS16{Y: v})
+		}
+		return img
+	}
+	base := s16(1, 2, 3, 4, 5, 6)
+
+	// A sub-image with a wider stride holding the same pixels.
+	wide := NewGrayS16Image(image.Rect(0, 0, 5, 2))
+	for i := range 6 {
+		wide.SetGrayS16(i%3, i/3, GrayS16{Y: int16(i + 1)})
+	}
+	wide.SetGrayS16(4, 1, GrayS16{Y: 99})
+	narrow := wide.SubImage(r)
+
+	nan := NewGrayF32Image(r)
+	nan.SetGrayF32(1, 1, GrayF32{Y: float32(math.NaN())})
+	otherNaN := NewGrayF32Image(r)
+	otherNaN.SetGrayF32(1, 1, GrayF32{Y: math.Float32frombits(0xffc00001)})
+	negZero := NewGrayF32Image(r)
+	negZero.SetGrayF32(0, 0, GrayF32{Y: float32(math.Copysign(0, -1))})
+	c128 := NewGrayC128Image(r)
+	c128.SetGrayC128(0, 1, GrayC128{Y: complex(math.Copysign(0, -1), 0)})
+
+	rgb555 := NewRGB555Image(r)
+	rgb555.SetRGB555(1, 0, 0x1234)
+	topBit := NewRGB555Image(r)
+	topBit.SetRGB555(1, 0, 0x9234)
+
+	gray := image.NewGray(r)
+	gray.SetGray(2, 0, color.Gray{Y: 0x80})
+	rgba := image.NewRGBA(r)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			rgba.Set(x, y, color.Black)
+		}
+	}
+	rgba.Set(2, 0, color.Gray{Y: 0x80})
+
+	tests := []struct {
+		name string
+		a, b image.Image
+		want bool
+	}{
+		{"identical", base, s16(1, 2, 3, 4, 5, 6), true},
+		{"one pixel differs", base, s16(1, 2, 3, 4, 5, 7), false},
+		{"different strides", base, narrow, true},
+		{"different bounds", base, NewGrayS16Image(image.Rect(0, 0, 3, 3)), false},
+		{"shifted bounds", base, NewGrayS16Image(image.Rect(1, 0, 4, 2)), false},
+		{"identical NaN", nan, nan.SubImage(r), true},
+		{"different NaNs", nan, otherNaN, true},
+		{"NaN and zero", nan, NewGrayF32Image(r), false},
+		{"negative zero", negZero, NewGrayF32Image(r), true},
+		{"complex negative zero", c128, NewGrayC128Image(r), true},
+		{"RGB555 top bit", rgb555, topBit, true},
+		{"RGB555 differs", rgb555, NewRGB555Image(r), false},
+		{"different types with same colors", gray, rgba, true},
+		{"different types with different colors", gray, image.NewRGBA(r), false},
+		{"empty", NewGrayS16Image(image.Rectangle{}), image.NewGray(image.Rectangle{}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualWithinTolerance(t *testing.T) {
+	r := image.Rect(-1, -1, 2, 0)
+	a := NewGrayS16Image(r)
+	b := NewGrayS16Image(r)
+	a.SetGrayS16(-1, -1, GrayS16{Y: 10})
+	b.SetGrayS16(-1, -1, GrayS16{Y: 8})
+	a.SetGrayS16(1, -1, GrayS16{Y: -32768})
+	b.SetGrayS16(1, -1, GrayS16{Y: 32767})
+
+	tests := []struct {
+		name string
+		b    *GrayS16Image
+		tol  int16
+		want bool
+	}{
+		{"self", a, 0, true},
+		{"beyond tolerance", b, 2, false},
+		{"max tolerance", b, 32767, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, _ := EqualWithinTolerance(a, tt.b, tt.tol); ok != tt.want {
+				t.Errorf("EqualWithinTolerance(tol=%d) = %v, want %v", tt.tol, ok, tt.want)
+			}
+		})
+	}
+
+	// Within tolerance once the saturating pixel matches.
+	b.SetGrayS16(1, -1, GrayS16{Y: -32768})
+	ok, diff := EqualWithinTolerance(a, b, 2)
+	if !ok {
+		t.Error("EqualWithinTolerance(tol=2) = false, want true")
+	}
+	if diff.Rect != r {
+		t.Fatalf("diff bounds = %v, want %v", diff.Rect, r)
+	}
+	want := []int16{2, 0, 0}
+	for x := -1; x < 2; x++ {
+		if got := diff.GrayS16At(x, -1).Y; got != want[x+1] {
+			t.Errorf("diff at x=%d = %d, want %d", x, got, want[x+1])
+		}
+	}
+
+	// Differences saturate.
+	b.SetGrayS16(1, -1, GrayS16{Y: 32767})
+	if _, diff := EqualWithinTolerance(a, b, 0); diff.GrayS16At(1, -1).Y != -32768 {
+		t.Errorf("saturated diff = %d, want -32768", diff.GrayS16At(1, -1).Y)
+	}
+
+	if ok, diff := EqualWithinTolerance(a, NewGrayS16Image(image.Rect(0, 0, 3, 1)), 100); ok || diff != nil {
+		t.Errorf("mismatched bounds = %v, %v, want false, nil", ok, diff)
+	}
+}
+
+func BenchmarkEqualWithinTolerance(b *testing.B) {
+	a := NewGrayS16Image(image.Rect(0, 0, 512, 512))
+	c := NewGrayS16Image(image.Rect(0, 0, 512, 512))
+	b.SetBytes(int64(len(a.Pix)))
+	for b.Loop() {
+		EqualWithinTolerance(a, c, 0)
+	}
+}
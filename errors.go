@@ -0,0 +1,79 @@
+package colorext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrBoundsMismatch is returned when the images or maps given to an
+// operation do not have the bounds it requires, such as a frame that does
+// not cover an accumulator. Errors reporting it wrap it with the offending
+// bounds.
+var ErrBoundsMismatch = errors.New("colorext: image bounds mismatch")
+
+// ErrUnsupportedDType is returned when an operation or codec cannot handle
+// the pixel type it is given or finds in its input. Errors reporting it
+// wrap it with the offending type.
+var ErrUnsupportedDType = errors.New("colorext: unsupported pixel type")
+
+// ErrStrideMismatch is returned when serialized pixels declare a stride
+// that does not match their width. Errors reporting it wrap it with the
+// offending values.
+var ErrStrideMismatch = errors.New("colorext: stride mismatch")
+
+// DecodeError reports malformed input found by one of the package's
+// decoders, and where in the input it was found.
+type DecodeError struct {
+	// Format names the encoding being decoded, such as "QS16".
+	Format string
+	// Offset is the byte offset in the input at which the problem was
+	// detected.
+	Offset int64
+	// Err describes the problem. It is io.ErrUnexpectedEOF for truncated
+	// input, and may wrap ErrUnsupportedDType, ErrStrideMismatch or
+	// ErrInvalidLayout.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	// The package's errors carry their own prefix; report it only once.
+	msg := strings.TrimPrefix(e.Err.Error(), "colorext: ")
+	return fmt.Sprintf("colorext: %s at offset %d: %s", e.Format, e.Offset, msg)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError returns a DecodeError for format at off. A nil err reports
+// invalid data, and io.EOF is reported as io.ErrUnexpectedEOF.
+func decodeError(format string, off int64, err error) error {
+	switch err {
+	case nil:
+		err = errInvalidData
+	case io.EOF:
+		err = io.ErrUnexpectedEOF
+	}
+	return &DecodeError{Format: format, Offset: off, Err: err}
+}
+
+// errInvalidData is the Err of a DecodeError for input that is malformed
+// in a way with no more specific description.
+var errInvalidData = errors.New("invalid data")
+
+// offsetReader is an io.ByteReader that counts the bytes read through it,
+// so that decoders can report the offset of malformed input.
+type offsetReader struct {
+	r   io.ByteReader
+	off int64
+}
+
+func (r *offsetReader) ReadByte() (byte, error) {
+	c, err := r.r.ReadByte()
+	if err == nil {
+		r.off++
+	}
+	return c, err
+}
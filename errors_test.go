@@ -0,0 +1,133 @@
+package colorext
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *DecodeError
+		want string
+	}{
+		{"plain", &DecodeError{"QS16", 12, errors.New("invalid operation 0xff")},
+			"colorext: QS16 at offset 12: invalid operation 0xff"},
+		{"truncated", &DecodeError{"PNG", 0, io.ErrUnexpectedEOF},
+			"colorext: PNG at offset 0: unexpected EOF"},
+		{"sentinel", &DecodeError{"binary image", 5, ErrUnsupportedDType},
+			"colorext: binary image at offset 5: unsupported pixel type"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, got, tt.want)
+		}
+		if !errors.Is(tt.err, tt.err.Err) {
+			t.Errorf("%s: does not unwrap to %v", tt.name, tt.err.Err)
+		}
+	}
+}
+
+func TestErrorSentinels(t *testing.T) {
+	small := NewGrayS16Image(image.Rect(0, 0, 4, 4))
+	large := NewGrayS16Image(image.Rect(0, 0, 8, 8))
+	f32 := NewGrayF32Image(image.Rect(0, 0, 4, 4))
+	binary, err := large.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"Accumulator.Add", func() error {
+			return NewAccumulator(large.Rect, false).Add(small)
+		}, ErrBoundsMismatch},
+		{"MSE", func() error {
+			_, err := MSE(small, large)
+			return err
+		}, ErrBoundsMismatch},
+		{"Remap maps", func() error {
+			_, err := Remap(small, f32, NewGrayF32Image(image.Rect(0, 0, 2, 2)), Nearest, 0)
+			return err
+		}, ErrBoundsMismatch},
+		{"Remap source", func() error {
+			_, err := Remap(image.NewRGBA(small.Rect), f32, f32, Nearest, 0)
+			return err
+		}, ErrUnsupportedDType},
+		{"DeltaWriter.WriteFrame", func() error {
+			w, err := NewDeltaWriter(io.Discard, large.Rect, 4)
+			if err != nil {
+				return err
+			}
+			return w.WriteFrame(small)
+		}, ErrBoundsMismatch},
+		{"ToTensor", func() error {
+			_, err := ToTensor(image.NewRGBA(small.Rect))
+			return err
+		}, ErrUnsupportedDType},
+		{"WriteCompressed", func() error {
+			return WriteCompressed(io.Discard, image.NewRGBA(small.Rect), CompressionNone)
+		}, ErrUnsupportedDType},
+		{"UnmarshalBinary dtype", func() error {
+			return new(GrayF32Image).UnmarshalBinary(binary)
+		}, ErrUnsupportedDType},
+		{"UnmarshalBinary truncated", func() error {
+			return new(GrayS16Image).UnmarshalBinary(binary[:6])
+		}, io.ErrUnexpectedEOF},
+		{"UnmarshalJSON stride", func() error {
+			return new(GrayS16Image).UnmarshalJSON([]byte(`{"bounds":{"Min":{"X":0,"Y":0},"Max":{"X":2,"Y":2}},"stride":2,"dtype":"int16","data":"AAAAAAAAAAA="}`))
+		}, ErrStrideMismatch},
+	}
+	for _, tt := range tests {
+		if err := tt.err(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want an error wrapping %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeErrorOffsets(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func() error
+		format string
+		offset int64
+	}{
+		{"QS16 magic", func() error {
+			_, err := DecodeQS16(bytes.NewReader([]byte("qs17\x01")))
+			return err
+		}, "QS16", 0},
+		{"QS16 version", func() error {
+			_, err := DecodeQS16(bytes.NewReader([]byte("qs16\x09")))
+			return err
+		}, "QS16", 4},
+		{"QS16 operation", func() error {
+			// A 1×1 frame whose only operation is invalid.
+			_, err := DecodeQS16(bytes.NewReader([]byte("qs16\x01\x00\x00\x02\x02\xff")))
+			return err
+		}, "QS16", 9},
+		{"PNG signature", func() error {
+			_, err := DecodePNG(bytes.NewReader([]byte("not a png")))
+			return err
+		}, "PNG", 0},
+		{"GeoTIFF header", func() error {
+			_, _, err := DecodeGeoTIFF(bytes.NewReader([]byte("XX*\x00\x08\x00\x00\x00")))
+			return err
+		}, "GeoTIFF", 0},
+		{"GeoTIFF IFD offset", func() error {
+			_, _, err := DecodeGeoTIFF(bytes.NewReader([]byte("II*\x00\xff\x00\x00\x00")))
+			return err
+		}, "GeoTIFF", 4},
+	}
+	for _, tt := range tests {
+		err := tt.decode()
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Format != tt.format || de.Offset != tt.offset {
+			t.Errorf("%s: got %v, want a %s DecodeError at offset %d", tt.name, err, tt.format, tt.offset)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// FFT2 returns the two-dimensional discrete Fourier transform of src. The
+// result has the bounds of src, with the zero frequency at Rect.Min and the
+// frequency of column u (relative to Rect.Min.X) being u/width cycles per
+// pixel, wrapping to negative frequencies past the middle as usual.
+//
+// GrayC64Image and GrayC128Image sources are transformed as complex values.
+// Other images are read as real values in the manner of Contours; NaN and
+// invalid pixels are treated as 0. Any size is supported, although powers of
+// two are fastest.
+func FFT2(src image.Image) *GrayC64Image {
+	r := src.Bounds()
+	w, h := r.Dx(), r.Dy()
+	data := make([]complex128, w*h)
+	switch m := src.(type) {
+	case *GrayC64Image:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				data[y*w+x] = complex128(m.GrayC64At(r.Min.X+x, r.Min.Y+y).Y)
+			}
+		}
+	case *GrayC128Image:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				data[y*w+x] = m.GrayC128At(r.Min.X+x, r.Min.Y+y).Y
+			}
+		}
+	default:
+		at := scalarSampler(src)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if v := at(r.Min.X+x, r.Min.Y+y); !math.IsNaN(v) {
+					data[y*w+x] = complex(v, 0)
+				}
+			}
+		}
+	}
+	fft2(data, w, h, false)
+	return complexImage(r, data)
+}
+
+// IFFT2 returns the inverse two-dimensional discrete Fourier transform of
+// spec, scaled by 1/(width*height) so that IFFT2(FFT2(img)) reproduces img.
+// The spectrum layout is that produced by FFT2. The Real method of the
+// result recovers a real image.
+func IFFT2(spec *GrayC64Image) *GrayC64Image {
+	r := spec.Rect
+	w, h := r.Dx(), r.Dy()
+	data := make([]complex128, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			data[y*w+x] = complex128(spec.GrayC64At(r.Min.X+x, r.Min.Y+y).Y)
+		}
+	}
+	fft2(data, w, h, true)
+	scale := complex(1/float64(w*h), 0)
+	for i := range data {
+		data[i] *= scale
+	}
+	return complexImage(r, data)
+}
+
+// FrequencyFilter gives the gain applied to the spatial frequency (fx, fy),
+// in cycles per pixel. Both components lie in [-0.5, 0.5].
+type FrequencyFilter func(fx, fy float64) float64
+
+// LowPass returns a filter passing frequencies below cutoff cycles per
+// pixel, measured radially. An order of 0 gives an ideal filter with a sharp
+// cutoff; a positive order gives a Butterworth filter of that order, whose
+// gain is 1/2 at the cutoff and which rings less.
+func LowPass(cutoff float64, order int) FrequencyFilter {
+	return func(fx, fy float64) float64 {
+		return lowPassGain(math.Hypot(fx, fy), cutoff, order)
+	}
+}
+
+// HighPass returns a filter passing frequencies above cutoff cycles per
+// pixel. It is the complement of LowPass with the same arguments.
+func HighPass(cutoff float64, order int) FrequencyFilter {
+	return func(fx, fy float64) float64 {
+		return 1 - lowPassGain(math.Hypot(fx, fy), cutoff, order)
+	}
+}
+
+// BandPass returns a filter passing frequencies between lo and hi cycles per
+// pixel. It is the product of HighPass(lo, order) and LowPass(hi, order).
+func BandPass(lo, hi float64, order int) FrequencyFilter {
+	return func(fx, fy float64) float64 {
+		f := math.Hypot(fx, fy)
+		return (1 - lowPassGain(f, lo, order)) * lowPassGain(f, hi, order)
+	}
+}
+
+// lowPassGain returns the gain of an ideal (order 0) or Butterworth low-pass
+// filter at radial frequency f.
+func lowPassGain(f, cutoff float64, order int) float64 {
+	if order <= 0 {
+		if f <= cutoff {
+			return 1
+		}
+		return 0
+	}
+	if cutoff <= 0 {
+		if f == 0 {
+			return 0.5
+		}
+		return 0
+	}
+	return 1 / (1 + math.Pow(f/cutoff, float64(2*order)))
+}
+
+// Apply multiplies the spectrum spec, laid out as produced by FFT2, by the
+// filter gain in place.
+func (f FrequencyFilter) Apply(spec *GrayC64Image) {
+	r := spec.Rect
+	w, h := r.Dx(), r.Dy()
+	for y := 0; y < h; y++ {
+		fy := fftFrequency(y, h)
+		for x := 0; x < w; x++ {
+			i := spec.PixOffset(r.Min.X+x, r.Min.Y+y)
+			g := float32(f(fftFrequency(x, w), fy))
+			putF32(spec.Pix[i:], getF32(spec.Pix[i:])*g)
+			putF32(spec.Pix[i+4:], getF32(spec.Pix[i+4:])*g)
+		}
+	}
+}
+
+// FilterF32 filters src in the frequency domain and returns the real part of
+// the result. NaN pixels are treated as 0 and remain NaN in the result.
+func FilterF32(src *GrayF32Image, f FrequencyFilter) *GrayF32Image {
+	spec := FFT2(src)
+	f.Apply(spec)
+	dst := IFFT2(spec).Real()
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			if v := src.GrayF32At(x, y); math.IsNaN(float64(v.Y)) {
+				dst.SetGrayF32(x, y, v)
+			}
+		}
+	}
+	return dst
+}
+
+// FilterC64 filters src in the frequency domain.
+func FilterC64(src *GrayC64Image, f FrequencyFilter) *GrayC64Image {
+	spec := FFT2(src)
+	f.Apply(spec)
+	return IFFT2(spec)
+}
+
+// fftFrequency returns the frequency in cycles per sample of bin k of an
+// n-point transform.
+func fftFrequency(k, n int) float64 {
+	if 2*k >= n {
+		k -= n
+	}
+	return float64(k) / float64(n)
+}
+
+// complexImage returns a GrayC64Image covering r holding the row-major data.
+func complexImage(r image.Rectangle, data []complex128) *GrayC64Image {
+	dst := NewGrayC64Image(r)
+	for i, v := range data {
+		putF32(dst.Pix[8*i:], float32(real(v)))
+		putF32(dst.Pix[8*i+4:], float32(imag(v)))
+	}
+	return dst
+}
+
+// fft2 transforms the w×h row-major data in place, without scaling.
+func fft2(data []complex128, w, h int, inverse bool) {
+	if w == 0 || h == 0 {
+		return
+	}
+	for y := 0; y < h; y++ {
+		fft(data[y*w:(y+1)*w], inverse)
+	}
+	col := make([]complex128, h)
+	for x := 0; x < w; x++ {
+		for y := range col {
+			col[y] = data[y*w+x]
+		}
+		fft(col, inverse)
+		for y, v := range col {
+			data[y*w+x] = v
+		}
+	}
+}
+
+// fft transforms x in place, without scaling. Lengths that are not a power
+// of two use Bluestein's algorithm.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		fftRadix2(x, inverse)
+		return
+	}
+
+	// Bluestein: x[k] = conj(w[k]) * sum_j (x[j] conj(w[j])) w[k-j], with
+	// w[k] = exp(i pi k^2 / n), evaluated as a power of two convolution.
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	chirp := make([]complex128, n)
+	for k := range chirp {
+		// k^2 mod 2n keeps the angle accurate for large k.
+		k2 := (k * k) % (2 * n)
+		chirp[k] = cmplx.Rect(1, sign*math.Pi*float64(k2)/float64(n))
+	}
+	m := 1 << bits.Len(uint(2*n-2))
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := range n {
+		a[k] = x[k] * chirp[k]
+		b[k] = cmplx.Conj(chirp[k])
+		if k > 0 {
+			b[m-k] = b[k]
+		}
+	}
+	fftRadix2(a, false)
+	fftRadix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fftRadix2(a, true)
+	scale := complex(1/float64(m), 0)
+	for k := range n {
+		x[k] = a[k] * scale * chirp[k]
+	}
+}
+
+// fftRadix2 transforms x in place with the iterative Cooley-Tukey algorithm.
+// len(x) must be a power of two.
+func fftRadix2(x []complex128, inverse bool) {
+	n := len(x)
+	shift := bits.UintSize - bits.Len(uint(n-1))
+	for i := range x {
+		if j := int(bits.Reverse(uint(i)) >> shift); i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			tw := complex(1, 0)
+			for k := range size / 2 {
+				a, b := x[start+k], x[start+k+size/2]*tw
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				tw *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// naiveDFT2 is a direct O(n^2) reference transform of row-major data.
+func naiveDFT2(data []complex128, w, h int) []complex128 {
+	out := make([]complex128, w*h)
+	for v := 0; v < h; v++ {
+		for u := 0; u < w; u++ {
+			var sum complex128
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					phase := -2 * math.Pi * (float64(u*x)/float64(w) + float64(v*y)/float64(h))
+					sum += data[y*w+x] * cmplx.Rect(1, phase)
+				}
+			}
+			out[v*w+u] = sum
+		}
+	}
+	return out
+}
+
+func TestFFT2_MatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []image.Point{{8, 4}, {5, 3}, {6, 7}, {1, 9}, {12, 1}} {
+		r := image.Rect(2, -1, 2+size.X, -1+size.Y)
+		src := NewGrayC64Image(r)
+		data := make([]complex128, size.X*size.Y)
+		for i := range data {
+			v := complex64(complex(rng.Float64()*2-1, rng.Float64()*2-1))
+			data[i] = complex128(v)
+			src.SetGrayC64(r.Min.X+i%size.X, r.Min.Y+i/size.X, GrayC64{Y: v})
+		}
+		want := naiveDFT2(data, size.X, size.Y)
+		got := FFT2(src)
+		if got.Rect != r {
+			t.Fatalf("%v: bounds = %v, want %v", size, got.Rect, r)
+		}
+		for i, wv := range want {
+			gv := complex128(got.GrayC64At(r.Min.X+i%size.X, r.Min.Y+i/size.X).Y)
+			if cmplx.Abs(gv-wv) > 1e-4 {
+				t.Errorf("%v: bin %d = %v, want %v", size, i, gv, wv)
+				break
+			}
+		}
+	}
+}
+
+func TestFFT2_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, size := range []image.Point{{16, 16}, {7, 5}, {3, 32}} {
+		src := NewGrayF32Image(image.Rect(0, 0, size.X, size.Y))
+		for i := 0; i < len(src.Pix); i += 4 {
+			putF32(src.Pix[i:], rng.Float32()*100-50)
+		}
+		back := IFFT2(FFT2(src))
+		re, im := back.Real(), back.Imag()
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				want := float64(src.GrayF32At(x, y).Y)
+				if got := float64(re.GrayF32At(x, y).Y); !approxEqual(got, want, 1e-3) {
+					t.Fatalf("%v: real at (%d, %d) = %v, want %v", size, x, y, got, want)
+				}
+				if got := float64(im.GrayF32At(x, y).Y); !approxEqual(got, 0, 1e-3) {
+					t.Fatalf("%v: imag at (%d, %d) = %v, want 0", size, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestFFT2_Sources(t *testing.T) {
+	// An impulse has a flat spectrum, whatever the source type.
+	r := image.Rect(0, 0, 4, 3)
+	s16 := NewGrayS16Image(r)
+	s16.SetGrayS16(0, 0, GrayS16{Y: 2})
+	c128 := NewGrayC128Image(r)
+	c128.SetGrayC128(0, 0, GrayC128{Y: 2})
+	f32 := NewGrayF32Image(r)
+	f32.SetGrayF32(0, 0, GrayF32{Y: 2})
+	f32.SetGrayF32(2, 1, GrayF32{Y: float32(math.NaN())})
+
+	for _, src := range []image.Image{s16, c128, f32} {
+		spec := FFT2(src)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if got := spec.GrayC64At(x, y).Y; cmplx.Abs(complex128(got)-2) > 1e-6 {
+					t.Errorf("%T: bin (%d, %d) = %v, want 2", src, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestFrequencyFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      FrequencyFilter
+		fx, fy float64
+		want   float64
+	}{
+		{"ideal low pass below", LowPass(0.2, 0), 0.1, 0.1, 1},
+		{"ideal low pass above", LowPass(0.2, 0), 0.2, 0.1, 0},
+		{"butterworth low pass at cutoff", LowPass(0.25, 2), 0, 0.25, 0.5},
+		{"butterworth low pass DC", LowPass(0.25, 2), 0, 0, 1},
+		{"ideal high pass DC", HighPass(0.1, 0), 0, 0, 0},
+		{"ideal high pass nyquist", HighPass(0.1, 0), 0.5, 0, 1},
+		{"butterworth high pass at cutoff", HighPass(0.25, 1), -0.25, 0, 0.5},
+		{"band pass inside", BandPass(0.1, 0.3, 0), 0.2, 0, 1},
+		{"band pass below", BandPass(0.1, 0.3, 0), 0.05, 0, 0},
+		{"band pass above", BandPass(0.1, 0.3, 0), 0.4, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f(tt.fx, tt.fy); !approxEqual(got, tt.want, 1e-12) {
+				t.Errorf("gain(%v, %v) = %v, want %v", tt.fx, tt.fy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterF32(t *testing.T) {
+	// A constant plus a checkerboard at the Nyquist frequency.
+	r := image.Rect(0, 0, 8, 6)
+	src := NewGrayF32Image(r)
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			v := float32(10)
+			if (x+y)%2 == 0 {
+				v += 3
+			} else {
+				v -= 3
+			}
+			src.SetGrayF32(x, y, GrayF32{Y: v})
+		}
+	}
+
+	low := FilterF32(src, LowPass(0.25, 0))
+	high := FilterF32(src, HighPass(0.25, 0))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			if got := float64(low.GrayF32At(x, y).Y); !approxEqual(got, 10, 1e-4) {
+				t.Fatalf("low pass at (%d, %d) = %v, want 10", x, y, got)
+			}
+			want := float64(src.GrayF32At(x, y).Y) - 10
+			if got := float64(high.GrayF32At(x, y).Y); !approxEqual(got, want, 1e-4) {
+				t.Fatalf("high pass at (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// NaN pixels stay NaN.
+	src.SetGrayF32(3, 3, GrayF32{Y: float32(math.NaN())})
+	if got := FilterF32(src, LowPass(0.25, 0)).GrayF32At(3, 3).Y; !math.IsNaN(float64(got)) {
+		t.Errorf("NaN pixel filtered to %v, want NaN", got)
+	}
+}
+
+func TestFilterC64(t *testing.T) {
+	// A complex exponential at (1/4, 0) cycles per pixel passes a band
+	// around it unchanged and is removed by a low pass below it.
+	r := image.Rect(0, 0, 8, 4)
+	src := NewGrayC64Image(r)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetGrayC64(x, y, GrayC64{Y: complex64(cmplx.Rect(1, 2*math.Pi*float64(x)/4))})
+		}
+	}
+	band := FilterC64(src, BandPass(0.2, 0.3, 0))
+	low := FilterC64(src, LowPass(0.1, 0))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			want := complex128(src.GrayC64At(x, y).Y)
+			if got := complex128(band.GrayC64At(x, y).Y); cmplx.Abs(got-want) > 1e-5 {
+				t.Fatalf("band pass at (%d, %d) = %v, want %v", x, y, got, want)
+			}
+			if got := complex128(low.GrayC64At(x, y).Y); cmplx.Abs(got) > 1e-5 {
+				t.Fatalf("low pass at (%d, %d) = %v, want 0", x, y, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package colorext
+
+import (
+	"context"
+	"image"
+	"math"
+)
+
+// FlowOptions configures the Lucas-Kanade optical flow functions.
+type FlowOptions struct {
+	// WindowRadius is the radius of the square window over which the
+	// brightness constancy equations are solved. Zero means 3, a 7×7
+	// window.
+	WindowRadius int
+	// Levels is the number of pyramid levels, each half the size of the
+	// previous one. Motions up to about WindowRadius << (Levels-1) pixels
+	// can be tracked. Zero means 3.
+	Levels int
+	// Iterations bounds the refinement steps per level. Zero means 10.
+	Iterations int
+	// Progress, if non-nil, is called by DenseFlowContext after each row
+	// with the number of rows done so far.
+	Progress ProgressFunc
+}
+
+func (o *FlowOptions) defaults() FlowOptions {
+	var d FlowOptions
+	if o != nil {
+		d = *o
+	}
+	if d.WindowRadius <= 0 {
+		d.WindowRadius = 3
+	}
+	if d.Levels <= 0 {
+		d.Levels = 3
+	}
+	if d.Iterations <= 0 {
+		d.Iterations = 10
+	}
+	return d
+}
+
+// DenseFlow estimates the motion of every pixel from prev to next with
+// pyramidal Lucas-Kanade. The flow of the pixel at (x, y) is stored as a
+// complex number whose real part is the horizontal and imaginary part the
+// vertical displacement in pixels, so the returned image has prev's bounds
+// and two float32 channels. Pixels in featureless regions, where the motion
+// is undetermined, hold NaN. A nil opts uses the defaults.
+//
+// Samples are read as raw values, as by Contours, so GrayS16 and GrayF32
+// frames are tracked at full precision. prev and next should share bounds.
+func DenseFlow(prev, next image.Image, opts *FlowOptions) *GrayC64Image {
+	dst, _ := DenseFlowContext(context.Background(), prev, next, opts)
+	return dst
+}
+
+// DenseFlowContext is like DenseFlow but checks ctx between rows and
+// returns ctx.Err() once it is done, along with the rows computed so far;
+// the remaining rows are zero.
+func DenseFlowContext(ctx context.Context, prev, next image.Image, opts *FlowOptions) (*GrayC64Image, error) {
+	o := opts.defaults()
+	r := prev.Bounds()
+	dst := NewGrayC64Image(r)
+	if err := ctx.Err(); err != nil {
+		return dst, err
+	}
+	t := newFlowTracker(prev, next, o)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return dst, err
+		}
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d, ok := t.track(float64(x-r.Min.X), float64(y-r.Min.Y))
+			v := complex(float32(d.X), float32(d.Y))
+			if !ok {
+				nan := float32(math.NaN())
+				v = complex(nan, nan)
+			}
+			dst.SetGrayC64(x, y, GrayC64{Y: v})
+		}
+		if o.Progress != nil {
+			o.Progress(y-r.Min.Y+1, r.Dy())
+		}
+	}
+	return dst, nil
+}
+
+// SparseFlow tracks the points pts, in pixel-center coordinates, from prev
+// to next with pyramidal Lucas-Kanade. It returns the displacement of each
+// point and whether it could be tracked. Points in featureless regions or
+// outside prev cannot be tracked. A nil opts uses the defaults.
+func SparseFlow(prev, next image.Image, pts []PointF, opts *FlowOptions) ([]PointF, []bool) {
+	o := opts.defaults()
+	t := newFlowTracker(prev, next, o)
+	r := prev.Bounds()
+	flow := make([]PointF, len(pts))
+	ok := make([]bool, len(pts))
+	for i, p := range pts {
+		gx, gy := p.X-0.5-float64(r.Min.X), p.Y-0.5-float64(r.Min.Y)
+		if gx < 0 || gy < 0 || gx > float64(r.Dx()-1) || gy > float64(r.Dy()-1) {
+			continue
+		}
+		flow[i], ok[i] = t.track(gx, gy)
+	}
+	return flow, ok
+}
+
+// floatGrid is a w×h grid of samples indexed from zero.
+type floatGrid struct {
+	w, h int
+	v    []float64
+}
+
+// gridFrom reads img into a grid, replacing NaN and invalid pixels with 0.
+func gridFrom(img image.Image) *floatGrid {
+	r := img.Bounds()
+	at := scalarSampler(img)
+	g := &floatGrid{w: r.Dx(), h: r.Dy(), v: make([]float64, r.Dx()*r.Dy())}
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			if v := at(r.Min.X+x, r.Min.Y+y); !math.IsNaN(v) {
+				g.v[y*g.w+x] = v
+			}
+		}
+	}
+	return g
+}
+
+// at returns the sample at integer coordinates clamped to the grid.
+func (g *floatGrid) at(x, y int) float64 {
+	x = max(0, min(g.w-1, x))
+	y = max(0, min(g.h-1, y))
+	return g.v[y*g.w+x]
+}
+
+// bilinear interpolates the grid at (x, y), clamping to the edges.
+func (g *floatGrid) bilinear(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	ix, iy := int(fx), int(fy)
+	tx, ty := x-fx, y-fy
+	a := g.at(ix, iy) + (g.at(ix+1, iy)-g.at(ix, iy))*tx
+	b := g.at(ix, iy+1) + (g.at(ix+1, iy+1)-g.at(ix, iy+1))*tx
+	return a + (b-a)*ty
+}
+
+// half returns the grid downsampled by two with a 2×2 box filter.
+func (g *floatGrid) half() *floatGrid {
+	h := &floatGrid{w: max(1, (g.w+1)/2), h: max(1, (g.h+1)/2)}
+	h.v = make([]float64, h.w*h.h)
+	for y := 0; y < h.h; y++ {
+		for x := 0; x < h.w; x++ {
+			h.v[y*h.w+x] = (g.at(2*x, 2*y) + g.at(2*x+1, 2*y) + g.at(2*x, 2*y+1) + g.at(2*x+1, 2*y+1)) / 4
+		}
+	}
+	return h
+}
+
+// flowLevel holds one pyramid level of both frames and prev's gradients.
+type flowLevel struct {
+	prev, next, ix, iy *floatGrid
+}
+
+// flowTracker tracks points between two frames.
+type flowTracker struct {
+	levels []flowLevel
+	opts   FlowOptions
+}
+
+func newFlowTracker(prev, next image.Image, o FlowOptions) *flowTracker {
+	p, n := gridFrom(prev), gridFrom(next)
+	t := &flowTracker{opts: o}
+	for l := 0; l < o.Levels; l++ {
+		if l > 0 {
+			if p.w < 2*o.WindowRadius+1 || p.h < 2*o.WindowRadius+1 {
+				break
+			}
+			p, n = p.half(), n.half()
+		}
+		ix := &floatGrid{w: p.w, h: p.h, v: make([]float64, len(p.v))}
+		iy := &floatGrid{w: p.w, h: p.h, v: make([]float64, len(p.v))}
+		for y := 0; y < p.h; y++ {
+			for x := 0; x < p.w; x++ {
+				ix.v[y*p.w+x] = (p.at(x+1, y) - p.at(x-1, y)) / 2
+				iy.v[y*p.w+x] = (p.at(x, y+1) - p.at(x, y-1)) / 2
+			}
+		}
+		t.levels = append(t.levels, flowLevel{p, n, ix, iy})
+	}
+	return t
+}
+
+// track returns the displacement of the grid point (x, y), coarse to fine.
+func (t *flowTracker) track(x, y float64) (PointF, bool) {
+	rad := t.opts.WindowRadius
+	var gx, gy float64 // displacement estimate at the current level
+	for l := len(t.levels) - 1; l >= 0; l-- {
+		lv := t.levels[l]
+		s := math.Ldexp(1, -l)
+		px, py := (x+0.5)*s-0.5, (y+0.5)*s-0.5
+
+		// The structure tensor depends only on prev, so it is computed once
+		// per level.
+		var sxx, sxy, syy float64
+		for wy := -rad; wy <= rad; wy++ {
+			for wx := -rad; wx <= rad; wx++ {
+				ix := lv.ix.bilinear(px+float64(wx), py+float64(wy))
+				iy := lv.iy.bilinear(px+float64(wx), py+float64(wy))
+				sxx += ix * ix
+				sxy += ix * iy
+				syy += iy * iy
+			}
+		}
+		det := sxx*syy - sxy*sxy
+		// Reject windows whose smaller eigenvalue is negligible: an edge or
+		// flat region does not determine the motion.
+		tr := sxx + syy
+		minEig := (tr - math.Sqrt(max(0, tr*tr-4*det))) / 2
+		if !(minEig > 1e-6*float64((2*rad+1)*(2*rad+1))) {
+			return PointF{}, false
+		}
+
+		var dx, dy float64
+		for it := 0; it < t.opts.Iterations; it++ {
+			var bx, by float64
+			for wy := -rad; wy <= rad; wy++ {
+				for wx := -rad; wx <= rad; wx++ {
+					qx, qy := px+float64(wx), py+float64(wy)
+					diff := lv.prev.bilinear(qx, qy) - lv.next.bilinear(qx+gx+dx, qy+gy+dy)
+					bx += diff * lv.ix.bilinear(qx, qy)
+					by += diff * lv.iy.bilinear(qx, qy)
+				}
+			}
+			ux := (syy*bx - sxy*by) / det
+			uy := (sxx*by - sxy*bx) / det
+			dx += ux
+			dy += uy
+			if ux*ux+uy*uy < 1e-4 {
+				break
+			}
+		}
+		gx, gy = gx+dx, gy+dy
+		if l > 0 {
+			gx, gy = 2*gx, 2*gy
+		}
+	}
+	return PointF{X: gx, Y: gy}, true
+}
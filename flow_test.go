@@ -0,0 +1,107 @@
+package colorext
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+	"testing"
+)
+
+// blobs returns a smooth GrayS16 test scene shifted by (dx, dy).
+func blobs(r image.Rectangle, dx, dy float64) *GrayS16Image {
+	img := NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			u, v := float64(x)-dx, float64(y)-dy
+			f := math.Sin(u/5)*math.Cos(v/7) + 0.5*math.Sin((u+v)/9)
+			img.SetGrayS16(x, y, GrayS16{Y: int16(f * 10000)})
+		}
+	}
+	return img
+}
+
+func TestDenseFlow(t *testing.T) {
+	tests := []struct {
+		name   string
+		dx, dy float64
+		opts   *FlowOptions
+	}{
+		{"subpixel", 0.6, -0.4, &FlowOptions{Levels: 1}},
+		{"large", 5, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := image.Rect(-10, 5, 54, 69)
+			prev, next := blobs(r, 0, 0), blobs(r, tt.dx, tt.dy)
+			flow := DenseFlow(prev, next, tt.opts)
+			if flow.Rect != r {
+				t.Fatalf("bounds = %v, want %v", flow.Rect, r)
+			}
+			// Check the interior, away from edge effects.
+			var worst float64
+			for y := r.Min.Y + 16; y < r.Max.Y-16; y += 4 {
+				for x := r.Min.X + 16; x < r.Max.X-16; x += 4 {
+					v := flow.GrayC64At(x, y).Y
+					e := math.Hypot(float64(real(v))-tt.dx, float64(imag(v))-tt.dy)
+					if !(e <= worst) {
+						worst = e
+					}
+				}
+			}
+			// Bilinear interpolation biases subpixel estimates slightly.
+			if !(worst < 0.15) {
+				t.Errorf("worst flow error = %v, want < 0.15", worst)
+			}
+		})
+	}
+}
+
+func TestFlowFeatureless(t *testing.T) {
+	flat := NewGrayF32Image(image.Rect(0, 0, 16, 16))
+	v := DenseFlow(flat, flat, nil).GrayC64At(8, 8).Y
+	if !math.IsNaN(float64(real(v))) || !math.IsNaN(float64(imag(v))) {
+		t.Errorf("flow in a flat region = %v, want NaN", v)
+	}
+}
+
+func TestSparseFlow(t *testing.T) {
+	r := image.Rect(0, 0, 64, 64)
+	prev, next := blobs(r, 0, 0), blobs(r, -2, 1)
+	pts := []PointF{{32.5, 32.5}, {20.25, 40.75}, {-3, 10}}
+	flow, ok := SparseFlow(prev, next, pts, nil)
+	for i := range 2 {
+		if !ok[i] {
+			t.Fatalf("point %v not tracked", pts[i])
+		}
+		if e := math.Hypot(flow[i].X+2, flow[i].Y-1); e > 0.05 {
+			t.Errorf("flow at %v = %v, want (-2, 1)", pts[i], flow[i])
+		}
+	}
+	if ok[2] {
+		t.Error("point outside the image was tracked")
+	}
+}
+
+func TestDenseFlowContext(t *testing.T) {
+	r := image.Rect(0, 0, 24, 24)
+	prev, next := blobs(r, 0, 0), blobs(r, 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rows := 0
+	flow, err := DenseFlowContext(ctx, prev, next, &FlowOptions{Progress: func(done, total int) {
+		rows = done
+		if done == 5 {
+			cancel()
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if rows != 5 {
+		t.Errorf("stopped after %d rows, want 5", rows)
+	}
+	if v := flow.GrayC64At(12, 20).Y; v != 0 {
+		t.Errorf("unfinished row holds %v, want 0", v)
+	}
+}
@@ -0,0 +1,148 @@
+package colorext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// The package's own encodings are registered with the image package, so
+// that image.Decode and image.DecodeConfig recognize them by their magic
+// and return the package's image types:
+//
+//	"qs16"  a single QS16 frame, decoded as a *GrayS16Image
+//	"cxrz"  a compressed raster written by WriteCompressed
+//	"cxim"  a binary image written by MarshalBinary
+//
+// PNG files carrying an siNT chunk are left to image/png, which registers
+// the same signature; use DecodePNG to recover their signed values.
+func init() {
+	image.RegisterFormat("qs16", qs16Magic, decodeQS16Image, decodeQS16Config)
+	image.RegisterFormat("cxrz", compressedMagic, ReadCompressed, decodeCompressedConfig)
+	image.RegisterFormat("cxim", binaryMagic, decodeBinaryImage, decodeBinaryConfig)
+}
+
+// DecodeAny decodes an image in any format registered with the image
+// package, including the package's own, and reports the sample type of
+// the result. d is 0 if the image is not backed by one of the gray image
+// types, as for an ordinary 8-bit PNG.
+func DecodeAny(r io.Reader) (img image.Image, d DType, err error) {
+	img, _, err = image.Decode(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return img, dtypeOf(img), nil
+}
+
+// dtypeOf returns the sample type of img, looking through the package's
+// wrappers, or 0 if it is not backed by one of the gray image types.
+func dtypeOf(img image.Image) DType {
+	switch m := img.(type) {
+	case *PhysicalGrayS16Image, *MaskedGrayS16Image:
+		return DTypeInt16
+	case *ImageWithMeta:
+		return dtypeOf(m.Image)
+	case *FrozenImage:
+		return dtypeOf(m.img)
+	}
+	d, _, _, _ := grayLayout(img)
+	return d
+}
+
+// byteReader returns r as an offsetReader, buffering it if it is not
+// already an io.ByteReader.
+func byteReader(r io.Reader) *offsetReader {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &offsetReader{r: br}
+}
+
+// grayConfig returns the image.Config of an image of the gray type holding
+// samples of type d with bounds r.
+func grayConfig(d DType, r image.Rectangle) image.Config {
+	m, _ := newGrayImage(d, image.Rectangle{})
+	return image.Config{ColorModel: m.ColorModel(), Width: r.Dx(), Height: r.Dy()}
+}
+
+func decodeQS16Image(r io.Reader) (image.Image, error) {
+	return DecodeQS16(r)
+}
+
+func decodeQS16Config(r io.Reader) (image.Config, error) {
+	rect, err := readQS16Header(byteReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return grayConfig(DTypeInt16, rect), nil
+}
+
+func decodeCompressedConfig(r io.Reader) (image.Config, error) {
+	d, _, rect, err := readCompressedHeader(byteReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return grayConfig(d, rect), nil
+}
+
+// binaryImage returns a new, empty image of the gray type holding samples
+// of type d, to unmarshal into.
+func binaryImage(d DType) (encoding.BinaryUnmarshaler, bool) {
+	switch d {
+	case DTypeInt16:
+		return new(GrayS16Image), true
+	case DTypeFloat32:
+		return new(GrayF32Image), true
+	case DTypeUint32:
+		return new(GrayU32Image), true
+	case DTypeUint64:
+		return new(GrayU64Image), true
+	case DTypeInt64:
+		return new(GrayS64Image), true
+	case DTypeComplex64:
+		return new(GrayC64Image), true
+	case DTypeComplex128:
+		return new(GrayC128Image), true
+	}
+	return nil, false
+}
+
+func decodeBinaryImage(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d, _, err := readBinaryHeader(&offsetReader{r: bytes.NewReader(data)})
+	if err != nil {
+		return nil, err
+	}
+	u, ok := binaryImage(d)
+	if !ok {
+		return nil, decodeError("binary image", int64(len(binaryMagic)+1), fmt.Errorf("%w %d", ErrUnsupportedDType, d))
+	}
+	if err := u.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return u.(image.Image), nil
+}
+
+func decodeBinaryConfig(r io.Reader) (image.Config, error) {
+	br := byteReader(r)
+	d, c, err := readBinaryHeader(br)
+	if err != nil {
+		return image.Config{}, err
+	}
+	if d.Size() == 0 {
+		return image.Config{}, decodeError("binary image", int64(len(binaryMagic)+1), fmt.Errorf("%w %d", ErrUnsupportedDType, d))
+	}
+	w, h := uint64(c[2])-uint64(c[0]), uint64(c[3])-uint64(c[1])
+	if c[2] < c[0] || c[3] < c[1] || w > math.MaxInt32 || h > math.MaxInt32 {
+		return image.Config{}, decodeError("binary image", br.off, fmt.Errorf("%w: malformed bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, c[0], c[1], c[2], c[3]))
+	}
+	return grayConfig(d, image.Rect(c[0], c[1], c[2], c[3])), nil
+}
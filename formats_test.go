@@ -0,0 +1,96 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestRegisteredFormats(t *testing.T) {
+	s16 := randomGrayS16(image.Rect(-3, 2, 13, 9), 6)
+	f32 := NewGrayF32Image(image.Rect(0, 0, 5, 4))
+	f32.SetGrayF32(2, 1, GrayF32{Y: -1.5})
+	c128 := NewGrayC128Image(image.Rect(1, 1, 4, 3))
+	c128.SetGrayC128(2, 2, GrayC128{Y: complex(1, -2)})
+
+	encode := func(f func(*bytes.Buffer) error) []byte {
+		var buf bytes.Buffer
+		if err := f(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	marshal := func(m interface{ MarshalBinary() ([]byte, error) }) []byte {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name   string
+		data   []byte
+		img    image.Image
+		format string
+		dtype  DType
+	}{
+		{"qs16", encode(func(b *bytes.Buffer) error { return EncodeQS16(b, s16) }), s16, "qs16", DTypeInt16},
+		{"cxrz int16", encode(func(b *bytes.Buffer) error { return WriteCompressed(b, s16, CompressionDeflate) }), s16, "cxrz", DTypeInt16},
+		{"cxrz float32", encode(func(b *bytes.Buffer) error { return WriteCompressed(b, f32, CompressionNone) }), f32, "cxrz", DTypeFloat32},
+		{"cxim float32", marshal(f32), f32, "cxim", DTypeFloat32},
+		{"cxim complex128", marshal(c128), c128, "cxim", DTypeComplex128},
+		{"png", encode(func(b *bytes.Buffer) error { return png.Encode(b, image.NewRGBA(image.Rect(0, 0, 2, 2))) }), nil, "png", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := image.Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+			if tt.img != nil && !Equal(img, tt.img) {
+				t.Errorf("decoded %T differs from %T", img, tt.img)
+			}
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := img.Bounds()
+			if format != tt.format || cfg.Width != r.Dx() || cfg.Height != r.Dy() || cfg.ColorModel != img.ColorModel() {
+				t.Errorf("config %q %dx%d, want %q %dx%d", format, cfg.Width, cfg.Height, tt.format, r.Dx(), r.Dy())
+			}
+
+			_, d, err := DecodeAny(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d != tt.dtype {
+				t.Errorf("DecodeAny dtype = %v, want %v", d, tt.dtype)
+			}
+		})
+	}
+}
+
+func TestDTypeOf(t *testing.T) {
+	s16 := NewGrayS16Image(image.Rect(0, 0, 2, 2))
+	tests := []struct {
+		img  image.Image
+		want DType
+	}{
+		{s16, DTypeInt16},
+		{NewMaskedGrayS16Image(s16, 0), DTypeInt16},
+		{NewPhysicalGrayS16Image(s16, UnitsNone, 1, 0), DTypeInt16},
+		{Freeze(NewGrayU64Image(s16.Rect)), DTypeUint64},
+		{&ImageWithMeta{Image: NewGrayS64Image(s16.Rect)}, DTypeInt64},
+		{image.NewGray16(s16.Rect), 0},
+	}
+	for _, tt := range tests {
+		if got := dtypeOf(tt.img); got != tt.want {
+			t.Errorf("dtypeOf(%T) = %v, want %v", tt.img, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package colorext
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// ErrReadOnly is the panic value of Set on a FrozenImage.
+var ErrReadOnly = errors.New("colorext: image is read-only")
+
+// FrozenImage is a read-only view of an image, returned by Freeze. It
+// exposes the reading methods of the image it wraps, but not its pixel
+// buffer, so code holding only the view cannot modify the pixels.
+type FrozenImage struct {
+	img  image.Image
+	rect image.Rectangle
+}
+
+// Freeze returns a read-only view of img that can be shared freely between
+// goroutines. Any number of goroutines may read a FrozenImage
+// concurrently, provided nothing modifies img itself after Freeze is
+// called; Freeze does not copy the pixels, so ownership of img should pass
+// to the view. Freezing a FrozenImage returns it unchanged.
+func Freeze(img image.Image) *FrozenImage {
+	if f, ok := img.(*FrozenImage); ok {
+		return f
+	}
+	return &FrozenImage{img: img, rect: img.Bounds()}
+}
+
+// ColorModel returns the color model of the frozen image.
+func (p *FrozenImage) ColorModel() color.Model {
+	return p.img.ColorModel()
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *FrozenImage) Bounds() image.Rectangle {
+	return p.rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *FrozenImage) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return p.img.ColorModel().Convert(color.Transparent)
+	}
+	return p.img.At(x, y)
+}
+
+// RGBA64At returns the color of the pixel at (x, y) as a color.RGBA64.
+func (p *FrozenImage) RGBA64At(x, y int) color.RGBA64 {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return color.RGBA64{}
+	}
+	if m, ok := p.img.(image.RGBA64Image); ok {
+		return m.RGBA64At(x, y)
+	}
+	r, g, b, a := p.img.At(x, y).RGBA()
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+}
+
+// Valid reports whether the pixel at (x, y) is inside the bounds and, if
+// the frozen image is a Validator, valid in it.
+func (p *FrozenImage) Valid(x, y int) bool {
+	if !(image.Point{X: x, Y: y}.In(p.rect)) {
+		return false
+	}
+	if v, ok := p.img.(Validator); ok {
+		return v.Valid(x, y)
+	}
+	return true
+}
+
+// Opaque reports whether the frozen image is known to be fully opaque.
+func (p *FrozenImage) Opaque() bool {
+	if o, ok := p.img.(interface{ Opaque() bool }); ok && p.rect == p.img.Bounds() {
+		return o.Opaque()
+	}
+	return false
+}
+
+// Set panics with ErrReadOnly. It exists so that a FrozenImage passed
+// where a draw.Image is expected fails loudly rather than being silently
+// copied.
+func (p *FrozenImage) Set(x, y int, c color.Color) {
+	panic(ErrReadOnly)
+}
+
+// SubImage returns a read-only view of the portion of the image visible
+// through r.
+func (p *FrozenImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.rect)
+	if s, ok := p.img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return Freeze(s.SubImage(r))
+	}
+	return &FrozenImage{img: p.img, rect: r}
+}
@@ -0,0 +1,109 @@
+package colorext
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	src := randomGrayS16(image.Rect(-2, 1, 10, 9), 3)
+	f := Freeze(src)
+	if Freeze(f) != f {
+		t.Error("Freeze of a FrozenImage returned a new view")
+	}
+	if f.Bounds() != src.Rect || f.ColorModel() != GrayS16Model {
+		t.Errorf("Bounds %v ColorModel %v", f.Bounds(), f.ColorModel())
+	}
+	if !Equal(f, src) {
+		t.Error("frozen view differs from its image")
+	}
+	if ContentHash(f) != ContentHash(src) {
+		t.Error("frozen view hashes differently from its image")
+	}
+	r, g, b, a := src.At(0, 2).RGBA()
+	if got, want := f.RGBA64At(0, 2), (color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}); got != want {
+		t.Errorf("RGBA64At = %v, want %v", got, want)
+	}
+	if got := f.RGBA64At(-5, 0); got != (color.RGBA64{}) {
+		t.Errorf("RGBA64At out of bounds = %v", got)
+	}
+	if !f.Opaque() {
+		t.Error("frozen GrayS16Image not opaque")
+	}
+
+	sub := f.SubImage(image.Rect(0, 0, 4, 4))
+	if _, ok := sub.(*FrozenImage); !ok {
+		t.Fatalf("SubImage returned %T, want *FrozenImage", sub)
+	}
+	if sub.Bounds() != image.Rect(0, 1, 4, 4) {
+		t.Errorf("SubImage bounds %v", sub.Bounds())
+	}
+
+	// Scalar operations see through the view.
+	lo, hi := AutoWindow(f, WindowPercentile)
+	if wlo, whi := AutoWindow(src, WindowPercentile); lo != wlo || hi != whi {
+		t.Errorf("AutoWindow = %v, %v, want %v, %v", lo, hi, wlo, whi)
+	}
+}
+
+func TestFreezeSetPanics(t *testing.T) {
+	f := Freeze(NewGrayS16Image(image.Rect(0, 0, 2, 2)))
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("recovered %v, want ErrReadOnly", err)
+		}
+	}()
+	// Drawing onto a frozen image must not silently succeed.
+	draw.Draw(f, f.Bounds(), image.White, image.Point{}, draw.Src)
+	t.Error("Set did not panic")
+}
+
+func TestFreezeNoSubImage(t *testing.T) {
+	// Images without SubImage are cropped by the view itself.
+	u := Uniform(GrayF32{Y: 2.5})
+	f := Freeze(image.Image(&boundedUniform{u, image.Rect(0, 0, 5, 5)}))
+	sub := f.SubImage(image.Rect(1, 1, 3, 9)).(*FrozenImage)
+	if sub.Bounds() != image.Rect(1, 1, 3, 5) {
+		t.Errorf("bounds %v", sub.Bounds())
+	}
+	if sub.Valid(0, 0) || !sub.Valid(2, 2) {
+		t.Error("Valid disagrees with the cropped bounds")
+	}
+	at := scalarSampler(sub)
+	if v := at(2, 2); v != 2.5 {
+		t.Errorf("sample inside = %v", v)
+	}
+	if v := at(0, 0); !math.IsNaN(v) {
+		t.Errorf("sample outside the crop = %v, want NaN", v)
+	}
+}
+
+// boundedUniform is a finite uniform image without a SubImage method.
+type boundedUniform struct {
+	*UniformImage
+	r image.Rectangle
+}
+
+func (b *boundedUniform) Bounds() image.Rectangle { return b.r }
+
+func TestFreezeConcurrentReads(t *testing.T) {
+	f := Freeze(randomGrayS16(image.Rect(0, 0, 64, 64), 1))
+	want := ContentHash(f)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ContentHash(f) != want {
+				t.Error("concurrent hash differs")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,125 @@
+package colorext
+
+import (
+	"math"
+)
+
+// GamutMapping selects how MapGamut brings out-of-gamut colors into the
+// sRGB gamut.
+type GamutMapping int
+
+const (
+	// GamutClip clamps each channel to [0, 1] independently. It is fast but
+	// shifts the hue and lightness of strongly saturated colors.
+	GamutClip GamutMapping = iota
+	// GamutCompressChroma reduces the Oklch chroma of a color, keeping its
+	// lightness and hue, until it lies within the gamut. This is the CSS
+	// Color Level 4 gamut mapping algorithm.
+	GamutCompressChroma
+)
+
+// gamutJND is the Oklab distance below which a clipped color is
+// indistinguishable from the color being mapped.
+const gamutJND = 0.02
+
+// MapGamut returns a copy of src whose colors all lie within the sRGB
+// gamut, using the mapping m. Colors already in gamut are unchanged. Color
+// components are unpremultiplied before mapping and alpha is preserved.
+//
+// Wide-gamut images, such as those with DisplayP3 or Rec2020 pixels, keep
+// their out-of-gamut colors when converted with SRGBToLinear, so
+//
+//	LinearToSRGB(MapGamut(SRGBToLinear(p3), GamutCompressChroma))
+//
+// renders them to sRGB without the hue shifts of per-channel clipping.
+func MapGamut(src *LinearRGBAF32Image, m GamutMapping) *LinearRGBAF32Image {
+	dst := NewLinearRGBAF32Image(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			c := src.LinearRGBAF32At(x, y)
+			if c.A <= 0 {
+				dst.SetLinearRGBAF32(x, y, c)
+				continue
+			}
+			a := float64(c.A)
+			r, g, b := float64(c.R)/a, float64(c.G)/a, float64(c.B)/a
+			if !inGamut(r, g, b) {
+				if m == GamutCompressChroma {
+					r, g, b = compressChroma(r, g, b)
+				} else {
+					r, g, b = clamp01(r), clamp01(g), clamp01(b)
+				}
+			}
+			dst.SetLinearRGBAF32(x, y, LinearRGBAF32{float32(r * a), float32(g * a), float32(b * a), c.A})
+		}
+	}
+	return dst
+}
+
+// compressChroma maps the linear sRGB color (r, g, b) into gamut by binary
+// search on its Oklch chroma.
+func compressChroma(r, g, b float64) (float64, float64, float64) {
+	l, oa, ob := linearSRGBToOklab(r, g, b)
+	if l >= 1 {
+		return 1, 1, 1
+	}
+	if !(l > 0) {
+		return 0, 0, 0
+	}
+	chroma := math.Hypot(oa, ob)
+	ca, cb := oa/chroma, ob/chroma
+
+	clip := func(r, g, b float64) (float64, float64, float64) {
+		return clamp01(r), clamp01(g), clamp01(b)
+	}
+	// Accept the clipped color if it is close enough to the original.
+	cr, cg, cbl := clip(r, g, b)
+	if oklabDistance(l, oa, ob, cr, cg, cbl) < gamutJND {
+		return cr, cg, cbl
+	}
+
+	lo, hi := 0.0, chroma
+	for hi-lo > 1e-4 {
+		mid := (lo + hi) / 2
+		r, g, b := oklabToLinearSRGB(l, ca*mid, cb*mid)
+		if inGamut(r, g, b) {
+			lo = mid
+			continue
+		}
+		cr, cg, cbl := clip(r, g, b)
+		if oklabDistance(l, ca*mid, cb*mid, cr, cg, cbl) < gamutJND {
+			// Just outside the gamut: the clipped color is a close match.
+			return cr, cg, cbl
+		}
+		hi = mid
+	}
+	return clip(oklabToLinearSRGB(l, ca*lo, cb*lo))
+}
+
+// oklabDistance returns the Oklab distance between the Oklab color
+// (l, a, b) and the linear sRGB color (r, g, bl).
+func oklabDistance(l, a, b, r, g, bl float64) float64 {
+	l2, a2, b2 := linearSRGBToOklab(r, g, bl)
+	return math.Sqrt((l-l2)*(l-l2) + (a-a2)*(a-a2) + (b-b2)*(b-b2))
+}
+
+// linearSRGBToOklab converts linear sRGB to Björn Ottosson's Oklab.
+func linearSRGBToOklab(r, g, b float64) (l, a, bb float64) {
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc,
+		1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc,
+		0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+}
+
+// oklabToLinearSRGB converts Oklab to linear sRGB.
+func oklabToLinearSRGB(l, a, b float64) (r, g, bl float64) {
+	lc := l + 0.3963377774*a + 0.2158037573*b
+	mc := l - 0.1055613458*a - 0.0638541728*b
+	sc := l - 0.0894841775*a - 1.2914855480*b
+	lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+	return 4.0767416621*lc - 3.3077115913*mc + 0.2309699292*sc,
+		-1.2684380046*lc + 2.6097574011*mc - 0.3413193965*sc,
+		-0.0041960863*lc - 0.7034186147*mc + 1.7076147010*sc
+}
@@ -0,0 +1,72 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestOklab_RoundTrip(t *testing.T) {
+	l, a, b := linearSRGBToOklab(1, 1, 1)
+	if !approxEqual(l, 1, 1e-4) || !approxEqual(a, 0, 1e-4) || !approxEqual(b, 0, 1e-4) {
+		t.Errorf("Oklab of white = (%v, %v, %v), want (1, 0, 0)", l, a, b)
+	}
+	for _, c := range [][3]float64{{0.2, 0.5, 0.9}, {1, 0, 0}, {1.3, -0.2, 0.1}} {
+		r, g, bl := oklabToLinearSRGB(linearSRGBToOklab(c[0], c[1], c[2]))
+		if !approxEqual(r, c[0], 1e-6) || !approxEqual(g, c[1], 1e-6) || !approxEqual(bl, c[2], 1e-6) {
+			t.Errorf("round trip of %v = (%v, %v, %v)", c, r, g, bl)
+		}
+	}
+}
+
+func TestMapGamut(t *testing.T) {
+	src := NewLinearRGBAF32Image(image.Rect(0, 0, 4, 1))
+	inside := LinearRGBAF32{0.2, 0.4, 0.6, 1}
+	src.SetLinearRGBAF32(0, 0, inside)
+	// Display P3 green, far outside sRGB.
+	src.Set(1, 0, DisplayP3{0, 1, 0})
+	// Half-transparent out of gamut red, premultiplied.
+	src.SetLinearRGBAF32(2, 0, LinearRGBAF32{0.6, -0.05, 0, 0.5})
+	src.SetLinearRGBAF32(3, 0, LinearRGBAF32{2, 2, 2, 1})
+
+	for _, m := range []GamutMapping{GamutClip, GamutCompressChroma} {
+		dst := MapGamut(src, m)
+		if got := dst.LinearRGBAF32At(0, 0); got != inside {
+			t.Errorf("mapping %d: in-gamut pixel = %v, want %v", m, got, inside)
+		}
+		for x := 1; x < 4; x++ {
+			c := dst.LinearRGBAF32At(x, 0)
+			a := c.A
+			if !inGamut(float64(c.R/a), float64(c.G/a), float64(c.B/a)) {
+				t.Errorf("mapping %d: pixel %d = %v, out of gamut", m, x, c)
+			}
+		}
+		if a := dst.LinearRGBAF32At(2, 0).A; a != 0.5 {
+			t.Errorf("mapping %d: alpha = %v, want 0.5", m, a)
+		}
+		if got := dst.LinearRGBAF32At(3, 0); got != (LinearRGBAF32{1, 1, 1, 1}) {
+			t.Errorf("mapping %d: super-white = %v, want white", m, got)
+		}
+	}
+}
+
+func TestMapGamut_CompressChromaKeepsHue(t *testing.T) {
+	src := NewLinearRGBAF32Image(image.Rect(0, 0, 1, 1))
+	src.Set(0, 0, DisplayP3{0, 1, 0})
+	c := src.LinearRGBAF32At(0, 0)
+	l0, a0, b0 := linearSRGBToOklab(float64(c.R), float64(c.G), float64(c.B))
+
+	hue := func(m GamutMapping) (float64, float64) {
+		d := MapGamut(src, m).LinearRGBAF32At(0, 0)
+		l, a, b := linearSRGBToOklab(float64(d.R), float64(d.G), float64(d.B))
+		return math.Abs(math.Atan2(b, a) - math.Atan2(b0, a0)), math.Abs(l - l0)
+	}
+	clipHue, _ := hue(GamutClip)
+	compHue, compL := hue(GamutCompressChroma)
+	if compHue > clipHue {
+		t.Errorf("chroma compression hue shift %v exceeds clipping shift %v", compHue, clipHue)
+	}
+	if compL > gamutJND {
+		t.Errorf("chroma compression lightness shift = %v, want < %v", compL, gamutJND)
+	}
+}
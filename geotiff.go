@@ -0,0 +1,496 @@
+package colorext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TIFF tags understood by DecodeGeoTIFF.
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPredictor       = 317
+	tagTileWidth       = 322
+	tagTileLength      = 323
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSampleFormat    = 339
+
+	tagModelPixelScale    = 33550
+	tagModelTiepoint      = 33922
+	tagModelTransform     = 34264
+	tagGDALNoData         = 42113
+	tagGeoKeyDirectory    = 34735
+	geoKeyRasterType      = 1025
+	rasterPixelIsPoint    = 2
+	compressionNone       = 1
+	compressionDeflate    = 8
+	compressionDeflateOld = 32946
+	predictorNone         = 1
+	predictorHorizontal   = 2
+	sampleFormatInt       = 2
+	sampleFormatFloat     = 3
+)
+
+// TIFF field types.
+const (
+	tiffByte   = 1
+	tiffASCII  = 2
+	tiffShort  = 3
+	tiffLong   = 4
+	tiffSByte  = 6
+	tiffSShort = 8
+	tiffSLong  = 9
+	tiffFloat  = 11
+	tiffDouble = 12
+)
+
+var tiffTypeSize = map[uint16]int{
+	tiffByte: 1, tiffASCII: 1, tiffShort: 2, tiffLong: 4,
+	tiffSByte: 1, tiffSShort: 2, tiffSLong: 4, tiffFloat: 4, tiffDouble: 8,
+}
+
+// GeoTransform is an affine transform from pixel/line coordinates to
+// georeferenced coordinates. The coefficients use the same order as GDAL:
+//
+//	Xgeo = T[0] + px*T[1] + py*T[2]
+//	Ygeo = T[3] + px*T[4] + py*T[5]
+type GeoTransform [6]float64
+
+// Apply maps the pixel coordinate (px, py) to georeferenced coordinates.
+// Pixel (0, 0) refers to the top-left corner of the top-left pixel.
+func (t GeoTransform) Apply(px, py float64) (x, y float64) {
+	return t[0] + px*t[1] + py*t[2], t[3] + px*t[4] + py*t[5]
+}
+
+// GeoMetadata holds the georeferencing information read from a GeoTIFF.
+type GeoMetadata struct {
+	// Transform maps pixel coordinates to georeferenced coordinates.
+	// It is only meaningful if HasTransform is true.
+	Transform GeoTransform
+	// HasTransform reports whether the file contained a geotransform.
+	HasTransform bool
+	// PixelIsPoint reports whether the raster declares point (rather than
+	// area) pixel semantics. Transform already accounts for the half-pixel
+	// shift in that case, so it always refers to pixel corners.
+	PixelIsPoint bool
+	// NoData is the sentinel value marking missing samples.
+	// It is only meaningful if HasNoData is true.
+	NoData float64
+	// HasNoData reports whether the file declared a nodata value.
+	HasNoData bool
+}
+
+// MaxGeoTIFFPixels is the largest raster, and the largest strip or tile, in
+// pixels, that DecodeGeoTIFF decodes: 256 Mi pixels, taking up to 1 GiB.
+// A header of a few bytes can declare far larger rasters, so they are
+// rejected before their pixels are allocated.
+const MaxGeoTIFFPixels = 1 << 28
+
+// DecodeGeoTIFF reads a single-band GeoTIFF elevation model from r.
+// Signed 16-bit rasters are returned as a *GrayS16Image and 32-bit floating
+// point rasters as a *GrayF32Image. Uncompressed and Deflate-compressed
+// strips and tiles are supported, with or without horizontal differencing.
+// Malformed or unsupported files, and rasters of more than MaxGeoTIFFPixels
+// pixels, yield a *DecodeError.
+func DecodeGeoTIFF(r io.Reader) (image.Image, *GeoMetadata, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := newTIFFDecoder(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := d.decodeRaster()
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, err := d.geoMetadata()
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, meta, nil
+}
+
+// isGeoTIFF reports whether data is a TIFF file carrying GeoTIFF or GDAL
+// tags, as opposed to an ordinary picture.
+func isGeoTIFF(data []byte) bool {
+	d, err := newTIFFDecoder(data)
+	if err != nil {
+		return false
+	}
+	for _, tag := range []uint16{tagModelPixelScale, tagModelTiepoint, tagModelTransform, tagGeoKeyDirectory, tagGDALNoData} {
+		if _, ok := d.fields[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tiffField is a single IFD entry.
+type tiffField struct {
+	typ   uint16
+	count uint32
+	raw   []byte
+	// at is the offset of the entry in the file.
+	at int64
+}
+
+// geoTIFFError returns a DecodeError for a GeoTIFF at off.
+func geoTIFFError(off int64, err error) error {
+	return decodeError("GeoTIFF", off, err)
+}
+
+// at returns the offset of the entry for tag, or 0 if it is absent.
+func (d *tiffDecoder) at(tag uint16) int64 {
+	return d.fields[tag].at
+}
+
+type tiffDecoder struct {
+	data   []byte
+	bo     binary.ByteOrder
+	fields map[uint16]tiffField
+}
+
+func newTIFFDecoder(data []byte) (*tiffDecoder, error) {
+	if len(data) < 8 {
+		return nil, geoTIFFError(int64(len(data)), io.ErrUnexpectedEOF)
+	}
+	d := &tiffDecoder{data: data, fields: make(map[uint16]tiffField)}
+	switch string(data[:4]) {
+	case "II*\x00":
+		d.bo = binary.LittleEndian
+	case "MM\x00*":
+		d.bo = binary.BigEndian
+	default:
+		if string(data[:4]) == "II+\x00" || string(data[:4]) == "MM\x00+" {
+			return nil, geoTIFFError(0, errors.New("BigTIFF is not supported"))
+		}
+		return nil, geoTIFFError(0, errors.New("invalid TIFF header"))
+	}
+
+	ifd := int64(d.bo.Uint32(data[4:8]))
+	if ifd < 8 || ifd+2 > int64(len(data)) {
+		return nil, geoTIFFError(4, errors.New("IFD offset out of range"))
+	}
+	n := int64(d.bo.Uint16(data[ifd:]))
+	if ifd+2+n*12 > int64(len(data)) {
+		return nil, geoTIFFError(ifd, errors.New("IFD truncated"))
+	}
+	for i := int64(0); i < n; i++ {
+		at := ifd + 2 + i*12
+		e := data[at : at+12]
+		tag := d.bo.Uint16(e[0:2])
+		typ := d.bo.Uint16(e[2:4])
+		count := d.bo.Uint32(e[4:8])
+		size, ok := tiffTypeSize[typ]
+		if !ok {
+			// Unknown field types are skipped, as the TIFF spec requires.
+			continue
+		}
+		length := int64(size) * int64(count)
+		raw := e[8:12]
+		if length > 4 {
+			off := int64(d.bo.Uint32(e[8:12]))
+			if off+length > int64(len(data)) {
+				return nil, geoTIFFError(at+8, fmt.Errorf("tag %d value out of range", tag))
+			}
+			raw = data[off : off+length]
+		} else {
+			raw = raw[:length]
+		}
+		d.fields[tag] = tiffField{typ: typ, count: count, raw: raw, at: at}
+	}
+	return d, nil
+}
+
+// uints returns the values of an integer field.
+func (d *tiffDecoder) uints(tag uint16) ([]uint64, error) {
+	f, ok := d.fields[tag]
+	if !ok {
+		return nil, nil
+	}
+	v := make([]uint64, f.count)
+	for i := range v {
+		switch f.typ {
+		case tiffByte:
+			v[i] = uint64(f.raw[i])
+		case tiffShort:
+			v[i] = uint64(d.bo.Uint16(f.raw[2*i:]))
+		case tiffLong:
+			v[i] = uint64(d.bo.Uint32(f.raw[4*i:]))
+		default:
+			return nil, geoTIFFError(f.at, fmt.Errorf("tag %d has non-integer type %d", tag, f.typ))
+		}
+	}
+	return v, nil
+}
+
+// uintValue returns the first value of an integer field, or def if it is absent.
+func (d *tiffDecoder) uintValue(tag uint16, def uint64) (uint64, error) {
+	v, err := d.uints(tag)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) == 0 {
+		return def, nil
+	}
+	return v[0], nil
+}
+
+// floats returns the values of a DOUBLE field.
+func (d *tiffDecoder) floats(tag uint16) ([]float64, error) {
+	f, ok := d.fields[tag]
+	if !ok {
+		return nil, nil
+	}
+	if f.typ != tiffDouble {
+		return nil, geoTIFFError(f.at, fmt.Errorf("tag %d has non-double type %d", tag, f.typ))
+	}
+	v := make([]float64, f.count)
+	for i := range v {
+		v[i] = math.Float64frombits(d.bo.Uint64(f.raw[8*i:]))
+	}
+	return v, nil
+}
+
+func (d *tiffDecoder) decodeRaster() (image.Image, error) {
+	width, err := d.uintValue(tagImageWidth, 0)
+	if err != nil {
+		return nil, err
+	}
+	height, err := d.uintValue(tagImageLength, 0)
+	if err != nil {
+		return nil, err
+	}
+	if width == 0 || height == 0 || width > 1<<20 || height > 1<<20 {
+		return nil, geoTIFFError(d.at(tagImageWidth), fmt.Errorf("invalid dimensions %dx%d", width, height))
+	}
+	if width*height > MaxGeoTIFFPixels {
+		return nil, geoTIFFError(d.at(tagImageWidth), fmt.Errorf("raster of %d×%d pixels is too large", width, height))
+	}
+	if spp, err := d.uintValue(tagSamplesPerPixel, 1); err != nil {
+		return nil, err
+	} else if spp != 1 {
+		return nil, geoTIFFError(d.at(tagSamplesPerPixel), fmt.Errorf("%w: %d samples per pixel", ErrUnsupportedDType, spp))
+	}
+	bps, err := d.uintValue(tagBitsPerSample, 1)
+	if err != nil {
+		return nil, err
+	}
+	format, err := d.uintValue(tagSampleFormat, 1)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := d.uintValue(tagCompression, compressionNone)
+	if err != nil {
+		return nil, err
+	}
+	if compression != compressionNone && compression != compressionDeflate && compression != compressionDeflateOld {
+		return nil, geoTIFFError(d.at(tagCompression), fmt.Errorf("unsupported compression %d", compression))
+	}
+	predictor, err := d.uintValue(tagPredictor, predictorNone)
+	if err != nil {
+		return nil, err
+	}
+	if predictor != predictorNone && predictor != predictorHorizontal {
+		return nil, geoTIFFError(d.at(tagPredictor), fmt.Errorf("unsupported predictor %d", predictor))
+	}
+
+	var (
+		img    image.Image
+		pix    []uint8
+		bpp    int
+		rect   = image.Rect(0, 0, int(width), int(height))
+		stride int
+	)
+	switch {
+	case format == sampleFormatInt && bps == 16:
+		m := NewGrayS16Image(rect)
+		img, pix, bpp, stride = m, m.Pix, 2, m.Stride
+	case format == sampleFormatFloat && bps == 32:
+		m := NewGrayF32Image(rect)
+		img, pix, bpp, stride = m, m.Pix, 4, m.Stride
+	default:
+		return nil, geoTIFFError(d.at(tagSampleFormat), fmt.Errorf("%w: sample format %d with %d bits per sample", ErrUnsupportedDType, format, bps))
+	}
+
+	// Strips are treated as tiles spanning the full image width.
+	blockW, blockH := width, height
+	offsetsTag, sizeTag := uint16(tagTileOffsets), uint16(tagTileWidth)
+	offsets, err := d.uints(tagTileOffsets)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := d.uints(tagTileByteCounts)
+	if err != nil {
+		return nil, err
+	}
+	if offsets != nil {
+		if blockW, err = d.uintValue(tagTileWidth, 0); err != nil {
+			return nil, err
+		}
+		if blockH, err = d.uintValue(tagTileLength, 0); err != nil {
+			return nil, err
+		}
+	} else {
+		offsetsTag, sizeTag = tagStripOffsets, tagRowsPerStrip
+		if offsets, err = d.uints(tagStripOffsets); err != nil {
+			return nil, err
+		}
+		if counts, err = d.uints(tagStripByteCounts); err != nil {
+			return nil, err
+		}
+		if blockH, err = d.uintValue(tagRowsPerStrip, height); err != nil {
+			return nil, err
+		}
+		blockH = min(blockH, height)
+	}
+	if blockW == 0 || blockH == 0 {
+		return nil, geoTIFFError(d.at(sizeTag), errors.New("invalid block size"))
+	}
+	if blockW*blockH > MaxGeoTIFFPixels {
+		return nil, geoTIFFError(d.at(sizeTag), fmt.Errorf("block of %d×%d pixels is too large", blockW, blockH))
+	}
+	across := (width + blockW - 1) / blockW
+	down := (height + blockH - 1) / blockH
+	if uint64(len(offsets)) < across*down || len(counts) < len(offsets) {
+		return nil, geoTIFFError(d.at(offsetsTag), errors.New("missing strip or tile offsets"))
+	}
+
+	rowBytes := int(blockW) * bpp
+	for by := uint64(0); by < down; by++ {
+		for bx := uint64(0); bx < across; bx++ {
+			idx := by*across + bx
+			off, n := offsets[idx], counts[idx]
+			if off+n > uint64(len(d.data)) {
+				return nil, geoTIFFError(int64(off), errors.New("block data out of range"))
+			}
+			block := d.data[off : off+n]
+			if compression != compressionNone {
+				zr, err := zlib.NewReader(bytes.NewReader(block))
+				if err != nil {
+					return nil, geoTIFFError(int64(off), err)
+				}
+				// A block never holds more than blockH full rows, however
+				// far its stream would inflate.
+				block, err = io.ReadAll(io.LimitReader(zr, int64(blockH)*int64(rowBytes)))
+				if err != nil {
+					return nil, geoTIFFError(int64(off), err)
+				}
+			}
+
+			x0, y0 := int(bx*blockW), int(by*blockH)
+			rows := min(int(blockH), int(height)-y0)
+			cols := min(int(blockW), int(width)-x0)
+			if len(block) < (rows-1)*rowBytes+cols*bpp {
+				return nil, geoTIFFError(int64(off), errors.New("block data truncated"))
+			}
+			for row := 0; row < rows; row++ {
+				src := block[row*rowBytes:]
+				dst := pix[(y0+row)*stride+x0*bpp:]
+				d.copyRow(dst, src, cols, bpp, predictor == predictorHorizontal)
+			}
+		}
+	}
+	return img, nil
+}
+
+// copyRow converts cols samples of bpp bytes from file byte order in src to
+// big-endian in dst, undoing horizontal differencing if requested.
+func (d *tiffDecoder) copyRow(dst, src []byte, cols, bpp int, diff bool) {
+	switch bpp {
+	case 2:
+		var acc uint16
+		for i := 0; i < cols; i++ {
+			v := d.bo.Uint16(src[2*i:])
+			if diff {
+				acc += v
+				v = acc
+			}
+			binary.BigEndian.PutUint16(dst[2*i:], v)
+		}
+	case 4:
+		var acc uint32
+		for i := 0; i < cols; i++ {
+			v := d.bo.Uint32(src[4*i:])
+			if diff {
+				acc += v
+				v = acc
+			}
+			binary.BigEndian.PutUint32(dst[4*i:], v)
+		}
+	}
+}
+
+func (d *tiffDecoder) geoMetadata() (*GeoMetadata, error) {
+	meta := &GeoMetadata{}
+
+	scale, err := d.floats(tagModelPixelScale)
+	if err != nil {
+		return nil, err
+	}
+	tie, err := d.floats(tagModelTiepoint)
+	if err != nil {
+		return nil, err
+	}
+	m, err := d.floats(tagModelTransform)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(m) >= 16:
+		meta.Transform = GeoTransform{m[3], m[0], m[1], m[7], m[4], m[5]}
+		meta.HasTransform = true
+	case len(scale) >= 2 && len(tie) >= 6:
+		meta.Transform = GeoTransform{
+			tie[3] - tie[0]*scale[0], scale[0], 0,
+			tie[4] + tie[1]*scale[1], 0, -scale[1],
+		}
+		meta.HasTransform = true
+	}
+
+	keys, err := d.uints(tagGeoKeyDirectory)
+	if err != nil {
+		return nil, err
+	}
+	// The directory is a header of four shorts followed by entries of
+	// (key, location, count, value).
+	for i := 4; i+3 < len(keys); i += 4 {
+		if keys[i] == geoKeyRasterType && keys[i+1] == 0 && keys[i+3] == rasterPixelIsPoint {
+			meta.PixelIsPoint = true
+		}
+	}
+	if meta.PixelIsPoint && meta.HasTransform {
+		// Shift by half a pixel so the transform refers to pixel corners.
+		t := &meta.Transform
+		t[0] -= 0.5*t[1] + 0.5*t[2]
+		t[3] -= 0.5*t[4] + 0.5*t[5]
+	}
+
+	if f, ok := d.fields[tagGDALNoData]; ok && f.typ == tiffASCII {
+		s := strings.TrimSpace(strings.TrimRight(string(f.raw), "\x00"))
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, geoTIFFError(f.at, fmt.Errorf("invalid nodata value %q", s))
+		}
+		meta.NoData = v
+		meta.HasNoData = true
+	}
+	return meta, nil
+}
@@ -0,0 +1,334 @@
+package colorext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"math"
+	"sort"
+	"testing"
+)
+
+// tiffEntry is a field written by buildTIFF. Exactly one of the value
+// slices should be set.
+type tiffEntry struct {
+	tag     uint16
+	shorts  []uint16
+	longs   []uint32
+	doubles []float64
+	ascii   string
+}
+
+// tiffByteOrder is satisfied by binary.LittleEndian and binary.BigEndian.
+type tiffByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// buildTIFF assembles a classic TIFF file with a single IFD. Block data is
+// appended after the IFD and the offsets tag (273 or 324) is filled in.
+func buildTIFF(bo tiffByteOrder, entries []tiffEntry, offsetsTag uint16, blocks [][]byte) []byte {
+	var counts []uint32
+	for _, b := range blocks {
+		counts = append(counts, uint32(len(b)))
+	}
+	countsTag := uint16(tagStripByteCounts)
+	if offsetsTag == tagTileOffsets {
+		countsTag = tagTileByteCounts
+	}
+	entries = append(entries,
+		tiffEntry{tag: offsetsTag, longs: make([]uint32, len(blocks))},
+		tiffEntry{tag: countsTag, longs: counts},
+	)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+
+	header := make([]byte, 8)
+	if bo == binary.LittleEndian {
+		copy(header, "II*\x00")
+	} else {
+		copy(header, "MM\x00*")
+	}
+	bo.PutUint32(header[4:], 8)
+
+	ifdSize := 2 + 12*len(entries) + 4
+	extra := &bytes.Buffer{}
+	ifd := &bytes.Buffer{}
+	binary.Write(ifd, bo, uint16(len(entries)))
+	// offsetsPos is the file position of the first block offset.
+	var offsetsPos int
+
+	for i, e := range entries {
+		var typ uint16
+		var val []byte
+		var count int
+		switch {
+		case e.shorts != nil:
+			typ, count = tiffShort, len(e.shorts)
+			for _, v := range e.shorts {
+				val = bo.AppendUint16(val, v)
+			}
+		case e.longs != nil:
+			typ, count = tiffLong, len(e.longs)
+			for _, v := range e.longs {
+				val = bo.AppendUint32(val, v)
+			}
+		case e.doubles != nil:
+			typ, count = tiffDouble, len(e.doubles)
+			for _, v := range e.doubles {
+				val = bo.AppendUint64(val, math.Float64bits(v))
+			}
+		default:
+			typ, count = tiffASCII, len(e.ascii)+1
+			val = append([]byte(e.ascii), 0)
+		}
+		binary.Write(ifd, bo, e.tag)
+		binary.Write(ifd, bo, typ)
+		binary.Write(ifd, bo, uint32(count))
+		if len(val) <= 4 {
+			if e.tag == offsetsTag {
+				offsetsPos = 8 + 2 + 12*i + 8
+			}
+			ifd.Write(append(val, make([]byte, 4-len(val))...))
+			continue
+		}
+		off := 8 + ifdSize + extra.Len()
+		if e.tag == offsetsTag {
+			offsetsPos = off
+		}
+		binary.Write(ifd, bo, uint32(off))
+		extra.Write(val)
+	}
+	binary.Write(ifd, bo, uint32(0))
+
+	out := append(header, ifd.Bytes()...)
+	out = append(out, extra.Bytes()...)
+
+	// Append block data and patch the offsets.
+	var offs []uint32
+	for _, b := range blocks {
+		offs = append(offs, uint32(len(out)))
+		out = append(out, b...)
+	}
+	for i, o := range offs {
+		bo.PutUint32(out[offsetsPos+4*i:], o)
+	}
+	return out
+}
+
+func TestDecodeGeoTIFF_Int16Strips(t *testing.T) {
+	const w, h = 3, 4
+	values := []int16{
+		-32768, -1, 0,
+		1, 100, 32767,
+		-9999, 500, 501,
+		-500, 12, -12,
+	}
+	bo := binary.LittleEndian
+	// Two rows per strip.
+	var blocks [][]byte
+	for s := 0; s < 2; s++ {
+		var b []byte
+		for _, v := range values[s*2*w : (s+1)*2*w] {
+			b = bo.AppendUint16(b, uint16(v))
+		}
+		blocks = append(blocks, b)
+	}
+	data := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{w}},
+		{tag: tagImageLength, shorts: []uint16{h}},
+		{tag: tagBitsPerSample, shorts: []uint16{16}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+		{tag: tagRowsPerStrip, shorts: []uint16{2}},
+		{tag: tagModelPixelScale, doubles: []float64{30, 30, 0}},
+		{tag: tagModelTiepoint, doubles: []float64{0, 0, 0, 500000, 4200000, 0}},
+		{tag: tagGDALNoData, ascii: "-9999"},
+	}, tagStripOffsets, blocks)
+
+	img, meta, err := DecodeGeoTIFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGeoTIFF: %v", err)
+	}
+	dem, ok := img.(*GrayS16Image)
+	if !ok {
+		t.Fatalf("DecodeGeoTIFF returned %T, want *GrayS16Image", img)
+	}
+	if dem.Bounds() != image.Rect(0, 0, w, h) {
+		t.Errorf("Bounds() = %v, want %v", dem.Bounds(), image.Rect(0, 0, w, h))
+	}
+	for i, v := range values {
+		if got := dem.GrayS16At(i%w, i/w); got.Y != v {
+			t.Errorf("GrayS16At(%d, %d) = %d, want %d", i%w, i/w, got.Y, v)
+		}
+	}
+
+	if !meta.HasNoData || meta.NoData != -9999 {
+		t.Errorf("NoData = (%v, %v), want (-9999, true)", meta.NoData, meta.HasNoData)
+	}
+	want := GeoTransform{500000, 30, 0, 4200000, 0, -30}
+	if !meta.HasTransform || meta.Transform != want {
+		t.Errorf("Transform = %v, want %v", meta.Transform, want)
+	}
+	if x, y := meta.Transform.Apply(2, 1); x != 500060 || y != 4199970 {
+		t.Errorf("Transform.Apply(2, 1) = (%v, %v), want (500060, 4199970)", x, y)
+	}
+}
+
+func TestDecodeGeoTIFF_Float32DeflateTiles(t *testing.T) {
+	const w, h, tile = 5, 3, 4
+	at := func(x, y int) float32 { return float32(x) - 1.5*float32(y) }
+
+	bo := binary.BigEndian
+	var blocks [][]byte
+	for ty := 0; ty < h; ty += tile {
+		for tx := 0; tx < w; tx += tile {
+			var raw []byte
+			for y := ty; y < ty+tile; y++ {
+				for x := tx; x < tx+tile; x++ {
+					raw = bo.AppendUint32(raw, math.Float32bits(at(x, y)))
+				}
+			}
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			zw.Write(raw)
+			zw.Close()
+			blocks = append(blocks, buf.Bytes())
+		}
+	}
+	m := make([]float64, 16)
+	m[0], m[3], m[5], m[7], m[15] = 0.5, 10, -0.5, 20, 1
+	data := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, longs: []uint32{w}},
+		{tag: tagImageLength, longs: []uint32{h}},
+		{tag: tagBitsPerSample, shorts: []uint16{32}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatFloat}},
+		{tag: tagCompression, shorts: []uint16{compressionDeflate}},
+		{tag: tagTileWidth, shorts: []uint16{tile}},
+		{tag: tagTileLength, shorts: []uint16{tile}},
+		{tag: tagModelTransform, doubles: m},
+	}, tagTileOffsets, blocks)
+
+	img, meta, err := DecodeGeoTIFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGeoTIFF: %v", err)
+	}
+	dem, ok := img.(*GrayF32Image)
+	if !ok {
+		t.Fatalf("DecodeGeoTIFF returned %T, want *GrayF32Image", img)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if got := dem.GrayF32At(x, y); got.Y != at(x, y) {
+				t.Errorf("GrayF32At(%d, %d) = %v, want %v", x, y, got.Y, at(x, y))
+			}
+		}
+	}
+	if meta.HasNoData {
+		t.Error("HasNoData = true, want false")
+	}
+	want := GeoTransform{10, 0.5, 0, 20, 0, -0.5}
+	if meta.Transform != want {
+		t.Errorf("Transform = %v, want %v", meta.Transform, want)
+	}
+}
+
+func TestDecodeGeoTIFF_HorizontalPredictor(t *testing.T) {
+	values := []int16{100, 90, 120, -5}
+	bo := binary.LittleEndian
+	var b []byte
+	prev := int16(0)
+	for _, v := range values {
+		b = bo.AppendUint16(b, uint16(v-prev))
+		prev = v
+	}
+	data := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{4}},
+		{tag: tagImageLength, shorts: []uint16{1}},
+		{tag: tagBitsPerSample, shorts: []uint16{16}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+		{tag: tagPredictor, shorts: []uint16{predictorHorizontal}},
+	}, tagStripOffsets, [][]byte{b})
+
+	img, _, err := DecodeGeoTIFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGeoTIFF: %v", err)
+	}
+	dem := img.(*GrayS16Image)
+	for x, v := range values {
+		if got := dem.GrayS16At(x, 0); got.Y != v {
+			t.Errorf("GrayS16At(%d, 0) = %d, want %d", x, got.Y, v)
+		}
+	}
+}
+
+func TestDecodeGeoTIFF_Errors(t *testing.T) {
+	bo := binary.LittleEndian
+	uint8Raster := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{1}},
+		{tag: tagImageLength, shorts: []uint16{1}},
+		{tag: tagBitsPerSample, shorts: []uint16{8}},
+	}, tagStripOffsets, [][]byte{{0}})
+	// A header alone declares a terapixel raster, or a huge tile.
+	hugeRaster := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, longs: []uint32{1 << 20}},
+		{tag: tagImageLength, longs: []uint32{1 << 20}},
+		{tag: tagBitsPerSample, shorts: []uint16{32}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatFloat}},
+	}, tagStripOffsets, [][]byte{{0}})
+	hugeTile := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{1}},
+		{tag: tagImageLength, shorts: []uint16{1}},
+		{tag: tagBitsPerSample, shorts: []uint16{16}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+		{tag: tagTileWidth, longs: []uint32{1 << 20}},
+		{tag: tagTileLength, longs: []uint32{1 << 20}},
+	}, tagTileOffsets, [][]byte{{0}})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a tiff", []byte("GIF89a\x00\x00\x00\x00")},
+		{"bigtiff", []byte("II+\x00\x08\x00\x00\x00\x00\x00\x00\x00")},
+		{"ifd out of range", []byte("II*\x00\xff\x00\x00\x00")},
+		{"unsupported sample format", uint8Raster},
+		{"raster too large", hugeRaster},
+		{"tile too large", hugeTile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecodeGeoTIFF(bytes.NewReader(tt.data))
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Errorf("DecodeGeoTIFF error = %v, want a *DecodeError", err)
+			}
+		})
+	}
+}
+
+func TestDecodeGeoTIFF_InflateLimit(t *testing.T) {
+	// A strip inflating far beyond its rows is read only as far as needed.
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte{0, 7})
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+	data := buildTIFF(binary.LittleEndian, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{1}},
+		{tag: tagImageLength, shorts: []uint16{1}},
+		{tag: tagBitsPerSample, shorts: []uint16{16}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+		{tag: tagCompression, shorts: []uint16{compressionDeflate}},
+	}, tagStripOffsets, [][]byte{buf.Bytes()})
+
+	img, _, err := DecodeGeoTIFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGeoTIFF: %v", err)
+	}
+	if got := img.(*GrayS16Image).GrayS16At(0, 0).Y; got != 0x700 {
+		t.Errorf("GrayS16At(0, 0) = %#x, want 0x700", got)
+	}
+}
@@ -0,0 +1,14 @@
+// Package gocvx converts between colorext images and gocv (OpenCV) Mats.
+//
+// The converters are only built with the gocv build tag, since gocv requires
+// cgo and an OpenCV installation:
+//
+//	go get gocv.io/x/gocv
+//	go build -tags gocv
+//
+// GrayS16Image maps to CV_16SC1 and GrayF32Image to CV_32FC1. colorext
+// stores samples big-endian while a Mat holds them in native byte order, so
+// pixels can only be shared without copying on big-endian machines, and only
+// for images whose rows are contiguous. Otherwise they are copied and
+// byte-swapped.
+package gocvx
@@ -0,0 +1,126 @@
+//go:build gocv
+
+package gocvx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/gracefulearth/go-colorext"
+	"gocv.io/x/gocv"
+)
+
+// nativeBigEndian reports whether the machine stores samples in the same
+// order as colorext.
+var nativeBigEndian = binary.NativeEndian.Uint16([]byte{0, 1}) == 1
+
+// FromGrayS16 returns a CV_16SC1 Mat holding the pixels of img. If the Mat
+// shares img's pixels, img must be kept alive, and its pixels unmodified
+// unless the change is intended, while the Mat is in use. The caller must
+// Close the Mat.
+func FromGrayS16(img *colorext.GrayS16Image) (gocv.Mat, error) {
+	return fromPix(img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 2, gocv.MatTypeCV16SC1)
+}
+
+// FromGrayF32 is like FromGrayS16, returning a CV_32FC1 Mat.
+func FromGrayF32(img *colorext.GrayF32Image) (gocv.Mat, error) {
+	return fromPix(img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 4, gocv.MatTypeCV32FC1)
+}
+
+func fromPix(pix []uint8, stride, w, h, size int, t gocv.MatType) (gocv.Mat, error) {
+	if w == 0 || h == 0 {
+		return gocv.NewMatWithSize(h, w, t), nil
+	}
+	if nativeBigEndian && stride == w*size {
+		return gocv.NewMatFromBytes(h, w, t, pix[:h*stride])
+	}
+	m := gocv.NewMatWithSize(h, w, t)
+	dst, err := m.DataPtrUint8()
+	if err != nil {
+		m.Close()
+		return gocv.Mat{}, err
+	}
+	for y := 0; y < h; y++ {
+		row := dst[y*w*size : (y+1)*w*size]
+		copy(row, pix[y*stride:])
+		if !nativeBigEndian {
+			swap(row, size)
+		}
+	}
+	return m, nil
+}
+
+// ToGrayS16 returns a GrayS16Image holding the pixels of m, which must be a
+// CV_16SC1 Mat. On big-endian machines a continuous Mat's pixels are shared,
+// so m must stay open while the image is in use.
+func ToGrayS16(m gocv.Mat) (*colorext.GrayS16Image, error) {
+	pix, stride, err := toPix(m, 2, gocv.MatTypeCV16SC1)
+	if err != nil {
+		return nil, err
+	}
+	return &colorext.GrayS16Image{Pix: pix, Stride: stride, Rect: rect(m)}, nil
+}
+
+// ToGrayF32 is like ToGrayS16 for CV_32FC1 Mats.
+func ToGrayF32(m gocv.Mat) (*colorext.GrayF32Image, error) {
+	pix, stride, err := toPix(m, 4, gocv.MatTypeCV32FC1)
+	if err != nil {
+		return nil, err
+	}
+	return &colorext.GrayF32Image{Pix: pix, Stride: stride, Rect: rect(m)}, nil
+}
+
+func rect(m gocv.Mat) image.Rectangle {
+	if m.Rows() == 0 || m.Cols() == 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(0, 0, m.Cols(), m.Rows())
+}
+
+func toPix(m gocv.Mat, size int, t gocv.MatType) ([]uint8, int, error) {
+	if m.Type() != t {
+		return nil, 0, fmt.Errorf("gocvx: Mat type %v, want %v", m.Type(), t)
+	}
+	w, h := m.Cols(), m.Rows()
+	if w == 0 || h == 0 {
+		return nil, 0, nil
+	}
+	if m.IsContinuous() {
+		src, err := m.DataPtrUint8()
+		if err != nil {
+			return nil, 0, err
+		}
+		if nativeBigEndian {
+			return src[:w*h*size], w * size, nil
+		}
+		pix := make([]uint8, w*h*size)
+		copy(pix, src)
+		swap(pix, size)
+		return pix, w * size, nil
+	}
+	// Non-continuous Mats, such as regions of a larger Mat, are read
+	// element by element.
+	pix := make([]uint8, w*h*size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * size
+			if size == 2 {
+				binary.BigEndian.PutUint16(pix[i:], uint16(m.GetShortAt(y, x)))
+			} else {
+				binary.BigEndian.PutUint32(pix[i:], math.Float32bits(m.GetFloatAt(y, x)))
+			}
+		}
+	}
+	return pix, w * size, nil
+}
+
+// swap reverses the bytes of each size-byte sample in b.
+func swap(b []uint8, size int) {
+	if size == 2 {
+		colorext.SwapBytes16(b)
+	} else {
+		colorext.SwapBytes32(b)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build gocv
+
+package gocvx
+
+import (
+	"image"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+	"gocv.io/x/gocv"
+)
+
+func TestGrayS16RoundTrip(t *testing.T) {
+	img := colorext.NewGrayS16Image(image.Rect(0, 0, 7, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			img.SetGrayS16(x, y, colorext.GrayS16{Y: int16(x*3000 - y*7000)})
+		}
+	}
+	for _, src := range []*colorext.GrayS16Image{img, img.SubImage(image.Rect(2, 1, 6, 4)).(*colorext.GrayS16Image)} {
+		m, err := FromGrayS16(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer m.Close()
+		if m.Type() != gocv.MatTypeCV16SC1 || m.Rows() != src.Rect.Dy() || m.Cols() != src.Rect.Dx() {
+			t.Fatalf("Mat type %v, %dx%d", m.Type(), m.Cols(), m.Rows())
+		}
+		if got, want := m.GetShortAt(1, 1), src.GrayS16At(src.Rect.Min.X+1, src.Rect.Min.Y+1).Y; got != want {
+			t.Errorf("Mat(1, 1) = %d, want %d", got, want)
+		}
+		back, err := ToGrayS16(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < back.Rect.Dy(); y++ {
+			for x := 0; x < back.Rect.Dx(); x++ {
+				if got, want := back.GrayS16At(x, y), src.GrayS16At(src.Rect.Min.X+x, src.Rect.Min.Y+y); got != want {
+					t.Errorf("(%d, %d) = %d, want %d", x, y, got.Y, want.Y)
+				}
+			}
+		}
+	}
+}
+
+func TestGrayF32RoundTrip(t *testing.T) {
+	img := colorext.NewGrayF32Image(image.Rect(0, 0, 4, 3))
+	img.SetGrayF32(3, 2, colorext.GrayF32{Y: -1.25})
+	m, err := FromGrayF32(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if got := m.GetFloatAt(2, 3); got != -1.25 {
+		t.Errorf("Mat(2, 3) = %v, want -1.25", got)
+	}
+	back, err := ToGrayF32(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := back.GrayF32At(3, 2).Y; got != -1.25 {
+		t.Errorf("round trip = %v, want -1.25", got)
+	}
+}
+
+func TestToGrayS16WrongType(t *testing.T) {
+	m := gocv.NewMatWithSize(2, 2, gocv.MatTypeCV32FC1)
+	defer m.Close()
+	if _, err := ToGrayS16(m); err == nil {
+		t.Error("expected an error for a CV_32FC1 Mat")
+	}
+}
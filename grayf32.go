@@ -0,0 +1,162 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// GrayF32 represents a 32-bit floating point grayscale color.
+// The nominal display range of Y is [0, 1], but any float32 value may be stored.
+type GrayF32 struct {
+	Y float32
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayF32 color.
+// This implements the color.Color interface.
+// The Y value is clamped to [0, 1] and scaled to the range [0, 65535].
+// NaN values are treated as 0.
+func (c GrayF32) RGBA() (r, g, b, a uint32) {
+	y := c.Y
+	if !(y > 0) {
+		y = 0
+	} else if y > 1 {
+		y = 1
+	}
+	v := uint32(y*0xffff + 0.5)
+	return v, v, v, 0xffff
+}
+
+// GrayF32Model is the color model for 32-bit floating point grayscale colors.
+var GrayF32Model color.Model = color.ModelFunc(grayF32Model)
+
+// grayF32Model converts any color.Color to a GrayF32.
+func grayF32Model(c color.Color) color.Color {
+	if _, ok := c.(GrayF32); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+
+	// Same luma weights as GrayS16Model, normalized to [0, 1].
+	y := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+	return GrayF32{float32(y) / 0xffff}
+}
+
+// GrayF32Image is an in-memory image whose At method returns GrayF32 values.
+type GrayF32Image struct {
+	// Pix holds the image's pixels, as IEEE 754 float32 values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+	// CompactRatio is like GrayS16Image.CompactRatio.
+	CompactRatio int
+}
+
+// ColorModel returns the GrayF32Image's color model.
+func (p *GrayF32Image) ColorModel() color.Model {
+	return GrayF32Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayF32Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayF32Image) At(x, y int) color.Color {
+	return p.GrayF32At(x, y)
+}
+
+// GrayF32At returns the GrayF32 color of the pixel at (x, y).
+func (p *GrayF32Image) GrayF32At(x, y int) GrayF32 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayF32{}
+	}
+	i := p.PixOffset(x, y)
+	// Read big-endian float32 bits
+	bits := uint32(p.Pix[i+0])<<24 | uint32(p.Pix[i+1])<<16 | uint32(p.Pix[i+2])<<8 | uint32(p.Pix[i+3])
+	return GrayF32{Y: math.Float32frombits(bits)}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayF32Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayF32Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	c1 := GrayF32Model.Convert(c).(GrayF32)
+	p.SetGrayF32(x, y, c1)
+}
+
+// SetGrayF32 sets the pixel at (x, y) to a given GrayF32 color.
+func (p *GrayF32Image) SetGrayF32(x, y int, c GrayF32) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	// Write big-endian float32 bits
+	bits := math.Float32bits(c.Y)
+	p.Pix[i+0] = uint8(bits >> 24)
+	p.Pix[i+1] = uint8(bits >> 16)
+	p.Pix[i+2] = uint8(bits >> 8)
+	p.Pix[i+3] = uint8(bits)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayF32Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayF32Image{Meta: p.Meta, CompactRatio: p.CompactRatio}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	sub := &GrayF32Image{
+		Pix:          p.Pix[i:],
+		Stride:       p.Stride,
+		Rect:         r,
+		Meta:         p.Meta,
+		CompactRatio: p.CompactRatio,
+	}
+	if compactSub(p.Pix, p.CompactRatio, 4*r.Dx(), r.Dy()) {
+		sub.Compact()
+	}
+	return sub
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayF32Image is always fully opaque since the GrayF32 color model has no transparency.
+func (p *GrayF32Image) Opaque() bool {
+	return true
+}
+
+// NewGrayF32Image returns a new GrayF32Image with the given bounds.
+func NewGrayF32Image(r image.Rectangle, opts ...ImageOption) *GrayF32Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayF32Image{
+		Pix:    o.pix(4*w*h, 4),
+		Stride: 4 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 4)
+	return p
+}
+
+func (p *GrayF32Image) metadata() Metadata {
+	return p.Meta
+}
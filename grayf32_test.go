@@ -0,0 +1,149 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestGrayF32_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    GrayF32
+		want uint32
+	}{
+		{"zero", GrayF32{Y: 0}, 0},
+		{"one", GrayF32{Y: 1}, 0xffff},
+		{"half", GrayF32{Y: 0.5}, 32768},
+		{"negative clamps", GrayF32{Y: -3}, 0},
+		{"above one clamps", GrayF32{Y: 12.5}, 0xffff},
+		{"NaN", GrayF32{Y: float32(math.NaN())}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if r != tt.want || g != tt.want || b != tt.want || a != 0xffff {
+				t.Errorf("GrayF32{%v}.RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, 65535)",
+					tt.c.Y, r, g, b, a, tt.want, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayF32Model(t *testing.T) {
+	original := GrayF32{Y: -42.5}
+	if got := GrayF32Model.Convert(original); got != original {
+		t.Errorf("GrayF32Model.Convert(%v) = %v, want unchanged", original, got)
+	}
+
+	tests := []struct {
+		name  string
+		input color.Color
+		want  float32
+	}{
+		{"white", color.White, 1},
+		{"black", color.Black, 0},
+		{"gray16 middle", color.Gray16{Y: 0x8000}, float32(0x8000) / 0xffff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GrayF32Model.Convert(tt.input).(GrayF32)
+			if !ok {
+				t.Fatalf("GrayF32Model.Convert returned %T, want GrayF32", got)
+			}
+			if math.Abs(float64(got.Y-tt.want)) > 1e-6 {
+				t.Errorf("GrayF32Model.Convert(%v) = %v, want %v", tt.input, got.Y, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGrayF32Image(t *testing.T) {
+	r := image.Rect(0, 0, 10, 5)
+	img := NewGrayF32Image(r)
+
+	if img.Bounds() != r {
+		t.Errorf("Bounds() = %v, want %v", img.Bounds(), r)
+	}
+	if img.Stride != 40 {
+		t.Errorf("Stride = %d, want 40", img.Stride)
+	}
+	if len(img.Pix) != 4*10*5 {
+		t.Errorf("len(Pix) = %d, want %d", len(img.Pix), 4*10*5)
+	}
+	if img.ColorModel() != GrayF32Model {
+		t.Errorf("ColorModel() returned %v, want GrayF32Model", img.ColorModel())
+	}
+	if !img.Opaque() {
+		t.Error("Opaque() = false, want true")
+	}
+}
+
+func TestGrayF32Image_SetAndGet(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(-2, -2, 3, 3))
+
+	values := []float32{0, 1, -1, 3.25, -1234.5, float32(math.Inf(1))}
+	for i, v := range values {
+		x, y := i%5-2, i/5-2
+		img.SetGrayF32(x, y, GrayF32{Y: v})
+		if got := img.GrayF32At(x, y); got.Y != v {
+			t.Errorf("GrayF32At(%d, %d) = %v, want %v", x, y, got.Y, v)
+		}
+	}
+
+	img.Set(2, 2, color.White)
+	if got, ok := img.At(2, 2).(GrayF32); !ok || got.Y != 1 {
+		t.Errorf("At(2, 2) = %v, want GrayF32{1}", img.At(2, 2))
+	}
+}
+
+func TestGrayF32Image_OutOfBounds(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 4, 4))
+
+	// Setting out of bounds should not panic
+	img.SetGrayF32(-1, 0, GrayF32{Y: 1})
+	img.SetGrayF32(4, 0, GrayF32{Y: 1})
+	img.Set(0, 4, color.White)
+
+	if got := img.GrayF32At(-1, 0); got.Y != 0 {
+		t.Errorf("GrayF32At(-1, 0) = %v, want 0 for out of bounds", got.Y)
+	}
+}
+
+func TestGrayF32Image_BigEndianEncoding(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 1, 1))
+	img.SetGrayF32(0, 0, GrayF32{Y: 1})
+
+	// 1.0 is 0x3F800000 in IEEE 754 single precision
+	want := []uint8{0x3f, 0x80, 0x00, 0x00}
+	for i := range want {
+		if img.Pix[i] != want[i] {
+			t.Fatalf("Pix = % x, want % x", img.Pix[:4], want)
+		}
+	}
+}
+
+func TestGrayF32Image_SubImage(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 10, 10))
+	img.SetGrayF32(5, 5, GrayF32{Y: 0.25})
+
+	sub := img.SubImage(image.Rect(4, 4, 8, 8)).(*GrayF32Image)
+	if sub.Bounds() != image.Rect(4, 4, 8, 8) {
+		t.Errorf("SubImage bounds = %v, want %v", sub.Bounds(), image.Rect(4, 4, 8, 8))
+	}
+	if got := sub.GrayF32At(5, 5); got.Y != 0.25 {
+		t.Errorf("SubImage.GrayF32At(5, 5) = %v, want 0.25", got.Y)
+	}
+
+	sub.SetGrayF32(6, 6, GrayF32{Y: -2})
+	if got := img.GrayF32At(6, 6); got.Y != -2 {
+		t.Errorf("After modifying SubImage, original GrayF32At(6, 6) = %v, want -2", got.Y)
+	}
+
+	if empty := img.SubImage(image.Rect(20, 20, 30, 30)); !empty.Bounds().Empty() {
+		t.Errorf("Non-intersecting SubImage bounds = %v, want empty rectangle", empty.Bounds())
+	}
+}
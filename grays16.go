@@ -1,11 +1,32 @@
 // Package colorext provides extended color models for use with Go's image package.
+//
+// # Concurrency
+//
+// The image types follow the conventions of the image package: any number
+// of goroutines may read an image at once, but a write, through Set, a
+// typed setter or Pix, must not run concurrently with any other access to
+// the same pixels. Sub-images share their parent's pixels, so this applies
+// across them too. Functions taking images only read their arguments and
+// return new images unless documented otherwise. To hand an image to other
+// goroutines with a guarantee that nobody writes through the handle they
+// hold, wrap it with Freeze; for several goroutines writing regions that
+// may overlap, wrap it with NewSyncImage. Types with mutable internal
+// state, such as Accumulator, RunningBackground and DeltaReader, are not
+// safe for concurrent use.
 package colorext
 
 import (
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 )
 
+// ErrOutOfBounds is returned, or used as the panic value, when pixel
+// coordinates lie outside an image's bounds. Errors reporting it wrap it with
+// the offending coordinates.
+var ErrOutOfBounds = errors.New("colorext: coordinates out of bounds")
+
 // GrayS16 represents a signed 16-bit grayscale color.
 type GrayS16 struct {
 	Y int16
@@ -30,18 +51,11 @@ func grayS16Model(c color.Color) color.Color {
 	if _, ok := c.(GrayS16); ok {
 		return c
 	}
-	r, g, b, _ := c.RGBA()
-
 	// These coefficients (the fractions 0.299, 0.587 and 0.114) are the same
 	// as those given by the JFIF specification and used by the standard library.
 	// Note that 19595 + 38470 + 7471 equals 65536.
-	// The result y will be in the range [0, 65535].
-	y := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
-
-	// Convert from unsigned [0, 65535] to signed [-32768, 32767]
-	// by subtracting 32768. Use int32 for safe intermediate calculation.
-	signedY := int32(y) - 32768
-	return GrayS16{int16(signedY)}
+	// The result is shifted from [0, 65535] to the signed range [-32768, 32767].
+	return GrayS16{Y: weightedLuma(c, 19595, 38470, 7471)}
 }
 
 // GrayS16Image is an in-memory image whose At method returns GrayS16 values.
@@ -53,6 +67,18 @@ type GrayS16Image struct {
 	Stride int
 	// Rect is the image's bounds.
 	Rect image.Rectangle
+	// StrictBounds makes At, GrayS16At, Set and SetGrayS16 panic with an
+	// error wrapping ErrOutOfBounds for coordinates outside Rect, instead of
+	// returning the zero value or doing nothing. Sub-images inherit it.
+	StrictBounds bool
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+	// CompactRatio, if positive, makes SubImage compact its result, as by
+	// Compact, when the parent's Pix buffer is more than CompactRatio times
+	// the size of the result's pixels. Small crops of a huge image then do
+	// not keep its buffer alive. Sub-images inherit it.
+	CompactRatio int
 }
 
 // ColorModel returns the GrayS16Image's color model.
@@ -73,11 +99,22 @@ func (p *GrayS16Image) At(x, y int) color.Color {
 // GrayS16At returns the GrayS16 color of the pixel at (x, y).
 func (p *GrayS16Image) GrayS16At(x, y int) GrayS16 {
 	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		p.outOfBounds(x, y)
 		return GrayS16{}
 	}
 	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+2 : i+2] // Small cap improves performance, see https://golang.org/issue/27857
 	// Read big-endian int16
-	return GrayS16{Y: int16(uint16(p.Pix[i+0])<<8 | uint16(p.Pix[i+1]))}
+	return GrayS16{Y: int16(uint16(s[0])<<8 | uint16(s[1]))}
+}
+
+// GrayS16AtChecked is like GrayS16At but returns an error wrapping
+// ErrOutOfBounds for coordinates outside the image's bounds.
+func (p *GrayS16Image) GrayS16AtChecked(x, y int) (GrayS16, error) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayS16{}, outOfBoundsError(x, y, p.Rect)
+	}
+	return p.GrayS16At(x, y), nil
 }
 
 // PixOffset returns the index of the first element of Pix that corresponds to
@@ -89,24 +126,51 @@ func (p *GrayS16Image) PixOffset(x, y int) int {
 // Set sets the pixel at (x, y) to a given color.
 func (p *GrayS16Image) Set(x, y int, c color.Color) {
 	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		p.outOfBounds(x, y)
 		return
 	}
 	i := p.PixOffset(x, y)
 	c1 := GrayS16Model.Convert(c).(GrayS16)
+	s := p.Pix[i : i+2 : i+2] // Small cap improves performance, see https://golang.org/issue/27857
 	// Write big-endian int16
-	p.Pix[i+0] = uint8(uint16(c1.Y) >> 8)
-	p.Pix[i+1] = uint8(uint16(c1.Y))
+	s[0] = uint8(uint16(c1.Y) >> 8)
+	s[1] = uint8(uint16(c1.Y))
 }
 
 // SetGrayS16 sets the pixel at (x, y) to a given GrayS16 color.
 func (p *GrayS16Image) SetGrayS16(x, y int, c GrayS16) {
 	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		p.outOfBounds(x, y)
 		return
 	}
 	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+2 : i+2] // Small cap improves performance, see https://golang.org/issue/27857
 	// Write big-endian int16
-	p.Pix[i+0] = uint8(uint16(c.Y) >> 8)
-	p.Pix[i+1] = uint8(uint16(c.Y))
+	s[0] = uint8(uint16(c.Y) >> 8)
+	s[1] = uint8(uint16(c.Y))
+}
+
+// SetGrayS16Checked is like SetGrayS16 but returns an error wrapping
+// ErrOutOfBounds for coordinates outside the image's bounds.
+func (p *GrayS16Image) SetGrayS16Checked(x, y int, c GrayS16) error {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return outOfBoundsError(x, y, p.Rect)
+	}
+	p.SetGrayS16(x, y, c)
+	return nil
+}
+
+// outOfBounds panics if p has StrictBounds set.
+func (p *GrayS16Image) outOfBounds(x, y int) {
+	if p.StrictBounds {
+		panic(outOfBoundsError(x, y, p.Rect))
+	}
+}
+
+// outOfBoundsError returns an error wrapping ErrOutOfBounds that describes
+// the coordinates and bounds.
+func outOfBoundsError(x, y int, r image.Rectangle) error {
+	return fmt.Errorf("%w: (%d, %d) not in %v", ErrOutOfBounds, x, y, r)
 }
 
 // SubImage returns an image representing the portion of the image p visible
@@ -117,14 +181,21 @@ func (p *GrayS16Image) SubImage(r image.Rectangle) image.Image {
 	// either r1 or r2 if the intersection is empty. Without explicitly checking for
 	// this, the Pix[i:] expression below can panic.
 	if r.Empty() {
-		return &GrayS16Image{}
+		return &GrayS16Image{StrictBounds: p.StrictBounds, Meta: p.Meta, CompactRatio: p.CompactRatio}
 	}
 	i := p.PixOffset(r.Min.X, r.Min.Y)
-	return &GrayS16Image{
-		Pix:    p.Pix[i:],
-		Stride: p.Stride,
-		Rect:   r,
+	sub := &GrayS16Image{
+		Pix:          p.Pix[i:],
+		Stride:       p.Stride,
+		Rect:         r,
+		StrictBounds: p.StrictBounds,
+		Meta:         p.Meta,
+		CompactRatio: p.CompactRatio,
+	}
+	if compactSub(p.Pix, p.CompactRatio, 2*r.Dx(), r.Dy()) {
+		sub.Compact()
 	}
+	return sub
 }
 
 // Opaque reports whether the image is fully opaque.
@@ -134,12 +205,19 @@ func (p *GrayS16Image) Opaque() bool {
 }
 
 // NewGrayS16Image returns a new GrayS16Image with the given bounds.
-func NewGrayS16Image(r image.Rectangle) *GrayS16Image {
+func NewGrayS16Image(r image.Rectangle, opts ...ImageOption) *GrayS16Image {
+	o := newImageOptions(opts)
 	w, h := r.Dx(), r.Dy()
-	buf := make([]uint8, 2*w*h)
-	return &GrayS16Image{
-		Pix:    buf,
+	p := &GrayS16Image{
+		Pix:    o.pix(2*w*h, 2),
 		Stride: 2 * w,
 		Rect:   r,
+		Meta:   o.meta,
 	}
+	o.fillPix(p, p.Pix, p.Stride, 2)
+	return p
+}
+
+func (p *GrayS16Image) metadata() Metadata {
+	return p.Meta
 }
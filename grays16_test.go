@@ -1,6 +1,7 @@
 package colorext
 
 import (
+	"errors"
 	"image"
 	"image/color"
 	"testing"
@@ -468,3 +469,66 @@ func TestGrayS16Image_NegativeValueEncoding(t *testing.T) {
 		t.Errorf("GrayS16At(0, 0) = GrayS16{%d}, want GrayS16{-1}", got.Y)
 	}
 }
+
+func TestGrayS16Image_Checked(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 2, 2))
+	if err := img.SetGrayS16Checked(1, 1, GrayS16{Y: -7}); err != nil {
+		t.Fatalf("SetGrayS16Checked in bounds: %v", err)
+	}
+	if c, err := img.GrayS16AtChecked(1, 1); err != nil || c.Y != -7 {
+		t.Errorf("GrayS16AtChecked(1, 1) = %v, %v, want {-7}, nil", c, err)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+	}{
+		{"right", 2, 0},
+		{"below", 0, 2},
+		{"negative", -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := img.GrayS16AtChecked(tt.x, tt.y); !errors.Is(err, ErrOutOfBounds) {
+				t.Errorf("GrayS16AtChecked(%d, %d) error = %v, want ErrOutOfBounds", tt.x, tt.y, err)
+			}
+			if err := img.SetGrayS16Checked(tt.x, tt.y, GrayS16{}); !errors.Is(err, ErrOutOfBounds) {
+				t.Errorf("SetGrayS16Checked(%d, %d) error = %v, want ErrOutOfBounds", tt.x, tt.y, err)
+			}
+		})
+	}
+}
+
+func TestGrayS16Image_StrictBounds(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 2, 2))
+	img.StrictBounds = true
+
+	// In bounds access is unaffected.
+	img.SetGrayS16(0, 1, GrayS16{Y: 5})
+	if got := img.GrayS16At(0, 1); got.Y != 5 {
+		t.Errorf("GrayS16At(0, 1) = %d, want 5", got.Y)
+	}
+
+	sub := img.SubImage(image.Rect(1, 1, 2, 2)).(*GrayS16Image)
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"At", func() { img.At(2, 0) }},
+		{"GrayS16At", func() { img.GrayS16At(0, -1) }},
+		{"Set", func() { img.Set(5, 5, color.White) }},
+		{"SetGrayS16", func() { img.SetGrayS16(-1, 0, GrayS16{}) }},
+		{"SubImage inherits", func() { sub.GrayS16At(0, 0) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrOutOfBounds) {
+					t.Errorf("panic value = %v, want an error wrapping ErrOutOfBounds", err)
+				}
+			}()
+			tt.f()
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// GrayS64 represents a signed 64-bit grayscale value, such as an accumulated
+// sum of GrayS16 pixels.
+type GrayS64 struct {
+	Y int64
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayS64 color.
+// This implements the color.Color interface.
+// Y is saturated to the int16 range and then displayed as a GrayS16 would be,
+// so values within that range display the same as the equivalent GrayS16.
+func (c GrayS64) RGBA() (r, g, b, a uint32) {
+	return GrayS16{Y: int16(max(-32768, min(c.Y, 32767)))}.RGBA()
+}
+
+// GrayS64Model is the color model for signed 64-bit grayscale colors.
+// GrayS16 values are widened exactly; other colors are converted through
+// GrayS16Model.
+var GrayS64Model color.Model = color.ModelFunc(grayS64Model)
+
+// grayS64Model converts any color.Color to a GrayS64.
+func grayS64Model(c color.Color) color.Color {
+	if _, ok := c.(GrayS64); ok {
+		return c
+	}
+	return GrayS64{Y: int64(GrayS16Model.Convert(c).(GrayS16).Y)}
+}
+
+// GrayS64Image is an in-memory image whose At method returns GrayS64 values.
+type GrayS64Image struct {
+	// Pix holds the image's pixels, as signed 64-bit gray values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the GrayS64Image's color model.
+func (p *GrayS64Image) ColorModel() color.Model {
+	return GrayS64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayS64Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayS64Image) At(x, y int) color.Color {
+	return p.GrayS64At(x, y)
+}
+
+// GrayS64At returns the GrayS64 color of the pixel at (x, y).
+func (p *GrayS64Image) GrayS64At(x, y int) GrayS64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayS64{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	var v uint64
+	for _, b := range s {
+		v = v<<8 | uint64(b)
+	}
+	return GrayS64{Y: int64(v)}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayS64Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*8
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayS64Image) Set(x, y int, c color.Color) {
+	p.SetGrayS64(x, y, GrayS64Model.Convert(c).(GrayS64))
+}
+
+// SetGrayS64 sets the pixel at (x, y) to a given GrayS64 color.
+func (p *GrayS64Image) SetGrayS64(x, y int, c GrayS64) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	for k := range s {
+		s[k] = uint8(uint64(c.Y) >> (56 - 8*k))
+	}
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayS64Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayS64Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &GrayS64Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayS64Image is always fully opaque since the GrayS64 color model has no transparency.
+func (p *GrayS64Image) Opaque() bool {
+	return true
+}
+
+// NewGrayS64Image returns a new GrayS64Image with the given bounds.
+func NewGrayS64Image(r image.Rectangle, opts ...ImageOption) *GrayS64Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayS64Image{
+		Pix:    o.pix(8*w*h, 8),
+		Stride: 8 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 8)
+	return p
+}
+
+func (p *GrayS64Image) metadata() Metadata {
+	return p.Meta
+}
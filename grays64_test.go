@@ -0,0 +1,86 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGrayS64_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    GrayS64
+		want uint32
+	}{
+		{"zero", GrayS64{Y: 0}, 32768},
+		{"in range", GrayS64{Y: -16384}, 16384},
+		{"int16 max", GrayS64{Y: 32767}, 0xffff},
+		{"saturates high", GrayS64{Y: 1 << 40}, 0xffff},
+		{"saturates low", GrayS64{Y: -1 << 40}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if r != tt.want || g != tt.want || b != tt.want || a != 0xffff {
+				t.Errorf("GrayS64{%d}.RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, 65535)", tt.c.Y, r, g, b, a, tt.want, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayS64Model(t *testing.T) {
+	tests := []struct {
+		name string
+		in   color.Color
+		want GrayS64
+	}{
+		{"passthrough", GrayS64{Y: -1 << 50}, GrayS64{Y: -1 << 50}},
+		{"from GrayS16", GrayS16{Y: -1234}, GrayS64{Y: -1234}},
+		{"from white", color.White, GrayS64{Y: 32767}},
+		{"from black", color.Black, GrayS64{Y: -32768}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GrayS64Model.Convert(tt.in); got != tt.want {
+				t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayS64Image(t *testing.T) {
+	r := image.Rect(-2, 0, 1, 2)
+	img := NewGrayS64Image(r)
+	if img.Stride != 24 || len(img.Pix) != 48 {
+		t.Fatalf("Stride, len(Pix) = %d, %d, want 24, 48", img.Stride, len(img.Pix))
+	}
+	if img.Bounds() != r || img.ColorModel() != GrayS64Model || !img.Opaque() {
+		t.Error("unexpected Bounds, ColorModel or Opaque")
+	}
+
+	img.SetGrayS64(-1, 1, GrayS64{Y: -2})
+	if i := img.PixOffset(-1, 1); img.Pix[i] != 0xff || img.Pix[i+7] != 0xfe {
+		t.Errorf("Pix not big-endian two's complement: % x", img.Pix[i:i+8])
+	}
+	if got := img.GrayS64At(-1, 1); got.Y != -2 {
+		t.Errorf("GrayS64At = %d, want -2", got.Y)
+	}
+	img.Set(0, 0, GrayS16{Y: 100})
+	if got := img.At(0, 0); got != (GrayS64{Y: 100}) {
+		t.Errorf("At after Set = %v, want {100}", got)
+	}
+
+	img.SetGrayS64(1, 0, GrayS64{Y: 9})
+	if got := img.GrayS64At(1, 0); got.Y != 0 {
+		t.Errorf("out of bounds GrayS64At = %d, want 0", got.Y)
+	}
+
+	sub := img.SubImage(image.Rect(-1, 1, 1, 2)).(*GrayS64Image)
+	sub.SetGrayS64(0, 1, GrayS64{Y: 1 << 40})
+	if got := img.GrayS64At(0, 1); got.Y != 1<<40 {
+		t.Errorf("SubImage does not share pixels: got %d, want %d", got.Y, int64(1<<40))
+	}
+	if empty := img.SubImage(image.Rect(5, 5, 6, 6)); !empty.Bounds().Empty() {
+		t.Errorf("disjoint SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
@@ -0,0 +1,288 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// GrayU32 represents an unsigned 32-bit grayscale color, such as a photon or
+// event count.
+type GrayU32 struct {
+	Y uint32
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayU32 color.
+// This implements the color.Color interface.
+// Y is used directly as a 16-bit intensity, saturating at 65535, so values
+// up to 65535 display the same as the equivalent color.Gray16.
+func (c GrayU32) RGBA() (r, g, b, a uint32) {
+	y := min(c.Y, 0xffff)
+	return y, y, y, 0xffff
+}
+
+// GrayU32Model is the color model for unsigned 32-bit grayscale colors.
+var GrayU32Model color.Model = color.ModelFunc(grayU32Model)
+
+// grayU32Model converts any color.Color to a GrayU32. GrayU64 values are
+// saturated rather than scaled; other colors are converted to their 16-bit
+// luminance.
+func grayU32Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case GrayU32:
+		return c
+	case GrayU64:
+		return GrayU32{Y: uint32(min(c.Y, 0xffffffff))}
+	}
+	return GrayU32{Y: luma16(c)}
+}
+
+// GrayU64 represents an unsigned 64-bit grayscale color, such as an
+// accumulated count.
+type GrayU64 struct {
+	Y uint64
+}
+
+// RGBA returns the red, green, blue and alpha components of the GrayU64 color.
+// This implements the color.Color interface.
+// Y is used directly as a 16-bit intensity, saturating at 65535.
+func (c GrayU64) RGBA() (r, g, b, a uint32) {
+	y := uint32(min(c.Y, 0xffff))
+	return y, y, y, 0xffff
+}
+
+// GrayU64Model is the color model for unsigned 64-bit grayscale colors.
+var GrayU64Model color.Model = color.ModelFunc(grayU64Model)
+
+// grayU64Model converts any color.Color to a GrayU64. GrayU32 values are
+// widened exactly; other colors are converted to their 16-bit luminance.
+func grayU64Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case GrayU64:
+		return c
+	case GrayU32:
+		return GrayU64{Y: uint64(c.Y)}
+	}
+	return GrayU64{Y: uint64(luma16(c))}
+}
+
+// luma16 returns the 16-bit luminance of c, using the same weights as
+// GrayS16Model.
+func luma16(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+}
+
+// GrayU32Image is an in-memory image whose At method returns GrayU32 values.
+type GrayU32Image struct {
+	// Pix holds the image's pixels, as unsigned 32-bit gray values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the GrayU32Image's color model.
+func (p *GrayU32Image) ColorModel() color.Model {
+	return GrayU32Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayU32Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayU32Image) At(x, y int) color.Color {
+	return p.GrayU32At(x, y)
+}
+
+// GrayU32At returns the GrayU32 color of the pixel at (x, y).
+func (p *GrayU32Image) GrayU32At(x, y int) GrayU32 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayU32{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	return GrayU32{Y: uint32(s[0])<<24 | uint32(s[1])<<16 | uint32(s[2])<<8 | uint32(s[3])}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayU32Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayU32Image) Set(x, y int, c color.Color) {
+	p.SetGrayU32(x, y, GrayU32Model.Convert(c).(GrayU32))
+}
+
+// SetGrayU32 sets the pixel at (x, y) to a given GrayU32 color.
+func (p *GrayU32Image) SetGrayU32(x, y int, c GrayU32) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	s[0] = uint8(c.Y >> 24)
+	s[1] = uint8(c.Y >> 16)
+	s[2] = uint8(c.Y >> 8)
+	s[3] = uint8(c.Y)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayU32Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayU32Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &GrayU32Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayU32Image is always fully opaque since the GrayU32 color model has no transparency.
+func (p *GrayU32Image) Opaque() bool {
+	return true
+}
+
+// NewGrayU32Image returns a new GrayU32Image with the given bounds.
+func NewGrayU32Image(r image.Rectangle, opts ...ImageOption) *GrayU32Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayU32Image{
+		Pix:    o.pix(4*w*h, 4),
+		Stride: 4 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 4)
+	return p
+}
+
+func (p *GrayU32Image) metadata() Metadata {
+	return p.Meta
+}
+
+// GrayU64Image is an in-memory image whose At method returns GrayU64 values.
+type GrayU64Image struct {
+	// Pix holds the image's pixels, as unsigned 64-bit gray values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*8].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the GrayU64Image's color model.
+func (p *GrayU64Image) ColorModel() color.Model {
+	return GrayU64Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *GrayU64Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *GrayU64Image) At(x, y int) color.Color {
+	return p.GrayU64At(x, y)
+}
+
+// GrayU64At returns the GrayU64 color of the pixel at (x, y).
+func (p *GrayU64Image) GrayU64At(x, y int) GrayU64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return GrayU64{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	var v uint64
+	for _, b := range s {
+		v = v<<8 | uint64(b)
+	}
+	return GrayU64{Y: v}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *GrayU64Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*8
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *GrayU64Image) Set(x, y int, c color.Color) {
+	p.SetGrayU64(x, y, GrayU64Model.Convert(c).(GrayU64))
+}
+
+// SetGrayU64 sets the pixel at (x, y) to a given GrayU64 color.
+func (p *GrayU64Image) SetGrayU64(x, y int, c GrayU64) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+8 : i+8]
+	for k := range s {
+		s[k] = uint8(c.Y >> (56 - 8*k))
+	}
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *GrayU64Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &GrayU64Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &GrayU64Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// GrayU64Image is always fully opaque since the GrayU64 color model has no transparency.
+func (p *GrayU64Image) Opaque() bool {
+	return true
+}
+
+// NewGrayU64Image returns a new GrayU64Image with the given bounds.
+func NewGrayU64Image(r image.Rectangle, opts ...ImageOption) *GrayU64Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &GrayU64Image{
+		Pix:    o.pix(8*w*h, 8),
+		Stride: 8 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 8)
+	return p
+}
+
+func (p *GrayU64Image) metadata() Metadata {
+	return p.Meta
+}
@@ -0,0 +1,136 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGrayU_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want uint32
+	}{
+		{"u32 zero", GrayU32{Y: 0}, 0},
+		{"u32 in range", GrayU32{Y: 1234}, 1234},
+		{"u32 at 16-bit max", GrayU32{Y: 0xffff}, 0xffff},
+		{"u32 saturates", GrayU32{Y: 1 << 20}, 0xffff},
+		{"u32 max", GrayU32{Y: 0xffffffff}, 0xffff},
+		{"u64 in range", GrayU64{Y: 40000}, 40000},
+		{"u64 saturates", GrayU64{Y: 1 << 40}, 0xffff},
+		{"u64 max", GrayU64{Y: ^uint64(0)}, 0xffff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if r != tt.want || g != tt.want || b != tt.want || a != 0xffff {
+				t.Errorf("RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, 65535)", r, g, b, a, tt.want, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayUModels(t *testing.T) {
+	tests := []struct {
+		name  string
+		model color.Model
+		in    color.Color
+		want  color.Color
+	}{
+		{"u32 passthrough", GrayU32Model, GrayU32{Y: 1 << 30}, GrayU32{Y: 1 << 30}},
+		{"u32 from u64 saturates", GrayU32Model, GrayU64{Y: 1 << 40}, GrayU32{Y: 0xffffffff}},
+		{"u32 from u64", GrayU32Model, GrayU64{Y: 70000}, GrayU32{Y: 70000}},
+		{"u32 from gray16", GrayU32Model, color.Gray16{Y: 0x1234}, GrayU32{Y: 0x1234}},
+		{"u32 from white", GrayU32Model, color.White, GrayU32{Y: 0xffff}},
+		{"u64 passthrough", GrayU64Model, GrayU64{Y: 1 << 50}, GrayU64{Y: 1 << 50}},
+		{"u64 from u32", GrayU64Model, GrayU32{Y: 0xffffffff}, GrayU64{Y: 0xffffffff}},
+		{"u64 from gray16", GrayU64Model, color.Gray16{Y: 500}, GrayU64{Y: 500}},
+		{"u64 from black", GrayU64Model, color.Black, GrayU64{Y: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Convert(tt.in); got != tt.want {
+				t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayU32Image(t *testing.T) {
+	r := image.Rect(-1, 2, 3, 5)
+	img := NewGrayU32Image(r)
+	if img.Stride != 16 || len(img.Pix) != 48 {
+		t.Fatalf("Stride, len(Pix) = %d, %d, want 16, 48", img.Stride, len(img.Pix))
+	}
+	if img.Bounds() != r || img.ColorModel() != GrayU32Model || !img.Opaque() {
+		t.Error("unexpected Bounds, ColorModel or Opaque")
+	}
+
+	img.SetGrayU32(0, 3, GrayU32{Y: 0x01020304})
+	if i := img.PixOffset(0, 3); img.Pix[i] != 1 || img.Pix[i+3] != 4 {
+		t.Errorf("Pix not big-endian: % x", img.Pix[i:i+4])
+	}
+	if got := img.GrayU32At(0, 3); got.Y != 0x01020304 {
+		t.Errorf("GrayU32At = %#x, want 0x01020304", got.Y)
+	}
+	img.Set(2, 4, color.Gray16{Y: 777})
+	if got := img.At(2, 4); got != (GrayU32{Y: 777}) {
+		t.Errorf("At after Set = %v, want {777}", got)
+	}
+
+	// Out of bounds reads are zero and writes are ignored.
+	img.SetGrayU32(5, 5, GrayU32{Y: 9})
+	if got := img.GrayU32At(5, 5); got.Y != 0 {
+		t.Errorf("out of bounds GrayU32At = %d, want 0", got.Y)
+	}
+
+	sub := img.SubImage(image.Rect(0, 3, 2, 4)).(*GrayU32Image)
+	if got := sub.GrayU32At(0, 3); got.Y != 0x01020304 {
+		t.Errorf("SubImage GrayU32At = %#x, want 0x01020304", got.Y)
+	}
+	sub.SetGrayU32(1, 3, GrayU32{Y: 42})
+	if got := img.GrayU32At(1, 3); got.Y != 42 {
+		t.Errorf("SubImage does not share pixels: got %d, want 42", got.Y)
+	}
+	if empty := img.SubImage(image.Rect(10, 10, 12, 12)); !empty.Bounds().Empty() {
+		t.Errorf("disjoint SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
+
+func TestGrayU64Image(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	img := NewGrayU64Image(r)
+	if img.Stride != 24 || len(img.Pix) != 48 {
+		t.Fatalf("Stride, len(Pix) = %d, %d, want 24, 48", img.Stride, len(img.Pix))
+	}
+	if img.Bounds() != r || img.ColorModel() != GrayU64Model || !img.Opaque() {
+		t.Error("unexpected Bounds, ColorModel or Opaque")
+	}
+
+	const v = 0x0102030405060708
+	img.SetGrayU64(1, 1, GrayU64{Y: v})
+	if i := img.PixOffset(1, 1); img.Pix[i] != 1 || img.Pix[i+7] != 8 {
+		t.Errorf("Pix not big-endian: % x", img.Pix[i:i+8])
+	}
+	if got := img.GrayU64At(1, 1); got.Y != v {
+		t.Errorf("GrayU64At = %#x, want %#x", got.Y, uint64(v))
+	}
+	img.Set(0, 0, GrayU32{Y: 0xffffffff})
+	if got := img.At(0, 0); got != (GrayU64{Y: 0xffffffff}) {
+		t.Errorf("At after Set = %v, want {4294967295}", got)
+	}
+
+	img.SetGrayU64(-1, 0, GrayU64{Y: 9})
+	if got := img.GrayU64At(-1, 0); got.Y != 0 {
+		t.Errorf("out of bounds GrayU64At = %d, want 0", got.Y)
+	}
+
+	sub := img.SubImage(image.Rect(1, 1, 3, 2)).(*GrayU64Image)
+	if got := sub.GrayU64At(1, 1); got.Y != v {
+		t.Errorf("SubImage GrayU64At = %#x, want %#x", got.Y, uint64(v))
+	}
+	if empty := img.SubImage(image.Rect(5, 5, 6, 6)); !empty.Bounds().Empty() {
+		t.Errorf("disjoint SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
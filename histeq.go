@@ -0,0 +1,199 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// CLAHEOptions controls CLAHE. The zero value uses an 8×8 grid of tiles and
+// a clip limit of 2.
+type CLAHEOptions struct {
+	// TilesX and TilesY are the number of tiles across and down the image.
+	// Values below 1 mean 8. The grid is reduced for images smaller than it.
+	TilesX, TilesY int
+	// ClipLimit caps each histogram bin at ClipLimit times the average bin
+	// count of a tile, limiting how much contrast is amplified. Histograms
+	// span the range of values present in the image. A negative value
+	// disables clipping, giving plain adaptive equalization; zero means 2.
+	ClipLimit float64
+}
+
+// EqualizeS16 returns src with its histogram equalized over the full 16-bit
+// range, so that its values spread evenly over [-32768, 32767]. Each value is
+// mapped by its cumulative frequency; the lowest value present maps to
+// -32768 and the highest to 32767. A constant image maps to -32768.
+func EqualizeS16(src *GrayS16Image) *GrayS16Image {
+	dst := NewGrayS16Image(src.Rect)
+	lut := equalizeLUT(src)
+	mapPixels(src, func(x, y int, v int16) { dst.SetGrayS16(x, y, GrayS16{Y: signed16(lut[uint16(v)^0x8000])}) })
+	return dst
+}
+
+// EqualizeS16ToGray is like EqualizeS16 but returns 8-bit output for
+// display.
+func EqualizeS16ToGray(src *GrayS16Image) *image.Gray {
+	dst := image.NewGray(src.Rect)
+	lut := equalizeLUT(src)
+	mapPixels(src, func(x, y int, v int16) { dst.Pix[dst.PixOffset(x, y)] = unit8(float64(lut[uint16(v)^0x8000])) })
+	return dst
+}
+
+// equalizeLUT returns the equalized value in [0, 1] of each 16-bit value,
+// indexed by its offset-binary representation.
+func equalizeLUT(src *GrayS16Image) []float32 {
+	hist := make([]int, 1<<16)
+	mapPixels(src, func(_, _ int, v int16) { hist[uint16(v)^0x8000]++ })
+	lut := make([]float32, 1<<16)
+	n, cdfMin, cdf := src.Rect.Dx()*src.Rect.Dy(), 0, 0
+	for i, c := range hist {
+		cdf += c
+		if cdfMin == 0 {
+			cdfMin = cdf
+		}
+		if n > cdfMin {
+			lut[i] = float32(float64(cdf-cdfMin) / float64(n-cdfMin))
+		}
+	}
+	return lut
+}
+
+// CLAHE returns src enhanced by contrast-limited adaptive histogram
+// equalization. The image is divided into a grid of tiles; each tile's
+// clipped histogram is equalized and pixels interpolate bilinearly between
+// the mappings of the four nearest tile centers, which avoids visible tile
+// edges. Output spans [-32768, 32767]. opts may be nil.
+func CLAHE(src *GrayS16Image, opts *CLAHEOptions) *GrayS16Image {
+	dst := NewGrayS16Image(src.Rect)
+	clahe(src, opts, func(x, y int, t float64) { dst.SetGrayS16(x, y, GrayS16{Y: signed16(float32(t))}) })
+	return dst
+}
+
+// CLAHEToGray is like CLAHE but returns 8-bit output for display.
+func CLAHEToGray(src *GrayS16Image, opts *CLAHEOptions) *image.Gray {
+	dst := image.NewGray(src.Rect)
+	clahe(src, opts, func(x, y int, t float64) { dst.Pix[dst.PixOffset(x, y)] = unit8(t) })
+	return dst
+}
+
+// mapPixels calls f for each pixel of src.
+func mapPixels(src *GrayS16Image, f func(x, y int, v int16)) {
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			f(x, y, src.GrayS16At(x, y).Y)
+		}
+	}
+}
+
+// signed16 maps t in [0, 1] onto [-32768, 32767].
+func signed16(t float32) int16 {
+	return int16(int32(math.Round(float64(t)*0xffff)) - 0x8000)
+}
+
+// clahe calls set with the equalized value in [0, 1] of each pixel of src.
+func clahe(src *GrayS16Image, opts *CLAHEOptions, set func(x, y int, t float64)) {
+	r := src.Rect
+	w, h := r.Dx(), r.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	var o CLAHEOptions
+	if opts != nil {
+		o = *opts
+	}
+	tx, ty := o.TilesX, o.TilesY
+	if tx < 1 {
+		tx = 8
+	}
+	if ty < 1 {
+		ty = 8
+	}
+	tx, ty = min(tx, w), min(ty, h)
+	clip := o.ClipLimit
+	if clip == 0 {
+		clip = 2
+	}
+
+	lo, hi := int16(math.MaxInt16), int16(math.MinInt16)
+	mapPixels(src, func(_, _ int, v int16) { lo, hi = min(lo, v), max(hi, v) })
+	nb := int(hi) - int(lo) + 1
+
+	// Build the mapping of each tile.
+	luts := make([][]float32, tx*ty)
+	hist := make([]int, nb)
+	for j := 0; j < ty; j++ {
+		for i := 0; i < tx; i++ {
+			clear(hist)
+			x0, x1 := r.Min.X+i*w/tx, r.Min.X+(i+1)*w/tx
+			y0, y1 := r.Min.Y+j*h/ty, r.Min.Y+(j+1)*h/ty
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					hist[int(src.GrayS16At(x, y).Y)-int(lo)]++
+				}
+			}
+			area := (x1 - x0) * (y1 - y0)
+			if clip > 0 {
+				clipHistogram(hist, max(int(clip*float64(area)/float64(nb)), 1))
+			}
+			lut := make([]float32, nb)
+			cdf := 0
+			for k, c := range hist {
+				cdf += c
+				lut[k] = float32(float64(cdf) / float64(area))
+			}
+			luts[j*tx+i] = lut
+		}
+	}
+
+	// Interpolate between the mappings of the nearest tile centers.
+	tileW, tileH := float64(w)/float64(tx), float64(h)/float64(ty)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		j0, j1, fy := tileNeighbors(float64(y-r.Min.Y)+0.5, tileH, ty)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i0, i1, fx := tileNeighbors(float64(x-r.Min.X)+0.5, tileW, tx)
+			k := int(src.GrayS16At(x, y).Y) - int(lo)
+			top := float64(luts[j0*tx+i0][k])*(1-fx) + float64(luts[j0*tx+i1][k])*fx
+			bot := float64(luts[j1*tx+i0][k])*(1-fx) + float64(luts[j1*tx+i1][k])*fx
+			set(x, y, top*(1-fy)+bot*fy)
+		}
+	}
+}
+
+// tileNeighbors returns the indices of the tiles whose centers surround
+// position p along an axis of n tiles of the given size, and the weight of
+// the second.
+func tileNeighbors(p, size float64, n int) (a, b int, f float64) {
+	c := p/size - 0.5
+	a = int(math.Floor(c))
+	f = c - float64(a)
+	if a < 0 {
+		return 0, 0, 0
+	}
+	if a >= n-1 {
+		return n - 1, n - 1, 0
+	}
+	return a, a + 1, f
+}
+
+// clipHistogram caps each bin of hist at limit and redistributes the excess
+// evenly over all bins.
+func clipHistogram(hist []int, limit int) {
+	excess := 0
+	for k, c := range hist {
+		if c > limit {
+			excess += c - limit
+			hist[k] = limit
+		}
+	}
+	add, rem := excess/len(hist), excess%len(hist)
+	for k := range hist {
+		hist[k] += add
+	}
+	// Spread the remainder evenly across the range.
+	if rem > 0 {
+		step := len(hist) / rem
+		for k := 0; rem > 0; k += step {
+			hist[k]++
+			rem--
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package colorext
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestEqualizeS16(t *testing.T) {
+	// Values clustered near zero spread over the full range in order.
+	img := NewGrayS16Image(image.Rect(0, 0, 4, 1))
+	for x, v := range []int16{-3, 0, 0, 10} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	got := EqualizeS16(img)
+	want := []int16{-32768, 10922, 10922, 32767}
+	for x, v := range want {
+		if g := got.GrayS16At(x, 0).Y; g != v {
+			t.Errorf("EqualizeS16 pixel %d = %d, want %d", x, g, v)
+		}
+	}
+	gray := EqualizeS16ToGray(img)
+	if g := gray.Pix; g[0] != 0 || g[1] != 170 || g[3] != 255 {
+		t.Errorf("EqualizeS16ToGray = %v", g)
+	}
+
+	flat := NewGrayS16Image(image.Rect(0, 0, 3, 3))
+	if g := EqualizeS16(flat).GrayS16At(1, 1).Y; g != -32768 {
+		t.Errorf("flat image equalized to %d, want -32768", g)
+	}
+	EqualizeS16(NewGrayS16Image(image.Rectangle{}))
+}
+
+func TestCLAHE(t *testing.T) {
+	// Left half dark and noisy, right half bright and noisy: global
+	// equalization keeps each half's detail compressed, while CLAHE
+	// stretches each half locally.
+	r := image.Rect(0, 0, 64, 32)
+	img := NewGrayS16Image(r)
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			base := -20000
+			if x >= 32 {
+				base = 20000
+			}
+			img.SetGrayS16(x, y, GrayS16{Y: int16(base + rng.Intn(100))})
+		}
+	}
+	spread := func(m *GrayS16Image, x0, x1 int) int {
+		lo, hi := int16(32767), int16(-32768)
+		for y := 8; y < 24; y++ {
+			for x := x0; x < x1; x++ {
+				v := m.GrayS16At(x, y).Y
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+		return int(hi) - int(lo)
+	}
+	global := EqualizeS16(img)
+	local := CLAHE(img, &CLAHEOptions{TilesX: 4, TilesY: 2, ClipLimit: -1})
+	if g, l := spread(global, 4, 12), spread(local, 4, 12); l <= g {
+		t.Errorf("dark region spread: CLAHE %d, global %d; want CLAHE larger", l, g)
+	}
+
+	// Clipping limits the contrast gain.
+	clipped := CLAHE(img, &CLAHEOptions{TilesX: 4, TilesY: 2, ClipLimit: 1.5})
+	if c, l := spread(clipped, 4, 12), spread(local, 4, 12); c >= l {
+		t.Errorf("clipped spread %d, unclipped %d; want clipped smaller", c, l)
+	}
+
+	// Default options, tiny images and 8-bit output.
+	if g := CLAHEToGray(img, nil); g.Rect != r {
+		t.Errorf("CLAHEToGray bounds = %v", g.Rect)
+	}
+	tiny := NewGrayS16Image(image.Rect(5, 5, 7, 6))
+	tiny.SetGrayS16(6, 5, GrayS16{Y: 100})
+	// Each pixel is its own tile, and the top of its own histogram.
+	if g := CLAHE(tiny, nil); g.Rect != tiny.Rect || g.GrayS16At(5, 5).Y != 32767 {
+		t.Errorf("tiny image = %v, %d", g.Rect, g.GrayS16At(5, 5).Y)
+	}
+	CLAHE(NewGrayS16Image(image.Rectangle{}), nil)
+}
+
+func TestClipHistogram(t *testing.T) {
+	hist := []int{10, 0, 0, 1}
+	clipHistogram(hist, 3)
+	// 7 excess: 1 to each bin, then the remaining 3 spread out.
+	want := []int{5, 2, 2, 2}
+	for k := range want {
+		if hist[k] != want[k] {
+			t.Fatalf("clipHistogram = %v, want %v", hist, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+)
+
+// HSV represents a color by hue, saturation and value. H is in degrees in
+// [0, 360); S and V are in [0, 1].
+type HSV struct {
+	H, S, V float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the HSV color.
+// This implements the color.Color interface.
+func (c HSV) RGBA() (r, g, b, a uint32) {
+	chroma := clamp01(c.V) * clamp01(c.S)
+	m := clamp01(c.V) - chroma
+	fr, fg, fb := hueToRGB(c.H, chroma)
+	return unit16(fr + m), unit16(fg + m), unit16(fb + m), 0xffff
+}
+
+// RotateHue returns c with its hue rotated by degrees.
+func (c HSV) RotateHue(degrees float64) HSV {
+	c.H = normalizeHue(c.H + degrees)
+	return c
+}
+
+// HSVModel is the color model for HSV colors. As with color.YCbCrModel, alpha
+// is discarded.
+var HSVModel color.Model = color.ModelFunc(hsvModel)
+
+// hsvModel converts any color.Color to an HSV.
+func hsvModel(c color.Color) color.Color {
+	if c, ok := c.(HSV); ok {
+		return c
+	}
+	r, g, b := rgbUnit(c)
+	hi, lo := max(r, g, b), min(r, g, b)
+	s := 0.0
+	if hi > 0 {
+		s = (hi - lo) / hi
+	}
+	return HSV{H: rgbHue(r, g, b, hi, lo), S: s, V: hi}
+}
+
+// HSL represents a color by hue, saturation and lightness. H is in degrees in
+// [0, 360); S and L are in [0, 1].
+type HSL struct {
+	H, S, L float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the HSL color.
+// This implements the color.Color interface.
+func (c HSL) RGBA() (r, g, b, a uint32) {
+	l := clamp01(c.L)
+	chroma := (1 - math.Abs(2*l-1)) * clamp01(c.S)
+	m := l - chroma/2
+	fr, fg, fb := hueToRGB(c.H, chroma)
+	return unit16(fr + m), unit16(fg + m), unit16(fb + m), 0xffff
+}
+
+// RotateHue returns c with its hue rotated by degrees.
+func (c HSL) RotateHue(degrees float64) HSL {
+	c.H = normalizeHue(c.H + degrees)
+	return c
+}
+
+// HSLModel is the color model for HSL colors. As with color.YCbCrModel, alpha
+// is discarded.
+var HSLModel color.Model = color.ModelFunc(hslModel)
+
+// hslModel converts any color.Color to an HSL.
+func hslModel(c color.Color) color.Color {
+	if c, ok := c.(HSL); ok {
+		return c
+	}
+	r, g, b := rgbUnit(c)
+	hi, lo := max(r, g, b), min(r, g, b)
+	l := (hi + lo) / 2
+	s := 0.0
+	if d := hi - lo; d > 0 {
+		s = d / (1 - math.Abs(2*l-1))
+	}
+	return HSL{H: rgbHue(r, g, b, hi, lo), S: s, L: l}
+}
+
+// RotateHue returns c with its hue rotated by degrees, preserving its
+// lightness, saturation and alpha. The rotation is performed in HSL space.
+func RotateHue(c color.Color, degrees float64) color.NRGBA64 {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	hsl := HSLModel.Convert(color.RGBA64{R: n.R, G: n.G, B: n.B, A: 0xffff}).(HSL)
+	r, g, b, _ := hsl.RotateHue(degrees).RGBA()
+	return color.NRGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: n.A}
+}
+
+// rgbUnit returns the red, green and blue components of c in [0, 1].
+func rgbUnit(c color.Color) (r, g, b float64) {
+	r16, g16, b16, _ := c.RGBA()
+	return float64(r16) / 0xffff, float64(g16) / 0xffff, float64(b16) / 0xffff
+}
+
+// rgbHue returns the hue in degrees of an RGB triple with the given maximum
+// and minimum components. Achromatic colors have hue 0.
+func rgbHue(r, g, b, hi, lo float64) float64 {
+	d := hi - lo
+	if d == 0 {
+		return 0
+	}
+	var h float64
+	switch hi {
+	case r:
+		h = (g - b) / d
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return normalizeHue(h * 60)
+}
+
+// hueToRGB returns the RGB components of a color with the given hue and
+// chroma and a minimum component of zero.
+func hueToRGB(h, chroma float64) (r, g, b float64) {
+	hp := normalizeHue(h) / 60
+	x := chroma * (1 - math.Abs(math.Mod(hp, 2)-1))
+	switch int(hp) {
+	case 0:
+		return chroma, x, 0
+	case 1:
+		return x, chroma, 0
+	case 2:
+		return 0, chroma, x
+	case 3:
+		return 0, x, chroma
+	case 4:
+		return x, 0, chroma
+	default:
+		return chroma, 0, x
+	}
+}
+
+// normalizeHue wraps h into [0, 360).
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	// Adding 360 to a tiny negative value can round up to exactly 360.
+	if h >= 360 {
+		h = 0
+	}
+	return h
+}
@@ -0,0 +1,91 @@
+package colorext
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestHSVModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  HSV
+	}{
+		{"black", color.Black, HSV{0, 0, 0}},
+		{"white", color.White, HSV{0, 0, 1}},
+		{"red", color.RGBA{255, 0, 0, 255}, HSV{0, 1, 1}},
+		{"green", color.RGBA{0, 255, 0, 255}, HSV{120, 1, 1}},
+		{"blue", color.RGBA{0, 0, 255, 255}, HSV{240, 1, 1}},
+		{"magenta", color.RGBA{255, 0, 255, 255}, HSV{300, 1, 1}},
+		{"dark cyan", color.RGBA64{0, 0x8000, 0x8000, 0xffff}, HSV{180, 1, float64(0x8000) / 0xffff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HSVModel.Convert(tt.input).(HSV)
+			if !approxEqual(got.H, tt.want.H, 1e-9) || !approxEqual(got.S, tt.want.S, 1e-9) || !approxEqual(got.V, tt.want.V, 1e-9) {
+				t.Errorf("HSVModel.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSLModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  HSL
+	}{
+		{"black", color.Black, HSL{0, 0, 0}},
+		{"white", color.White, HSL{0, 0, 1}},
+		{"red", color.RGBA{255, 0, 0, 255}, HSL{0, 1, 0.5}},
+		{"yellow", color.RGBA{255, 255, 0, 255}, HSL{60, 1, 0.5}},
+		{"pastel blue", color.RGBA64{0x8000, 0x8000, 0xffff, 0xffff}, HSL{240, 1, (1 + float64(0x8000)/0xffff) / 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HSLModel.Convert(tt.input).(HSL)
+			if !approxEqual(got.H, tt.want.H, 1e-9) || !approxEqual(got.S, tt.want.S, 1e-9) || !approxEqual(got.L, tt.want.L, 1e-9) {
+				t.Errorf("HSLModel.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSVHSL_RoundTripExact(t *testing.T) {
+	// Every 16-bit color must survive the trip through float HSV/HSL exactly.
+	step := uint32(0x0fff)
+	for r := uint32(0); r <= 0xffff; r += step {
+		for g := uint32(0); g <= 0xffff; g += step {
+			for b := uint32(0); b <= 0xffff; b += step {
+				c := color.RGBA64{uint16(r), uint16(g), uint16(b), 0xffff}
+				for _, m := range []color.Model{HSVModel, HSLModel} {
+					r2, g2, b2, a2 := m.Convert(c).RGBA()
+					if r2 != r || g2 != g || b2 != b || a2 != 0xffff {
+						t.Fatalf("%v round-tripped to (%d, %d, %d, %d)", c, r2, g2, b2, a2)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRotateHue(t *testing.T) {
+	if got := (HSV{H: 350, S: 1, V: 1}).RotateHue(20); got.H != 10 {
+		t.Errorf("HSV.RotateHue wrapped to %v, want 10", got.H)
+	}
+	if got := (HSL{H: 10, S: 1, L: 0.5}).RotateHue(-20); got.H != 350 {
+		t.Errorf("HSL.RotateHue wrapped to %v, want 350", got.H)
+	}
+
+	// Rotating red by 120 degrees gives green, preserving alpha.
+	got := RotateHue(color.NRGBA{R: 255, A: 128}, 120)
+	if got.R != 0 || got.G != 0xffff || got.B != 0 || got.A != 0x8080 {
+		t.Errorf("RotateHue(red, 120) = %+v, want green with alpha 0x8080", got)
+	}
+	// Achromatic colors are unaffected.
+	if got := RotateHue(color.Gray16{Y: 0x1234}, 77); got.R != 0x1234 || got.G != 0x1234 || got.B != 0x1234 {
+		t.Errorf("RotateHue(gray) = %+v, want unchanged gray", got)
+	}
+}
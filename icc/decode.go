@@ -0,0 +1,269 @@
+package icc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Decode reads a matrix/TRC RGB profile from r. Malformed or unsupported
+// profiles yield a *colorext.DecodeError.
+func Decode(r io.Reader) (*Profile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a matrix/TRC RGB profile. Version 2 and version 4 profiles
+// are supported; profiles that describe their transform only with lookup
+// tables are rejected. Malformed or unsupported profiles yield a
+// *colorext.DecodeError, whose Err wraps colorext.ErrUnsupportedDType for
+// color spaces other than RGB.
+func Parse(data []byte) (*Profile, error) {
+	if len(data) < 132 {
+		return nil, decodeError(int64(len(data)), io.ErrUnexpectedEOF)
+	}
+	if string(data[36:40]) != "acsp" {
+		return nil, decodeError(36, errors.New("missing profile signature"))
+	}
+	if cs := string(data[16:20]); cs != "RGB " {
+		return nil, decodeError(16, fmt.Errorf("%w: color space %q", colorext.ErrUnsupportedDType, cs))
+	}
+	if pcs := string(data[20:24]); pcs != "XYZ " {
+		return nil, decodeError(20, fmt.Errorf("unsupported connection space %q", pcs))
+	}
+
+	n := int(binary.BigEndian.Uint32(data[128:]))
+	if n > (len(data)-132)/12 {
+		return nil, decodeError(128, errors.New("tag table out of range"))
+	}
+	tags := make(map[string][]byte, n)
+	// at holds the offsets of the tags, for reporting errors in them.
+	at := make(map[string]int64, n)
+	for i := 0; i < n; i++ {
+		e := data[132+12*i:]
+		off, size := binary.BigEndian.Uint32(e[4:]), binary.BigEndian.Uint32(e[8:])
+		if uint64(off)+uint64(size) > uint64(len(data)) || size < 8 {
+			return nil, decodeError(int64(132+12*i), fmt.Errorf("tag %q out of range", e[:4]))
+		}
+		tags[string(e[:4])] = data[off : off+size]
+		at[string(e[:4])] = int64(off)
+	}
+
+	p := &Profile{MediaWhite: colorext.D50.XYZ()}
+	for _, t := range []struct {
+		sig string
+		dst *colorext.XYZ
+	}{{"rXYZ", &p.Red}, {"gXYZ", &p.Green}, {"bXYZ", &p.Blue}} {
+		b, ok := tags[t.sig]
+		if !ok {
+			return nil, decodeError(128, errors.New("profile is not a matrix/TRC profile"))
+		}
+		xyz, err := parseXYZ(b)
+		if err != nil {
+			return nil, decodeError(at[t.sig], err)
+		}
+		*t.dst = xyz
+	}
+	for i, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		b, ok := tags[sig]
+		if !ok {
+			return nil, decodeError(128, errors.New("profile is not a matrix/TRC profile"))
+		}
+		c, err := parseCurve(b)
+		if err != nil {
+			return nil, decodeError(at[sig], err)
+		}
+		p.TRC[i] = c
+	}
+	if b, ok := tags["wtpt"]; ok {
+		xyz, err := parseXYZ(b)
+		if err != nil {
+			return nil, decodeError(at["wtpt"], err)
+		}
+		p.MediaWhite = xyz
+	}
+	if b, ok := tags["desc"]; ok {
+		p.Description = parseText(b)
+	}
+	return p, nil
+}
+
+// decodeError returns a *colorext.DecodeError for a profile found to be
+// malformed at off.
+func decodeError(off int64, err error) error {
+	return &colorext.DecodeError{Format: "ICC profile", Offset: off, Err: err}
+}
+
+// s15Fixed16 decodes a signed 15.16 fixed point number.
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// parseXYZ decodes an XYZType tag holding at least one value.
+func parseXYZ(b []byte) (colorext.XYZ, error) {
+	if string(b[:4]) != "XYZ " || len(b) < 20 {
+		return colorext.XYZ{}, errors.New("malformed XYZ tag")
+	}
+	return colorext.XYZ{X: s15Fixed16(b[8:]), Y: s15Fixed16(b[12:]), Z: s15Fixed16(b[16:])}, nil
+}
+
+// parseCurve decodes a curveType or parametricCurveType tag.
+func parseCurve(b []byte) (colorext.TransferFunction, error) {
+	switch string(b[:4]) {
+	case "curv":
+		if len(b) < 12 {
+			break
+		}
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		if n > (len(b)-12)/2 {
+			break
+		}
+		switch n {
+		case 0:
+			return colorext.TransferLinear, nil
+		case 1:
+			return colorext.Gamma(float64(binary.BigEndian.Uint16(b[12:])) / 256), nil
+		}
+		t := make(tableCurve, n)
+		for i := range t {
+			t[i] = float64(binary.BigEndian.Uint16(b[12+2*i:])) / 0xffff
+		}
+		return t, nil
+	case "para":
+		if len(b) < 12 {
+			break
+		}
+		fn := binary.BigEndian.Uint16(b[8:])
+		counts := []int{1, 3, 4, 5, 7}
+		if int(fn) >= len(counts) || len(b) < 12+4*counts[fn] {
+			break
+		}
+		var v [7]float64
+		for i := 0; i < counts[fn]; i++ {
+			v[i] = s15Fixed16(b[12+4*i:])
+		}
+		c := paramCurve{g: v[0], a: 1}
+		switch fn {
+		case 1:
+			c.a, c.b = v[1], v[2]
+			c.d = -c.b / c.a
+		case 2:
+			c.a, c.b, c.e, c.f = v[1], v[2], v[3], v[3]
+			c.d = -c.b / c.a
+		case 3:
+			c.a, c.b, c.c, c.d = v[1], v[2], v[3], v[4]
+		case 4:
+			c.a, c.b, c.c, c.d, c.e, c.f = v[1], v[2], v[3], v[4], v[5], v[6]
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("malformed curve tag %q", b[:4])
+}
+
+// parseText decodes a v2 textDescriptionType or v4 multiLocalizedUnicodeType
+// tag, returning the first record of the latter.
+func parseText(b []byte) string {
+	switch string(b[:4]) {
+	case "desc":
+		if len(b) < 12 {
+			return ""
+		}
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		if n > len(b)-12 {
+			return ""
+		}
+		s := b[12 : 12+n]
+		for len(s) > 0 && s[len(s)-1] == 0 {
+			s = s[:len(s)-1]
+		}
+		return string(s)
+	case "mluc":
+		if len(b) < 28 || binary.BigEndian.Uint32(b[8:]) == 0 {
+			return ""
+		}
+		n, off := binary.BigEndian.Uint32(b[20:]), binary.BigEndian.Uint32(b[24:])
+		if uint64(off)+uint64(n) > uint64(len(b)) {
+			return ""
+		}
+		u := make([]uint16, n/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(b[int(off)+2*i:])
+		}
+		return string(utf16.Decode(u))
+	}
+	return ""
+}
+
+// paramCurve is the general form of an ICC parametric curve:
+//
+//	Y = (aX + b)^g + e  for X >= d
+//	Y = cX + f          for X < d
+type paramCurve struct {
+	g, a, b, c, d, e, f float64
+}
+
+// Decode evaluates the curve.
+func (p paramCurve) Decode(v float64) float64 {
+	if v >= p.d {
+		base := p.a*v + p.b
+		if base <= 0 {
+			return p.e
+		}
+		return math.Pow(base, p.g) + p.e
+	}
+	return p.c*v + p.f
+}
+
+// Encode evaluates the inverse of the curve.
+func (p paramCurve) Encode(v float64) float64 {
+	if v >= p.Decode(p.d) {
+		if v <= p.e || p.a == 0 {
+			return p.d
+		}
+		return (math.Pow(v-p.e, 1/p.g) - p.b) / p.a
+	}
+	if p.c == 0 {
+		return p.d
+	}
+	return (v - p.f) / p.c
+}
+
+// tableCurve is a sampled curve with evenly spaced inputs on [0, 1].
+// Samples are assumed to be non-decreasing.
+type tableCurve []float64
+
+// Decode interpolates the table at v.
+func (t tableCurve) Decode(v float64) float64 {
+	pos := clamp01(v) * float64(len(t)-1)
+	i := int(pos)
+	if i >= len(t)-1 {
+		return t[len(t)-1]
+	}
+	f := pos - float64(i)
+	return t[i] + (t[i+1]-t[i])*f
+}
+
+// Encode inverts the table by searching for the segment containing v.
+func (t tableCurve) Encode(v float64) float64 {
+	i := sort.SearchFloat64s(t, v)
+	switch {
+	case i == 0:
+		return 0
+	case i >= len(t):
+		return 1
+	}
+	f := 0.0
+	if t[i] != t[i-1] {
+		f = (v - t[i-1]) / (t[i] - t[i-1])
+	}
+	return (float64(i-1) + f) / float64(len(t)-1)
+}
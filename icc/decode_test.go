@@ -0,0 +1,204 @@
+package icc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// buildProfile assembles an RGB display profile holding the given tags.
+func buildProfile(tags map[string][]byte) []byte {
+	sigs := make([]string, 0, len(tags))
+	for s := range tags {
+		sigs = append(sigs, s)
+	}
+	sort.Strings(sigs)
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntrRGB XYZ ")
+	copy(header[36:], "acsp")
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(sigs)))
+	var body []byte
+	off := 128 + 4 + 12*len(sigs)
+	for _, s := range sigs {
+		table = append(table, s...)
+		table = binary.BigEndian.AppendUint32(table, uint32(off+len(body)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(tags[s])))
+		body = append(body, tags[s]...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	out := append(header, table...)
+	out = append(out, body...)
+	binary.BigEndian.PutUint32(out, uint32(len(out)))
+	return out
+}
+
+func fixed(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+}
+
+func xyzTag(c colorext.XYZ) []byte {
+	b := []byte("XYZ \x00\x00\x00\x00")
+	b = append(b, fixed(c.X)...)
+	b = append(b, fixed(c.Y)...)
+	return append(b, fixed(c.Z)...)
+}
+
+func curvTag(values ...uint16) []byte {
+	b := []byte("curv\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, uint32(len(values)))
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+func paraTag(fn uint16, params ...float64) []byte {
+	b := []byte("para\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint16(b, fn)
+	b = append(b, 0, 0)
+	for _, p := range params {
+		b = append(b, fixed(p)...)
+	}
+	return b
+}
+
+func matrixTags() map[string][]byte {
+	return map[string][]byte{
+		"rXYZ": xyzTag(SRGB.Red),
+		"gXYZ": xyzTag(SRGB.Green),
+		"bXYZ": xyzTag(SRGB.Blue),
+		"wtpt": xyzTag(colorext.D50.XYZ()),
+	}
+}
+
+func TestParse_ParametricSRGB(t *testing.T) {
+	tags := matrixTags()
+	srgb := paraTag(3, 2.4, 1/1.055, 0.055/1.055, 1/12.92, 0.04045)
+	tags["rTRC"], tags["gTRC"], tags["bTRC"] = srgb, srgb, srgb
+	tags["desc"] = append(append([]byte("desc\x00\x00\x00\x00"), binary.BigEndian.AppendUint32(nil, 5)...), "sRGB\x00"...)
+
+	p, err := Parse(buildProfile(tags))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.Description != "sRGB" {
+		t.Errorf("Description = %q, want %q", p.Description, "sRGB")
+	}
+	if !approxEqual(p.Red.X, SRGB.Red.X, 1e-4) || !approxEqual(p.Blue.Z, SRGB.Blue.Z, 1e-4) {
+		t.Errorf("colorants = %v, %v, want %v, %v", p.Red, p.Blue, SRGB.Red, SRGB.Blue)
+	}
+	for v := 0.0; v <= 1; v += 0.125 {
+		got, want := p.TRC[0].Decode(v), colorext.TransferSRGB.Decode(v)
+		if !approxEqual(got, want, 1e-4) {
+			t.Errorf("TRC.Decode(%v) = %v, want %v", v, got, want)
+		}
+		if back := p.TRC[0].Encode(got); !approxEqual(back, v, 1e-6) {
+			t.Errorf("TRC.Encode(Decode(%v)) = %v", v, back)
+		}
+	}
+}
+
+func TestParse_Curves(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  []byte
+		in   float64
+		want float64
+	}{
+		{"identity", curvTag(), 0.3, 0.3},
+		{"gamma", curvTag(0x0200), 0.5, 0.25},
+		{"table", curvTag(0, 0x4000, 0xffff), 0.25, 0.125},
+		{"para type 0", paraTag(0, 2), 0.5, 0.25},
+		{"para type 1", paraTag(1, 1, 2, -1), 0.25, 0},
+		{"para type 2", paraTag(2, 1, 1, 0, 0.5), 0.25, 0.75},
+		{"para type 4", paraTag(4, 1, 1, 0, 2, 0.5, 0.1, 0.2), 0.25, 0.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := matrixTags()
+			tags["rTRC"], tags["gTRC"], tags["bTRC"] = tt.tag, tt.tag, tt.tag
+			p, err := Parse(buildProfile(tags))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := p.TRC[1].Decode(tt.in); !approxEqual(got, tt.want, 1e-4) {
+				t.Errorf("Decode(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Mluc(t *testing.T) {
+	tags := matrixTags()
+	tags["rTRC"], tags["gTRC"], tags["bTRC"] = curvTag(), curvTag(), curvTag()
+	b := []byte("mluc\x00\x00\x00\x00")
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, 12)
+	b = append(b, "enUS"...)
+	b = binary.BigEndian.AppendUint32(b, 6)
+	b = binary.BigEndian.AppendUint32(b, 28)
+	b = append(b, 0, 'P', 0, '3', 0, '!')
+	tags["desc"] = b
+
+	p, err := Decode(bytes.NewReader(buildProfile(tags)))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if p.Description != "P3!" {
+		t.Errorf("Description = %q, want %q", p.Description, "P3!")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	noTRC := buildProfile(matrixTags())
+	gray := buildProfile(nil)
+	copy(gray[16:], "GRAY")
+	badCurve := matrixTags()
+	badCurve["rTRC"], badCurve["gTRC"], badCurve["bTRC"] = paraTag(9, 1), paraTag(9, 1), paraTag(9, 1)
+	bad := buildProfile(badCurve)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int64
+	}{
+		{"empty", nil, 0},
+		{"no signature", make([]byte, 200), 36},
+		{"gray", gray, 16},
+		{"lut profile", noTRC, 128},
+		{"bad curve", bad, tagOffset(bad, "rTRC")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			var de *colorext.DecodeError
+			if !errors.As(err, &de) || de.Offset != tt.offset {
+				t.Errorf("Parse error = %v, want a DecodeError at offset %d", err, tt.offset)
+			}
+		})
+	}
+	if _, err := Parse(gray); !errors.Is(err, colorext.ErrUnsupportedDType) {
+		t.Errorf("Parse(gray) = %v, want %v", err, colorext.ErrUnsupportedDType)
+	}
+}
+
+// tagOffset returns the offset of the data of tag sig in the profile data.
+func tagOffset(data []byte, sig string) int64 {
+	n := int(binary.BigEndian.Uint32(data[128:]))
+	for i := 0; i < n; i++ {
+		if e := data[132+12*i:]; string(e[:4]) == sig {
+			return int64(binary.BigEndian.Uint32(e[4:]))
+		}
+	}
+	return -1
+}
@@ -0,0 +1,183 @@
+// Package icc converts colors between RGB spaces described by matrix/TRC ICC
+// profiles.
+//
+// A matrix/TRC profile describes an RGB space with three tone reproduction
+// curves, which decode the stored values to linear light, and three
+// colorants, which map linear RGB to the D50 profile connection space.
+// Profiles for common spaces are built in; others can be read with Decode.
+package icc
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Profile is a matrix/TRC RGB profile.
+type Profile struct {
+	// Description is the human readable profile name.
+	Description string
+	// Red, Green and Blue are the XYZ coordinates of the primaries at full
+	// intensity, adapted to the D50 profile connection space.
+	Red, Green, Blue colorext.XYZ
+	// MediaWhite is the XYZ white point of the medium.
+	MediaWhite colorext.XYZ
+	// TRC holds the red, green and blue tone reproduction curves.
+	// Decode maps stored values to linear light.
+	TRC [3]colorext.TransferFunction
+}
+
+// NewProfile returns the profile of an RGB space with the given primary
+// chromaticities, white point and transfer function. The colorants are
+// adapted from white to D50 with the Bradford transform.
+func NewProfile(description string, red, green, blue colorext.XyY, white colorext.WhitePoint, tf colorext.TransferFunction) *Profile {
+	prim := mat3{}
+	for j, c := range []colorext.XyY{red, green, blue} {
+		xyz := colorext.XyY{Cx: c.Cx, Cy: c.Cy, Y: 1}.XYZ()
+		prim[0][j], prim[1][j], prim[2][j] = xyz.X, xyz.Y, xyz.Z
+	}
+	// Scale the primaries so that RGB (1, 1, 1) is the white point.
+	s := prim.inverse()
+	sr, sg, sb := s.apply(white.X, white.Y, white.Z)
+
+	p := &Profile{
+		Description: description,
+		MediaWhite:  colorext.D50.XYZ(),
+		TRC:         [3]colorext.TransferFunction{tf, tf, tf},
+	}
+	for j, dst := range []*colorext.XYZ{&p.Red, &p.Green, &p.Blue} {
+		k := []float64{sr, sg, sb}[j]
+		c := colorext.XYZ{X: prim[0][j] * k, Y: prim[1][j] * k, Z: prim[2][j] * k}
+		*dst = colorext.Adapt(c, white, colorext.D50)
+	}
+	return p
+}
+
+// Built-in profiles.
+var (
+	// SRGB is the IEC 61966-2-1 sRGB space.
+	SRGB = NewProfile("sRGB",
+		colorext.XyY{Cx: 0.64, Cy: 0.33}, colorext.XyY{Cx: 0.30, Cy: 0.60}, colorext.XyY{Cx: 0.15, Cy: 0.06},
+		colorext.D65, colorext.TransferSRGB)
+	// DisplayP3 is Apple's Display P3 space: DCI-P3 primaries with a D65
+	// white and the sRGB transfer function.
+	DisplayP3 = NewProfile("Display P3",
+		colorext.XyY{Cx: 0.680, Cy: 0.320}, colorext.XyY{Cx: 0.265, Cy: 0.690}, colorext.XyY{Cx: 0.150, Cy: 0.060},
+		colorext.D65, colorext.TransferSRGB)
+	// AdobeRGB is the Adobe RGB (1998) space.
+	AdobeRGB = NewProfile("Adobe RGB (1998)",
+		colorext.XyY{Cx: 0.64, Cy: 0.33}, colorext.XyY{Cx: 0.21, Cy: 0.71}, colorext.XyY{Cx: 0.15, Cy: 0.06},
+		colorext.D65, colorext.Gamma(563.0/256))
+	// ProPhotoRGB is the ROMM RGB space with a pure 1.8 gamma curve.
+	ProPhotoRGB = NewProfile("ProPhoto RGB",
+		colorext.XyY{Cx: 0.7347, Cy: 0.2653}, colorext.XyY{Cx: 0.1596, Cy: 0.8404}, colorext.XyY{Cx: 0.0366, Cy: 0.0001},
+		colorext.D50, colorext.Gamma(1.8))
+)
+
+// matrix returns the linear RGB to PCS XYZ matrix of the profile.
+func (p *Profile) matrix() mat3 {
+	return mat3{
+		{p.Red.X, p.Green.X, p.Blue.X},
+		{p.Red.Y, p.Green.Y, p.Blue.Y},
+		{p.Red.Z, p.Green.Z, p.Blue.Z},
+	}
+}
+
+// Transform converts colors encoded in one profile's space to another's,
+// using the relative colorimetric intent. Colors outside the destination
+// gamut are clipped.
+//
+// Transform implements color.Model. Colors passed to Convert are always
+// interpreted as encoded in the source space, so converting an already
+// converted color converts it again.
+type Transform struct {
+	src, dst *Profile
+	m        mat3
+}
+
+// NewTransform returns a Transform from the space of src to that of dst.
+func NewTransform(src, dst *Profile) *Transform {
+	return &Transform{
+		src: src,
+		dst: dst,
+		m:   dst.matrix().inverse().mul(src.matrix()),
+	}
+}
+
+// Convert returns c, interpreted in the source space, encoded in the
+// destination space as a color.NRGBA64. Alpha is preserved.
+func (t *Transform) Convert(c color.Color) color.Color {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	r, g, b := t.convert(float64(n.R)/0xffff, float64(n.G)/0xffff, float64(n.B)/0xffff)
+	return color.NRGBA64{R: unit16(r), G: unit16(g), B: unit16(b), A: n.A}
+}
+
+// convert converts unit encoded source values to unit encoded destination
+// values.
+func (t *Transform) convert(r, g, b float64) (float64, float64, float64) {
+	lr, lg, lb := t.m.apply(t.src.TRC[0].Decode(r), t.src.TRC[1].Decode(g), t.src.TRC[2].Decode(b))
+	return t.dst.TRC[0].Encode(clamp01(lr)), t.dst.TRC[1].Encode(clamp01(lg)), t.dst.TRC[2].Encode(clamp01(lb))
+}
+
+// Image returns a copy of src converted to the destination space.
+func (t *Transform) Image(src image.Image) *image.NRGBA64 {
+	r := src.Bounds()
+	dst := image.NewNRGBA64(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetNRGBA64(x, y, t.Convert(src.At(x, y)).(color.NRGBA64))
+		}
+	}
+	return dst
+}
+
+// clamp01 clamps v to [0, 1], mapping NaN to 0.
+func clamp01(v float64) float64 {
+	if !(v > 0) {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// unit16 converts a value in [0, 1] to a 16-bit channel.
+func unit16(v float64) uint16 {
+	return uint16(clamp01(v)*0xffff + 0.5)
+}
+
+// mat3 is a 3×3 matrix acting on column vectors.
+type mat3 [3][3]float64
+
+// apply returns m × (x, y, z).
+func (m mat3) apply(x, y, z float64) (float64, float64, float64) {
+	return m[0][0]*x + m[0][1]*y + m[0][2]*z,
+		m[1][0]*x + m[1][1]*y + m[1][2]*z,
+		m[2][0]*x + m[2][1]*y + m[2][2]*z
+}
+
+// mul returns the matrix product m × n.
+func (m mat3) mul(n mat3) mat3 {
+	var p mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			p[i][j] = m[i][0]*n[0][j] + m[i][1]*n[1][j] + m[i][2]*n[2][j]
+		}
+	}
+	return p
+}
+
+// inverse returns the inverse of m. m must be non-singular.
+func (m mat3) inverse() mat3 {
+	c00 := m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	c01 := m[1][2]*m[2][0] - m[1][0]*m[2][2]
+	c02 := m[1][0]*m[2][1] - m[1][1]*m[2][0]
+	det := m[0][0]*c00 + m[0][1]*c01 + m[0][2]*c02
+	return mat3{
+		{c00 / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{c01 / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{c02 / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}
+}
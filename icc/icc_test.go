@@ -0,0 +1,103 @@
+package icc
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func approxEqual(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestNewProfile_SRGBColorants(t *testing.T) {
+	// D50-adapted sRGB colorants as found in the ICC sRGB profiles.
+	want := []colorext.XYZ{
+		{X: 0.4361, Y: 0.2225, Z: 0.0139},
+		{X: 0.3851, Y: 0.7169, Z: 0.0971},
+		{X: 0.1431, Y: 0.0606, Z: 0.7141},
+	}
+	for i, got := range []colorext.XYZ{SRGB.Red, SRGB.Green, SRGB.Blue} {
+		if !approxEqual(got.X, want[i].X, 2e-4) || !approxEqual(got.Y, want[i].Y, 2e-4) || !approxEqual(got.Z, want[i].Z, 2e-4) {
+			t.Errorf("colorant %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestNewProfile_WhiteIsD50(t *testing.T) {
+	for _, p := range []*Profile{SRGB, DisplayP3, AdobeRGB, ProPhotoRGB} {
+		x, y, z := p.matrix().apply(1, 1, 1)
+		if !approxEqual(x, colorext.D50.X, 1e-6) || !approxEqual(y, 1, 1e-6) || !approxEqual(z, colorext.D50.Z, 1e-6) {
+			t.Errorf("%s: white = (%v, %v, %v), want D50", p.Description, x, y, z)
+		}
+	}
+}
+
+func TestTransform_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst *Profile
+		in       color.NRGBA64
+		want     [3]float64
+	}{
+		{"identity", SRGB, SRGB, color.NRGBA64{0x1234, 0x8000, 0xfedc, 0xffff}, [3]float64{0x1234, 0x8000, 0xfedc}},
+		{"white", SRGB, AdobeRGB, color.NRGBA64{0xffff, 0xffff, 0xffff, 0xffff}, [3]float64{0xffff, 0xffff, 0xffff}},
+		// sRGB red sits inside Display P3 at about (0.9175, 0.2003, 0.1386).
+		{"sRGB red in P3", SRGB, DisplayP3, color.NRGBA64{0xffff, 0, 0, 0xffff}, [3]float64{0.9175 * 0xffff, 0.2003 * 0xffff, 0.1386 * 0xffff}},
+		// P3 green lies outside sRGB and is clipped.
+		{"P3 green in sRGB", DisplayP3, SRGB, color.NRGBA64{0, 0xffff, 0, 0xffff}, [3]float64{0, 0xffff, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTransform(tt.src, tt.dst).Convert(tt.in).(color.NRGBA64)
+			for i, v := range []uint16{got.R, got.G, got.B} {
+				if !approxEqual(float64(v), tt.want[i], 0.001*0xffff) {
+					t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTransform_RoundTrip(t *testing.T) {
+	fwd, back := NewTransform(SRGB, ProPhotoRGB), NewTransform(ProPhotoRGB, SRGB)
+	for _, c := range []color.NRGBA64{
+		{0x4000, 0x8000, 0xc000, 0xffff},
+		{0xffff, 0x2000, 0x1000, 0x8000},
+		{0x0100, 0x0100, 0x0100, 0xffff},
+	} {
+		got := back.Convert(fwd.Convert(c)).(color.NRGBA64)
+		for i, v := range []uint16{got.R, got.G, got.B} {
+			want := []uint16{c.R, c.G, c.B}[i]
+			if !approxEqual(float64(v), float64(want), 32) {
+				t.Errorf("round trip of %v = %v", c, got)
+				break
+			}
+		}
+		if got.A != c.A {
+			t.Errorf("round trip alpha = %#x, want %#x", got.A, c.A)
+		}
+	}
+}
+
+func TestTransform_Image(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(1, 1, 3, 2))
+	src.SetNRGBA(1, 1, color.NRGBA{0xff, 0, 0, 0xff})
+	src.SetNRGBA(2, 1, color.NRGBA{0x80, 0x80, 0x80, 0x40})
+
+	tr := NewTransform(SRGB, DisplayP3)
+	dst := tr.Image(src)
+	if dst.Bounds() != src.Bounds() {
+		t.Fatalf("Bounds() = %v, want %v", dst.Bounds(), src.Bounds())
+	}
+	for x := 1; x < 3; x++ {
+		if got, want := dst.NRGBA64At(x, 1), tr.Convert(src.At(x, 1)); got != want {
+			t.Errorf("pixel %d = %v, want %v", x, got, want)
+		}
+	}
+}
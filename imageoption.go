@@ -0,0 +1,126 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image/color"
+	"image/draw"
+)
+
+// ImageOption configures an image made by one of the New*Image functions
+// allocating a pixel buffer for a settable image, such as NewGrayS16Image,
+// NewBayerImage or NewPaletted16Image. The atomic images hold counters
+// rather than bytes, so only WithFill and WithMeta apply to them.
+type ImageOption func(*imageOptions)
+
+type imageOptions struct {
+	fill   color.Color
+	alloc  Allocator
+	little bool
+	meta   Metadata
+}
+
+// WithFill sets every pixel of the new image to c, converted by the image's
+// color model, instead of leaving it zero.
+func WithFill(c color.Color) ImageOption {
+	return func(o *imageOptions) { o.fill = c }
+}
+
+// WithAllocator makes the new image take its pixel buffers from a instead
+// of the Go heap. Their contents become the initial pixels, as described
+// by Allocator.
+func WithAllocator(a Allocator) ImageOption {
+	return func(o *imageOptions) { o.alloc = a }
+}
+
+// WithEndianness declares the byte order of the samples in a buffer from
+// WithAllocator. Images hold multi-byte samples big-endian, so a buffer in
+// another order is converted in place, as by SwapEndianness. It has no
+// effect without WithAllocator or on images of single-byte samples.
+func WithEndianness(order binary.ByteOrder) ImageOption {
+	little := order.Uint16([]byte{1, 0}) == 1
+	return func(o *imageOptions) { o.little = little }
+}
+
+// WithMeta sets the Meta field of the new image to meta, which MetaOf then
+// returns for it and its sub-images.
+func WithMeta(meta Metadata) ImageOption {
+	return func(o *imageOptions) { o.meta = meta }
+}
+
+func newImageOptions(opts []ImageOption) imageOptions {
+	var o imageOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// pix returns the pixel buffer of n bytes of a new image whose samples are
+// word bytes wide.
+func (o *imageOptions) pix(n, word int) []uint8 {
+	if o.alloc == nil {
+		return make([]uint8, n)
+	}
+	buf := allocFrom(o.alloc, n)
+	if o.little {
+		switch word {
+		case 2:
+			SwapBytes16(buf)
+		case 4:
+			SwapBytes32(buf)
+		case 8:
+			swapBytes64(buf)
+		}
+	}
+	return buf
+}
+
+// fillPix applies WithFill to a new image img of bpp bytes per pixel, whose
+// rows of stride bytes are laid out contiguously in pix.
+func (o *imageOptions) fillPix(img draw.Image, pix []uint8, stride, bpp int) {
+	o.fillBlocks(img, pix, stride, bpp, 1, 1)
+}
+
+// fillBlocks is like fillPix for images whose pixels repeat every block
+// bytes, holding per pixels, and every rows rows, as when samples are
+// packed or their meaning depends on their position.
+func (o *imageOptions) fillBlocks(img draw.Image, pix []uint8, stride, block, per, rows int) {
+	r := img.Bounds()
+	if o.fill == nil || r.Empty() {
+		return
+	}
+	// Set one period of pixels, then replicate it, doubling the copied span
+	// each time: first along the leading rows, then down the image.
+	for y := r.Min.Y; y < r.Min.Y+rows && y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Min.X+per && x < r.Max.X; x++ {
+			img.Set(x, y, o.fill)
+		}
+		row := pix[(y-r.Min.Y)*stride : (y-r.Min.Y+1)*stride]
+		for n := block; n < len(row); n *= 2 {
+			copy(row[n:], row[:n])
+		}
+	}
+	for n := rows * stride; n < len(pix); n *= 2 {
+		copy(pix[n:], pix[:n])
+	}
+}
+
+// fillRepeat fills pix with copies of sample, whose length divides
+// len(pix).
+func fillRepeat(pix []uint8, sample ...uint8) {
+	if len(pix) == 0 {
+		return
+	}
+	n := copy(pix, sample)
+	for ; n < len(pix); n *= 2 {
+		copy(pix[n:], pix[:n])
+	}
+}
+
+// swapBytes64 reverses the byte order of each 64-bit value in b in place.
+// Trailing bytes not forming a whole value are left unchanged.
+func swapBytes64(b []uint8) {
+	for i := 0; i+8 <= len(b); i += 8 {
+		binary.LittleEndian.PutUint64(b[i:], binary.BigEndian.Uint64(b[i:]))
+	}
+}
@@ -0,0 +1,238 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestWithFill(t *testing.T) {
+	c := color.NRGBA64{R: 0x1234, G: 0xabcd, B: 0x5678, A: 0xffff}
+	r := image.Rect(-3, 2, 8, 7)
+	palette := color.Palette{color.Black, color.White, c}
+	tests := []struct {
+		name string
+		img  draw.Image
+		new  func(image.Rectangle) draw.Image
+	}{
+		{"GrayS16", NewGrayS16Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayS16Image(r) }},
+		{"GrayF32", NewGrayF32Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayF32Image(r) }},
+		{"GrayS64", NewGrayS64Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayS64Image(r) }},
+		{"GrayU32", NewGrayU32Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayU32Image(r) }},
+		{"GrayU64", NewGrayU64Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayU64Image(r) }},
+		{"GrayC64", NewGrayC64Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayC64Image(r) }},
+		{"GrayC128", NewGrayC128Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGrayC128Image(r) }},
+		{"BGR", NewBGRImage(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBGRImage(r) }},
+		{"BGRA", NewBGRAImage(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBGRAImage(r) }},
+		{"BGR48", NewBGR48Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBGR48Image(r) }},
+		{"BGRA64", NewBGRA64Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBGRA64Image(r) }},
+		{"CMYK64", NewCMYK64Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewCMYK64Image(r) }},
+		{"LinearRGBAF32", NewLinearRGBAF32Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewLinearRGBAF32Image(r) }},
+		{"RGBAF32", NewRGBAF32Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewRGBAF32Image(r) }},
+		{"NRGBAF32", NewNRGBAF32Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewNRGBAF32Image(r) }},
+		{"RGB565", NewRGB565Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewRGB565Image(r) }},
+		{"RGB555", NewRGB555Image(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewRGB555Image(r) }},
+		{"Gray10Packed", NewGray10PackedImage(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGray10PackedImage(r) }},
+		{"Gray12Packed", NewGray12PackedImage(r, WithFill(c)), func(r image.Rectangle) draw.Image { return NewGray12PackedImage(r) }},
+		{"Paletted16", NewPaletted16Image(r, palette, WithFill(c)), func(r image.Rectangle) draw.Image { return NewPaletted16Image(r, palette) }},
+		{"Bayer8", NewBayerImage(r, GRBG, 8, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBayerImage(r, GRBG, 8) }},
+		{"Bayer16", NewBayerImage(r, GRBG, 16, WithFill(c)), func(r image.Rectangle) draw.Image { return NewBayerImage(r, GRBG, 16) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The fill must match setting every pixel in turn.
+			want := tt.new(r)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					want.Set(x, y, c)
+				}
+			}
+			if !Equal(tt.img, want) {
+				t.Errorf("filled %T differs from one set pixel by pixel", tt.img)
+			}
+		})
+	}
+}
+
+func TestWithFillSmall(t *testing.T) {
+	c := GrayS16{Y: 100}
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 1, 1), image.Rect(0, 0, 1, 3), image.Rect(2, 2, 2, 2)} {
+		img := NewGray10PackedImage(r, WithFill(c))
+		want := NewGray10PackedImage(r)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				want.Set(x, y, c)
+			}
+		}
+		if !Equal(img, want) {
+			t.Errorf("%v: filled image differs from one set pixel by pixel", r)
+		}
+	}
+}
+
+func TestWithAllocator(t *testing.T) {
+	var calls int
+	adopt := func(data []byte) Allocator {
+		return AllocatorFunc(func(n int) []byte {
+			calls++
+			return data[:n]
+		})
+	}
+
+	le := binary.LittleEndian
+	tests := []struct {
+		name  string
+		order binary.ByteOrder
+		data  []byte
+		check func(*testing.T, []byte, binary.ByteOrder)
+	}{
+		{"int16 big-endian", binary.BigEndian, binary.BigEndian.AppendUint16(nil, uint16(0xfff6)), func(t *testing.T, data []byte, order binary.ByteOrder) {
+			if got := NewGrayS16Image(image.Rect(0, 0, 1, 1), WithAllocator(adopt(data)), WithEndianness(order)).GrayS16At(0, 0).Y; got != -10 {
+				t.Errorf("pixel = %d, want -10", got)
+			}
+		}},
+		{"int16 little-endian", le, le.AppendUint16(nil, uint16(0xfff6)), func(t *testing.T, data []byte, order binary.ByteOrder) {
+			if got := NewGrayS16Image(image.Rect(0, 0, 1, 1), WithAllocator(adopt(data)), WithEndianness(order)).GrayS16At(0, 0).Y; got != -10 {
+				t.Errorf("pixel = %d, want -10", got)
+			}
+		}},
+		{"float32 little-endian", le, le.AppendUint32(nil, math.Float32bits(-2.5)), func(t *testing.T, data []byte, order binary.ByteOrder) {
+			if got := NewGrayF32Image(image.Rect(0, 0, 1, 1), WithAllocator(adopt(data)), WithEndianness(order)).GrayF32At(0, 0).Y; got != -2.5 {
+				t.Errorf("pixel = %v, want -2.5", got)
+			}
+		}},
+		{"int64 little-endian", le, le.AppendUint64(nil, uint64(math.MaxInt64-1)), func(t *testing.T, data []byte, order binary.ByteOrder) {
+			if got := NewGrayS64Image(image.Rect(0, 0, 1, 1), WithAllocator(adopt(data)), WithEndianness(order)).GrayS64At(0, 0).Y; got != math.MaxInt64-1 {
+				t.Errorf("pixel = %d, want %d", got, int64(math.MaxInt64-1))
+			}
+		}},
+		{"bytes unswapped", le, []byte{1, 2, 3}, func(t *testing.T, data []byte, order binary.ByteOrder) {
+			if got := NewBGRImage(image.Rect(0, 0, 1, 1), WithAllocator(adopt(data)), WithEndianness(order)).Pix; got[0] != 1 || got[2] != 3 {
+				t.Errorf("Pix = %v, want [1 2 3]", got)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			tt.check(t, tt.data, tt.order)
+			if calls != 1 {
+				t.Errorf("allocator called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestWithAllocatorFill(t *testing.T) {
+	data := []byte{0x12, 0x34, 0x56, 0x78}
+	img := NewGrayS16Image(image.Rect(0, 0, 2, 1), WithAllocator(AllocatorFunc(func(n int) []byte { return data[:n] })), WithFill(GrayS16{Y: 7}))
+	if &img.Pix[0] != &data[0] || img.GrayS16At(1, 0).Y != 7 {
+		t.Errorf("image does not fill the adopted buffer")
+	}
+}
+
+func TestWithFillPlanar(t *testing.T) {
+	c := color.RGBA{R: 0xc0, G: 0x40, B: 0x20, A: 0xff}
+	r := image.Rect(-3, 2, 8, 7)
+	close8 := func(got color.Color) bool {
+		g := color.RGBAModel.Convert(got).(color.RGBA)
+		return absDiff8(g.R, c.R) <= 2 && absDiff8(g.G, c.G) <= 2 && absDiff8(g.B, c.B) <= 2
+	}
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"NV12 full", NewNV12Image(r, BT601, false, WithFill(c))},
+		{"NV12 limited", NewNV12Image(r, BT709, true, WithFill(c))},
+		{"I420 full", NewI420Image(r, BT709, false, WithFill(c))},
+		{"I420 limited", NewI420Image(r, BT601, true, WithFill(c))},
+		{"YCbCr48 420", NewYCbCr48Image(r, image.YCbCrSubsampleRatio420, BT709, WithFill(c))},
+		{"YCbCr48 444", NewYCbCr48Image(r, image.YCbCrSubsampleRatio444, BT601, WithFill(c))},
+	}
+	for _, tt := range tests {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if got := tt.img.At(x, y); !close8(got) {
+					t.Fatalf("%s: At(%d, %d) = %v, want about %v", tt.name, x, y, got, c)
+				}
+			}
+		}
+	}
+
+	// Without options the chroma is neutral, so the image is black.
+	for _, img := range []image.Image{NewNV12Image(r, BT601, false), NewI420Image(r, BT601, false), NewYCbCr48Image(r, image.YCbCrSubsampleRatio420, BT601)} {
+		if got := color.RGBAModel.Convert(img.At(0, 3)); got != (color.RGBA{A: 0xff}) {
+			t.Errorf("new %T: At(0, 3) = %v, want black", img, got)
+		}
+	}
+}
+
+func TestWithFillAtomic(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	if got := NewAtomicGrayS64Image(r, WithFill(GrayS64{Y: -1 << 40})).Load(2, 1); got != -1<<40 {
+		t.Errorf("AtomicGrayS64: Load = %d, want %d", got, int64(-1<<40))
+	}
+	if got := NewAtomicGrayS32Image(r, WithFill(GrayS64{Y: -7})).Load(2, 1); got != -7 {
+		t.Errorf("AtomicGrayS32: Load = %d, want -7", got)
+	}
+	if got := NewAtomicGrayS32Image(r, WithFill(GrayS64{Y: 1 << 40})).Load(0, 0); got != math.MaxInt32 {
+		t.Errorf("AtomicGrayS32: Load = %d, want %d", got, math.MaxInt32)
+	}
+}
+
+func TestWithMeta(t *testing.T) {
+	meta := Metadata{MetaUnits: "K"}
+	r := image.Rect(0, 0, 4, 4)
+	opt := WithMeta(meta)
+	palette := color.Palette{color.Black, color.White}
+	images := []image.Image{
+		NewGrayS16Image(r, opt),
+		NewGrayF32Image(r, opt),
+		NewGrayS64Image(r, opt),
+		NewGrayU32Image(r, opt),
+		NewGrayU64Image(r, opt),
+		NewGrayC64Image(r, opt),
+		NewGrayC128Image(r, opt),
+		NewBGRImage(r, opt),
+		NewBGRAImage(r, opt),
+		NewBGR48Image(r, opt),
+		NewBGRA64Image(r, opt),
+		NewCMYK64Image(r, opt),
+		NewLinearRGBAF32Image(r, opt),
+		NewRGBAF32Image(r, opt),
+		NewNRGBAF32Image(r, opt),
+		NewRGB565Image(r, opt),
+		NewRGB555Image(r, opt),
+		NewGray10PackedImage(r, opt),
+		NewGray12PackedImage(r, opt),
+		NewPaletted16Image(r, palette, opt),
+		NewBayerImage(r, RGGB, 8, opt),
+		NewYCbCr48Image(r, image.YCbCrSubsampleRatio420, BT709, opt),
+		NewNV12Image(r, BT601, false, opt),
+		NewI420Image(r, BT601, false, opt),
+	}
+	for _, img := range images {
+		if MetaOf(img)[MetaUnits] != "K" {
+			t.Errorf("%T: MetaOf = %v, want %v", img, MetaOf(img), meta)
+		}
+		sub := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		})
+		for _, sr := range []image.Rectangle{image.Rect(1, 1, 3, 3), image.Rect(5, 5, 6, 6)} {
+			if got := MetaOf(sub.SubImage(sr)); got[MetaUnits] != "K" {
+				t.Errorf("%T: sub-image %v has metadata %v", img, sr, got)
+			}
+		}
+	}
+
+	a := NewAtomicGrayS64Image(r, opt)
+	if MetaOf(a)[MetaUnits] != "K" || MetaOf(a.Snapshot())[MetaUnits] != "K" {
+		t.Error("AtomicGrayS64Image or its snapshot lost the metadata")
+	}
+	if MetaOf(NewGrayS16Image(r)) != nil {
+		t.Error("image made without WithMeta has metadata")
+	}
+}
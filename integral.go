@@ -0,0 +1,40 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// Integral returns the integral image (summed-area table) of src. The value
+// at (x, y) is the sum of all source pixels at or above and to the left of
+// (x, y), so the result has the bounds of src. Use SumRect to query it.
+func Integral(src *GrayS16Image) *GrayS64Image {
+	r := src.Rect
+	dst := NewGrayS64Image(r)
+	w := r.Dx()
+	above := make([]int64, w)
+	for y := 0; y < r.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+2*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+8*w]
+		var row int64
+		for x := range above {
+			row += int64(int16(uint16(s[2*x])<<8 | uint16(s[2*x+1])))
+			above[x] += row
+			binary.BigEndian.PutUint64(d[8*x:], uint64(above[x]))
+		}
+	}
+	return dst
+}
+
+// SumRect returns the sum of the source pixels inside r, treating p as an
+// integral image produced by Integral. Only the part of r inside p's bounds
+// is counted. It takes constant time regardless of the size of r.
+func (p *GrayS64Image) SumRect(r image.Rectangle) int64 {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return 0
+	}
+	// Corners above or to the left of the bounds read as 0.
+	x0, y0, x1, y1 := r.Min.X-1, r.Min.Y-1, r.Max.X-1, r.Max.Y-1
+	return p.GrayS64At(x1, y1).Y - p.GrayS64At(x0, y1).Y - p.GrayS64At(x1, y0).Y + p.GrayS64At(x0, y0).Y
+}
@@ -0,0 +1,90 @@
+package colorext
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestIntegral(t *testing.T) {
+	r := image.Rect(-2, 3, 3, 6)
+	src := NewGrayS16Image(r)
+	// Row-major values 1..15.
+	v := int16(1)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			src.SetGrayS16(x, y, GrayS16{Y: v})
+			v++
+		}
+	}
+	ii := Integral(src)
+	if ii.Rect != r {
+		t.Fatalf("bounds = %v, want %v", ii.Rect, r)
+	}
+	tests := []struct {
+		x, y int
+		want int64
+	}{
+		{-2, 3, 1},
+		{2, 3, 15},
+		{-2, 5, 1 + 6 + 11},
+		{0, 4, 1 + 2 + 3 + 6 + 7 + 8},
+		{2, 5, 120},
+	}
+	for _, tt := range tests {
+		if got := ii.GrayS64At(tt.x, tt.y).Y; got != tt.want {
+			t.Errorf("integral at (%d, %d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestGrayS64Image_SumRect(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	r := image.Rect(1, -1, 9, 6)
+	src := NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			src.SetGrayS16(x, y, GrayS16{Y: int16(rng.Intn(65536) - 32768)})
+		}
+	}
+	ii := Integral(src)
+
+	bruteSum := func(q image.Rectangle) int64 {
+		q = q.Intersect(r)
+		var s int64
+		for y := q.Min.Y; y < q.Max.Y; y++ {
+			for x := q.Min.X; x < q.Max.X; x++ {
+				s += int64(src.GrayS16At(x, y).Y)
+			}
+		}
+		return s
+	}
+	tests := []struct {
+		name string
+		r    image.Rectangle
+	}{
+		{"whole image", r},
+		{"single pixel", image.Rect(4, 2, 5, 3)},
+		{"top left corner", image.Rect(1, -1, 3, 1)},
+		{"interior", image.Rect(3, 0, 7, 4)},
+		{"bottom right edge", image.Rect(5, 3, 9, 6)},
+		{"overhanging", image.Rect(-5, -5, 4, 20)},
+		{"disjoint", image.Rect(20, 20, 30, 30)},
+		{"empty", image.Rect(3, 3, 3, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := ii.SumRect(tt.r), bruteSum(tt.r); got != want {
+				t.Errorf("SumRect(%v) = %d, want %d", tt.r, got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkIntegral(b *testing.B) {
+	img := NewGrayS16Image(image.Rect(0, 0, 512, 512))
+	b.SetBytes(int64(len(img.Pix)))
+	for b.Loop() {
+		Integral(img)
+	}
+}
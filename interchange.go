@@ -0,0 +1,125 @@
+package colorext
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// The encoders in this file write formats that practically every viewer
+// opens, with the conversion from the package's types made explicit by the
+// caller rather than left to color models.
+
+// EncodeBMP writes img to w as an uncompressed 8-bit BMP with a gray
+// palette. Use ApplyWindow or EncodeBMPWindow to produce the 8-bit levels
+// from wider data.
+func EncodeBMP(w io.Writer, img *image.Gray) error {
+	r := img.Rect
+	width, height := r.Dx(), r.Dy()
+	if width == 0 || height == 0 {
+		return errors.New("colorext: cannot encode an empty image as BMP")
+	}
+	if int64(width) > math.MaxInt32 || int64(height) > math.MaxInt32 {
+		return errors.New("colorext: image too large for BMP")
+	}
+	const (
+		fileHeader = 14
+		infoHeader = 40
+		palette    = 256 * 4
+	)
+	rowSize := (width + 3) &^ 3
+	pixOffset := fileHeader + infoHeader + palette
+	fileSize := int64(pixOffset) + int64(rowSize)*int64(height)
+	if fileSize > math.MaxUint32 {
+		return errors.New("colorext: image too large for BMP")
+	}
+
+	hdr := make([]byte, pixOffset)
+	copy(hdr, "BM")
+	binary.LittleEndian.PutUint32(hdr[2:], uint32(fileSize))
+	binary.LittleEndian.PutUint32(hdr[10:], uint32(pixOffset))
+	h := hdr[fileHeader:]
+	binary.LittleEndian.PutUint32(h[0:], infoHeader)
+	binary.LittleEndian.PutUint32(h[4:], uint32(width))
+	// A positive height stores rows bottom-up, which every reader accepts.
+	binary.LittleEndian.PutUint32(h[8:], uint32(height))
+	binary.LittleEndian.PutUint16(h[12:], 1) // planes
+	binary.LittleEndian.PutUint16(h[14:], 8) // bits per pixel
+	binary.LittleEndian.PutUint32(h[20:], uint32(rowSize*height))
+	binary.LittleEndian.PutUint32(h[32:], 256) // colors used
+	p := hdr[fileHeader+infoHeader:]
+	for i := 0; i < 256; i++ {
+		p[4*i], p[4*i+1], p[4*i+2] = uint8(i), uint8(i), uint8(i)
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write(hdr)
+	row := make([]byte, rowSize)
+	for y := height - 1; y >= 0; y-- {
+		copy(row, img.Pix[y*img.Stride:y*img.Stride+width])
+		bw.Write(row)
+	}
+	return bw.Flush()
+}
+
+// EncodeBMPWindow writes img to w as an 8-bit BMP after rendering it with
+// ApplyWindow(img, center, width).
+func EncodeBMPWindow(w io.Writer, img image.Image, center, width float64) error {
+	return EncodeBMP(w, ApplyWindow(img, center, width))
+}
+
+// farbfeldMagic starts every farbfeld image.
+const farbfeldMagic = "farbfeld"
+
+// EncodeFarbfeld writes img to w in the farbfeld format: 16-bit
+// non-premultiplied RGBA in big-endian order. GrayS16Image values, including
+// those of masked and physical images, are mapped to gray levels with
+// policy; other images, including a MappedGrayS16Image with its own
+// policy, are converted with color.NRGBA64Model. Invalid
+// pixels of a Validator are written transparent.
+func EncodeFarbfeld(w io.Writer, img image.Image, policy MappingPolicy) error {
+	r := img.Bounds()
+	if int64(r.Dx()) > math.MaxUint32 || int64(r.Dy()) > math.MaxUint32 {
+		return errors.New("colorext: image too large for farbfeld")
+	}
+	var s16 *GrayS16Image
+	switch m := img.(type) {
+	case *GrayS16Image:
+		s16 = m
+	case *MaskedGrayS16Image:
+		s16 = m.GrayS16Image
+	case *PhysicalGrayS16Image:
+		s16 = m.GrayS16Image
+	}
+	valid, hasValidity := img.(Validator)
+
+	bw := bufio.NewWriter(w)
+	var hdr [16]byte
+	copy(hdr[:], farbfeldMagic)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(r.Dx()))
+	binary.BigEndian.PutUint32(hdr[12:], uint32(r.Dy()))
+	bw.Write(hdr[:])
+	px := make([]byte, 8)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var c color.NRGBA64
+			switch {
+			case hasValidity && !valid.Valid(x, y):
+			case s16 != nil:
+				v := policy.Unsigned(s16.GrayS16At(x, y).Y)
+				c = color.NRGBA64{R: v, G: v, B: v, A: 0xffff}
+			default:
+				c = color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			}
+			binary.BigEndian.PutUint16(px[0:], c.R)
+			binary.BigEndian.PutUint16(px[2:], c.G)
+			binary.BigEndian.PutUint16(px[4:], c.B)
+			binary.BigEndian.PutUint16(px[6:], c.A)
+			bw.Write(px)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,128 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeBMP(t *testing.T) {
+	tests := []struct {
+		name string
+		r    image.Rectangle
+	}{
+		{"padded rows", image.Rect(0, 0, 3, 2)},
+		{"aligned rows", image.Rect(-2, 5, 6, 8)},
+		{"single pixel", image.Rect(0, 0, 1, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewGray(tt.r)
+			for i := range img.Pix {
+				img.Pix[i] = uint8(37 * i)
+			}
+			var buf bytes.Buffer
+			if err := EncodeBMP(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			b := buf.Bytes()
+			le := binary.LittleEndian
+			if string(b[:2]) != "BM" || int(le.Uint32(b[2:])) != len(b) {
+				t.Fatalf("bad file header % x", b[:14])
+			}
+			w, h := int(le.Uint32(b[18:])), int(le.Uint32(b[22:]))
+			if w != tt.r.Dx() || h != tt.r.Dy() || le.Uint16(b[28:]) != 8 {
+				t.Fatalf("header %dx%d %d bpp", w, h, le.Uint16(b[28:]))
+			}
+			if p := b[54+4*200:]; p[0] != 200 || p[1] != 200 || p[2] != 200 {
+				t.Errorf("palette entry 200 = % x", p[:4])
+			}
+			pix := b[le.Uint32(b[10:]):]
+			stride := (w + 3) &^ 3
+			for y := 0; y < h; y++ {
+				row := pix[(h-1-y)*stride:]
+				for x := 0; x < w; x++ {
+					if want := img.GrayAt(tt.r.Min.X+x, tt.r.Min.Y+y).Y; row[x] != want {
+						t.Errorf("(%d, %d) = %d, want %d", x, y, row[x], want)
+					}
+				}
+			}
+		})
+	}
+	if err := EncodeBMP(&bytes.Buffer{}, image.NewGray(image.Rectangle{})); err == nil {
+		t.Error("empty image: got nil error")
+	}
+}
+
+func TestEncodeBMPWindow(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	for x, v := range []int16{-1000, 0, 1000} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	var buf bytes.Buffer
+	if err := EncodeBMPWindow(&buf, img, 0, 2000); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	pix := b[binary.LittleEndian.Uint32(b[10:]):]
+	if pix[0] != 0 || pix[1] != 128 || pix[2] != 255 {
+		t.Errorf("windowed levels %v, want [0 128 255]", pix[:3])
+	}
+}
+
+// decodeFarbfeld parses a farbfeld stream into an NRGBA64 image.
+func decodeFarbfeld(t *testing.T, b []byte) *image.NRGBA64 {
+	t.Helper()
+	if string(b[:8]) != farbfeldMagic {
+		t.Fatalf("bad magic %q", b[:8])
+	}
+	w, h := int(binary.BigEndian.Uint32(b[8:])), int(binary.BigEndian.Uint32(b[12:]))
+	if len(b) != 16+8*w*h {
+		t.Fatalf("length %d, want %d", len(b), 16+8*w*h)
+	}
+	img := image.NewNRGBA64(image.Rect(0, 0, w, h))
+	copy(img.Pix, b[16:])
+	return img
+}
+
+func TestEncodeFarbfeld(t *testing.T) {
+	s16 := NewGrayS16Image(image.Rect(4, 4, 6, 5))
+	s16.SetGrayS16(4, 4, GrayS16{Y: -5})
+	s16.SetGrayS16(5, 4, GrayS16{Y: 32767})
+	masked := &MaskedGrayS16Image{GrayS16Image: s16}
+	masked.SetValid(4, 4, false)
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.SetRGBA(0, 0, color.RGBA{R: 0x80, G: 0x40, B: 0, A: 0x80})
+
+	gray := func(v uint16) color.NRGBA64 { return color.NRGBA64{R: v, G: v, B: v, A: 0xffff} }
+	tests := []struct {
+		name   string
+		img    image.Image
+		policy MappingPolicy
+		want   []color.NRGBA64
+	}{
+		{"offset binary", s16, MappingPolicy{}, []color.NRGBA64{gray(32763), gray(0xffff)}},
+		{"clamp", s16, MappingPolicy{Kind: TwosComplementClamp}, []color.NRGBA64{gray(0), gray(0xffff)}},
+		{"masked", masked, MappingPolicy{}, []color.NRGBA64{{}, gray(0xffff)}},
+		{"rgba", rgba, MappingPolicy{}, []color.NRGBA64{{R: 0xffff, G: 0x7fff, B: 0, A: 0x8080}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeFarbfeld(&buf, tt.img, tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			got := decodeFarbfeld(t, buf.Bytes())
+			if got.Rect.Dx() != len(tt.want) {
+				t.Fatalf("width %d, want %d", got.Rect.Dx(), len(tt.want))
+			}
+			for x, want := range tt.want {
+				if c := got.NRGBA64At(x, 0); c != want {
+					t.Errorf("x=%d: %v, want %v", x, c, want)
+				}
+			}
+		})
+	}
+}
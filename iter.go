@@ -0,0 +1,75 @@
+package colorext
+
+import (
+	"image"
+	"iter"
+)
+
+// Pixels returns an iterator over the pixels of p in row-major order,
+// yielding each pixel's coordinates and value.
+func (p *GrayS16Image) Pixels() iter.Seq2[image.Point, GrayS16] {
+	return func(yield func(image.Point, GrayS16) bool) {
+		for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+			row := p.Pix[p.PixOffset(p.Rect.Min.X, y):]
+			for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+				i := 2 * (x - p.Rect.Min.X)
+				c := GrayS16{Y: int16(uint16(row[i])<<8 | uint16(row[i+1]))}
+				if !yield(image.Point{X: x, Y: y}, c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Rows returns an iterator over the rows of p from top to bottom, yielding
+// each row's y coordinate and its values from left to right. The slice is
+// reused between rows; copy it to retain it.
+func (p *GrayS16Image) Rows() iter.Seq2[int, []GrayS16] {
+	return func(yield func(int, []GrayS16) bool) {
+		buf := make([]GrayS16, p.Rect.Dx())
+		for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+			row := p.Pix[p.PixOffset(p.Rect.Min.X, y):]
+			for k := range buf {
+				buf[k] = GrayS16{Y: int16(uint16(row[2*k])<<8 | uint16(row[2*k+1]))}
+			}
+			if !yield(y, buf) {
+				return
+			}
+		}
+	}
+}
+
+// Pixels returns an iterator over the pixels of p in row-major order,
+// yielding each pixel's coordinates and value.
+func (p *GrayF32Image) Pixels() iter.Seq2[image.Point, GrayF32] {
+	return func(yield func(image.Point, GrayF32) bool) {
+		for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+			row := p.Pix[p.PixOffset(p.Rect.Min.X, y):]
+			for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+				c := GrayF32{Y: getF32(row[4*(x-p.Rect.Min.X):])}
+				if !yield(image.Point{X: x, Y: y}, c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Rows returns an iterator over the rows of p from top to bottom, yielding
+// each row's y coordinate and its values from left to right. The slice is
+// reused between rows; copy it to retain it.
+func (p *GrayF32Image) Rows() iter.Seq2[int, []GrayF32] {
+	return func(yield func(int, []GrayF32) bool) {
+		buf := make([]GrayF32, p.Rect.Dx())
+		for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+			row := p.Pix[p.PixOffset(p.Rect.Min.X, y):]
+			for k := range buf {
+				buf[k] = GrayF32{Y: getF32(row[4*k:])}
+			}
+			if !yield(y, buf) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGrayS16Image_Pixels(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(-1, 2, 2, 4))
+	v := int16(-3)
+	for y := 2; y < 4; y++ {
+		for x := -1; x < 2; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: v})
+			v++
+		}
+	}
+
+	var pts []image.Point
+	want := int16(-3)
+	for pt, c := range img.Pixels() {
+		if got := img.GrayS16At(pt.X, pt.Y); c != got {
+			t.Errorf("Pixels yielded %v at %v, GrayS16At = %v", c, pt, got)
+		}
+		if c.Y != want {
+			t.Errorf("Pixels out of order: got %d, want %d", c.Y, want)
+		}
+		want++
+		pts = append(pts, pt)
+	}
+	if len(pts) != 6 || pts[0] != image.Pt(-1, 2) || pts[5] != image.Pt(1, 3) {
+		t.Errorf("Pixels visited %v", pts)
+	}
+
+	// Breaking out of the loop stops the iteration.
+	n := 0
+	for range img.Pixels() {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("iterated %d pixels after break, want 2", n)
+	}
+
+	// A sub-image iterates only its own pixels.
+	sub := img.SubImage(image.Rect(0, 3, 2, 4)).(*GrayS16Image)
+	n = 0
+	for pt := range sub.Pixels() {
+		if !pt.In(sub.Rect) {
+			t.Errorf("sub-image yielded %v outside %v", pt, sub.Rect)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("sub-image yielded %d pixels, want 2", n)
+	}
+}
+
+func TestGrayS16Image_Rows(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 5, 3, 7))
+	img.SetGrayS16(2, 5, GrayS16{Y: 7})
+	img.SetGrayS16(0, 6, GrayS16{Y: -9})
+
+	var ys []int
+	for y, row := range img.Rows() {
+		ys = append(ys, y)
+		if len(row) != 3 {
+			t.Fatalf("row %d has %d values, want 3", y, len(row))
+		}
+		for i, c := range row {
+			if want := img.GrayS16At(i, y); c != want {
+				t.Errorf("row %d[%d] = %v, want %v", y, i, c, want)
+			}
+		}
+	}
+	if len(ys) != 2 || ys[0] != 5 || ys[1] != 6 {
+		t.Errorf("Rows visited %v, want [5 6]", ys)
+	}
+}
+
+func TestGrayF32Image_Pixels(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(3, 3, 5, 5))
+	img.SetGrayF32(4, 3, GrayF32{Y: 1.5})
+	img.SetGrayF32(3, 4, GrayF32{Y: -2})
+
+	n := 0
+	for pt, c := range img.Pixels() {
+		if want := img.GrayF32At(pt.X, pt.Y); c != want {
+			t.Errorf("Pixels yielded %v at %v, want %v", c, pt, want)
+		}
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Pixels yielded %d pixels, want 4", n)
+	}
+
+	for y, row := range img.Rows() {
+		for i, c := range row {
+			if want := img.GrayF32At(3+i, y); c != want {
+				t.Errorf("row %d[%d] = %v, want %v", y, i, c, want)
+			}
+		}
+	}
+}
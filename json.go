@@ -0,0 +1,411 @@
+package colorext
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// jsonImage is the JSON form of a single-plane image. Data holds the rows in
+// big-endian order, Stride bytes apart, and is encoded as base64. Palette
+// holds the colors of a Paletted16Image as alpha-premultiplied 16-bit
+// [R, G, B, A] values.
+type jsonImage struct {
+	Bounds  image.Rectangle `json:"bounds"`
+	Stride  int             `json:"stride"`
+	DType   string          `json:"dtype"`
+	Data    []byte          `json:"data"`
+	Palette [][4]uint16     `json:"palette,omitempty"`
+}
+
+// jsonPix returns the JSON form of an image of type t with its rows
+// compacted.
+func jsonPix(t pixelType, r image.Rectangle, pix []uint8, stride int) jsonImage {
+	n := t.rowBytes(r.Dx())
+	data := make([]byte, 0, n*r.Dy())
+	for y := 0; y < r.Dy(); y++ {
+		data = append(data, pix[y*stride:y*stride+n]...)
+	}
+	return jsonImage{Bounds: r, Stride: n, DType: t.String(), Data: data}
+}
+
+// marshalJSONPix encodes an image of type t with its rows compacted.
+func marshalJSONPix(t pixelType, r image.Rectangle, pix []uint8, stride int) ([]byte, error) {
+	return json.Marshal(jsonPix(t, r, pix, stride))
+}
+
+// unmarshalJSONPix decodes an image of type t encoded by marshalJSONPix. The
+// bounds, pixels and stride of the result are validated, and all zero for
+// an empty image.
+func unmarshalJSONPix(t pixelType, b []byte) (jsonImage, error) {
+	var j jsonImage
+	if err := json.Unmarshal(b, &j); err != nil {
+		return jsonImage{}, err
+	}
+	if j.DType != t.String() {
+		return jsonImage{}, fmt.Errorf("%w: JSON image holds %q pixels, want %q", ErrUnsupportedDType, j.DType, t.String())
+	}
+	r := j.Bounds
+	if r.Empty() {
+		return jsonImage{Palette: j.Palette}, nil
+	}
+	// Validate the row width before the stride so the products below cannot
+	// overflow. Every pixel takes between one and 16 bytes.
+	if uint64(r.Dx()) > uint64(len(j.Data)) || r.Dx() > math.MaxInt/16 {
+		return jsonImage{}, fmt.Errorf("%w: %d pixel bytes do not match bounds %v", ErrInvalidLayout, len(j.Data), r)
+	}
+	n := t.rowBytes(r.Dx())
+	if n > len(j.Data) {
+		return jsonImage{}, fmt.Errorf("%w: %d pixel bytes do not match bounds %v", ErrInvalidLayout, len(j.Data), r)
+	}
+	if j.Stride < n {
+		return jsonImage{}, fmt.Errorf("%w: stride %d is shorter than a row of %d bytes", ErrStrideMismatch, j.Stride, n)
+	}
+	if uint64(r.Dy()-1) > uint64(len(j.Data)-n)/uint64(j.Stride) {
+		return jsonImage{}, fmt.Errorf("%w: %d pixel bytes with stride %d do not match bounds %v", ErrInvalidLayout, len(j.Data), j.Stride, r)
+	}
+	return j, nil
+}
+
+// MarshalJSON implements json.Marshaler. The image is encoded as an object
+// holding its bounds, stride, pixel type and big-endian pixels in base64.
+//
+// The other gray, color, packed and paletted images encode the same way.
+// The planar YCbCr48Image, NV12Image and I420Image, the BayerImage, whose
+// samples mean nothing without its Pattern and Depth, and the atomic images,
+// which have no pixel buffer, do not implement json.Marshaler or
+// encoding.BinaryMarshaler; convert them or take a Snapshot first.
+func (p *GrayS16Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeInt16, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayS16Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeInt16, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayF32Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeFloat32, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayF32Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeFloat32, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayU32Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeUint32, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayU32Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeUint32, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayU64Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeUint64, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayU64Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeUint64, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayS64Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeInt64, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayS64Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeInt64, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayC64Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeComplex64, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayC64Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeComplex64, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *GrayC128Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(DTypeComplex128, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *GrayC128Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(DTypeComplex128, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *BGRImage) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeBGR, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *BGRImage) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeBGR, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *BGRAImage) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeBGRA, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *BGRAImage) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeBGRA, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *BGR48Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeBGR48, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *BGR48Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeBGR48, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *BGRA64Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeBGRA64, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *BGRA64Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeBGRA64, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *RGB565Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeRGB565, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *RGB565Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeRGB565, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *RGB555Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeRGB555, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *RGB555Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeRGB555, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *CMYK64Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeCMYK64, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *CMYK64Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeCMYK64, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *RGBAF32Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeRGBAF32, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *RGBAF32Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeRGBAF32, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *NRGBAF32Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeNRGBAF32, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *NRGBAF32Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeNRGBAF32, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON.
+func (p *LinearRGBAF32Image) MarshalJSON() ([]byte, error) {
+	return marshalJSONPix(typeLinearRGBAF32, p.Rect, p.Pix, p.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *LinearRGBAF32Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeLinearRGBAF32, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON. The
+// rows are realigned to start on a group boundary if needed.
+func (p *Gray10PackedImage) MarshalJSON() ([]byte, error) {
+	q := p.aligned()
+	return marshalJSONPix(typeGray10Packed, q.Rect, q.Pix, q.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *Gray10PackedImage) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeGray10Packed, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect, p.Offset = j.Data, j.Stride, j.Bounds, 0
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON. The
+// rows are realigned to start on a group boundary if needed.
+func (p *Gray12PackedImage) MarshalJSON() ([]byte, error) {
+	q := p.aligned()
+	return marshalJSONPix(typeGray12Packed, q.Rect, q.Pix, q.Stride)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds and
+// pixels.
+func (p *Gray12PackedImage) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typeGray12Packed, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect, p.Offset = j.Data, j.Stride, j.Bounds, 0
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler like GrayS16Image.MarshalJSON,
+// adding the palette.
+func (p *Paletted16Image) MarshalJSON() ([]byte, error) {
+	j := jsonPix(typePaletted16, p.Rect, p.Pix, p.Stride)
+	for _, c := range p.Palette {
+		r, g, b, a := c.RGBA()
+		j.Palette = append(j.Palette, [4]uint16{uint16(r), uint16(g), uint16(b), uint16(a)})
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing p's bounds, pixels
+// and palette. The palette colors are color.RGBA64 values.
+func (p *Paletted16Image) UnmarshalJSON(b []byte) error {
+	j, err := unmarshalJSONPix(typePaletted16, b)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = j.Data, j.Stride, j.Bounds
+	p.Palette = make(color.Palette, len(j.Palette))
+	for i, c := range j.Palette {
+		p.Palette[i] = color.RGBA64{R: c[0], G: c[1], B: c[2], A: c[3]}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestGrayS16ImageJSON(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	img.SetGrayS16(0, 0, GrayS16{Y: -2})
+	img.SetGrayS16(1, 0, GrayS16{Y: 0x0102})
+	b, err := json.Marshal(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"bounds":{"Min":{"X":0,"Y":0},"Max":{"X":2,"Y":1}},"stride":4,"dtype":"int16","data":"//4BAg=="}`
+	if string(b) != want {
+		t.Errorf("JSON = %s, want %s", b, want)
+	}
+
+	src := randomGrayS16(image.Rect(-3, 2, 10, 9), 4)
+	for _, img := range []*GrayS16Image{
+		src,
+		src.SubImage(image.Rect(0, 3, 7, 8)).(*GrayS16Image),
+		NewGrayS16Image(image.Rectangle{}),
+	} {
+		b, err := json.Marshal(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got GrayS16Image
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%v: %v", img.Rect, err)
+		}
+		if got.Rect != img.Rect || !Equal(&got, img) {
+			t.Errorf("%v: round trip mismatch, got bounds %v", img.Rect, got.Rect)
+		}
+	}
+}
+
+func TestJSONRoundTripTypes(t *testing.T) {
+	r := image.Rect(1, -2, 6, 3)
+	rng := rand.New(rand.NewSource(8))
+	tests := []struct {
+		name string
+		img  image.Image
+		dst  image.Image
+	}{
+		{"F32", NewGrayF32Image(r), new(GrayF32Image)},
+		{"U32", NewGrayU32Image(r), new(GrayU32Image)},
+		{"U64", NewGrayU64Image(r), new(GrayU64Image)},
+		{"S64", NewGrayS64Image(r), new(GrayS64Image)},
+		{"C64", NewGrayC64Image(r), new(GrayC64Image)},
+		{"C128", NewGrayC128Image(r), new(GrayC128Image)},
+	}
+	for _, tt := range tests {
+		pix, _, _, _ := pixelLayout(tt.img)
+		rng.Read(pix)
+		b, err := json.Marshal(tt.img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, tt.dst); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		gotPix, _, _, _ := pixelLayout(tt.dst)
+		if tt.dst.Bounds() != r || !bytes.Equal(gotPix, pix) {
+			t.Errorf("%s: round trip mismatch", tt.name)
+		}
+	}
+}
+
+func TestGrayS16ImageJSONStride(t *testing.T) {
+	// Rows may be padded as long as the last row is complete.
+	in := `{"bounds":{"Min":{"X":0,"Y":0},"Max":{"X":1,"Y":2}},"stride":4,"dtype":"int16","data":"AAEAAAAC"}`
+	var img GrayS16Image
+	if err := json.Unmarshal([]byte(in), &img); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := img.GrayS16At(0, 0).Y, img.GrayS16At(0, 1).Y; a != 1 || b != 2 {
+		t.Errorf("pixels = %d, %d, want 1, 2", a, b)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"syntax", `{`},
+		{"dtype", `{"bounds":{"Max":{"X":1,"Y":1}},"stride":4,"dtype":"float32","data":"AAAAAA=="}`},
+		{"short", `{"bounds":{"Max":{"X":2,"Y":2}},"stride":4,"dtype":"int16","data":"AAAAAAAA"}`},
+		{"narrow stride", `{"bounds":{"Max":{"X":2,"Y":1}},"stride":2,"dtype":"int16","data":"AAAAAA=="}`},
+		{"huge", `{"bounds":{"Max":{"X":2147483647,"Y":2}},"stride":4,"dtype":"int16","data":"AAAAAA=="}`},
+	}
+	for _, tt := range tests {
+		var img GrayS16Image
+		err := json.Unmarshal([]byte(tt.in), &img)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		} else if tt.name != "syntax" && !strings.HasPrefix(err.Error(), "colorext: ") {
+			t.Errorf("%s: error %q lacks the package prefix", tt.name, err)
+		}
+	}
+}
+
+func TestJSONRoundTripColorTypes(t *testing.T) {
+	for _, tt := range colorImages() {
+		sub := tt.img.(interface {
+			SubImage(image.Rectangle) image.Image
+		})
+		for _, img := range []image.Image{tt.img, sub.SubImage(image.Rect(0, 3, 5, 5)), sub.SubImage(image.Rectangle{})} {
+			b, err := json.Marshal(img)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(b, tt.dst); err != nil {
+				t.Fatalf("%T %v: %v", img, img.Bounds(), err)
+			}
+			if tt.dst.Bounds() != img.Bounds() || !Equal(tt.dst, img) {
+				t.Errorf("%T %v: round trip mismatch", img, img.Bounds())
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// The conversions in this file work on whole rows, eight bytes at a time
+// where possible, and are much faster than converting pixel by pixel through
+// color models. Signed and unsigned values are related by the OffsetBinary
+// mapping, which flips the sign bit, so they agree exactly with GrayS16's
+// RGBA method.
+
+// GrayS16ToGray16 converts src to an *image.Gray16 by adding 32768 to each
+// value.
+func GrayS16ToGray16(src *GrayS16Image) *image.Gray16 {
+	dst := image.NewGray16(src.Rect)
+	convertRows(dst.Pix, dst.Stride, src.Pix, src.Stride, 2*src.Rect.Dx(), src.Rect.Dy(), flipSign16)
+	return dst
+}
+
+// Gray16ToGrayS16 converts src to a GrayS16Image by subtracting 32768 from
+// each value.
+func Gray16ToGrayS16(src *image.Gray16) *GrayS16Image {
+	dst := NewGrayS16Image(src.Rect)
+	convertRows(dst.Pix, dst.Stride, src.Pix, src.Stride, 2*src.Rect.Dx(), src.Rect.Dy(), flipSign16)
+	return dst
+}
+
+// GrayS16ToGray converts src to an *image.Gray, keeping the high byte of
+// each offset value. This truncates, as color.GrayModel does.
+func GrayS16ToGray(src *GrayS16Image) *image.Gray {
+	dst := image.NewGray(src.Rect)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < h; y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+2*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+		x := 0
+		for ; x+8 <= w; x += 8 {
+			s := s[2*x : 2*x+16 : 2*x+16]
+			d := d[x : x+8 : x+8]
+			d[0], d[1], d[2], d[3] = s[0]^0x80, s[2]^0x80, s[4]^0x80, s[6]^0x80
+			d[4], d[5], d[6], d[7] = s[8]^0x80, s[10]^0x80, s[12]^0x80, s[14]^0x80
+		}
+		for ; x < w; x++ {
+			d[x] = s[2*x] ^ 0x80
+		}
+	}
+	return dst
+}
+
+// GrayToGrayS16 converts src to a GrayS16Image, replicating each 8-bit value
+// into both bytes (v<<8 | v) before subtracting 32768, so that white maps to
+// 32767 rather than 32512.
+func GrayToGrayS16(src *image.Gray) *GrayS16Image {
+	dst := NewGrayS16Image(src.Rect)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < h; y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+2*w]
+		for x, v := range s {
+			d := d[2*x : 2*x+2 : 2*x+2]
+			d[0], d[1] = v^0x80, v
+		}
+	}
+	return dst
+}
+
+// SwapBytes16 reverses the byte order of each 16-bit value in b in place.
+// A trailing odd byte is left unchanged.
+func SwapBytes16(b []uint8) {
+	i := 0
+	for ; i+8 <= len(b); i += 8 {
+		v := binary.LittleEndian.Uint64(b[i:])
+		v = (v&0x00ff00ff00ff00ff)<<8 | (v>>8)&0x00ff00ff00ff00ff
+		binary.LittleEndian.PutUint64(b[i:], v)
+	}
+	for ; i+2 <= len(b); i += 2 {
+		b[i], b[i+1] = b[i+1], b[i]
+	}
+}
+
+// SwapBytes32 reverses the byte order of each 32-bit value in b in place.
+// Trailing bytes not forming a whole value are left unchanged.
+func SwapBytes32(b []uint8) {
+	i := 0
+	for ; i+8 <= len(b); i += 8 {
+		v := binary.LittleEndian.Uint64(b[i:])
+		v = (v&0x00ff00ff00ff00ff)<<8 | (v>>8)&0x00ff00ff00ff00ff
+		v = (v&0x0000ffff0000ffff)<<16 | (v>>16)&0x0000ffff0000ffff
+		binary.LittleEndian.PutUint64(b[i:], v)
+	}
+	for ; i+4 <= len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+}
+
+// flipSign16 copies big-endian 16-bit values from src to dst, flipping the
+// sign bit of each.
+func flipSign16(dst, src []uint8) {
+	const mask = 0x0080008000800080 // the high byte of each value
+	i := 0
+	for ; i+8 <= len(src); i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], binary.LittleEndian.Uint64(src[i:])^mask)
+	}
+	for ; i < len(src); i += 2 {
+		dst[i], dst[i+1] = src[i]^0x80, src[i+1]
+	}
+}
+
+// convertRows applies kernel to h rows of n bytes, handling both buffers as
+// a single row when they are contiguous.
+func convertRows(dst []uint8, dstStride int, src []uint8, srcStride int, n, h int, kernel func(dst, src []uint8)) {
+	if h == 0 || n == 0 {
+		return
+	}
+	if dstStride == n && srcStride == n {
+		kernel(dst[:n*h], src[:n*h])
+		return
+	}
+	for y := 0; y < h; y++ {
+		kernel(dst[y*dstStride:y*dstStride+n], src[y*srcStride:y*srcStride+n])
+	}
+}
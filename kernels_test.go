@@ -0,0 +1,160 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// randomGrayS16 returns a GrayS16Image with random pixels. Widths that are
+// not multiples of four exercise the scalar tails of the kernels.
+func randomGrayS16(r image.Rectangle, seed int64) *GrayS16Image {
+	img := NewGrayS16Image(r)
+	rand.New(rand.NewSource(seed)).Read(img.Pix)
+	return img
+}
+
+func TestGrayS16ToGray16(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 13, 5), image.Rect(-3, 2, 5, 4), {}} {
+		src := randomGrayS16(r, 7)
+		// A sub-image has a stride wider than its rows.
+		sub := src.SubImage(image.Rect(r.Min.X+1, r.Min.Y, r.Max.X, r.Max.Y)).(*GrayS16Image)
+		for _, s := range []*GrayS16Image{src, sub} {
+			dst := GrayS16ToGray16(s)
+			if dst.Rect != s.Rect {
+				t.Fatalf("bounds = %v, want %v", dst.Rect, s.Rect)
+			}
+			for y := s.Rect.Min.Y; y < s.Rect.Max.Y; y++ {
+				for x := s.Rect.Min.X; x < s.Rect.Max.X; x++ {
+					want := color.Gray16Model.Convert(s.At(x, y)).(color.Gray16)
+					if got := dst.Gray16At(x, y); got != want {
+						t.Fatalf("%v at (%d, %d) = %v, want %v", s.Rect, x, y, got, want)
+					}
+				}
+			}
+			if back := Gray16ToGrayS16(dst); !Equal(back, s) {
+				t.Errorf("%v: Gray16ToGrayS16 does not invert GrayS16ToGray16", s.Rect)
+			}
+		}
+	}
+}
+
+func TestGrayS16ToGray(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 19, 3), 8)
+	sub := src.SubImage(image.Rect(2, 1, 19, 3)).(*GrayS16Image)
+	for _, s := range []*GrayS16Image{src, sub} {
+		dst := GrayS16ToGray(s)
+		for y := s.Rect.Min.Y; y < s.Rect.Max.Y; y++ {
+			for x := s.Rect.Min.X; x < s.Rect.Max.X; x++ {
+				want := color.GrayModel.Convert(s.At(x, y)).(color.Gray)
+				if got := dst.GrayAt(x, y); got != want {
+					t.Fatalf("at (%d, %d) = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGrayToGrayS16(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 256, 1))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+	dst := GrayToGrayS16(src)
+	for x := 0; x < 256; x++ {
+		want := GrayS16Model.Convert(src.At(x, 0)).(GrayS16)
+		if got := dst.GrayS16At(x, 0); got != want {
+			t.Fatalf("at %d = %v, want %v", x, got, want)
+		}
+	}
+	if got := dst.GrayS16At(255, 0).Y; got != 32767 {
+		t.Errorf("white = %d, want 32767", got)
+	}
+	if back := GrayS16ToGray(dst); !Equal(back, src) {
+		t.Error("GrayS16ToGray does not invert GrayToGrayS16")
+	}
+}
+
+func TestSwapBytes(t *testing.T) {
+	b := []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
+	SwapBytes16(b)
+	want16 := []uint8{2, 1, 4, 3, 6, 5, 8, 7, 10, 9, 12, 11, 13}
+	for i := range b {
+		if b[i] != want16[i] {
+			t.Fatalf("SwapBytes16 = %v, want %v", b, want16)
+		}
+	}
+
+	b = []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+	SwapBytes32(b)
+	want32 := []uint8{4, 3, 2, 1, 8, 7, 6, 5, 12, 11, 10, 9, 13, 14}
+	for i := range b {
+		if b[i] != want32[i] {
+			t.Fatalf("SwapBytes32 = %v, want %v", b, want32)
+		}
+	}
+}
+
+func BenchmarkGrayS16ToGray16(b *testing.B) {
+	src := randomGrayS16(image.Rect(0, 0, 1920, 1080), 9)
+	b.Run("Kernel", func(b *testing.B) {
+		b.SetBytes(int64(len(src.Pix)))
+		for b.Loop() {
+			GrayS16ToGray16(src)
+		}
+	})
+	b.Run("PerPixel", func(b *testing.B) {
+		b.SetBytes(int64(len(src.Pix)))
+		for b.Loop() {
+			dst := image.NewGray16(src.Rect)
+			for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+				for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+					dst.Set(x, y, src.At(x, y))
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkGrayS16ToGray(b *testing.B) {
+	src := randomGrayS16(image.Rect(0, 0, 1920, 1080), 10)
+	b.SetBytes(int64(len(src.Pix)))
+	for b.Loop() {
+		GrayS16ToGray(src)
+	}
+}
+
+func BenchmarkSwapBytes16(b *testing.B) {
+	buf := make([]uint8, 1920*1080*2)
+	b.SetBytes(int64(len(buf)))
+	for b.Loop() {
+		SwapBytes16(buf)
+	}
+}
+
+func BenchmarkGrayS16Image_GrayS16At(b *testing.B) {
+	img := NewGrayS16Image(image.Rect(0, 0, 512, 512))
+	b.SetBytes(int64(len(img.Pix)))
+	for b.Loop() {
+		var sum int
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				sum += int(img.GrayS16At(x, y).Y)
+			}
+		}
+		_ = sum
+	}
+}
+
+func BenchmarkGrayS16Image_SetGrayS16(b *testing.B) {
+	img := NewGrayS16Image(image.Rect(0, 0, 512, 512))
+	b.SetBytes(int64(len(img.Pix)))
+	for b.Loop() {
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				img.SetGrayS16(x, y, GrayS16{Y: int16(x ^ y)})
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+)
+
+// CIE constants used by the Lab and Luv transforms.
+const (
+	cieEpsilon = 216.0 / 24389.0
+	cieKappa   = 24389.0 / 27.0
+)
+
+// Lab represents a color in the CIE 1976 L*a*b* color space, relative to the
+// D65 white point. L ranges from 0 (black) to 100 (white); A and B are
+// unbounded opponent axes, roughly within [-128, 127] for sRGB colors.
+type Lab struct {
+	L, A, B float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the Lab color.
+// This implements the color.Color interface.
+func (c Lab) RGBA() (r, g, b, a uint32) {
+	return LabToXYZ(c, D65).RGBA()
+}
+
+// LCh converts c to cylindrical lightness, chroma and hue coordinates.
+func (c Lab) LCh() LCh {
+	h := math.Atan2(c.B, c.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return LCh{L: c.L, C: math.Hypot(c.A, c.B), H: h}
+}
+
+// LabModel is the color model for CIE L*a*b* colors relative to D65.
+var LabModel color.Model = color.ModelFunc(labModel)
+
+// labModel converts any color.Color to a Lab.
+func labModel(c color.Color) color.Color {
+	switch c := c.(type) {
+	case Lab:
+		return c
+	case LCh:
+		return c.Lab()
+	}
+	return XYZToLab(toXYZ(c), D65)
+}
+
+// XYZToLab converts c to L*a*b* relative to the given reference white.
+func XYZToLab(c XYZ, white WhitePoint) Lab {
+	fx := labF(c.X / white.X)
+	fy := labF(c.Y / white.Y)
+	fz := labF(c.Z / white.Z)
+	return Lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// LabToXYZ converts c to XYZ relative to the given reference white.
+func LabToXYZ(c Lab, white WhitePoint) XYZ {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+
+	var yr float64
+	if c.L > cieKappa*cieEpsilon {
+		yr = fy * fy * fy
+	} else {
+		yr = c.L / cieKappa
+	}
+	return XYZ{X: labFInv(fx) * white.X, Y: yr * white.Y, Z: labFInv(fz) * white.Z}
+}
+
+func labF(t float64) float64 {
+	if t > cieEpsilon {
+		return math.Cbrt(t)
+	}
+	return (cieKappa*t + 16) / 116
+}
+
+func labFInv(f float64) float64 {
+	if f3 := f * f * f; f3 > cieEpsilon {
+		return f3
+	}
+	return (116*f - 16) / cieKappa
+}
+
+// LCh represents a CIE L*a*b* color in cylindrical coordinates: lightness L,
+// chroma C and hue angle H in degrees in [0, 360).
+type LCh struct {
+	L, C, H float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the LCh color.
+// This implements the color.Color interface.
+func (c LCh) RGBA() (r, g, b, a uint32) {
+	return c.Lab().RGBA()
+}
+
+// Lab converts c to rectangular L*a*b* coordinates.
+func (c LCh) Lab() Lab {
+	s, co := math.Sincos(c.H * math.Pi / 180)
+	return Lab{L: c.L, A: c.C * co, B: c.C * s}
+}
+
+// LChModel is the color model for CIE LCh(ab) colors relative to D65.
+var LChModel color.Model = color.ModelFunc(lchModel)
+
+// lchModel converts any color.Color to an LCh.
+func lchModel(c color.Color) color.Color {
+	if c, ok := c.(LCh); ok {
+		return c
+	}
+	return LabModel.Convert(c).(Lab).LCh()
+}
+
+// DeltaE76 returns the CIE 1976 color difference between a and b, the
+// Euclidean distance in L*a*b*.
+func DeltaE76(a, b Lab) float64 {
+	dl, da, db := a.L-b.L, a.A-b.A, a.B-b.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// DeltaE94 returns the CIE 1994 color difference between a reference color
+// and a sample, using the graphic arts weighting factors.
+func DeltaE94(ref, sample Lab) float64 {
+	const kL, k1, k2 = 1, 0.045, 0.015
+	dl := ref.L - sample.L
+	c1 := math.Hypot(ref.A, ref.B)
+	c2 := math.Hypot(sample.A, sample.B)
+	dc := c1 - c2
+	da, db := ref.A-sample.A, ref.B-sample.B
+	dh2 := max(da*da+db*db-dc*dc, 0)
+	sc := 1 + k1*c1
+	sh := 1 + k2*c1
+	return math.Sqrt((dl/kL)*(dl/kL) + (dc/sc)*(dc/sc) + dh2/(sh*sh))
+}
+
+// DeltaE2000 returns the CIEDE2000 color difference between a and b with
+// unit weighting factors.
+func DeltaE2000(a, b Lab) float64 {
+	const pow25to7 = 6103515625.0 // 25^7
+	rad := math.Pi / 180
+
+	c1 := math.Hypot(a.A, a.B)
+	c2 := math.Hypot(b.A, b.B)
+	cbar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cbar7/(cbar7+pow25to7)))
+	a1p, a2p := (1+g)*a.A, (1+g)*b.A
+	c1p, c2p := math.Hypot(a1p, a.B), math.Hypot(a2p, b.B)
+	h1p, h2p := hueDegrees(a.B, a1p), hueDegrees(b.B, a2p)
+
+	dLp := b.L - a.L
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(dhp/2*rad)
+
+	lbp := (a.L + b.L) / 2
+	cbp := (c1p + c2p) / 2
+	hbp := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hbp /= 2
+		case hbp < 360:
+			hbp = (hbp + 360) / 2
+		default:
+			hbp = (hbp - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos((hbp-30)*rad) + 0.24*math.Cos(2*hbp*rad) +
+		0.32*math.Cos((3*hbp+6)*rad) - 0.20*math.Cos((4*hbp-63)*rad)
+	dTheta := 30 * math.Exp(-((hbp-275)/25)*((hbp-275)/25))
+	cbp7 := math.Pow(cbp, 7)
+	rc := 2 * math.Sqrt(cbp7/(cbp7+pow25to7))
+	l50 := (lbp - 50) * (lbp - 50)
+	sl := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sc := 1 + 0.045*cbp
+	sh := 1 + 0.015*cbp*t
+	rt := -math.Sin(2*dTheta*rad) * rc
+
+	dl, dc, dh := dLp/sl, dCp/sc, dHp/sh
+	return math.Sqrt(dl*dl + dc*dc + dh*dh + rt*dc*dh)
+}
+
+// hueDegrees returns atan2(b, a) in degrees in [0, 360), or 0 if both are 0.
+func hueDegrees(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
@@ -0,0 +1,127 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLabModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  Lab
+	}{
+		{"white", color.White, Lab{100, 0, 0}},
+		{"black", color.Black, Lab{0, 0, 0}},
+		{"red", color.RGBA{255, 0, 0, 255}, Lab{53.2408, 80.0925, 67.2032}},
+		{"blue", color.RGBA{0, 0, 255, 255}, Lab{32.2970, 79.1875, -107.8602}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LabModel.Convert(tt.input).(Lab)
+			if DeltaE76(got, tt.want) > 0.01 {
+				t.Errorf("LabModel.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	original := Lab{50, -10, 20}
+	if got := LabModel.Convert(original); got != original {
+		t.Errorf("LabModel.Convert(%v) = %v, want unchanged", original, got)
+	}
+}
+
+func TestLab_RoundTrip(t *testing.T) {
+	for _, c := range []color.RGBA64{
+		{0x1234, 0x5678, 0x9abc, 0xffff}, {0xffff, 0x8000, 0, 0xffff}, {5, 5, 5, 0xffff},
+	} {
+		lab := LabModel.Convert(c).(Lab)
+		r, g, b, _ := lab.RGBA()
+		if !approxEqual(float64(r), float64(c.R), 1) || !approxEqual(float64(g), float64(c.G), 1) ||
+			!approxEqual(float64(b), float64(c.B), 1) {
+			t.Errorf("%v round-tripped through Lab to (%d, %d, %d)", c, r, g, b)
+		}
+
+		lch := LChModel.Convert(c).(LCh)
+		back := lch.Lab()
+		if DeltaE76(back, lab) > 1e-9 {
+			t.Errorf("Lab %+v -> LCh %+v -> Lab %+v", lab, lch, back)
+		}
+	}
+}
+
+func TestLab_D50(t *testing.T) {
+	// The reference white maps to L = 100 regardless of illuminant.
+	if got := XYZToLab(D50.XYZ(), D50); DeltaE76(got, Lab{100, 0, 0}) > 1e-9 {
+		t.Errorf("XYZToLab(D50, D50) = %+v, want {100 0 0}", got)
+	}
+	xyz := XYZ{0.3, 0.2, 0.1}
+	if got := LabToXYZ(XYZToLab(xyz, D50), D50); !approxEqual(got.X, xyz.X, 1e-12) ||
+		!approxEqual(got.Z, xyz.Z, 1e-12) {
+		t.Errorf("D50 Lab round trip = %+v, want %+v", got, xyz)
+	}
+	// Dark colors use the linear segment of the transfer function.
+	dark := XYZ{0.001, 0.001, 0.001}
+	if got := LabToXYZ(XYZToLab(dark, D65), D65); !approxEqual(got.Y, dark.Y, 1e-12) {
+		t.Errorf("dark Lab round trip = %+v, want %+v", got, dark)
+	}
+}
+
+func TestLCh(t *testing.T) {
+	got := Lab{50, 0, -10}.LCh()
+	if got.C != 10 || got.H != 270 {
+		t.Errorf("Lab{50 0 -10}.LCh() = %+v, want {50 10 270}", got)
+	}
+	if r, g, b, _ := (LCh{L: 100}).RGBA(); r < 0xfffe || g < 0xfffe || b < 0xfffe {
+		t.Errorf("LCh white RGBA = (%d, %d, %d)", r, g, b)
+	}
+	original := LCh{1, 2, 3}
+	if got := LChModel.Convert(original); got != original {
+		t.Errorf("LChModel.Convert(%v) = %v, want unchanged", original, got)
+	}
+}
+
+func TestDeltaE(t *testing.T) {
+	a, b := Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}
+	if got := DeltaE76(a, b); !approxEqual(got, math.Hypot(2.6772, 2.9734), 1e-9) {
+		t.Errorf("DeltaE76 = %v", got)
+	}
+	if got := DeltaE76(a, a); got != 0 {
+		t.Errorf("DeltaE76(a, a) = %v, want 0", got)
+	}
+	if got := DeltaE94(a, a); got != 0 {
+		t.Errorf("DeltaE94(a, a) = %v, want 0", got)
+	}
+	// Lightness differences are unweighted in CIE94.
+	if got := DeltaE94(Lab{50, 0, 0}, Lab{60, 0, 0}); !approxEqual(got, 10, 1e-9) {
+		t.Errorf("DeltaE94 lightness only = %v, want 10", got)
+	}
+	// Chroma differences are discounted for saturated references.
+	if got := DeltaE94(Lab{50, 100, 0}, Lab{50, 90, 0}); !approxEqual(got, 10/5.5, 1e-9) {
+		t.Errorf("DeltaE94 chroma only = %v, want %v", got, 10/5.5)
+	}
+}
+
+func TestDeltaE2000(t *testing.T) {
+	// Reference pairs from Sharma, Wu and Dalal (2005).
+	tests := []struct {
+		a, b Lab
+		want float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 3.1571, -77.2803}, Lab{50, 0, -82.7485}, 2.8615},
+		{Lab{50, 0, 0}, Lab{50, -1, 2}, 2.3669},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+	}
+
+	for _, tt := range tests {
+		if got := DeltaE2000(tt.a, tt.b); !approxEqual(got, tt.want, 1e-4) {
+			t.Errorf("DeltaE2000(%+v, %+v) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+		if got := DeltaE2000(tt.b, tt.a); !approxEqual(got, tt.want, 1e-4) {
+			t.Errorf("DeltaE2000 is not symmetric for %+v, %+v: %.4f", tt.a, tt.b, got)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// LinearRGBF32 represents a fully opaque color in linear-light sRGB, with
+// nominal component range [0, 1]. Unlike color.RGBA, the components are
+// proportional to physical light intensity, so averaging and filtering them
+// produces correct results.
+type LinearRGBF32 struct {
+	R, G, B float32
+}
+
+// RGBA returns the red, green, blue and alpha components of the LinearRGBF32
+// color. This implements the color.Color interface.
+// Each component is clamped to [0, 1] and encoded with the sRGB transfer
+// function.
+func (c LinearRGBF32) RGBA() (r, g, b, a uint32) {
+	r = unit16(linearToSRGB(clamp01(float64(c.R))))
+	g = unit16(linearToSRGB(clamp01(float64(c.G))))
+	b = unit16(linearToSRGB(clamp01(float64(c.B))))
+	return r, g, b, 0xffff
+}
+
+// LinearRGBF32Model is the color model for linear-light sRGB colors.
+// Conversion decodes the sRGB transfer function. Colors with an XYZ method,
+// such as DisplayP3, are converted without clipping, so colors outside the
+// sRGB gamut yield components outside [0, 1]. As with color.YCbCrModel,
+// alpha is discarded.
+var LinearRGBF32Model color.Model = color.ModelFunc(linearRGBF32Model)
+
+// linearRGBF32Model converts any color.Color to a LinearRGBF32.
+func linearRGBF32Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case LinearRGBF32:
+		return c
+	case XYZ, interface{ XYZ() XYZ }:
+		xyz := toXYZ(c)
+		r, g, b := xyzToLinearSRGB(xyz.X, xyz.Y, xyz.Z)
+		return LinearRGBF32{float32(r), float32(g), float32(b)}
+	}
+	r, g, b, _ := c.RGBA()
+	return LinearRGBF32{
+		R: float32(srgbToLinear(float64(r) / 0xffff)),
+		G: float32(srgbToLinear(float64(g) / 0xffff)),
+		B: float32(srgbToLinear(float64(b) / 0xffff)),
+	}
+}
+
+// LinearRGBAF32 represents a color in linear-light sRGB with alpha. The
+// color components are premultiplied by alpha, so each of R, G and B should
+// not exceed A for in-gamut colors.
+type LinearRGBAF32 struct {
+	R, G, B, A float32
+}
+
+// RGBA returns the red, green, blue and alpha components of the
+// LinearRGBAF32 color. This implements the color.Color interface.
+// The transfer function is applied to the unpremultiplied components, which
+// are then premultiplied again in the sRGB domain.
+func (c LinearRGBAF32) RGBA() (r, g, b, a uint32) {
+	alpha := clamp01(float64(c.A))
+	if alpha == 0 {
+		return 0, 0, 0, 0
+	}
+	enc := func(v float32) uint32 {
+		return uint32(linearToSRGB(clamp01(float64(v)/alpha))*alpha*0xffff + 0.5)
+	}
+	return enc(c.R), enc(c.G), enc(c.B), unit16(alpha)
+}
+
+// LinearRGBAF32Model is the color model for premultiplied linear-light sRGB
+// colors with alpha. As with LinearRGBF32Model, colors with an XYZ method
+// are converted without clipping.
+var LinearRGBAF32Model color.Model = color.ModelFunc(linearRGBAF32Model)
+
+// linearRGBAF32Model converts any color.Color to a LinearRGBAF32.
+func linearRGBAF32Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case LinearRGBAF32:
+		return c
+	case LinearRGBF32:
+		return LinearRGBAF32{c.R, c.G, c.B, 1}
+	case XYZ, interface{ XYZ() XYZ }:
+		l := linearRGBF32Model(c).(LinearRGBF32)
+		return LinearRGBAF32{l.R, l.G, l.B, 1}
+	}
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	a := float64(n.A) / 0xffff
+	return LinearRGBAF32{
+		R: float32(srgbToLinear(float64(n.R)/0xffff) * a),
+		G: float32(srgbToLinear(float64(n.G)/0xffff) * a),
+		B: float32(srgbToLinear(float64(n.B)/0xffff) * a),
+		A: float32(a),
+	}
+}
+
+// LinearRGBAF32Image is an in-memory image whose At method returns
+// LinearRGBAF32 values.
+type LinearRGBAF32Image struct {
+	// Pix holds the image's pixels, in R, G, B, A order, as IEEE 754
+	// float32 values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*16].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the LinearRGBAF32Image's color model.
+func (p *LinearRGBAF32Image) ColorModel() color.Model {
+	return LinearRGBAF32Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *LinearRGBAF32Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *LinearRGBAF32Image) At(x, y int) color.Color {
+	return p.LinearRGBAF32At(x, y)
+}
+
+// LinearRGBAF32At returns the LinearRGBAF32 color of the pixel at (x, y).
+func (p *LinearRGBAF32Image) LinearRGBAF32At(x, y int) LinearRGBAF32 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return LinearRGBAF32{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	return LinearRGBAF32{getF32(s[0:4]), getF32(s[4:8]), getF32(s[8:12]), getF32(s[12:16])}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *LinearRGBAF32Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*16
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *LinearRGBAF32Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetLinearRGBAF32(x, y, LinearRGBAF32Model.Convert(c).(LinearRGBAF32))
+}
+
+// SetLinearRGBAF32 sets the pixel at (x, y) to a given LinearRGBAF32 color.
+func (p *LinearRGBAF32Image) SetLinearRGBAF32(x, y int, c LinearRGBAF32) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	putF32(s[0:4], c.R)
+	putF32(s[4:8], c.G)
+	putF32(s[8:12], c.B)
+	putF32(s[12:16], c.A)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *LinearRGBAF32Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &LinearRGBAF32Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &LinearRGBAF32Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *LinearRGBAF32Image) Opaque() bool {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.LinearRGBAF32At(x, y).A < 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NewLinearRGBAF32Image returns a new LinearRGBAF32Image with the given bounds.
+func NewLinearRGBAF32Image(r image.Rectangle, opts ...ImageOption) *LinearRGBAF32Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &LinearRGBAF32Image{
+		Pix:    o.pix(16*w*h, 4),
+		Stride: 16 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 16)
+	return p
+}
+
+func (p *LinearRGBAF32Image) metadata() Metadata {
+	return p.Meta
+}
+
+// SRGBToLinear converts src to a linear-light image by decoding the sRGB
+// transfer function of every pixel. Resampling, blurring and compositing
+// the result, then converting back with LinearToSRGB, avoids the darkening
+// and fringing caused by filtering gamma-encoded values.
+func SRGBToLinear(src image.Image) *LinearRGBAF32Image {
+	r := src.Bounds()
+	dst := NewLinearRGBAF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetLinearRGBAF32(x, y, LinearRGBAF32Model.Convert(src.At(x, y)).(LinearRGBAF32))
+		}
+	}
+	return dst
+}
+
+// LinearToSRGB converts a linear-light image back to sRGB, encoding each
+// unpremultiplied component with the sRGB transfer function. 16 bits per
+// channel are used to avoid banding in dark tones.
+func LinearToSRGB(src *LinearRGBAF32Image) *image.NRGBA64 {
+	dst := image.NewNRGBA64(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			c := src.LinearRGBAF32At(x, y)
+			a := clamp01(float64(c.A))
+			if a == 0 {
+				continue
+			}
+			enc := func(v float32) uint16 {
+				return uint16(unit16(linearToSRGB(clamp01(float64(v) / a))))
+			}
+			dst.SetNRGBA64(x, y, color.NRGBA64{R: enc(c.R), G: enc(c.G), B: enc(c.B), A: uint16(unit16(a))})
+		}
+	}
+	return dst
+}
+
+// getF32 reads a big-endian float32 from b.
+func getF32(b []uint8) float32 {
+	_ = b[3] // bounds check hint to compiler
+	return math.Float32frombits(uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]))
+}
+
+// putF32 writes v to b as a big-endian float32.
+func putF32(b []uint8, v float32) {
+	_ = b[3] // bounds check hint to compiler
+	bits := math.Float32bits(v)
+	b[0] = uint8(bits >> 24)
+	b[1] = uint8(bits >> 16)
+	b[2] = uint8(bits >> 8)
+	b[3] = uint8(bits)
+}
@@ -0,0 +1,147 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestLinearRGBF32Model(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  float64
+	}{
+		{"black", color.Black, 0},
+		{"white", color.White, 1},
+		// sRGB 50% gray is about 21.4% linear light.
+		{"middle gray", color.Gray16{Y: 0x8000}, 0.2140},
+		// Values in the linear toe are divided by 12.92.
+		{"toe", color.Gray16{Y: 0x0100}, float64(0x0100) / 0xffff / 12.92},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LinearRGBF32Model.Convert(tt.input).(LinearRGBF32)
+			if !approxEqual(float64(got.R), tt.want, 1e-4) || got.R != got.G || got.G != got.B {
+				t.Errorf("LinearRGBF32Model.Convert(%v) = %+v, want gray %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearRGBF32Model_WideGamut(t *testing.T) {
+	// Display P3 red lies outside sRGB and must not be clipped.
+	got := LinearRGBF32Model.Convert(DisplayP3{1, 0, 0}).(LinearRGBF32)
+	if !(got.R > 1) || !(got.G < 0) {
+		t.Errorf("LinearRGBF32Model.Convert(P3 red) = %+v, want R > 1 and G < 0", got)
+	}
+	ga := LinearRGBAF32Model.Convert(DisplayP3{1, 0, 0}).(LinearRGBAF32)
+	if ga != (LinearRGBAF32{got.R, got.G, got.B, 1}) {
+		t.Errorf("LinearRGBAF32Model.Convert(P3 red) = %+v, want %+v", ga, got)
+	}
+}
+
+func TestLinearRGBF32_RoundTrip(t *testing.T) {
+	for v := uint32(0); v <= 0xffff; v += 0x0101 {
+		c := color.RGBA64{uint16(v), uint16(0xffff - v), uint16(v / 2), 0xffff}
+		r, g, b, a := LinearRGBF32Model.Convert(c).RGBA()
+		if !approxEqual(float64(r), float64(c.R), 1) || !approxEqual(float64(g), float64(c.G), 1) ||
+			!approxEqual(float64(b), float64(c.B), 1) || a != 0xffff {
+			t.Fatalf("%v round-tripped to (%d, %d, %d, %d)", c, r, g, b, a)
+		}
+	}
+	// Out of range components clamp.
+	if r, _, _, _ := (LinearRGBF32{R: 5}).RGBA(); r != 0xffff {
+		t.Errorf("LinearRGBF32{R: 5}.RGBA() red = %d, want 65535", r)
+	}
+}
+
+func TestLinearRGBAF32Model(t *testing.T) {
+	// Half-transparent white stays white after unpremultiplication.
+	c := LinearRGBAF32Model.Convert(color.NRGBA{255, 255, 255, 128}).(LinearRGBAF32)
+	if !approxEqual(float64(c.R), float64(c.A), 1e-6) || !approxEqual(float64(c.A), 128.0/255, 1e-6) {
+		t.Errorf("Convert(half white) = %+v, want premultiplied white", c)
+	}
+	r, g, b, a := c.RGBA()
+	want := color.NRGBA{255, 255, 255, 128}
+	wr, wg, wb, wa := want.RGBA()
+	if r != wr || g != wg || b != wb || a != wa {
+		t.Errorf("RGBA() = (%d, %d, %d, %d), want (%d, %d, %d, %d)", r, g, b, a, wr, wg, wb, wa)
+	}
+
+	if got := LinearRGBAF32Model.Convert(LinearRGBF32{0.1, 0.2, 0.3}); got != (LinearRGBAF32{0.1, 0.2, 0.3, 1}) {
+		t.Errorf("Convert(LinearRGBF32) = %v, want opaque", got)
+	}
+	if r, g, b, a := (LinearRGBAF32{}).RGBA(); r|g|b|a != 0 {
+		t.Errorf("transparent RGBA() = (%d, %d, %d, %d), want zero", r, g, b, a)
+	}
+}
+
+func TestLinearRGBAF32Image(t *testing.T) {
+	img := NewLinearRGBAF32Image(image.Rect(0, 0, 3, 2))
+	if img.Stride != 48 || len(img.Pix) != 96 {
+		t.Fatalf("Stride = %d, len(Pix) = %d, want 48, 96", img.Stride, len(img.Pix))
+	}
+	if img.Opaque() {
+		t.Error("new image is Opaque, want transparent")
+	}
+
+	want := LinearRGBAF32{0.25, 0.5, -1, 1}
+	img.SetLinearRGBAF32(2, 1, want)
+	if got := img.LinearRGBAF32At(2, 1); got != want {
+		t.Errorf("LinearRGBAF32At(2, 1) = %v, want %v", got, want)
+	}
+	img.Set(0, 0, color.White)
+	if got := img.At(0, 0); got != (LinearRGBAF32{1, 1, 1, 1}) {
+		t.Errorf("At(0, 0) = %v, want opaque white", got)
+	}
+
+	sub := img.SubImage(image.Rect(2, 1, 3, 2)).(*LinearRGBAF32Image)
+	if got := sub.LinearRGBAF32At(2, 1); got != want {
+		t.Errorf("SubImage.LinearRGBAF32At(2, 1) = %v, want %v", got, want)
+	}
+	if !sub.Opaque() {
+		t.Error("opaque SubImage reported not Opaque")
+	}
+	if got := img.LinearRGBAF32At(3, 0); got != (LinearRGBAF32{}) {
+		t.Errorf("out of bounds LinearRGBAF32At = %v, want zero", got)
+	}
+}
+
+func TestSRGBToLinear_RoundTrip(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	src.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	src.SetNRGBA(1, 0, color.NRGBA{10, 128, 250, 255})
+	src.SetNRGBA(0, 1, color.NRGBA{200, 100, 50, 64})
+
+	lin := SRGBToLinear(src)
+	if got := lin.LinearRGBAF32At(0, 0); got != (LinearRGBAF32{1, 0, 0, 1}) {
+		t.Errorf("linear red = %v, want {1 0 0 1}", got)
+	}
+
+	out := LinearToSRGB(lin)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			want := color.NRGBA64Model.Convert(src.At(x, y)).(color.NRGBA64)
+			got := out.NRGBA64At(x, y)
+			if !approxEqual(float64(got.R), float64(want.R), 1) || !approxEqual(float64(got.G), float64(want.G), 1) ||
+				!approxEqual(float64(got.B), float64(want.B), 1) || got.A != want.A {
+				t.Errorf("(%d, %d) round-tripped to %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestSRGBToLinear_Averaging(t *testing.T) {
+	// Averaging black and white in linear light gives sRGB ~188, not 128.
+	src := image.NewGray(image.Rect(0, 0, 2, 1))
+	src.SetGray(1, 0, color.Gray{Y: 255})
+	lin := SRGBToLinear(src)
+	a, b := lin.LinearRGBAF32At(0, 0), lin.LinearRGBAF32At(1, 0)
+	avg := NewLinearRGBAF32Image(image.Rect(0, 0, 1, 1))
+	avg.SetLinearRGBAF32(0, 0, LinearRGBAF32{(a.R + b.R) / 2, (a.G + b.G) / 2, (a.B + b.B) / 2, 1})
+	if got := LinearToSRGB(avg).NRGBA64At(0, 0).R >> 8; got != 187 && got != 188 {
+		t.Errorf("linear average of black and white = %d, want 188", got)
+	}
+}
@@ -0,0 +1,92 @@
+package colorext
+
+import (
+	"image/color"
+	"sync"
+)
+
+// LumaWeights selects how GrayS16ModelWithWeights computes gray levels from
+// RGB colors.
+type LumaWeights int
+
+const (
+	// LumaRec601 applies the BT.601 weights (0.299, 0.587, 0.114) to the
+	// gamma-encoded components, as GrayS16Model and the stdlib do.
+	LumaRec601 LumaWeights = iota
+	// LumaRec709 applies the BT.709 weights (0.2126, 0.7152, 0.0722) to the
+	// gamma-encoded components.
+	LumaRec709
+	// LumaLinear computes relative luminance: the components are decoded
+	// from sRGB to linear light and weighted with the BT.709 weights. The
+	// result is linear, not gamma-encoded, so mid grays map to lower levels
+	// than with the other weights.
+	LumaLinear
+)
+
+// GrayS16ModelWithWeights returns a color model converting to GrayS16 with
+// the given luma weights. LumaRec601 returns GrayS16Model itself.
+func GrayS16ModelWithWeights(w LumaWeights) color.Model {
+	switch w {
+	case LumaRec709:
+		return grayS16Rec709Model
+	case LumaLinear:
+		return grayS16LinearModel
+	}
+	return GrayS16Model
+}
+
+var (
+	grayS16Rec709Model = color.ModelFunc(func(c color.Color) color.Color {
+		if _, ok := c.(GrayS16); ok {
+			return c
+		}
+		return GrayS16{Y: weightedLuma(c, 13933, 46871, 4732)}
+	})
+	grayS16LinearModel = color.ModelFunc(func(c color.Color) color.Color {
+		if _, ok := c.(GrayS16); ok {
+			return c
+		}
+		r, g, b := rgb16(c)
+		lut := linearLUT()
+		y := 0.2126*lut[r] + 0.7152*lut[g] + 0.0722*lut[b]
+		return GrayS16{Y: int16(int32(y*0xffff+0.5) - 32768)}
+	})
+)
+
+// linearLUT maps 16-bit sRGB-encoded values to linear light in [0, 1].
+var linearLUT = sync.OnceValue(func() *[1 << 16]float32 {
+	var t [1 << 16]float32
+	for i := range t {
+		t[i] = float32(srgbToLinear(float64(i) / 0xffff))
+	}
+	return &t
+})
+
+// weightedLuma returns the signed gray level of c using the given 16.16
+// fixed point weights, which must sum to 65536.
+func weightedLuma(c color.Color, wr, wg, wb uint32) int16 {
+	r, g, b := rgb16(c)
+	y := (wr*r + wg*g + wb*b + 1<<15) >> 16
+	return int16(int32(y) - 32768)
+}
+
+// rgb16 returns the premultiplied 16-bit red, green and blue components of
+// c, as c.RGBA would, avoiding the dynamic call for common stdlib colors.
+func rgb16(c color.Color) (r, g, b uint32) {
+	switch c := c.(type) {
+	case color.RGBA:
+		return uint32(c.R) * 0x101, uint32(c.G) * 0x101, uint32(c.B) * 0x101
+	case color.NRGBA:
+		// As in color.NRGBA.RGBA.
+		a := uint32(c.A) * 0x101
+		return uint32(c.R) * 0x101 * a / 0xffff, uint32(c.G) * 0x101 * a / 0xffff, uint32(c.B) * 0x101 * a / 0xffff
+	case color.Gray:
+		y := uint32(c.Y) * 0x101
+		return y, y, y
+	case color.Gray16:
+		y := uint32(c.Y)
+		return y, y, y
+	}
+	r, g, b, _ = c.RGBA()
+	return r, g, b
+}
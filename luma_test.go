@@ -0,0 +1,86 @@
+package colorext
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestGrayS16ModelWithWeights(t *testing.T) {
+	tests := []struct {
+		name string
+		w    LumaWeights
+		in   color.Color
+		want int16
+	}{
+		{"601 white", LumaRec601, color.White, 32767},
+		{"601 black", LumaRec601, color.Black, -32768},
+		{"601 green", LumaRec601, color.RGBA{G: 0xff, A: 0xff}, int16(38470*0xffff>>16 - 32768)},
+		{"709 white", LumaRec709, color.White, 32767},
+		{"709 green", LumaRec709, color.RGBA{G: 0xff, A: 0xff}, int16((46871*0xffff+1<<15)>>16 - 32768)},
+		{"709 blue", LumaRec709, color.RGBA{B: 0xff, A: 0xff}, int16((4732*0xffff+1<<15)>>16 - 32768)},
+		{"linear white", LumaLinear, color.White, 32767},
+		{"linear black", LumaLinear, color.Black, -32768},
+		// sRGB 0x80 decodes to about 0.2159 in linear light, or 14147/65535.
+		{"linear mid gray", LumaLinear, color.Gray{Y: 0x80}, -18621},
+		{"passthrough", LumaLinear, GrayS16{Y: 5}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GrayS16ModelWithWeights(tt.w).Convert(tt.in).(GrayS16)
+			if d := int(got.Y) - int(tt.want); d < -2 || d > 2 {
+				t.Errorf("Convert(%v) = %d, want %d", tt.in, got.Y, tt.want)
+			}
+		})
+	}
+	if GrayS16ModelWithWeights(LumaRec601) != GrayS16Model {
+		t.Error("LumaRec601 does not return GrayS16Model")
+	}
+}
+
+// slowColor hides a color's concrete type to force the generic path.
+type slowColor struct{ color.Color }
+
+func TestRGB16_FastPaths(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < 1000; i++ {
+		v := [4]uint8{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))}
+		for _, c := range []color.Color{
+			color.RGBA{v[0], v[1], v[2], 0xff},
+			color.NRGBA{v[0], v[1], v[2], v[3]},
+			color.Gray{Y: v[0]},
+			color.Gray16{Y: uint16(v[0])<<8 | uint16(v[1])},
+		} {
+			for _, w := range []LumaWeights{LumaRec601, LumaRec709, LumaLinear} {
+				m := GrayS16ModelWithWeights(w)
+				if fast, slow := m.Convert(c), m.Convert(slowColor{c}); fast != slow {
+					t.Fatalf("weights %d: Convert(%#v) = %v, generic path gives %v", w, c, fast, slow)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkGrayS16Model(b *testing.B) {
+	inputs := []struct {
+		name string
+		c    color.Color
+	}{
+		{"RGBA", color.RGBA{10, 200, 30, 0xff}},
+		{"NRGBA", color.NRGBA{10, 200, 30, 0x80}},
+		{"RGBA64", color.RGBA64{1000, 20000, 3000, 0xffff}},
+	}
+	for _, w := range []struct {
+		name string
+		w    LumaWeights
+	}{{"601", LumaRec601}, {"709", LumaRec709}, {"Linear", LumaLinear}} {
+		m := GrayS16ModelWithWeights(w.w)
+		for _, in := range inputs {
+			b.Run(w.name+"/"+in.name, func(b *testing.B) {
+				for b.Loop() {
+					m.Convert(in.c)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+)
+
+// Luv represents a color in the CIE 1976 L*u*v* color space, relative to the
+// D65 white point. L ranges from 0 (black) to 100 (white).
+type Luv struct {
+	L, U, V float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the Luv color.
+// This implements the color.Color interface.
+func (c Luv) RGBA() (r, g, b, a uint32) {
+	return LuvToXYZ(c, D65).RGBA()
+}
+
+// HCL converts c to cylindrical hue, chroma and lightness coordinates.
+func (c Luv) HCL() HCL {
+	return HCL{H: hueDegrees(c.V, c.U), C: math.Hypot(c.U, c.V), L: c.L}
+}
+
+// LuvModel is the color model for CIE L*u*v* colors relative to D65.
+var LuvModel color.Model = color.ModelFunc(luvModel)
+
+// luvModel converts any color.Color to a Luv.
+func luvModel(c color.Color) color.Color {
+	switch c := c.(type) {
+	case Luv:
+		return c
+	case HCL:
+		return c.Luv()
+	}
+	return XYZToLuv(toXYZ(c), D65)
+}
+
+// XYZToLuv converts c to L*u*v* relative to the given reference white.
+func XYZToLuv(c XYZ, white WhitePoint) Luv {
+	l := 116*labF(c.Y/white.Y) - 16
+	d := c.X + 15*c.Y + 3*c.Z
+	if d == 0 {
+		return Luv{L: l}
+	}
+	un, vn := uvPrime(white.X, white.Y, white.Z)
+	up, vp := 4*c.X/d, 9*c.Y/d
+	return Luv{L: l, U: 13 * l * (up - un), V: 13 * l * (vp - vn)}
+}
+
+// LuvToXYZ converts c to XYZ relative to the given reference white.
+func LuvToXYZ(c Luv, white WhitePoint) XYZ {
+	if c.L <= 0 {
+		return XYZ{}
+	}
+	un, vn := uvPrime(white.X, white.Y, white.Z)
+	up := c.U/(13*c.L) + un
+	vp := c.V/(13*c.L) + vn
+
+	var y float64
+	if c.L > cieKappa*cieEpsilon {
+		f := (c.L + 16) / 116
+		y = f * f * f
+	} else {
+		y = c.L / cieKappa
+	}
+	y *= white.Y
+	if vp == 0 {
+		return XYZ{Y: y}
+	}
+	return XYZ{
+		X: y * 9 * up / (4 * vp),
+		Y: y,
+		Z: y * (12 - 3*up - 20*vp) / (4 * vp),
+	}
+}
+
+// uvPrime returns the CIE 1976 u'v' chromaticity of an XYZ triple.
+func uvPrime(x, y, z float64) (u, v float64) {
+	d := x + 15*y + 3*z
+	return 4 * x / d, 9 * y / d
+}
+
+// HCL represents a CIE L*u*v* color in cylindrical coordinates, also known as
+// LCh(uv): hue angle H in degrees in [0, 360), chroma C and lightness L.
+// It is well suited to building perceptual palettes, since varying H at fixed
+// C and L yields colors of similar perceived brightness and colorfulness.
+type HCL struct {
+	H, C, L float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the HCL color.
+// This implements the color.Color interface.
+func (c HCL) RGBA() (r, g, b, a uint32) {
+	return c.Luv().RGBA()
+}
+
+// Luv converts c to rectangular L*u*v* coordinates.
+func (c HCL) Luv() Luv {
+	s, co := math.Sincos(c.H * math.Pi / 180)
+	return Luv{L: c.L, U: c.C * co, V: c.C * s}
+}
+
+// HCLModel is the color model for HCL colors relative to D65.
+var HCLModel color.Model = color.ModelFunc(hclModel)
+
+// hclModel converts any color.Color to an HCL.
+func hclModel(c color.Color) color.Color {
+	if c, ok := c.(HCL); ok {
+		return c
+	}
+	return LuvModel.Convert(c).(Luv).HCL()
+}
@@ -0,0 +1,77 @@
+package colorext
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestLuvModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  Luv
+	}{
+		{"white", color.White, Luv{100, 0, 0}},
+		{"black", color.Black, Luv{0, 0, 0}},
+		{"red", color.RGBA{255, 0, 0, 255}, Luv{53.2408, 175.0151, 37.7564}},
+		{"green", color.RGBA{0, 255, 0, 255}, Luv{87.7347, -83.0776, 107.3985}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LuvModel.Convert(tt.input).(Luv)
+			if !approxEqual(got.L, tt.want.L, 0.01) || !approxEqual(got.U, tt.want.U, 0.01) || !approxEqual(got.V, tt.want.V, 0.01) {
+				t.Errorf("LuvModel.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	original := Luv{50, 1, 2}
+	if got := LuvModel.Convert(original); got != original {
+		t.Errorf("LuvModel.Convert(%v) = %v, want unchanged", original, got)
+	}
+}
+
+func TestLuv_RoundTrip(t *testing.T) {
+	for _, c := range []color.RGBA64{
+		{0x1234, 0x5678, 0x9abc, 0xffff}, {0xffff, 0x8000, 0, 0xffff}, {7, 7, 7, 0xffff}, {0, 0, 0, 0xffff},
+	} {
+		for _, m := range []color.Model{LuvModel, HCLModel} {
+			r, g, b, _ := m.Convert(c).RGBA()
+			if !approxEqual(float64(r), float64(c.R), 1) || !approxEqual(float64(g), float64(c.G), 1) ||
+				!approxEqual(float64(b), float64(c.B), 1) {
+				t.Errorf("%v round-tripped to (%d, %d, %d)", c, r, g, b)
+			}
+		}
+	}
+
+	xyz := XYZ{0.2, 0.3, 0.1}
+	if got := LuvToXYZ(XYZToLuv(xyz, D50), D50); !approxEqual(got.X, xyz.X, 1e-12) || !approxEqual(got.Z, xyz.Z, 1e-12) {
+		t.Errorf("D50 Luv round trip = %+v, want %+v", got, xyz)
+	}
+}
+
+func TestHCL(t *testing.T) {
+	hcl := Luv{L: 60, U: 0, V: 30}.HCL()
+	if hcl.H != 90 || hcl.C != 30 || hcl.L != 60 {
+		t.Errorf("Luv{60 0 30}.HCL() = %+v, want {90 30 60}", hcl)
+	}
+	back := hcl.Luv()
+	if !approxEqual(back.U, 0, 1e-12) || !approxEqual(back.V, 30, 1e-12) {
+		t.Errorf("HCL.Luv() = %+v, want {60 0 30}", back)
+	}
+
+	// Palettes at fixed C and L share the same lightness.
+	for h := 0.0; h < 360; h += 60 {
+		c := HCL{H: h, C: 30, L: 65}
+		got := LuvModel.Convert(c).(Luv)
+		if !approxEqual(got.L, 65, 1e-9) {
+			t.Errorf("HCL{%v 30 65} has L = %v", h, got.L)
+		}
+	}
+
+	original := HCL{1, 2, 3}
+	if got := HCLModel.Convert(original); got != original {
+		t.Errorf("HCLModel.Convert(%v) = %v, want unchanged", original, got)
+	}
+}
@@ -0,0 +1,147 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// MappingKind selects how a MappingPolicy maps signed values to the unsigned
+// range used by RGBA.
+type MappingKind int
+
+const (
+	// OffsetBinary adds 32768, mapping -32768 to black and 0 to mid gray.
+	// This is the mapping GrayS16 itself uses.
+	OffsetBinary MappingKind = iota
+	// TwosComplementClamp clamps negative values to black and scales
+	// [0, 32767] to the full unsigned range, so 0 maps to black.
+	TwosComplementClamp
+	// AbsoluteValue maps each value by its magnitude, scaling [0, 32767] to
+	// the full unsigned range; -32768 saturates to white.
+	AbsoluteValue
+	// CustomRange scales [Min, Max] linearly to the full unsigned range,
+	// clamping values outside it.
+	CustomRange
+)
+
+// MappingPolicy describes how signed GrayS16 values map to unsigned 16-bit
+// levels. The zero value is the OffsetBinary mapping used by GrayS16.
+type MappingPolicy struct {
+	Kind MappingKind
+	// Min and Max bound the CustomRange mapping. If Max <= Min, every value
+	// below Max maps to black and the rest to white.
+	Min, Max int16
+}
+
+// Unsigned returns the unsigned level for v.
+func (p MappingPolicy) Unsigned(v int16) uint16 {
+	switch p.Kind {
+	case TwosComplementClamp:
+		return scaleTo16(max(int32(v), 0), 0, 32767)
+	case AbsoluteValue:
+		a := int32(v)
+		if a < 0 {
+			a = -a
+		}
+		return scaleTo16(min(a, 32767), 0, 32767)
+	case CustomRange:
+		if p.Max <= p.Min {
+			if v < p.Max {
+				return 0
+			}
+			return 0xffff
+		}
+		return scaleTo16(max(int32(p.Min), min(int32(v), int32(p.Max))), int32(p.Min), int32(p.Max))
+	}
+	return uint16(int32(v) + 32768)
+}
+
+// Signed returns the signed value whose level is nearest u, inverting
+// Unsigned. AbsoluteValue yields non-negative values.
+func (p MappingPolicy) Signed(u uint16) int16 {
+	lo, hi := int32(0), int32(32767)
+	switch p.Kind {
+	case TwosComplementClamp, AbsoluteValue:
+	case CustomRange:
+		lo, hi = int32(p.Min), int32(p.Max)
+		if hi <= lo {
+			if u < 0x8000 {
+				return p.Min
+			}
+			return p.Max
+		}
+	default:
+		return int16(int32(u) - 32768)
+	}
+	return int16(lo + (int32(u)*(hi-lo)+0x7fff)/0xffff)
+}
+
+// Model returns a color model converting colors to MappedGrayS16 values
+// with policy p. The luminance of a color is computed as GrayS16Model does
+// and mapped back through Signed.
+func (p MappingPolicy) Model() color.Model {
+	return color.ModelFunc(func(c color.Color) color.Color {
+		switch c := c.(type) {
+		case MappedGrayS16:
+			if c.Policy == p {
+				return c
+			}
+		case GrayS16:
+			return MappedGrayS16{Y: c.Y, Policy: p}
+		}
+		y := uint32(weightedLuma(c, 19595, 38470, 7471)) + 32768
+		return MappedGrayS16{Y: p.Signed(uint16(y)), Policy: p}
+	})
+}
+
+// scaleTo16 maps v in [lo, hi] linearly to [0, 65535], rounding to nearest.
+func scaleTo16(v, lo, hi int32) uint16 {
+	return uint16((int64(v-lo)*0xffff + int64(hi-lo)/2) / int64(hi-lo))
+}
+
+// MappedGrayS16 is a GrayS16 value displayed through a MappingPolicy.
+type MappedGrayS16 struct {
+	Y      int16
+	Policy MappingPolicy
+}
+
+// RGBA returns the red, green, blue and alpha components of the color,
+// mapping Y to an unsigned level with Policy. This implements the
+// color.Color interface.
+func (c MappedGrayS16) RGBA() (r, g, b, a uint32) {
+	y := uint32(c.Policy.Unsigned(c.Y))
+	return y, y, y, 0xffff
+}
+
+// MappedGrayS16Image is a GrayS16Image whose pixels are displayed through a
+// MappingPolicy. Stdlib consumers such as draw.Draw and png.Encode see the
+// mapped levels, and Set maps colors back with Policy.Signed. GrayS16At and
+// SetGrayS16 still access the raw values.
+type MappedGrayS16Image struct {
+	*GrayS16Image
+	Policy MappingPolicy
+}
+
+// ColorModel returns the model of the image's policy.
+func (p *MappedGrayS16Image) ColorModel() color.Model {
+	return p.Policy.Model()
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *MappedGrayS16Image) At(x, y int) color.Color {
+	return MappedGrayS16{Y: p.GrayS16At(x, y).Y, Policy: p.Policy}
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *MappedGrayS16Image) Set(x, y int, c color.Color) {
+	p.SetGrayS16(x, y, GrayS16{Y: p.Policy.Model().Convert(c).(MappedGrayS16).Y})
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *MappedGrayS16Image) SubImage(r image.Rectangle) image.Image {
+	return &MappedGrayS16Image{
+		GrayS16Image: p.GrayS16Image.SubImage(r).(*GrayS16Image),
+		Policy:       p.Policy,
+	}
+}
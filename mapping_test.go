@@ -0,0 +1,102 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMappingPolicy_Unsigned(t *testing.T) {
+	custom := MappingPolicy{Kind: CustomRange, Min: -100, Max: 100}
+	tests := []struct {
+		name string
+		p    MappingPolicy
+		v    int16
+		want uint16
+	}{
+		{"offset min", MappingPolicy{}, -32768, 0},
+		{"offset zero", MappingPolicy{}, 0, 32768},
+		{"offset max", MappingPolicy{}, 32767, 0xffff},
+		{"clamp negative", MappingPolicy{Kind: TwosComplementClamp}, -5, 0},
+		{"clamp zero", MappingPolicy{Kind: TwosComplementClamp}, 0, 0},
+		{"clamp half", MappingPolicy{Kind: TwosComplementClamp}, 16384, 32769},
+		{"clamp max", MappingPolicy{Kind: TwosComplementClamp}, 32767, 0xffff},
+		{"abs negative", MappingPolicy{Kind: AbsoluteValue}, -32767, 0xffff},
+		{"abs min saturates", MappingPolicy{Kind: AbsoluteValue}, -32768, 0xffff},
+		{"abs positive", MappingPolicy{Kind: AbsoluteValue}, 16384, 32769},
+		{"custom below", custom, -1000, 0},
+		{"custom min", custom, -100, 0},
+		{"custom mid", custom, 0, 32768},
+		{"custom max", custom, 100, 0xffff},
+		{"custom above", custom, 30000, 0xffff},
+		{"degenerate below", MappingPolicy{Kind: CustomRange, Min: 5, Max: 5}, 4, 0},
+		{"degenerate at", MappingPolicy{Kind: CustomRange, Min: 5, Max: 5}, 5, 0xffff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Unsigned(tt.v); got != tt.want {
+				t.Errorf("Unsigned(%d) = %d, want %d", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMappingPolicy_RoundTrip(t *testing.T) {
+	policies := []MappingPolicy{
+		{},
+		{Kind: TwosComplementClamp},
+		{Kind: AbsoluteValue},
+		{Kind: CustomRange, Min: -1000, Max: 3000},
+	}
+	for _, p := range policies {
+		// Every value in the policy's invertible range survives a round trip.
+		lo, hi := int16(0), int16(32767)
+		switch p.Kind {
+		case OffsetBinary:
+			lo = -32768
+		case CustomRange:
+			lo, hi = p.Min, p.Max
+		}
+		for v := int32(lo); v <= int32(hi); v += 7 {
+			if got := p.Signed(p.Unsigned(int16(v))); got != int16(v) {
+				t.Fatalf("kind %d: Signed(Unsigned(%d)) = %d", p.Kind, v, got)
+			}
+		}
+	}
+}
+
+func TestMappedGrayS16Image(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	src.SetGrayS16(0, 0, GrayS16{Y: 0})
+	src.SetGrayS16(1, 0, GrayS16{Y: 32767})
+	img := &MappedGrayS16Image{GrayS16Image: src, Policy: MappingPolicy{Kind: TwosComplementClamp}}
+
+	// Rendering through the stdlib uses the mapped levels.
+	dst := image.NewGray16(src.Rect)
+	draw.Draw(dst, dst.Rect, img, image.Point{}, draw.Src)
+	if got := dst.Gray16At(0, 0).Y; got != 0 {
+		t.Errorf("rendered zero = %d, want 0", got)
+	}
+	if got := dst.Gray16At(1, 0).Y; got != 0xffff {
+		t.Errorf("rendered max = %d, want 65535", got)
+	}
+
+	// Set maps colors back to raw values.
+	img.Set(0, 0, color.Gray16{Y: 0x8000})
+	if got := src.GrayS16At(0, 0).Y; got != 16384 {
+		t.Errorf("raw value after Set = %d, want 16384", got)
+	}
+	img.Set(1, 0, GrayS16{Y: -3})
+	if got := src.GrayS16At(1, 0).Y; got != -3 {
+		t.Errorf("raw value after setting GrayS16 = %d, want -3", got)
+	}
+	if got := img.ColorModel().Convert(color.Black); got != (MappedGrayS16{Y: 0, Policy: img.Policy}) {
+		t.Errorf("model converts black to %v, want raw 0", got)
+	}
+
+	sub := img.SubImage(image.Rect(1, 0, 2, 1)).(*MappedGrayS16Image)
+	if sub.Policy != img.Policy || sub.GrayS16At(1, 0).Y != -3 {
+		t.Errorf("SubImage lost policy or pixels: %+v", sub.Policy)
+	}
+}
@@ -0,0 +1,589 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// binaryMagic starts every binary-encoded image.
+const binaryMagic = "CXIM"
+
+// Binary encoding versions and byte orders.
+const (
+	binaryVersion = 1
+	bigEndian     = 0
+)
+
+func init() {
+	// Allow the images to be sent as image.Image values.
+	gob.Register(&GrayS16Image{})
+	gob.Register(&GrayF32Image{})
+	gob.Register(&GrayU32Image{})
+	gob.Register(&GrayU64Image{})
+	gob.Register(&GrayS64Image{})
+	gob.Register(&GrayC64Image{})
+	gob.Register(&GrayC128Image{})
+	gob.Register(&BGRImage{})
+	gob.Register(&BGRAImage{})
+	gob.Register(&BGR48Image{})
+	gob.Register(&BGRA64Image{})
+	gob.Register(&RGB565Image{})
+	gob.Register(&RGB555Image{})
+	gob.Register(&CMYK64Image{})
+	gob.Register(&RGBAF32Image{})
+	gob.Register(&NRGBAF32Image{})
+	gob.Register(&LinearRGBAF32Image{})
+	gob.Register(&Gray10PackedImage{})
+	gob.Register(&Gray12PackedImage{})
+	gob.Register(&Paletted16Image{})
+}
+
+// pixelType is the pixel type of an image in the binary and JSON encodings:
+// a DType for the gray images, or a colorType.
+type pixelType interface {
+	code() byte
+	String() string
+	// rowBytes returns the size of a row of w pixels without padding,
+	// between w and 16*w bytes.
+	rowBytes(w int) int
+}
+
+func (d DType) code() byte { return byte(d) }
+
+func (d DType) rowBytes(w int) int { return d.Size() * w }
+
+// colorType is the pixel type of a color or packed image. Every group
+// pixels take size bytes. The codes follow the DTypes, so an encoded image
+// of either kind is identified by its pixel type byte alone.
+type colorType struct {
+	id    byte
+	name  string
+	group int
+	size  int
+}
+
+func (t colorType) code() byte { return t.id }
+
+func (t colorType) String() string { return t.name }
+
+func (t colorType) rowBytes(w int) int { return (w + t.group - 1) / t.group * t.size }
+
+// Pixel types of the color and packed images.
+var (
+	typeBGR           = colorType{0x40, "bgr", 1, 3}
+	typeBGRA          = colorType{0x41, "bgra", 1, 4}
+	typeBGR48         = colorType{0x42, "bgr48", 1, 6}
+	typeBGRA64        = colorType{0x43, "bgra64", 1, 8}
+	typeRGB565        = colorType{0x44, "rgb565", 1, 2}
+	typeRGB555        = colorType{0x45, "rgb555", 1, 2}
+	typeCMYK64        = colorType{0x46, "cmyk64", 1, 8}
+	typeRGBAF32       = colorType{0x47, "rgbaf32", 1, 16}
+	typeNRGBAF32      = colorType{0x48, "nrgbaf32", 1, 16}
+	typeLinearRGBAF32 = colorType{0x49, "linear_rgbaf32", 1, 16}
+	typeGray10Packed  = colorType{0x4a, "gray10_packed", 4, 5}
+	typeGray12Packed  = colorType{0x4b, "gray12_packed", 2, 3}
+	typePaletted16    = colorType{0x4c, "paletted16", 1, 2}
+)
+
+var colorTypes = []colorType{
+	typeBGR, typeBGRA, typeBGR48, typeBGRA64, typeRGB565, typeRGB555,
+	typeCMYK64, typeRGBAF32, typeNRGBAF32, typeLinearRGBAF32,
+	typeGray10Packed, typeGray12Packed, typePaletted16,
+}
+
+// pixelTypeName returns the name of the pixel type with the given code.
+func pixelTypeName(code byte) string {
+	for _, t := range colorTypes {
+		if t.id == code {
+			return t.name
+		}
+	}
+	return DType(code).String()
+}
+
+// marshalPix encodes an image as the magic, version, pixel type, byte order,
+// the four coordinates of r as varints, and the pixels of each row without
+// stride padding, in big-endian order.
+func marshalPix(t pixelType, r image.Rectangle, pix []uint8, stride int) []byte {
+	n := t.rowBytes(r.Dx())
+	buf := make([]byte, 0, len(binaryMagic)+3+4*binary.MaxVarintLen64+n*r.Dy())
+	buf = append(buf, binaryMagic...)
+	buf = append(buf, binaryVersion, t.code(), bigEndian)
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y} {
+		buf = binary.AppendVarint(buf, int64(v))
+	}
+	for y := 0; y < r.Dy(); y++ {
+		buf = append(buf, pix[y*stride:y*stride+n]...)
+	}
+	return buf
+}
+
+// unmarshalPix decodes data encoded by marshalPix, which must hold an image
+// of type t, returning its bounds and compact pixels. Malformed data yields
+// a *DecodeError.
+func unmarshalPix(t pixelType, data []byte) (image.Rectangle, []uint8, error) {
+	r, pix, rest, err := unmarshalPixPrefix(t, data)
+	if err == nil && len(rest) != 0 {
+		err = decodeError("binary image", int64(len(data)-len(rest)), fmt.Errorf("%w: %d bytes after the pixels of bounds %v", ErrInvalidLayout, len(rest), r))
+	}
+	return r, pix, err
+}
+
+// unmarshalPixPrefix is like unmarshalPix for an encoding followed by more
+// data, which it returns as rest.
+func unmarshalPixPrefix(t pixelType, data []byte) (r image.Rectangle, pix, rest []uint8, err error) {
+	br := &offsetReader{r: bytes.NewReader(data)}
+	d, c, err := readBinaryHeader(br)
+	if err != nil {
+		return image.Rectangle{}, nil, nil, err
+	}
+	if d.code() != t.code() {
+		err := fmt.Errorf("%w: image holds %v pixels, want %v", ErrUnsupportedDType, pixelTypeName(d.code()), t)
+		return image.Rectangle{}, nil, nil, decodeError("binary image", int64(len(binaryMagic)+1), err)
+	}
+	off := int(br.off)
+	r, n, err := checkedRect(c[0], c[1], c[2], c[3], t, len(data)-off)
+	if err != nil {
+		return image.Rectangle{}, nil, nil, decodeError("binary image", br.off, err)
+	}
+	pix = make([]uint8, n)
+	copy(pix, data[off:])
+	return r, pix, data[off+n:], nil
+}
+
+// readBinaryHeader reads the header written by marshalPix from br,
+// returning the sample type and the four coordinates of the bounds, which
+// are not validated.
+func readBinaryHeader(br *offsetReader) (DType, [4]int, error) {
+	var c [4]int
+	fail := func(off int64, err error) (DType, [4]int, error) {
+		return 0, c, decodeError("binary image", off, err)
+	}
+	var hdr [len(binaryMagic) + 3]byte
+	for i := range hdr {
+		b, err := br.ReadByte()
+		if err != nil {
+			return fail(br.off, err)
+		}
+		hdr[i] = b
+	}
+	if string(hdr[:len(binaryMagic)]) != binaryMagic {
+		return fail(0, errors.New("not a binary image"))
+	}
+	h := hdr[len(binaryMagic):]
+	off := int64(len(binaryMagic))
+	if h[0] != binaryVersion {
+		return fail(off, fmt.Errorf("unsupported version %d", h[0]))
+	}
+	if h[2] != bigEndian {
+		return fail(off+2, fmt.Errorf("unsupported byte order %d", h[2]))
+	}
+	for i := range c {
+		v, err := binary.ReadVarint(br)
+		if err != nil {
+			return fail(br.off, err)
+		}
+		if v != int64(int(v)) {
+			return fail(br.off, errors.New("invalid bounds"))
+		}
+		c[i] = int(v)
+	}
+	return DType(h[1]), c, nil
+}
+
+// checkedRect validates decoded bounds against the number of pixel bytes
+// available, guarding against overflow, and returns them with the number of
+// bytes their pixels of type t take.
+func checkedRect(x0, y0, x1, y1 int, t pixelType, have int) (image.Rectangle, int, error) {
+	if x1 < x0 || y1 < y0 {
+		return image.Rectangle{}, 0, fmt.Errorf("%w: malformed bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, x0, y0, x1, y1)
+	}
+	w, h := uint64(x1)-uint64(x0), uint64(y1)-uint64(y0)
+	// Pixels take at most 16 bytes, so the row size below cannot overflow.
+	if w > uint64(have) || w > math.MaxInt/16 || h > uint64(have) || (w != 0 && h > uint64(have)/w) {
+		return image.Rectangle{}, 0, fmt.Errorf("%w: %d pixel bytes do not match bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, have, x0, y0, x1, y1)
+	}
+	n := uint64(t.rowBytes(int(w))) * h
+	if n > uint64(have) {
+		return image.Rectangle{}, 0, fmt.Errorf("%w: %d pixel bytes do not match bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, have, x0, y0, x1, y1)
+	}
+	if w == 0 || h == 0 {
+		return image.Rectangle{}, 0, nil
+	}
+	return image.Rect(x0, y0, x1, y1), int(n), nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding holds the
+// bounds and the pixels in big-endian order without stride padding.
+func (p *GrayS16Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeInt16, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayS16Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeInt16, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 2*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayF32Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeFloat32, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayF32Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeFloat32, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 4*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayU32Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeUint32, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayU32Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeUint32, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 4*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayU64Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeUint64, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayU64Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeUint64, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 8*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayS64Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeInt64, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayS64Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeInt64, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 8*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayC64Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeComplex64, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayC64Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeComplex64, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 8*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *GrayC128Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(DTypeComplex128, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *GrayC128Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(DTypeComplex128, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 16*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *BGRImage) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeBGR, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *BGRImage) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeBGR, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 3*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *BGRAImage) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeBGRA, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *BGRAImage) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeBGRA, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 4*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *BGR48Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeBGR48, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *BGR48Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeBGR48, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 6*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *BGRA64Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeBGRA64, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *BGRA64Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeBGRA64, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 8*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *RGB565Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeRGB565, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *RGB565Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeRGB565, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 2*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *RGB555Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeRGB555, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *RGB555Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeRGB555, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 2*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *CMYK64Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeCMYK64, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *CMYK64Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeCMYK64, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 8*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *RGBAF32Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeRGBAF32, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *RGBAF32Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeRGBAF32, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 16*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *NRGBAF32Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeNRGBAF32, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *NRGBAF32Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeNRGBAF32, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 16*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary.
+func (p *LinearRGBAF32Image) MarshalBinary() ([]byte, error) {
+	return marshalPix(typeLinearRGBAF32, p.Rect, p.Pix, p.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *LinearRGBAF32Image) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeLinearRGBAF32, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect = pix, 16*r.Dx(), r
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary. The rows are realigned to start on a group
+// boundary if needed.
+func (p *Gray10PackedImage) MarshalBinary() ([]byte, error) {
+	q := p.aligned()
+	return marshalPix(typeGray10Packed, q.Rect, q.Pix, q.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *Gray10PackedImage) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeGray10Packed, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect, p.Offset = pix, typeGray10Packed.rowBytes(r.Dx()), r, 0
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary. The rows are realigned to start on a group
+// boundary if needed.
+func (p *Gray12PackedImage) MarshalBinary() ([]byte, error) {
+	q := p.aligned()
+	return marshalPix(typeGray12Packed, q.Rect, q.Pix, q.Stride), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds and pixels.
+func (p *Gray12PackedImage) UnmarshalBinary(data []byte) error {
+	r, pix, err := unmarshalPix(typeGray12Packed, data)
+	if err != nil {
+		return err
+	}
+	p.Pix, p.Stride, p.Rect, p.Offset = pix, typeGray12Packed.rowBytes(r.Dx()), r, 0
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler like
+// GrayS16Image.MarshalBinary. The pixels are followed by the number of
+// palette colors as a uvarint and each color as alpha-premultiplied 16-bit
+// R, G, B and A values.
+func (p *Paletted16Image) MarshalBinary() ([]byte, error) {
+	buf := marshalPix(typePaletted16, p.Rect, p.Pix, p.Stride)
+	buf = binary.AppendUvarint(buf, uint64(len(p.Palette)))
+	for _, c := range p.Palette {
+		r, g, b, a := c.RGBA()
+		buf = binary.BigEndian.AppendUint16(buf, uint16(r))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(g))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(b))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(a))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing p's
+// bounds, pixels and palette. The palette colors are color.RGBA64 values.
+func (p *Paletted16Image) UnmarshalBinary(data []byte) error {
+	r, pix, rest, err := unmarshalPixPrefix(typePaletted16, data)
+	if err != nil {
+		return err
+	}
+	n, k := binary.Uvarint(rest)
+	if k <= 0 || n != uint64(len(rest)-k)/8 || (len(rest)-k)%8 != 0 {
+		return decodeError("binary image", int64(len(data)-len(rest)), fmt.Errorf("%w: malformed palette", ErrInvalidLayout))
+	}
+	palette := make(color.Palette, n)
+	for i := range palette {
+		c := rest[k+8*i:]
+		palette[i] = color.RGBA64{
+			R: binary.BigEndian.Uint16(c[0:]),
+			G: binary.BigEndian.Uint16(c[2:]),
+			B: binary.BigEndian.Uint16(c[4:]),
+			A: binary.BigEndian.Uint16(c[6:]),
+		}
+	}
+	p.Pix, p.Stride, p.Rect, p.Palette = pix, 2*r.Dx(), r, palette
+	return nil
+}
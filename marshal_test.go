@@ -0,0 +1,193 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestGrayS16ImageBinaryRoundTrip(t *testing.T) {
+	src := randomGrayS16(image.Rect(-3, 2, 10, 9), 11)
+	for _, img := range []*GrayS16Image{
+		src,
+		src.SubImage(image.Rect(0, 3, 7, 8)).(*GrayS16Image),
+		NewGrayS16Image(image.Rectangle{}),
+	} {
+		data, err := img.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got GrayS16Image
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%v: %v", img.Rect, err)
+		}
+		if got.Rect != img.Rect || !Equal(&got, img) {
+			t.Errorf("%v: round trip mismatch, got bounds %v", img.Rect, got.Rect)
+		}
+		if got.Stride != 2*got.Rect.Dx() || len(got.Pix) != got.Stride*got.Rect.Dy() {
+			t.Errorf("%v: stride %d, %d bytes; want compact pixels", img.Rect, got.Stride, len(got.Pix))
+		}
+	}
+}
+
+func TestBinaryRoundTripTypes(t *testing.T) {
+	r := image.Rect(1, -2, 6, 3)
+	rng := rand.New(rand.NewSource(3))
+	tests := []struct {
+		name string
+		img  interface {
+			image.Image
+			encoding.BinaryMarshaler
+		}
+		dst encoding.BinaryUnmarshaler
+	}{
+		{"F32", NewGrayF32Image(r), new(GrayF32Image)},
+		{"U32", NewGrayU32Image(r), new(GrayU32Image)},
+		{"U64", NewGrayU64Image(r), new(GrayU64Image)},
+		{"S64", NewGrayS64Image(r), new(GrayS64Image)},
+		{"C64", NewGrayC64Image(r), new(GrayC64Image)},
+		{"C128", NewGrayC128Image(r), new(GrayC128Image)},
+	}
+	for _, tt := range tests {
+		pix, _, _, _ := pixelLayout(tt.img)
+		rng.Read(pix)
+		data, err := tt.img.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.dst.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := tt.dst.(image.Image)
+		gotPix, _, _, _ := pixelLayout(got)
+		if got.Bounds() != r || !bytes.Equal(gotPix, pix) {
+			t.Errorf("%s: round trip mismatch", tt.name)
+		}
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	good, _ := randomGrayS16(image.Rect(0, 0, 4, 3), 1).MarshalBinary()
+	f32, _ := NewGrayF32Image(image.Rect(0, 0, 2, 2)).MarshalBinary()
+	badVersion := append([]byte(nil), good...)
+	badVersion[4] = 9
+	bigRect := append([]byte(binaryMagic), binaryVersion, byte(DTypeInt16), bigEndian)
+	for _, v := range []int64{0, 0, 1 << 40, 1 << 40} {
+		bigRect = binary.AppendVarint(bigRect, v)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", append([]byte("XXXX"), good[4:]...)},
+		{"bad version", badVersion},
+		{"wrong type", f32},
+		{"truncated", good[:len(good)-1]},
+		{"extra bytes", append(append([]byte(nil), good...), 0)},
+		{"huge bounds", bigRect},
+	}
+	for _, tt := range tests {
+		var img GrayS16Image
+		if err := img.UnmarshalBinary(tt.data); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestGobImage(t *testing.T) {
+	src := randomGrayS16(image.Rect(2, 2, 9, 5), 5)
+	var buf bytes.Buffer
+	var in image.Image = src
+	if err := gob.NewEncoder(&buf).Encode(&in); err != nil {
+		t.Fatal(err)
+	}
+	var out image.Image
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := out.(*GrayS16Image)
+	if !ok || !Equal(got, src) {
+		t.Errorf("gob round trip = %T, want equal *GrayS16Image", out)
+	}
+}
+
+// colorImages returns random color, packed and paletted images, along with
+// a fresh value of each type to decode into.
+func colorImages() []struct {
+	img draw.Image
+	dst image.Image
+} {
+	r := image.Rect(-1, 2, 6, 6)
+	palette := color.Palette{color.Black, color.White, color.NRGBA{R: 200, G: 30, B: 90, A: 128}}
+	images := []struct {
+		img draw.Image
+		dst image.Image
+	}{
+		{NewBGRImage(r), new(BGRImage)},
+		{NewBGRAImage(r), new(BGRAImage)},
+		{NewBGR48Image(r), new(BGR48Image)},
+		{NewBGRA64Image(r), new(BGRA64Image)},
+		{NewRGB565Image(r), new(RGB565Image)},
+		{NewRGB555Image(r), new(RGB555Image)},
+		{NewCMYK64Image(r), new(CMYK64Image)},
+		{NewRGBAF32Image(r), new(RGBAF32Image)},
+		{NewNRGBAF32Image(r), new(NRGBAF32Image)},
+		{NewLinearRGBAF32Image(r), new(LinearRGBAF32Image)},
+		{NewGray10PackedImage(r), new(Gray10PackedImage)},
+		{NewGray12PackedImage(r), new(Gray12PackedImage)},
+		{NewPaletted16Image(r, palette), new(Paletted16Image)},
+	}
+	rng := rand.New(rand.NewSource(4))
+	for _, tt := range images {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				tt.img.Set(x, y, color.NRGBA64{R: uint16(rng.Uint32()), G: uint16(rng.Uint32()), B: uint16(rng.Uint32()), A: uint16(rng.Uint32())})
+			}
+		}
+	}
+	return images
+}
+
+func TestBinaryRoundTripColorTypes(t *testing.T) {
+	for _, tt := range colorImages() {
+		sub := tt.img.(interface {
+			SubImage(image.Rectangle) image.Image
+		})
+		for _, img := range []image.Image{tt.img, sub.SubImage(image.Rect(0, 3, 5, 5)), sub.SubImage(image.Rectangle{})} {
+			data, err := img.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.dst.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+				t.Fatalf("%T %v: %v", img, img.Bounds(), err)
+			}
+			if tt.dst.Bounds() != img.Bounds() || !Equal(tt.dst, img) {
+				t.Errorf("%T %v: round trip mismatch", img, img.Bounds())
+			}
+		}
+	}
+}
+
+func TestUnmarshalBinaryColorErrors(t *testing.T) {
+	bgr, _ := NewBGRImage(image.Rect(0, 0, 2, 2)).MarshalBinary()
+	var bgra BGRAImage
+	if err := bgra.UnmarshalBinary(bgr); !errors.Is(err, ErrUnsupportedDType) || !strings.Contains(err.Error(), "bgr pixels") {
+		t.Errorf("BGR data into a BGRAImage: err = %v", err)
+	}
+	good, _ := NewPaletted16Image(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White}).MarshalBinary()
+	for _, data := range [][]byte{good[:len(good)-1], append(append([]byte(nil), good...), 0), good[:len(good)-17]} {
+		var p Paletted16Image
+		if err := p.UnmarshalBinary(data); !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("%d bytes of paletted image: err = %v, want an error wrapping %v", len(data), err, ErrInvalidLayout)
+		}
+	}
+}
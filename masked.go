@@ -0,0 +1,131 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// Validator is implemented by images that can mark individual pixels as
+// invalid, such as holes in elevation or depth data. Operations that
+// understand validity skip pixels for which Valid returns false.
+type Validator interface {
+	Valid(x, y int) bool
+}
+
+// MaskedGrayS16Image is a GrayS16Image with an optional NoData sentinel value
+// and an optional per-pixel validity mask. A pixel is valid if it lies inside
+// the bounds, does not hold the NoData value (when HasNoData is set) and is
+// not cleared in Mask (when Mask is non-nil).
+//
+// At and GrayS16At still return the stored value of invalid pixels; use Valid
+// or ValidMask to tell them apart.
+type MaskedGrayS16Image struct {
+	*GrayS16Image
+	// NoData is the sentinel value that marks a pixel as invalid.
+	// It is only used if HasNoData is true.
+	NoData int16
+	// HasNoData reports whether NoData is in effect.
+	HasNoData bool
+	// Mask marks valid pixels with a non-zero alpha. A nil Mask marks every
+	// pixel as valid. If non-nil, Mask must cover the image's bounds.
+	Mask *image.Alpha
+}
+
+// NewMaskedGrayS16Image returns a MaskedGrayS16Image wrapping img that treats
+// pixels equal to noData as invalid.
+func NewMaskedGrayS16Image(img *GrayS16Image, noData int16) *MaskedGrayS16Image {
+	return &MaskedGrayS16Image{
+		GrayS16Image: img,
+		NoData:       noData,
+		HasNoData:    true,
+	}
+}
+
+// Valid reports whether the pixel at (x, y) holds valid data.
+func (p *MaskedGrayS16Image) Valid(x, y int) bool {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return false
+	}
+	if p.HasNoData && p.GrayS16At(x, y).Y == p.NoData {
+		return false
+	}
+	if p.Mask != nil && p.Mask.AlphaAt(x, y).A == 0 {
+		return false
+	}
+	return true
+}
+
+// SetValid marks the pixel at (x, y) as valid or invalid in the mask,
+// allocating the mask on first use. The pixel value itself is unchanged.
+func (p *MaskedGrayS16Image) SetValid(x, y int, valid bool) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	if p.Mask == nil {
+		if valid {
+			return
+		}
+		p.Mask = image.NewAlpha(p.Rect)
+		for i := range p.Mask.Pix {
+			p.Mask.Pix[i] = 0xff
+		}
+	}
+	a := uint8(0)
+	if valid {
+		a = 0xff
+	}
+	p.Mask.SetAlpha(x, y, color.Alpha{A: a})
+}
+
+// Invalidate marks the pixel at (x, y) as invalid. If HasNoData is set the
+// pixel is overwritten with the NoData value; otherwise it is cleared in the
+// mask.
+func (p *MaskedGrayS16Image) Invalidate(x, y int) {
+	if p.HasNoData {
+		p.SetGrayS16(x, y, GrayS16{Y: p.NoData})
+		return
+	}
+	p.SetValid(x, y, false)
+}
+
+// ValidMask returns an alpha mask that is opaque where pixels are valid and
+// transparent elsewhere. It can be passed to draw.DrawMask so that rendering
+// skips invalid pixels.
+func (p *MaskedGrayS16Image) ValidMask() *image.Alpha {
+	m := image.NewAlpha(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.Valid(x, y) {
+				m.Pix[m.PixOffset(x, y)] = 0xff
+			}
+		}
+	}
+	return m
+}
+
+// ValidCount returns the number of valid pixels in the image.
+func (p *MaskedGrayS16Image) ValidCount() int {
+	n := 0
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.Valid(x, y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels and mask with the original image.
+func (p *MaskedGrayS16Image) SubImage(r image.Rectangle) image.Image {
+	sub := &MaskedGrayS16Image{
+		GrayS16Image: p.GrayS16Image.SubImage(r).(*GrayS16Image),
+		NoData:       p.NoData,
+		HasNoData:    p.HasNoData,
+	}
+	if p.Mask != nil {
+		sub.Mask = p.Mask.SubImage(r).(*image.Alpha)
+	}
+	return sub
+}
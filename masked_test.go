@@ -0,0 +1,111 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMaskedGrayS16Image_NoData(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 3))
+	m := NewMaskedGrayS16Image(img, -9999)
+
+	img.SetGrayS16(1, 1, GrayS16{Y: -9999})
+	img.SetGrayS16(2, 2, GrayS16{Y: 0})
+
+	tests := []struct {
+		name string
+		x, y int
+		want bool
+	}{
+		{"nodata pixel", 1, 1, false},
+		{"zero is valid", 2, 2, true},
+		{"out of bounds", 3, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Valid(tt.x, tt.y); got != tt.want {
+				t.Errorf("Valid(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	if got := m.ValidCount(); got != 8 {
+		t.Errorf("ValidCount() = %d, want 8", got)
+	}
+}
+
+func TestMaskedGrayS16Image_Mask(t *testing.T) {
+	m := &MaskedGrayS16Image{GrayS16Image: NewGrayS16Image(image.Rect(0, 0, 2, 2))}
+
+	// Marking a pixel valid without a mask should not allocate one.
+	m.SetValid(0, 0, true)
+	if m.Mask != nil {
+		t.Error("SetValid(true) allocated a mask")
+	}
+
+	m.Invalidate(1, 0)
+	if m.Mask == nil {
+		t.Fatal("Invalidate did not allocate a mask")
+	}
+	if m.Valid(1, 0) {
+		t.Error("Valid(1, 0) = true after Invalidate")
+	}
+	if !m.Valid(0, 1) {
+		t.Error("Valid(0, 1) = false, want true")
+	}
+
+	m.SetValid(1, 0, true)
+	if !m.Valid(1, 0) {
+		t.Error("Valid(1, 0) = false after SetValid(true)")
+	}
+}
+
+func TestMaskedGrayS16Image_Invalidate_NoData(t *testing.T) {
+	m := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 2, 2)), -1)
+	m.Invalidate(0, 0)
+
+	if got := m.GrayS16At(0, 0); got.Y != -1 {
+		t.Errorf("GrayS16At(0, 0) = %d, want NoData -1", got.Y)
+	}
+	if m.Mask != nil {
+		t.Error("Invalidate with NoData allocated a mask")
+	}
+}
+
+func TestMaskedGrayS16Image_ValidMask(t *testing.T) {
+	m := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 2, 1)), 7)
+	m.SetGrayS16(0, 0, GrayS16{Y: 7})
+	m.SetGrayS16(1, 0, GrayS16{Y: 32767})
+
+	// Drawing through the mask should leave invalid pixels untouched.
+	dst := image.NewGray(image.Rect(0, 0, 2, 1))
+	draw.DrawMask(dst, dst.Bounds(), m, image.Point{}, m.ValidMask(), image.Point{}, draw.Src)
+
+	if got := dst.GrayAt(0, 0); got.Y != 0 {
+		t.Errorf("dst.GrayAt(0, 0) = %d, want 0 (masked out)", got.Y)
+	}
+	if got := dst.GrayAt(1, 0); got != (color.Gray{Y: 255}) {
+		t.Errorf("dst.GrayAt(1, 0) = %d, want 255", got.Y)
+	}
+}
+
+func TestMaskedGrayS16Image_SubImage(t *testing.T) {
+	m := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 4, 4)), -1)
+	m.SetValid(2, 2, false)
+	m.SetGrayS16(3, 3, GrayS16{Y: -1})
+
+	sub, ok := m.SubImage(image.Rect(2, 2, 4, 4)).(*MaskedGrayS16Image)
+	if !ok {
+		t.Fatalf("SubImage returned %T, want *MaskedGrayS16Image", sub)
+	}
+	if sub.Valid(2, 2) || sub.Valid(3, 3) {
+		t.Error("SubImage lost validity information")
+	}
+	if !sub.Valid(3, 2) {
+		t.Error("sub.Valid(3, 2) = false, want true")
+	}
+	var _ Validator = sub
+}
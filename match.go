@@ -0,0 +1,118 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+)
+
+// MatchMethod selects the score computed by MatchTemplate.
+type MatchMethod int
+
+const (
+	// MatchNCC is the zero-mean normalized cross-correlation, in [-1, 1].
+	// It is insensitive to gain and offset changes between the image and
+	// the template, and the best match has the highest score.
+	MatchNCC MatchMethod = iota
+	// MatchSSD is the sum of squared differences. The best match has the
+	// lowest score.
+	MatchSSD
+)
+
+// MatchTemplate slides tpl over img and scores every placement with the
+// method m. The returned map holds the score of the placement whose top
+// left corner is at (x, y), so its bounds are img's bounds shrunk by the
+// template size minus one. best is the top left corner of the best
+// placement; ties go to the first in row-major order.
+//
+// Window sums and sums of squares come from integral images, so only the
+// cross-correlation costs time proportional to the template size. NCC
+// scores of windows or templates with no variance are 0.
+func MatchTemplate(img, tpl *GrayS16Image, m MatchMethod) (scores *GrayF32Image, best image.Point, err error) {
+	r, tr := img.Rect, tpl.Rect
+	tw, th := tr.Dx(), tr.Dy()
+	if tw == 0 || th == 0 || tw > r.Dx() || th > r.Dy() {
+		return nil, image.Point{}, fmt.Errorf("%w: template size %v does not fit in image bounds %v", ErrBoundsMismatch, tr.Size(), r)
+	}
+	out := image.Rect(r.Min.X, r.Min.Y, r.Max.X-tw+1, r.Max.Y-th+1)
+	scores = NewGrayF32Image(out)
+
+	// Template values as float64, and their sums.
+	n := float64(tw * th)
+	t := make([]float64, tw*th)
+	var tSum, tSq float64
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			v := float64(tpl.GrayS16At(tr.Min.X+x, tr.Min.Y+y).Y)
+			t[y*tw+x] = v
+			tSum += v
+			tSq += v * v
+		}
+	}
+	tMean := tSum / n
+	tVar := tSq - tSum*tSum/n
+	if m == MatchNCC {
+		// Correlating with the zero-mean template makes the image mean
+		// cancel out of the numerator.
+		for i := range t {
+			t[i] -= tMean
+		}
+	}
+
+	sum := Integral(img)
+	sq := integralSquares(img)
+	bestScore := math.Inf(1)
+	if m == MatchNCC {
+		bestScore = math.Inf(-1)
+	}
+	for y := out.Min.Y; y < out.Max.Y; y++ {
+		for x := out.Min.X; x < out.Max.X; x++ {
+			var cross float64
+			for ty := 0; ty < th; ty++ {
+				i := img.PixOffset(x, y+ty)
+				s := img.Pix[i : i+2*tw]
+				for tx := 0; tx < tw; tx++ {
+					cross += float64(int16(binary.BigEndian.Uint16(s[2*tx:]))) * t[ty*tw+tx]
+				}
+			}
+			win := image.Rect(x, y, x+tw, y+th)
+			wSum, wSq := float64(sum.SumRect(win)), float64(sq.SumRect(win))
+
+			var score float64
+			if m == MatchSSD {
+				score = wSq - 2*cross + tSq
+			} else {
+				wVar := wSq - wSum*wSum/n
+				if wVar > 0 && tVar > 0 {
+					score = max(-1, min(1, cross/math.Sqrt(wVar*tVar)))
+				}
+			}
+			scores.SetGrayF32(x, y, GrayF32{Y: float32(score)})
+			if (m == MatchSSD && score < bestScore) || (m == MatchNCC && score > bestScore) {
+				bestScore, best = score, image.Pt(x, y)
+			}
+		}
+	}
+	return scores, best, nil
+}
+
+// integralSquares is like Integral for the squares of the source pixels.
+func integralSquares(src *GrayS16Image) *GrayS64Image {
+	r := src.Rect
+	dst := NewGrayS64Image(r)
+	w := r.Dx()
+	above := make([]int64, w)
+	for y := 0; y < r.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+2*w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+8*w]
+		var row int64
+		for x := range above {
+			v := int64(int16(uint16(s[2*x])<<8 | uint16(s[2*x+1])))
+			row += v * v
+			above[x] += row
+			binary.BigEndian.PutUint64(d[8*x:], uint64(above[x]))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,81 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestMatchTemplate(t *testing.T) {
+	img := NoiseGrayS16(image.Rect(-4, 2, 36, 30), &NoiseOptions{Amplitude: 3000, Seed: 11})
+	at := image.Pt(17, 9)
+	tpl := NewGrayS16Image(image.Rect(0, 0, 7, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			tpl.SetGrayS16(x, y, img.GrayS16At(at.X+x, at.Y+y))
+		}
+	}
+
+	// A brighter, higher-contrast copy of the template still matches with
+	// NCC.
+	gained := NewGrayS16Image(tpl.Rect)
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			gained.SetGrayS16(x, y, GrayS16{Y: 2*tpl.GrayS16At(x, y).Y/3 + 500})
+		}
+	}
+
+	tests := []struct {
+		name  string
+		tpl   *GrayS16Image
+		m     MatchMethod
+		score float32
+	}{
+		{"NCC", tpl, MatchNCC, 1},
+		{"NCC gain and offset", gained, MatchNCC, 1},
+		{"SSD", tpl, MatchSSD, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, best, err := MatchTemplate(img, tt.tpl, tt.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := image.Rect(-4, 2, 30, 26); scores.Rect != want {
+				t.Errorf("score map bounds = %v, want %v", scores.Rect, want)
+			}
+			if best != at {
+				t.Errorf("best = %v, want %v", best, at)
+			}
+			if got := scores.GrayF32At(at.X, at.Y).Y; math.Abs(float64(got-tt.score)) > 1e-3 {
+				t.Errorf("best score = %v, want %v", got, tt.score)
+			}
+		})
+	}
+}
+
+func TestMatchTemplateScores(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 1))
+	for x, v := range []int16{1, 3, 5} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	tpl := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	tpl.SetGrayS16(0, 0, GrayS16{Y: 2})
+	tpl.SetGrayS16(1, 0, GrayS16{Y: 3})
+
+	ssd, best, _ := MatchTemplate(img, tpl, MatchSSD)
+	// (1-2)^2 + (3-3)^2 = 1 and (3-2)^2 + (5-3)^2 = 5.
+	if got := []float32{ssd.GrayF32At(0, 0).Y, ssd.GrayF32At(1, 0).Y}; got[0] != 1 || got[1] != 5 || best.X != 0 {
+		t.Errorf("SSD = %v best %v, want [1 5] best 0", got, best)
+	}
+
+	flat := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	ncc, _, _ := MatchTemplate(img, flat, MatchNCC)
+	if got := ncc.GrayF32At(0, 0).Y; got != 0 {
+		t.Errorf("NCC with a flat template = %v, want 0", got)
+	}
+
+	if _, _, err := MatchTemplate(tpl, img, MatchNCC); err == nil {
+		t.Error("template larger than image: expected an error")
+	}
+}
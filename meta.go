@@ -0,0 +1,104 @@
+package colorext
+
+import (
+	"image"
+	"io"
+	"maps"
+	"time"
+)
+
+// Metadata holds key/value annotations of an image, such as the physical
+// units of its samples or when it was acquired. Keys are lower-case
+// snake_case strings; the well-known keys below have fixed value types.
+type Metadata map[string]any
+
+// Well-known Metadata keys and the types of their values.
+const (
+	MetaUnits           = "units"            // string, such as "HU", "m" or "K"
+	MetaScale           = "scale"            // float64 multiplying stored values
+	MetaOffset          = "offset"           // float64 added after scaling
+	MetaAcquisitionTime = "acquisition_time" // time.Time
+	MetaGeo             = "geo"              // *GeoMetadata
+	MetaNoData          = "nodata"           // float64 marking missing samples
+)
+
+// Clone returns a shallow copy of m.
+func (m Metadata) Clone() Metadata {
+	return maps.Clone(m)
+}
+
+// Float returns the value of key if it is a float64.
+func (m Metadata) Float(key string) (float64, bool) {
+	v, ok := m[key].(float64)
+	return v, ok
+}
+
+// String returns the value of key if it is a string.
+func (m Metadata) String(key string) (string, bool) {
+	v, ok := m[key].(string)
+	return v, ok
+}
+
+// Time returns the value of key if it is a time.Time.
+func (m Metadata) Time(key string) (time.Time, bool) {
+	v, ok := m[key].(time.Time)
+	return v, ok
+}
+
+// ImageWithMeta is an image carrying Metadata. Codecs that understand
+// metadata return it and keep it across a decode and encode round trip.
+type ImageWithMeta struct {
+	image.Image
+	Meta Metadata
+}
+
+// Annotate returns img annotated with meta.
+func Annotate(img image.Image, meta Metadata) *ImageWithMeta {
+	return &ImageWithMeta{Image: img, Meta: meta}
+}
+
+// Unwrap returns the annotated image.
+func (p *ImageWithMeta) Unwrap() image.Image {
+	return p.Image
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r, with the same Metadata. It returns nil if the annotated image
+// has no SubImage method. The returned value shares pixels and metadata with
+// the original image.
+func (p *ImageWithMeta) SubImage(r image.Rectangle) image.Image {
+	s, ok := p.Image.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil
+	}
+	return &ImageWithMeta{Image: s.SubImage(r), Meta: p.Meta}
+}
+
+// MetaOf returns the Metadata of img if it is an *ImageWithMeta or one of
+// the package's images with a Meta field, such as one made with WithMeta.
+// It returns nil for other images.
+func MetaOf(img image.Image) Metadata {
+	switch m := img.(type) {
+	case *ImageWithMeta:
+		return m.Meta
+	case interface{ metadata() Metadata }:
+		return m.metadata()
+	}
+	return nil
+}
+
+// DecodeGeoTIFFWithMeta is like DecodeGeoTIFF, returning the image with its
+// GeoMetadata under MetaGeo and its nodata value, if any, under MetaNoData.
+func DecodeGeoTIFFWithMeta(r io.Reader) (*ImageWithMeta, error) {
+	img, geo, err := DecodeGeoTIFF(r)
+	if err != nil {
+		return nil, err
+	}
+	meta := Metadata{MetaGeo: geo}
+	if geo.HasNoData {
+		meta[MetaNoData] = geo.NoData
+	}
+	return Annotate(img, meta), nil
+}
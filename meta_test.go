@@ -0,0 +1,87 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := Metadata{MetaUnits: "K", MetaScale: 0.01, MetaAcquisitionTime: when, "frame": 7}
+	if v, ok := m.String(MetaUnits); !ok || v != "K" {
+		t.Errorf("String(units) = %q, %v", v, ok)
+	}
+	if v, ok := m.Float(MetaScale); !ok || v != 0.01 {
+		t.Errorf("Float(scale) = %v, %v", v, ok)
+	}
+	if v, ok := m.Time(MetaAcquisitionTime); !ok || !v.Equal(when) {
+		t.Errorf("Time(acquisition_time) = %v, %v", v, ok)
+	}
+	if _, ok := m.Float("frame"); ok {
+		t.Error("Float(frame) accepted an int")
+	}
+	if _, ok := m.String(MetaOffset); ok {
+		t.Error("String of a missing key reported ok")
+	}
+	c := m.Clone()
+	c[MetaUnits] = "C"
+	if m[MetaUnits] != "K" {
+		t.Error("Clone shares its map")
+	}
+}
+
+func TestImageWithMeta(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 4, 4))
+	img.SetGrayS16(2, 2, GrayS16{Y: -300})
+	m := Annotate(img, Metadata{MetaUnits: "m"})
+	if MetaOf(m)[MetaUnits] != "m" || MetaOf(img) != nil {
+		t.Error("MetaOf did not find the metadata")
+	}
+	if m.At(2, 2) != img.At(2, 2) || m.Unwrap() != image.Image(img) {
+		t.Error("wrapper does not expose the annotated image")
+	}
+
+	sub := m.SubImage(image.Rect(1, 1, 3, 3))
+	if sub.Bounds() != image.Rect(1, 1, 3, 3) || MetaOf(sub)[MetaUnits] != "m" {
+		t.Errorf("SubImage = %v with metadata %v", sub.Bounds(), MetaOf(sub))
+	}
+	if Annotate(image.NewUniform(GrayS16{}), nil).SubImage(image.Rect(0, 0, 1, 1)) != nil {
+		t.Error("SubImage of an image without SubImage should be nil")
+	}
+
+	// Samplers see the stored values, not the wrapper's luminance.
+	if v := scalarSampler(m)(2, 2); v != -300 {
+		t.Errorf("scalarSampler = %v, want -300", v)
+	}
+}
+
+func TestDecodeGeoTIFFWithMeta(t *testing.T) {
+	bo := binary.LittleEndian
+	data := buildTIFF(bo, []tiffEntry{
+		{tag: tagImageWidth, shorts: []uint16{1}},
+		{tag: tagImageLength, shorts: []uint16{1}},
+		{tag: tagBitsPerSample, shorts: []uint16{16}},
+		{tag: tagSampleFormat, shorts: []uint16{sampleFormatInt}},
+		{tag: tagRowsPerStrip, shorts: []uint16{1}},
+		{tag: tagGDALNoData, ascii: "-9999"},
+	}, tagStripOffsets, [][]byte{bo.AppendUint16(nil, 7)})
+	m, err := DecodeGeoTIFFWithMeta(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m.Meta.Float(MetaNoData); !ok || v != -9999 {
+		t.Errorf("nodata = %v, %v", v, ok)
+	}
+	if geo, ok := m.Meta[MetaGeo].(*GeoMetadata); !ok || !geo.HasNoData {
+		t.Errorf("geo = %v", m.Meta[MetaGeo])
+	}
+	if got := m.Image.(*GrayS16Image).GrayS16At(0, 0).Y; got != 7 {
+		t.Errorf("pixel = %d, want 7", got)
+	}
+	if _, err := DecodeGeoTIFFWithMeta(bytes.NewReader(nil)); err == nil {
+		t.Error("empty input: expected an error")
+	}
+}
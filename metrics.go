@@ -0,0 +1,149 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// The quality metrics read pixels as raw values in the manner of Contours:
+// GrayS16Image and GrayF32Image pixels use their stored values, stdlib gray
+// images their unsigned values, and other images, including RGB ones, their
+// GrayF32 luminance in [0, 1]. Pixels that are NaN or invalid in either
+// image are skipped. The peak argument is the dynamic range of the data,
+// such as 65535 for full-range GrayS16 data or 1 for luminance.
+
+// MSE returns the mean squared error between a and b, which must have the
+// same bounds. It returns NaN if no pixel is valid in both images.
+func MSE(a, b image.Image) (float64, error) {
+	if a.Bounds() != b.Bounds() {
+		return 0, fmt.Errorf("%w: MSE of images with bounds %v and %v", ErrBoundsMismatch, a.Bounds(), b.Bounds())
+	}
+	r := a.Bounds()
+	atA, atB := scalarSampler(a), scalarSampler(b)
+	var sum float64
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			va, vb := atA(x, y), atB(x, y)
+			if math.IsNaN(va) || math.IsNaN(vb) {
+				continue
+			}
+			sum += (va - vb) * (va - vb)
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN(), nil
+	}
+	return sum / float64(n), nil
+}
+
+// PSNR returns the peak signal-to-noise ratio between a and b in decibels.
+// Identical images have an infinite PSNR.
+func PSNR(a, b image.Image, peak float64) (float64, error) {
+	mse, err := MSE(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return 10 * math.Log10(peak*peak/mse), nil
+}
+
+// SSIM returns the mean structural similarity index between a and b, using
+// the 11×11 Gaussian window with a standard deviation of 1.5 and the
+// constants K1 = 0.01 and K2 = 0.03 of Wang et al. Only windows lying fully
+// inside the images and holding no skipped pixels contribute; images smaller
+// than the window are compared as a single window. The result is 1 for
+// identical images, and NaN if no window contributes.
+//
+// The luminance term assumes non-negative samples, so the signed samples of
+// GrayS16Image and MaskedGrayS16Image are first offset by 32768 into
+// [0, 65535], the range of a peak of 65535.
+func SSIM(a, b image.Image, peak float64) (float64, error) {
+	if a.Bounds() != b.Bounds() {
+		return 0, fmt.Errorf("%w: SSIM of images with bounds %v and %v", ErrBoundsMismatch, a.Bounds(), b.Bounds())
+	}
+	r := a.Bounds()
+	w, h := r.Dx(), r.Dy()
+	atA, atB := scalarSampler(a), scalarSampler(b)
+	offA, offB := ssimOffset(a), ssimOffset(b)
+	va := make([]float64, w*h)
+	vb := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			va[y*w+x] = atA(r.Min.X+x, r.Min.Y+y) + offA
+			vb[y*w+x] = atB(r.Min.X+x, r.Min.Y+y) + offB
+		}
+	}
+
+	// The window is shrunk to fit small images; weights are normalized so
+	// they sum to 1 over the window.
+	wx, wy := min(11, w), min(11, h)
+	kx, ky := ssimWeights(wx), ssimWeights(wy)
+
+	c1 := (0.01 * peak) * (0.01 * peak)
+	c2 := (0.03 * peak) * (0.03 * peak)
+	var sum float64
+	n := 0
+	for y0 := 0; y0+wy <= h; y0++ {
+	windows:
+		for x0 := 0; x0+wx <= w; x0++ {
+			var ma, mb, saa, sbb, sab float64
+			for j := 0; j < wy; j++ {
+				for i := 0; i < wx; i++ {
+					k := (y0+j)*w + x0 + i
+					pa, pb := va[k], vb[k]
+					if math.IsNaN(pa) || math.IsNaN(pb) {
+						continue windows
+					}
+					wt := kx[i] * ky[j]
+					ma += wt * pa
+					mb += wt * pb
+					saa += wt * pa * pa
+					sbb += wt * pb * pb
+					sab += wt * pa * pb
+				}
+			}
+			saa -= ma * ma
+			sbb -= mb * mb
+			sab -= ma * mb
+			sum += (2*ma*mb + c1) * (2*sab + c2) / ((ma*ma + mb*mb + c1) * (saa + sbb + c2))
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN(), nil
+	}
+	return sum / float64(n), nil
+}
+
+// ssimOffset returns the offset SSIM adds to the samples of img, moving
+// signed 16-bit samples into the unsigned range.
+func ssimOffset(img image.Image) float64 {
+	switch m := img.(type) {
+	case *ImageWithMeta:
+		return ssimOffset(m.Image)
+	case *FrozenImage:
+		return ssimOffset(m.img)
+	case *GrayS16Image, *MaskedGrayS16Image:
+		return 1 << 15
+	}
+	return 0
+}
+
+// ssimWeights returns n Gaussian weights with a standard deviation of 1.5,
+// centered on the window and summing to 1.
+func ssimWeights(n int) []float64 {
+	k := make([]float64, n)
+	c := float64(n-1) / 2
+	var sum float64
+	for i := range k {
+		d := float64(i) - c
+		k[i] = math.Exp(-d * d / (2 * 1.5 * 1.5))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
@@ -0,0 +1,143 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMSE_PSNR(t *testing.T) {
+	r := image.Rect(0, 0, 4, 2)
+	a := NewGrayS16Image(r)
+	b := NewGrayS16Image(r)
+	// Differences of 0, 2, -2, 4 and four zeros: MSE = (4+4+16)/8 = 3.
+	b.SetGrayS16(1, 0, GrayS16{Y: 2})
+	b.SetGrayS16(2, 0, GrayS16{Y: -2})
+	b.SetGrayS16(3, 1, GrayS16{Y: 4})
+
+	mse, err := MSE(a, b)
+	if err != nil || mse != 3 {
+		t.Errorf("MSE = %v, %v, want 3, nil", mse, err)
+	}
+	psnr, err := PSNR(a, b, 65535)
+	if want := 10 * math.Log10(65535.0*65535/3); err != nil || !approxEqual(psnr, want, 1e-9) {
+		t.Errorf("PSNR = %v, %v, want %v, nil", psnr, err, want)
+	}
+	if psnr, _ := PSNR(a, a, 65535); !math.IsInf(psnr, 1) {
+		t.Errorf("PSNR of identical images = %v, want +Inf", psnr)
+	}
+
+	// Invalid pixels are skipped.
+	m := NewMaskedGrayS16Image(b, 4)
+	if mse, _ := MSE(a, m); !approxEqual(mse, 8.0/7, 1e-12) {
+		t.Errorf("MSE with NoData = %v, want %v", mse, 8.0/7)
+	}
+	if _, err := MSE(a, NewGrayS16Image(image.Rect(0, 0, 4, 3))); err == nil {
+		t.Error("MSE of mismatched bounds returned nil error")
+	}
+	empty := NewGrayF32Image(image.Rect(0, 0, 1, 1))
+	empty.SetGrayF32(0, 0, GrayF32{Y: float32(math.NaN())})
+	if mse, _ := MSE(empty, empty); !math.IsNaN(mse) {
+		t.Errorf("MSE with no valid pixels = %v, want NaN", mse)
+	}
+}
+
+func TestMSE_RGB(t *testing.T) {
+	// RGB images are compared by luminance in [0, 1].
+	r := image.Rect(0, 0, 2, 1)
+	a := image.NewRGBA(r)
+	b := image.NewRGBA(r)
+	a.Set(0, 0, color.White)
+	if mse, _ := MSE(a, b); !approxEqual(mse, 0.5, 1e-6) {
+		t.Errorf("MSE = %v, want 0.5", mse)
+	}
+}
+
+func TestSSIM(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	r := image.Rect(0, 0, 32, 24)
+	src := NewGrayF32Image(r)
+	noisy := NewGrayF32Image(r)
+	noisier := NewGrayF32Image(r)
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			v := 0.5 + 0.4*math.Sin(float64(x)/3)*math.Cos(float64(y)/4)
+			src.SetGrayF32(x, y, GrayF32{Y: float32(v)})
+			noisy.SetGrayF32(x, y, GrayF32{Y: float32(v + rng.NormFloat64()*0.02)})
+			noisier.SetGrayF32(x, y, GrayF32{Y: float32(v + rng.NormFloat64()*0.2)})
+		}
+	}
+
+	same, err := SSIM(src, src, 1)
+	if err != nil || !approxEqual(same, 1, 1e-9) {
+		t.Errorf("SSIM of identical images = %v, %v, want 1, nil", same, err)
+	}
+	s1, _ := SSIM(src, noisy, 1)
+	s2, _ := SSIM(src, noisier, 1)
+	if !(s1 < 1 && s2 < s1 && s2 > 0) {
+		t.Errorf("SSIM = %v with light noise and %v with heavy noise, want 1 > light > heavy > 0", s1, s2)
+	}
+
+	// A constant offset lowers luminance similarity only.
+	shifted := NewGrayF32Image(r)
+	for i := 0; i < len(src.Pix); i += 4 {
+		putF32(shifted.Pix[i:], getF32(src.Pix[i:])+0.1)
+	}
+	if s, _ := SSIM(src, shifted, 1); !(s > 0.9 && s < 1) {
+		t.Errorf("SSIM with offset = %v, want in (0.9, 1)", s)
+	}
+
+	// Small images use a single shrunken window.
+	small := src.SubImage(image.Rect(0, 0, 5, 4))
+	if s, err := SSIM(small, small, 1); err != nil || !approxEqual(s, 1, 1e-9) {
+		t.Errorf("SSIM of small identical images = %v, %v, want 1, nil", s, err)
+	}
+
+	// A NaN pixel removes the windows covering it.
+	holed := NewGrayF32Image(r)
+	copy(holed.Pix, src.Pix)
+	holed.SetGrayF32(16, 12, GrayF32{Y: float32(math.NaN())})
+	if s, _ := SSIM(holed, holed, 1); !approxEqual(s, 1, 1e-9) {
+		t.Errorf("SSIM with NaN pixel = %v, want 1", s)
+	}
+	if _, err := SSIM(src, small, 1); err == nil {
+		t.Error("SSIM of mismatched bounds returned nil error")
+	}
+}
+
+func TestSSIM_Signed(t *testing.T) {
+	// Signed samples score as the same data shifted into [0, 65535] does,
+	// even when their means have opposite signs.
+	r := image.Rect(0, 0, 16, 16)
+	tests := []struct {
+		name string
+		a, b func(x, y int) int16
+	}{
+		{"constant", func(x, y int) int16 { return -5000 }, func(x, y int) int16 { return 5000 }},
+		{"ramp", func(x, y int) int16 { return int16(x*300 - 4000) }, func(x, y int) int16 { return int16(y*200 - 1000) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa, sb := NewGrayS16Image(r), NewGrayS16Image(r)
+			ua, ub := image.NewGray16(r), image.NewGray16(r)
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					sa.SetGrayS16(x, y, GrayS16{Y: tt.a(x, y)})
+					sb.SetGrayS16(x, y, GrayS16{Y: tt.b(x, y)})
+					ua.SetGray16(x, y, color.Gray16{Y: uint16(int(tt.a(x, y)) + 32768)})
+					ub.SetGray16(x, y, color.Gray16{Y: uint16(int(tt.b(x, y)) + 32768)})
+				}
+			}
+			got, err := SSIM(sa, sb, 65535)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := SSIM(ua, ub, 65535)
+			if !approxEqual(got, want, 1e-9) || got <= 0 {
+				t.Errorf("SSIM = %v, want %v, the SSIM of the shifted data", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+)
+
+// NoiseKind selects the distribution produced by the noise generators.
+type NoiseKind int
+
+const (
+	// NoiseGaussian noise is normally distributed with standard deviation
+	// Amplitude.
+	NoiseGaussian NoiseKind = iota
+	// NoiseUniform noise is uniformly distributed in [-Amplitude, Amplitude).
+	NoiseUniform
+	// NoisePerlin is smooth gradient noise with features about Scale
+	// pixels across, in roughly [-Amplitude, Amplitude].
+	NoisePerlin
+	// NoiseSaltPepper sets a Density fraction of pixels to Amplitude or
+	// -Amplitude with equal probability. Rather than adding to pixels, it
+	// replaces them.
+	NoiseSaltPepper
+)
+
+// NoiseOptions configures the noise generators.
+type NoiseOptions struct {
+	Kind NoiseKind
+	// Seed seeds the generator. Equal options and bounds always produce
+	// equal noise.
+	Seed uint64
+	// Amplitude scales the noise, as described on each NoiseKind, in raw
+	// pixel units. Zero means the full positive range of the image type:
+	// 32767 for GrayS16 and 1 for GrayF32.
+	Amplitude float64
+	// Scale is the feature size of NoisePerlin in pixels. Zero means 16.
+	Scale float64
+	// Density is the fraction of pixels affected by NoiseSaltPepper. Zero
+	// means 0.05.
+	Density float64
+}
+
+// NoiseGrayS16 returns a GrayS16Image with bounds r filled with noise.
+// Values are rounded and saturated to the int16 range.
+func NoiseGrayS16(r image.Rectangle, opts *NoiseOptions) *GrayS16Image {
+	img := NewGrayS16Image(r)
+	AddNoiseGrayS16(img, opts)
+	return img
+}
+
+// NoiseGrayF32 returns a GrayF32Image with bounds r filled with noise.
+func NoiseGrayF32(r image.Rectangle, opts *NoiseOptions) *GrayF32Image {
+	img := NewGrayF32Image(r)
+	AddNoiseGrayF32(img, opts)
+	return img
+}
+
+// AddNoiseGrayS16 adds noise to img in place, saturating to the int16
+// range. A nil opts means Gaussian noise with the default amplitude and a
+// zero seed.
+func AddNoiseGrayS16(img *GrayS16Image, opts *NoiseOptions) {
+	gen := newNoiseGen(opts, math.MaxInt16)
+	r := img.Rect
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v, replace := gen(x, y)
+			if !replace {
+				v += float64(img.GrayS16At(x, y).Y)
+			}
+			v = math.Round(math.Max(math.MinInt16, math.Min(math.MaxInt16, v)))
+			img.SetGrayS16(x, y, GrayS16{Y: int16(v)})
+		}
+	}
+}
+
+// AddNoiseGrayF32 adds noise to img in place. A nil opts means Gaussian
+// noise with the default amplitude and a zero seed.
+func AddNoiseGrayF32(img *GrayF32Image, opts *NoiseOptions) {
+	gen := newNoiseGen(opts, 1)
+	r := img.Rect
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v, replace := gen(x, y)
+			if !replace {
+				v += float64(img.GrayF32At(x, y).Y)
+			}
+			img.SetGrayF32(x, y, GrayF32{Y: float32(v)})
+		}
+	}
+}
+
+// newNoiseGen returns a function producing the noise at (x, y), and whether
+// it replaces the pixel rather than adding to it. Pixels must be visited in
+// row-major order for the random kinds to be reproducible.
+func newNoiseGen(opts *NoiseOptions, fullScale float64) func(x, y int) (float64, bool) {
+	var o NoiseOptions
+	if opts != nil {
+		o = *opts
+	}
+	amp := o.Amplitude
+	if amp == 0 {
+		amp = fullScale
+	}
+	rng := rand.New(rand.NewPCG(o.Seed, 0x636f6c6f72657874))
+	switch o.Kind {
+	case NoiseUniform:
+		return func(x, y int) (float64, bool) {
+			return (2*rng.Float64() - 1) * amp, false
+		}
+	case NoisePerlin:
+		scale := o.Scale
+		if scale == 0 {
+			scale = 16
+		}
+		p := newPerlin(rng)
+		return func(x, y int) (float64, bool) {
+			return p.at((float64(x)+0.5)/scale, (float64(y)+0.5)/scale) * amp, false
+		}
+	case NoiseSaltPepper:
+		density := o.Density
+		if density == 0 {
+			density = 0.05
+		}
+		return func(x, y int) (float64, bool) {
+			if rng.Float64() >= density {
+				return 0, false
+			}
+			if rng.IntN(2) == 0 {
+				return -amp, true
+			}
+			return amp, true
+		}
+	}
+	return func(x, y int) (float64, bool) {
+		return rng.NormFloat64() * amp, false
+	}
+}
+
+// perlin evaluates Ken Perlin's improved gradient noise in two dimensions.
+type perlin struct {
+	perm [512]uint8
+}
+
+// newPerlin returns a perlin generator with a permutation drawn from rng.
+func newPerlin(rng *rand.Rand) *perlin {
+	p := new(perlin)
+	for i, v := range rng.Perm(256) {
+		p.perm[i] = uint8(v)
+		p.perm[i+256] = uint8(v)
+	}
+	return p
+}
+
+// at returns the noise at (x, y), in roughly [-1, 1]. It is zero at integer
+// lattice points.
+func (p *perlin) at(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	xi, yi := int(fx)&255, int(fy)&255
+	x, y = x-fx, y-fy
+	u, v := fade(x), fade(y)
+	a, b := int(p.perm[xi])+yi, int(p.perm[xi+1])+yi
+	n00 := grad2(p.perm[a], x, y)
+	n10 := grad2(p.perm[b], x-1, y)
+	n01 := grad2(p.perm[a+1], x, y-1)
+	n11 := grad2(p.perm[b+1], x-1, y-1)
+	nx0 := n00 + (n10-n00)*u
+	nx1 := n01 + (n11-n01)*u
+	return nx0 + (nx1-nx0)*v
+}
+
+// fade is Perlin's quintic smoothstep.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// grad2 returns the dot product of (x, y) with one of eight gradient
+// directions selected by h.
+func grad2(h uint8, x, y float64) float64 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	}
+	return -y
+}
@@ -0,0 +1,123 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestNoiseReproducible(t *testing.T) {
+	r := image.Rect(-5, 3, 60, 40)
+	for _, kind := range []NoiseKind{NoiseGaussian, NoiseUniform, NoisePerlin, NoiseSaltPepper} {
+		a := NoiseGrayS16(r, &NoiseOptions{Kind: kind, Seed: 7, Amplitude: 1000})
+		b := NoiseGrayS16(r, &NoiseOptions{Kind: kind, Seed: 7, Amplitude: 1000})
+		c := NoiseGrayS16(r, &NoiseOptions{Kind: kind, Seed: 8, Amplitude: 1000})
+		if !bytes.Equal(a.Pix, b.Pix) {
+			t.Errorf("kind %d: equal seeds produced different noise", kind)
+		}
+		if bytes.Equal(a.Pix, c.Pix) {
+			t.Errorf("kind %d: different seeds produced equal noise", kind)
+		}
+	}
+}
+
+func TestNoiseDistribution(t *testing.T) {
+	r := image.Rect(0, 0, 200, 200)
+	tests := []struct {
+		name         string
+		opts         *NoiseOptions
+		mean, stddev float64
+		lo, hi       float64
+	}{
+		{"gaussian", &NoiseOptions{Amplitude: 2}, 0, 2, math.Inf(-1), math.Inf(1)},
+		{"uniform", &NoiseOptions{Kind: NoiseUniform, Amplitude: 3}, 0, 3 / math.Sqrt(3), -3, 3},
+		{"default", nil, 0, 1, math.Inf(-1), math.Inf(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NoiseGrayF32(r, tt.opts)
+			var sum, sum2 float64
+			for y := 0; y < 200; y++ {
+				for x := 0; x < 200; x++ {
+					v := float64(img.GrayF32At(x, y).Y)
+					if v < tt.lo || v > tt.hi {
+						t.Fatalf("value %v outside [%v, %v]", v, tt.lo, tt.hi)
+					}
+					sum += v
+					sum2 += v * v
+				}
+			}
+			n := float64(r.Dx() * r.Dy())
+			mean := sum / n
+			sd := math.Sqrt(sum2/n - mean*mean)
+			if math.Abs(mean-tt.mean) > 0.05*tt.stddev || math.Abs(sd-tt.stddev) > 0.05*tt.stddev {
+				t.Errorf("mean, stddev = %.3f, %.3f, want %.3f, %.3f", mean, sd, tt.mean, tt.stddev)
+			}
+		})
+	}
+}
+
+func TestSaltPepperNoise(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 0x11
+	}
+	AddNoiseGrayS16(img, &NoiseOptions{Kind: NoiseSaltPepper, Density: 0.2, Seed: 3})
+	counts := map[int16]int{}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			counts[img.GrayS16At(x, y).Y]++
+		}
+	}
+	if len(counts) != 3 {
+		t.Fatalf("got %d distinct values, want 3: %v", len(counts), counts)
+	}
+	if n := counts[32767] + counts[-32767]; n < 1800 || n > 2200 {
+		t.Errorf("%d pixels replaced, want about 2000", n)
+	}
+}
+
+func TestPerlinNoise(t *testing.T) {
+	img := NoiseGrayF32(image.Rect(0, 0, 64, 64), &NoiseOptions{Kind: NoisePerlin, Scale: 16})
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := float64(img.GrayF32At(x, y).Y)
+			lo, hi = min(lo, v), max(hi, v)
+			// Neighbouring pixels differ by at most a fraction of the
+			// feature scale.
+			if x > 0 {
+				if d := math.Abs(v - float64(img.GrayF32At(x-1, y).Y)); d > 0.2 {
+					t.Fatalf("step of %v at (%d, %d) is not smooth", d, x, y)
+				}
+			}
+		}
+	}
+	if lo < -1.01 || hi > 1.01 || hi-lo < 0.5 {
+		t.Errorf("range [%v, %v], want a spread within [-1, 1]", lo, hi)
+	}
+}
+
+func TestAddNoiseSaturates(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 50, 50))
+	for i := 0; i < len(img.Pix); i += 2 {
+		img.Pix[i], img.Pix[i+1] = 0x7f, 0xf0
+	}
+	AddNoiseGrayS16(img, &NoiseOptions{Kind: NoiseUniform, Amplitude: 1000})
+	saturated := 0
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 50; x++ {
+			v := img.GrayS16At(x, y).Y
+			if v < 32752-1000 {
+				t.Fatalf("pixel (%d, %d) = %d wrapped around", x, y, v)
+			}
+			if v == 32767 {
+				saturated++
+			}
+		}
+	}
+	if saturated < 1000 || saturated > 1500 {
+		t.Errorf("%d pixels saturated, want about half", saturated)
+	}
+}
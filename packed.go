@@ -0,0 +1,329 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// Gray10PackedImage is an in-memory 10-bit grayscale image in the MIPI CSI-2
+// RAW10 layout used by industrial cameras. Every four pixels are
+// packed into five bytes: the high eight bits of each pixel, followed by a
+// byte holding their low two bits, first pixel in the least significant
+// bits. At decodes the sample and scales it to a color.Gray16.
+type Gray10PackedImage struct {
+	// Pix holds the image's packed groups.
+	// The group holding the pixel at (x, y) starts at
+	// Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X+Offset)/4*5].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Offset is the number of pixels in the first group of each row that
+	// precede Rect.Min.X. It is non-zero only for sub-images that do not
+	// start on a group boundary.
+	Offset int
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the Gray10PackedImage's color model.
+func (p *Gray10PackedImage) ColorModel() color.Model {
+	return color.Gray16Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *Gray10PackedImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y), scaled to 16 bits.
+func (p *Gray10PackedImage) At(x, y int) color.Color {
+	v := p.SampleAt(x, y)
+	return color.Gray16{Y: v<<6 | v>>4}
+}
+
+// BitDepth returns 10.
+func (p *Gray10PackedImage) BitDepth() int {
+	return 10
+}
+
+// SampleAt returns the raw 10-bit sample of the pixel at (x, y).
+func (p *Gray10PackedImage) SampleAt(x, y int) uint16 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i, k := p.PixOffset(x, y), p.index(x)%4
+	s := p.Pix[i : i+5 : i+5]
+	return uint16(s[k])<<2 | uint16(s[4]>>(2*k))&3
+}
+
+// SetSample sets the raw sample of the pixel at (x, y). Bits above the
+// 10-bit range are discarded.
+func (p *Gray10PackedImage) SetSample(x, y int, v uint16) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i, k := p.PixOffset(x, y), p.index(x)%4
+	s := p.Pix[i : i+5 : i+5]
+	s[k] = uint8(v >> 2)
+	s[4] = s[4]&^(3<<(2*k)) | uint8(v&3)<<(2*k)
+}
+
+// index returns the position of column x within a row of groups.
+func (p *Gray10PackedImage) index(x int) int {
+	return x - p.Rect.Min.X + p.Offset
+}
+
+// PixOffset returns the index of the first element of Pix of the group that
+// holds the pixel at (x, y).
+func (p *Gray10PackedImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + p.index(x)/4*5
+}
+
+// Set sets the pixel at (x, y) to the gray level of c, quantized to 10 bits.
+func (p *Gray10PackedImage) Set(x, y int, c color.Color) {
+	v := color.Gray16Model.Convert(c).(color.Gray16).Y
+	p.SetSample(x, y, v>>6)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *Gray10PackedImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &Gray10PackedImage{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &Gray10PackedImage{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Offset: p.index(r.Min.X) % 4,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// Gray10PackedImage is always fully opaque since the Gray16 color model has no transparency.
+func (p *Gray10PackedImage) Opaque() bool {
+	return true
+}
+
+// NewGray10PackedImage returns a new Gray10PackedImage with the given bounds.
+// Each row is padded to a whole number of groups.
+func NewGray10PackedImage(r image.Rectangle, opts ...ImageOption) *Gray10PackedImage {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	stride := (w + 3) / 4 * 5
+	p := &Gray10PackedImage{
+		Pix:    o.pix(stride*h, 1),
+		Stride: stride,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillBlocks(p, p.Pix, p.Stride, 5, 4, 1)
+	return p
+}
+
+func (p *Gray10PackedImage) metadata() Metadata {
+	return p.Meta
+}
+
+// aligned returns p, or a copy of it if its rows do not start on a group
+// boundary.
+func (p *Gray10PackedImage) aligned() *Gray10PackedImage {
+	if p.Offset == 0 {
+		return p
+	}
+	q := NewGray10PackedImage(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			q.SetSample(x, y, p.SampleAt(x, y))
+		}
+	}
+	return q
+}
+
+// Gray12PackedImage is an in-memory 12-bit grayscale image in the MIPI CSI-2
+// RAW12 layout used by industrial cameras. Every two pixels are
+// packed into three bytes: the high eight bits of each pixel, followed by a
+// byte holding their low four bits, first pixel in the low nibble. At
+// decodes the sample and scales it to a color.Gray16.
+type Gray12PackedImage struct {
+	// Pix holds the image's packed groups.
+	// The group holding the pixel at (x, y) starts at
+	// Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X+Offset)/2*3].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Offset is the number of pixels in the first group of each row that
+	// precede Rect.Min.X. It is non-zero only for sub-images that do not
+	// start on a group boundary.
+	Offset int
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the Gray12PackedImage's color model.
+func (p *Gray12PackedImage) ColorModel() color.Model {
+	return color.Gray16Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *Gray12PackedImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y), scaled to 16 bits.
+func (p *Gray12PackedImage) At(x, y int) color.Color {
+	v := p.SampleAt(x, y)
+	return color.Gray16{Y: v<<4 | v>>8}
+}
+
+// BitDepth returns 12.
+func (p *Gray12PackedImage) BitDepth() int {
+	return 12
+}
+
+// SampleAt returns the raw 12-bit sample of the pixel at (x, y).
+func (p *Gray12PackedImage) SampleAt(x, y int) uint16 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i, k := p.PixOffset(x, y), p.index(x)%2
+	s := p.Pix[i : i+3 : i+3]
+	return uint16(s[k])<<4 | uint16(s[2]>>(4*k))&0xf
+}
+
+// SetSample sets the raw sample of the pixel at (x, y). Bits above the
+// 12-bit range are discarded.
+func (p *Gray12PackedImage) SetSample(x, y int, v uint16) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i, k := p.PixOffset(x, y), p.index(x)%2
+	s := p.Pix[i : i+3 : i+3]
+	s[k] = uint8(v >> 4)
+	s[2] = s[2]&^(0xf<<(4*k)) | uint8(v&0xf)<<(4*k)
+}
+
+// index returns the position of column x within a row of groups.
+func (p *Gray12PackedImage) index(x int) int {
+	return x - p.Rect.Min.X + p.Offset
+}
+
+// PixOffset returns the index of the first element of Pix of the group that
+// holds the pixel at (x, y).
+func (p *Gray12PackedImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + p.index(x)/2*3
+}
+
+// Set sets the pixel at (x, y) to the gray level of c, quantized to 12 bits.
+func (p *Gray12PackedImage) Set(x, y int, c color.Color) {
+	v := color.Gray16Model.Convert(c).(color.Gray16).Y
+	p.SetSample(x, y, v>>4)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *Gray12PackedImage) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &Gray12PackedImage{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &Gray12PackedImage{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Offset: p.index(r.Min.X) % 2,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// Gray12PackedImage is always fully opaque since the Gray16 color model has no transparency.
+func (p *Gray12PackedImage) Opaque() bool {
+	return true
+}
+
+// NewGray12PackedImage returns a new Gray12PackedImage with the given bounds.
+// Each row is padded to a whole number of groups.
+func NewGray12PackedImage(r image.Rectangle, opts ...ImageOption) *Gray12PackedImage {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	stride := (w + 1) / 2 * 3
+	p := &Gray12PackedImage{
+		Pix:    o.pix(stride*h, 1),
+		Stride: stride,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillBlocks(p, p.Pix, p.Stride, 3, 2, 1)
+	return p
+}
+
+func (p *Gray12PackedImage) metadata() Metadata {
+	return p.Meta
+}
+
+// aligned returns p, or a copy of it if its rows do not start on a group
+// boundary.
+func (p *Gray12PackedImage) aligned() *Gray12PackedImage {
+	if p.Offset == 0 {
+		return p
+	}
+	q := NewGray12PackedImage(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			q.SetSample(x, y, p.SampleAt(x, y))
+		}
+	}
+	return q
+}
+
+// PackedGray is implemented by the packed grayscale image types.
+type PackedGray interface {
+	image.Image
+	// BitDepth returns the number of significant bits per sample.
+	BitDepth() int
+	// SampleAt returns the raw sample of the pixel at (x, y).
+	SampleAt(x, y int) uint16
+}
+
+// Unpack expands src into an image.Gray16. Samples are copied without
+// scaling, so a 10-bit image yields values in [0, 1023].
+func Unpack(src PackedGray) *image.Gray16 {
+	r := src.Bounds()
+	dst := image.NewGray16(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetGray16(x, y, color.Gray16{Y: src.SampleAt(x, y)})
+		}
+	}
+	return dst
+}
+
+// UnpackGrayS16 expands src into a GrayS16Image. Samples are copied without
+// scaling; every 10- and 12-bit value fits in an int16.
+func UnpackGrayS16(src PackedGray) *GrayS16Image {
+	r := src.Bounds()
+	dst := NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetGrayS16(x, y, GrayS16{Y: int16(src.SampleAt(x, y))})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,118 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGray10PackedImage_Decode(t *testing.T) {
+	// Samples 0x3ff, 0x001, 0x200, 0x155 in RAW10 layout.
+	img := &Gray10PackedImage{
+		Pix:    []uint8{0xff, 0x00, 0x80, 0x55, 0b01_00_01_11},
+		Stride: 5,
+		Rect:   image.Rect(0, 0, 4, 1),
+	}
+	want := []uint16{0x3ff, 0x001, 0x200, 0x155}
+	for x, w := range want {
+		if got := img.SampleAt(x, 0); got != w {
+			t.Errorf("SampleAt(%d, 0) = %#x, want %#x", x, got, w)
+		}
+	}
+	if got := img.At(0, 0); got != (color.Gray16{Y: 0xffff}) {
+		t.Errorf("At(0, 0) = %v, want full scale", got)
+	}
+	if got := img.At(2, 0); got != (color.Gray16{Y: 0x8020}) {
+		t.Errorf("At(2, 0) = %v, want 0x8020", got)
+	}
+}
+
+func TestGray12PackedImage_Decode(t *testing.T) {
+	// Samples 0xabc, 0x123 in RAW12 layout.
+	img := &Gray12PackedImage{
+		Pix:    []uint8{0xab, 0x12, 0x3c},
+		Stride: 3,
+		Rect:   image.Rect(0, 0, 2, 1),
+	}
+	if got := img.SampleAt(0, 0); got != 0xabc {
+		t.Errorf("SampleAt(0, 0) = %#x, want 0xabc", got)
+	}
+	if got := img.SampleAt(1, 0); got != 0x123 {
+		t.Errorf("SampleAt(1, 0) = %#x, want 0x123", got)
+	}
+	if got := img.At(1, 0); got != (color.Gray16{Y: 0x1231}) {
+		t.Errorf("At(1, 0) = %v, want 0x1231", got)
+	}
+}
+
+func TestPackedImages_SetAndSubImage(t *testing.T) {
+	tests := []struct {
+		name string
+		img  interface {
+			PackedGray
+			SetSample(x, y int, v uint16)
+			SubImage(r image.Rectangle) image.Image
+		}
+		stride, max int
+	}{
+		{"10-bit", NewGray10PackedImage(image.Rect(-1, 0, 6, 3)), 10, 0x3ff},
+		{"12-bit", NewGray12PackedImage(image.Rect(-1, 0, 6, 3)), 12, 0xfff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val := func(x, y int) uint16 { return uint16((x+1)*97+y*311) & uint16(tt.max) }
+			for y := 0; y < 3; y++ {
+				for x := -1; x < 6; x++ {
+					tt.img.SetSample(x, y, val(x, y))
+				}
+			}
+			for y := 0; y < 3; y++ {
+				for x := -1; x < 6; x++ {
+					if got := tt.img.SampleAt(x, y); got != val(x, y) {
+						t.Errorf("SampleAt(%d, %d) = %#x, want %#x", x, y, got, val(x, y))
+					}
+				}
+			}
+			// Sub-images starting inside a group decode the same samples.
+			sub := tt.img.SubImage(image.Rect(0, 1, 5, 3)).(PackedGray)
+			for y := 1; y < 3; y++ {
+				for x := 0; x < 5; x++ {
+					if got := sub.SampleAt(x, y); got != val(x, y) {
+						t.Errorf("SubImage.SampleAt(%d, %d) = %#x, want %#x", x, y, got, val(x, y))
+					}
+				}
+			}
+			if got := sub.SampleAt(5, 1); got != 0 {
+				t.Errorf("out of bounds SampleAt = %#x, want 0", got)
+			}
+		})
+	}
+}
+
+func TestNewPackedImages_Stride(t *testing.T) {
+	if img := NewGray10PackedImage(image.Rect(0, 0, 5, 2)); img.Stride != 10 || len(img.Pix) != 20 {
+		t.Errorf("10-bit Stride = %d, len(Pix) = %d, want 10, 20", img.Stride, len(img.Pix))
+	}
+	if img := NewGray12PackedImage(image.Rect(0, 0, 5, 2)); img.Stride != 9 || len(img.Pix) != 18 {
+		t.Errorf("12-bit Stride = %d, len(Pix) = %d, want 9, 18", img.Stride, len(img.Pix))
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	src := NewGray12PackedImage(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.Gray16{Y: 0xffff})
+	src.SetSample(1, 0, 0x800)
+	src.SetSample(2, 0, 7)
+
+	g := Unpack(src)
+	s := UnpackGrayS16(src)
+	for x, want := range []uint16{0xfff, 0x800, 7} {
+		if got := g.Gray16At(x, 0).Y; got != want {
+			t.Errorf("Unpack pixel %d = %#x, want %#x", x, got, want)
+		}
+		if got := s.GrayS16At(x, 0).Y; got != int16(want) {
+			t.Errorf("UnpackGrayS16 pixel %d = %d, want %d", x, got, want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// Paletted16Image is an in-memory image of 16-bit indices into a given
+// palette. It supports up to 65536 palette entries, which makes it suitable
+// for label maps and segmentation masks that overflow image.Paletted.
+type Paletted16Image struct {
+	// Pix holds the image's pixels, as 16-bit palette indices in big-endian
+	// format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*2].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Palette is the image's palette.
+	Palette color.Palette
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the Paletted16Image's palette.
+func (p *Paletted16Image) ColorModel() color.Model {
+	return p.Palette
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *Paletted16Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the palette color of the pixel at (x, y). As with
+// image.Paletted, it returns nil if the palette is empty and the first
+// palette entry for points outside the bounds. Indices beyond the end of the
+// palette yield color.Transparent.
+func (p *Paletted16Image) At(x, y int) color.Color {
+	if len(p.Palette) == 0 {
+		return nil
+	}
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return p.Palette[0]
+	}
+	i := int(p.ColorIndexAt(x, y))
+	if i >= len(p.Palette) {
+		return color.Transparent
+	}
+	return p.Palette[i]
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *Paletted16Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+// Set sets the pixel at (x, y) to the index of the palette entry closest
+// to c.
+func (p *Paletted16Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetColorIndex(x, y, uint16(p.Palette.Index(c)))
+}
+
+// ColorIndexAt returns the palette index of the pixel at (x, y).
+func (p *Paletted16Image) ColorIndexAt(x, y int) uint16 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i := p.PixOffset(x, y)
+	return uint16(p.Pix[i])<<8 | uint16(p.Pix[i+1])
+}
+
+// SetColorIndex sets the palette index of the pixel at (x, y).
+func (p *Paletted16Image) SetColorIndex(x, y int, index uint16) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i] = uint8(index >> 8)
+	p.Pix[i+1] = uint8(index)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *Paletted16Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &Paletted16Image{
+			Palette: p.Palette,
+			Meta:    p.Meta,
+		}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &Paletted16Image{
+		Pix:     p.Pix[i:],
+		Stride:  p.Stride,
+		Rect:    r,
+		Palette: p.Palette,
+		Meta:    p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *Paletted16Image) Opaque() bool {
+	present := make([]bool, 1<<16)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			present[p.ColorIndexAt(x, y)] = true
+		}
+	}
+	for i, used := range present {
+		if !used {
+			continue
+		}
+		if i >= len(p.Palette) {
+			return false
+		}
+		if _, _, _, a := p.Palette[i].RGBA(); a != 0xffff {
+			return false
+		}
+	}
+	return true
+}
+
+// NewPaletted16Image returns a new Paletted16Image with the given bounds and
+// palette.
+func NewPaletted16Image(r image.Rectangle, p color.Palette, opts ...ImageOption) *Paletted16Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	m := &Paletted16Image{
+		Pix:     o.pix(2*w*h, 2),
+		Stride:  2 * w,
+		Rect:    r,
+		Palette: p,
+		Meta:    o.meta,
+	}
+	o.fillPix(m, m.Pix, m.Stride, 2)
+	return m
+}
+
+func (p *Paletted16Image) metadata() Metadata {
+	return p.Meta
+}
@@ -0,0 +1,66 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// labelPalette returns n distinct opaque colors.
+func labelPalette(n int) color.Palette {
+	p := make(color.Palette, n)
+	for i := range p {
+		p[i] = color.RGBA64{R: uint16(i), G: uint16(i * 7), B: 0, A: 0xffff}
+	}
+	return p
+}
+
+func TestPaletted16Image(t *testing.T) {
+	pal := labelPalette(1 << 16) // the full 16-bit index range
+	img := NewPaletted16Image(image.Rect(0, 0, 3, 2), pal)
+	if img.Stride != 6 || len(img.Pix) != 12 {
+		t.Fatalf("Stride = %d, len(Pix) = %d, want 6, 12", img.Stride, len(img.Pix))
+	}
+
+	img.SetColorIndex(2, 1, 0xfffe)
+	if got := img.ColorIndexAt(2, 1); got != 0xfffe {
+		t.Errorf("ColorIndexAt(2, 1) = %#x, want 0xfffe", got)
+	}
+	if got := img.At(2, 1); got != pal[0xfffe] {
+		t.Errorf("At(2, 1) = %v, want %v", got, pal[0xfffe])
+	}
+	img.Set(0, 1, pal[300])
+	if got := img.ColorIndexAt(0, 1); got != 300 {
+		t.Errorf("Set chose index %d, want 300", got)
+	}
+	if got := img.At(5, 5); got != pal[0] {
+		t.Errorf("out of bounds At = %v, want first palette entry", got)
+	}
+	if !img.Opaque() {
+		t.Error("image with opaque palette reported not Opaque")
+	}
+
+	sub := img.SubImage(image.Rect(2, 1, 3, 2)).(*Paletted16Image)
+	if got := sub.ColorIndexAt(2, 1); got != 0xfffe {
+		t.Errorf("SubImage.ColorIndexAt(2, 1) = %#x", got)
+	}
+	if sub.ColorModel().Convert(pal[12]) != pal[12] {
+		t.Error("ColorModel does not map palette colors to themselves")
+	}
+}
+
+func TestPaletted16Image_ShortPalette(t *testing.T) {
+	img := NewPaletted16Image(image.Rect(0, 0, 2, 1), color.Palette{color.Black, color.White})
+	img.SetColorIndex(1, 0, 5)
+	if got := img.At(1, 0); got != color.Transparent {
+		t.Errorf("At beyond palette = %v, want transparent", got)
+	}
+	if img.Opaque() {
+		t.Error("image with indices beyond the palette reported Opaque")
+	}
+
+	empty := &Paletted16Image{Pix: []uint8{0, 0}, Stride: 2, Rect: image.Rect(0, 0, 1, 1)}
+	if got := empty.At(0, 0); got != nil {
+		t.Errorf("At with empty palette = %v, want nil", got)
+	}
+}
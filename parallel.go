@@ -0,0 +1,183 @@
+package colorext
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelOptions configures how Apply, ApplyHalo, Map and their Context
+// variants split an image into horizontal bands and run them concurrently.
+type ParallelOptions struct {
+	// Workers is the number of goroutines. Zero means
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// BandHeight is the number of rows per band. Zero chooses bands of
+	// about 64 KiB, small enough to stay in cache, with at least four
+	// bands per worker when the image is tall enough.
+	BandHeight int
+	// Progress, if non-nil, is called by the Context variants after each
+	// band with the number of rows done so far.
+	Progress ProgressFunc
+}
+
+// ProgressFunc reports the progress of a long operation as done out of
+// total units of work, such as rows. Calls are serialized and done never
+// decreases, so a ProgressFunc needs no locking of its own even when the
+// operation runs on several goroutines.
+type ProgressFunc func(done, total int)
+
+func (o *ParallelOptions) defaults(r image.Rectangle, bpp int) (workers, band int) {
+	var opts ParallelOptions
+	if o != nil {
+		opts = *o
+	}
+	workers = opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	band = opts.BandHeight
+	if band <= 0 {
+		band = max(1, (64<<10)/max(1, bpp*r.Dx()))
+		band = min(band, max(1, (r.Dy()+4*workers-1)/(4*workers)))
+	}
+	return workers, band
+}
+
+func (o *ParallelOptions) progress() ProgressFunc {
+	if o == nil {
+		return nil
+	}
+	return o.Progress
+}
+
+// forBands calls f for each band of rows of r, band rows at a time, from
+// up to workers goroutines, and returns when all calls have finished.
+func forBands(r image.Rectangle, workers, band int, f func(b image.Rectangle)) {
+	forBandsContext(context.Background(), r, workers, band, nil, f)
+}
+
+// forBandsContext is like forBands but stops starting bands once ctx is
+// done, returning ctx.Err() if any band was skipped, and reports the rows
+// done to progress after each band.
+func forBandsContext(ctx context.Context, r image.Rectangle, workers, band int, progress ProgressFunc, f func(b image.Rectangle)) error {
+	if r.Empty() {
+		return ctx.Err()
+	}
+	n := (r.Dy() + band - 1) / band
+	workers = min(workers, n)
+	bandAt := func(i int) image.Rectangle {
+		y0 := r.Min.Y + i*band
+		return image.Rect(r.Min.X, y0, r.Max.X, min(y0+band, r.Max.Y))
+	}
+	var mu sync.Mutex
+	var rows int
+	run := func(i int) {
+		b := bandAt(i)
+		f(b)
+		if progress != nil {
+			mu.Lock()
+			rows += b.Dy()
+			progress(rows, r.Dy())
+			mu.Unlock()
+		}
+	}
+	if workers <= 1 {
+		for i := range n {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			run(i)
+		}
+		return nil
+	}
+	var next atomic.Int64
+	var skipped atomic.Bool
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if ctx.Err() != nil {
+					skipped.Store(true)
+					return
+				}
+				run(i)
+			}
+		}()
+	}
+	wg.Wait()
+	if skipped.Load() {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Apply calls f concurrently on disjoint horizontal bands of img, each a
+// sub-image sharing img's pixels, so that f can process its band in place.
+// f must only access the pixels of the band it is given. opts may be nil.
+func Apply(img *GrayS16Image, f func(region *GrayS16Image), opts *ParallelOptions) {
+	ApplyContext(context.Background(), img, f, opts)
+}
+
+// ApplyContext is like Apply but stops starting bands once ctx is done and
+// returns ctx.Err(), leaving the remaining bands unprocessed. Bands already
+// running are not interrupted.
+func ApplyContext(ctx context.Context, img *GrayS16Image, f func(region *GrayS16Image), opts *ParallelOptions) error {
+	workers, band := opts.defaults(img.Rect, 2)
+	return forBandsContext(ctx, img.Rect, workers, band, opts.progress(), func(b image.Rectangle) {
+		f(img.SubImage(b).(*GrayS16Image))
+	})
+}
+
+// ApplyHalo runs a neighborhood operation concurrently on horizontal bands
+// of dst. For each band, f receives in, the matching region of src grown by
+// halo pixels on every side and clipped to src's bounds, and out, the band
+// of dst to fill. Bands of dst are disjoint while their inputs overlap, so
+// src and dst must not share pixels. A filter with a radius of r needs a
+// halo of r. opts may be nil.
+func ApplyHalo(src, dst *GrayS16Image, halo int, f func(in, out *GrayS16Image), opts *ParallelOptions) {
+	ApplyHaloContext(context.Background(), src, dst, halo, f, opts)
+}
+
+// ApplyHaloContext is like ApplyHalo but stops starting bands once ctx is
+// done and returns ctx.Err(), leaving the remaining bands of dst unwritten.
+func ApplyHaloContext(ctx context.Context, src, dst *GrayS16Image, halo int, f func(in, out *GrayS16Image), opts *ParallelOptions) error {
+	workers, band := opts.defaults(dst.Rect, 2)
+	return forBandsContext(ctx, dst.Rect, workers, band, opts.progress(), func(b image.Rectangle) {
+		in := src.SubImage(b.Inset(-halo)).(*GrayS16Image)
+		f(in, dst.SubImage(b).(*GrayS16Image))
+	})
+}
+
+// Map sets each pixel of dst to f of the corresponding pixel of src,
+// concurrently over the intersection of their bounds. src and dst may be
+// the same image. opts may be nil.
+func Map(src, dst *GrayS16Image, f func(v int16) int16, opts *ParallelOptions) {
+	MapContext(context.Background(), src, dst, f, opts)
+}
+
+// MapContext is like Map but stops starting bands once ctx is done and
+// returns ctx.Err(), leaving the remaining rows of dst unwritten.
+func MapContext(ctx context.Context, src, dst *GrayS16Image, f func(v int16) int16, opts *ParallelOptions) error {
+	r := src.Rect.Intersect(dst.Rect)
+	workers, band := opts.defaults(r, 2)
+	return forBandsContext(ctx, r, workers, band, opts.progress(), func(b image.Rectangle) {
+		w := 2 * b.Dx()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			s := src.Pix[src.PixOffset(b.Min.X, y):][:w]
+			d := dst.Pix[dst.PixOffset(b.Min.X, y):][:w]
+			for i := 0; i < w; i += 2 {
+				v := uint16(f(int16(uint16(s[i])<<8 | uint16(s[i+1]))))
+				d[i], d[i+1] = uint8(v>>8), uint8(v)
+			}
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package colorext
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		r    image.Rectangle
+		opts *ParallelOptions
+	}{
+		{"defaults", image.Rect(-3, 5, 61, 77), nil},
+		{"one worker", image.Rect(0, 0, 10, 10), &ParallelOptions{Workers: 1}},
+		{"tiny bands", image.Rect(0, 0, 9, 31), &ParallelOptions{Workers: 4, BandHeight: 1}},
+		{"band taller than image", image.Rect(0, 0, 9, 5), &ParallelOptions{BandHeight: 100}},
+		{"empty", image.Rectangle{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := randomGrayS16(tt.r, 2)
+			want := NewGrayS16Image(tt.r)
+			copy(want.Pix, img.Pix)
+			for y := tt.r.Min.Y; y < tt.r.Max.Y; y++ {
+				for x := tt.r.Min.X; x < tt.r.Max.X; x++ {
+					want.SetGrayS16(x, y, GrayS16{Y: want.GrayS16At(x, y).Y / 3})
+				}
+			}
+
+			var mu sync.Mutex
+			var covered int
+			Apply(img, func(region *GrayS16Image) {
+				for y := region.Rect.Min.Y; y < region.Rect.Max.Y; y++ {
+					for x := region.Rect.Min.X; x < region.Rect.Max.X; x++ {
+						region.SetGrayS16(x, y, GrayS16{Y: region.GrayS16At(x, y).Y / 3})
+					}
+				}
+				mu.Lock()
+				covered += region.Rect.Dx() * region.Rect.Dy()
+				mu.Unlock()
+			}, tt.opts)
+			if covered != tt.r.Dx()*tt.r.Dy() {
+				t.Errorf("bands covered %d pixels, want %d", covered, tt.r.Dx()*tt.r.Dy())
+			}
+			if !Equal(img, want) {
+				t.Error("result differs from the sequential computation")
+			}
+		})
+	}
+}
+
+// boxSum3 returns the sum of the 3×3 neighborhood of (x, y) in img,
+// treating pixels outside its bounds as 0.
+func boxSum3(img *GrayS16Image, x, y int) int16 {
+	var s int16
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (image.Point{X: x + dx, Y: y + dy}).In(img.Rect) {
+				s += img.GrayS16At(x+dx, y+dy).Y
+			}
+		}
+	}
+	return s
+}
+
+func TestApplyHalo(t *testing.T) {
+	src := randomGrayS16(image.Rect(2, -4, 40, 50), 3)
+	Map(src, src, func(v int16) int16 { return v / 16 }, nil)
+	want := NewGrayS16Image(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			want.SetGrayS16(x, y, GrayS16{Y: boxSum3(src, x, y)})
+		}
+	}
+	for _, band := range []int{1, 2, 7, 0} {
+		dst := NewGrayS16Image(src.Rect)
+		ApplyHalo(src, dst, 1, func(in, out *GrayS16Image) {
+			if !out.Rect.In(in.Rect) {
+				t.Errorf("input %v does not cover output %v", in.Rect, out.Rect)
+			}
+			for y := out.Rect.Min.Y; y < out.Rect.Max.Y; y++ {
+				for x := out.Rect.Min.X; x < out.Rect.Max.X; x++ {
+					out.SetGrayS16(x, y, GrayS16{Y: boxSum3(in, x, y)})
+				}
+			}
+		}, &ParallelOptions{Workers: 3, BandHeight: band})
+		if !Equal(dst, want) {
+			t.Errorf("band height %d: result differs from the sequential filter", band)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 33, 21), 5)
+	dst := NewGrayS16Image(image.Rect(5, 5, 50, 50))
+	Map(src, dst, func(v int16) int16 { return -v }, &ParallelOptions{BandHeight: 2})
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 50; x++ {
+			p := image.Pt(x, y)
+			if !p.In(dst.Rect) {
+				continue
+			}
+			want := int16(0)
+			if p.In(src.Rect) {
+				want = -src.GrayS16At(x, y).Y
+			}
+			if got := dst.GrayS16At(x, y).Y; got != want {
+				t.Fatalf("(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestMapContext(t *testing.T) {
+	r := image.Rect(0, 0, 7, 40)
+	tests := []struct {
+		name      string
+		cancelAt  int
+		opts      ParallelOptions
+		wantErr   error
+		wantRows  int
+		exactRows bool
+	}{
+		{"complete", -1, ParallelOptions{Workers: 4, BandHeight: 3}, nil, 40, true},
+		{"canceled serial", 10, ParallelOptions{Workers: 1, BandHeight: 5}, context.Canceled, 10, true},
+		{"canceled parallel", 10, ParallelOptions{Workers: 4, BandHeight: 2}, context.Canceled, 10, false},
+		{"canceled before start", 0, ParallelOptions{}, context.Canceled, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAt == 0 {
+				cancel()
+			}
+			last := 0
+			opts := tt.opts
+			opts.Progress = func(done, total int) {
+				if done <= last || total != r.Dy() {
+					t.Errorf("progress(%d, %d) after %d", done, total, last)
+				}
+				last = done
+				if done >= tt.cancelAt && tt.cancelAt > 0 {
+					cancel()
+				}
+			}
+			img := NewGrayS16Image(r)
+			err := MapContext(ctx, img, img, func(int16) int16 { return 1 }, &opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			written := 0
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				if img.GrayS16At(0, y).Y == 1 {
+					written++
+				}
+			}
+			if written != last {
+				t.Errorf("%d rows written, progress reported %d", written, last)
+			}
+			if tt.exactRows && written != tt.wantRows || written < tt.wantRows {
+				t.Errorf("%d rows written, want %d", written, tt.wantRows)
+			}
+		})
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	img := randomGrayS16(image.Rect(0, 0, 2048, 2048), 1)
+	b.SetBytes(int64(len(img.Pix)))
+	for b.Loop() {
+		Map(img, img, func(v int16) int16 { return v ^ 1 }, nil)
+	}
+}
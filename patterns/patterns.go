@@ -0,0 +1,181 @@
+// Package patterns generates synthetic test patterns for calibration and
+// codec testing.
+//
+// Scalar patterns compute an intensity in [0, 1] for each pixel and are
+// rendered by Fill into any draw.Image. Intensities span the full range of
+// the destination type: 0 maps to -32768 and 1 to 32767 in a
+// colorext.GrayS16Image, 0 and 1 to themselves in a colorext.GrayF32Image,
+// and other images receive a color.Gray16.
+package patterns
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Pattern returns the intensity, in [0, 1], of the pixel at (x, y) of an
+// image with bounds r.
+type Pattern func(x, y int, r image.Rectangle) float64
+
+// Fill renders p into dst over its whole bounds.
+func Fill(dst draw.Image, p Pattern) {
+	r := dst.Bounds()
+	switch m := dst.(type) {
+	case *colorext.GrayS16Image:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				v := math.Round(clamp01(p(x, y, r))*0xffff) - 32768
+				m.SetGrayS16(x, y, colorext.GrayS16{Y: int16(v)})
+			}
+		}
+	case *colorext.GrayF32Image:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				m.SetGrayF32(x, y, colorext.GrayF32{Y: float32(clamp01(p(x, y, r)))})
+			}
+		}
+	default:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				dst.Set(x, y, color.Gray16{Y: uint16(math.Round(clamp01(p(x, y, r)) * 0xffff))})
+			}
+		}
+	}
+}
+
+// HorizontalRamp rises linearly from 0 in the leftmost column to 1 in the
+// rightmost, so every column of a 65536-pixel-wide GrayS16Image holds a
+// distinct value.
+func HorizontalRamp(x, y int, r image.Rectangle) float64 {
+	return ramp(x-r.Min.X, r.Dx())
+}
+
+// VerticalRamp rises linearly from 0 in the top row to 1 in the bottom row.
+func VerticalRamp(x, y int, r image.Rectangle) float64 {
+	return ramp(y-r.Min.Y, r.Dy())
+}
+
+// ramp returns i/(n-1), or 0 if n < 2.
+func ramp(i, n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	return float64(i) / float64(n-1)
+}
+
+// Checkerboard returns a pattern of alternating squares, size pixels
+// across, starting with 1 at the top-left corner. A size below 1 means 1.
+func Checkerboard(size int) Pattern {
+	size = max(size, 1)
+	return func(x, y int, r image.Rectangle) float64 {
+		if ((x-r.Min.X)/size+(y-r.Min.Y)/size)%2 == 0 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// SiemensStar returns a star of spokes alternating light and dark wedges
+// centered on the image. Resolution charts use it to measure the
+// frequency at which a system stops resolving detail. A spokes value below
+// 1 means 1.
+func SiemensStar(spokes int) Pattern {
+	spokes = max(spokes, 1)
+	return func(x, y int, r image.Rectangle) float64 {
+		cx, cy := center(r)
+		a := math.Atan2(float64(y)+0.5-cy, float64(x)+0.5-cx)
+		if math.Sin(a*float64(spokes)) >= 0 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// ZonePlate returns a sinusoidal circular zone plate centered on the image.
+// Its spatial frequency rises linearly with distance from the center,
+// reaching maxFreq cycles per pixel at the middle of the shorter edge.
+// With maxFreq = 0.5 the pattern reaches the Nyquist limit there, and any
+// aliasing introduced by resampling or compression shows as moiré rings.
+func ZonePlate(maxFreq float64) Pattern {
+	return func(x, y int, r image.Rectangle) float64 {
+		cx, cy := center(r)
+		radius := float64(min(r.Dx(), r.Dy())) / 2
+		if radius == 0 {
+			return 1
+		}
+		dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+		phase := math.Pi * maxFreq * (dx*dx + dy*dy) / radius
+		return 0.5 + 0.5*math.Cos(phase)
+	}
+}
+
+// center returns the center of r in pixel-center coordinates.
+func center(r image.Rectangle) (x, y float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+// SMPTE bar colors, in full-range 8-bit sRGB.
+var (
+	smpteTop = []color.RGBA{
+		{0xbf, 0xbf, 0xbf, 0xff}, {0xbf, 0xbf, 0x00, 0xff}, {0x00, 0xbf, 0xbf, 0xff},
+		{0x00, 0xbf, 0x00, 0xff}, {0xbf, 0x00, 0xbf, 0xff}, {0xbf, 0x00, 0x00, 0xff},
+		{0x00, 0x00, 0xbf, 0xff},
+	}
+	smpteMiddle = []color.RGBA{
+		{0x00, 0x00, 0xbf, 0xff}, {0x13, 0x13, 0x13, 0xff}, {0xbf, 0x00, 0xbf, 0xff},
+		{0x13, 0x13, 0x13, 0xff}, {0x00, 0xbf, 0xbf, 0xff}, {0x13, 0x13, 0x13, 0xff},
+		{0xbf, 0xbf, 0xbf, 0xff},
+	}
+	// The bottom row is -I, white, +Q and black across the first five
+	// sevenths, then the PLUGE bars below, at and above black.
+	smpteBottom = []color.RGBA{
+		{0x00, 0x21, 0x4c, 0xff}, {0xff, 0xff, 0xff, 0xff}, {0x32, 0x00, 0x6a, 0xff},
+		{0x13, 0x13, 0x13, 0xff}, {0x09, 0x09, 0x09, 0xff}, {0x13, 0x13, 0x13, 0xff},
+		{0x1d, 0x1d, 0x1d, 0xff}, {0x13, 0x13, 0x13, 0xff},
+	}
+	// smpteBottomEdges are the right edges of the bottom row's bars in
+	// 84ths of the width.
+	smpteBottomEdges = []int{15, 30, 45, 60, 64, 68, 72, 84}
+)
+
+// SMPTEBars draws SMPTE color bars (EG 1-1990 layout) into dst: seven
+// 75% bars over the top two thirds, reversed blue bars below, and the -I,
+// white, +Q and PLUGE row at the bottom. Black is at the 7.5% setup level
+// so that the PLUGE bar below black stays representable in full-range
+// RGB.
+func SMPTEBars(dst draw.Image) {
+	r := dst.Bounds()
+	w, h := r.Dx(), r.Dy()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := y - r.Min.Y
+		for x := r.Min.X; x < r.Max.X; x++ {
+			col := x - r.Min.X
+			var c color.RGBA
+			switch {
+			case row*3 < h*2:
+				c = smpteTop[col*7/w]
+			case row*4 < h*3:
+				c = smpteMiddle[col*7/w]
+			default:
+				i := 0
+				for col*84 >= smpteBottomEdges[i]*w {
+					i++
+				}
+				c = smpteBottom[i]
+			}
+			dst.Set(x, y, c)
+		}
+	}
+}
+
+// clamp01 clamps t to [0, 1], mapping NaN to 0.
+func clamp01(t float64) float64 {
+	if !(t > 0) {
+		return 0
+	}
+	return min(t, 1)
+}
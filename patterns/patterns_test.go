@@ -0,0 +1,110 @@
+package patterns
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func TestFillRamp(t *testing.T) {
+	// A 65536-pixel ramp covers every int16 value exactly once.
+	img := colorext.NewGrayS16Image(image.Rect(-100, 0, 65436, 1))
+	Fill(img, HorizontalRamp)
+	for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+		want := int16(x - img.Rect.Min.X - 32768)
+		if got := img.GrayS16At(x, 0).Y; got != want {
+			t.Fatalf("ramp at %d = %d, want %d", x, got, want)
+		}
+	}
+
+	f := colorext.NewGrayF32Image(image.Rect(0, 0, 1, 5))
+	Fill(f, VerticalRamp)
+	for y, want := range []float32{0, 0.25, 0.5, 0.75, 1} {
+		if got := f.GrayF32At(0, y).Y; got != want {
+			t.Errorf("vertical ramp at %d = %v, want %v", y, got, want)
+		}
+	}
+
+	g := image.NewGray16(image.Rect(0, 0, 3, 1))
+	Fill(g, HorizontalRamp)
+	for x, want := range []uint16{0, 0x8000, 0xffff} {
+		if got := g.Gray16At(x, 0).Y; got != want {
+			t.Errorf("Gray16 ramp at %d = %#x, want %#x", x, got, want)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	r := image.Rect(10, 10, 74, 74)
+	tests := []struct {
+		name string
+		p    Pattern
+		x, y int
+		want float64
+	}{
+		{"checker origin", Checkerboard(8), 10, 10, 1},
+		{"checker next square", Checkerboard(8), 18, 10, 0},
+		{"checker diagonal", Checkerboard(8), 19, 19, 1},
+		{"checker zero size", Checkerboard(0), 11, 10, 0},
+		{"star right", SiemensStar(4), 70, 44, 1},
+		{"star above right", SiemensStar(4), 60, 30, 0},
+		{"zone plate center", ZonePlate(0.5), 42, 42, 1},
+	}
+	for _, tt := range tests {
+		got := tt.p(tt.x, tt.y, r)
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestZonePlateFrequency(t *testing.T) {
+	r := image.Rect(0, 0, 1001, 1001)
+	p := ZonePlate(0.1)
+	// Count the number of bright rings along the radius: the phase grows
+	// as pi*f*d^2/R, so it reaches pi*0.1*500 = 50*pi at the edge, which
+	// is 25 full cycles.
+	peaks := 0
+	prev := p(500, 500, r)
+	rising := false
+	for x := 501; x < 1001; x++ {
+		v := p(x, 500, r)
+		if v < prev {
+			if rising {
+				peaks++
+			}
+			rising = false
+		} else {
+			rising = true
+		}
+		prev = v
+	}
+	if peaks < 24 || peaks > 25 {
+		t.Errorf("found %d rings, want 25", peaks)
+	}
+}
+
+func TestSMPTEBars(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 840, 480))
+	SMPTEBars(img)
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, color.RGBA{0xbf, 0xbf, 0xbf, 0xff}},
+		{839, 0, color.RGBA{0x00, 0x00, 0xbf, 0xff}},
+		{130, 340, color.RGBA{0x13, 0x13, 0x13, 0xff}},
+		{0, 479, color.RGBA{0x00, 0x21, 0x4c, 0xff}},
+		{610, 479, color.RGBA{0x09, 0x09, 0x09, 0xff}},
+		{690, 479, color.RGBA{0x1d, 0x1d, 0x1d, 0xff}},
+		{839, 479, color.RGBA{0x13, 0x13, 0x13, 0xff}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("bar at (%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package colorext
+
+import (
+	"cmp"
+	"image"
+	"math"
+	"slices"
+)
+
+// Peak is a local maximum or blob found in an image.
+type Peak struct {
+	// Center is the sub-pixel position, in pixel-center coordinates.
+	Center PointF
+	// Value is the interpolated height of the peak, in raw units for
+	// FindPeaks and in difference-of-Gaussian response for FindBlobs.
+	Value float64
+	// Sigma is the scale of a blob found by FindBlobs, whose radius is
+	// about Sigma√2. It is zero for FindPeaks.
+	Sigma float64
+}
+
+// FindPeaks returns the local maxima of img above threshold, strongest
+// first. A pixel is a peak if no pixel within minDistance of it in either
+// direction is higher; of equal neighbors, the first in row-major order
+// wins. Centers are refined to sub-pixel precision by fitting a parabola
+// through each peak and its neighbors.
+//
+// Samples are read as raw values, as by Contours; NaN and invalid pixels
+// are never peaks. A minDistance below 1 means 1.
+func FindPeaks(img image.Image, minDistance int, threshold float64) []Peak {
+	minDistance = max(minDistance, 1)
+	r := img.Bounds()
+	at := scalarSampler(img)
+	w, h := r.Dx(), r.Dy()
+	v := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v[y*w+x] = at(r.Min.X+x, r.Min.Y+y)
+		}
+	}
+	g := &floatGrid{w: w, h: h, v: v}
+
+	var peaks []Peak
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := v[y*w+x]
+			if !(c > threshold) || !isLocalMax(v, w, h, x, y, minDistance) {
+				continue
+			}
+			dx, hx := parabolaPeak(g.at(x-1, y), c, g.at(x+1, y))
+			dy, hy := parabolaPeak(g.at(x, y-1), c, g.at(x, y+1))
+			peaks = append(peaks, Peak{
+				Center: PointF{X: float64(r.Min.X+x) + 0.5 + dx, Y: float64(r.Min.Y+y) + 0.5 + dy},
+				Value:  hx + hy - c,
+			})
+		}
+	}
+	slices.SortStableFunc(peaks, func(a, b Peak) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+	return peaks
+}
+
+// isLocalMax reports whether the sample at (x, y) of the w×h grid v is the
+// maximum within radius d, with ties going to the earlier sample in
+// row-major order. NaN samples are ignored.
+func isLocalMax(v []float64, w, h, x, y, d int) bool {
+	c := v[y*w+x]
+	for ny := max(0, y-d); ny <= min(h-1, y+d); ny++ {
+		for nx := max(0, x-d); nx <= min(w-1, x+d); nx++ {
+			n := v[ny*w+nx]
+			if n > c || (n == c && (ny < y || (ny == y && nx < x))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parabolaPeak fits a parabola through three equally spaced samples with
+// the middle one highest, and returns the offset of its vertex from the
+// middle sample, in [-0.5, 0.5], and its height. NaN neighbors leave the
+// peak at the middle sample.
+func parabolaPeak(l, c, r float64) (offset, height float64) {
+	den := l - 2*c + r
+	if math.IsNaN(den) || den >= 0 {
+		return 0, c
+	}
+	offset = max(-0.5, min(0.5, (l-r)/(2*den)))
+	return offset, c - (l-r)*offset/4
+}
+
+// BlobOptions configures FindBlobs.
+type BlobOptions struct {
+	// MinSigma and MaxSigma bound the scales searched, in pixels. Zero
+	// means 1 and 8.
+	MinSigma, MaxSigma float64
+	// ScaleRatio is the ratio between successive scales. Zero means 1.6,
+	// which makes the difference of Gaussians a close approximation of
+	// the scale-normalized Laplacian.
+	ScaleRatio float64
+	// Threshold is the minimum difference-of-Gaussian response, in raw
+	// units, of a reported blob.
+	Threshold float64
+	// Dark detects dark blobs on a bright background instead of bright
+	// blobs.
+	Dark bool
+}
+
+// FindBlobs detects blobs in img with a difference-of-Gaussian scale
+// space, as used for particle tracking in microscopy. A blob is a maximum
+// of the response over its 26 neighbors in position and scale. Results are
+// ordered strongest first, with sub-pixel centers and the scale at which
+// each blob responds most. Samples are read as raw values, with NaN and
+// invalid pixels treated as 0. A nil opts uses the defaults.
+func FindBlobs(img image.Image, opts *BlobOptions) []Peak {
+	var o BlobOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MinSigma <= 0 {
+		o.MinSigma = 1
+	}
+	if o.MaxSigma <= 0 {
+		o.MaxSigma = 8
+	}
+	if o.ScaleRatio <= 1 {
+		o.ScaleRatio = 1.6
+	}
+
+	src := gridFrom(img)
+	if o.Dark {
+		for i := range src.v {
+			src.v[i] = -src.v[i]
+		}
+	}
+	// Blur scales step by ScaleRatio, offset so that the differences
+	// between neighbors, each responding at the geometric mean of its two
+	// blurs, span [MinSigma, MaxSigma] with one extra level at each end.
+	k := o.ScaleRatio
+	var sigmas []float64
+	for s := o.MinSigma / math.Pow(k, 1.5); ; s *= k {
+		sigmas = append(sigmas, s)
+		if s >= o.MaxSigma*math.Pow(k, 1.5) {
+			break
+		}
+	}
+	blurred := make([]*floatGrid, len(sigmas))
+	for i, s := range sigmas {
+		blurred[i] = gaussianBlur(src, s)
+	}
+	dog := make([]*floatGrid, len(sigmas)-1)
+	for i := range dog {
+		d := &floatGrid{w: src.w, h: src.h, v: make([]float64, len(src.v))}
+		for j := range d.v {
+			d.v[j] = blurred[i].v[j] - blurred[i+1].v[j]
+		}
+		dog[i] = d
+	}
+
+	r := img.Bounds()
+	var blobs []Peak
+	for s := 1; s+1 < len(dog); s++ {
+		d := dog[s]
+		for y := 0; y < d.h; y++ {
+			for x := 0; x < d.w; x++ {
+				c := d.v[y*d.w+x]
+				if !(c > o.Threshold) || !isScaleSpaceMax(dog, s, x, y) {
+					continue
+				}
+				dx, hx := parabolaPeak(d.at(x-1, y), c, d.at(x+1, y))
+				dy, hy := parabolaPeak(d.at(x, y-1), c, d.at(x, y+1))
+				ds, _ := parabolaPeak(dog[s-1].at(x, y), c, dog[s+1].at(x, y))
+				sigma := sigmas[s] * math.Pow(k, 0.5+ds)
+				blobs = append(blobs, Peak{
+					Center: PointF{X: float64(r.Min.X+x) + 0.5 + dx, Y: float64(r.Min.Y+y) + 0.5 + dy},
+					Value:  hx + hy - c,
+					Sigma:  sigma,
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(blobs, func(a, b Peak) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+	return blobs
+}
+
+// isScaleSpaceMax reports whether dog[s] at (x, y) exceeds its 26
+// neighbors in position and scale.
+func isScaleSpaceMax(dog []*floatGrid, s, x, y int) bool {
+	c := dog[s].v[y*dog[s].w+x]
+	for ds := -1; ds <= 1; ds++ {
+		g := dog[s+ds]
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if ds == 0 && dx == 0 && dy == 0 {
+					continue
+				}
+				nx, ny := x+dx, y+dy
+				if nx < 0 || ny < 0 || nx >= g.w || ny >= g.h {
+					continue
+				}
+				if g.v[ny*g.w+nx] >= c {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// gaussianBlur returns g convolved with a Gaussian of standard deviation
+// sigma, truncated at three sigma, with edges clamped.
+func gaussianBlur(g *floatGrid, sigma float64) *floatGrid {
+	rad := int(math.Ceil(3 * sigma))
+	k := make([]float64, 2*rad+1)
+	var sum float64
+	for i := range k {
+		d := float64(i - rad)
+		k[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	tmp := &floatGrid{w: g.w, h: g.h, v: make([]float64, len(g.v))}
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			var v float64
+			for i, kv := range k {
+				v += kv * g.at(x+i-rad, y)
+			}
+			tmp.v[y*g.w+x] = v
+		}
+	}
+	dst := &floatGrid{w: g.w, h: g.h, v: make([]float64, len(g.v))}
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			var v float64
+			for i, kv := range k {
+				v += kv * tmp.at(x, y+i-rad)
+			}
+			dst.v[y*g.w+x] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,161 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"slices"
+	"testing"
+)
+
+// gaussSpots returns a GrayF32Image of r holding a Gaussian spot of the
+// given height and sigma at each center.
+func gaussSpots(r image.Rectangle, centers []PointF, height, sigma float64) *GrayF32Image {
+	img := NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var v float64
+			for _, c := range centers {
+				dx, dy := float64(x)+0.5-c.X, float64(y)+0.5-c.Y
+				v += height * math.Exp(-(dx*dx+dy*dy)/(2*sigma*sigma))
+			}
+			img.SetGrayF32(x, y, GrayF32{Y: float32(v)})
+		}
+	}
+	return img
+}
+
+func TestFindPeaks(t *testing.T) {
+	centers := []PointF{{10.3, 8.8}, {30.6, 20.2}, {12.5, 25.5}}
+	img := gaussSpots(image.Rect(2, 1, 42, 33), centers, 1000, 1.5)
+	// Make the spots distinguishable by height.
+	for y := 18; y < 33; y++ {
+		for x := 2; x < 42; x++ {
+			img.SetGrayF32(x, y, GrayF32{Y: img.GrayF32At(x, y).Y / 2})
+		}
+	}
+
+	tests := []struct {
+		name        string
+		minDistance int
+		threshold   float64
+		want        int
+	}{
+		{"all", 3, 10, 3},
+		{"threshold", 3, 600, 1},
+		{"none", 3, 2000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peaks := FindPeaks(img, tt.minDistance, tt.threshold)
+			if len(peaks) != tt.want {
+				t.Fatalf("got %d peaks, want %d: %v", len(peaks), tt.want, peaks)
+			}
+			for i := 1; i < len(peaks); i++ {
+				if peaks[i].Value > peaks[i-1].Value {
+					t.Errorf("peaks not sorted: %v", peaks)
+				}
+			}
+		})
+	}
+
+	peaks := FindPeaks(img, 3, 10)
+	if len(peaks) != 3 {
+		t.Fatalf("got %d peaks, want 3", len(peaks))
+	}
+	// The first spot is the only one at full height.
+	if d := math.Hypot(peaks[0].Center.X-centers[0].X, peaks[0].Center.Y-centers[0].Y); d > 0.1 {
+		t.Errorf("strongest peak at %v, want %v", peaks[0].Center, centers[0])
+	}
+	if math.Abs(peaks[0].Value-1000) > 20 {
+		t.Errorf("strongest peak value %v, want about 1000", peaks[0].Value)
+	}
+	for _, c := range centers {
+		found := false
+		for _, p := range peaks {
+			if math.Hypot(p.Center.X-c.X, p.Center.Y-c.Y) < 0.1 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no peak near %v: %v", c, peaks)
+		}
+	}
+}
+
+func TestFindPeaksMinDistance(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 10, 1))
+	for x, v := range []int16{0, 5, 0, 7, 0, 0, 0, 6, 6, 0} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	// The plateau at x = 7 and 8 yields one peak between the two pixels.
+	tests := []struct {
+		minDistance int
+		want        []float64
+	}{
+		{1, []float64{3.5, 8, 1.5}},
+		{2, []float64{3.5, 8}},
+		{3, []float64{3.5, 8}},
+		{4, []float64{3.5}},
+	}
+	for _, tt := range tests {
+		peaks := FindPeaks(img, tt.minDistance, 0)
+		var got []float64
+		for _, p := range peaks {
+			got = append(got, p.Center.X)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("minDistance %d: got %v, want %v", tt.minDistance, got, tt.want)
+		}
+	}
+}
+
+func TestFindPeaksSkipsInvalid(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 5, 5))
+	img.SetGrayF32(2, 2, GrayF32{Y: float32(math.NaN())})
+	img.SetGrayF32(1, 1, GrayF32{Y: 3})
+	peaks := FindPeaks(img, 1, 0)
+	if len(peaks) != 1 || peaks[0].Center != (PointF{1.5, 1.5}) {
+		t.Errorf("got %v, want one peak at (1.5, 1.5)", peaks)
+	}
+}
+
+func TestFindBlobs(t *testing.T) {
+	tests := []struct {
+		name  string
+		sigma float64
+		dark  bool
+	}{
+		{"small", 1.5, false},
+		{"large", 4, false},
+		{"dark", 2.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := PointF{30.4, 27.7}
+			img := gaussSpots(image.Rect(0, 0, 60, 56), []PointF{c}, 1000, tt.sigma)
+			if tt.dark {
+				for i := 0; i < len(img.Pix); i += 4 {
+					putF32(img.Pix[i:], 2000-getF32(img.Pix[i:]))
+				}
+			}
+			blobs := FindBlobs(img, &BlobOptions{Threshold: 20, Dark: tt.dark})
+			if len(blobs) != 1 {
+				t.Fatalf("got %d blobs, want 1: %v", len(blobs), blobs)
+			}
+			b := blobs[0]
+			if d := math.Hypot(b.Center.X-c.X, b.Center.Y-c.Y); d > 0.15 {
+				t.Errorf("center %v, want %v", b.Center, c)
+			}
+			if ratio := b.Sigma / tt.sigma; ratio < 0.7 || ratio > 1.4 {
+				t.Errorf("sigma %v, want about %v", b.Sigma, tt.sigma)
+			}
+		})
+	}
+}
+
+func TestFindBlobsNoBlobs(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 20, 20))
+	if blobs := FindBlobs(img, nil); len(blobs) != 0 {
+		t.Errorf("got %v, want no blobs in a flat image", blobs)
+	}
+}
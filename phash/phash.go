@@ -0,0 +1,235 @@
+// Package phash computes perceptual hashes of images, so that visually
+// similar images can be found by comparing hashes with a Hamming distance.
+//
+// Hashes are computed from raw sample values without first reducing the
+// data to 8 bits: colorext.GrayS16Image and colorext.GrayF32Image pixels use
+// their stored values and other images their colorext.GrayF32 luminance.
+// NaN pixels and invalid pixels of a colorext.Validator are ignored. Only
+// differences between values and their relative ordering matter, so the
+// hashes do not depend on the offset or positive gain of the data.
+package phash
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// Hash64 is a 64-bit perceptual hash.
+type Hash64 uint64
+
+// Distance returns the Hamming distance between h and o, the number of bits
+// in which they differ.
+func (h Hash64) Distance(o Hash64) int {
+	return bits.OnesCount64(uint64(h ^ o))
+}
+
+// String returns h as 16 hexadecimal digits.
+func (h Hash64) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Hash256 is a 256-bit perceptual hash, most significant word first.
+type Hash256 [4]uint64
+
+// Distance returns the Hamming distance between h and o, the number of bits
+// in which they differ.
+func (h Hash256) Distance(o Hash256) int {
+	n := 0
+	for i := range h {
+		n += bits.OnesCount64(h[i] ^ o[i])
+	}
+	return n
+}
+
+// String returns h as 64 hexadecimal digits.
+func (h Hash256) String() string {
+	return fmt.Sprintf("%016x%016x%016x%016x", h[0], h[1], h[2], h[3])
+}
+
+// PHash returns the 64-bit DCT hash of img. The image is reduced to 32×32
+// samples, and each bit records whether one of the 8×8 lowest-frequency DCT
+// coefficients exceeds their median. As in the classic pHash, frequency
+// zero in either direction is skipped, leaving out the DC term, which moves
+// with the offset of the data.
+func PHash(img image.Image) Hash64 {
+	return Hash64(dctBits(img, 32, 8)[0])
+}
+
+// PHash256 is like PHash with 256 bits, taken from the 16×16
+// lowest non-zero frequency coefficients of a 64×64 reduction.
+func PHash256(img image.Image) Hash256 {
+	var h Hash256
+	copy(h[:], dctBits(img, 64, 16))
+	return h
+}
+
+// DHash returns the 64-bit difference hash of img. The image is reduced to
+// 9×8 samples, and each bit records whether a sample is brighter than its
+// right-hand neighbor.
+func DHash(img image.Image) Hash64 {
+	return Hash64(diffBits(img, 8)[0])
+}
+
+// DHash256 is like DHash with 256 bits, from a 17×16 reduction.
+func DHash256(img image.Image) Hash256 {
+	var h Hash256
+	copy(h[:], diffBits(img, 16))
+	return h
+}
+
+// dctBits reduces img to n×n samples and thresholds the k×k DCT
+// coefficients of frequencies 1 to k at their median, packing the bits row
+// by row into words.
+func dctBits(img image.Image, n, k int) []uint64 {
+	s := reduce(img, n, n)
+	// Separable DCT-II, keeping only frequencies 1 to k in each direction.
+	basis := make([]float64, k*n)
+	for u := 0; u < k; u++ {
+		for x := 0; x < n; x++ {
+			basis[u*n+x] = math.Cos(math.Pi * float64(u+1) * (2*float64(x) + 1) / float64(2*n))
+		}
+	}
+	rows := make([]float64, n*k)
+	for y := 0; y < n; y++ {
+		for u := 0; u < k; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += basis[u*n+x] * s[y*n+x]
+			}
+			rows[y*k+u] = sum
+		}
+	}
+	coef := make([]float64, k*k)
+	for v := 0; v < k; v++ {
+		for u := 0; u < k; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += basis[v*n+y] * rows[y*k+u]
+			}
+			coef[v*k+u] = sum
+		}
+	}
+
+	med := median(slices.Clone(coef))
+	words := make([]uint64, k*k/64)
+	for i, c := range coef {
+		if c > med {
+			words[i/64] |= 1 << (63 - i%64)
+		}
+	}
+	return words
+}
+
+// diffBits reduces img to (n+1)×n samples and compares horizontal
+// neighbors, packing the bits row by row into words.
+func diffBits(img image.Image, n int) []uint64 {
+	s := reduce(img, n+1, n)
+	words := make([]uint64, n*n/64)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if s[y*(n+1)+x] > s[y*(n+1)+x+1] {
+				i := y*n + x
+				words[i/64] |= 1 << (63 - i%64)
+			}
+		}
+	}
+	return words
+}
+
+// reduce returns img averaged down to w×h samples in row-major order. Each
+// sample averages the valid pixels of its cell; cells without any take the
+// mean of the image.
+func reduce(img image.Image, w, h int) []float64 {
+	r := img.Bounds()
+	at := sampler(img)
+	sums := make([]float64, w*h)
+	counts := make([]int, w*h)
+	var total float64
+	n := 0
+	// Cells overlap when the image is smaller than the grid, so each pixel
+	// contributes to every cell whose range covers it.
+	for cy := 0; cy < h; cy++ {
+		y0, y1 := cellRange(cy, h, r.Dy())
+		for cx := 0; cx < w; cx++ {
+			x0, x1 := cellRange(cx, w, r.Dx())
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					if v := at(r.Min.X+x, r.Min.Y+y); !math.IsNaN(v) {
+						sums[cy*w+cx] += v
+						counts[cy*w+cx]++
+					}
+				}
+			}
+		}
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= float64(counts[i])
+			total += sums[i]
+			n++
+		}
+	}
+	mean := 0.0
+	if n > 0 {
+		mean = total / float64(n)
+	}
+	for i := range sums {
+		if counts[i] == 0 {
+			sums[i] = mean
+		}
+	}
+	return sums
+}
+
+// cellRange returns the pixel range of cell i of n cells spanning size
+// pixels. Every cell covers at least one pixel unless size is zero.
+func cellRange(i, n, size int) (lo, hi int) {
+	lo = i * size / n
+	hi = ((i+1)*size + n - 1) / n
+	if hi <= lo && lo < size {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// sampler returns a function reading raw values from img, yielding NaN for
+// invalid pixels.
+func sampler(img image.Image) func(x, y int) float64 {
+	var f func(x, y int) float64
+	switch m := img.(type) {
+	case *colorext.GrayS16Image:
+		f = func(x, y int) float64 { return float64(m.GrayS16At(x, y).Y) }
+	case *colorext.MaskedGrayS16Image:
+		f = func(x, y int) float64 { return float64(m.GrayS16At(x, y).Y) }
+	case *colorext.GrayF32Image:
+		f = func(x, y int) float64 { return float64(m.GrayF32At(x, y).Y) }
+	default:
+		f = func(x, y int) float64 {
+			return float64(colorext.GrayF32Model.Convert(img.At(x, y)).(colorext.GrayF32).Y)
+		}
+	}
+	if v, ok := img.(colorext.Validator); ok {
+		return func(x, y int) float64 {
+			if !v.Valid(x, y) {
+				return math.NaN()
+			}
+			return f(x, y)
+		}
+	}
+	return f
+}
+
+// median returns the median of v, sorting it.
+func median(v []float64) float64 {
+	slices.Sort(v)
+	m := len(v) / 2
+	if len(v)%2 == 0 {
+		return (v[m-1] + v[m]) / 2
+	}
+	return v[m]
+}
@@ -0,0 +1,159 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+// scene returns a smooth synthetic GrayS16 image with a few features.
+func scene(r image.Rectangle, seed int64) *colorext.GrayS16Image {
+	rng := rand.New(rand.NewSource(seed))
+	type blob struct{ x, y, s, a float64 }
+	blobs := make([]blob, 6)
+	for i := range blobs {
+		blobs[i] = blob{rng.Float64(), rng.Float64(), 0.05 + 0.2*rng.Float64(), rng.Float64()*2 - 1}
+	}
+	img := colorext.NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			u := float64(x-r.Min.X) / float64(r.Dx())
+			v := float64(y-r.Min.Y) / float64(r.Dy())
+			var sum float64
+			for _, b := range blobs {
+				d2 := (u-b.x)*(u-b.x) + (v-b.y)*(v-b.y)
+				sum += b.a * math.Exp(-d2/(2*b.s*b.s))
+			}
+			img.SetGrayS16(x, y, colorext.GrayS16{Y: int16(max(-32768, min(sum*12000, 32767)))})
+		}
+	}
+	return img
+}
+
+func TestHashes_SimilarAndDifferent(t *testing.T) {
+	r := image.Rect(0, 0, 120, 90)
+	a := scene(r, 1)
+	b := scene(r, 2)
+
+	// Gain, offset and noise.
+	rng := rand.New(rand.NewSource(5))
+	similar := colorext.NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := float64(a.GrayS16At(x, y).Y)*0.5 + 1000 + rng.NormFloat64()*50
+			similar.SetGrayS16(x, y, colorext.GrayS16{Y: int16(v)})
+		}
+	}
+	// The same scene as float data at a different size.
+	f32 := colorext.NewGrayF32Image(image.Rect(0, 0, 240, 180))
+	for y := 0; y < 180; y++ {
+		for x := 0; x < 240; x++ {
+			f32.SetGrayF32(x, y, colorext.GrayF32{Y: float32(a.GrayS16At(x/2, y/2).Y) / 32768})
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dist    func(a, b image.Image) int
+		bits    int
+		similar int
+	}{
+		{"PHash", func(a, b image.Image) int { return PHash(a).Distance(PHash(b)) }, 64, 8},
+		{"PHash256", func(a, b image.Image) int { return PHash256(a).Distance(PHash256(b)) }, 256, 32},
+		{"DHash", func(a, b image.Image) int { return DHash(a).Distance(DHash(b)) }, 64, 8},
+		{"DHash256", func(a, b image.Image) int { return DHash256(a).Distance(DHash256(b)) }, 256, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := tt.dist(a, a); d != 0 {
+				t.Errorf("distance to self = %d, want 0", d)
+			}
+			if d := tt.dist(a, similar); d > tt.similar {
+				t.Errorf("distance to rescaled noisy copy = %d, want <= %d", d, tt.similar)
+			}
+			if d := tt.dist(a, f32); d > tt.similar {
+				t.Errorf("distance to upscaled float copy = %d, want <= %d", d, tt.similar)
+			}
+			if d := tt.dist(a, b); d < tt.bits/4 {
+				t.Errorf("distance to different scene = %d, want >= %d", d, tt.bits/4)
+			}
+		})
+	}
+}
+
+func TestHashes_GainAndOffset(t *testing.T) {
+	r := image.Rect(0, 0, 64, 64)
+	a := scene(r, 3)
+	f := colorext.NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			f.SetGrayF32(x, y, colorext.GrayF32{Y: float32(a.GrayS16At(x, y).Y)})
+		}
+	}
+	for _, offset := range []float32{1e5, -1e5} {
+		shifted := colorext.NewGrayF32Image(r)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				shifted.SetGrayF32(x, y, colorext.GrayF32{Y: 3*f.GrayF32At(x, y).Y + offset})
+			}
+		}
+		if h, g := PHash(f), PHash(shifted); h != g {
+			t.Errorf("PHash = %v, %v after gain 3 and offset %g", h, g, offset)
+		}
+		if h, g := PHash256(f), PHash256(shifted); h != g {
+			t.Errorf("PHash256 = %v, %v after gain 3 and offset %g", h, g, offset)
+		}
+		if h, g := DHash(f), DHash(shifted); h != g {
+			t.Errorf("DHash = %v, %v after gain 3 and offset %g", h, g, offset)
+		}
+	}
+}
+
+func TestHashes_InvalidPixels(t *testing.T) {
+	r := image.Rect(0, 0, 64, 64)
+	a := scene(r, 3)
+	masked := colorext.NewMaskedGrayS16Image(scene(r, 3), -32768)
+	// Replace a few pixels with NoData, which should barely matter.
+	for i := 0; i < 20; i++ {
+		masked.Invalidate(3*i, 2*i)
+	}
+	if d := PHash(a).Distance(PHash(masked)); d > 4 {
+		t.Errorf("PHash distance with NoData pixels = %d, want <= 4", d)
+	}
+	if d := DHash(a).Distance(DHash(masked)); d > 4 {
+		t.Errorf("DHash distance with NoData pixels = %d, want <= 4", d)
+	}
+}
+
+func TestHashes_SmallAndDegenerate(t *testing.T) {
+	// Images smaller than the hash grid and empty images do not panic.
+	tiny := image.NewGray(image.Rect(0, 0, 3, 2))
+	tiny.SetGray(0, 0, color.Gray{Y: 200})
+	PHash(tiny)
+	DHash256(tiny)
+	if h := DHash(image.NewGray(image.Rectangle{})); h != 0 {
+		t.Errorf("DHash of empty image = %v, want 0", h)
+	}
+	if h := PHash(image.NewGray(image.Rect(0, 0, 10, 10))); h != 0 {
+		t.Errorf("PHash of flat image = %v, want 0", h)
+	}
+}
+
+func TestDistanceAndString(t *testing.T) {
+	if d := Hash64(0xff00).Distance(0x0f0f); d != 8 {
+		t.Errorf("Hash64 distance = %d, want 8", d)
+	}
+	if d := (Hash256{1, 0, ^uint64(0), 0}).Distance(Hash256{0, 0, 0, 3}); d != 67 {
+		t.Errorf("Hash256 distance = %d, want 67", d)
+	}
+	if s := Hash64(0xabc).String(); s != "0000000000000abc" {
+		t.Errorf("Hash64 String = %q", s)
+	}
+	if s := (Hash256{1, 2, 3, 4}).String(); len(s) != 64 || s[15] != '1' || s[63] != '4' {
+		t.Errorf("Hash256 String = %q", s)
+	}
+}
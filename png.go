@@ -0,0 +1,170 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Signed PNG convention.
+//
+// PNG has no signed samples, so EncodePNG stores a GrayS16Image as a
+// 16-bit gray PNG with 32768 added to each value, the OffsetBinary mapping,
+// which any viewer displays sensibly. It then records how to undo this in
+// an "siNT" chunk placed before the image data. The chunk name marks it as
+// ancillary, private and unsafe to copy, so other decoders ignore it and
+// editors that rewrite the pixels drop it. Its data is, in big-endian
+// order:
+//
+//	version   uint8    pngSignedVersion
+//	dtype     uint8    the DType of the stored values; DTypeInt16
+//	scale     float64  physical scale, as PhysicalGrayS16Image.Scale
+//	offset    float64  physical offset, as PhysicalGrayS16Image.Offset
+//	min x, y  int32    the origin of the image's bounds
+//	units     UTF-8    the remaining bytes, as PhysicalGrayS16Image.Units
+const (
+	pngSignedChunk   = "siNT"
+	pngSignedVersion = 1
+	pngSignedHeader  = 2 + 8 + 8 + 4 + 4
+)
+
+// pngSignature starts every PNG stream.
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// EncodePNG writes img to w as a PNG. A *GrayS16Image or
+// *PhysicalGrayS16Image is written as 16-bit gray with an siNT chunk, so
+// that DecodePNG restores its signed values, bounds and physical units;
+// other decoders see the OffsetBinary levels. Other images are written by
+// image/png unchanged.
+func EncodePNG(w io.Writer, img image.Image) error {
+	var s *GrayS16Image
+	var units Units
+	scale, offset := 1.0, 0.0
+	switch m := img.(type) {
+	case *GrayS16Image:
+		s = m
+	case *PhysicalGrayS16Image:
+		s, units, scale, offset = m.GrayS16Image, m.Units, m.scale(), m.Offset
+	default:
+		return png.Encode(w, img)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, GrayS16ToGray16(s)); err != nil {
+		return err
+	}
+	data := make([]byte, pngSignedHeader, pngSignedHeader+len(units))
+	data[0], data[1] = pngSignedVersion, byte(DTypeInt16)
+	binary.BigEndian.PutUint64(data[2:], math.Float64bits(scale))
+	binary.BigEndian.PutUint64(data[10:], math.Float64bits(offset))
+	binary.BigEndian.PutUint32(data[18:], uint32(int32(s.Rect.Min.X)))
+	binary.BigEndian.PutUint32(data[22:], uint32(int32(s.Rect.Min.Y)))
+	data = append(data, units...)
+
+	// image/png always writes IHDR first; the chunk goes right after it.
+	encoded := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + int(binary.BigEndian.Uint32(encoded[len(pngSignature):]))
+	if _, err := w.Write(encoded[:ihdrEnd]); err != nil {
+		return err
+	}
+	if _, err := w.Write(pngChunk(pngSignedChunk, data)); err != nil {
+		return err
+	}
+	_, err := w.Write(encoded[ihdrEnd:])
+	return err
+}
+
+// DecodePNG reads a PNG from r. If it holds an siNT chunk written by
+// EncodePNG, the result is a *GrayS16Image with the original values and
+// bounds, or a *PhysicalGrayS16Image if the chunk records units, a scale or
+// an offset. Otherwise the result is whatever image/png decodes. Problems
+// with the signature or the siNT chunk yield a *DecodeError; errors from
+// image/png are returned as is.
+func DecodePNG(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	chunk, at, err := findPNGChunk(data, pngSignedChunk)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || chunk == nil {
+		return img, err
+	}
+
+	if len(chunk) < pngSignedHeader {
+		return nil, decodeError("PNG", at, errors.New("truncated siNT chunk"))
+	}
+	if chunk[0] != pngSignedVersion {
+		return nil, decodeError("PNG", at, fmt.Errorf("unsupported siNT version %d", chunk[0]))
+	}
+	if d := DType(chunk[1]); d != DTypeInt16 {
+		return nil, decodeError("PNG", at+1, fmt.Errorf("%w %v in siNT chunk", ErrUnsupportedDType, d))
+	}
+	g, ok := img.(*image.Gray16)
+	if !ok {
+		return nil, decodeError("PNG", at, fmt.Errorf("%w: siNT chunk on %T, want 16-bit gray", ErrUnsupportedDType, img))
+	}
+	scale := math.Float64frombits(binary.BigEndian.Uint64(chunk[2:]))
+	offset := math.Float64frombits(binary.BigEndian.Uint64(chunk[10:]))
+	origin := image.Pt(
+		int(int32(binary.BigEndian.Uint32(chunk[18:]))),
+		int(int32(binary.BigEndian.Uint32(chunk[22:]))),
+	)
+	units := Units(chunk[pngSignedHeader:])
+
+	s := Gray16ToGrayS16(g)
+	s.Rect = s.Rect.Add(origin)
+	if units == UnitsNone && scale == 1 && offset == 0 {
+		return s, nil
+	}
+	return NewPhysicalGrayS16Image(s, units, scale, offset), nil
+}
+
+// pngChunk returns a complete PNG chunk: length, type, data and CRC.
+func pngChunk(typ string, data []byte) []byte {
+	b := make([]byte, 0, 12+len(data))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+// findPNGChunk returns the data of the first chunk of type typ before the
+// image data in the PNG stream data and its offset in the stream, or nil
+// if there is none. A chunk with a bad CRC is an error.
+func findPNGChunk(data []byte, typ string) ([]byte, int64, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, 0, decodeError("PNG", 0, errors.New("not a PNG stream"))
+	}
+	off := int64(len(pngSignature))
+	data = data[len(pngSignature):]
+	for len(data) >= 12 {
+		n := binary.BigEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-12) {
+			break
+		}
+		name := string(data[4:8])
+		if name == "IDAT" {
+			break
+		}
+		if name == typ {
+			body := data[8 : 8+n]
+			if crc32.ChecksumIEEE(data[4:8+n]) != binary.BigEndian.Uint32(data[8+n:]) {
+				return nil, 0, decodeError("PNG", off+8+int64(n), fmt.Errorf("bad CRC in %s chunk", typ))
+			}
+			return body, off + 8, nil
+		}
+		off += 12 + int64(n)
+		data = data[12+n:]
+	}
+	return nil, 0, nil
+}
@@ -0,0 +1,133 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPNGRoundTrip(t *testing.T) {
+	src := randomGrayS16(image.Rect(-3, 7, 13, 18), 9)
+	src.SetGrayS16(-3, 7, GrayS16{Y: -32768})
+	src.SetGrayS16(12, 17, GrayS16{Y: 32767})
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"GrayS16", src},
+		{"sub-image", src.SubImage(image.Rect(0, 8, 5, 12))},
+		{"physical", NewPhysicalGrayS16Image(src, UnitsCelsius, 0.01, -40)},
+		{"physical scale only", NewPhysicalGrayS16Image(src, UnitsNone, 0.5, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodePNG(&buf, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodePNG(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Bounds() != tt.img.Bounds() {
+				t.Fatalf("bounds %v, want %v", got.Bounds(), tt.img.Bounds())
+			}
+			want := tt.img
+			if p, ok := want.(*PhysicalGrayS16Image); ok {
+				g, ok := got.(*PhysicalGrayS16Image)
+				if !ok {
+					t.Fatalf("decoded %T, want *PhysicalGrayS16Image", got)
+				}
+				if g.Units != p.Units || g.Scale != p.Scale || g.Offset != p.Offset {
+					t.Errorf("decoded %q %v %v, want %q %v %v", g.Units, g.Scale, g.Offset, p.Units, p.Scale, p.Offset)
+				}
+				got, want = g.GrayS16Image, p.GrayS16Image
+			}
+			if !Equal(got, want) {
+				t.Errorf("decoded pixels differ")
+			}
+		})
+	}
+}
+
+func TestPNGStdlibCompatible(t *testing.T) {
+	src := NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	src.SetGrayS16(0, 0, GrayS16{Y: -32768})
+	src.SetGrayS16(1, 0, GrayS16{Y: 1000})
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(pngSignedChunk)) {
+		t.Fatal("no siNT chunk written")
+	}
+	// image/png ignores the chunk and sees the OffsetBinary levels.
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := img.(*image.Gray16)
+	if a, b := g.Gray16At(0, 0).Y, g.Gray16At(1, 0).Y; a != 0 || b != 33768 {
+		t.Errorf("stdlib decoded %d, %d, want 0, 33768", a, b)
+	}
+}
+
+func TestPNGPlain(t *testing.T) {
+	// Images without the chunk pass through image/png in both directions.
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	src.Pix[4] = 200
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte(pngSignedChunk)) {
+		t.Error("siNT chunk written for an *image.Gray")
+	}
+	got, err := DecodePNG(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := got.(*image.Gray); !ok || !bytes.Equal(g.Pix, src.Pix) {
+		t.Errorf("decoded %T %v", got, got)
+	}
+}
+
+func TestDecodePNGErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, NewGrayS16Image(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	at := bytes.Index(good, []byte(pngSignedChunk))
+	corrupt := func(i int, v byte) []byte {
+		b := bytes.Clone(good)
+		b[i] = v
+		return b
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not png", []byte("GIF89a"), "not a PNG"},
+		{"bad crc", corrupt(at+4, 9), "bad CRC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodePNG(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// A chunk with a valid CRC but an unknown version is rejected too.
+	b := bytes.Clone(good[:at-4])
+	b = append(b, pngChunk(pngSignedChunk, append([]byte{9}, make([]byte, pngSignedHeader-1)...))...)
+	b = append(b, good[at+4+pngSignedHeader+4:]...)
+	if _, err := DecodePNG(bytes.NewReader(b)); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("got error %v, want unsupported version", err)
+	}
+}
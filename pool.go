@@ -0,0 +1,62 @@
+package colorext
+
+import (
+	"image"
+	"math/bits"
+	"sync"
+)
+
+// pixPools holds reusable pixel buffers, bucketed by capacity: pool i holds
+// buffers with a capacity of exactly 1<<i bytes.
+var pixPools [bits.UintSize]sync.Pool
+
+// getPix returns a zeroed buffer of n bytes from the pools.
+func getPix(n int) []uint8 {
+	if n == 0 {
+		return nil
+	}
+	b := bits.Len(uint(n - 1))
+	if v := pixPools[b].Get(); v != nil {
+		buf := (*v.(*[]uint8))[:n]
+		clear(buf)
+		return buf
+	}
+	return make([]uint8, n, 1<<b)
+}
+
+// putPix returns a buffer obtained from getPix to the pools.
+func putPix(buf []uint8) {
+	c := cap(buf)
+	if c == 0 || c&(c-1) != 0 {
+		// Not allocated by getPix.
+		return
+	}
+	buf = buf[:0]
+	pixPools[bits.Len(uint(c-1))].Put(&buf)
+}
+
+// NewGrayS16ImageFromPool is like NewGrayS16Image but takes its pixel buffer
+// from a pool of buffers released by ReleaseGrayS16Image, avoiding an
+// allocation per image when images of similar sizes are created repeatedly,
+// as when processing video frames. The pixels are zeroed.
+func NewGrayS16ImageFromPool(r image.Rectangle) *GrayS16Image {
+	w, h := r.Dx(), r.Dy()
+	return &GrayS16Image{
+		Pix:    getPix(2 * w * h),
+		Stride: 2 * w,
+		Rect:   r,
+	}
+}
+
+// ReleaseGrayS16Image returns the pixel buffer of an image created by
+// NewGrayS16ImageFromPool to the pool and clears the image. Neither img nor
+// any sub-image of it may be used afterwards, and sub-images must not be
+// released themselves. Buffers that did not come from the pool may be
+// pooled too, as long as nothing else refers to them.
+func ReleaseGrayS16Image(img *GrayS16Image) {
+	if img == nil {
+		return
+	}
+	putPix(img.Pix)
+	*img = GrayS16Image{}
+}
@@ -0,0 +1,61 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGrayS16ImagePool(t *testing.T) {
+	r := image.Rect(-2, 1, 98, 51)
+	img := NewGrayS16ImageFromPool(r)
+	if img.Rect != r || img.Stride != 200 || len(img.Pix) != 10000 {
+		t.Fatalf("Rect, Stride, len(Pix) = %v, %d, %d, want %v, 200, 10000", img.Rect, img.Stride, len(img.Pix), r)
+	}
+	for i := range img.Pix {
+		img.Pix[i] = 0xaa
+	}
+	ReleaseGrayS16Image(img)
+	if img.Pix != nil || !img.Rect.Empty() {
+		t.Error("ReleaseGrayS16Image did not clear the image")
+	}
+
+	// A reused buffer is zeroed. The pool may or may not hand back the same
+	// buffer, so only the contents are checked.
+	for range 3 {
+		img = NewGrayS16ImageFromPool(image.Rect(0, 0, 90, 50))
+		if len(img.Pix) != 9000 {
+			t.Fatalf("len(Pix) = %d, want 9000", len(img.Pix))
+		}
+		for i, v := range img.Pix {
+			if v != 0 {
+				t.Fatalf("Pix[%d] = %#x, want 0", i, v)
+			}
+		}
+		img.SetGrayS16(3, 4, GrayS16{Y: -1})
+		ReleaseGrayS16Image(img)
+	}
+
+	// Images from elsewhere and empty images are accepted.
+	ReleaseGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 3, 3)))
+	ReleaseGrayS16Image(NewGrayS16ImageFromPool(image.Rectangle{}))
+	ReleaseGrayS16Image(nil)
+}
+
+func BenchmarkGrayS16ImagePool(b *testing.B) {
+	r := image.Rect(0, 0, 1920, 1080)
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			img := NewGrayS16Image(r)
+			img.Pix[0] = 1
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			img := NewGrayS16ImageFromPool(r)
+			img.Pix[0] = 1
+			ReleaseGrayS16Image(img)
+		}
+	})
+}
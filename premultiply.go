@@ -0,0 +1,69 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// Premultiply returns a premultiplied copy of src. Each color component is
+// multiplied by alpha and rounded to the nearest 16-bit value.
+func Premultiply(src *image.NRGBA64) *image.RGBA64 {
+	dst := image.NewRGBA64(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			c := src.NRGBA64At(x, y)
+			a := uint32(c.A)
+			mul := func(v uint16) uint16 {
+				return uint16((uint32(v)*a + 0x7fff) / 0xffff)
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: mul(c.R), G: mul(c.G), B: mul(c.B), A: c.A})
+		}
+	}
+	return dst
+}
+
+// Unpremultiply returns a non-premultiplied copy of src. Each color
+// component is divided by alpha and rounded to the nearest 16-bit value;
+// components exceeding alpha are clamped. Fully transparent pixels become
+// transparent black.
+func Unpremultiply(src *image.RGBA64) *image.NRGBA64 {
+	dst := image.NewNRGBA64(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			c := src.RGBA64At(x, y)
+			if c.A == 0 {
+				continue
+			}
+			a := uint32(c.A)
+			div := func(v uint16) uint16 {
+				return uint16((min(uint32(v), a)*0xffff + a/2) / a)
+			}
+			dst.SetNRGBA64(x, y, color.NRGBA64{R: div(c.R), G: div(c.G), B: div(c.B), A: c.A})
+		}
+	}
+	return dst
+}
+
+// PremultiplyF32 returns a premultiplied copy of src. Values are not
+// clamped.
+func PremultiplyF32(src *NRGBAF32Image) *RGBAF32Image {
+	dst := NewRGBAF32Image(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			dst.SetRGBAF32(x, y, src.NRGBAF32At(x, y).Premultiply())
+		}
+	}
+	return dst
+}
+
+// UnpremultiplyF32 returns a non-premultiplied copy of src. Values are not
+// clamped, and fully transparent pixels become transparent black.
+func UnpremultiplyF32(src *RGBAF32Image) *NRGBAF32Image {
+	dst := NewNRGBAF32Image(src.Rect)
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			dst.SetNRGBAF32(x, y, src.RGBAF32At(x, y).Unpremultiply())
+		}
+	}
+	return dst
+}
@@ -0,0 +1,67 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPremultiply(t *testing.T) {
+	src := image.NewNRGBA64(image.Rect(0, 0, 3, 1))
+	src.SetNRGBA64(0, 0, color.NRGBA64{0xffff, 0x8000, 0, 0x8000})
+	src.SetNRGBA64(1, 0, color.NRGBA64{0x1234, 0x5678, 0x9abc, 0xffff})
+	src.SetNRGBA64(2, 0, color.NRGBA64{0xffff, 0xffff, 0xffff, 0})
+
+	dst := Premultiply(src)
+	want := []color.RGBA64{
+		{0x8000, 0x4000, 0, 0x8000},
+		{0x1234, 0x5678, 0x9abc, 0xffff},
+		{},
+	}
+	for x, w := range want {
+		if got := dst.RGBA64At(x, 0); got != w {
+			t.Errorf("pixel %d = %v, want %v", x, got, w)
+		}
+		// The result matches the stdlib's own conversion.
+		if got, std := dst.RGBA64At(x, 0), color.RGBA64Model.Convert(src.At(x, 0)); got != std {
+			t.Errorf("pixel %d = %v, stdlib conversion gives %v", x, got, std)
+		}
+	}
+}
+
+func TestUnpremultiply(t *testing.T) {
+	src := image.NewRGBA64(image.Rect(0, 0, 3, 1))
+	src.SetRGBA64(0, 0, color.RGBA64{0x8000, 0x4000, 0, 0x8000})
+	src.SetRGBA64(1, 0, color.RGBA64{0xffff, 0, 0, 0x8000})
+	src.SetRGBA64(2, 0, color.RGBA64{0x1000, 0, 0, 0})
+
+	dst := Unpremultiply(src)
+	want := []color.NRGBA64{
+		{0xffff, 0x8000, 0, 0x8000},
+		{0xffff, 0, 0, 0x8000},
+		{},
+	}
+	for x, w := range want {
+		if got := dst.NRGBA64At(x, 0); got != w {
+			t.Errorf("pixel %d = %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestPremultiplyF32_RoundTrip(t *testing.T) {
+	src := NewNRGBAF32Image(image.Rect(0, 0, 2, 1))
+	src.SetNRGBAF32(0, 0, NRGBAF32{0.5, 3, 0.25, 0.5})
+	src.SetNRGBAF32(1, 0, NRGBAF32{1, 1, 1, 0})
+
+	p := PremultiplyF32(src)
+	if got := p.RGBAF32At(0, 0); got != (RGBAF32{0.25, 1.5, 0.125, 0.5}) {
+		t.Errorf("PremultiplyF32 pixel = %v", got)
+	}
+	back := UnpremultiplyF32(p)
+	if got := back.NRGBAF32At(0, 0); got != src.NRGBAF32At(0, 0) {
+		t.Errorf("round trip = %v, want %v", got, src.NRGBAF32At(0, 0))
+	}
+	if got := back.NRGBAF32At(1, 0); got != (NRGBAF32{}) {
+		t.Errorf("transparent round trip = %v, want zero", got)
+	}
+}
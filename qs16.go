@@ -0,0 +1,288 @@
+package colorext
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// QS16 is a lossless codec for GrayS16Image frames in the spirit of QOI:
+// a single pass with no entropy coder, fast enough to record sensor video
+// as it arrives. Each frame is the magic "qs16", a version byte, the four
+// coordinates of its bounds as varints, and one operation stream over the
+// pixels in row-major order.
+//
+// Each pixel is predicted by its left neighbor, or for the first pixel of
+// a row by the pixel above it, and the difference, wrapped to 16 bits, is
+// coded as one of:
+//
+//	00rrrrrr                    r+1 zero differences (1 to 64)
+//	01dddddd                    difference in [-32, 31]
+//	10dddddd dddddddd           difference in [-8192, 8191], big-endian
+//	11000000 uvarint(zigzag(d)) any difference
+//	11000001 uvarint(n)         n zero differences, for long runs
+//
+// A frame carries its own length implicitly, so frames can simply be
+// concatenated; QS16Writer and QS16Reader do so.
+const (
+	qs16Magic   = "qs16"
+	qs16Version = 1
+
+	qs16Run      = 0x00
+	qs16Small    = 0x40
+	qs16Medium   = 0x80
+	qs16Full     = 0xc0
+	qs16LongRun  = 0xc1
+	qs16MaxShort = 64
+)
+
+// DefaultQS16MaxPixels is the largest frame, in pixels, that DecodeQS16
+// and QS16Reader decode by default: 256 Mi pixels, taking 512 MiB. A few
+// header and run bytes can declare a frame of up to 2 Gi pixels, so larger
+// frames are rejected before their pixels are allocated.
+const DefaultQS16MaxPixels = 1 << 28
+
+// EncodeQS16 writes img to w as a single QS16 frame.
+func EncodeQS16(w io.Writer, img *GrayS16Image) error {
+	return encodeQS16(w, img)
+}
+
+// DecodeQS16 reads a single QS16 frame from r. If r is not an
+// io.ByteReader it is buffered, and may be read past the end of the frame;
+// use QS16Reader for streams of frames or frames of more than
+// DefaultQS16MaxPixels pixels. Malformed input yields a *DecodeError.
+func DecodeQS16(r io.Reader) (*GrayS16Image, error) {
+	return decodeQS16(byteReader(r), DefaultQS16MaxPixels)
+}
+
+// encodeQS16 writes one frame to w, in chunks of about qs16Chunk bytes.
+func encodeQS16(w io.Writer, img *GrayS16Image) error {
+	const qs16Chunk = 32 << 10
+	r := img.Rect
+	out := make([]byte, 0, qs16Chunk+3*binary.MaxVarintLen64)
+	out = append(out, qs16Magic...)
+	out = append(out, qs16Version)
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y} {
+		out = binary.AppendVarint(out, int64(v))
+	}
+
+	run := 0
+	endRun := func() {
+		switch {
+		case run == 0:
+		case run <= qs16MaxShort:
+			out = append(out, qs16Run|byte(run-1))
+		default:
+			out = append(out, qs16LongRun)
+			out = binary.AppendUvarint(out, uint64(run))
+		}
+		run = 0
+	}
+	width := r.Dx()
+	var above []uint8
+	for y := 0; y < r.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+2*width]
+		var pred int16
+		if above != nil {
+			pred = int16(uint16(above[0])<<8 | uint16(above[1]))
+		}
+		for x := 0; x < width; x++ {
+			v := int16(uint16(row[2*x])<<8 | uint16(row[2*x+1]))
+			d := v - pred
+			pred = v
+			if d == 0 {
+				run++
+				continue
+			}
+			endRun()
+			switch {
+			case d >= -32 && d < 32:
+				out = append(out, qs16Small|byte(d)&0x3f)
+			case d >= -8192 && d < 8192:
+				u := uint16(d) & 0x3fff
+				out = append(out, qs16Medium|byte(u>>8), byte(u))
+			default:
+				zz := uint16(d<<1) ^ uint16(d>>15)
+				out = binary.AppendUvarint(append(out, qs16Full), uint64(zz))
+			}
+			if len(out) >= qs16Chunk {
+				if _, err := w.Write(out); err != nil {
+					return err
+				}
+				out = out[:0]
+			}
+		}
+		above = row
+	}
+	endRun()
+	_, err := w.Write(out)
+	return err
+}
+
+// readQS16Header reads the header of a frame from r and returns its
+// bounds, which may be empty. Clean EOF before the header is returned as
+// io.EOF; any other failure is a *DecodeError.
+func readQS16Header(r *offsetReader) (image.Rectangle, error) {
+	fail := func(off int64, err error) (image.Rectangle, error) {
+		return image.Rectangle{}, decodeError("QS16", off, err)
+	}
+	var hdr [len(qs16Magic) + 1]byte
+	for i := range hdr {
+		c, err := r.ReadByte()
+		if err != nil {
+			if i == 0 && err == io.EOF {
+				return image.Rectangle{}, err
+			}
+			return fail(r.off, err)
+		}
+		hdr[i] = c
+	}
+	if string(hdr[:len(qs16Magic)]) != qs16Magic {
+		return fail(r.off-int64(len(hdr)), errors.New("not a QS16 frame"))
+	}
+	if hdr[len(qs16Magic)] != qs16Version {
+		return fail(r.off-1, fmt.Errorf("unsupported version %d", hdr[len(qs16Magic)]))
+	}
+	var c [4]int
+	for i := range c {
+		v, err := binary.ReadVarint(r)
+		if err != nil || v != int64(int(v)) {
+			return fail(r.off, err)
+		}
+		c[i] = int(v)
+	}
+	if c[2] < c[0] || c[3] < c[1] {
+		return fail(r.off, fmt.Errorf("%w: malformed bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, c[0], c[1], c[2], c[3]))
+	}
+	w, h := uint64(c[2])-uint64(c[0]), uint64(c[3])-uint64(c[1])
+	if w != 0 && h > math.MaxInt32/w {
+		return fail(r.off, fmt.Errorf("frame of %d×%d pixels is too large", w, h))
+	}
+	if w == 0 || h == 0 {
+		return image.Rectangle{}, nil
+	}
+	return image.Rect(c[0], c[1], c[2], c[3]), nil
+}
+
+// decodeQS16 reads one frame of at most maxPixels pixels from r. Clean EOF
+// before the frame is returned as io.EOF; any other failure is a
+// *DecodeError.
+func decodeQS16(r *offsetReader, maxPixels int) (*GrayS16Image, error) {
+	fail := func(off int64, err error) (*GrayS16Image, error) {
+		return nil, decodeError("QS16", off, err)
+	}
+	rect, err := readQS16Header(r)
+	if err != nil {
+		return nil, err
+	}
+	// readQS16Header bounds the area, so the product cannot overflow.
+	if n := rect.Dx() * rect.Dy(); n > maxPixels {
+		return fail(r.off, fmt.Errorf("frame of %d×%d pixels exceeds the limit of %d", rect.Dx(), rect.Dy(), maxPixels))
+	}
+	img := NewGrayS16Image(rect)
+	n := len(img.Pix) / 2
+	width := rect.Dx()
+
+	pix := img.Pix
+	var pred int16
+	i := 0
+	put := func(d int16) {
+		if i%width == 0 {
+			pred = 0
+			if i >= width {
+				j := 2 * (i - width)
+				pred = int16(uint16(pix[j])<<8 | uint16(pix[j+1]))
+			}
+		}
+		pred += d
+		pix[2*i], pix[2*i+1] = uint8(uint16(pred)>>8), uint8(pred)
+		i++
+	}
+	for i < n {
+		at := r.off
+		op, err := r.ReadByte()
+		if err != nil {
+			return fail(r.off, err)
+		}
+		switch {
+		case op < qs16Small, op == qs16LongRun:
+			run := uint64(op&0x3f) + 1
+			if op == qs16LongRun {
+				if run, err = binary.ReadUvarint(r); err != nil {
+					return fail(r.off, err)
+				}
+			}
+			if run > uint64(n-i) {
+				return fail(at, fmt.Errorf("run of %d pixels overflows the frame", run))
+			}
+			for range run {
+				put(0)
+			}
+		case op < qs16Medium:
+			put(int16(int8(op<<2) >> 2))
+		case op < qs16Full:
+			lo, err := r.ReadByte()
+			if err != nil {
+				return fail(r.off, err)
+			}
+			put(int16(uint16(op&0x3f)<<10|uint16(lo)<<2) >> 2)
+		case op == qs16Full:
+			zz, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fail(r.off, err)
+			}
+			if zz > math.MaxUint16 {
+				return fail(at, fmt.Errorf("difference %d out of range", zz))
+			}
+			put(int16(zz>>1) ^ -int16(zz&1))
+		default:
+			return fail(at, fmt.Errorf("invalid operation %#02x", op))
+		}
+	}
+	return img, nil
+}
+
+// QS16Writer writes a stream of QS16 frames. Each frame is written as it
+// is encoded, without further buffering.
+type QS16Writer struct {
+	w io.Writer
+}
+
+// NewQS16Writer returns a QS16Writer writing to w.
+func NewQS16Writer(w io.Writer) *QS16Writer {
+	return &QS16Writer{w: w}
+}
+
+// WriteFrame appends img to the stream.
+func (w *QS16Writer) WriteFrame(img *GrayS16Image) error {
+	return encodeQS16(w.w, img)
+}
+
+// QS16Reader reads a stream of QS16 frames.
+type QS16Reader struct {
+	r offsetReader
+	// MaxPixels is the largest frame, in pixels, that ReadFrame decodes.
+	// Zero means DefaultQS16MaxPixels.
+	MaxPixels int
+}
+
+// NewQS16Reader returns a QS16Reader reading from r.
+func NewQS16Reader(r io.Reader) *QS16Reader {
+	return &QS16Reader{r: offsetReader{r: bufio.NewReaderSize(r, 64<<10)}}
+}
+
+// ReadFrame returns the next frame in the stream, or io.EOF after the
+// last one. Malformed frames yield a *DecodeError whose offset counts from
+// the start of the stream; a stream ending inside a frame yields one
+// wrapping io.ErrUnexpectedEOF.
+func (r *QS16Reader) ReadFrame() (*GrayS16Image, error) {
+	limit := r.MaxPixels
+	if limit == 0 {
+		limit = DefaultQS16MaxPixels
+	}
+	return decodeQS16(&r.r, limit)
+}
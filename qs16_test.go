@@ -0,0 +1,152 @@
+package colorext
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestQS16RoundTrip(t *testing.T) {
+	smooth := NewGrayS16Image(image.Rect(-4, 3, 60, 40))
+	for y := smooth.Rect.Min.Y; y < smooth.Rect.Max.Y; y++ {
+		for x := smooth.Rect.Min.X; x < smooth.Rect.Max.X; x++ {
+			smooth.SetGrayS16(x, y, GrayS16{Y: int16(300*math.Sin(float64(x)/40) + 5*float64(y))})
+		}
+	}
+	extremes := NewGrayS16Image(image.Rect(0, 0, 5, 2))
+	for i, v := range []int16{-32768, 32767, -32768, 0, 8191, -8192, 8192, -8193, 31, -33} {
+		extremes.SetGrayS16(i%5, i/5, GrayS16{Y: v})
+	}
+	noise := randomGrayS16(image.Rect(0, 0, 31, 17), 4)
+	tests := []struct {
+		name string
+		img  *GrayS16Image
+	}{
+		{"smooth", smooth},
+		{"flat", NewGrayS16Image(image.Rect(0, 0, 300, 2))},
+		{"extremes", extremes},
+		{"noise", noise},
+		{"sub-image", noise.SubImage(image.Rect(3, 2, 20, 9)).(*GrayS16Image)},
+		{"empty", NewGrayS16Image(image.Rectangle{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeQS16(&buf, tt.img); err != nil {
+				t.Fatal(err)
+			}
+			n := buf.Len()
+			got, err := DecodeQS16(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(got, tt.img) {
+				t.Errorf("decoded image differs")
+			}
+			if tt.name == "flat" && n > 20 {
+				t.Errorf("flat frame encoded in %d bytes", n)
+			}
+			if tt.name == "smooth" && n > len(tt.img.Pix)*6/10 {
+				t.Errorf("smooth frame encoded in %d bytes, raw is %d", n, len(tt.img.Pix))
+			}
+		})
+	}
+}
+
+func TestQS16Stream(t *testing.T) {
+	var frames []*GrayS16Image
+	for i := range 5 {
+		frames = append(frames, randomGrayS16(image.Rect(0, 0, 16+i, 9), int64(i)))
+	}
+	var buf bytes.Buffer
+	w := NewQS16Writer(&buf)
+	for _, f := range frames {
+		if err := w.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	r := NewQS16Reader(bytes.NewReader(data))
+	for i, want := range frames {
+		got, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !Equal(got, want) {
+			t.Errorf("frame %d differs", i)
+		}
+	}
+	if _, err := r.ReadFrame(); err != io.EOF {
+		t.Errorf("after last frame: got %v, want io.EOF", err)
+	}
+
+	r = NewQS16Reader(bytes.NewReader(data[:len(data)-3]))
+	var err error
+	for err == nil {
+		_, err = r.ReadFrame()
+	}
+	var de *DecodeError
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &de) || de.Offset != int64(len(data)-3) {
+		t.Errorf("truncated stream: got %v, want io.ErrUnexpectedEOF at offset %d", err, len(data)-3)
+	}
+}
+
+func TestQS16ReaderMaxPixels(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeQS16(&buf, randomGrayS16(image.Rect(0, 0, 4, 4), 2))
+	for _, tt := range []struct {
+		max int
+		ok  bool
+	}{{0, true}, {16, true}, {15, false}} {
+		r := NewQS16Reader(bytes.NewReader(buf.Bytes()))
+		r.MaxPixels = tt.max
+		_, err := r.ReadFrame()
+		var de *DecodeError
+		if tt.ok && err != nil || !tt.ok && !errors.As(err, &de) {
+			t.Errorf("MaxPixels %d: err = %v", tt.max, err)
+		}
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestQS16Errors(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeQS16(&buf, NewGrayS16Image(image.Rect(0, 0, 2, 2)))
+	good := buf.Bytes()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"magic", []byte("qoif\x01\x00\x00\x04\x04")},
+		{"version", append([]byte("qs16\x09"), good[5:]...)},
+		{"bounds", []byte("qs16\x01\x04\x00\x00\x02")},
+		{"run overflow", []byte("qs16\x01\x00\x00\x04\x04\x3f")},
+		{"bad op", []byte("qs16\x01\x00\x00\x02\x02\xff")},
+		{"huge", []byte("qs16\x01\x00\x00\xfe\xff\xff\xff\x0f\xfe\xff\xff\xff\x0f")},
+		// 32768×16384 pixels, over DefaultQS16MaxPixels, in one long run.
+		{"over limit", []byte("qs16\x01\x00\x00\x80\x80\x04\x80\x80\x02\xc1\x80\x80\x80\x80\x02")},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeQS16(bytes.NewReader(tt.data)); err == nil {
+			t.Errorf("%s: got nil error", tt.name)
+		}
+	}
+	if err := EncodeQS16(failWriter{}, NewGrayS16Image(image.Rect(0, 0, 2, 2))); err == nil {
+		t.Error("write failure: got nil error")
+	}
+}
+
+func BenchmarkEncodeQS16(b *testing.B) {
+	img := randomGrayS16(image.Rect(0, 0, 640, 480), 1)
+	b.SetBytes(int64(len(img.Pix)))
+	for b.Loop() {
+		EncodeQS16(io.Discard, img)
+	}
+}
@@ -0,0 +1,202 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// QuantizeMethod selects how GrayS16Quantizer chooses palette levels.
+type QuantizeMethod int
+
+const (
+	// MedianCut repeatedly splits the range of values spanning the widest
+	// interval at its median, and uses the mean of each final range as a
+	// palette level. It spreads levels evenly over the population.
+	MedianCut QuantizeMethod = iota
+	// KMeans places initial levels by variance-minimizing splits and refines
+	// them with Lloyd's algorithm, which minimizes the mean squared
+	// quantization error. It finds clusters of values that MedianCut may
+	// split or merge.
+	KMeans
+)
+
+// GrayS16Quantizer builds palettes of GrayS16 levels from the histogram of
+// an image. It implements draw.Quantizer, so it can be used with
+// gif.Options or to prepare the palette of a Paletted16Image.
+//
+// Pixel values are read as raw int16 values from GrayS16Image sources and
+// through GrayS16Model otherwise. Invalid pixels of a Validator, such as
+// NoData pixels of a MaskedGrayS16Image, are ignored.
+type GrayS16Quantizer struct {
+	Method QuantizeMethod
+	// Iterations bounds the number of k-means refinement passes. Zero means
+	// 20. It is ignored by MedianCut.
+	Iterations int
+}
+
+// Quantize appends up to cap(p)-len(p) GrayS16 levels chosen from the
+// histogram of m to p, in ascending order. If m holds fewer distinct values
+// than requested, each distinct value is appended once.
+func (q GrayS16Quantizer) Quantize(p color.Palette, m image.Image) color.Palette {
+	n := cap(p) - len(p)
+	if n <= 0 {
+		return p
+	}
+	vals, counts := grayS16Histogram(m)
+	var levels []float64
+	if len(vals) <= n {
+		for _, v := range vals {
+			levels = append(levels, float64(v))
+		}
+	} else {
+		levels = cutLevels(vals, counts, n, q.Method == KMeans)
+		if q.Method == KMeans {
+			iters := q.Iterations
+			if iters == 0 {
+				iters = 20
+			}
+			levels = kMeans1D(vals, counts, levels, iters)
+		}
+	}
+	for _, l := range levels {
+		p = append(p, GrayS16{Y: int16(math.Round(l))})
+	}
+	return p
+}
+
+// grayS16Histogram returns the distinct values of m in ascending order and
+// the number of pixels holding each.
+func grayS16Histogram(m image.Image) (vals []int16, counts []int) {
+	hist := make([]int, 1<<16)
+	r := m.Bounds()
+	v, hasValidity := m.(Validator)
+	s16, isS16 := m.(*GrayS16Image)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if hasValidity && !v.Valid(x, y) {
+				continue
+			}
+			var c GrayS16
+			if isS16 {
+				c = s16.GrayS16At(x, y)
+			} else {
+				c = GrayS16Model.Convert(m.At(x, y)).(GrayS16)
+			}
+			hist[int(c.Y)+32768]++
+		}
+	}
+	for i, c := range hist {
+		if c > 0 {
+			vals = append(vals, int16(i-32768))
+			counts = append(counts, c)
+		}
+	}
+	return vals, counts
+}
+
+// cutLevels splits the distinct values into n ranges and returns the
+// weighted mean of each, in ascending order. len(vals) must be at least n.
+//
+// Without variance, it performs a classic median cut: the range spanning
+// the widest interval of values is split at its median. With variance, the
+// range with the largest squared error is split where that error is
+// reduced the most, which places levels on clusters of values.
+func cutLevels(vals []int16, counts []int, n int, variance bool) []float64 {
+	// Prefix sums of the population, first and second moments.
+	w := make([]float64, len(vals)+1)
+	s1 := make([]float64, len(vals)+1)
+	s2 := make([]float64, len(vals)+1)
+	for i, v := range vals {
+		c, fv := float64(counts[i]), float64(v)
+		w[i+1] = w[i] + c
+		s1[i+1] = s1[i] + c*fv
+		s2[i+1] = s2[i] + c*fv*fv
+	}
+	sse := func(lo, hi int) float64 {
+		d := s1[hi] - s1[lo]
+		return s2[hi] - s2[lo] - d*d/(w[hi]-w[lo])
+	}
+
+	type box struct{ lo, hi int }
+	boxes := []box{{0, len(vals)}}
+	for len(boxes) < n {
+		best, bestScore := -1, -1.0
+		for i, b := range boxes {
+			if b.hi-b.lo < 2 {
+				continue
+			}
+			score := float64(vals[b.hi-1]) - float64(vals[b.lo])
+			if variance {
+				score = sse(b.lo, b.hi)
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		b := boxes[best]
+
+		mid := b.lo + 1
+		if variance {
+			minErr := math.Inf(1)
+			for k := b.lo + 1; k < b.hi; k++ {
+				if e := sse(b.lo, k) + sse(k, b.hi); e < minErr {
+					mid, minErr = k, e
+				}
+			}
+		} else {
+			// The first value past half the population, kept away from the
+			// ends so both halves are non-empty.
+			half := (w[b.lo] + w[b.hi]) / 2
+			for mid < b.hi-1 && w[mid+1] <= half {
+				mid++
+			}
+		}
+		boxes[best] = box{b.lo, mid}
+		boxes = append(boxes, box{mid, b.hi})
+	}
+	sort.Slice(boxes, func(i, j int) bool { return boxes[i].lo < boxes[j].lo })
+
+	levels := make([]float64, len(boxes))
+	for i, b := range boxes {
+		levels[i] = (s1[b.hi] - s1[b.lo]) / (w[b.hi] - w[b.lo])
+	}
+	return levels
+}
+
+// kMeans1D refines ascending levels with Lloyd's algorithm over the
+// weighted values, stopping after iters passes or once the levels settle.
+func kMeans1D(vals []int16, counts []int, levels []float64, iters int) []float64 {
+	sums := make([]float64, len(levels))
+	pops := make([]float64, len(levels))
+	for ; iters > 0; iters-- {
+		clear(sums)
+		clear(pops)
+		// Levels stay sorted, so each value's nearest level is found by
+		// advancing past midpoints.
+		j := 0
+		for i, v := range vals {
+			fv := float64(v)
+			for j+1 < len(levels) && fv > (levels[j]+levels[j+1])/2 {
+				j++
+			}
+			sums[j] += fv * float64(counts[i])
+			pops[j] += float64(counts[i])
+		}
+		changed := false
+		for j := range levels {
+			if pops[j] == 0 {
+				continue
+			}
+			if l := sums[j] / pops[j]; math.Abs(l-levels[j]) > 1e-9 {
+				levels[j] = l
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return levels
+}
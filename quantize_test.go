@@ -0,0 +1,138 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// fillS16 returns a one-row GrayS16Image holding count copies of each value.
+func fillS16(values []int16, counts []int) *GrayS16Image {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	img := NewGrayS16Image(image.Rect(0, 0, n, 1))
+	x := 0
+	for i, v := range values {
+		for k := 0; k < counts[i]; k++ {
+			img.SetGrayS16(x, 0, GrayS16{Y: v})
+			x++
+		}
+	}
+	return img
+}
+
+func levelsOf(p color.Palette) []int16 {
+	var out []int16
+	for _, c := range p {
+		out = append(out, c.(GrayS16).Y)
+	}
+	return out
+}
+
+func TestGrayS16Quantizer_Clusters(t *testing.T) {
+	// Three tight clusters.
+	img := fillS16(
+		[]int16{-1001, -1000, -999, 0, 1, 4999, 5000, 5001},
+		[]int{10, 80, 10, 25, 25, 5, 10, 5},
+	)
+	want := []int16{-1000, 1, 5000}
+
+	got := levelsOf(GrayS16Quantizer{Method: KMeans}.Quantize(make(color.Palette, 0, 3), img))
+	if len(got) != 3 {
+		t.Fatalf("got %d levels, want 3", len(got))
+	}
+	for i := range want {
+		// The middle cluster averages to 0.5, which may round either way.
+		if d := int(got[i]) - int(want[i]); d < -1 || d > 1 {
+			t.Errorf("levels = %v, want about %v", got, want)
+			break
+		}
+	}
+}
+
+func TestGrayS16Quantizer_MedianCut(t *testing.T) {
+	// Equal populations at eight values are split into pairs.
+	img := fillS16([]int16{0, 1, 2, 3, 10, 11, 12, 13}, []int{5, 5, 5, 5, 5, 5, 5, 5})
+	got := levelsOf(GrayS16Quantizer{Method: MedianCut}.Quantize(make(color.Palette, 0, 4), img))
+	want := []int16{1, 3, 11, 13}
+	if len(got) != len(want) {
+		t.Fatalf("levels = %v, want %v", got, want)
+	}
+	for i := range want {
+		// Pair means fall on half values, which round away from zero.
+		if d := int(got[i]) - int(want[i]); d < -1 || d > 0 {
+			t.Errorf("levels = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestGrayS16Quantizer_KMeansImproves(t *testing.T) {
+	// A uniform ramp: k-means should never have a larger error than the
+	// median cut levels it starts from.
+	var values []int16
+	var counts []int
+	for v := -300; v < 300; v++ {
+		values = append(values, int16(v))
+		counts = append(counts, 1+(v+300)/50)
+	}
+	img := fillS16(values, counts)
+
+	sse := func(p color.Palette) float64 {
+		s := 0.0
+		for i, v := range values {
+			best := 1e18
+			for _, l := range levelsOf(p) {
+				d := float64(v) - float64(l)
+				best = min(best, d*d)
+			}
+			s += best * float64(counts[i])
+		}
+		return s
+	}
+	mc := GrayS16Quantizer{Method: MedianCut}.Quantize(make(color.Palette, 0, 8), img)
+	km := GrayS16Quantizer{Method: KMeans}.Quantize(make(color.Palette, 0, 8), img)
+	if sse(km) > sse(mc) {
+		t.Errorf("k-means error %v exceeds median cut error %v", sse(km), sse(mc))
+	}
+}
+
+func TestGrayS16Quantizer_FewValues(t *testing.T) {
+	img := fillS16([]int16{7, -3}, []int{4, 1})
+	p := color.Palette{color.Black}
+	p = GrayS16Quantizer{}.Quantize(append(make(color.Palette, 0, 10), p...), img)
+	if len(p) != 3 || p[0] != color.Black {
+		t.Fatalf("palette = %v, want black followed by two levels", p)
+	}
+	if got := levelsOf(p[1:]); got[0] != -3 || got[1] != 7 {
+		t.Errorf("levels = %v, want [-3 7]", got)
+	}
+	if full := (GrayS16Quantizer{}).Quantize(p[:len(p):len(p)], img); len(full) != len(p) {
+		t.Error("Quantize appended to a full palette")
+	}
+}
+
+func TestGrayS16Quantizer_IgnoresInvalid(t *testing.T) {
+	m := NewMaskedGrayS16Image(fillS16([]int16{-9999, 10, 20}, []int{50, 1, 1}), -9999)
+	got := levelsOf(GrayS16Quantizer{}.Quantize(make(color.Palette, 0, 4), m))
+	if len(got) != 2 || got[0] != 10 || got[1] != 20 {
+		t.Errorf("levels = %v, want [10 20]", got)
+	}
+}
+
+func TestGrayS16Quantizer_Drawer(t *testing.T) {
+	src := fillS16([]int16{-500, 500}, []int{3, 3})
+	var q draw.Quantizer = GrayS16Quantizer{}
+	pal := q.Quantize(make(color.Palette, 0, 2), src)
+	dst := NewPaletted16Image(src.Bounds(), pal)
+	draw.Draw(dst, dst.Rect, src, image.Point{}, draw.Src)
+	for x := 0; x < 6; x++ {
+		want := uint16(x / 3)
+		if got := dst.ColorIndexAt(x, 0); got != want {
+			t.Errorf("index at %d = %d, want %d", x, got, want)
+		}
+	}
+}
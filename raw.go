@@ -0,0 +1,88 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// The functions in this file form a minimal raw processing pipeline:
+//
+//	signed := BlackLevelSubtract(raw, black)
+//	WhiteBalance(signed, raw.Pattern, gains)
+//	rgb := Demosaic(RawToBayer(signed, raw.Pattern, white-black), DemosaicMalvar)
+//
+// Channel arrays are indexed by the values returned by
+// BayerImage.ChannelAt: 0 for red, 1 for green and 2 for blue.
+
+// BlackLevelSubtract returns the samples of src minus the black level of
+// their color channel. Sensor noise around the black level yields small
+// negative values, which the signed result keeps so that averaging stays
+// unbiased. Results saturate to the int16 range.
+func BlackLevelSubtract(src *BayerImage, black [3]uint16) *GrayS16Image {
+	r := src.Rect
+	dst := NewGrayS16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := int(src.SampleAt(x, y)) - int(black[src.ChannelAt(x, y)])
+			dst.SetGrayS16(x, y, GrayS16{Y: int16(max(math.MinInt16, min(math.MaxInt16, v)))})
+		}
+	}
+	return dst
+}
+
+// WhiteBalance multiplies each sample of the mosaic img in place by the gain
+// of its color channel under the CFA layout p, rounding to the nearest value
+// and saturating to the int16 range. It returns the number of samples that
+// saturated.
+func WhiteBalance(img *GrayS16Image, p CFAPattern, gains [3]float64) int {
+	clipped := 0
+	r := img.Rect
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := math.Round(float64(img.GrayS16At(x, y).Y) * gains[p.channel(x, y)])
+			if v > math.MaxInt16 || v < math.MinInt16 {
+				clipped++
+				v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v))
+			}
+			img.SetGrayS16(x, y, GrayS16{Y: int16(v)})
+		}
+	}
+	return clipped
+}
+
+// ClipMask returns a mask that is opaque where a sample of src is at or
+// above whiteLevel, the value at which the sensor saturates. Clipped
+// samples carry no color information, so highlight recovery and exposure
+// tools use the mask to find them.
+func ClipMask(src *BayerImage, whiteLevel uint16) *image.Alpha {
+	r := src.Rect
+	m := image.NewAlpha(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if src.SampleAt(x, y) >= whiteLevel {
+				m.Pix[m.PixOffset(x, y)] = 0xff
+			}
+		}
+	}
+	return m
+}
+
+// RawToBayer converts a black-level-subtracted mosaic back to a 16-bit
+// BayerImage for Demosaic, scaling [0, whiteLevel] to the full 16-bit
+// range. Negative samples become 0 and samples above whiteLevel 0xffff.
+// A non-positive whiteLevel means 32767.
+func RawToBayer(src *GrayS16Image, p CFAPattern, whiteLevel int) *BayerImage {
+	if whiteLevel <= 0 {
+		whiteLevel = math.MaxInt16
+	}
+	scale := float64(0xffff) / float64(whiteLevel)
+	r := src.Rect
+	dst := NewBayerImage(r, p, 16)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := float64(src.GrayS16At(x, y).Y) * scale
+			dst.SetSample(x, y, uint16(math.Round(math.Max(0, math.Min(0xffff, v)))))
+		}
+	}
+	return dst
+}
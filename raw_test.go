@@ -0,0 +1,69 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRawPipeline(t *testing.T) {
+	raw := NewBayerImage(image.Rect(0, 0, 2, 2), RGGB, 16)
+	raw.SetSample(0, 0, 1100) // R
+	raw.SetSample(1, 0, 600)  // G
+	raw.SetSample(0, 1, 480)  // G, below black
+	raw.SetSample(1, 1, 4095) // B, saturated
+
+	black := [3]uint16{500, 512, 500}
+	signed := BlackLevelSubtract(raw, black)
+	tests := []struct {
+		x, y int
+		want int16
+	}{
+		{0, 0, 600},
+		{1, 0, 88},
+		{0, 1, -32},
+		{1, 1, 3595},
+	}
+	for _, tt := range tests {
+		if got := signed.GrayS16At(tt.x, tt.y).Y; got != tt.want {
+			t.Errorf("black-subtracted (%d, %d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	if n := WhiteBalance(signed, RGGB, [3]float64{2, 1, 10}); n != 1 {
+		t.Errorf("WhiteBalance clipped %d samples, want 1", n)
+	}
+	for _, tt := range []struct {
+		x, y int
+		want int16
+	}{{0, 0, 1200}, {1, 0, 88}, {0, 1, -32}, {1, 1, 32767}} {
+		if got := signed.GrayS16At(tt.x, tt.y).Y; got != tt.want {
+			t.Errorf("balanced (%d, %d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	clip := ClipMask(raw, 4095)
+	if clip.AlphaAt(1, 1).A != 0xff || clip.AlphaAt(0, 0).A != 0 {
+		t.Errorf("ClipMask = %v, want only (1, 1) set", clip.Pix)
+	}
+
+	b := RawToBayer(signed, RGGB, 3595)
+	if b.Depth != 16 || b.Pattern != RGGB {
+		t.Fatalf("RawToBayer depth, pattern = %d, %v", b.Depth, b.Pattern)
+	}
+	for _, tt := range []struct {
+		x, y int
+		want uint16
+	}{{0, 0, 21875}, {0, 1, 0}, {1, 1, 0xffff}} {
+		if got := b.SampleAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("RawToBayer (%d, %d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestBlackLevelSubtractSaturates(t *testing.T) {
+	raw := NewBayerImage(image.Rect(0, 0, 1, 1), BGGR, 16)
+	raw.SetSample(0, 0, 0xffff)
+	if got := BlackLevelSubtract(raw, [3]uint16{}).GrayS16At(0, 0).Y; got != 32767 {
+		t.Errorf("saturated sample = %d, want 32767", got)
+	}
+}
@@ -0,0 +1,61 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Remap resamples src through per-pixel coordinate maps, the general form
+// of geometric warps such as lens undistortion and projection changes.
+// Output pixel (x, y) takes the value of src at (mapX(x, y), mapY(x, y)),
+// in the pixel-center coordinates of SampleF, interpolated with interp.
+// The output has the bounds of the maps, which must match.
+//
+// src must be a *GrayS16Image or *GrayF32Image, and the result has the
+// same type. Positions outside src's bounds, and NaN map entries, take
+// the value fill; interpolation near the edges clamps to the edge pixels.
+// GrayS16 results are rounded and saturated to the int16 range.
+func Remap(src image.Image, mapX, mapY *GrayF32Image, interp Interpolation, fill float64) (image.Image, error) {
+	r := mapX.Rect
+	if mapY.Rect != r {
+		return nil, fmt.Errorf("%w: remap map bounds %v and %v differ", ErrBoundsMismatch, r, mapY.Rect)
+	}
+	var sample func(x, y float64) float64
+	var sr image.Rectangle
+	var dst image.Image
+	var set func(x, y int, v float64)
+	switch s := src.(type) {
+	case *GrayS16Image:
+		sr = s.Rect
+		sample = func(x, y float64) float64 { return s.SampleF(x, y, interp, BorderClamp) }
+		m := NewGrayS16Image(r)
+		set = func(x, y int, v float64) {
+			m.SetGrayS16(x, y, GrayS16{Y: int16(math.Round(math.Max(math.MinInt16, math.Min(math.MaxInt16, v))))})
+		}
+		dst = m
+	case *GrayF32Image:
+		sr = s.Rect
+		sample = func(x, y float64) float64 { return s.SampleF(x, y, interp, BorderClamp) }
+		m := NewGrayF32Image(r)
+		set = func(x, y int, v float64) { m.SetGrayF32(x, y, GrayF32{Y: float32(v)}) }
+		dst = m
+	default:
+		return nil, fmt.Errorf("%w: cannot remap %T", ErrUnsupportedDType, src)
+	}
+
+	minX, minY := float64(sr.Min.X), float64(sr.Min.Y)
+	maxX, maxY := float64(sr.Max.X), float64(sr.Max.Y)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			mx := float64(mapX.GrayF32At(x, y).Y)
+			my := float64(mapY.GrayF32At(x, y).Y)
+			if !(mx >= minX && mx < maxX && my >= minY && my < maxY) {
+				set(x, y, fill)
+				continue
+			}
+			set(x, y, sample(mx, my))
+		}
+	}
+	return dst, nil
+}
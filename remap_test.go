@@ -0,0 +1,100 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// identityMaps returns coordinate maps over r that sample each pixel's own
+// center, shifted by (dx, dy).
+func identityMaps(r image.Rectangle, dx, dy float64) (mapX, mapY *GrayF32Image) {
+	mapX, mapY = NewGrayF32Image(r), NewGrayF32Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			mapX.SetGrayF32(x, y, GrayF32{Y: float32(float64(x) + 0.5 + dx)})
+			mapY.SetGrayF32(x, y, GrayF32{Y: float32(float64(y) + 0.5 + dy)})
+		}
+	}
+	return mapX, mapY
+}
+
+func TestRemap(t *testing.T) {
+	src := randomGrayS16(image.Rect(3, -2, 15, 8), 5)
+	tests := []struct {
+		name   string
+		dx, dy float64
+		interp Interpolation
+	}{
+		{"identity nearest", 0, 0, Nearest},
+		{"identity bicubic", 0, 0, Bicubic},
+		{"shift", 2, -1, Bilinear},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapX, mapY := identityMaps(src.Rect, tt.dx, tt.dy)
+			out, err := Remap(src, mapX, mapY, tt.interp, -7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := out.(*GrayS16Image)
+			if dst.Rect != src.Rect {
+				t.Fatalf("bounds %v, want %v", dst.Rect, src.Rect)
+			}
+			for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+				for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+					sx, sy := x+int(tt.dx), y+int(tt.dy)
+					want := int16(-7)
+					if (image.Point{X: sx, Y: sy}).In(src.Rect) {
+						want = src.GrayS16At(sx, sy).Y
+					}
+					if got := dst.GrayS16At(x, y).Y; got != want {
+						t.Fatalf("(%d, %d) = %d, want %d", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRemapF32(t *testing.T) {
+	src := NewGrayF32Image(image.Rect(0, 0, 4, 1))
+	for x := 0; x < 4; x++ {
+		src.SetGrayF32(x, 0, GrayF32{Y: float32(10 * x)})
+	}
+	r := image.Rect(0, 0, 4, 1)
+	mapX, mapY := NewGrayF32Image(r), NewGrayF32Image(r)
+	for x, v := range []float32{1.75, 0, float32(math.NaN()), 4.5} {
+		mapX.SetGrayF32(x, 0, GrayF32{Y: v})
+		mapY.SetGrayF32(x, 0, GrayF32{Y: 0.5})
+	}
+	out, err := Remap(src, mapX, mapY, Bilinear, math.NaN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := out.(*GrayF32Image)
+	want := []float64{12.5, 0, math.NaN(), math.NaN()}
+	for x, w := range want {
+		got := float64(dst.GrayF32At(x, 0).Y)
+		if math.IsNaN(w) != math.IsNaN(got) || !math.IsNaN(w) && got != w {
+			t.Errorf("x=%d: got %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestRemapErrors(t *testing.T) {
+	mapX := NewGrayF32Image(image.Rect(0, 0, 2, 2))
+	tests := []struct {
+		name string
+		src  image.Image
+		mapY *GrayF32Image
+	}{
+		{"bounds", NewGrayS16Image(mapX.Rect), NewGrayF32Image(image.Rect(0, 0, 2, 3))},
+		{"type", image.NewGray(mapX.Rect), NewGrayF32Image(mapX.Rect)},
+	}
+	for _, tt := range tests {
+		if _, err := Remap(tt.src, mapX, tt.mapY, Bilinear, 0); err == nil {
+			t.Errorf("%s: got nil error", tt.name)
+		}
+	}
+}
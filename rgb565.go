@@ -0,0 +1,293 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// expand5 and expand6 widen 5- and 6-bit channel values to 16 bits.
+func expand5(v uint16) uint32 { return (uint32(v)*0xffff + 15) / 31 }
+func expand6(v uint16) uint32 { return (uint32(v)*0xffff + 31) / 63 }
+
+// quantize5 and quantize6 round 16-bit channel values to 5 and 6 bits.
+func quantize5(v uint32) uint16 { return uint16((v*31 + 0x7fff) / 0xffff) }
+func quantize6(v uint32) uint16 { return uint16((v*63 + 0x7fff) / 0xffff) }
+
+// RGB565 is a fully opaque color packed into 16 bits: five bits of red in
+// the most significant bits, six bits of green and five bits of blue. It is
+// the native pixel format of many embedded LCD controllers.
+type RGB565 uint16
+
+// RGBA returns the red, green, blue and alpha components of the RGB565
+// color. This implements the color.Color interface.
+func (c RGB565) RGBA() (r, g, b, a uint32) {
+	return expand5(uint16(c) >> 11), expand6(uint16(c) >> 5 & 0x3f), expand5(uint16(c) & 0x1f), 0xffff
+}
+
+// RGB555 is a fully opaque color packed into 16 bits: five bits each of
+// red, green and blue, from the most significant end of the low 15 bits.
+// The top bit is ignored.
+type RGB555 uint16
+
+// RGBA returns the red, green, blue and alpha components of the RGB555
+// color. This implements the color.Color interface.
+func (c RGB555) RGBA() (r, g, b, a uint32) {
+	return expand5(uint16(c) >> 10 & 0x1f), expand5(uint16(c) >> 5 & 0x1f), expand5(uint16(c) & 0x1f), 0xffff
+}
+
+// Models for the packed 16-bit RGB colors. Conversion rounds each channel
+// to the nearest representable value. As with color.YCbCrModel, alpha is
+// discarded, so translucent colors appear composited over black.
+var (
+	RGB565Model color.Model = color.ModelFunc(rgb565Model)
+	RGB555Model color.Model = color.ModelFunc(rgb555Model)
+)
+
+// rgb565Model converts any color.Color to an RGB565.
+func rgb565Model(c color.Color) color.Color {
+	if _, ok := c.(RGB565); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+	return RGB565(quantize5(r)<<11 | quantize6(g)<<5 | quantize5(b))
+}
+
+// rgb555Model converts any color.Color to an RGB555.
+func rgb555Model(c color.Color) color.Color {
+	if _, ok := c.(RGB555); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+	return RGB555(quantize5(r)<<10 | quantize5(g)<<5 | quantize5(b))
+}
+
+// RGB565Image is an in-memory image whose At method returns RGB565 values.
+type RGB565Image struct {
+	// Pix holds the image's pixels, as packed 16-bit values in big-endian
+	// format, the byte order expected by SPI display controllers.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*2].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the RGB565Image's color model.
+func (p *RGB565Image) ColorModel() color.Model {
+	return RGB565Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *RGB565Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *RGB565Image) At(x, y int) color.Color {
+	return p.RGB565At(x, y)
+}
+
+// RGB565At returns the RGB565 color of the pixel at (x, y).
+func (p *RGB565Image) RGB565At(x, y int) RGB565 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i := p.PixOffset(x, y)
+	return RGB565(uint16(p.Pix[i])<<8 | uint16(p.Pix[i+1]))
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *RGB565Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *RGB565Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetRGB565(x, y, RGB565Model.Convert(c).(RGB565))
+}
+
+// SetRGB565 sets the pixel at (x, y) to a given RGB565 color.
+func (p *RGB565Image) SetRGB565(x, y int, c RGB565) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i] = uint8(c >> 8)
+	p.Pix[i+1] = uint8(c)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *RGB565Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &RGB565Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &RGB565Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// RGB565Image is always fully opaque since the RGB565 color model has no transparency.
+func (p *RGB565Image) Opaque() bool {
+	return true
+}
+
+// NewRGB565Image returns a new RGB565Image with the given bounds.
+func NewRGB565Image(r image.Rectangle, opts ...ImageOption) *RGB565Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &RGB565Image{
+		Pix:    o.pix(2*w*h, 2),
+		Stride: 2 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 2)
+	return p
+}
+
+func (p *RGB565Image) metadata() Metadata {
+	return p.Meta
+}
+
+// DitherRGB565 converts src to a RGB565Image using Floyd-Steinberg error
+// diffusion, which hides the banding that plain rounding produces in smooth
+// gradients.
+func DitherRGB565(src image.Image) *RGB565Image {
+	dst := NewRGB565Image(src.Bounds())
+	draw.FloydSteinberg.Draw(dst, dst.Rect, src, dst.Rect.Min)
+	return dst
+}
+
+// RGB555Image is an in-memory image whose At method returns RGB555 values.
+type RGB555Image struct {
+	// Pix holds the image's pixels, as packed 16-bit values in big-endian
+	// format, the byte order expected by SPI display controllers.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*2].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the RGB555Image's color model.
+func (p *RGB555Image) ColorModel() color.Model {
+	return RGB555Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *RGB555Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *RGB555Image) At(x, y int) color.Color {
+	return p.RGB555At(x, y)
+}
+
+// RGB555At returns the RGB555 color of the pixel at (x, y).
+func (p *RGB555Image) RGB555At(x, y int) RGB555 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0
+	}
+	i := p.PixOffset(x, y)
+	return RGB555(uint16(p.Pix[i])<<8 | uint16(p.Pix[i+1]))
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *RGB555Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *RGB555Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetRGB555(x, y, RGB555Model.Convert(c).(RGB555))
+}
+
+// SetRGB555 sets the pixel at (x, y) to a given RGB555 color.
+func (p *RGB555Image) SetRGB555(x, y int, c RGB555) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i] = uint8(c >> 8)
+	p.Pix[i+1] = uint8(c)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *RGB555Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &RGB555Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &RGB555Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// RGB555Image is always fully opaque since the RGB555 color model has no transparency.
+func (p *RGB555Image) Opaque() bool {
+	return true
+}
+
+// NewRGB555Image returns a new RGB555Image with the given bounds.
+func NewRGB555Image(r image.Rectangle, opts ...ImageOption) *RGB555Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &RGB555Image{
+		Pix:    o.pix(2*w*h, 2),
+		Stride: 2 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 2)
+	return p
+}
+
+func (p *RGB555Image) metadata() Metadata {
+	return p.Meta
+}
+
+// DitherRGB555 converts src to a RGB555Image using Floyd-Steinberg error
+// diffusion, which hides the banding that plain rounding produces in smooth
+// gradients.
+func DitherRGB555(src image.Image) *RGB555Image {
+	dst := NewRGB555Image(src.Bounds())
+	draw.FloydSteinberg.Draw(dst, dst.Rect, src, dst.Rect.Min)
+	return dst
+}
@@ -0,0 +1,125 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRGB565_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want color.RGBA64
+	}{
+		{"RGB565 white", RGB565(0xffff), color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}},
+		{"RGB565 red", RGB565(0xf800), color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{"RGB565 green", RGB565(0x07e0), color.RGBA64{0, 0xffff, 0, 0xffff}},
+		{"RGB565 mid blue", RGB565(0x0010), color.RGBA64{0, 0, 0x8421, 0xffff}},
+		{"RGB555 white", RGB555(0x7fff), color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}},
+		{"RGB555 ignores top bit", RGB555(0x8000 | 0x03e0), color.RGBA64{0, 0xffff, 0, 0xffff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			if got := (color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}); got != tt.want {
+				t.Errorf("RGBA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRGB565Models(t *testing.T) {
+	tests := []struct {
+		name  string
+		model color.Model
+		in    color.Color
+		want  color.Color
+	}{
+		{"565 white", RGB565Model, color.White, RGB565(0xffff)},
+		{"565 orange", RGB565Model, color.RGBA{0xff, 0x80, 0, 0xff}, RGB565(0xfc00)},
+		{"555 orange", RGB555Model, color.RGBA{0xff, 0x80, 0, 0xff}, RGB555(0x7e00)},
+		{"555 black", RGB555Model, color.Black, RGB555(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Convert(tt.in); got != tt.want {
+				t.Errorf("Convert(%v) = %#04x, want %#04x", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// Every packed value survives a round trip through RGBA.
+	for v := 0; v <= 0xffff; v++ {
+		if got := RGB565Model.Convert(color.RGBA64Model.Convert(RGB565(v))); got != RGB565(v) {
+			t.Fatalf("RGB565 round trip of %#04x = %#04x", v, got)
+		}
+	}
+}
+
+func TestRGB565Image(t *testing.T) {
+	img := NewRGB565Image(image.Rect(0, 0, 2, 2))
+	img.SetRGB565(1, 0, 0xf81f)
+	if img.Pix[2] != 0xf8 || img.Pix[3] != 0x1f {
+		t.Errorf("Pix = %x, want big-endian f81f at offset 2", img.Pix)
+	}
+	if got := img.At(1, 0); got != RGB565(0xf81f) {
+		t.Errorf("At(1, 0) = %v", got)
+	}
+	sub := img.SubImage(image.Rect(1, 0, 2, 1)).(*RGB565Image)
+	if got := sub.RGB565At(1, 0); got != 0xf81f {
+		t.Errorf("SubImage.RGB565At(1, 0) = %#04x", got)
+	}
+
+	img555 := NewRGB555Image(image.Rect(0, 0, 1, 1))
+	img555.Set(0, 0, color.RGBA{0, 0, 0xff, 0xff})
+	if got := img555.RGB555At(0, 0); got != 0x001f {
+		t.Errorf("RGB555At(0, 0) = %#04x, want 0x001f", got)
+	}
+}
+
+func TestDitherRGB565(t *testing.T) {
+	// A dark gray about halfway between the two lowest 5-bit levels.
+	bounds := image.Rect(0, 0, 32, 8)
+	gray := image.NewRGBA(bounds)
+	plain := NewRGB565Image(bounds)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 32; x++ {
+			gray.SetRGBA(x, y, color.RGBA{4, 4, 4, 0xff})
+			plain.Set(x, y, gray.At(x, y))
+		}
+	}
+
+	d := DitherRGB565(gray)
+	if d.Bounds() != bounds {
+		t.Fatalf("Bounds() = %v, want %v", d.Bounds(), bounds)
+	}
+	var sum, plainSum uint32
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 32; x++ {
+			r, _, _, _ := d.At(x, y).RGBA()
+			pr, _, _, _ := plain.At(x, y).RGBA()
+			sum += r
+			plainSum += pr
+		}
+	}
+	// Rounding loses the gray entirely; dithering preserves its average.
+	want := uint32(0x0404 * 32 * 8)
+	if plainSum != 0 {
+		t.Errorf("plain conversion sum = %d, want 0", plainSum)
+	}
+	if sum < want*9/10 || sum > want*11/10 {
+		t.Errorf("dithered red sum = %d, want about %d", sum, want)
+	}
+}
+
+func TestDitherRGB555(t *testing.T) {
+	src := image.NewRGBA(image.Rect(2, 3, 5, 4))
+	src.SetRGBA(4, 3, color.RGBA{0xff, 0, 0, 0xff})
+	d := DitherRGB555(src)
+	if got := d.RGB555At(4, 3); got != 0x7c00 {
+		t.Errorf("RGB555At(4, 3) = %#04x, want 0x7c00", got)
+	}
+}
@@ -0,0 +1,323 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// RGBAF32 represents a color with float32 components premultiplied by
+// alpha. Like color.RGBA64, the color components carry the same encoding
+// as the stdlib colors, normally sRGB, with nominal range [0, 1]. Values
+// above 1 are allowed for high dynamic range content.
+type RGBAF32 struct {
+	R, G, B, A float32
+}
+
+// RGBA returns the red, green, blue and alpha components of the RGBAF32
+// color. This implements the color.Color interface.
+// Components are clamped to [0, 1], and color components to alpha.
+func (c RGBAF32) RGBA() (r, g, b, a uint32) {
+	alpha := clamp01(float64(c.A))
+	ch := func(v float32) uint32 {
+		return unit16(min(float64(v), alpha))
+	}
+	return ch(c.R), ch(c.G), ch(c.B), unit16(alpha)
+}
+
+// Unpremultiply returns c with its color components divided by alpha.
+// A fully transparent color yields the zero NRGBAF32.
+func (c RGBAF32) Unpremultiply() NRGBAF32 {
+	if c.A == 0 {
+		return NRGBAF32{}
+	}
+	return NRGBAF32{c.R / c.A, c.G / c.A, c.B / c.A, c.A}
+}
+
+// NRGBAF32 represents a color with float32 components that are not
+// premultiplied by alpha. It is the non-premultiplied counterpart of
+// RGBAF32, as color.NRGBA64 is to color.RGBA64.
+type NRGBAF32 struct {
+	R, G, B, A float32
+}
+
+// RGBA returns the red, green, blue and alpha components of the NRGBAF32
+// color. This implements the color.Color interface.
+// Components are clamped to [0, 1] before premultiplication.
+func (c NRGBAF32) RGBA() (r, g, b, a uint32) {
+	alpha := clamp01(float64(c.A))
+	ch := func(v float32) uint32 {
+		return uint32(clamp01(float64(v))*alpha*0xffff + 0.5)
+	}
+	return ch(c.R), ch(c.G), ch(c.B), unit16(alpha)
+}
+
+// Premultiply returns c with its color components multiplied by alpha.
+func (c NRGBAF32) Premultiply() RGBAF32 {
+	return RGBAF32{c.R * c.A, c.G * c.A, c.B * c.A, c.A}
+}
+
+// Models for the float32 RGBA color types. Conversion between RGBAF32 and
+// NRGBAF32 premultiplies or unpremultiplies without clamping; other colors
+// are converted from their 16-bit RGBA components.
+var (
+	RGBAF32Model  color.Model = color.ModelFunc(rgbaF32Model)
+	NRGBAF32Model color.Model = color.ModelFunc(nrgbaF32Model)
+)
+
+// rgbaF32Model converts any color.Color to an RGBAF32.
+func rgbaF32Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case RGBAF32:
+		return c
+	case NRGBAF32:
+		return c.Premultiply()
+	}
+	r, g, b, a := c.RGBA()
+	return RGBAF32{float32(r) / 0xffff, float32(g) / 0xffff, float32(b) / 0xffff, float32(a) / 0xffff}
+}
+
+// nrgbaF32Model converts any color.Color to an NRGBAF32.
+func nrgbaF32Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case NRGBAF32:
+		return c
+	case RGBAF32:
+		return c.Unpremultiply()
+	}
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	return NRGBAF32{float32(n.R) / 0xffff, float32(n.G) / 0xffff, float32(n.B) / 0xffff, float32(n.A) / 0xffff}
+}
+
+// RGBAF32Image is an in-memory image whose At method returns
+// RGBAF32 values.
+type RGBAF32Image struct {
+	// Pix holds the image's pixels, in R, G, B, A order, as IEEE 754
+	// float32 values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*16].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the RGBAF32Image's color model.
+func (p *RGBAF32Image) ColorModel() color.Model {
+	return RGBAF32Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *RGBAF32Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *RGBAF32Image) At(x, y int) color.Color {
+	return p.RGBAF32At(x, y)
+}
+
+// RGBAF32At returns the RGBAF32 color of the pixel at (x, y).
+func (p *RGBAF32Image) RGBAF32At(x, y int) RGBAF32 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return RGBAF32{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	return RGBAF32{getF32(s[0:4]), getF32(s[4:8]), getF32(s[8:12]), getF32(s[12:16])}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *RGBAF32Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*16
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *RGBAF32Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetRGBAF32(x, y, RGBAF32Model.Convert(c).(RGBAF32))
+}
+
+// SetRGBAF32 sets the pixel at (x, y) to a given RGBAF32 color.
+func (p *RGBAF32Image) SetRGBAF32(x, y int, c RGBAF32) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	putF32(s[0:4], c.R)
+	putF32(s[4:8], c.G)
+	putF32(s[8:12], c.B)
+	putF32(s[12:16], c.A)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *RGBAF32Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &RGBAF32Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &RGBAF32Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *RGBAF32Image) Opaque() bool {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.RGBAF32At(x, y).A < 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NewRGBAF32Image returns a new RGBAF32Image with the given bounds.
+func NewRGBAF32Image(r image.Rectangle, opts ...ImageOption) *RGBAF32Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &RGBAF32Image{
+		Pix:    o.pix(16*w*h, 4),
+		Stride: 16 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 16)
+	return p
+}
+
+func (p *RGBAF32Image) metadata() Metadata {
+	return p.Meta
+}
+
+// NRGBAF32Image is an in-memory image whose At method returns
+// NRGBAF32 values.
+type NRGBAF32Image struct {
+	// Pix holds the image's pixels, in R, G, B, A order, as IEEE 754
+	// float32 values in big-endian format.
+	// The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*16].
+	Pix []uint8
+	// Stride is the Pix stride (in bytes) between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the NRGBAF32Image's color model.
+func (p *NRGBAF32Image) ColorModel() color.Model {
+	return NRGBAF32Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *NRGBAF32Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *NRGBAF32Image) At(x, y int) color.Color {
+	return p.NRGBAF32At(x, y)
+}
+
+// NRGBAF32At returns the NRGBAF32 color of the pixel at (x, y).
+func (p *NRGBAF32Image) NRGBAF32At(x, y int) NRGBAF32 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return NRGBAF32{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	return NRGBAF32{getF32(s[0:4]), getF32(s[4:8]), getF32(s[8:12]), getF32(s[12:16])}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to
+// the pixel at (x, y).
+func (p *NRGBAF32Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*16
+}
+
+// Set sets the pixel at (x, y) to a given color.
+func (p *NRGBAF32Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	p.SetNRGBAF32(x, y, NRGBAF32Model.Convert(c).(NRGBAF32))
+}
+
+// SetNRGBAF32 sets the pixel at (x, y) to a given NRGBAF32 color.
+func (p *NRGBAF32Image) SetNRGBAF32(x, y int, c NRGBAF32) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+16 : i+16]
+	putF32(s[0:4], c.R)
+	putF32(s[4:8], c.G)
+	putF32(s[8:12], c.B)
+	putF32(s[12:16], c.A)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *NRGBAF32Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &NRGBAF32Image{Meta: p.Meta}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &NRGBAF32Image{
+		Pix:    p.Pix[i:],
+		Stride: p.Stride,
+		Rect:   r,
+		Meta:   p.Meta,
+	}
+}
+
+// Opaque scans the entire image and reports whether it is fully opaque.
+func (p *NRGBAF32Image) Opaque() bool {
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			if p.NRGBAF32At(x, y).A < 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NewNRGBAF32Image returns a new NRGBAF32Image with the given bounds.
+func NewNRGBAF32Image(r image.Rectangle, opts ...ImageOption) *NRGBAF32Image {
+	o := newImageOptions(opts)
+	w, h := r.Dx(), r.Dy()
+	p := &NRGBAF32Image{
+		Pix:    o.pix(16*w*h, 4),
+		Stride: 16 * w,
+		Rect:   r,
+		Meta:   o.meta,
+	}
+	o.fillPix(p, p.Pix, p.Stride, 16)
+	return p
+}
+
+func (p *NRGBAF32Image) metadata() Metadata {
+	return p.Meta
+}
@@ -0,0 +1,96 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRGBAF32_RGBA(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want color.RGBA64
+	}{
+		{"premultiplied half", RGBAF32{0.25, 0.5, 0, 0.5}, color.RGBA64{0x4000, 0x8000, 0, 0x8000}},
+		{"premultiplied clamps to alpha", RGBAF32{2, 0.5, -1, 0.5}, color.RGBA64{0x8000, 0x8000, 0, 0x8000}},
+		{"straight half", NRGBAF32{0.5, 1, 0, 0.5}, color.RGBA64{0x4000, 0x8000, 0, 0x8000}},
+		{"straight opaque", NRGBAF32{1, 0, 0.5, 1}, color.RGBA64{0xffff, 0, 0x8000, 0xffff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := tt.c.RGBA()
+			got := color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+			if got != tt.want {
+				t.Errorf("RGBA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRGBAF32_Premultiply(t *testing.T) {
+	n := NRGBAF32{0.5, 2, 0, 0.25}
+	p := n.Premultiply()
+	if p != (RGBAF32{0.125, 0.5, 0, 0.25}) {
+		t.Errorf("Premultiply() = %v", p)
+	}
+	if got := p.Unpremultiply(); got != n {
+		t.Errorf("Unpremultiply() = %v, want %v", got, n)
+	}
+	if got := (RGBAF32{0.1, 0, 0, 0}).Unpremultiply(); got != (NRGBAF32{}) {
+		t.Errorf("transparent Unpremultiply() = %v, want zero", got)
+	}
+}
+
+func TestRGBAF32Models(t *testing.T) {
+	if got := RGBAF32Model.Convert(NRGBAF32{1, 0.5, 0, 0.5}); got != (RGBAF32{0.5, 0.25, 0, 0.5}) {
+		t.Errorf("RGBAF32Model.Convert(NRGBAF32) = %v", got)
+	}
+	if got := NRGBAF32Model.Convert(RGBAF32{0.5, 0.25, 0, 0.5}); got != (NRGBAF32{1, 0.5, 0, 0.5}) {
+		t.Errorf("NRGBAF32Model.Convert(RGBAF32) = %v", got)
+	}
+	if got := NRGBAF32Model.Convert(color.NRGBA{0xff, 0, 0, 0x80}); got != (NRGBAF32{1, 0, 0, float32(0x8080) / 0xffff}) {
+		t.Errorf("NRGBAF32Model.Convert(NRGBA) = %v", got)
+	}
+	if got := RGBAF32Model.Convert(color.White); got != (RGBAF32{1, 1, 1, 1}) {
+		t.Errorf("RGBAF32Model.Convert(White) = %v", got)
+	}
+}
+
+func TestRGBAF32Image(t *testing.T) {
+	img := NewRGBAF32Image(image.Rect(0, 0, 3, 2))
+	if img.Stride != 48 || len(img.Pix) != 96 {
+		t.Fatalf("Stride = %d, len(Pix) = %d, want 48, 96", img.Stride, len(img.Pix))
+	}
+	want := RGBAF32{0.25, 0.5, 4, 1}
+	img.SetRGBAF32(2, 1, want)
+	if got := img.RGBAF32At(2, 1); got != want {
+		t.Errorf("RGBAF32At(2, 1) = %v, want %v", got, want)
+	}
+	img.Set(0, 0, NRGBAF32{1, 1, 1, 0.5})
+	if got := img.At(0, 0); got != (RGBAF32{0.5, 0.5, 0.5, 0.5}) {
+		t.Errorf("At(0, 0) = %v, want premultiplied half white", got)
+	}
+	sub := img.SubImage(image.Rect(2, 1, 3, 2)).(*RGBAF32Image)
+	if got := sub.RGBAF32At(2, 1); got != want || !sub.Opaque() {
+		t.Errorf("SubImage.RGBAF32At(2, 1) = %v, want opaque %v", got, want)
+	}
+	if img.Opaque() {
+		t.Error("partially transparent image reported Opaque")
+	}
+}
+
+func TestNRGBAF32Image(t *testing.T) {
+	img := NewNRGBAF32Image(image.Rect(-1, -1, 1, 1))
+	img.Set(-1, -1, RGBAF32{0.25, 0, 0, 0.5})
+	if got := img.NRGBAF32At(-1, -1); got != (NRGBAF32{0.5, 0, 0, 0.5}) {
+		t.Errorf("NRGBAF32At(-1, -1) = %v, want unpremultiplied", got)
+	}
+	if got := img.NRGBAF32At(1, 1); got != (NRGBAF32{}) {
+		t.Errorf("out of bounds NRGBAF32At = %v, want zero", got)
+	}
+	if empty := img.SubImage(image.Rect(5, 5, 6, 6)); !empty.Bounds().Empty() {
+		t.Errorf("disjoint SubImage bounds = %v, want empty", empty.Bounds())
+	}
+}
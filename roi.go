@@ -0,0 +1,141 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// ROI is a region of interest. A pixel belongs to the region if its center
+// does, using the pixel-center coordinates of PointF.
+type ROI interface {
+	// Bounds returns a rectangle containing every pixel of the region.
+	Bounds() image.Rectangle
+	// Contains reports whether the pixel at (x, y) belongs to the region.
+	Contains(x, y int) bool
+}
+
+// RectROI is a rectangular region of interest.
+type RectROI image.Rectangle
+
+// Bounds returns the rectangle.
+func (r RectROI) Bounds() image.Rectangle {
+	return image.Rectangle(r)
+}
+
+// Contains reports whether (x, y) lies in the rectangle.
+func (r RectROI) Contains(x, y int) bool {
+	return image.Pt(x, y).In(image.Rectangle(r))
+}
+
+// EllipseROI is an axis-aligned elliptical region of interest with radii RX
+// and RY.
+type EllipseROI struct {
+	Center PointF
+	RX, RY float64
+}
+
+// Bounds returns the bounding box of the ellipse.
+func (e EllipseROI) Bounds() image.Rectangle {
+	return image.Rect(
+		int(math.Floor(e.Center.X-e.RX)), int(math.Floor(e.Center.Y-e.RY)),
+		int(math.Ceil(e.Center.X+e.RX)), int(math.Ceil(e.Center.Y+e.RY)),
+	)
+}
+
+// Contains reports whether the center of the pixel at (x, y) lies inside
+// or on the ellipse.
+func (e EllipseROI) Contains(x, y int) bool {
+	if e.RX <= 0 || e.RY <= 0 {
+		return false
+	}
+	dx := (float64(x) + 0.5 - e.Center.X) / e.RX
+	dy := (float64(y) + 0.5 - e.Center.Y) / e.RY
+	return dx*dx+dy*dy <= 1
+}
+
+// PolygonROI is a polygonal region of interest. The last vertex connects
+// back to the first; a repeated closing vertex, as in a closed Polyline, is
+// allowed. Self-intersecting polygons use the even-odd rule.
+type PolygonROI []PointF
+
+// Bounds returns the bounding box of the vertices.
+func (p PolygonROI) Bounds() image.Rectangle {
+	if len(p) == 0 {
+		return image.Rectangle{}
+	}
+	minX, minY, maxX, maxY := p[0].X, p[0].Y, p[0].X, p[0].Y
+	for _, v := range p[1:] {
+		minX, minY = min(minX, v.X), min(minY, v.Y)
+		maxX, maxY = max(maxX, v.X), max(maxY, v.Y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// Contains reports whether the center of the pixel at (x, y) lies inside
+// the polygon.
+func (p PolygonROI) Contains(x, y int) bool {
+	px, py := float64(x)+0.5, float64(y)+0.5
+	in := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Y > py) != (b.Y > py) && px < a.X+(py-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// MaskROI is a freehand region of interest: the pixels where the mask has a
+// non-zero alpha.
+type MaskROI struct {
+	*image.Alpha
+}
+
+// Contains reports whether the mask is set at (x, y).
+func (m MaskROI) Contains(x, y int) bool {
+	return m.AlphaAt(x, y).A != 0
+}
+
+// ROIStats summarizes the pixels in a region of interest.
+type ROIStats struct {
+	Min, Max float64
+	Mean     float64
+	// StdDev is the population standard deviation.
+	StdDev float64
+	// Area is the number of pixels included in the statistics.
+	Area int
+}
+
+// StatsInROI returns statistics of the raw sample values of img inside roi,
+// as read by scalarSampler. Pixels outside the image, NaN pixels and invalid
+// pixels of a Validator are excluded. If no pixels remain, Area is zero and
+// the other fields are NaN.
+func StatsInROI(img image.Image, roi ROI) ROIStats {
+	at := scalarSampler(img)
+	r := roi.Bounds().Intersect(img.Bounds())
+	s := ROIStats{Min: math.Inf(1), Max: math.Inf(-1)}
+	// Welford's algorithm avoids cancellation for data with a large offset.
+	var m2 float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if !roi.Contains(x, y) {
+				continue
+			}
+			v := at(x, y)
+			if math.IsNaN(v) {
+				continue
+			}
+			s.Area++
+			s.Min, s.Max = min(s.Min, v), max(s.Max, v)
+			d := v - s.Mean
+			s.Mean += d / float64(s.Area)
+			m2 += d * (v - s.Mean)
+		}
+	}
+	if s.Area == 0 {
+		nan := math.NaN()
+		return ROIStats{Min: nan, Max: nan, Mean: nan, StdDev: nan}
+	}
+	s.StdDev = math.Sqrt(m2 / float64(s.Area))
+	return s
+}
@@ -0,0 +1,84 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestROIContains(t *testing.T) {
+	tests := []struct {
+		name string
+		roi  ROI
+		in   []image.Point
+		out  []image.Point
+		area int
+	}{
+		{"rect", RectROI(image.Rect(1, 1, 3, 4)), []image.Point{{1, 1}, {2, 3}}, []image.Point{{3, 1}, {0, 0}}, 6},
+		// A circle of radius 2 centered on a pixel corner covers 12 pixel centers.
+		{"ellipse", EllipseROI{Center: PointF{5, 5}, RX: 2, RY: 2}, []image.Point{{4, 4}, {5, 3}}, []image.Point{{6, 6}, {3, 3}}, 12},
+		{"degenerate ellipse", EllipseROI{Center: PointF{5, 5}}, nil, []image.Point{{4, 4}}, 0},
+		// A right triangle with legs of 4 pixels.
+		{"polygon", PolygonROI{{0, 0}, {4, 0}, {0, 4}, {0, 0}}, []image.Point{{0, 0}, {1, 1}, {0, 2}}, []image.Point{{3, 3}, {2, 2}}, 6},
+	}
+	for _, tt := range tests {
+		for _, p := range tt.in {
+			if !tt.roi.Contains(p.X, p.Y) {
+				t.Errorf("%s: %v not contained", tt.name, p)
+			}
+		}
+		for _, p := range tt.out {
+			if tt.roi.Contains(p.X, p.Y) {
+				t.Errorf("%s: %v contained", tt.name, p)
+			}
+		}
+		img := NewGrayS16Image(image.Rect(-10, -10, 20, 20))
+		if got := StatsInROI(img, tt.roi).Area; got != tt.area {
+			t.Errorf("%s: area = %d, want %d", tt.name, got, tt.area)
+		}
+	}
+	if b := (PolygonROI{}).Bounds(); !b.Empty() {
+		t.Errorf("empty polygon bounds = %v", b)
+	}
+}
+
+func TestStatsInROI(t *testing.T) {
+	img := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 4, 4)), -9999)
+	vals := []int16{
+		2, 4, 4, 4,
+		5, 5, 7, 9,
+		-9999, 0, 0, 0,
+		0, 0, 0, 0,
+	}
+	for i, v := range vals {
+		img.SetGrayS16(i%4, i/4, GrayS16{Y: v})
+	}
+	// The mask covers the top two rows and the NoData pixel below them.
+	mask := image.NewAlpha(image.Rect(0, 0, 4, 4))
+	for i := 0; i < 9; i++ {
+		mask.Pix[i] = 0xff
+	}
+	s := StatsInROI(img, MaskROI{mask})
+	want := ROIStats{Min: 2, Max: 9, Mean: 5, StdDev: 2, Area: 8}
+	if math.Abs(s.Mean-want.Mean) > 1e-12 || math.Abs(s.StdDev-want.StdDev) > 1e-12 {
+		t.Errorf("StatsInROI = %+v, want %+v", s, want)
+	}
+	s.Mean, s.StdDev = want.Mean, want.StdDev
+	if s != want {
+		t.Errorf("StatsInROI = %+v, want %+v", s, want)
+	}
+
+	// A region outside the image yields no pixels.
+	s = StatsInROI(img, RectROI(image.Rect(10, 10, 12, 12)))
+	if s.Area != 0 || !math.IsNaN(s.Mean) || !math.IsNaN(s.Min) || !math.IsNaN(s.StdDev) {
+		t.Errorf("StatsInROI outside = %+v", s)
+	}
+
+	// Large offsets do not lose precision.
+	f := NewGrayF32Image(image.Rect(0, 0, 2, 1))
+	f.SetGrayF32(0, 0, GrayF32{Y: 1e7})
+	f.SetGrayF32(1, 0, GrayF32{Y: 1e7 + 2})
+	if s := StatsInROI(f, RectROI(f.Rect)); s.StdDev != 1 || s.Mean != 1e7+1 {
+		t.Errorf("offset stats = %+v", s)
+	}
+}
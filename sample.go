@@ -0,0 +1,161 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// Interpolation selects how values between pixel centers are computed.
+type Interpolation int
+
+const (
+	// Nearest returns the value of the pixel containing the point.
+	Nearest Interpolation = iota
+	// Bilinear interpolates linearly between the four nearest pixel
+	// centers.
+	Bilinear
+	// Bicubic fits a Catmull-Rom spline through the sixteen nearest pixel
+	// centers. It is sharper than Bilinear but can overshoot near edges.
+	Bicubic
+)
+
+// Border selects the value interpolation uses for pixels outside the
+// image's bounds.
+type Border int
+
+const (
+	// BorderClamp repeats the nearest edge pixel.
+	BorderClamp Border = iota
+	// BorderReflect mirrors the image about its edges, repeating the edge
+	// pixel, so that column -1 reads column 0 and column -2 column 1.
+	BorderReflect
+	// BorderWrap tiles the image periodically, as the FFT assumes.
+	BorderWrap
+	// BorderNaN makes any sample that needs a pixel outside the image NaN.
+	BorderNaN
+)
+
+// index maps i onto [0, n) according to b, reporting false if the pixel
+// has no value.
+func (b Border) index(i, n int) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+	switch b {
+	case BorderClamp:
+		return max(0, min(n-1, i)), true
+	case BorderReflect:
+		i %= 2 * n
+		if i < 0 {
+			i += 2 * n
+		}
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i, true
+	case BorderWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+// SampleF returns the value of p at the sub-pixel position (x, y), in
+// pixel-center coordinates, so that SampleF(x+0.5, y+0.5, …) returns the
+// value of pixel (x, y) exactly. Pixels beyond the bounds needed by interp
+// are read according to border. An empty image yields NaN.
+func (p *GrayS16Image) SampleF(x, y float64, interp Interpolation, border Border) float64 {
+	return sampleF(p.Rect, func(i, j int) float64 {
+		s := p.Pix[j*p.Stride+2*i:]
+		return float64(int16(uint16(s[0])<<8 | uint16(s[1])))
+	}, x, y, interp, border)
+}
+
+// SampleF is like GrayS16Image.SampleF. NaN pixels make every sample that
+// uses them NaN.
+func (p *GrayF32Image) SampleF(x, y float64, interp Interpolation, border Border) float64 {
+	return sampleF(p.Rect, func(i, j int) float64 {
+		return float64(getF32(p.Pix[j*p.Stride+4*i:]))
+	}, x, y, interp, border)
+}
+
+// sampleF interpolates at (x, y) in an image with bounds r, reading pixels
+// through at, which takes coordinates relative to r.Min.
+func sampleF(r image.Rectangle, at func(i, j int) float64, x, y float64, interp Interpolation, border Border) float64 {
+	w, h := r.Dx(), r.Dy()
+	if w <= 0 || h <= 0 || math.IsNaN(x) || math.IsNaN(y) {
+		return math.NaN()
+	}
+	// Move to coordinates relative to r.Min in which pixel centers are
+	// integers.
+	fx := x - float64(r.Min.X) - 0.5
+	fy := y - float64(r.Min.Y) - 0.5
+	pixel := func(i, j int) float64 {
+		i, okx := border.index(i, w)
+		j, oky := border.index(j, h)
+		if !okx || !oky {
+			return math.NaN()
+		}
+		return at(i, j)
+	}
+	// Positions far outside the image would overflow int; every border
+	// gives them the same value as a point just outside.
+	const limit = 1 << 30
+	fx = max(-limit, min(limit, fx))
+	fy = max(-limit, min(limit, fy))
+
+	switch interp {
+	case Bilinear:
+		x0, y0 := math.Floor(fx), math.Floor(fy)
+		tx, ty := fx-x0, fy-y0
+		i, j := int(x0), int(y0)
+		top := lerp(pixel(i, j), pixel(i+1, j), tx)
+		bottom := lerp(pixel(i, j+1), pixel(i+1, j+1), tx)
+		return lerp(top, bottom, ty)
+	case Bicubic:
+		x0, y0 := math.Floor(fx), math.Floor(fy)
+		wx, wy := catmullRom(fx-x0), catmullRom(fy-y0)
+		i, j := int(x0)-1, int(y0)-1
+		var v float64
+		for b := 0; b < 4; b++ {
+			var row float64
+			for a := 0; a < 4; a++ {
+				if wx[a] != 0 {
+					row += wx[a] * pixel(i+a, j+b)
+				}
+			}
+			if wy[b] != 0 {
+				v += wy[b] * row
+			}
+		}
+		return v
+	}
+	return pixel(int(math.Floor(fx+0.5)), int(math.Floor(fy+0.5)))
+}
+
+// lerp interpolates linearly from a to b. A zero weight ignores the other
+// value, so that an exact hit next to a NaN pixel is still valid.
+func lerp(a, b, t float64) float64 {
+	switch t {
+	case 0:
+		return a
+	case 1:
+		return b
+	}
+	return a + (b-a)*t
+}
+
+// catmullRom returns the weights of the four samples around a point at
+// fraction t past the second of them.
+func catmullRom(t float64) [4]float64 {
+	t2, t3 := t*t, t*t*t
+	return [4]float64{
+		(-t3 + 2*t2 - t) / 2,
+		(3*t3 - 5*t2 + 2) / 2,
+		(-3*t3 + 4*t2 + t) / 2,
+		(t3 - t2) / 2,
+	}
+}
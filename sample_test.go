@@ -0,0 +1,94 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestSampleF(t *testing.T) {
+	// A 3×2 image at an offset origin holding 10*x + 100*y.
+	img := NewGrayS16Image(image.Rect(2, 5, 5, 7))
+	f32 := NewGrayF32Image(img.Rect)
+	for y := 5; y < 7; y++ {
+		for x := 2; x < 5; x++ {
+			v := 10*(x-2) + 100*(y-5)
+			img.SetGrayS16(x, y, GrayS16{Y: int16(v)})
+			f32.SetGrayF32(x, y, GrayF32{Y: float32(v)})
+		}
+	}
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		x, y   float64
+		interp Interpolation
+		border Border
+		want   float64
+	}{
+		{"nearest center", 3.5, 5.5, Nearest, BorderClamp, 10},
+		{"nearest corner", 3.0, 6.0, Nearest, BorderClamp, 110},
+		{"nearest left edge", 2.0, 5.0, Nearest, BorderNaN, 0},
+		{"bilinear center", 4.5, 6.5, Bilinear, BorderClamp, 120},
+		{"bilinear between", 3.25, 6.0, Bilinear, BorderClamp, 57.5},
+		{"bicubic center", 3.5, 6.5, Bicubic, BorderNaN, 110},
+		{"clamp", -10, 20, Bilinear, BorderClamp, 100},
+		{"reflect", 1.5, 5.5, Nearest, BorderReflect, 0},
+		{"reflect far", 0.5, 5.5, Nearest, BorderReflect, 10},
+		{"wrap", 1.5, 7.5, Nearest, BorderWrap, 20},
+		{"nan outside", 1.5, 5.5, Nearest, BorderNaN, nan},
+		{"nan edge center", 4.5, 6.5, Bilinear, BorderNaN, 120},
+		{"nan edge between", 4.75, 6.5, Bilinear, BorderNaN, nan},
+		{"huge", 1e300, -1e300, Nearest, BorderClamp, 20},
+		{"nan position", nan, 5.5, Bilinear, BorderClamp, nan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, got := range []float64{
+				img.SampleF(tt.x, tt.y, tt.interp, tt.border),
+				f32.SampleF(tt.x, tt.y, tt.interp, tt.border),
+			} {
+				if math.IsNaN(tt.want) != math.IsNaN(got) || !math.IsNaN(got) && math.Abs(got-tt.want) > 1e-9 {
+					t.Errorf("SampleF(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleFExactAtCenters(t *testing.T) {
+	img := randomGrayS16(image.Rect(-3, 4, 9, 13), 7)
+	for _, interp := range []Interpolation{Nearest, Bilinear, Bicubic} {
+		for _, b := range []Border{BorderClamp, BorderReflect, BorderWrap, BorderNaN} {
+			for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+				for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+					got := img.SampleF(float64(x)+0.5, float64(y)+0.5, interp, b)
+					if want := float64(img.GrayS16At(x, y).Y); got != want {
+						t.Fatalf("interp %d border %d: (%d, %d) = %v, want %v", interp, b, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSampleFEmpty(t *testing.T) {
+	if v := NewGrayF32Image(image.Rectangle{}).SampleF(0, 0, Bilinear, BorderClamp); !math.IsNaN(v) {
+		t.Errorf("empty image: got %v, want NaN", v)
+	}
+}
+
+func TestSampleFBicubicRamp(t *testing.T) {
+	// Catmull-Rom splines reproduce linear ramps away from the borders.
+	img := NewGrayF32Image(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetGrayF32(x, y, GrayF32{Y: float32(3*x - 2*y)})
+		}
+	}
+	for _, p := range []PointF{{2.5, 2.5}, {3.2, 4.9}, {5.75, 2.125}} {
+		want := 3*(p.X-0.5) - 2*(p.Y-0.5)
+		if got := img.SampleF(p.X, p.Y, Bicubic, BorderNaN); math.Abs(got-want) > 1e-9 {
+			t.Errorf("SampleF(%v, %v) = %v, want %v", p.X, p.Y, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// PointF is a point with floating point coordinates. Operations that produce
+// sub-pixel positions use pixel-center coordinates, so the center of the pixel
+// at (x, y) is PointF{x + 0.5, y + 0.5}.
+type PointF struct {
+	X, Y float64
+}
+
+// scalarSampler returns a function reading the raw scalar value of img at
+// (x, y). Signed and float images yield their stored values, physical
+// images their physical values, stdlib gray images their unsigned values,
+// and other images their GrayF32 luminance.
+// A UniformImage yields the raw value of its color when it is one of these
+// gray types.
+// Invalid pixels of a Validator and out of bounds pixels yield NaN. An
+// *ImageWithMeta or *FrozenImage is sampled through the image it wraps.
+func scalarSampler(img image.Image) func(x, y int) float64 {
+	switch m := img.(type) {
+	case *ImageWithMeta:
+		return scalarSampler(m.Image)
+	case *FrozenImage:
+		f := scalarSampler(m.img)
+		return func(x, y int) float64 {
+			if !(image.Point{X: x, Y: y}.In(m.rect)) {
+				return math.NaN()
+			}
+			return f(x, y)
+		}
+	}
+	r := img.Bounds()
+	var f func(x, y int) float64
+	switch m := img.(type) {
+	case *GrayS16Image:
+		f = func(x, y int) float64 { return float64(m.GrayS16At(x, y).Y) }
+	case *GrayF32Image:
+		f = func(x, y int) float64 { return float64(m.GrayF32At(x, y).Y) }
+	case *MaskedGrayS16Image:
+		f = func(x, y int) float64 { return float64(m.GrayS16At(x, y).Y) }
+	case *PhysicalGrayS16Image:
+		f = m.ValueAt
+	case *image.Gray16:
+		f = func(x, y int) float64 { return float64(m.Gray16At(x, y).Y) }
+	case *image.Gray:
+		f = func(x, y int) float64 { return float64(m.GrayAt(x, y).Y) }
+	case *UniformImage:
+		v := uniformScalar(m)
+		f = func(x, y int) float64 { return v }
+	default:
+		f = func(x, y int) float64 {
+			return float64(GrayF32Model.Convert(img.At(x, y)).(GrayF32).Y)
+		}
+	}
+	v, hasValidity := img.(Validator)
+	return func(x, y int) float64 {
+		if !(image.Point{X: x, Y: y}.In(r)) {
+			return math.NaN()
+		}
+		if hasValidity && !v.Valid(x, y) {
+			return math.NaN()
+		}
+		return f(x, y)
+	}
+}
+
+// uniformScalar returns the raw scalar value of u's color, following the
+// same rules as scalarSampler.
+func uniformScalar(u *UniformImage) float64 {
+	switch c := u.C.(type) {
+	case GrayS16:
+		return float64(c.Y)
+	case GrayF32:
+		return float64(c.Y)
+	case color.Gray16:
+		return float64(c.Y)
+	case color.Gray:
+		return float64(c.Y)
+	}
+	return float64(u.GrayF32At(0, 0).Y)
+}
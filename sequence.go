@@ -0,0 +1,362 @@
+package colorext
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// FrameSequence is an ordered series of frames sharing the same bounds,
+// such as thermal or depth video.
+type FrameSequence []*GrayS16Image
+
+// Bounds returns the bounds of the first frame, or the empty rectangle if
+// s is empty.
+func (s FrameSequence) Bounds() image.Rectangle {
+	if len(s) == 0 {
+		return image.Rectangle{}
+	}
+	return s[0].Rect
+}
+
+// EncodeDelta writes s to w in the delta sequence format, with a keyframe
+// every keyInterval frames. See DeltaWriter.
+func (s FrameSequence) EncodeDelta(w io.Writer, keyInterval int) error {
+	dw, err := NewDeltaWriter(w, s.Bounds(), keyInterval)
+	if err != nil {
+		return err
+	}
+	for _, f := range s {
+		if err := dw.WriteFrame(f); err != nil {
+			return err
+		}
+	}
+	return dw.Close()
+}
+
+// Delta sequence format.
+//
+// A delta sequence stores most frames as their wrapped int16 difference
+// from the previous frame, which is mostly zero for slowly changing scenes
+// and so shrinks to a few QS16 run codes. Every keyInterval-th frame is
+// stored whole, bounding the work needed to reach any frame. The stream
+// is the magic "CXDS", a version byte, the four coordinates of the bounds
+// and the key interval as varints, then one record per frame: a kind byte
+// (deltaKey or deltaDiff) followed by a QS16 frame. An index of the kind
+// and uint64 offset of every record follows the last record, and the
+// stream ends with a fixed trailer: the uint64 frame count, the uint64
+// offset of the index and the magic "CXDI". Integers are big-endian.
+const (
+	deltaMagic        = "CXDS"
+	deltaTrailerMagic = "CXDI"
+	deltaVersion      = 1
+	deltaTrailerSize  = 8 + 8 + 4
+
+	deltaKey  = 0
+	deltaDiff = 1
+)
+
+// DeltaWriter writes frames in the delta sequence format. Call Close after
+// the last frame to write the index; a stream without one cannot be read.
+type DeltaWriter struct {
+	bw          *bufio.Writer
+	w           *countingWriter
+	rect        image.Rectangle
+	keyInterval int
+	prev        *GrayS16Image
+	diff        *GrayS16Image
+	kinds       []byte
+	offsets     []int64
+	err         error
+}
+
+// NewDeltaWriter writes the header of a delta sequence of frames with
+// bounds r to w. A keyInterval below 1 means 30.
+func NewDeltaWriter(w io.Writer, r image.Rectangle, keyInterval int) (*DeltaWriter, error) {
+	if keyInterval < 1 {
+		keyInterval = 30
+	}
+	bw := bufio.NewWriter(w)
+	dw := &DeltaWriter{
+		bw:          bw,
+		w:           &countingWriter{w: bw},
+		rect:        r,
+		keyInterval: keyInterval,
+	}
+	hdr := append([]byte(deltaMagic), deltaVersion)
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y, keyInterval} {
+		hdr = binary.AppendVarint(hdr, int64(v))
+	}
+	if _, err := dw.w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return dw, nil
+}
+
+// WriteFrame appends img, which must have the sequence's bounds.
+func (w *DeltaWriter) WriteFrame(img *GrayS16Image) error {
+	if w.err != nil {
+		return w.err
+	}
+	if img.Rect != w.rect {
+		return fmt.Errorf("%w: frame bounds %v differ from sequence bounds %v", ErrBoundsMismatch, img.Rect, w.rect)
+	}
+	kind, frame := byte(deltaKey), img
+	if len(w.offsets)%w.keyInterval != 0 {
+		if w.diff == nil {
+			w.diff = NewGrayS16Image(w.rect)
+		}
+		diffRows(w.diff, img, w.prev)
+		kind, frame = deltaDiff, w.diff
+	}
+	if w.prev == nil {
+		w.prev = NewGrayS16Image(w.rect)
+	}
+	copyRows16(w.prev.Pix, w.prev.Stride, 0, img.Pix, img.Stride, 0, w.rect, w.rect.Min)
+
+	w.kinds = append(w.kinds, kind)
+	w.offsets = append(w.offsets, w.w.n)
+	if _, err := w.w.Write([]byte{kind}); err != nil {
+		w.err = err
+		return err
+	}
+	if err := encodeQS16(w.w, frame); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// Close writes the index and trailer and flushes the stream. It does not
+// close the underlying writer.
+func (w *DeltaWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	indexAt := w.w.n
+	buf := make([]byte, 0, 9*len(w.offsets)+deltaTrailerSize)
+	for i, off := range w.offsets {
+		buf = append(buf, w.kinds[i])
+		buf = binary.BigEndian.AppendUint64(buf, uint64(off))
+	}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(w.offsets)))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(indexAt))
+	buf = append(buf, deltaTrailerMagic...)
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+	w.err = errors.New("colorext: DeltaWriter is closed")
+	return w.bw.Flush()
+}
+
+// diffRows stores the wrapped difference a - b in dst.
+func diffRows(dst, a, b *GrayS16Image) {
+	w := 2 * dst.Rect.Dx()
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+		pa := a.Pix[y*a.Stride : y*a.Stride+w]
+		pb := b.Pix[y*b.Stride : y*b.Stride+w]
+		for i := 0; i < w; i += 2 {
+			v := (uint16(pa[i])<<8 | uint16(pa[i+1])) - (uint16(pb[i])<<8 | uint16(pb[i+1]))
+			d[i], d[i+1] = uint8(v>>8), uint8(v)
+		}
+	}
+}
+
+// addRows adds the wrapped difference d to dst in place.
+func addRows(dst, d *GrayS16Image) {
+	w := 2 * dst.Rect.Dx()
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		p := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+		q := d.Pix[y*d.Stride : y*d.Stride+w]
+		for i := 0; i < w; i += 2 {
+			v := (uint16(p[i])<<8 | uint16(p[i+1])) + (uint16(q[i])<<8 | uint16(q[i+1]))
+			p[i], p[i+1] = uint8(v>>8), uint8(v)
+		}
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// DeltaReader reads frames of a delta sequence in any order. Reading
+// frames in order costs one decode each; seeking decodes from the nearest
+// preceding keyframe. A DeltaReader is not safe for concurrent use.
+type DeltaReader struct {
+	r       io.ReaderAt
+	rect    image.Rectangle
+	kinds   []byte
+	offsets []int64
+	end     int64
+
+	// cur holds frame pos, the last one reconstructed, or pos is -1.
+	cur *GrayS16Image
+	pos int
+}
+
+// NewDeltaReader opens the delta sequence of size bytes in r, reading its
+// header and index. Malformed input yields a *DecodeError.
+func NewDeltaReader(r io.ReaderAt, size int64) (*DeltaReader, error) {
+	fail := func(off int64, err error) (*DeltaReader, error) {
+		return nil, decodeError("delta sequence", off, err)
+	}
+	if size < int64(len(deltaMagic)+1+deltaTrailerSize) {
+		return fail(0, io.ErrUnexpectedEOF)
+	}
+	trailerAt := size - deltaTrailerSize
+	var tr [deltaTrailerSize]byte
+	if _, err := r.ReadAt(tr[:], trailerAt); err != nil {
+		if err == io.EOF {
+			return fail(trailerAt, err)
+		}
+		return nil, err
+	}
+	if string(tr[16:]) != deltaTrailerMagic {
+		return fail(trailerAt+16, errors.New("no index"))
+	}
+	count := binary.BigEndian.Uint64(tr[0:])
+	indexAt := binary.BigEndian.Uint64(tr[8:])
+	// Divide before multiplying: 9*count wraps for counts from a corrupt
+	// trailer.
+	if indexAt > uint64(trailerAt) || count > (uint64(trailerAt)-indexAt)/9 || uint64(trailerAt)-indexAt != 9*count {
+		return fail(trailerAt, errors.New("index does not match its trailer"))
+	}
+
+	br := &offsetReader{r: bufio.NewReader(io.NewSectionReader(r, 0, int64(indexAt)))}
+	var hdr [len(deltaMagic) + 1]byte
+	for i := range hdr {
+		c, err := br.ReadByte()
+		if err != nil {
+			return fail(br.off, err)
+		}
+		hdr[i] = c
+	}
+	if string(hdr[:len(deltaMagic)]) != deltaMagic {
+		return fail(0, errors.New("not a delta sequence"))
+	}
+	if hdr[len(deltaMagic)] != deltaVersion {
+		return fail(br.off-1, fmt.Errorf("unsupported version %d", hdr[len(deltaMagic)]))
+	}
+	var c [5]int
+	for i := range c {
+		v, err := binary.ReadVarint(br)
+		if err != nil || v != int64(int(v)) {
+			return fail(br.off, err)
+		}
+		c[i] = int(v)
+	}
+	if c[2] < c[0] || c[3] < c[1] {
+		return fail(br.off, fmt.Errorf("%w: malformed bounds (%d,%d)-(%d,%d)", ErrInvalidLayout, c[0], c[1], c[2], c[3]))
+	}
+
+	index := make([]byte, 9*count)
+	if _, err := r.ReadAt(index, int64(indexAt)); err != nil {
+		if err == io.EOF {
+			return fail(int64(indexAt), err)
+		}
+		return nil, err
+	}
+	d := &DeltaReader{
+		r:       r,
+		rect:    image.Rect(c[0], c[1], c[2], c[3]),
+		kinds:   make([]byte, count),
+		offsets: make([]int64, count),
+		end:     int64(indexAt),
+		pos:     -1,
+	}
+	prev := int64(0)
+	for i := range d.offsets {
+		e := index[9*i:]
+		d.kinds[i] = e[0]
+		d.offsets[i] = int64(binary.BigEndian.Uint64(e[1:]))
+		if d.offsets[i] < prev || d.offsets[i] >= d.end || (i == 0 && e[0] != deltaKey) {
+			return fail(int64(indexAt)+int64(9*i), fmt.Errorf("invalid index entry %d", i))
+		}
+		prev = d.offsets[i]
+	}
+	return d, nil
+}
+
+// Len returns the number of frames in the sequence.
+func (d *DeltaReader) Len() int {
+	return len(d.offsets)
+}
+
+// Bounds returns the bounds shared by the frames.
+func (d *DeltaReader) Bounds() image.Rectangle {
+	return d.rect
+}
+
+// Frame returns a new copy of frame i.
+func (d *DeltaReader) Frame(i int) (*GrayS16Image, error) {
+	if i < 0 || i >= len(d.offsets) {
+		return nil, fmt.Errorf("colorext: frame %d out of range [0, %d)", i, len(d.offsets))
+	}
+	// Continue from the current frame if it lies between i and the
+	// keyframe preceding i; otherwise start at that keyframe.
+	start := i
+	for d.kinds[start] != deltaKey {
+		start--
+	}
+	if d.pos >= start && d.pos <= i {
+		start = d.pos + 1
+	}
+	for j := start; j <= i; j++ {
+		if err := d.apply(j); err != nil {
+			d.pos = -1
+			return nil, err
+		}
+	}
+	out := NewGrayS16Image(d.cur.Rect)
+	copyRows16(out.Pix, out.Stride, 0, d.cur.Pix, d.cur.Stride, 0, out.Rect, out.Rect.Min)
+	return out, nil
+}
+
+// apply decodes record j on top of the current frame.
+func (d *DeltaReader) apply(j int) error {
+	end := d.end
+	if j+1 < len(d.offsets) {
+		end = d.offsets[j+1]
+	}
+	at := d.offsets[j]
+	br := &offsetReader{r: bufio.NewReader(io.NewSectionReader(d.r, at, end-at)), off: at}
+	kind, err := br.ReadByte()
+	if err != nil {
+		return decodeError("delta sequence", br.off, err)
+	}
+	if kind != d.kinds[j] {
+		return decodeError("delta sequence", at, fmt.Errorf("record %d has kind %d, its index entry %d", j, kind, d.kinds[j]))
+	}
+	f, err := decodeQS16(br, DefaultQS16MaxPixels)
+	if err == io.EOF {
+		return decodeError("delta sequence", br.off, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !f.Rect.Eq(d.rect) {
+		return decodeError("delta sequence", at, fmt.Errorf("%w: frame %d has bounds %v, want %v", ErrBoundsMismatch, j, f.Rect, d.rect))
+	}
+	switch {
+	case kind == deltaKey:
+		d.cur = f
+	case kind == deltaDiff && j > 0 && d.cur != nil && d.pos == j-1:
+		addRows(d.cur, f)
+	default:
+		return decodeError("delta sequence", at, fmt.Errorf("invalid record %d", j))
+	}
+	d.pos = j
+	return nil
+}
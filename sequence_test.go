@@ -0,0 +1,199 @@
+package colorext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// driftingSequence returns n frames of a slowly changing scene: a fixed
+// random background with a few pixels changing per frame.
+func driftingSequence(r image.Rectangle, n int) FrameSequence {
+	rng := rand.New(rand.NewSource(1))
+	frame := randomGrayS16(r, 6)
+	var s FrameSequence
+	for range n {
+		f := NewGrayS16Image(r)
+		copy(f.Pix, frame.Pix)
+		for range 5 {
+			x, y := r.Min.X+rng.Intn(r.Dx()), r.Min.Y+rng.Intn(r.Dy())
+			f.SetGrayS16(x, y, GrayS16{Y: int16(rng.Intn(65536) - 32768)})
+		}
+		s = append(s, f)
+		frame = f
+	}
+	return s
+}
+
+func TestDeltaSequence(t *testing.T) {
+	r := image.Rect(-3, 2, 37, 30)
+	seq := driftingSequence(r, 23)
+	var buf bytes.Buffer
+	if err := seq.EncodeDelta(&buf, 8); err != nil {
+		t.Fatal(err)
+	}
+	// Deltas must beat coding every frame on its own.
+	var intra bytes.Buffer
+	for _, f := range seq {
+		EncodeQS16(&intra, f)
+	}
+	if buf.Len() > intra.Len()/3 {
+		t.Errorf("encoded %d bytes, %d as independent frames", buf.Len(), intra.Len())
+	}
+
+	d, err := NewDeltaReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != len(seq) || d.Bounds() != r {
+		t.Fatalf("Len %d Bounds %v, want %d %v", d.Len(), d.Bounds(), len(seq), r)
+	}
+	// Sequential, repeated, backward and forward seeks.
+	for _, i := range []int{0, 1, 2, 3, 3, 22, 7, 8, 9, 5, 15, 16, 12, 0} {
+		got, err := d.Frame(i)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !Equal(got, seq[i]) {
+			t.Errorf("frame %d differs", i)
+		}
+		// The returned frame is a copy.
+		got.SetGrayS16(r.Min.X, r.Min.Y, GrayS16{Y: 1})
+	}
+	if _, err := d.Frame(23); err == nil {
+		t.Error("frame 23: got nil error")
+	}
+}
+
+func TestDeltaSequenceEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (FrameSequence{}).EncodeDelta(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDeltaReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 0 {
+		t.Errorf("Len = %d, want 0", d.Len())
+	}
+}
+
+func TestDeltaWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewDeltaWriter(&buf, image.Rect(0, 0, 4, 4), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFrame(NewGrayS16Image(image.Rect(0, 0, 4, 5))); err == nil {
+		t.Error("mismatched bounds: got nil error")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFrame(NewGrayS16Image(image.Rect(0, 0, 4, 4))); err == nil {
+		t.Error("write after Close: got nil error")
+	}
+}
+
+func TestNewDeltaReaderErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := driftingSequence(image.Rect(0, 0, 8, 8), 4).EncodeDelta(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	patch := func(i int, v byte) []byte {
+		b := bytes.Clone(good)
+		b[i] = v
+		return b
+	}
+	trailer := int64(len(good) - deltaTrailerSize)
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		offset int64
+	}{
+		{"short", good[:10], "unexpected EOF", 0},
+		{"no index", good[:len(good)-1], "no index", trailer - 1 + 16},
+		{"magic", patch(0, 'X'), "not a delta sequence", 0},
+		{"version", patch(4, 7), "version", 4},
+		{"count", patch(len(good)-deltaTrailerSize+7, 9), "index", trailer},
+		{"count overflow", overflowingDeltaIndex(), "index", 12},
+	}
+	for _, tt := range tests {
+		_, err := NewDeltaReader(bytes.NewReader(tt.data), int64(len(tt.data)))
+		var de *DecodeError
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !errors.As(err, &de) || de.Offset != tt.offset {
+			t.Errorf("%s: got %v, want error containing %q at offset %d", tt.name, err, tt.want, tt.offset)
+		}
+	}
+
+	// Corrupt frame data is reported when the frame is read.
+	idx := len(good) - deltaTrailerSize - 9*4
+	b := bytes.Clone(good)
+	for i := idx - 8; i < idx; i++ {
+		b[i] = 0xff
+	}
+	d, err := NewDeltaReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Frame(3); err == nil {
+		t.Error("corrupt frame: got nil error")
+	}
+
+	// A record whose kind disagrees with its index entry, here a difference
+	// where the index promises a keyframe, is rejected.
+	d, err = NewDeltaReader(bytes.NewReader(diffFirstRecord(good)), int64(len(good)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Frame(0); err == nil || !strings.Contains(err.Error(), "kind") {
+		t.Errorf("difference as first record: got %v", err)
+	}
+}
+
+// overflowingDeltaIndex returns a stream of an empty header, two spare
+// bytes and a trailer whose frame count times the index entry size wraps
+// to those two bytes.
+func overflowingDeltaIndex() []byte {
+	b := append([]byte(deltaMagic), deltaVersion, 0, 0, 0, 0, 0, 0xff, 0xff)
+	b = binary.BigEndian.AppendUint64(b, 2049638230412172402)
+	b = binary.BigEndian.AppendUint64(b, 10)
+	return append(b, deltaTrailerMagic...)
+}
+
+// diffFirstRecord returns a copy of the delta sequence data with the kind
+// byte of its first record changed to deltaDiff.
+func diffFirstRecord(data []byte) []byte {
+	b := bytes.Clone(data)
+	indexAt := binary.BigEndian.Uint64(b[len(b)-deltaTrailerSize+8:])
+	first := binary.BigEndian.Uint64(b[indexAt+1:])
+	b[first] = deltaDiff
+	return b
+}
+
+func FuzzDeltaReader(f *testing.F) {
+	var buf bytes.Buffer
+	if err := driftingSequence(image.Rect(0, 0, 4, 3), 5).EncodeDelta(&buf, 3); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.Bytes())
+	f.Add(diffFirstRecord(buf.Bytes()))
+	f.Add(overflowingDeltaIndex())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, err := NewDeltaReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		// Malformed frames must be reported, never panic.
+		for i := d.Len() - 1; i >= 0 && i >= d.Len()-8; i-- {
+			d.Frame(i)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// SubImageCopy returns a copy of the portion of p visible through r. Unlike
+// SubImage, the result does not share pixels with p, and its Pix holds only
+// the copied pixels.
+func (p *GrayS16Image) SubImageCopy(r image.Rectangle) *GrayS16Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &GrayS16Image{StrictBounds: p.StrictBounds, Meta: p.Meta, CompactRatio: p.CompactRatio}
+	}
+	dst := NewGrayS16Image(r, WithMeta(p.Meta))
+	n := 2 * r.Dx()
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	for y := 0; y < r.Dy(); y++ {
+		copy(dst.Pix[y*dst.Stride:y*dst.Stride+n], p.Pix[i+y*p.Stride:])
+	}
+	dst.StrictBounds = p.StrictBounds
+	dst.CompactRatio = p.CompactRatio
+	return dst
+}
+
+// CopyOnWriteSubImage returns a view of the portion of p visible through r
+// that shares p's pixels until it is first written to, when it copies them.
+// Read-mostly crops are therefore cheap, and writes through the view never
+// reach p. Writes to p before the view's first write remain visible in it;
+// use SubImageCopy to isolate a crop from its parent entirely.
+func (p *GrayS16Image) CopyOnWriteSubImage(r image.Rectangle) *CopyOnWriteGrayS16Image {
+	return &CopyOnWriteGrayS16Image{img: p.SubImage(r).(*GrayS16Image)}
+}
+
+// CopyOnWriteGrayS16Image is a GrayS16Image view that copies its pixels on
+// first write. Create one with GrayS16Image.CopyOnWriteSubImage.
+type CopyOnWriteGrayS16Image struct {
+	img *GrayS16Image
+	// owned reports whether img's pixels belong to this view alone.
+	owned bool
+}
+
+// ColorModel returns the image's color model.
+func (p *CopyOnWriteGrayS16Image) ColorModel() color.Model {
+	return GrayS16Model
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *CopyOnWriteGrayS16Image) Bounds() image.Rectangle {
+	return p.img.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *CopyOnWriteGrayS16Image) At(x, y int) color.Color {
+	return p.img.GrayS16At(x, y)
+}
+
+// GrayS16At returns the GrayS16 color of the pixel at (x, y).
+func (p *CopyOnWriteGrayS16Image) GrayS16At(x, y int) GrayS16 {
+	return p.img.GrayS16At(x, y)
+}
+
+// Set sets the pixel at (x, y) to a given color, copying the pixels first if
+// they are still shared.
+func (p *CopyOnWriteGrayS16Image) Set(x, y int, c color.Color) {
+	p.SetGrayS16(x, y, GrayS16Model.Convert(c).(GrayS16))
+}
+
+// SetGrayS16 sets the pixel at (x, y) to a given GrayS16 color, copying the
+// pixels first if they are still shared.
+func (p *CopyOnWriteGrayS16Image) SetGrayS16(x, y int, c GrayS16) {
+	if !(image.Point{X: x, Y: y}.In(p.img.Rect)) {
+		p.img.outOfBounds(x, y)
+		return
+	}
+	p.Image().SetGrayS16(x, y, c)
+}
+
+// Image returns a GrayS16Image holding the view's pixels, copying them first
+// if they are still shared. The returned image may be modified freely; it
+// remains the view's storage.
+func (p *CopyOnWriteGrayS16Image) Image() *GrayS16Image {
+	if !p.owned {
+		p.img = p.img.SubImageCopy(p.img.Rect)
+		p.owned = true
+	}
+	return p.img
+}
+
+// Shared reports whether the view still shares its pixels with another
+// image.
+func (p *CopyOnWriteGrayS16Image) Shared() bool {
+	return !p.owned
+}
+
+// SubImage returns a copy-on-write view of the portion of the image visible
+// through r. Both images then share pixels until either is written to.
+func (p *CopyOnWriteGrayS16Image) SubImage(r image.Rectangle) image.Image {
+	p.owned = false
+	return p.img.CopyOnWriteSubImage(r)
+}
+
+// Opaque reports whether the image is fully opaque. It always is.
+func (p *CopyOnWriteGrayS16Image) Opaque() bool {
+	return true
+}
+
+// Compact moves p's pixels into a new buffer holding exactly its bounds, so
+// that p stops sharing pixels with any other image. A small SubImage of a
+// large image otherwise keeps the whole parent buffer alive; compacting it
+// lets the parent be garbage collected. SubImageCopy produces an already
+// compact crop in one step, and CompactRatio makes SubImage compact small
+// crops itself.
+func (p *GrayS16Image) Compact() {
+	compactPix(&p.Pix, &p.Stride, 2*p.Rect.Dx(), p.Rect.Dy())
+}
+
+// Compact is like GrayS16Image.Compact.
+func (p *GrayF32Image) Compact() {
+	compactPix(&p.Pix, &p.Stride, 4*p.Rect.Dx(), p.Rect.Dy())
+}
+
+// compactPix reallocates pix to hold h rows of n bytes without padding.
+// It copies even a buffer that already has that shape, since a sub-image
+// of the bottom rows of its parent does too, yet shares the parent's
+// memory.
+func compactPix(pix *[]uint8, stride *int, n, h int) {
+	if n == 0 || h == 0 {
+		*pix, *stride = nil, 0
+		return
+	}
+	buf := make([]uint8, n*h)
+	for y := 0; y < h; y++ {
+		copy(buf[y*n:(y+1)*n], (*pix)[y**stride:])
+	}
+	*pix, *stride = buf, n
+}
+
+// compactSub reports whether a sub-image of h rows of n bytes, taken from
+// an image whose buffer is pix and whose CompactRatio is ratio, should be
+// compacted.
+func compactSub(pix []uint8, ratio, n, h int) bool {
+	return ratio > 0 && cap(pix)/ratio > n*h
+}
@@ -0,0 +1,175 @@
+package colorext
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGrayS16Image_SubImageCopy(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 10, 8), 11)
+	r := image.Rect(3, 2, 7, 5)
+	cp := src.SubImageCopy(r)
+	if cp.Rect != r || cp.Stride != 8 || len(cp.Pix) != 24 {
+		t.Fatalf("Rect, Stride, len(Pix) = %v, %d, %d, want %v, 8, 24", cp.Rect, cp.Stride, len(cp.Pix), r)
+	}
+	if !Equal(cp, src.SubImage(r)) {
+		t.Error("copy differs from the sub-image")
+	}
+	// The copy is independent of its source in both directions.
+	old := src.GrayS16At(4, 3)
+	cp.SetGrayS16(4, 3, GrayS16{Y: old.Y + 1})
+	if src.GrayS16At(4, 3) != old {
+		t.Error("write to copy reached the source")
+	}
+	src.SetGrayS16(5, 4, GrayS16{Y: 1234})
+	if cp.GrayS16At(5, 4).Y == 1234 && old.Y != 1234 {
+		t.Error("write to source reached the copy")
+	}
+
+	if empty := src.SubImageCopy(image.Rect(20, 20, 30, 30)); !empty.Rect.Empty() || len(empty.Pix) != 0 {
+		t.Errorf("disjoint copy = %v with %d bytes, want empty", empty.Rect, len(empty.Pix))
+	}
+	// Clipped to the source bounds.
+	if c := src.SubImageCopy(image.Rect(-5, 6, 3, 20)); c.Rect != image.Rect(0, 6, 3, 8) {
+		t.Errorf("clipped copy bounds = %v", c.Rect)
+	}
+}
+
+func TestCopyOnWriteGrayS16Image(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 6, 6), 12)
+	orig := src.SubImageCopy(src.Rect)
+	r := image.Rect(1, 1, 4, 5)
+
+	cow := src.CopyOnWriteSubImage(r)
+	if !cow.Shared() || cow.Bounds() != r || cow.ColorModel() != GrayS16Model || !cow.Opaque() {
+		t.Fatal("unexpected initial state")
+	}
+	if !Equal(cow, src.SubImage(r)) {
+		t.Fatal("view differs from the sub-image")
+	}
+
+	// Reads share the parent's pixels.
+	src.SetGrayS16(2, 2, GrayS16{Y: 77})
+	if cow.GrayS16At(2, 2).Y != 77 {
+		t.Error("unwritten view does not see parent writes")
+	}
+
+	// The first write copies; the parent is untouched.
+	cow.SetGrayS16(3, 4, GrayS16{Y: -5})
+	if cow.Shared() {
+		t.Error("view still shared after a write")
+	}
+	if cow.GrayS16At(3, 4).Y != -5 {
+		t.Error("write through the view was lost")
+	}
+	if src.GrayS16At(3, 4) != orig.GrayS16At(3, 4) {
+		t.Error("write through the view reached the parent")
+	}
+	src.SetGrayS16(1, 1, GrayS16{Y: 99})
+	if cow.GrayS16At(1, 1).Y == 99 && orig.GrayS16At(1, 1).Y != 99 {
+		t.Error("parent writes reach the view after it copied")
+	}
+
+	// Writes outside the bounds neither copy nor panic.
+	cow2 := src.CopyOnWriteSubImage(r)
+	cow2.Set(0, 0, GrayS16{Y: 1})
+	if !cow2.Shared() {
+		t.Error("out of bounds write copied the pixels")
+	}
+
+	// Sub-images of a view share with it until either writes.
+	sub := cow.SubImage(image.Rect(2, 2, 4, 4)).(*CopyOnWriteGrayS16Image)
+	if !cow.Shared() || !sub.Shared() {
+		t.Error("view and its sub-image should both be shared")
+	}
+	sub.SetGrayS16(2, 2, GrayS16{Y: 500})
+	cow.SetGrayS16(3, 3, GrayS16{Y: 600})
+	if cow.GrayS16At(2, 2).Y == 500 || sub.GrayS16At(3, 3).Y == 600 {
+		t.Error("writes leaked between a view and its sub-image")
+	}
+}
+
+func TestGrayS16Image_Compact(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 100, 100), 13)
+	sub := src.SubImage(image.Rect(10, 20, 13, 22)).(*GrayS16Image)
+	want := sub.SubImageCopy(sub.Rect)
+
+	sub.Compact()
+	if sub.Stride != 6 || len(sub.Pix) != 12 || cap(sub.Pix) != 12 {
+		t.Fatalf("Stride, len, cap = %d, %d, %d, want 6, 12, 12", sub.Stride, len(sub.Pix), cap(sub.Pix))
+	}
+	if !Equal(sub, want) {
+		t.Error("compacting changed the pixels")
+	}
+	sub.SetGrayS16(10, 20, GrayS16{Y: 1})
+	src.SetGrayS16(11, 20, GrayS16{Y: 2})
+	if src.GrayS16At(10, 20) != want.GrayS16At(10, 20) || sub.GrayS16At(11, 20) != want.GrayS16At(11, 20) {
+		t.Error("compacted image still shares pixels with its parent")
+	}
+
+	// A full-width crop of the bottom rows already looks compact, but still
+	// shares its parent's buffer.
+	parent := randomGrayS16(image.Rect(0, 0, 4, 4), 14)
+	bottom := parent.SubImage(image.Rect(0, 2, 4, 4)).(*GrayS16Image)
+	bottom.Compact()
+	before := parent.GrayS16At(1, 3)
+	bottom.SetGrayS16(1, 3, GrayS16{Y: before.Y + 1})
+	if parent.GrayS16At(1, 3) != before {
+		t.Error("compacted bottom rows still share pixels with their parent")
+	}
+
+	empty := src.SubImage(image.Rect(200, 200, 300, 300)).(*GrayS16Image)
+	empty.Compact()
+	if empty.Pix != nil {
+		t.Error("empty image kept a buffer")
+	}
+}
+
+func TestCompactRatio(t *testing.T) {
+	src := randomGrayS16(image.Rect(0, 0, 100, 100), 15)
+	src.CompactRatio = 16
+	tests := []struct {
+		name    string
+		r       image.Rectangle
+		compact bool
+	}{
+		{"small", image.Rect(10, 10, 20, 20), true},
+		{"large", image.Rect(0, 0, 50, 100), false},
+		{"empty", image.Rect(200, 200, 300, 300), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := src.SubImage(tt.r).(*GrayS16Image)
+			if sub.CompactRatio != 16 {
+				t.Errorf("CompactRatio = %d, want 16", sub.CompactRatio)
+			}
+			if got := sub.Stride == 2*sub.Rect.Dx() && len(sub.Pix) > 0; got != tt.compact {
+				t.Errorf("compacted = %v, want %v", got, tt.compact)
+			}
+			if !Equal(sub, src.SubImageCopy(tt.r)) {
+				t.Error("sub-image pixels differ from the parent's")
+			}
+		})
+	}
+
+	f := NewGrayF32Image(image.Rect(0, 0, 64, 64))
+	f.CompactRatio = 4
+	f.SetGrayF32(1, 1, GrayF32{Y: 3})
+	sub := f.SubImage(image.Rect(0, 0, 2, 2)).(*GrayF32Image)
+	if sub.Stride != 8 || sub.GrayF32At(1, 1).Y != 3 {
+		t.Errorf("Stride, pixel = %d, %v, want 8, 3", sub.Stride, sub.GrayF32At(1, 1).Y)
+	}
+}
+
+func TestGrayF32Image_Compact(t *testing.T) {
+	src := NewGrayF32Image(image.Rect(0, 0, 8, 8))
+	src.SetGrayF32(5, 6, GrayF32{Y: 2.5})
+	sub := src.SubImage(image.Rect(4, 5, 7, 7)).(*GrayF32Image)
+	sub.Compact()
+	if sub.Stride != 12 || len(sub.Pix) != 24 {
+		t.Fatalf("Stride, len = %d, %d, want 12, 24", sub.Stride, len(sub.Pix))
+	}
+	if got := sub.GrayF32At(5, 6).Y; got != 2.5 {
+		t.Errorf("pixel after Compact = %v, want 2.5", got)
+	}
+}
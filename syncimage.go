@@ -0,0 +1,141 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// SyncImage wraps a draw.Image so that At and Set may be called from any
+// number of goroutines. Rows are guarded by a fixed set of read-write
+// locks, row y by lock y mod n, so writers to different rows rarely
+// contend while overlapping writes are still serialized. With at least as
+// many locks as rows every row has its own lock.
+//
+// Only access through the SyncImage is synchronized; the wrapped image
+// must not be used directly while the SyncImage is in use.
+type SyncImage struct {
+	img   draw.Image
+	locks []sync.RWMutex
+}
+
+// NewSyncImage returns a SyncImage guarding img with the given number of
+// row locks. A stripes value below 1 means one lock per row, up to 256.
+func NewSyncImage(img draw.Image, stripes int) *SyncImage {
+	if stripes < 1 {
+		stripes = max(1, min(img.Bounds().Dy(), 256))
+	}
+	return &SyncImage{img: img, locks: make([]sync.RWMutex, stripes)}
+}
+
+// stripe returns the lock guarding row y.
+func (p *SyncImage) stripe(y int) *sync.RWMutex {
+	i := y % len(p.locks)
+	if i < 0 {
+		i += len(p.locks)
+	}
+	return &p.locks[i]
+}
+
+// ColorModel returns the color model of the wrapped image.
+func (p *SyncImage) ColorModel() color.Model {
+	return p.img.ColorModel()
+}
+
+// Bounds returns the bounds of the wrapped image.
+func (p *SyncImage) Bounds() image.Rectangle {
+	return p.img.Bounds()
+}
+
+// At returns the color of the pixel at (x, y) under the row's read lock.
+func (p *SyncImage) At(x, y int) color.Color {
+	l := p.stripe(y)
+	l.RLock()
+	defer l.RUnlock()
+	return p.img.At(x, y)
+}
+
+// Set sets the pixel at (x, y) under the row's write lock.
+func (p *SyncImage) Set(x, y int, c color.Color) {
+	l := p.stripe(y)
+	l.Lock()
+	defer l.Unlock()
+	p.img.Set(x, y, c)
+}
+
+// Update calls f with the wrapped image while holding the write locks of
+// every row of r, so that f can read and modify the pixels of r, including
+// through typed accessors or Pix, atomically with respect to other users
+// of the SyncImage. f must not touch pixels outside r or call methods of
+// p.
+func (p *SyncImage) Update(r image.Rectangle, f func(img draw.Image)) {
+	locks := p.rowLocks(r)
+	for _, i := range locks {
+		p.locks[i].Lock()
+	}
+	defer func() {
+		for _, i := range locks {
+			p.locks[i].Unlock()
+		}
+	}()
+	f(p.img)
+}
+
+// View is like Update but holds the read locks, so f must only read.
+func (p *SyncImage) View(r image.Rectangle, f func(img image.Image)) {
+	locks := p.rowLocks(r)
+	for _, i := range locks {
+		p.locks[i].RLock()
+	}
+	defer func() {
+		for _, i := range locks {
+			p.locks[i].RUnlock()
+		}
+	}()
+	f(p.img)
+}
+
+// rowLocks returns the indices of the locks guarding the rows of r, in
+// ascending order so that concurrent callers cannot deadlock.
+func (p *SyncImage) rowLocks(r image.Rectangle) []int {
+	r = r.Intersect(p.img.Bounds())
+	n := len(p.locks)
+	if r.Dy() >= n {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	used := make([]bool, n)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := y % n
+		if i < 0 {
+			i += n
+		}
+		used[i] = true
+	}
+	var locks []int
+	for i, u := range used {
+		if u {
+			locks = append(locks, i)
+		}
+	}
+	return locks
+}
+
+// SubImage returns a SyncImage for the portion of the image visible
+// through r, sharing the locks of p so that writes through either are
+// synchronized. If the wrapped image has no SubImage method, the result
+// wraps the whole image.
+func (p *SyncImage) SubImage(r image.Rectangle) image.Image {
+	if s, ok := p.img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		if sub, ok := s.SubImage(r).(draw.Image); ok {
+			return &SyncImage{img: sub, locks: p.locks}
+		}
+	}
+	return p
+}
@@ -0,0 +1,128 @@
+package colorext
+
+import (
+	"image"
+	"image/draw"
+	"sync"
+	"testing"
+)
+
+func TestSyncImageUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		stripes int
+	}{
+		{"per row", 0},
+		{"striped", 3},
+		{"single lock", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NewGrayS16Image(image.Rect(0, -4, 16, 12))
+			s := NewSyncImage(img, tt.stripes)
+			// Workers increment overlapping bands; lost updates would leave
+			// counts short.
+			const workers, rounds = 6, 50
+			var wg sync.WaitGroup
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r := image.Rect(0, -4+w, 16, 4+w)
+					for range rounds {
+						s.Update(r, func(d draw.Image) {
+							g := d.(*GrayS16Image)
+							for y := r.Min.Y; y < r.Max.Y; y++ {
+								for x := r.Min.X; x < r.Max.X; x++ {
+									g.SetGrayS16(x, y, GrayS16{Y: g.GrayS16At(x, y).Y + 1})
+								}
+							}
+						})
+					}
+				}()
+			}
+			wg.Wait()
+			for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+				want := 0
+				for w := range workers {
+					if y >= -4+w && y < 4+w {
+						want += rounds
+					}
+				}
+				if got := int(img.GrayS16At(3, y).Y); got != want {
+					t.Errorf("row %d: %d, want %d", y, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncImageSetAt(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 8, 8))
+	s := NewSyncImage(img, 0)
+	if s.Bounds() != img.Rect || s.ColorModel() != GrayS16Model {
+		t.Fatalf("Bounds %v ColorModel %v", s.Bounds(), s.ColorModel())
+	}
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				s.Set(i%8, (i/8+w)%8, GrayS16{Y: int16(w)})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var n int
+			s.View(s.Bounds(), func(m image.Image) {
+				for y := 0; y < 8; y++ {
+					n += int(m.(*GrayS16Image).GrayS16At(0, y).Y)
+				}
+			})
+			for i := range 200 {
+				_ = s.At(i%8, i/8%8)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sub, ok := s.SubImage(image.Rect(2, 2, 5, 5)).(*SyncImage)
+	if !ok || sub.Bounds() != image.Rect(2, 2, 5, 5) {
+		t.Fatalf("SubImage = %T %v", sub, sub.Bounds())
+	}
+	sub.Set(3, 3, GrayS16{Y: -9})
+	if got := img.GrayS16At(3, 3).Y; got != -9 {
+		t.Errorf("write through sub-image: %d, want -9", got)
+	}
+	if &sub.locks[0] != &s.locks[0] {
+		t.Error("sub-image does not share locks")
+	}
+}
+
+func TestSyncImageRowLocks(t *testing.T) {
+	s := NewSyncImage(NewGrayS16Image(image.Rect(0, -5, 4, 20)), 4)
+	tests := []struct {
+		r    image.Rectangle
+		want []int
+	}{
+		{image.Rect(0, -5, 4, -4), []int{3}},
+		{image.Rect(0, 2, 4, 4), []int{2, 3}},
+		{image.Rect(0, 3, 4, 6), []int{0, 1, 3}},
+		{image.Rect(0, 0, 4, 50), []int{0, 1, 2, 3}},
+		{image.Rect(0, 30, 4, 40), nil},
+	}
+	for _, tt := range tests {
+		got := s.rowLocks(tt.r)
+		if len(got) != len(tt.want) {
+			t.Errorf("%v: locks %v, want %v", tt.r, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%v: locks %v, want %v", tt.r, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// Tensor is a minimal two-dimensional tensor descriptor for handing image
+// samples to data-science tooling such as Arrow or ONNX preprocessing. Data
+// is laid out in row-major order with Shape {height, width} and Strides in
+// bytes; element (i, j) starts at Data[i*Strides[0]+j*Strides[1]].
+type Tensor struct {
+	Shape   []int
+	Strides []int
+	DType   DType
+	// ByteOrder is the byte order of each sample. A nil ByteOrder is
+	// big-endian, the order used by the image types.
+	ByteOrder binary.ByteOrder
+	Data      []byte
+}
+
+// ToTensor returns a Tensor viewing the samples of img, which must be one of
+// the gray image types. Data shares memory with img and holds big-endian
+// samples; a sub-image keeps its parent's row stride. Tensors have no
+// origin, so the position of img's bounds is not recorded.
+func ToTensor(img image.Image) (Tensor, error) {
+	d, pix, stride, ok := grayLayout(img)
+	if !ok {
+		return Tensor{}, fmt.Errorf("%w: cannot convert %T to a tensor", ErrUnsupportedDType, img)
+	}
+	r := img.Bounds()
+	w, h := r.Dx(), r.Dy()
+	n := 0
+	if w > 0 && h > 0 {
+		n = (h-1)*stride + w*d.Size()
+	}
+	return Tensor{
+		Shape:     []int{h, w},
+		Strides:   []int{stride, d.Size()},
+		DType:     d,
+		ByteOrder: binary.BigEndian,
+		Data:      pix[:n:n],
+	}, nil
+}
+
+// FromTensor returns an image holding the samples of t, with bounds starting
+// at the origin. The image shares t.Data when the samples are big-endian and
+// each row is contiguous; otherwise the samples are copied. Nil Strides
+// describe contiguous rows.
+func FromTensor(t Tensor) (image.Image, error) {
+	size := t.DType.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("%w: tensor dtype %v", ErrUnsupportedDType, t.DType)
+	}
+	if len(t.Shape) != 2 {
+		return nil, fmt.Errorf("colorext: tensor has %d dimensions, want 2", len(t.Shape))
+	}
+	h, w := t.Shape[0], t.Shape[1]
+	if h < 0 || w < 0 {
+		return nil, fmt.Errorf("colorext: invalid tensor shape %v", t.Shape)
+	}
+	rowStride, colStride := w*size, size
+	if t.Strides != nil {
+		if len(t.Strides) != 2 {
+			return nil, fmt.Errorf("colorext: tensor has %d strides, want 2", len(t.Strides))
+		}
+		rowStride, colStride = t.Strides[0], t.Strides[1]
+	}
+	// Probe the byte order so that binary.NativeEndian and other
+	// implementations are recognized.
+	little := t.ByteOrder != nil && t.ByteOrder.Uint16([]byte{1, 0}) == 1
+
+	r := image.Rect(0, 0, w, h)
+	if r.Empty() {
+		img, _ := newGrayImage(t.DType, image.Rectangle{})
+		return img, nil
+	}
+	if rowStride < 0 || colStride < size {
+		return nil, fmt.Errorf("%w: tensor strides %v overlap samples or are negative", ErrStrideMismatch, t.Strides)
+	}
+	// The last sample must lie within Data; check without overflowing.
+	last := uint64(h-1)*uint64(rowStride) + uint64(w-1)*uint64(colStride) + uint64(size)
+	if uint64(h-1) > uint64(len(t.Data)) || uint64(w-1) > uint64(len(t.Data)) ||
+		rowStride != 0 && uint64(h-1) > uint64(len(t.Data))/uint64(rowStride) ||
+		uint64(w-1) > uint64(len(t.Data))/uint64(colStride) || last > uint64(len(t.Data)) {
+		return nil, fmt.Errorf("%w: %d tensor bytes are too few for shape %v and strides %v", ErrInvalidLayout, len(t.Data), t.Shape, t.Strides)
+	}
+
+	if !little && colStride == size && rowStride >= w*size {
+		return wrapGray(t.DType, r, t.Data, rowStride), nil
+	}
+	img, pix := newGrayImage(t.DType, r)
+	for y := 0; y < h; y++ {
+		row := pix[y*w*size : (y+1)*w*size]
+		for x := 0; x < w; x++ {
+			copy(row[x*size:(x+1)*size], t.Data[y*rowStride+x*colStride:])
+		}
+	}
+	if little {
+		swapSamples(t.DType, pix)
+	}
+	return img, nil
+}
+
+// wrapGray returns an image of the gray type holding samples of type d that
+// uses pix as its pixel buffer.
+func wrapGray(d DType, r image.Rectangle, pix []uint8, stride int) image.Image {
+	switch d {
+	case DTypeInt16:
+		return &GrayS16Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeFloat32:
+		return &GrayF32Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeUint32:
+		return &GrayU32Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeUint64:
+		return &GrayU64Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeInt64:
+		return &GrayS64Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeComplex64:
+		return &GrayC64Image{Pix: pix, Stride: stride, Rect: r}
+	case DTypeComplex128:
+		return &GrayC128Image{Pix: pix, Stride: stride, Rect: r}
+	}
+	return nil
+}
+
+// swapSamples reverses the byte order of each sample of type d in b. The
+// parts of complex samples are swapped separately.
+func swapSamples(d DType, b []uint8) {
+	switch d {
+	case DTypeInt16:
+		SwapBytes16(b)
+	case DTypeFloat32, DTypeUint32, DTypeComplex64:
+		SwapBytes32(b)
+	default:
+		for i := 0; i+8 <= len(b); i += 8 {
+			binary.BigEndian.PutUint64(b[i:], binary.LittleEndian.Uint64(b[i:]))
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestToTensor(t *testing.T) {
+	img := randomGrayS16(image.Rect(-2, 1, 6, 5), 3)
+	sub := img.SubImage(image.Rect(0, 2, 5, 4)).(*GrayS16Image)
+	tn, err := ToTensor(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tn.Shape[0] != 2 || tn.Shape[1] != 5 || tn.Strides[0] != img.Stride || tn.Strides[1] != 2 || tn.DType != DTypeInt16 {
+		t.Errorf("tensor = shape %v, strides %v, dtype %v", tn.Shape, tn.Strides, tn.DType)
+	}
+	if want := img.Stride + 10; len(tn.Data) != want {
+		t.Errorf("len(Data) = %d, want %d", len(tn.Data), want)
+	}
+	// The tensor views the image's pixels.
+	sub.SetGrayS16(1, 3, GrayS16{Y: -1234})
+	i := 1*tn.Strides[0] + 1*tn.Strides[1]
+	if got := int16(tn.ByteOrder.Uint16(tn.Data[i:])); got != -1234 {
+		t.Errorf("tensor element (1, 1) = %d, want -1234", got)
+	}
+
+	if _, err := ToTensor(image.NewGray(image.Rect(0, 0, 1, 1))); err == nil {
+		t.Error("ToTensor(*image.Gray): expected an error")
+	}
+	if tn, err := ToTensor(NewGrayF32Image(image.Rectangle{})); err != nil || len(tn.Data) != 0 {
+		t.Errorf("empty image: %v, %v", tn, err)
+	}
+}
+
+func TestFromTensorShares(t *testing.T) {
+	src := randomGrayS16(image.Rect(3, 3, 10, 8), 9).SubImage(image.Rect(4, 4, 9, 7))
+	tn, _ := ToTensor(src)
+	img, err := FromTensor(tn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := img.(*GrayS16Image)
+	if got.Rect != image.Rect(0, 0, 5, 3) {
+		t.Fatalf("bounds = %v", got.Rect)
+	}
+	if !Equal(got, shift(src.(*GrayS16Image))) {
+		t.Error("pixels differ from the source")
+	}
+	if &got.Pix[0] != &tn.Data[0] {
+		t.Error("big-endian contiguous rows were copied")
+	}
+}
+
+// shift returns a view of img with its bounds moved to the origin.
+func shift(img *GrayS16Image) *GrayS16Image {
+	return &GrayS16Image{Pix: img.Pix, Stride: img.Stride, Rect: image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy())}
+}
+
+func TestFromTensorCopies(t *testing.T) {
+	// A column-major little-endian float32 tensor, as numpy's
+	// np.asfortranarray would produce.
+	vals := [2][3]float32{{1, 2, 3}, {-4, float32(math.Inf(1)), 0.5}}
+	data := make([]byte, 24)
+	for i := range 2 {
+		for j := range 3 {
+			binary.LittleEndian.PutUint32(data[j*8+i*4:], math.Float32bits(vals[i][j]))
+		}
+	}
+	img, err := FromTensor(Tensor{Shape: []int{2, 3}, Strides: []int{4, 8}, DType: DTypeFloat32, ByteOrder: binary.LittleEndian, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := img.(*GrayF32Image)
+	for i := range 2 {
+		for j := range 3 {
+			if got := f.GrayF32At(j, i).Y; got != vals[i][j] {
+				t.Errorf("(%d, %d) = %v, want %v", j, i, got, vals[i][j])
+			}
+		}
+	}
+
+	// Complex parts are swapped individually.
+	data = make([]byte, 16)
+	binary.LittleEndian.PutUint64(data, math.Float64bits(2))
+	binary.LittleEndian.PutUint64(data[8:], math.Float64bits(-3))
+	img, err = FromTensor(Tensor{Shape: []int{1, 1}, DType: DTypeComplex128, ByteOrder: binary.LittleEndian, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.(*GrayC128Image).GrayC128At(0, 0).Y; got != complex(2, -3) {
+		t.Errorf("complex sample = %v, want (2-3i)", got)
+	}
+}
+
+func TestFromTensorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Tensor
+	}{
+		{"dtype", Tensor{Shape: []int{1, 1}, Data: make([]byte, 2)}},
+		{"rank", Tensor{Shape: []int{1}, DType: DTypeInt16, Data: make([]byte, 2)}},
+		{"negative shape", Tensor{Shape: []int{-1, 2}, DType: DTypeInt16}},
+		{"strides", Tensor{Shape: []int{1, 1}, Strides: []int{2}, DType: DTypeInt16, Data: make([]byte, 2)}},
+		{"overlap", Tensor{Shape: []int{2, 2}, Strides: []int{4, 1}, DType: DTypeInt16, Data: make([]byte, 8)}},
+		{"short", Tensor{Shape: []int{2, 2}, DType: DTypeInt16, Data: make([]byte, 7)}},
+		{"huge", Tensor{Shape: []int{math.MaxInt/2 + 1, 3}, DType: DTypeInt16, Data: make([]byte, 8)}},
+	}
+	for _, tt := range tests {
+		if _, err := FromTensor(tt.t); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if img, err := FromTensor(Tensor{Shape: []int{0, 4}, DType: DTypeUint64}); err != nil || !img.Bounds().Empty() {
+		t.Errorf("empty tensor: %v, %v", img, err)
+	}
+}
+
+func TestDType(t *testing.T) {
+	if DTypeComplex128.Size() != 16 || DTypeInt16.String() != "int16" {
+		t.Errorf("DTypeComplex128.Size() = %d, DTypeInt16 = %v", DTypeComplex128.Size(), DTypeInt16)
+	}
+	if d := DType(0); d.Size() != 0 || d.String() != "DType(0)" {
+		t.Errorf("DType(0) = %v with size %d", d, d.Size())
+	}
+	if d := DType(200); d.Size() != 0 || d.String() != "DType(200)" {
+		t.Errorf("DType(200) = %v with size %d", d, d.Size())
+	}
+}
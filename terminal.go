@@ -0,0 +1,137 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// RenderTerminal returns a preview of img for display in a terminal, as
+// lines of ANSI-colored half-block characters. Each character cell shows
+// two pixels stacked vertically, so the preview keeps the image's aspect
+// ratio in terminals whose cells are twice as tall as they are wide.
+//
+// The image is downsampled by averaging to at most width columns, and the
+// raw sample values are windowed to the range of the preview before being
+// mapped through cmap; a nil cmap renders in grayscale. NaN and invalid
+// pixels are left blank. If trueColor is false the colors are reduced to
+// the xterm 256-color palette for terminals without 24-bit color support.
+func RenderTerminal(img image.Image, width int, cmap Colormap, trueColor bool) string {
+	r := img.Bounds()
+	if r.Empty() || width <= 0 {
+		return ""
+	}
+	if cmap == nil {
+		cmap = LinearColormap{{0, 0, 0, 0xff}, {0xff, 0xff, 0xff, 0xff}}
+	}
+	w := min(width, r.Dx())
+	h := max(int(math.Round(float64(r.Dy())*float64(w)/float64(r.Dx()))), 1)
+
+	// Average the samples of each preview pixel.
+	at := scalarSampler(img)
+	vals := make([]float64, w*h)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for py := 0; py < h; py++ {
+		y0, y1 := r.Min.Y+py*r.Dy()/h, r.Min.Y+(py+1)*r.Dy()/h
+		for px := 0; px < w; px++ {
+			x0, x1 := r.Min.X+px*r.Dx()/w, r.Min.X+(px+1)*r.Dx()/w
+			sum, n := 0.0, 0
+			for y := y0; y < max(y1, y0+1); y++ {
+				for x := x0; x < max(x1, x0+1); x++ {
+					if v := at(x, y); !math.IsNaN(v) {
+						sum += v
+						n++
+					}
+				}
+			}
+			v := math.NaN()
+			if n > 0 {
+				v = sum / float64(n)
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			vals[py*w+px] = v
+		}
+	}
+
+	pixel := func(px, py int) (color.RGBA, bool) {
+		if py >= h || math.IsNaN(vals[py*w+px]) {
+			return color.RGBA{}, false
+		}
+		t := 0.0
+		if hi > lo {
+			t = (vals[py*w+px] - lo) / (hi - lo)
+		}
+		return cmap.Map(t), true
+	}
+	var sb strings.Builder
+	for py := 0; py < h; py += 2 {
+		for px := 0; px < w; px++ {
+			top, topOK := pixel(px, py)
+			bot, botOK := pixel(px, py+1)
+			switch {
+			case topOK && botOK:
+				sb.WriteString(ansiColor(top, 38, trueColor))
+				sb.WriteString(ansiColor(bot, 48, trueColor))
+				sb.WriteString("▀")
+			case topOK:
+				sb.WriteString(ansiColor(top, 38, trueColor))
+				sb.WriteString("\x1b[49m▀")
+			case botOK:
+				sb.WriteString(ansiColor(bot, 38, trueColor))
+				sb.WriteString("\x1b[49m▄")
+			default:
+				sb.WriteString("\x1b[39;49m ")
+			}
+		}
+		sb.WriteString("\x1b[0m\n")
+	}
+	return sb.String()
+}
+
+// ansiColor returns the escape sequence setting the foreground (layer 38)
+// or background (layer 48) color to c.
+func ansiColor(c color.RGBA, layer int, trueColor bool) string {
+	if trueColor {
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("\x1b[%d;5;%dm", layer, xterm256(c))
+}
+
+// xterm256 returns the index of the closest color to c in the 6×6×6 color
+// cube or the gray ramp of the xterm 256-color palette.
+func xterm256(c color.RGBA) int {
+	// The cube levels are 0, 95, 135, 175, 215 and 255.
+	level := func(v uint8) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return int(v-35) / 40
+	}
+	value := func(l int) int {
+		if l == 0 {
+			return 0
+		}
+		return 55 + 40*l
+	}
+	dist := func(r, g, b int) int {
+		dr, dg, db := int(c.R)-r, int(c.G)-g, int(c.B)-b
+		return dr*dr + dg*dg + db*db
+	}
+	lr, lg, lb := level(c.R), level(c.G), level(c.B)
+	cube := 16 + 36*lr + 6*lg + lb
+	cubeDist := dist(value(lr), value(lg), value(lb))
+
+	// The gray ramp runs from 8 to 238 in steps of 10.
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	g := min(max((avg-3)/10, 0), 23)
+	gv := 8 + 10*g
+	if dist(gv, gv, gv) < cubeDist {
+		return 232 + g
+	}
+	return cube
+}
@@ -0,0 +1,63 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestRenderTerminal(t *testing.T) {
+	// A 4×4 ramp downsampled to 2×2 yields one line of two cells.
+	img := NewGrayS16Image(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetGrayS16(x, y, GrayS16{Y: int16(-1000 + 1000*(y/2))})
+		}
+	}
+	img.SetGrayS16(3, 0, GrayS16{Y: -1000})
+	got := RenderTerminal(img, 2, nil, true)
+	want := "\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m▀" +
+		"\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m▀\x1b[0m\n"
+	if got != want {
+		t.Errorf("RenderTerminal = %q, want %q", got, want)
+	}
+
+	got = RenderTerminal(img, 2, Viridis, false)
+	if !strings.Contains(got, "\x1b[38;5;") || strings.Contains(got, ";2;") {
+		t.Errorf("256-color output = %q", got)
+	}
+
+	// Invalid pixels are blank, and odd heights leave the bottom half empty.
+	m := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 2, 3)), 0)
+	m.SetGrayS16(0, 0, GrayS16{Y: 5})
+	m.SetGrayS16(1, 1, GrayS16{Y: 7})
+	got = RenderTerminal(m, 10, nil, true)
+	want = "\x1b[38;2;0;0;0m\x1b[49m▀\x1b[38;2;255;255;255m\x1b[49m▄\x1b[0m\n" +
+		"\x1b[39;49m \x1b[39;49m \x1b[0m\n"
+	if got != want {
+		t.Errorf("masked RenderTerminal = %q, want %q", got, want)
+	}
+
+	if got := RenderTerminal(NewGrayS16Image(image.Rectangle{}), 10, nil, true); got != "" {
+		t.Errorf("empty image = %q", got)
+	}
+}
+
+func TestXterm256(t *testing.T) {
+	tests := []struct {
+		c    color.RGBA
+		want int
+	}{
+		{color.RGBA{0, 0, 0, 0xff}, 16},
+		{color.RGBA{0xff, 0xff, 0xff, 0xff}, 231},
+		{color.RGBA{0xff, 0, 0, 0xff}, 196},
+		{color.RGBA{95, 135, 175, 0xff}, 67},
+		{color.RGBA{128, 128, 128, 0xff}, 244},
+	}
+	for _, tt := range tests {
+		if got := xterm256(tt.c); got != tt.want {
+			t.Errorf("xterm256(%v) = %d, want %d", tt.c, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package colorext
+
+import (
+	"image"
+	"math"
+)
+
+// Hillshade computes a shaded relief image of dem lit from the given sun
+// position. azimuth is the compass direction of the light source in degrees
+// clockwise from north (up), altitude its elevation above the horizon in
+// degrees, and cellSize the ground distance between adjacent pixels in the
+// same units as the elevation values. Gradients use Horn's method and edge
+// pixels replicate their nearest neighbors.
+func Hillshade(dem *GrayS16Image, azimuth, altitude, cellSize float64) *image.Gray {
+	dst := image.NewGray(dem.Rect)
+	zenith := (90 - altitude) * math.Pi / 180
+	// Convert the compass azimuth to a math angle measured counterclockwise from east.
+	az := math.Mod(360-azimuth+90, 360) * math.Pi / 180
+	cosZ, sinZ := math.Cos(zenith), math.Sin(zenith)
+
+	for y := dem.Rect.Min.Y; y < dem.Rect.Max.Y; y++ {
+		for x := dem.Rect.Min.X; x < dem.Rect.Max.X; x++ {
+			dzdx, dzdy := hornGradient(dem, x, y, cellSize)
+			slope := math.Atan(math.Hypot(dzdx, dzdy))
+			aspect := 0.0
+			switch {
+			case dzdx != 0:
+				aspect = math.Atan2(dzdy, -dzdx)
+				if aspect < 0 {
+					aspect += 2 * math.Pi
+				}
+			case dzdy > 0:
+				aspect = math.Pi / 2
+			case dzdy < 0:
+				aspect = 3 * math.Pi / 2
+			}
+			v := 255 * (cosZ*math.Cos(slope) + sinZ*math.Sin(slope)*math.Cos(az-aspect))
+			dst.Pix[dst.PixOffset(x, y)] = uint8(math.Max(0, math.Min(255, v)) + 0.5)
+		}
+	}
+	return dst
+}
+
+// Slope returns the terrain slope of dem in degrees, from 0 for flat ground
+// to 90 for a vertical wall. cellSize is the ground distance between adjacent
+// pixels in the same units as the elevation values.
+func Slope(dem *GrayS16Image, cellSize float64) *GrayF32Image {
+	dst := NewGrayF32Image(dem.Rect)
+	for y := dem.Rect.Min.Y; y < dem.Rect.Max.Y; y++ {
+		for x := dem.Rect.Min.X; x < dem.Rect.Max.X; x++ {
+			dzdx, dzdy := hornGradient(dem, x, y, cellSize)
+			deg := math.Atan(math.Hypot(dzdx, dzdy)) * 180 / math.Pi
+			dst.SetGrayF32(x, y, GrayF32{Y: float32(deg)})
+		}
+	}
+	return dst
+}
+
+// Aspect returns the compass direction that each pixel of dem faces, in
+// degrees clockwise from north (up) in the range [0, 360). Flat pixels have
+// no defined aspect and are set to NaN.
+func Aspect(dem *GrayS16Image) *GrayF32Image {
+	dst := NewGrayF32Image(dem.Rect)
+	for y := dem.Rect.Min.Y; y < dem.Rect.Max.Y; y++ {
+		for x := dem.Rect.Min.X; x < dem.Rect.Max.X; x++ {
+			// The cell size scales both gradients equally and cancels out.
+			dzdx, dzdy := hornGradient(dem, x, y, 1)
+			deg := float32(math.NaN())
+			if dzdx != 0 || dzdy != 0 {
+				a := math.Atan2(dzdy, -dzdx) * 180 / math.Pi
+				c := 90 - a
+				if a > 90 {
+					c += 360
+				}
+				deg = float32(math.Mod(c, 360))
+			}
+			dst.SetGrayF32(x, y, GrayF32{Y: deg})
+		}
+	}
+	return dst
+}
+
+// hornGradient returns the elevation gradient of dem at (x, y) using Horn's
+// 3x3 weighted difference. dzdx increases to the east (right) and dzdy to the
+// south (down). Neighbors outside the image are clamped to the nearest edge.
+func hornGradient(dem *GrayS16Image, x, y int, cellSize float64) (dzdx, dzdy float64) {
+	r := dem.Rect
+	z := func(dx, dy int) float64 {
+		px := min(max(x+dx, r.Min.X), r.Max.X-1)
+		py := min(max(y+dy, r.Min.Y), r.Max.Y-1)
+		return float64(dem.GrayS16At(px, py).Y)
+	}
+	a, b, c := z(-1, -1), z(0, -1), z(1, -1)
+	d, f := z(-1, 0), z(1, 0)
+	g, h, i := z(-1, 1), z(0, 1), z(1, 1)
+	dzdx = ((c + 2*f + i) - (a + 2*d + g)) / (8 * cellSize)
+	dzdy = ((g + 2*h + i) - (a + 2*b + c)) / (8 * cellSize)
+	return dzdx, dzdy
+}
@@ -0,0 +1,95 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// rampDEM returns a 5x5 DEM whose elevation changes by dx per column and dy
+// per row.
+func rampDEM(dx, dy int16) *GrayS16Image {
+	dem := NewGrayS16Image(image.Rect(0, 0, 5, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			dem.SetGrayS16(x, y, GrayS16{Y: int16(x)*dx + int16(y)*dy})
+		}
+	}
+	return dem
+}
+
+func TestSlope(t *testing.T) {
+	tests := []struct {
+		name     string
+		dem      *GrayS16Image
+		cellSize float64
+		want     float64
+	}{
+		{"flat", rampDEM(0, 0), 1, 0},
+		{"45 degrees", rampDEM(10, 0), 10, 45},
+		{"steep north-south", rampDEM(0, -30), 10, math.Atan(3) * 180 / math.Pi},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slope(tt.dem, tt.cellSize).GrayF32At(2, 2).Y
+			if math.Abs(float64(got)-tt.want) > 1e-4 {
+				t.Errorf("Slope at center = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAspect(t *testing.T) {
+	tests := []struct {
+		name string
+		dem  *GrayS16Image
+		want float64
+	}{
+		{"faces east", rampDEM(-10, 0), 90},
+		{"faces west", rampDEM(10, 0), 270},
+		{"faces south", rampDEM(0, -10), 180},
+		{"faces north", rampDEM(0, 10), 0},
+		{"faces north-east", rampDEM(-10, 10), 45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Aspect(tt.dem).GrayF32At(2, 2).Y
+			if math.Abs(float64(got)-tt.want) > 1e-4 {
+				t.Errorf("Aspect at center = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Aspect(rampDEM(0, 0)).GrayF32At(2, 2).Y; !math.IsNaN(float64(got)) {
+		t.Errorf("Aspect of flat ground = %v, want NaN", got)
+	}
+}
+
+func TestHillshade(t *testing.T) {
+	// Flat ground lit from 45 degrees gives 255*cos(45°).
+	flat := Hillshade(rampDEM(0, 0), 315, 45, 1)
+	if got := flat.GrayAt(2, 2).Y; got != 180 {
+		t.Errorf("flat Hillshade = %d, want 180", got)
+	}
+	if flat.Bounds() != image.Rect(0, 0, 5, 5) {
+		t.Errorf("Bounds() = %v, want %v", flat.Bounds(), image.Rect(0, 0, 5, 5))
+	}
+
+	// A slope facing the sun is brighter than one facing away.
+	east := Hillshade(rampDEM(-10, 0), 90, 30, 10).GrayAt(2, 2).Y
+	west := Hillshade(rampDEM(10, 0), 90, 30, 10).GrayAt(2, 2).Y
+	if east <= west {
+		t.Errorf("sun-facing slope %d not brighter than shaded slope %d", east, west)
+	}
+	if west != 0 {
+		t.Errorf("slope facing away from a 30° sun at 45° pitch = %d, want 0", west)
+	}
+
+	// Overhead light depends only on slope.
+	top := Hillshade(rampDEM(10, 0), 0, 90, 10).GrayAt(2, 2).Y
+	if want := uint8(255*math.Cos(math.Pi/4) + 0.5); top != want {
+		t.Errorf("overhead Hillshade = %d, want %d", top, want)
+	}
+}
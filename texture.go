@@ -0,0 +1,163 @@
+package colorext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// TextureFormat describes how to upload an image as a GPU texture. The
+// OpenGL fields are the arguments of glTexImage2D and VkFormat is the
+// matching Vulkan format, or 0 (VK_FORMAT_UNDEFINED) if Vulkan has none.
+type TextureFormat struct {
+	InternalFormat uint32
+	Format         uint32
+	Type           uint32
+	VkFormat       uint32
+	// RowLength is the distance between rows of the image's Pix in pixels,
+	// for GL_UNPACK_ROW_LENGTH or VkBufferImageCopy.bufferRowLength. It is 0
+	// if the stride is not a whole number of pixels.
+	RowLength int
+}
+
+// OpenGL enum values.
+const (
+	glUnsignedByte  = 0x1401
+	glShort         = 0x1402
+	glUnsignedShort = 0x1403
+	glUnsignedInt   = 0x1405
+	glFloat         = 0x1406
+	glRed           = 0x1903
+	glRGBA          = 0x1908
+	glRGB8          = 0x8051
+	glRGB16         = 0x8054
+	glRGBA8         = 0x8058
+	glRGBA16        = 0x805B
+	glBGR           = 0x80E0
+	glBGRA          = 0x80E1
+	glRG            = 0x8227
+	glR8            = 0x8229
+	glR16           = 0x822A
+	glRG32F         = 0x8230
+	glR32F          = 0x822E
+	glR32UI         = 0x8236
+	glRGBA32F       = 0x8814
+	glRedInteger    = 0x8D94
+	glR16SNorm      = 0x8F98
+)
+
+// Vulkan VkFormat values.
+const (
+	vkR8UNorm            = 9
+	vkB8G8R8UNorm        = 30
+	vkR8G8B8A8UNorm      = 37
+	vkB8G8R8A8UNorm      = 44
+	vkR16UNorm           = 70
+	vkR16SNorm           = 71
+	vkR16G16B16A16UNorm  = 91
+	vkR32UInt            = 98
+	vkR32SFloat          = 100
+	vkR32G32SFloat       = 103
+	vkR32G32B32A32SFloat = 109
+)
+
+// textureFormat returns the texture format of img and the size of the
+// components whose byte order must match the host's.
+func textureFormat(img image.Image) (f TextureFormat, word int, ok bool) {
+	switch img.(type) {
+	case *GrayS16Image:
+		return TextureFormat{glR16SNorm, glRed, glShort, vkR16SNorm, 0}, 2, true
+	case *GrayF32Image:
+		return TextureFormat{glR32F, glRed, glFloat, vkR32SFloat, 0}, 4, true
+	case *GrayU32Image:
+		return TextureFormat{glR32UI, glRedInteger, glUnsignedInt, vkR32UInt, 0}, 4, true
+	case *GrayC64Image:
+		return TextureFormat{glRG32F, glRG, glFloat, vkR32G32SFloat, 0}, 4, true
+	case *RGBAF32Image, *NRGBAF32Image, *LinearRGBAF32Image:
+		return TextureFormat{glRGBA32F, glRGBA, glFloat, vkR32G32B32A32SFloat, 0}, 4, true
+	case *BGRImage:
+		return TextureFormat{glRGB8, glBGR, glUnsignedByte, vkB8G8R8UNorm, 0}, 1, true
+	case *BGRAImage:
+		return TextureFormat{glRGBA8, glBGRA, glUnsignedByte, vkB8G8R8A8UNorm, 0}, 1, true
+	case *BGR48Image:
+		return TextureFormat{glRGB16, glBGR, glUnsignedShort, 0, 0}, 2, true
+	case *BGRA64Image:
+		return TextureFormat{glRGBA16, glBGRA, glUnsignedShort, 0, 0}, 2, true
+	case *image.Gray:
+		return TextureFormat{glR8, glRed, glUnsignedByte, vkR8UNorm, 0}, 1, true
+	case *image.Gray16:
+		return TextureFormat{glR16, glRed, glUnsignedShort, vkR16UNorm, 0}, 2, true
+	case *image.RGBA, *image.NRGBA:
+		return TextureFormat{glRGBA8, glRGBA, glUnsignedByte, vkR8G8B8A8UNorm, 0}, 1, true
+	case *image.RGBA64, *image.NRGBA64:
+		return TextureFormat{glRGBA16, glRGBA, glUnsignedShort, vkR16G16B16A16UNorm, 0}, 2, true
+	}
+	return TextureFormat{}, 0, false
+}
+
+// Describe returns the texture format matching img. GrayS16Image maps to
+// R16_SNORM, so values are normalized to [-1, 1] when sampled; GrayF32Image
+// maps to R32F and GrayC64Image to RG32F.
+//
+// Samples wider than a byte are stored big-endian, while GPUs expect the
+// host's byte order, so on little-endian machines such images must be
+// uploaded from PixForUpload rather than from Pix.
+func Describe(img image.Image) (TextureFormat, error) {
+	f, _, ok := textureFormat(img)
+	if !ok {
+		return TextureFormat{}, fmt.Errorf("%w: no texture format for %T", ErrUnsupportedDType, img)
+	}
+	_, stride, bpp, _ := pixelLayout(img)
+	if stride%bpp == 0 {
+		f.RowLength = stride / bpp
+	}
+	return f, nil
+}
+
+// nativeLittleEndian reports whether the host stores values little-endian.
+var nativeLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// PixForUpload returns the pixels of img in the host's byte order, with each
+// row padded to a multiple of alignment bytes. This is the layout OpenGL
+// reads with GL_UNPACK_ALIGNMENT set to alignment and GL_UNPACK_ROW_LENGTH
+// set to 0; alignment must be 1, 2, 4 or 8. Pix is returned without copying
+// when it already has this layout.
+func PixForUpload(img image.Image, alignment int) ([]byte, error) {
+	switch alignment {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("colorext: invalid texture row alignment %d", alignment)
+	}
+	_, word, ok := textureFormat(img)
+	if !ok {
+		return nil, fmt.Errorf("%w: no texture format for %T", ErrUnsupportedDType, img)
+	}
+	pix, stride, bpp, _ := pixelLayout(img)
+	r := img.Bounds()
+	w, h := r.Dx(), r.Dy()
+	if w <= 0 || h <= 0 {
+		return nil, nil
+	}
+	n := w * bpp
+	padded := (n + alignment - 1) / alignment * alignment
+	swap := word > 1 && nativeLittleEndian
+	if !swap && stride == padded && len(pix) >= padded*h {
+		return pix[: padded*h : padded*h], nil
+	}
+
+	dst := make([]byte, padded*h)
+	for y := 0; y < h; y++ {
+		row := dst[y*padded : y*padded+n]
+		copy(row, pix[y*stride:])
+		if !swap {
+			continue
+		}
+		switch word {
+		case 2:
+			SwapBytes16(row)
+		case 4:
+			SwapBytes32(row)
+		}
+	}
+	return dst, nil
+}
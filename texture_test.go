@@ -0,0 +1,90 @@
+package colorext
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		img  image.Image
+		want TextureFormat
+	}{
+		{NewGrayS16Image(image.Rect(0, 0, 5, 2)), TextureFormat{glR16SNorm, glRed, glShort, vkR16SNorm, 5}},
+		{NewGrayF32Image(image.Rect(0, 0, 3, 2)), TextureFormat{glR32F, glRed, glFloat, vkR32SFloat, 3}},
+		{NewGrayC64Image(image.Rect(0, 0, 3, 2)), TextureFormat{glRG32F, glRG, glFloat, vkR32G32SFloat, 3}},
+		{NewBGRAImage(image.Rect(0, 0, 2, 2)), TextureFormat{glRGBA8, glBGRA, glUnsignedByte, vkB8G8R8A8UNorm, 2}},
+		{NewBGR48Image(image.Rect(0, 0, 2, 2)), TextureFormat{glRGB16, glBGR, glUnsignedShort, 0, 2}},
+		{image.NewGray16(image.Rect(0, 0, 4, 1)), TextureFormat{glR16, glRed, glUnsignedShort, vkR16UNorm, 4}},
+		// A sub-image keeps its parent's row length.
+		{NewGrayS16Image(image.Rect(0, 0, 9, 4)).SubImage(image.Rect(2, 1, 4, 3)), TextureFormat{glR16SNorm, glRed, glShort, vkR16SNorm, 9}},
+	}
+	for _, tt := range tests {
+		got, err := Describe(tt.img)
+		if err != nil {
+			t.Fatalf("%T: %v", tt.img, err)
+		}
+		if got != tt.want {
+			t.Errorf("Describe(%T) = %+v, want %+v", tt.img, got, tt.want)
+		}
+	}
+	if _, err := Describe(NewGrayC128Image(image.Rect(0, 0, 1, 1))); err == nil {
+		t.Error("Describe(*GrayC128Image): expected an error")
+	}
+}
+
+func TestPixForUpload(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 2))
+	for i := range 6 {
+		img.SetGrayS16(i%3, i/3, GrayS16{Y: int16(0x0102 * (i + 1))})
+	}
+	got, err := PixForUpload(img, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []byte
+	for y := range 2 {
+		for x := range 3 {
+			v := uint16(img.GrayS16At(x, y).Y)
+			if nativeLittleEndian {
+				want = append(want, byte(v), byte(v>>8))
+			} else {
+				want = append(want, byte(v>>8), byte(v))
+			}
+		}
+		want = append(want, 0, 0) // 6 bytes padded to 8
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("PixForUpload = % x, want % x", got, want)
+	}
+
+	// Byte data with matching row padding is returned without copying.
+	gray := image.NewGray(image.Rect(0, 0, 8, 3))
+	if got, _ := PixForUpload(gray, 4); &got[0] != &gray.Pix[0] || len(got) != 24 {
+		t.Error("aligned *image.Gray pixels were copied")
+	}
+	// Odd widths are padded.
+	gray = image.NewGray(image.Rect(0, 0, 3, 2))
+	gray.Pix = []byte{1, 2, 3, 4, 5, 6}
+	if got, _ := PixForUpload(gray, 2); !bytes.Equal(got, []byte{1, 2, 3, 0, 4, 5, 6, 0}) {
+		t.Errorf("padded *image.Gray = %v", got)
+	}
+	// A sub-image whose stride matches the padding but whose last row ends
+	// at the buffer's end is copied rather than over-sliced.
+	gray = image.NewGray(image.Rect(0, 0, 4, 2))
+	sub := gray.SubImage(image.Rect(1, 0, 4, 2))
+	if got, err := PixForUpload(sub, 4); err != nil || len(got) != 8 {
+		t.Errorf("sub-image upload = %d bytes, %v; want 8", len(got), err)
+	}
+
+	if _, err := PixForUpload(img, 3); err == nil {
+		t.Error("alignment 3: expected an error")
+	}
+	if _, err := PixForUpload(image.NewCMYK(image.Rect(0, 0, 1, 1)), 1); err == nil {
+		t.Error("*image.CMYK: expected an error")
+	}
+	if got, err := PixForUpload(NewGrayF32Image(image.Rectangle{}), 4); err != nil || got != nil {
+		t.Errorf("empty image = %v, %v", got, err)
+	}
+}
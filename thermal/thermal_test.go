@@ -0,0 +1,97 @@
+package thermal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/gracefulearth/go-colorext"
+)
+
+func TestLinear_Temperature(t *testing.T) {
+	c := Linear{Gain: 0.01, Offset: ZeroCelsius}
+	if got := c.Temperature(2500); math.Abs(got-(ZeroCelsius+25)) > 1e-9 {
+		t.Errorf("Temperature(2500) = %v, want %v", got, ZeroCelsius+25)
+	}
+}
+
+func TestPlanck_Temperature(t *testing.T) {
+	c := Planck{R1: 21106.77, R2: 0.012545258, B: 1501, F: 1, O: -7340}
+
+	// Invert the formula to find the counts for 300 K and check round-trip.
+	want := 300.0
+	counts := c.R1/(c.R2*(math.Exp(c.B/want)-c.F)) - c.O
+	if got := c.Temperature(counts); math.Abs(got-want) > 1e-6 {
+		t.Errorf("Temperature(%v) = %v, want %v", counts, got, want)
+	}
+
+	// Counts below the offset are outside the calibrated range.
+	if got := c.Temperature(0); !math.IsNaN(got) {
+		t.Errorf("Temperature(0) = %v, want NaN", got)
+	}
+
+	// Temperature increases monotonically with counts.
+	if c.Temperature(counts+100) <= c.Temperature(counts) {
+		t.Error("Temperature is not increasing in counts")
+	}
+}
+
+func TestToTemperature(t *testing.T) {
+	cal := Linear{Gain: 0.5, Offset: 100}
+
+	s16 := colorext.NewGrayS16Image(image.Rect(0, 0, 2, 1))
+	s16.SetGrayS16(0, 0, colorext.GrayS16{Y: -100})
+	s16.SetGrayS16(1, 0, colorext.GrayS16{Y: 200})
+	got, err := ToTemperature(s16, cal)
+	if err != nil {
+		t.Fatalf("ToTemperature(GrayS16Image): %v", err)
+	}
+	if v := got.GrayF32At(0, 0).Y; v != 50 {
+		t.Errorf("GrayF32At(0, 0) = %v, want 50", v)
+	}
+	if v := got.GrayF32At(1, 0).Y; v != 200 {
+		t.Errorf("GrayF32At(1, 0) = %v, want 200", v)
+	}
+
+	u16 := image.NewGray16(image.Rect(0, 0, 1, 1))
+	u16.SetGray16(0, 0, color.Gray16{Y: 60000})
+	got, err = ToTemperature(u16, cal)
+	if err != nil {
+		t.Fatalf("ToTemperature(Gray16): %v", err)
+	}
+	if v := got.GrayF32At(0, 0).Y; v != 30100 {
+		t.Errorf("GrayF32At(0, 0) = %v, want 30100", v)
+	}
+
+	if _, err := ToTemperature(image.NewRGBA(image.Rect(0, 0, 1, 1)), cal); !errors.Is(err, colorext.ErrUnsupportedDType) {
+		t.Errorf("ToTemperature(RGBA) = %v, want %v", err, colorext.ErrUnsupportedDType)
+	}
+}
+
+func TestOverlayIsotherms(t *testing.T) {
+	temp := colorext.NewGrayF32Image(image.Rect(0, 0, 3, 1))
+	temp.SetGrayF32(0, 0, colorext.GrayF32{Y: 290})
+	temp.SetGrayF32(1, 0, colorext.GrayF32{Y: 310})
+	temp.SetGrayF32(2, 0, colorext.GrayF32{Y: 350})
+
+	dst := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	for i := range dst.Pix {
+		dst.Pix[i] = 0xff
+	}
+	OverlayIsotherms(dst, temp,
+		Isotherm{Min: 300, Max: 320, Color: color.RGBA{R: 255, A: 255}},
+		Isotherm{Min: 340, Max: 400, Color: color.RGBA{B: 255, A: 128}},
+	)
+
+	if got := dst.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel outside isotherms = %v, want white", got)
+	}
+	if got := dst.RGBAAt(1, 0); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("opaque isotherm pixel = %v, want red", got)
+	}
+	if got := dst.RGBAAt(2, 0); got != (color.RGBA{127, 127, 255, 255}) {
+		t.Errorf("translucent isotherm pixel = %v, want {127 127 255 255}", got)
+	}
+}
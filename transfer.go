@@ -0,0 +1,199 @@
+package colorext
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// TransferFunction converts between linear light and an encoded signal.
+type TransferFunction interface {
+	// Encode maps a linear light value to its encoded signal value.
+	Encode(v float64) float64
+	// Decode maps an encoded signal value back to linear light.
+	Decode(v float64) float64
+}
+
+// Gamma is a pure power law transfer function: Decode(v) = v^Gamma.
+// Negative values are mirrored around zero.
+type Gamma float64
+
+// Encode returns v^(1/g).
+func (g Gamma) Encode(v float64) float64 {
+	return mirrored(v, func(v float64) float64 { return math.Pow(v, 1/float64(g)) })
+}
+
+// Decode returns v^g.
+func (g Gamma) Decode(v float64) float64 {
+	return mirrored(v, func(v float64) float64 { return math.Pow(v, float64(g)) })
+}
+
+// Built-in transfer functions.
+var (
+	// TransferLinear leaves values unchanged.
+	TransferLinear TransferFunction = linearTransfer{}
+	// TransferSRGB is the piecewise sRGB curve of IEC 61966-2-1. Negative
+	// values are mirrored around zero, as in extended-range scRGB.
+	TransferSRGB TransferFunction = srgbTransfer{}
+	// TransferBT709 is the ITU-R BT.709 OETF, also used by BT.2020 for
+	// 10-bit video. Negative values are mirrored around zero.
+	TransferBT709 TransferFunction = bt709Transfer{}
+	// TransferPQ is the SMPTE ST 2084 perceptual quantizer used by HDR10.
+	// Linear values are normalized so that 1 corresponds to 10000 cd/m².
+	// Negative inputs are clamped to zero.
+	TransferPQ TransferFunction = pqTransfer{}
+	// TransferHLG is the ITU-R BT.2100 hybrid log-gamma OETF. Linear values
+	// are normalized scene light in [0, 1]; Decode is the inverse OETF and
+	// does not apply the display-dependent OOTF. Negative inputs are
+	// clamped to zero.
+	TransferHLG TransferFunction = hlgTransfer{}
+)
+
+type linearTransfer struct{}
+
+func (linearTransfer) Encode(v float64) float64 { return v }
+func (linearTransfer) Decode(v float64) float64 { return v }
+
+type srgbTransfer struct{}
+
+func (srgbTransfer) Encode(v float64) float64 { return mirrored(v, linearToSRGB) }
+func (srgbTransfer) Decode(v float64) float64 { return mirrored(v, srgbToLinear) }
+
+// ITU-R BT.709 OETF constants, given to the precision of BT.2020.
+const (
+	bt709Alpha = 1.09929682680944
+	bt709Beta  = 0.018053968510807
+)
+
+type bt709Transfer struct{}
+
+func (bt709Transfer) Encode(v float64) float64 {
+	return mirrored(v, func(v float64) float64 {
+		if v < bt709Beta {
+			return 4.5 * v
+		}
+		return bt709Alpha*math.Pow(v, 0.45) - (bt709Alpha - 1)
+	})
+}
+
+func (bt709Transfer) Decode(v float64) float64 {
+	return mirrored(v, func(v float64) float64 {
+		if v < 4.5*bt709Beta {
+			return v / 4.5
+		}
+		return math.Pow((v+bt709Alpha-1)/bt709Alpha, 1/0.45)
+	})
+}
+
+// SMPTE ST 2084 constants.
+const (
+	pqM1 = 2610.0 / 16384
+	pqM2 = 2523.0 / 4096 * 128
+	pqC1 = 3424.0 / 4096
+	pqC2 = 2413.0 / 4096 * 32
+	pqC3 = 2392.0 / 4096 * 32
+)
+
+type pqTransfer struct{}
+
+func (pqTransfer) Encode(v float64) float64 {
+	y := math.Pow(math.Max(v, 0), pqM1)
+	return math.Pow((pqC1+pqC2*y)/(1+pqC3*y), pqM2)
+}
+
+func (pqTransfer) Decode(v float64) float64 {
+	e := math.Pow(math.Max(v, 0), 1/pqM2)
+	return math.Pow(math.Max(e-pqC1, 0)/(pqC2-pqC3*e), 1/pqM1)
+}
+
+// ITU-R BT.2100 HLG constants.
+const (
+	hlgA = 0.17883277
+	hlgB = 1 - 4*hlgA
+	hlgC = 0.55991073 // 0.5 - a*ln(4a)
+)
+
+type hlgTransfer struct{}
+
+func (hlgTransfer) Encode(v float64) float64 {
+	v = math.Max(v, 0)
+	if v <= 1.0/12 {
+		return math.Sqrt(3 * v)
+	}
+	return hlgA*math.Log(12*v-hlgB) + hlgC
+}
+
+func (hlgTransfer) Decode(v float64) float64 {
+	v = math.Max(v, 0)
+	if v <= 0.5 {
+		return v * v / 3
+	}
+	return (math.Exp((v-hlgC)/hlgA) + hlgB) / 12
+}
+
+// mirrored applies f to |v| and restores the sign of v.
+func mirrored(v float64, f func(float64) float64) float64 {
+	if v < 0 {
+		return -f(-v)
+	}
+	return f(v)
+}
+
+// TransferDirection selects which way ApplyTransfer converts values.
+type TransferDirection int
+
+const (
+	// TransferEncode converts linear light to encoded signal values.
+	TransferEncode TransferDirection = iota
+	// TransferDecode converts encoded signal values to linear light.
+	TransferDecode
+)
+
+// ApplyTransfer writes the transfer function tf, applied in direction dir
+// to every pixel of src, into dst over the intersection of their bounds. dst
+// and src may be the same image.
+//
+// dst and src must both be *GrayF32Image or both be *LinearRGBAF32Image. For
+// the latter, color components are unpremultiplied before the conversion
+// and alpha is left unchanged.
+func ApplyTransfer(dst, src image.Image, tf TransferFunction, dir TransferDirection) error {
+	f := tf.Encode
+	if dir == TransferDecode {
+		f = tf.Decode
+	}
+	r := dst.Bounds().Intersect(src.Bounds())
+
+	switch s := src.(type) {
+	case *GrayF32Image:
+		d, ok := dst.(*GrayF32Image)
+		if !ok {
+			break
+		}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				v := s.GrayF32At(x, y).Y
+				d.SetGrayF32(x, y, GrayF32{Y: float32(f(float64(v)))})
+			}
+		}
+		return nil
+	case *LinearRGBAF32Image:
+		d, ok := dst.(*LinearRGBAF32Image)
+		if !ok {
+			break
+		}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				c := s.LinearRGBAF32At(x, y)
+				if c.A == 0 {
+					d.SetLinearRGBAF32(x, y, c)
+					continue
+				}
+				a := float64(c.A)
+				conv := func(v float32) float32 { return float32(f(float64(v)/a) * a) }
+				d.SetLinearRGBAF32(x, y, LinearRGBAF32{conv(c.R), conv(c.G), conv(c.B), c.A})
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: ApplyTransfer requires matching *GrayF32Image or *LinearRGBAF32Image images", ErrUnsupportedDType)
+}
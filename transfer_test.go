@@ -0,0 +1,130 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestTransferFunctions_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		tf   TransferFunction
+	}{
+		{"linear", TransferLinear},
+		{"gamma 2.2", Gamma(2.2)},
+		{"sRGB", TransferSRGB},
+		{"BT.709", TransferBT709},
+		{"PQ", TransferPQ},
+		{"HLG", TransferHLG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for v := 0.0; v <= 1; v += 1.0 / 64 {
+				if got := tt.tf.Decode(tt.tf.Encode(v)); !approxEqual(got, v, 1e-9) {
+					t.Errorf("Decode(Encode(%v)) = %v", v, got)
+				}
+			}
+			if got := tt.tf.Encode(0); !approxEqual(got, 0, 1e-6) {
+				t.Errorf("Encode(0) = %v, want 0", got)
+			}
+			if got := tt.tf.Encode(1); !approxEqual(got, 1, 1e-6) {
+				t.Errorf("Encode(1) = %v, want 1", got)
+			}
+		})
+	}
+}
+
+func TestTransferFunctions_KnownValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+		tol       float64
+	}{
+		{"sRGB encode 0.5", TransferSRGB.Encode(0.5), 0.7354, 1e-4},
+		{"BT.709 encode 0.5", TransferBT709.Encode(0.5), 0.7055, 1e-4},
+		{"gamma 2 decode", Gamma(2).Decode(0.5), 0.25, 1e-12},
+		// 100 cd/m² is SDR reference white, about 50.8% PQ signal.
+		{"PQ 100 nits", TransferPQ.Encode(0.01), 0.5081, 1e-4},
+		{"PQ 1000 nits", TransferPQ.Encode(0.1), 0.7518, 1e-4},
+		{"HLG knee", TransferHLG.Encode(1.0 / 12), 0.5, 1e-12},
+		{"HLG quarter", TransferHLG.Encode(0.25), 0.7385, 1e-4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !approxEqual(tt.got, tt.want, tt.tol) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferFunctions_Negative(t *testing.T) {
+	if got := TransferSRGB.Encode(-0.5); !approxEqual(got, -TransferSRGB.Encode(0.5), 1e-12) {
+		t.Errorf("sRGB Encode(-0.5) = %v, want mirrored", got)
+	}
+	if got := Gamma(2).Decode(-0.5); got != -0.25 {
+		t.Errorf("Gamma(2).Decode(-0.5) = %v, want -0.25", got)
+	}
+	if got := TransferPQ.Decode(-1); got != 0 {
+		t.Errorf("PQ Decode(-1) = %v, want 0", got)
+	}
+	if got := TransferHLG.Encode(-1); got != 0 {
+		t.Errorf("HLG Encode(-1) = %v, want 0", got)
+	}
+}
+
+func TestApplyTransfer_GrayF32(t *testing.T) {
+	src := NewGrayF32Image(image.Rect(0, 0, 2, 1))
+	src.SetGrayF32(0, 0, GrayF32{Y: 0.25})
+	src.SetGrayF32(1, 0, GrayF32{Y: 1})
+
+	dst := NewGrayF32Image(image.Rect(0, 0, 2, 1))
+	if err := ApplyTransfer(dst, src, Gamma(2), TransferEncode); err != nil {
+		t.Fatalf("ApplyTransfer: %v", err)
+	}
+	if got := dst.GrayF32At(0, 0).Y; got != 0.5 {
+		t.Errorf("encoded value = %v, want 0.5", got)
+	}
+
+	// In-place decoding restores the original.
+	if err := ApplyTransfer(dst, dst, Gamma(2), TransferDecode); err != nil {
+		t.Fatalf("ApplyTransfer: %v", err)
+	}
+	if got := dst.GrayF32At(0, 0).Y; got != 0.25 {
+		t.Errorf("decoded value = %v, want 0.25", got)
+	}
+}
+
+func TestApplyTransfer_LinearRGBAF32(t *testing.T) {
+	img := NewLinearRGBAF32Image(image.Rect(0, 0, 2, 1))
+	// A half-transparent pixel holding unpremultiplied 0.25.
+	img.SetLinearRGBAF32(0, 0, LinearRGBAF32{0.125, 0.125, 0, 0.5})
+
+	if err := ApplyTransfer(img, img, Gamma(2), TransferEncode); err != nil {
+		t.Fatalf("ApplyTransfer: %v", err)
+	}
+	got := img.LinearRGBAF32At(0, 0)
+	if got.R != 0.25 || got.B != 0 || got.A != 0.5 {
+		t.Errorf("encoded pixel = %v, want {0.25 0.25 0 0.5}", got)
+	}
+	if got := img.LinearRGBAF32At(1, 0); got != (LinearRGBAF32{}) {
+		t.Errorf("transparent pixel = %v, want unchanged", got)
+	}
+	if math.IsNaN(float64(got.G)) {
+		t.Error("encoded pixel contains NaN")
+	}
+}
+
+func TestApplyTransfer_MismatchedTypes(t *testing.T) {
+	g := NewGrayF32Image(image.Rect(0, 0, 1, 1))
+	c := NewLinearRGBAF32Image(image.Rect(0, 0, 1, 1))
+	if err := ApplyTransfer(g, c, TransferSRGB, TransferEncode); err == nil {
+		t.Error("ApplyTransfer(GrayF32Image, LinearRGBAF32Image) returned nil error")
+	}
+	if err := ApplyTransfer(g, image.NewGray(g.Rect), TransferSRGB, TransferEncode); err == nil {
+		t.Error("ApplyTransfer(GrayF32Image, Gray) returned nil error")
+	}
+}
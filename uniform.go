@@ -0,0 +1,81 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+)
+
+// UniformImage is an infinite-sized image of uniform color, like
+// image.Uniform, that also provides the typed accessors of the package's
+// images. It is typically used as a source for draw.Draw or Drawer to fill
+// a region, or as a mask.
+type UniformImage struct {
+	C color.Color
+}
+
+// Uniform returns a UniformImage of color c.
+func Uniform(c color.Color) *UniformImage {
+	return &UniformImage{C: c}
+}
+
+// ColorModel returns the model of the package's color type held by the
+// image. For other colors it returns a model that converts every color to
+// C, as image.Uniform does.
+func (u *UniformImage) ColorModel() color.Model {
+	switch u.C.(type) {
+	case GrayS16:
+		return GrayS16Model
+	case GrayF32:
+		return GrayF32Model
+	case GrayU32:
+		return GrayU32Model
+	case GrayU64:
+		return GrayU64Model
+	case GrayS64:
+		return GrayS64Model
+	case RGBAF32:
+		return RGBAF32Model
+	case NRGBAF32:
+		return NRGBAF32Model
+	case LinearRGBAF32:
+		return LinearRGBAF32Model
+	}
+	return color.ModelFunc(func(color.Color) color.Color { return u.C })
+}
+
+// Bounds returns an effectively infinite rectangle.
+func (u *UniformImage) Bounds() image.Rectangle {
+	return image.Rectangle{Min: image.Point{X: -1e9, Y: -1e9}, Max: image.Point{X: 1e9, Y: 1e9}}
+}
+
+// At returns C for every pixel.
+func (u *UniformImage) At(x, y int) color.Color {
+	return u.C
+}
+
+// RGBA64At returns C converted to color.RGBA64.
+func (u *UniformImage) RGBA64At(x, y int) color.RGBA64 {
+	r, g, b, a := u.C.RGBA()
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+}
+
+// GrayS16At returns C converted to GrayS16.
+func (u *UniformImage) GrayS16At(x, y int) GrayS16 {
+	return GrayS16Model.Convert(u.C).(GrayS16)
+}
+
+// GrayF32At returns C converted to GrayF32.
+func (u *UniformImage) GrayF32At(x, y int) GrayF32 {
+	return GrayF32Model.Convert(u.C).(GrayF32)
+}
+
+// RGBAF32At returns C converted to RGBAF32.
+func (u *UniformImage) RGBAF32At(x, y int) RGBAF32 {
+	return RGBAF32Model.Convert(u.C).(RGBAF32)
+}
+
+// Opaque reports whether C is fully opaque.
+func (u *UniformImage) Opaque() bool {
+	_, _, _, a := u.C.RGBA()
+	return a == 0xffff
+}
@@ -0,0 +1,70 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestUniform(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      color.Color
+		model  color.Model
+		s16    int16
+		opaque bool
+	}{
+		{"GrayS16", GrayS16{Y: -1234}, GrayS16Model, -1234, true},
+		{"GrayF32", GrayF32{Y: 0.5}, GrayF32Model, 0, true},
+		{"RGBAF32", RGBAF32{0, 0, 0, 0.5}, RGBAF32Model, -32768, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := Uniform(tt.c)
+			if got := u.At(-5, 1e6); got != tt.c {
+				t.Errorf("At = %v, want %v", got, tt.c)
+			}
+			if got := u.ColorModel().Convert(color.Black); got != tt.model.Convert(color.Black) {
+				t.Errorf("ColorModel converts black to %v, want %v", got, tt.model.Convert(color.Black))
+			}
+			if got := u.GrayS16At(3, 4).Y; got != tt.s16 {
+				t.Errorf("GrayS16At = %d, want %d", got, tt.s16)
+			}
+			if got := u.Opaque(); got != tt.opaque {
+				t.Errorf("Opaque = %v, want %v", got, tt.opaque)
+			}
+		})
+	}
+
+	// Other colors convert everything to themselves, like image.Uniform.
+	u := Uniform(color.RGBA{1, 2, 3, 4})
+	if got := u.ColorModel().Convert(color.White); got != u.C {
+		t.Errorf("ColorModel of stdlib color converts white to %v, want %v", got, u.C)
+	}
+}
+
+func TestUniformFill(t *testing.T) {
+	want := NewGrayS16Image(image.Rect(0, 0, 5, 4))
+	got := NewGrayS16Image(want.Rect)
+	fill := Uniform(GrayS16{Y: -300})
+	r := image.Rect(1, 1, 9, 3)
+	draw.Draw(want, r, fill, image.Point{}, draw.Src)
+	Draw(got, r, fill, image.Point{}, draw.Over)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 5; x++ {
+			if g, w := got.GrayS16At(x, y), want.GrayS16At(x, y); g != w {
+				t.Errorf("pixel (%d, %d) = %d, want %d", x, y, g.Y, w.Y)
+			}
+		}
+	}
+	if got.GrayS16At(4, 2).Y != -300 {
+		t.Error("fill did not reach the clipped edge")
+	}
+
+	// Uniform scalar values are read raw.
+	at := scalarSampler(Uniform(GrayF32{Y: -2.5}))
+	if got := at(100, -100); got != -2.5 {
+		t.Errorf("scalar value = %v, want -2.5", got)
+	}
+}
@@ -0,0 +1,72 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestPhysicalGrayS16Image(t *testing.T) {
+	// CT data stored with a DICOM rescale slope of 1 and intercept of -1024.
+	img := NewGrayS16Image(image.Rect(0, 0, 3, 2))
+	p := NewPhysicalGrayS16Image(img, UnitsHounsfield, 1, -1024)
+	p.SetValue(0, 0, -1000)
+	p.SetValue(1, 0, 40.4)
+	if got := img.GrayS16At(0, 0).Y; got != 24 {
+		t.Errorf("stored value = %d, want 24", got)
+	}
+	if got := p.ValueAt(1, 0); got != 40 {
+		t.Errorf("ValueAt(1, 0) = %v, want 40", got)
+	}
+	if got := p.ValueAt(5, 0); !math.IsNaN(got) {
+		t.Errorf("out of bounds ValueAt = %v, want NaN", got)
+	}
+
+	tests := []struct {
+		scale, offset, v float64
+		want             int16
+	}{
+		{0.01, 0, 12.345, 1235}, // rounds
+		{0, 10, 15, 5},          // zero scale means 1
+		{0.5, 0, 1e9, math.MaxInt16},
+		{0.5, 0, -1e9, math.MinInt16},
+		{1, 0, math.NaN(), 0},
+	}
+	for _, tt := range tests {
+		p := NewPhysicalGrayS16Image(img, UnitsNone, tt.scale, tt.offset)
+		if got := p.Stored(tt.v); got != tt.want {
+			t.Errorf("scale %v offset %v: Stored(%v) = %d, want %d", tt.scale, tt.offset, tt.v, got, tt.want)
+		}
+	}
+
+	vals := p.Values()
+	if vals.GrayF32At(0, 0).Y != -1000 || vals.GrayF32At(2, 1).Y != -1024 {
+		t.Errorf("Values = %v, %v", vals.GrayF32At(0, 0).Y, vals.GrayF32At(2, 1).Y)
+	}
+	sub := p.SubImage(image.Rect(1, 0, 3, 2)).(*PhysicalGrayS16Image)
+	if sub.Units != UnitsHounsfield || sub.ValueAt(1, 0) != 40 {
+		t.Errorf("SubImage lost its annotation: %+v", sub)
+	}
+	if got := scalarSampler(p)(1, 0); got != 40 {
+		t.Errorf("scalarSampler = %v, want 40", got)
+	}
+}
+
+func TestPhysicalFromMeta(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 1, 1))
+	img.SetGrayS16(0, 0, GrayS16{Y: 3000})
+	p := PhysicalFromMeta(img, Metadata{MetaUnits: "K", MetaScale: 0.1})
+	if p.Units != UnitsKelvin || p.ValueAt(0, 0) != 300 {
+		t.Errorf("PhysicalFromMeta = %s, %v", p.Units, p.ValueAt(0, 0))
+	}
+	m := p.Meta()
+	if u, _ := m.String(MetaUnits); u != "K" {
+		t.Errorf("Meta units = %q", u)
+	}
+	if s, _ := m.Float(MetaScale); s != 0.1 {
+		t.Errorf("Meta scale = %v", s)
+	}
+	if PhysicalFromMeta(img, nil).ValueAt(0, 0) != 3000 {
+		t.Error("nil Metadata should leave values unscaled")
+	}
+}
@@ -0,0 +1,216 @@
+package colorext
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// ErrInvalidLayout is returned when an image's Pix, Stride and Rect are
+// inconsistent, so that indexing its pixels could panic. Errors reporting
+// it wrap it with the offending values.
+var ErrInvalidLayout = errors.New("colorext: inconsistent pixel layout")
+
+// NewGrayS16ImageFromPix returns a GrayS16Image using pix as its pixels,
+// without copying. Unlike building the struct directly, it reports an error
+// wrapping ErrInvalidLayout if the stride or the length of pix cannot hold
+// rect, so decoders built on it fail cleanly on malformed input.
+func NewGrayS16ImageFromPix(pix []uint8, stride int, rect image.Rectangle) (*GrayS16Image, error) {
+	p := &GrayS16Image{Pix: pix, Stride: stride, Rect: rect}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validatePix checks that pix, with the given stride, holds h rows of
+// rowBytes bytes each. rowBytes is negative if the row size overflowed.
+func validatePix(pix []uint8, stride int, r image.Rectangle, rowBytes int) error {
+	if r.Min.X > r.Max.X || r.Min.Y > r.Max.Y {
+		return fmt.Errorf("%w: malformed bounds %v", ErrInvalidLayout, r)
+	}
+	if r.Empty() {
+		return nil
+	}
+	if rowBytes < 0 {
+		return fmt.Errorf("%w: bounds %v overflow", ErrInvalidLayout, r)
+	}
+	if stride < rowBytes {
+		return fmt.Errorf("%w: stride %d is shorter than a row of %d bytes", ErrInvalidLayout, stride, rowBytes)
+	}
+	h := r.Dy()
+	if h-1 > (math.MaxInt-rowBytes)/stride {
+		return fmt.Errorf("%w: bounds %v overflow", ErrInvalidLayout, r)
+	}
+	if need := (h-1)*stride + rowBytes; len(pix) < need {
+		return fmt.Errorf("%w: %d pixel bytes, need %d for bounds %v", ErrInvalidLayout, len(pix), need, r)
+	}
+	return nil
+}
+
+// rowSize returns the number of bytes in a row of w pixels of size bytes,
+// or -1 on overflow.
+func rowSize(w, size int) int {
+	if w > math.MaxInt/size {
+		return -1
+	}
+	return w * size
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayS16Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 2))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent, or its mask does not cover Rect.
+func (p *MaskedGrayS16Image) Validate() error {
+	if err := p.GrayS16Image.Validate(); err != nil {
+		return err
+	}
+	if p.Mask != nil && !p.Rect.In(p.Mask.Rect) {
+		return fmt.Errorf("%w: mask bounds %v do not cover %v", ErrInvalidLayout, p.Mask.Rect, p.Rect)
+	}
+	return nil
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayF32Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 4))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayU32Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 4))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayU64Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayS64Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayC64Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *GrayC128Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 16))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *RGBAF32Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 16))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *NRGBAF32Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 16))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *LinearRGBAF32Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 16))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *BGRImage) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 3))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *BGRAImage) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 4))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *BGR48Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 6))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *BGRA64Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *CMYK64Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *RGB565Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 2))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent.
+func (p *RGB555Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 2))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Pix, Stride
+// and Rect are inconsistent. Indices outside the palette are not errors;
+// At maps them to the zero color.
+func (p *Paletted16Image) Validate() error {
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), 2))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Depth is not 8
+// or 16, or its Pix, Stride and Rect are inconsistent.
+func (p *BayerImage) Validate() error {
+	if p.Depth != 8 && p.Depth != 16 {
+		return fmt.Errorf("%w: unsupported Bayer depth %d", ErrInvalidLayout, p.Depth)
+	}
+	return validatePix(p.Pix, p.Stride, p.Rect, rowSize(p.Rect.Dx(), p.Depth/8))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Offset is out
+// of range, or its Pix, Stride and Rect are inconsistent.
+func (p *Gray10PackedImage) Validate() error {
+	if p.Offset < 0 || p.Offset >= 4 {
+		return fmt.Errorf("%w: packed offset %d out of range", ErrInvalidLayout, p.Offset)
+	}
+	return validatePix(p.Pix, p.Stride, p.Rect, packedRowSize(p.Rect.Dx()+p.Offset, 4, 5))
+}
+
+// Validate reports an error wrapping ErrInvalidLayout if p's Offset is out
+// of range, or its Pix, Stride and Rect are inconsistent.
+func (p *Gray12PackedImage) Validate() error {
+	if p.Offset < 0 || p.Offset >= 2 {
+		return fmt.Errorf("%w: packed offset %d out of range", ErrInvalidLayout, p.Offset)
+	}
+	return validatePix(p.Pix, p.Stride, p.Rect, packedRowSize(p.Rect.Dx()+p.Offset, 2, 3))
+}
+
+// packedRowSize returns the number of bytes in a row of w pixels packed in
+// groups of n pixels per size bytes, or -1 on overflow.
+func packedRowSize(w, n, size int) int {
+	if w < 0 || w > math.MaxInt-n {
+		return -1
+	}
+	return rowSize((w+n-1)/n, size)
+}
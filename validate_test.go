@@ -0,0 +1,101 @@
+package colorext
+
+import (
+	"errors"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestNewGrayS16ImageFromPix(t *testing.T) {
+	tests := []struct {
+		name   string
+		pix    int
+		stride int
+		rect   image.Rectangle
+		ok     bool
+	}{
+		{"exact", 24, 8, image.Rect(0, 0, 4, 3), true},
+		{"padded stride", 36, 12, image.Rect(1, 1, 5, 4), true},
+		{"short last row", 32, 12, image.Rect(0, 0, 4, 3), true},
+		{"empty", 0, 0, image.Rectangle{}, true},
+		{"empty with bogus stride", 0, -5, image.Rect(3, 3, 3, 9), true},
+		{"short pix", 23, 8, image.Rect(0, 0, 4, 3), false},
+		{"short stride", 24, 6, image.Rect(0, 0, 4, 3), false},
+		{"negative stride", 24, -8, image.Rect(0, 0, 4, 3), false},
+		{"malformed bounds", 24, 8, image.Rectangle{image.Pt(4, 0), image.Pt(0, 3)}, false},
+		{"overflowing width", 24, 8, image.Rect(math.MinInt/2, 0, math.MaxInt/2, 1), false},
+		{"overflowing height", 24, math.MaxInt / 2, image.Rect(0, 0, 1, 4), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := NewGrayS16ImageFromPix(make([]uint8, tt.pix), tt.stride, tt.rect)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				// Every pixel must be addressable.
+				for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+					for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+						img.SetGrayS16(x, y, img.GrayS16At(x, y))
+					}
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidLayout) {
+				t.Errorf("error = %v, want ErrInvalidLayout", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := image.Rect(0, 0, 5, 2)
+	tests := []struct {
+		name  string
+		valid interface{ Validate() error }
+		bad   interface{ Validate() error }
+	}{
+		{"GrayF32", NewGrayF32Image(r), &GrayF32Image{Pix: make([]uint8, 39), Stride: 20, Rect: r}},
+		{"GrayC128", NewGrayC128Image(r), &GrayC128Image{Pix: make([]uint8, 160), Stride: 79, Rect: r}},
+		{"RGBAF32", NewRGBAF32Image(r), &RGBAF32Image{Pix: make([]uint8, 159), Stride: 80, Rect: r}},
+		{"BGR", NewBGRImage(r), &BGRImage{Pix: make([]uint8, 29), Stride: 15, Rect: r}},
+		{"Bayer depth", NewBayerImage(r, RGGB, 16), &BayerImage{Pix: make([]uint8, 20), Stride: 10, Rect: r, Depth: 12}},
+		{"Gray10Packed", NewGray10PackedImage(r), &Gray10PackedImage{Pix: make([]uint8, 20), Stride: 10, Rect: r, Offset: 4}},
+		{"Gray12Packed", NewGray12PackedImage(r), &Gray12PackedImage{Pix: make([]uint8, 17), Stride: 9, Rect: r}},
+		{"Masked", NewMaskedGrayS16Image(NewGrayS16Image(r), 0), &MaskedGrayS16Image{GrayS16Image: NewGrayS16Image(r), Mask: image.NewAlpha(image.Rect(0, 0, 5, 1))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.valid.Validate(); err != nil {
+				t.Errorf("valid image: %v", err)
+			}
+			if err := tt.bad.Validate(); !errors.Is(err, ErrInvalidLayout) {
+				t.Errorf("invalid image: error = %v, want ErrInvalidLayout", err)
+			}
+		})
+	}
+}
+
+func FuzzNewGrayS16ImageFromPix(f *testing.F) {
+	f.Add(24, 8, 0, 0, 4, 3)
+	f.Add(10, 4, -2, 5, 0, 7)
+	f.Fuzz(func(t *testing.T, n, stride, x0, y0, x1, y1 int) {
+		if n < 0 || n > 1<<16 {
+			return
+		}
+		img, err := NewGrayS16ImageFromPix(make([]uint8, n), stride, image.Rect(x0, y0, x1, y1))
+		if err != nil {
+			return
+		}
+		// A validated image must never panic on access. Visit the corners
+		// rather than every pixel to keep large bounds fast.
+		r := img.Rect
+		if r.Empty() {
+			return
+		}
+		for _, p := range []image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, r.Max.Sub(image.Pt(1, 1))} {
+			img.SetGrayS16(p.X, p.Y, img.GrayS16At(p.X, p.Y))
+		}
+	})
+}
@@ -0,0 +1,97 @@
+//go:build js && wasm
+
+package colorext
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"syscall/js"
+)
+
+// Int16Array returns a JavaScript Int16Array holding the pixels of img in
+// row order. Typed arrays use the host's little-endian byte order, so the
+// samples are swapped and copied once, straight into the array's buffer,
+// rather than converted through RGBA.
+func Int16Array(img *GrayS16Image) js.Value {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	arr := js.Global().Get("Int16Array").New(max(w*h, 0))
+	copyToTypedArray(arr, img.Pix, img.Stride, 2*w, h, SwapBytes16)
+	return arr
+}
+
+// Float32Array is like Int16Array, returning a Float32Array.
+func Float32Array(img *GrayF32Image) js.Value {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	arr := js.Global().Get("Float32Array").New(max(w*h, 0))
+	copyToTypedArray(arr, img.Pix, img.Stride, 4*w, h, SwapBytes32)
+	return arr
+}
+
+// GrayS16FromInt16Array returns a w×h GrayS16Image holding the values of
+// arr, an Int16Array in row order.
+func GrayS16FromInt16Array(arr js.Value, w, h int) (*GrayS16Image, error) {
+	img := NewGrayS16Image(image.Rect(0, 0, w, h))
+	if err := copyFromTypedArray(img.Pix, arr, "Int16Array", SwapBytes16); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// GrayF32FromFloat32Array is like GrayS16FromInt16Array for a Float32Array.
+func GrayF32FromFloat32Array(arr js.Value, w, h int) (*GrayF32Image, error) {
+	img := NewGrayF32Image(image.Rect(0, 0, w, h))
+	if err := copyFromTypedArray(img.Pix, arr, "Float32Array", SwapBytes32); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// FromImageData returns an *image.NRGBA holding the pixels of a canvas
+// ImageData object, whose data is non-premultiplied RGBA.
+func FromImageData(v js.Value) (*image.NRGBA, error) {
+	data := v.Get("data")
+	if data.Type() != js.TypeObject || !data.InstanceOf(js.Global().Get("Uint8ClampedArray")) {
+		return nil, errors.New("colorext: value is not an ImageData")
+	}
+	w, h := v.Get("width").Int(), v.Get("height").Int()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if n := data.Length(); n != len(img.Pix) {
+		return nil, fmt.Errorf("colorext: ImageData holds %d bytes, want %d", n, len(img.Pix))
+	}
+	js.CopyBytesToGo(img.Pix, data)
+	return img, nil
+}
+
+// bytesOf returns a Uint8Array viewing the memory of a typed array.
+func bytesOf(arr js.Value) js.Value {
+	return js.Global().Get("Uint8Array").New(arr.Get("buffer"), arr.Get("byteOffset"), arr.Get("byteLength"))
+}
+
+// copyToTypedArray copies h rows of n bytes from pix into arr, converting
+// them to little-endian with swap.
+func copyToTypedArray(arr js.Value, pix []uint8, stride, n, h int, swap func([]uint8)) {
+	if n <= 0 || h <= 0 {
+		return
+	}
+	buf := make([]uint8, n*h)
+	for y := 0; y < h; y++ {
+		copy(buf[y*n:(y+1)*n], pix[y*stride:])
+	}
+	swap(buf)
+	js.CopyBytesToJS(bytesOf(arr), buf)
+}
+
+// copyFromTypedArray fills pix from arr, a typed array of the named type
+// with exactly len(pix) bytes, converting the samples to big-endian.
+func copyFromTypedArray(pix []uint8, arr js.Value, typ string, swap func([]uint8)) error {
+	if arr.Type() != js.TypeObject || !arr.InstanceOf(js.Global().Get(typ)) {
+		return fmt.Errorf("colorext: value is not an %s", typ)
+	}
+	if n := arr.Get("byteLength").Int(); n != len(pix) {
+		return fmt.Errorf("colorext: %s holds %d bytes, want %d", typ, n, len(pix))
+	}
+	js.CopyBytesToGo(pix, bytesOf(arr))
+	swap(pix)
+	return nil
+}
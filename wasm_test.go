@@ -0,0 +1,78 @@
+//go:build js && wasm
+
+package colorext
+
+import (
+	"image"
+	"syscall/js"
+	"testing"
+)
+
+func TestInt16Array(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 5, 3))
+	for i := range 15 {
+		img.SetGrayS16(i%5, i/5, GrayS16{Y: int16(i*2000 - 15000)})
+	}
+	sub := img.SubImage(image.Rect(1, 1, 4, 3)).(*GrayS16Image)
+	arr := Int16Array(sub)
+	if n := arr.Length(); n != 6 {
+		t.Fatalf("length = %d, want 6", n)
+	}
+	for i := range 6 {
+		if got, want := arr.Index(i).Int(), int(sub.GrayS16At(1+i%3, 1+i/3).Y); got != want {
+			t.Errorf("element %d = %d, want %d", i, got, want)
+		}
+	}
+
+	back, err := GrayS16FromInt16Array(arr, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 6 {
+		if got, want := back.GrayS16At(i%3, i/3), sub.GrayS16At(1+i%3, 1+i/3); got != want {
+			t.Errorf("round trip %d = %d, want %d", i, got.Y, want.Y)
+		}
+	}
+	if _, err := GrayS16FromInt16Array(arr, 4, 2); err == nil {
+		t.Error("size mismatch: expected an error")
+	}
+	if _, err := GrayS16FromInt16Array(js.Global().Get("Float32Array").New(6), 3, 2); err == nil {
+		t.Error("Float32Array: expected an error")
+	}
+}
+
+func TestFloat32Array(t *testing.T) {
+	img := NewGrayF32Image(image.Rect(0, 0, 2, 1))
+	img.SetGrayF32(0, 0, GrayF32{Y: -1.5})
+	img.SetGrayF32(1, 0, GrayF32{Y: 1e10})
+	arr := Float32Array(img)
+	if a, b := arr.Index(0).Float(), arr.Index(1).Float(); a != -1.5 || b != float64(float32(1e10)) {
+		t.Errorf("elements = %v, %v", a, b)
+	}
+	back, err := GrayF32FromFloat32Array(arr, 2, 1)
+	if err != nil || !Equal(back, img) {
+		t.Errorf("round trip mismatch: %v", err)
+	}
+}
+
+func TestFromImageData(t *testing.T) {
+	data := js.Global().Get("Uint8ClampedArray").New(8)
+	for i := range 8 {
+		data.SetIndex(i, i*30)
+	}
+	v := js.Global().Get("Object").New()
+	v.Set("data", data)
+	v.Set("width", 2)
+	v.Set("height", 1)
+	img, err := FromImageData(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.NRGBAAt(1, 0); c.R != 120 || c.A != 210 {
+		t.Errorf("pixel (1, 0) = %v", c)
+	}
+	v.Set("height", 2)
+	if _, err := FromImageData(v); err == nil {
+		t.Error("short data: expected an error")
+	}
+}
@@ -0,0 +1,202 @@
+package colorext
+
+import (
+	"image/color"
+)
+
+// rgbSpace is an RGB color space with a D65 white point.
+type rgbSpace struct {
+	toXYZ, fromXYZ mat3
+	tf             TransferFunction
+}
+
+// newRGBSpace returns the RGB space with the given primary chromaticities,
+// a D65 white point and transfer function tf.
+func newRGBSpace(red, green, blue XyY, tf TransferFunction) rgbSpace {
+	m := primariesMatrix(red, green, blue, D65)
+	return rgbSpace{toXYZ: m, fromXYZ: m.inverse(), tf: tf}
+}
+
+// primariesMatrix returns the linear RGB to XYZ matrix of the space with
+// the given primaries, scaled so that RGB (1, 1, 1) maps to white.
+func primariesMatrix(red, green, blue XyY, white WhitePoint) mat3 {
+	var m mat3
+	for j, c := range []XyY{red, green, blue} {
+		xyz := XyY{Cx: c.Cx, Cy: c.Cy, Y: 1}.XYZ()
+		m[0][j], m[1][j], m[2][j] = xyz.X, xyz.Y, xyz.Z
+	}
+	s := [3]float64{}
+	s[0], s[1], s[2] = m.inverse().apply(white.X, white.Y, white.Z)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] *= s[j]
+		}
+	}
+	return m
+}
+
+// xyz returns the XYZ coordinates of the encoded components r, g and b.
+func (s rgbSpace) xyz(r, g, b float64) XYZ {
+	x, y, z := s.toXYZ.apply(s.tf.Decode(r), s.tf.Decode(g), s.tf.Decode(b))
+	return XYZ{x, y, z}
+}
+
+// encode returns the encoded components of c, without clipping.
+func (s rgbSpace) encode(c color.Color) (r, g, b float64) {
+	xyz := toXYZ(c)
+	lr, lg, lb := s.fromXYZ.apply(xyz.X, xyz.Y, xyz.Z)
+	return s.tf.Encode(lr), s.tf.Encode(lg), s.tf.Encode(lb)
+}
+
+var (
+	displayP3Space = newRGBSpace(XyY{0.680, 0.320, 1}, XyY{0.265, 0.690, 1}, XyY{0.150, 0.060, 1}, TransferSRGB)
+	adobeRGBSpace  = newRGBSpace(XyY{0.64, 0.33, 1}, XyY{0.21, 0.71, 1}, XyY{0.15, 0.06, 1}, Gamma(563.0/256))
+	rec2020Space   = newRGBSpace(XyY{0.708, 0.292, 1}, XyY{0.170, 0.797, 1}, XyY{0.131, 0.046, 1}, TransferBT709)
+)
+
+// inGamut reports whether r, g and b all lie in [0, 1].
+func inGamut(r, g, b float64) bool {
+	return r >= 0 && r <= 1 && g >= 0 && g <= 1 && b >= 0 && b <= 1
+}
+
+// DisplayP3 represents a color in Apple's Display P3 space: DCI-P3 primaries,
+// a D65 white point and the sRGB transfer function. Components are encoded
+// and nominally in [0, 1]; values outside that range represent colors
+// outside the P3 gamut.
+type DisplayP3 struct {
+	R, G, B float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the DisplayP3
+// color. This implements the color.Color interface.
+// Colors outside the sRGB gamut are clipped.
+func (c DisplayP3) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// XYZ converts c to XYZ coordinates.
+func (c DisplayP3) XYZ() XYZ {
+	return displayP3Space.xyz(c.R, c.G, c.B)
+}
+
+// InGamut reports whether c lies within the Display P3 gamut.
+func (c DisplayP3) InGamut() bool {
+	return inGamut(c.R, c.G, c.B)
+}
+
+// ClipToGamut returns c with each component clamped to [0, 1].
+func (c DisplayP3) ClipToGamut() DisplayP3 {
+	return DisplayP3{clamp01(c.R), clamp01(c.G), clamp01(c.B)}
+}
+
+// DisplayP3Model is the color model for Display P3 colors. Conversion goes
+// through XYZ and does not clip, so colors outside the P3 gamut yield
+// components outside [0, 1]. As with color.YCbCrModel, alpha is discarded.
+var DisplayP3Model color.Model = color.ModelFunc(displayP3Model)
+
+// displayP3Model converts any color.Color to a DisplayP3.
+func displayP3Model(c color.Color) color.Color {
+	if c, ok := c.(DisplayP3); ok {
+		return c
+	}
+	r, g, b := displayP3Space.encode(c)
+	return DisplayP3{r, g, b}
+}
+
+// AdobeRGB represents a color in the Adobe RGB (1998) space. Components are
+// encoded with a 563/256 gamma and nominally in [0, 1]; values outside that
+// range represent colors outside the Adobe RGB gamut.
+type AdobeRGB struct {
+	R, G, B float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the AdobeRGB
+// color. This implements the color.Color interface.
+// Colors outside the sRGB gamut are clipped.
+func (c AdobeRGB) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// XYZ converts c to XYZ coordinates.
+func (c AdobeRGB) XYZ() XYZ {
+	return adobeRGBSpace.xyz(c.R, c.G, c.B)
+}
+
+// InGamut reports whether c lies within the Adobe RGB gamut.
+func (c AdobeRGB) InGamut() bool {
+	return inGamut(c.R, c.G, c.B)
+}
+
+// ClipToGamut returns c with each component clamped to [0, 1].
+func (c AdobeRGB) ClipToGamut() AdobeRGB {
+	return AdobeRGB{clamp01(c.R), clamp01(c.G), clamp01(c.B)}
+}
+
+// AdobeRGBModel is the color model for Adobe RGB colors. Conversion goes
+// through XYZ and does not clip. As with color.YCbCrModel, alpha is
+// discarded.
+var AdobeRGBModel color.Model = color.ModelFunc(adobeRGBModel)
+
+// adobeRGBModel converts any color.Color to an AdobeRGB.
+func adobeRGBModel(c color.Color) color.Color {
+	if c, ok := c.(AdobeRGB); ok {
+		return c
+	}
+	r, g, b := adobeRGBSpace.encode(c)
+	return AdobeRGB{r, g, b}
+}
+
+// Rec2020 represents a color in the ITU-R BT.2020 space with the BT.2020
+// (BT.709) transfer function. Components are encoded and nominally in
+// [0, 1]; values outside that range represent colors outside the Rec.2020
+// gamut.
+type Rec2020 struct {
+	R, G, B float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the Rec2020
+// color. This implements the color.Color interface.
+// Colors outside the sRGB gamut are clipped.
+func (c Rec2020) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// XYZ converts c to XYZ coordinates.
+func (c Rec2020) XYZ() XYZ {
+	return rec2020Space.xyz(c.R, c.G, c.B)
+}
+
+// InGamut reports whether c lies within the Rec.2020 gamut.
+func (c Rec2020) InGamut() bool {
+	return inGamut(c.R, c.G, c.B)
+}
+
+// ClipToGamut returns c with each component clamped to [0, 1].
+func (c Rec2020) ClipToGamut() Rec2020 {
+	return Rec2020{clamp01(c.R), clamp01(c.G), clamp01(c.B)}
+}
+
+// Rec2020Model is the color model for Rec.2020 colors. Conversion goes
+// through XYZ and does not clip. As with color.YCbCrModel, alpha is
+// discarded.
+var Rec2020Model color.Model = color.ModelFunc(rec2020Model)
+
+// rec2020Model converts any color.Color to a Rec2020.
+func rec2020Model(c color.Color) color.Color {
+	if c, ok := c.(Rec2020); ok {
+		return c
+	}
+	r, g, b := rec2020Space.encode(c)
+	return Rec2020{r, g, b}
+}
+
+// InSRGBGamut reports whether c can be displayed in sRGB without clipping,
+// allowing for a small rounding tolerance. Colors are examined through
+// their XYZ method if they have one, such as DisplayP3 and XyY; colors that
+// only provide 16-bit RGBA components are always in gamut.
+func InSRGBGamut(c color.Color) bool {
+	const tol = 1e-6
+	xyz := toXYZ(c)
+	r, g, b := xyzToLinearSRGB(xyz.X, xyz.Y, xyz.Z)
+	return r >= -tol && r <= 1+tol && g >= -tol && g <= 1+tol && b >= -tol && b <= 1+tol
+}
@@ -0,0 +1,104 @@
+package colorext
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPrimariesMatrix_SRGB(t *testing.T) {
+	got := primariesMatrix(XyY{0.64, 0.33, 1}, XyY{0.30, 0.60, 1}, XyY{0.15, 0.06, 1}, D65)
+	for i := range got {
+		for j := range got[i] {
+			if !approxEqual(got[i][j], srgbToXYZMatrix[i][j], 1e-4) {
+				t.Errorf("[%d][%d] = %v, want %v", i, j, got[i][j], srgbToXYZMatrix[i][j])
+			}
+		}
+	}
+}
+
+func TestWideGamutModels(t *testing.T) {
+	tests := []struct {
+		name  string
+		model color.Model
+		in    color.Color
+		want  [3]float64
+	}{
+		{"sRGB red in P3", DisplayP3Model, color.RGBA{0xff, 0, 0, 0xff}, [3]float64{0.9175, 0.2003, 0.1386}},
+		{"sRGB green in Adobe RGB", AdobeRGBModel, color.RGBA{0, 0xff, 0, 0xff}, [3]float64{0.5647, 1, 0.2345}},
+		{"sRGB blue in Rec.2020", Rec2020Model, color.RGBA{0, 0, 0xff, 0xff}, [3]float64{0.1683, 0.0511, 0.9468}},
+		{"white in Rec.2020", Rec2020Model, color.White, [3]float64{1, 1, 1}},
+		// P3 green lies outside the Adobe RGB gamut.
+		{"P3 green in Adobe RGB", AdobeRGBModel, DisplayP3{0, 1, 0}, [3]float64{0.4036, 1.0189, -0.2106}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [3]float64
+			switch c := tt.model.Convert(tt.in).(type) {
+			case DisplayP3:
+				got = [3]float64{c.R, c.G, c.B}
+			case AdobeRGB:
+				got = [3]float64{c.R, c.G, c.B}
+			case Rec2020:
+				got = [3]float64{c.R, c.G, c.B}
+			default:
+				t.Fatalf("Convert returned %T", c)
+			}
+			for i := range got {
+				if !approxEqual(got[i], tt.want[i], 2e-3) {
+					t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWideGamut_RGBARoundTrip(t *testing.T) {
+	in := color.RGBA64{0x1234, 0x8000, 0xfedc, 0xffff}
+	for _, m := range []color.Model{DisplayP3Model, AdobeRGBModel, Rec2020Model} {
+		r, g, b, _ := m.Convert(in).RGBA()
+		if !approxEqual(float64(r), float64(in.R), 2) || !approxEqual(float64(g), float64(in.G), 2) || !approxEqual(float64(b), float64(in.B), 2) {
+			t.Errorf("%T round trip = (%#x, %#x, %#x), want %v", m.Convert(in), r, g, b, in)
+		}
+	}
+}
+
+func TestWideGamut_InGamutAndClip(t *testing.T) {
+	c := DisplayP3{1.2, -0.1, 0.5}
+	if c.InGamut() {
+		t.Errorf("%v.InGamut() = true, want false", c)
+	}
+	if got := c.ClipToGamut(); got != (DisplayP3{1, 0, 0.5}) || !got.InGamut() {
+		t.Errorf("ClipToGamut() = %v, want {1 0 0.5}", got)
+	}
+	if got := (AdobeRGB{0, 0, 1}).ClipToGamut(); got != (AdobeRGB{0, 0, 1}) {
+		t.Errorf("AdobeRGB ClipToGamut() = %v, want unchanged", got)
+	}
+	if !(Rec2020{0.5, 0.5, 0.5}).InGamut() {
+		t.Error("Rec2020 gray InGamut() = false, want true")
+	}
+}
+
+func TestInSRGBGamut(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color.Color
+		want bool
+	}{
+		{"8-bit color", color.RGBA{0xff, 0, 0, 0xff}, true},
+		{"P3 white", DisplayP3{1, 1, 1}, true},
+		{"P3 red", DisplayP3{1, 0, 0}, false},
+		{"Rec.2020 gray", Rec2020{0.4, 0.4, 0.4}, true},
+		{"Adobe RGB green", AdobeRGB{0, 1, 0}, false},
+		{"XYZ of D50", D50.XYZ(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InSRGBGamut(tt.c); got != tt.want {
+				t.Errorf("InSRGBGamut(%v) = %v, want %v", tt.c, got, tt.want)
+			}
+		})
+	}
+}
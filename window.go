@@ -0,0 +1,155 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// WindowMethod selects how AutoWindow estimates a display window.
+type WindowMethod int
+
+const (
+	// WindowPercentile spans the values between a low and a high
+	// percentile, clipping outliers such as hot pixels.
+	WindowPercentile WindowMethod = iota
+	// WindowMeanStdDev spans K standard deviations either side of the mean.
+	WindowMeanStdDev
+	// WindowMode finds the most populated histogram peak and spans the
+	// surrounding values whose frequency stays above a fraction of the
+	// peak's. It isolates the dominant structure, such as soft tissue in CT,
+	// ignoring a large background.
+	WindowMode
+)
+
+// WindowEstimator estimates display windows from image statistics.
+type WindowEstimator struct {
+	Method WindowMethod
+	// LowPercentile and HighPercentile bound WindowPercentile, in [0, 100].
+	// If both are zero, 1 and 99 are used.
+	LowPercentile, HighPercentile float64
+	// K is the number of standard deviations spanned by WindowMeanStdDev
+	// on each side of the mean. Zero means 2.
+	K float64
+}
+
+// AutoWindow estimates a window for displaying img with method and the
+// default parameters. See WindowEstimator.Estimate.
+func AutoWindow(img image.Image, method WindowMethod) (center, width float64) {
+	return WindowEstimator{Method: method}.Estimate(img)
+}
+
+// Estimate returns the center and width of a display window for img, in the
+// DICOM convention used by ApplyWindow. Samples are the raw values read by
+// scalarSampler; NaN and invalid pixels are ignored. The width is at least
+// 1. An image without valid pixels yields (0, 1).
+func (e WindowEstimator) Estimate(img image.Image) (center, width float64) {
+	at := scalarSampler(img)
+	r := img.Bounds()
+	vals := make([]float64, 0, max(r.Dx()*r.Dy(), 0))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if v := at(x, y); !math.IsNaN(v) {
+				vals = append(vals, v)
+			}
+		}
+	}
+	if len(vals) == 0 {
+		return 0, 1
+	}
+
+	var lo, hi float64
+	switch e.Method {
+	case WindowMeanStdDev:
+		k := e.K
+		if k == 0 {
+			k = 2
+		}
+		mean, m2 := 0.0, 0.0
+		for i, v := range vals {
+			d := v - mean
+			mean += d / float64(i+1)
+			m2 += d * (v - mean)
+		}
+		sd := math.Sqrt(m2 / float64(len(vals)))
+		lo, hi = mean-k*sd, mean+k*sd
+	case WindowMode:
+		lo, hi = modeRange(vals)
+	default:
+		pl, ph := e.LowPercentile, e.HighPercentile
+		if pl == 0 && ph == 0 {
+			pl, ph = 1, 99
+		}
+		slices.Sort(vals)
+		lo, hi = percentile(vals, pl), percentile(vals, ph)
+	}
+	return (lo + hi) / 2, max(hi-lo, 1)
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating
+// linearly between ranks.
+func percentile(sorted []float64, p float64) float64 {
+	pos := min(max(p, 0), 100) / 100 * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (sorted[i+1]-sorted[i])*(pos-float64(i))
+}
+
+// modeRange returns the value range around the highest peak of a 256-bin
+// histogram of vals, extended while bins hold at least a tenth of the peak.
+func modeRange(vals []float64) (lo, hi float64) {
+	const bins, fraction = 256, 0.1
+	vmin, vmax := slices.Min(vals), slices.Max(vals)
+	if vmin == vmax {
+		return vmin, vmax
+	}
+	size := (vmax - vmin) / bins
+	var hist [bins]int
+	for _, v := range vals {
+		hist[min(int((v-vmin)/size), bins-1)]++
+	}
+	peak := 0
+	for i, c := range hist {
+		if c > hist[peak] {
+			peak = i
+		}
+	}
+	limit := max(int(fraction*float64(hist[peak])), 1)
+	a, b := peak, peak
+	for a > 0 && hist[a-1] >= limit {
+		a--
+	}
+	for b < bins-1 && hist[b+1] >= limit {
+		b++
+	}
+	return vmin + float64(a)*size, vmin + float64(b+1)*size
+}
+
+// ApplyWindow renders img for display with the DICOM linear VOI function:
+// values up to center - width/2 map to black, values from center + width/2
+// map to white, and values in between map linearly. Samples are the raw
+// values read by scalarSampler; NaN and invalid pixels map to black.
+func ApplyWindow(img image.Image, center, width float64) *image.Gray {
+	r := img.Bounds()
+	dst := image.NewGray(r)
+	at := scalarSampler(img)
+	c, w := center-0.5, max(width, 1)-1
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := at(x, y)
+			var t float64
+			switch {
+			case math.IsNaN(v) || v <= c-w/2:
+				t = 0
+			case v > c+w/2:
+				t = 1
+			default:
+				t = (v-c)/w + 0.5
+			}
+			dst.Pix[dst.PixOffset(x, y)] = unit8(t)
+		}
+	}
+	return dst
+}
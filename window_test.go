@@ -0,0 +1,81 @@
+package colorext
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestAutoWindow(t *testing.T) {
+	// Values 0..99 plus one hot pixel.
+	img := NewGrayS16Image(image.Rect(0, 0, 101, 1))
+	for x := 0; x < 100; x++ {
+		img.SetGrayS16(x, 0, GrayS16{Y: int16(x)})
+	}
+	img.SetGrayS16(100, 0, GrayS16{Y: 30000})
+
+	c, w := AutoWindow(img, WindowPercentile)
+	if math.Abs(c-50) > 0.01 || math.Abs(w-98) > 0.01 {
+		t.Errorf("WindowPercentile = (%v, %v), want (50, 98)", c, w)
+	}
+	c, w = WindowEstimator{Method: WindowPercentile, HighPercentile: 100}.Estimate(img)
+	if c != 15000 || w != 30000 {
+		t.Errorf("0-100 percentile window = (%v, %v), want (15000, 30000)", c, w)
+	}
+
+	two := NewGrayF32Image(image.Rect(0, 0, 2, 1))
+	two.SetGrayF32(0, 0, GrayF32{Y: 10})
+	two.SetGrayF32(1, 0, GrayF32{Y: 20})
+	c, w = AutoWindow(two, WindowMeanStdDev)
+	if c != 15 || w != 20 {
+		t.Errorf("WindowMeanStdDev = (%v, %v), want (15, 20)", c, w)
+	}
+	c, w = WindowEstimator{Method: WindowMeanStdDev, K: 1}.Estimate(two)
+	if c != 15 || w != 10 {
+		t.Errorf("WindowMeanStdDev K=1 = (%v, %v), want (15, 10)", c, w)
+	}
+
+	// A large background at -1000 with a dense band of tissue around 40:
+	// the mode window finds the background peak unless it is masked out.
+	ct := NewMaskedGrayS16Image(NewGrayS16Image(image.Rect(0, 0, 100, 100)), -1000)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			v := int16(-1000)
+			if x >= 30 && x < 70 {
+				v = int16(20 + (x+y)%40)
+			}
+			ct.SetGrayS16(x, y, GrayS16{Y: v})
+		}
+	}
+	ct.SetGrayS16(0, 0, GrayS16{Y: 3000})
+	c, w = AutoWindow(ct, WindowMode)
+	if c < 20 || c > 60 || w > 60 {
+		t.Errorf("WindowMode = (%v, %v), want a window around 20..59", c, w)
+	}
+
+	if c, w := AutoWindow(NewGrayS16Image(image.Rectangle{}), WindowPercentile); c != 0 || w != 1 {
+		t.Errorf("empty image window = (%v, %v), want (0, 1)", c, w)
+	}
+	if _, w := AutoWindow(NewGrayS16Image(image.Rect(0, 0, 2, 2)), WindowMode); w != 1 {
+		t.Errorf("flat image width = %v, want 1", w)
+	}
+}
+
+func TestApplyWindow(t *testing.T) {
+	img := NewGrayS16Image(image.Rect(0, 0, 5, 1))
+	for x, v := range []int16{-100, 0, 40, 80, 400} {
+		img.SetGrayS16(x, 0, GrayS16{Y: v})
+	}
+	// The classic soft-tissue window.
+	got := ApplyWindow(img, 40, 400).Pix
+	want := []uint8{38, 102, 128, 153, 255}
+	for i := range want {
+		if d := int(got[i]) - int(want[i]); d < -1 || d > 1 {
+			t.Errorf("ApplyWindow = %v, want %v", got, want)
+			break
+		}
+	}
+	if got := ApplyWindow(img, 40, 0).Pix; got[1] != 0 || got[2] != 255 {
+		t.Errorf("width 0 = %v, want a threshold at 40", got)
+	}
+}
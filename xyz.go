@@ -0,0 +1,222 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+)
+
+// WhitePoint is a reference white expressed in CIE XYZ, normalized so that
+// Y = 1.
+type WhitePoint struct {
+	X, Y, Z float64
+}
+
+// Standard illuminants for the CIE 1931 2° observer.
+var (
+	// D65 is average daylight and the white point of sRGB.
+	D65 = WhitePoint{0.95047, 1, 1.08883}
+	// D50 is horizon daylight and the profile connection space white of ICC.
+	D50 = WhitePoint{0.96422, 1, 0.82521}
+	// D55 is mid-morning daylight.
+	D55 = WhitePoint{0.95682, 1, 0.92149}
+	// D75 is north sky daylight.
+	D75 = WhitePoint{0.94972, 1, 1.22638}
+	// IlluminantA is incandescent tungsten light.
+	IlluminantA = WhitePoint{1.09850, 1, 0.35585}
+	// IlluminantC is average daylight, superseded by D65.
+	IlluminantC = WhitePoint{0.98074, 1, 1.18232}
+	// IlluminantE is the equal-energy white.
+	IlluminantE = WhitePoint{1, 1, 1}
+	// F2 is cool white fluorescent light.
+	F2 = WhitePoint{0.99186, 1, 0.67393}
+	// F7 is broadband daylight fluorescent light.
+	F7 = WhitePoint{0.95041, 1, 1.08747}
+	// F11 is narrow band white fluorescent light.
+	F11 = WhitePoint{1.00962, 1, 0.64350}
+)
+
+// XYZ returns the white point as an XYZ color.
+func (w WhitePoint) XYZ() XYZ {
+	return XYZ{w.X, w.Y, w.Z}
+}
+
+// XYZ represents a color in the CIE 1931 XYZ color space, relative to the
+// D65 white point of sRGB. Y is relative luminance, with 1 for the sRGB
+// white. XYZD50 holds coordinates relative to D50 instead.
+type XYZ struct {
+	X, Y, Z float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the XYZ color.
+// This implements the color.Color interface.
+// The color is converted to linear sRGB, clipped to the sRGB gamut and
+// gamma encoded.
+func (c XYZ) RGBA() (r, g, b, a uint32) {
+	lr, lg, lb := xyzToLinearSRGB(c.X, c.Y, c.Z)
+	return unit16(linearToSRGB(lr)), unit16(linearToSRGB(lg)), unit16(linearToSRGB(lb)), 0xffff
+}
+
+// XyY converts c to chromaticity coordinates and luminance. Black has no
+// defined chromaticity and maps to the chromaticity of D65.
+func (c XYZ) XyY() XyY {
+	sum := c.X + c.Y + c.Z
+	if sum == 0 {
+		w := D65.XYZ().XyY()
+		return XyY{Cx: w.Cx, Cy: w.Cy, Y: 0}
+	}
+	return XyY{Cx: c.X / sum, Cy: c.Y / sum, Y: c.Y}
+}
+
+// XYZModel is the color model for CIE XYZ colors. Conversion decodes the
+// sRGB transfer function before applying the sRGB to XYZ matrix. As with
+// color.YCbCrModel, alpha is discarded.
+var XYZModel color.Model = color.ModelFunc(xyzModel)
+
+// xyzModel converts any color.Color to an XYZ.
+func xyzModel(c color.Color) color.Color {
+	switch c := c.(type) {
+	case XYZ:
+		return c
+	case XyY:
+		return c.XYZ()
+	}
+	return toXYZ(c)
+}
+
+// toXYZ returns the XYZ coordinates of any color.Color.
+func toXYZ(c color.Color) XYZ {
+	switch c := c.(type) {
+	case XYZ:
+		return c
+	case interface{ XYZ() XYZ }:
+		return c.XYZ()
+	}
+	r, g, b, _ := c.RGBA()
+	x, y, z := linearSRGBToXYZ(
+		srgbToLinear(float64(r)/0xffff),
+		srgbToLinear(float64(g)/0xffff),
+		srgbToLinear(float64(b)/0xffff),
+	)
+	return XYZ{x, y, z}
+}
+
+// D50 converts c to coordinates relative to the D50 white point, with the
+// Bradford chromatic adaptation transform.
+func (c XYZ) D50() XYZD50 {
+	x, y, z := d65ToD50.apply(c.X, c.Y, c.Z)
+	return XYZD50{x, y, z}
+}
+
+// XYZD50 represents a color in the CIE 1931 XYZ color space, relative to
+// the D50 white point of the ICC profile connection space, so that the sRGB
+// white has the coordinates of D50.
+type XYZD50 struct {
+	X, Y, Z float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the XYZD50
+// color. This implements the color.Color interface.
+// The color is adapted to D65 and converted as by XYZ.RGBA.
+func (c XYZD50) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// XYZ converts c to coordinates relative to the D65 white point, with the
+// Bradford chromatic adaptation transform.
+func (c XYZD50) XYZ() XYZ {
+	x, y, z := d50ToD65.apply(c.X, c.Y, c.Z)
+	return XYZ{x, y, z}
+}
+
+// XYZD50Model is the color model for D50-relative CIE XYZ colors. Colors
+// are converted as by XYZModel and then adapted from D65 to D50.
+var XYZD50Model color.Model = color.ModelFunc(xyzD50Model)
+
+// xyzD50Model converts any color.Color to an XYZD50.
+func xyzD50Model(c color.Color) color.Color {
+	if c, ok := c.(XYZD50); ok {
+		return c
+	}
+	return toXYZ(c).D50()
+}
+
+// d65ToD50 and d50ToD65 adapt XYZ coordinates between the white points of
+// sRGB and of the ICC profile connection space.
+var (
+	d65ToD50 = bradford(D65, D50)
+	d50ToD65 = bradford(D50, D65)
+)
+
+// XyY represents a color as CIE xy chromaticity coordinates (Cx, Cy) and
+// relative luminance Y, relative to the D65 white point of sRGB.
+type XyY struct {
+	Cx, Cy, Y float64
+}
+
+// RGBA returns the red, green, blue and alpha components of the xyY color.
+// This implements the color.Color interface.
+func (c XyY) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// XYZ converts c to XYZ coordinates. A zero Cy yields black.
+func (c XyY) XYZ() XYZ {
+	if c.Cy == 0 {
+		return XYZ{}
+	}
+	return XYZ{
+		X: c.Cx * c.Y / c.Cy,
+		Y: c.Y,
+		Z: (1 - c.Cx - c.Cy) * c.Y / c.Cy,
+	}
+}
+
+// XyYModel is the color model for CIE xyY colors.
+var XyYModel color.Model = color.ModelFunc(xyYModel)
+
+// xyYModel converts any color.Color to an XyY.
+func xyYModel(c color.Color) color.Color {
+	if c, ok := c.(XyY); ok {
+		return c
+	}
+	return toXYZ(c).XyY()
+}
+
+// srgbToLinear decodes an sRGB-encoded channel value in [0, 1].
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB encodes a linear channel value in [0, 1] with the sRGB
+// transfer function.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// linearSRGBToXYZ applies the sRGB (D65) primaries matrix.
+func linearSRGBToXYZ(r, g, b float64) (x, y, z float64) {
+	x = 0.4124564*r + 0.3575761*g + 0.1804375*b
+	y = 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z = 0.0193339*r + 0.1191920*g + 0.9503041*b
+	return x, y, z
+}
+
+// xyzToLinearSRGB applies the inverse of the sRGB (D65) primaries matrix.
+func xyzToLinearSRGB(x, y, z float64) (r, g, b float64) {
+	r = 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g = -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b = 0.0556434*x - 0.2040259*y + 1.0572252*z
+	return r, g, b
+}
+
+// unit16 converts a value in [0, 1] to a 16-bit channel, clamping out of
+// range values.
+func unit16(v float64) uint32 {
+	return uint32(clamp01(v)*0xffff + 0.5)
+}
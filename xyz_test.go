@@ -0,0 +1,127 @@
+package colorext
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestXYZModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  XYZ
+	}{
+		{"white", color.White, D65.XYZ()},
+		{"black", color.Black, XYZ{}},
+		{"red", color.RGBA{255, 0, 0, 255}, XYZ{0.4124564, 0.2126729, 0.0193339}},
+		{"middle gray", color.Gray{Y: 128}, XYZ{0.2052, 0.2159, 0.2350}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := XYZModel.Convert(tt.input).(XYZ)
+			if !approxEqual(got.X, tt.want.X, 1e-4) || !approxEqual(got.Y, tt.want.Y, 1e-4) || !approxEqual(got.Z, tt.want.Z, 1e-4) {
+				t.Errorf("XYZModel.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	original := XYZ{0.1, 0.2, 0.3}
+	if got := XYZModel.Convert(original); got != original {
+		t.Errorf("XYZModel.Convert(%v) = %v, want unchanged", original, got)
+	}
+}
+
+func TestXYZ_RoundTrip(t *testing.T) {
+	for _, c := range []color.RGBA64{
+		{0, 0, 0, 0xffff}, {0xffff, 0xffff, 0xffff, 0xffff}, {0x1234, 0x5678, 0x9abc, 0xffff},
+		{0xffff, 0, 0, 0xffff}, {10, 20, 30, 0xffff},
+	} {
+		r, g, b, a := XYZModel.Convert(c).RGBA()
+		if a != 0xffff || !approxEqual(float64(r), float64(c.R), 1) ||
+			!approxEqual(float64(g), float64(c.G), 1) || !approxEqual(float64(b), float64(c.B), 1) {
+			t.Errorf("%v round-tripped through XYZ to (%d, %d, %d, %d)", c, r, g, b, a)
+		}
+	}
+}
+
+func TestXYZD50(t *testing.T) {
+	tests := []struct {
+		name  string
+		input color.Color
+		want  XYZD50
+	}{
+		{"white", color.White, XYZD50(D50)},
+		{"black", color.Black, XYZD50{}},
+		// The Bradford-adapted sRGB red primary.
+		{"red", color.RGBA{255, 0, 0, 255}, XYZD50{0.4360747, 0.2225045, 0.0139322}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := XYZD50Model.Convert(tt.input).(XYZD50)
+			if !approxEqual(got.X, tt.want.X, 1e-4) || !approxEqual(got.Y, tt.want.Y, 1e-4) || !approxEqual(got.Z, tt.want.Z, 1e-4) {
+				t.Errorf("XYZD50Model.Convert(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	c := XYZ{0.3, 0.2, 0.6}
+	back := XYZModel.Convert(c.D50()).(XYZ)
+	if !approxEqual(back.X, c.X, 1e-9) || !approxEqual(back.Y, c.Y, 1e-9) || !approxEqual(back.Z, c.Z, 1e-9) {
+		t.Errorf("%+v round-tripped through XYZD50 to %+v", c, back)
+	}
+	r1, g1, b1, _ := c.RGBA()
+	r2, g2, b2, _ := c.D50().RGBA()
+	if r1 != r2 || g1 != g2 || b1 != b2 {
+		t.Errorf("XYZD50.RGBA() = (%d, %d, %d), want (%d, %d, %d)", r2, g2, b2, r1, g1, b1)
+	}
+}
+
+func TestXYZ_RGBA_ClipsOutOfGamut(t *testing.T) {
+	// Pure spectral green lies outside sRGB; it must clip rather than wrap.
+	r, g, b, _ := XYZ{0.1, 0.8, 0.05}.RGBA()
+	if r != 0 || g != 0xffff || b > 0xffff {
+		t.Errorf("out of gamut XYZ.RGBA() = (%d, %d, %d)", r, g, b)
+	}
+}
+
+func TestXyY(t *testing.T) {
+	w := D65.XYZ().XyY()
+	if !approxEqual(w.Cx, 0.3127, 1e-4) || !approxEqual(w.Cy, 0.3290, 1e-4) || w.Y != 1 {
+		t.Errorf("D65 xyY = %+v, want {0.3127 0.3290 1}", w)
+	}
+	d50 := D50.XYZ().XyY()
+	if !approxEqual(d50.Cx, 0.3457, 1e-4) || !approxEqual(d50.Cy, 0.3585, 1e-4) {
+		t.Errorf("D50 xyY = %+v, want {0.3457 0.3585 1}", d50)
+	}
+
+	xyz := XYZ{0.2, 0.3, 0.4}
+	back := xyz.XyY().XYZ()
+	if !approxEqual(back.X, xyz.X, 1e-12) || !approxEqual(back.Y, xyz.Y, 1e-12) || !approxEqual(back.Z, xyz.Z, 1e-12) {
+		t.Errorf("XYZ -> xyY -> XYZ = %+v, want %+v", back, xyz)
+	}
+
+	// Black keeps the white point's chromaticity and zero luminance.
+	if black := (XYZ{}).XyY(); black.Y != 0 || black.Cx != w.Cx {
+		t.Errorf("black xyY = %+v", black)
+	}
+	if got := (XyY{Cx: 0.3, Cy: 0, Y: 1}).XYZ(); got != (XYZ{}) {
+		t.Errorf("xyY with Cy = 0 converted to %+v, want black", got)
+	}
+
+	got := XyYModel.Convert(color.White).(XyY)
+	if !approxEqual(got.Cx, w.Cx, 1e-6) || !approxEqual(got.Y, 1, 1e-6) {
+		t.Errorf("XyYModel.Convert(white) = %+v, want %+v", got, w)
+	}
+	if r, _, _, _ := got.RGBA(); !approxEqual(float64(r), 0xffff, 1) {
+		t.Errorf("white xyY.RGBA() red = %d, want 65535", r)
+	}
+	if xyz := XYZModel.Convert(XyY{0.3127, 0.3290, 0.5}).(XYZ); !approxEqual(xyz.Y, 0.5, 1e-12) {
+		t.Errorf("XYZModel.Convert(xyY).Y = %v, want 0.5", xyz.Y)
+	}
+}
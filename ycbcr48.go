@@ -0,0 +1,271 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// YCbCrMatrix selects the RGB to YCbCr conversion coefficients.
+type YCbCrMatrix uint8
+
+const (
+	// BT601 uses the ITU-R BT.601 coefficients (standard definition video,
+	// JPEG). It is the zero value, matching color.YCbCr.
+	BT601 YCbCrMatrix = iota
+	// BT709 uses the ITU-R BT.709 coefficients (high definition video).
+	BT709
+)
+
+// coefficients returns the red and blue luma weights of m.
+func (m YCbCrMatrix) coefficients() (kr, kb float64) {
+	if m == BT709 {
+		return 0.2126, 0.0722
+	}
+	return 0.299, 0.114
+}
+
+// String returns the name of the matrix.
+func (m YCbCrMatrix) String() string {
+	if m == BT709 {
+		return "BT.709"
+	}
+	return "BT.601"
+}
+
+// YCbCr48 represents a fully opaque, full-range Y'CbCr color with 16 bits per
+// component. Cb and Cr are offset so that 32768 means zero chroma. Matrix
+// records which coefficients relate the color to R'G'B'.
+type YCbCr48 struct {
+	Y, Cb, Cr uint16
+	Matrix    YCbCrMatrix
+}
+
+// RGBA returns the red, green, blue and alpha components of the YCbCr48 color.
+// This implements the color.Color interface.
+func (c YCbCr48) RGBA() (r, g, b, a uint32) {
+	kr, kb := c.Matrix.coefficients()
+	y := float64(c.Y) / 0xffff
+	cb := (float64(c.Cb) - 0x8000) / 0xffff
+	cr := (float64(c.Cr) - 0x8000) / 0xffff
+	fr := y + 2*(1-kr)*cr
+	fb := y + 2*(1-kb)*cb
+	fg := (y - kr*fr - kb*fb) / (1 - kr - kb)
+	return unit16(fr), unit16(fg), unit16(fb), 0xffff
+}
+
+// YCbCr48Model is the color model for YCbCr48 colors using BT.601.
+var YCbCr48Model = YCbCr48ModelFor(BT601)
+
+// YCbCr48ModelFor returns the color model for YCbCr48 colors using matrix m.
+// Colors already using m are returned unchanged; colors using another matrix
+// are converted through RGB. As with color.YCbCrModel, alpha is discarded.
+func YCbCr48ModelFor(m YCbCrMatrix) color.Model {
+	return color.ModelFunc(func(c color.Color) color.Color {
+		if c, ok := c.(YCbCr48); ok && c.Matrix == m {
+			return c
+		}
+		r, g, b, _ := c.RGBA()
+		y, cb, cr := RGBToYCbCr48(uint16(r), uint16(g), uint16(b), m)
+		return YCbCr48{Y: y, Cb: cb, Cr: cr, Matrix: m}
+	})
+}
+
+// RGBToYCbCr48 converts a 16-bit R'G'B' triple to full-range Y'CbCr using
+// matrix m.
+func RGBToYCbCr48(r, g, b uint16, m YCbCrMatrix) (y, cb, cr uint16) {
+	kr, kb := m.coefficients()
+	fr, fg, fb := float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff
+	fy := kr*fr + (1-kr-kb)*fg + kb*fb
+	y = uint16(fy*0xffff + 0.5)
+	cb = chroma16((fb - fy) / (2 * (1 - kb)))
+	cr = chroma16((fr - fy) / (2 * (1 - kr)))
+	return y, cb, cr
+}
+
+// chroma16 converts a chroma value in [-0.5, 0.5] to its offset 16-bit form.
+func chroma16(v float64) uint16 {
+	return uint16(math.Max(0, math.Min(0xffff, math.Round(v*0xffff+0x8000))))
+}
+
+// YCbCr48Image is an in-memory planar image of YCbCr48 colors. There is one
+// Y sample per pixel, but each Cb and Cr sample can span one or more pixels
+// as determined by SubsampleRatio, using the same layout as image.YCbCr.
+// Samples are stored as big-endian uint16 values.
+type YCbCr48Image struct {
+	Y, Cb, Cr []uint8
+	// YStride and CStride are the plane strides in bytes.
+	YStride        int
+	CStride        int
+	SubsampleRatio image.YCbCrSubsampleRatio
+	Matrix         YCbCrMatrix
+	Rect           image.Rectangle
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the YCbCr48Image's color model.
+func (p *YCbCr48Image) ColorModel() color.Model {
+	return YCbCr48ModelFor(p.Matrix)
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *YCbCr48Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y).
+func (p *YCbCr48Image) At(x, y int) color.Color {
+	return p.YCbCr48At(x, y)
+}
+
+// YCbCr48At returns the YCbCr48 color of the pixel at (x, y).
+func (p *YCbCr48Image) YCbCr48At(x, y int) YCbCr48 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return YCbCr48{Matrix: p.Matrix}
+	}
+	yi := p.YOffset(x, y)
+	ci := p.COffset(x, y)
+	return YCbCr48{
+		Y:      uint16(p.Y[yi])<<8 | uint16(p.Y[yi+1]),
+		Cb:     uint16(p.Cb[ci])<<8 | uint16(p.Cb[ci+1]),
+		Cr:     uint16(p.Cr[ci])<<8 | uint16(p.Cr[ci+1]),
+		Matrix: p.Matrix,
+	}
+}
+
+// YOffset returns the index of the first element of Y that corresponds to
+// the pixel at (x, y).
+func (p *YCbCr48Image) YOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.YStride + (x-p.Rect.Min.X)*2
+}
+
+// COffset returns the index of the first element of Cb or Cr that
+// corresponds to the pixel at (x, y).
+func (p *YCbCr48Image) COffset(x, y int) int {
+	switch p.SubsampleRatio {
+	case image.YCbCrSubsampleRatio422:
+		return (y-p.Rect.Min.Y)*p.CStride + (x/2-p.Rect.Min.X/2)*2
+	case image.YCbCrSubsampleRatio420:
+		return (y/2-p.Rect.Min.Y/2)*p.CStride + (x/2-p.Rect.Min.X/2)*2
+	case image.YCbCrSubsampleRatio440:
+		return (y/2-p.Rect.Min.Y/2)*p.CStride + (x-p.Rect.Min.X)*2
+	case image.YCbCrSubsampleRatio411:
+		return (y-p.Rect.Min.Y)*p.CStride + (x/4-p.Rect.Min.X/4)*2
+	case image.YCbCrSubsampleRatio410:
+		return (y/2-p.Rect.Min.Y/2)*p.CStride + (x/4-p.Rect.Min.X/4)*2
+	}
+	// Default to 4:4:4 subsampling.
+	return (y-p.Rect.Min.Y)*p.CStride + (x-p.Rect.Min.X)*2
+}
+
+// SetYCbCr48 sets the pixel at (x, y) to a given color. The luma sample is
+// written for that pixel only, while the chroma samples are shared with the
+// other pixels covered by the same chroma sample.
+func (p *YCbCr48Image) SetYCbCr48(x, y int, c YCbCr48) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return
+	}
+	if c.Matrix != p.Matrix {
+		c = YCbCr48ModelFor(p.Matrix).Convert(c).(YCbCr48)
+	}
+	yi := p.YOffset(x, y)
+	ci := p.COffset(x, y)
+	p.Y[yi], p.Y[yi+1] = uint8(c.Y>>8), uint8(c.Y)
+	p.Cb[ci], p.Cb[ci+1] = uint8(c.Cb>>8), uint8(c.Cb)
+	p.Cr[ci], p.Cr[ci+1] = uint8(c.Cr>>8), uint8(c.Cr)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *YCbCr48Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &YCbCr48Image{SubsampleRatio: p.SubsampleRatio, Matrix: p.Matrix, Meta: p.Meta}
+	}
+	yi := p.YOffset(r.Min.X, r.Min.Y)
+	ci := p.COffset(r.Min.X, r.Min.Y)
+	return &YCbCr48Image{
+		Y:              p.Y[yi:],
+		Cb:             p.Cb[ci:],
+		Cr:             p.Cr[ci:],
+		YStride:        p.YStride,
+		CStride:        p.CStride,
+		SubsampleRatio: p.SubsampleRatio,
+		Matrix:         p.Matrix,
+		Rect:           r,
+		Meta:           p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque.
+// YCbCr48Image is always fully opaque since the YCbCr48 color model has no transparency.
+func (p *YCbCr48Image) Opaque() bool {
+	return true
+}
+
+// NewYCbCr48Image returns a new YCbCr48Image with the given bounds,
+// subsample ratio and matrix. Unless given WithFill or WithAllocator, all
+// chroma samples start at zero chroma.
+func NewYCbCr48Image(r image.Rectangle, ratio image.YCbCrSubsampleRatio, m YCbCrMatrix, opts ...ImageOption) *YCbCr48Image {
+	o := newImageOptions(opts)
+	w, h, cw, ch := yCbCrSize(r, ratio)
+	p := &YCbCr48Image{
+		Y:              o.pix(2*w*h, 2),
+		Cb:             o.pix(2*cw*ch, 2),
+		Cr:             o.pix(2*cw*ch, 2),
+		YStride:        2 * w,
+		CStride:        2 * cw,
+		SubsampleRatio: ratio,
+		Matrix:         m,
+		Rect:           r,
+		Meta:           o.meta,
+	}
+	switch {
+	case o.fill != nil:
+		c := YCbCr48ModelFor(m).Convert(o.fill).(YCbCr48)
+		fillRepeat(p.Y, uint8(c.Y>>8), uint8(c.Y))
+		fillRepeat(p.Cb, uint8(c.Cb>>8), uint8(c.Cb))
+		fillRepeat(p.Cr, uint8(c.Cr>>8), uint8(c.Cr))
+	case o.alloc == nil:
+		fillRepeat(p.Cb, 0x80, 0)
+		fillRepeat(p.Cr, 0x80, 0)
+	}
+	return p
+}
+
+func (p *YCbCr48Image) metadata() Metadata {
+	return p.Meta
+}
+
+// yCbCrSize returns the luma and chroma plane dimensions, in samples, for
+// the given bounds and subsample ratio.
+func yCbCrSize(r image.Rectangle, ratio image.YCbCrSubsampleRatio) (w, h, cw, ch int) {
+	w, h = r.Dx(), r.Dy()
+	switch ratio {
+	case image.YCbCrSubsampleRatio422:
+		cw = (r.Max.X+1)/2 - r.Min.X/2
+		ch = h
+	case image.YCbCrSubsampleRatio420:
+		cw = (r.Max.X+1)/2 - r.Min.X/2
+		ch = (r.Max.Y+1)/2 - r.Min.Y/2
+	case image.YCbCrSubsampleRatio440:
+		cw = w
+		ch = (r.Max.Y+1)/2 - r.Min.Y/2
+	case image.YCbCrSubsampleRatio411:
+		cw = (r.Max.X+3)/4 - r.Min.X/4
+		ch = h
+	case image.YCbCrSubsampleRatio410:
+		cw = (r.Max.X+3)/4 - r.Min.X/4
+		ch = (r.Max.Y+1)/2 - r.Min.Y/2
+	default:
+		// Default to 4:4:4 subsampling.
+		cw = w
+		ch = h
+	}
+	return w, h, cw, ch
+}
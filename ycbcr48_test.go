@@ -0,0 +1,137 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestYCbCr48_RGBA(t *testing.T) {
+	for _, m := range []YCbCrMatrix{BT601, BT709} {
+		t.Run(m.String(), func(t *testing.T) {
+			white := YCbCr48{Y: 0xffff, Cb: 0x8000, Cr: 0x8000, Matrix: m}
+			if r, g, b, a := white.RGBA(); r != 0xffff || g != 0xffff || b != 0xffff || a != 0xffff {
+				t.Errorf("white.RGBA() = (%d, %d, %d, %d)", r, g, b, a)
+			}
+			black := YCbCr48{Cb: 0x8000, Cr: 0x8000, Matrix: m}
+			if r, g, b, _ := black.RGBA(); r != 0 || g != 0 || b != 0 {
+				t.Errorf("black.RGBA() = (%d, %d, %d)", r, g, b)
+			}
+		})
+	}
+}
+
+func TestRGBToYCbCr48(t *testing.T) {
+	// Pure red has luma equal to the red coefficient of each matrix.
+	tests := []struct {
+		m    YCbCrMatrix
+		want uint16
+	}{
+		{BT601, 19595},
+		{BT709, 13933},
+	}
+	for _, tt := range tests {
+		y, cb, cr := RGBToYCbCr48(0xffff, 0, 0, tt.m)
+		if y != tt.want {
+			t.Errorf("%v: Y of red = %d, want %d", tt.m, y, tt.want)
+		}
+		if cr != 0xffff || cb >= 0x8000 {
+			t.Errorf("%v: red chroma = (%d, %d), want Cb < 32768, Cr = 65535", tt.m, cb, cr)
+		}
+	}
+
+	// Grays have zero chroma.
+	if _, cb, cr := RGBToYCbCr48(0x4000, 0x4000, 0x4000, BT709); cb != 0x8000 || cr != 0x8000 {
+		t.Errorf("gray chroma = (%d, %d), want (32768, 32768)", cb, cr)
+	}
+}
+
+func TestYCbCr48Model_RoundTrip(t *testing.T) {
+	for _, m := range []YCbCrMatrix{BT601, BT709} {
+		model := YCbCr48ModelFor(m)
+		for _, c := range []color.RGBA64{
+			{0x1234, 0x5678, 0x9abc, 0xffff}, {0xffff, 0, 0, 0xffff}, {0, 0xffff, 0, 0xffff}, {0, 0, 0xffff, 0xffff},
+		} {
+			ycc := model.Convert(c).(YCbCr48)
+			if ycc.Matrix != m {
+				t.Errorf("Convert set Matrix = %v, want %v", ycc.Matrix, m)
+			}
+			r, g, b, _ := ycc.RGBA()
+			if !approxEqual(float64(r), float64(c.R), 2) || !approxEqual(float64(g), float64(c.G), 2) ||
+				!approxEqual(float64(b), float64(c.B), 2) {
+				t.Errorf("%v: %v round-tripped to (%d, %d, %d)", m, c, r, g, b)
+			}
+		}
+	}
+
+	// Converting between matrices goes through RGB.
+	c601 := YCbCr48Model.Convert(color.RGBA64{0xffff, 0, 0, 0xffff}).(YCbCr48)
+	c709 := YCbCr48ModelFor(BT709).Convert(c601).(YCbCr48)
+	if c709.Matrix != BT709 || c709.Y == c601.Y {
+		t.Errorf("BT.601 red converted to BT.709 as %+v", c709)
+	}
+	if got := YCbCr48Model.Convert(c601); got != c601 {
+		t.Errorf("YCbCr48Model.Convert(%v) = %v, want unchanged", c601, got)
+	}
+}
+
+func TestNewYCbCr48Image(t *testing.T) {
+	tests := []struct {
+		ratio          image.YCbCrSubsampleRatio
+		cStride, cSize int
+	}{
+		{image.YCbCrSubsampleRatio444, 10, 10 * 4},
+		{image.YCbCrSubsampleRatio422, 6, 6 * 4},
+		{image.YCbCrSubsampleRatio420, 6, 6 * 2},
+		{image.YCbCrSubsampleRatio440, 10, 10 * 2},
+		{image.YCbCrSubsampleRatio411, 4, 4 * 4},
+		{image.YCbCrSubsampleRatio410, 4, 4 * 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ratio.String(), func(t *testing.T) {
+			img := NewYCbCr48Image(image.Rect(0, 0, 5, 4), tt.ratio, BT709)
+			if img.YStride != 10 || len(img.Y) != 40 {
+				t.Errorf("YStride = %d, len(Y) = %d, want 10, 40", img.YStride, len(img.Y))
+			}
+			if img.CStride != tt.cStride || len(img.Cb) != tt.cSize || len(img.Cr) != tt.cSize {
+				t.Errorf("CStride = %d, len(Cb) = %d, want %d, %d", img.CStride, len(img.Cb), tt.cStride, tt.cSize)
+			}
+			// A fresh image is black with neutral chroma.
+			if r, g, b, _ := img.At(4, 3).RGBA(); r != 0 || g != 0 || b != 0 {
+				t.Errorf("At(4, 3) = (%d, %d, %d), want black", r, g, b)
+			}
+		})
+	}
+}
+
+func TestYCbCr48Image_SubsampledChroma(t *testing.T) {
+	img := NewYCbCr48Image(image.Rect(0, 0, 4, 4), image.YCbCrSubsampleRatio420, BT601)
+	c := YCbCr48{Y: 1000, Cb: 2000, Cr: 3000}
+	img.SetYCbCr48(1, 1, c)
+
+	if got := img.YCbCr48At(1, 1); got != c {
+		t.Errorf("YCbCr48At(1, 1) = %+v, want %+v", got, c)
+	}
+	// The 2x2 block shares chroma but not luma.
+	if got := img.YCbCr48At(0, 0); got.Cb != 2000 || got.Cr != 3000 || got.Y != 0 {
+		t.Errorf("YCbCr48At(0, 0) = %+v, want shared chroma and zero luma", got)
+	}
+	if got := img.YCbCr48At(2, 0); got.Cb != 0x8000 {
+		t.Errorf("YCbCr48At(2, 0).Cb = %d, want untouched 32768", got.Cb)
+	}
+
+	sub := img.SubImage(image.Rect(1, 1, 3, 3)).(*YCbCr48Image)
+	if got := sub.YCbCr48At(1, 1); got != c {
+		t.Errorf("SubImage.YCbCr48At(1, 1) = %+v, want %+v", got, c)
+	}
+	if got := img.YCbCr48At(10, 10); got.Y != 0 || got.Matrix != BT601 {
+		t.Errorf("YCbCr48At out of bounds = %+v, want zero", got)
+	}
+	if empty := img.SubImage(image.Rect(8, 8, 9, 9)); !empty.Bounds().Empty() {
+		t.Errorf("Non-intersecting SubImage bounds = %v, want empty", empty.Bounds())
+	}
+	if !img.Opaque() || img.ColorModel() == nil {
+		t.Error("unexpected Opaque or ColorModel")
+	}
+}
@@ -0,0 +1,390 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// yuvToRGBA converts 8-bit Y'CbCr samples to an opaque color.RGBA. Limited
+// range samples use luma in [16, 235] and chroma in [16, 240].
+func yuvToRGBA(y, u, v uint8, m YCbCrMatrix, limited bool) color.RGBA {
+	kr, kb := m.coefficients()
+	fy := float64(y) / 255
+	cb := (float64(u) - 128) / 255
+	cr := (float64(v) - 128) / 255
+	if limited {
+		fy = (float64(y) - 16) / 219
+		cb = (float64(u) - 128) / 224
+		cr = (float64(v) - 128) / 224
+	}
+	fr := fy + 2*(1-kr)*cr
+	fb := fy + 2*(1-kb)*cb
+	fg := (fy - kr*fr - kb*fb) / (1 - kr - kb)
+	return color.RGBA{R: unit8(fr), G: unit8(fg), B: unit8(fb), A: 0xff}
+}
+
+// rgbToYUV converts c to 8-bit Y'CbCr samples, the inverse of yuvToRGBA.
+// As with color.YCbCrModel, alpha is discarded.
+func rgbToYUV(c color.Color, m YCbCrMatrix, limited bool) (y, u, v uint8) {
+	r, g, b, _ := c.RGBA()
+	kr, kb := m.coefficients()
+	fr, fg, fb := float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff
+	fy := kr*fr + (1-kr-kb)*fg + kb*fb
+	cb := (fb - fy) / (2 * (1 - kb))
+	cr := (fr - fy) / (2 * (1 - kr))
+	if limited {
+		return uint8(16 + 219*clamp01(fy) + 0.5), chroma8(224 * cb), chroma8(224 * cr)
+	}
+	return unit8(fy), chroma8(255 * cb), chroma8(255 * cr)
+}
+
+// chroma8 offsets a scaled chroma value to its 8-bit form.
+func chroma8(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(128+v))))
+}
+
+// lumaToGray expands a limited range luma sample to full range.
+func lumaToGray(y uint8, limited bool) uint8 {
+	if !limited {
+		return y
+	}
+	return unit8((float64(y) - 16) / 219)
+}
+
+// chromaOffset420 returns the offset of the 4:2:0 chroma sample covering
+// (x, y) in a plane with the given stride and bytes per sample pair.
+func chromaOffset420(r image.Rectangle, x, y, stride, size int) int {
+	return (y/2-r.Min.Y/2)*stride + (x/2-r.Min.X/2)*size
+}
+
+// NV12Image is an 8-bit semi-planar 4:2:0 Y'CbCr image, the native output
+// of most hardware video decoders. It has a full resolution Y plane and a
+// half resolution plane of interleaved Cb, Cr sample pairs.
+//
+// An NV12Image can wrap decoder buffers without copying by setting its
+// fields directly. At converts to RGB using Matrix and LimitedRange; like
+// image.YCbCr it has no Set method.
+type NV12Image struct {
+	// Y holds the luma plane. The sample for (x, y) is at
+	// Y[(y-Rect.Min.Y)*YStride + (x-Rect.Min.X)].
+	Y []uint8
+	// UV holds the interleaved chroma plane. The Cb sample for (x, y) is at
+	// UV[(y/2-Rect.Min.Y/2)*UVStride + (x/2-Rect.Min.X/2)*2], followed by
+	// the Cr sample.
+	UV       []uint8
+	YStride  int
+	UVStride int
+	Rect     image.Rectangle
+	// Matrix selects the conversion coefficients.
+	Matrix YCbCrMatrix
+	// LimitedRange reports whether samples use the video range, with luma
+	// in [16, 235] and chroma in [16, 240], rather than the full range.
+	LimitedRange bool
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the NV12Image's color model.
+func (p *NV12Image) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *NV12Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y), converted to RGB.
+func (p *NV12Image) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return color.RGBA{}
+	}
+	yy, u, v := p.YUVAt(x, y)
+	return yuvToRGBA(yy, u, v, p.Matrix, p.LimitedRange)
+}
+
+// YUVAt returns the raw luma and chroma samples of the pixel at (x, y).
+func (p *NV12Image) YUVAt(x, y int) (yy, u, v uint8) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0, 0, 0
+	}
+	c := p.UVOffset(x, y)
+	return p.Y[p.YOffset(x, y)], p.UV[c], p.UV[c+1]
+}
+
+// YOffset returns the index of the first element of Y that corresponds to
+// the pixel at (x, y).
+func (p *NV12Image) YOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.YStride + (x - p.Rect.Min.X)
+}
+
+// UVOffset returns the index of the Cb sample in UV that corresponds to the
+// pixel at (x, y).
+func (p *NV12Image) UVOffset(x, y int) int {
+	return chromaOffset420(p.Rect, x, y, p.UVStride, 2)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *NV12Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &NV12Image{Matrix: p.Matrix, LimitedRange: p.LimitedRange, Meta: p.Meta}
+	}
+	return &NV12Image{
+		Y:            p.Y[p.YOffset(r.Min.X, r.Min.Y):],
+		UV:           p.UV[p.UVOffset(r.Min.X, r.Min.Y):],
+		YStride:      p.YStride,
+		UVStride:     p.UVStride,
+		Rect:         r,
+		Matrix:       p.Matrix,
+		LimitedRange: p.LimitedRange,
+		Meta:         p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque. It is always true.
+func (p *NV12Image) Opaque() bool {
+	return true
+}
+
+// YPlane returns the luma plane as an image.Gray sharing p's pixels. Samples
+// are not rescaled, so limited range images yield values in [16, 235].
+func (p *NV12Image) YPlane() *image.Gray {
+	return &image.Gray{Pix: p.Y, Stride: p.YStride, Rect: p.Rect}
+}
+
+// ToGray returns a copy of the luma plane, expanded to full range if
+// LimitedRange is set. Chroma is ignored.
+func (p *NV12Image) ToGray() *image.Gray {
+	dst := image.NewGray(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			dst.Pix[dst.PixOffset(x, y)] = lumaToGray(p.Y[p.YOffset(x, y)], p.LimitedRange)
+		}
+	}
+	return dst
+}
+
+// ToRGBA returns a copy of p converted to RGB.
+func (p *NV12Image) ToRGBA() *image.RGBA {
+	dst := image.NewRGBA(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			yy, u, v := p.YUVAt(x, y)
+			dst.SetRGBA(x, y, yuvToRGBA(yy, u, v, p.Matrix, p.LimitedRange))
+		}
+	}
+	return dst
+}
+
+// NewNV12Image returns a new NV12Image with the given bounds, matrix and
+// range. Unless given WithFill or WithAllocator, the chroma plane is
+// initialized to neutral gray.
+func NewNV12Image(r image.Rectangle, m YCbCrMatrix, limited bool, opts ...ImageOption) *NV12Image {
+	o := newImageOptions(opts)
+	w, h, cw, ch := yCbCrSize(r, image.YCbCrSubsampleRatio420)
+	p := &NV12Image{
+		Y:            o.pix(w*h, 1),
+		UV:           o.pix(2*cw*ch, 1),
+		YStride:      w,
+		UVStride:     2 * cw,
+		Rect:         r,
+		Matrix:       m,
+		LimitedRange: limited,
+		Meta:         o.meta,
+	}
+	switch {
+	case o.fill != nil:
+		y, u, v := rgbToYUV(o.fill, m, limited)
+		fillRepeat(p.Y, y)
+		fillRepeat(p.UV, u, v)
+	case o.alloc == nil:
+		fillRepeat(p.UV, 0x80)
+	}
+	return p
+}
+
+func (p *NV12Image) metadata() Metadata {
+	return p.Meta
+}
+
+// I420Image is an 8-bit planar 4:2:0 Y'CbCr image with separate Y, U (Cb)
+// and V (Cr) planes, as produced by software video decoders. Its layout
+// matches image.YCbCr with a 4:2:0 subsample ratio, but it also records
+// the conversion matrix and range.
+//
+// An I420Image can wrap decoder buffers without copying by setting its
+// fields directly. Like image.YCbCr it has no Set method.
+type I420Image struct {
+	// Y holds the luma plane. The sample for (x, y) is at
+	// Y[(y-Rect.Min.Y)*YStride + (x-Rect.Min.X)].
+	Y []uint8
+	// U and V hold the chroma planes. The samples for (x, y) are at
+	// U[(y/2-Rect.Min.Y/2)*CStride + (x/2-Rect.Min.X/2)], and likewise for V.
+	U, V    []uint8
+	YStride int
+	CStride int
+	Rect    image.Rectangle
+	// Matrix selects the conversion coefficients.
+	Matrix YCbCrMatrix
+	// LimitedRange reports whether samples use the video range, with luma
+	// in [16, 235] and chroma in [16, 240], rather than the full range.
+	LimitedRange bool
+	// Meta holds annotations of the image, as set by WithMeta. Sub-images
+	// share it.
+	Meta Metadata
+}
+
+// ColorModel returns the I420Image's color model.
+func (p *I420Image) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+// Bounds returns the domain for which At can return non-zero color.
+func (p *I420Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At returns the color of the pixel at (x, y), converted to RGB.
+func (p *I420Image) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return color.RGBA{}
+	}
+	yy, u, v := p.YUVAt(x, y)
+	return yuvToRGBA(yy, u, v, p.Matrix, p.LimitedRange)
+}
+
+// YUVAt returns the raw luma and chroma samples of the pixel at (x, y).
+func (p *I420Image) YUVAt(x, y int) (yy, u, v uint8) {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return 0, 0, 0
+	}
+	c := p.COffset(x, y)
+	return p.Y[p.YOffset(x, y)], p.U[c], p.V[c]
+}
+
+// YOffset returns the index of the first element of Y that corresponds to
+// the pixel at (x, y).
+func (p *I420Image) YOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.YStride + (x - p.Rect.Min.X)
+}
+
+// COffset returns the index of the first element of U or V that corresponds
+// to the pixel at (x, y).
+func (p *I420Image) COffset(x, y int) int {
+	return chromaOffset420(p.Rect, x, y, p.CStride, 1)
+}
+
+// SubImage returns an image representing the portion of the image p visible
+// through r. The returned value shares pixels with the original image.
+func (p *I420Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	// If r1 and r2 are Rectangles, r1.Intersect(r2) is not guaranteed to be inside
+	// either r1 or r2 if the intersection is empty. Without explicitly checking for
+	// this, the Pix[i:] expression below can panic.
+	if r.Empty() {
+		return &I420Image{Matrix: p.Matrix, LimitedRange: p.LimitedRange, Meta: p.Meta}
+	}
+	c := p.COffset(r.Min.X, r.Min.Y)
+	return &I420Image{
+		Y:            p.Y[p.YOffset(r.Min.X, r.Min.Y):],
+		U:            p.U[c:],
+		V:            p.V[c:],
+		YStride:      p.YStride,
+		CStride:      p.CStride,
+		Rect:         r,
+		Matrix:       p.Matrix,
+		LimitedRange: p.LimitedRange,
+		Meta:         p.Meta,
+	}
+}
+
+// Opaque reports whether the image is fully opaque. It is always true.
+func (p *I420Image) Opaque() bool {
+	return true
+}
+
+// YPlane returns the luma plane as an image.Gray sharing p's pixels. Samples
+// are not rescaled, so limited range images yield values in [16, 235].
+func (p *I420Image) YPlane() *image.Gray {
+	return &image.Gray{Pix: p.Y, Stride: p.YStride, Rect: p.Rect}
+}
+
+// ToGray returns a copy of the luma plane, expanded to full range if
+// LimitedRange is set. Chroma is ignored.
+func (p *I420Image) ToGray() *image.Gray {
+	dst := image.NewGray(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			dst.Pix[dst.PixOffset(x, y)] = lumaToGray(p.Y[p.YOffset(x, y)], p.LimitedRange)
+		}
+	}
+	return dst
+}
+
+// ToRGBA returns a copy of p converted to RGB.
+func (p *I420Image) ToRGBA() *image.RGBA {
+	dst := image.NewRGBA(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			yy, u, v := p.YUVAt(x, y)
+			dst.SetRGBA(x, y, yuvToRGBA(yy, u, v, p.Matrix, p.LimitedRange))
+		}
+	}
+	return dst
+}
+
+// YCbCr returns an image.YCbCr sharing p's planes. The stdlib type always
+// interprets samples as full range BT.601, so the result only renders
+// correctly if p uses that matrix and range.
+func (p *I420Image) YCbCr() *image.YCbCr {
+	return &image.YCbCr{
+		Y:              p.Y,
+		Cb:             p.U,
+		Cr:             p.V,
+		YStride:        p.YStride,
+		CStride:        p.CStride,
+		SubsampleRatio: image.YCbCrSubsampleRatio420,
+		Rect:           p.Rect,
+	}
+}
+
+// NewI420Image returns a new I420Image with the given bounds, matrix and
+// range. Unless given WithFill or WithAllocator, the chroma planes are
+// initialized to neutral gray.
+func NewI420Image(r image.Rectangle, m YCbCrMatrix, limited bool, opts ...ImageOption) *I420Image {
+	o := newImageOptions(opts)
+	w, h, cw, ch := yCbCrSize(r, image.YCbCrSubsampleRatio420)
+	p := &I420Image{
+		Y:            o.pix(w*h, 1),
+		U:            o.pix(cw*ch, 1),
+		V:            o.pix(cw*ch, 1),
+		YStride:      w,
+		CStride:      cw,
+		Rect:         r,
+		Matrix:       m,
+		LimitedRange: limited,
+		Meta:         o.meta,
+	}
+	switch {
+	case o.fill != nil:
+		y, u, v := rgbToYUV(o.fill, m, limited)
+		fillRepeat(p.Y, y)
+		fillRepeat(p.U, u)
+		fillRepeat(p.V, v)
+	case o.alloc == nil:
+		fillRepeat(p.U, 0x80)
+		fillRepeat(p.V, 0x80)
+	}
+	return p
+}
+
+func (p *I420Image) metadata() Metadata {
+	return p.Meta
+}
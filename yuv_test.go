@@ -0,0 +1,110 @@
+package colorext
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestI420Image_MatchesStdlib(t *testing.T) {
+	r := image.Rect(1, 1, 6, 6)
+	img := NewI420Image(r, BT601, false)
+	std := img.YCbCr()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Y[img.YOffset(x, y)] = uint8(40*x + 7*y)
+			c := img.COffset(x, y)
+			img.U[c] = uint8(30 * x)
+			img.V[c] = uint8(255 - 25*y)
+		}
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			got := img.At(x, y).(color.RGBA)
+			want := color.RGBAModel.Convert(std.At(x, y)).(color.RGBA)
+			if absDiff8(got.R, want.R) > 1 || absDiff8(got.G, want.G) > 1 || absDiff8(got.B, want.B) > 1 {
+				t.Errorf("At(%d, %d) = %v, stdlib gives %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func absDiff8(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestNV12Image_LimitedRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       YCbCrMatrix
+		y, u, v uint8
+		want    color.RGBA
+	}{
+		{"black", BT709, 16, 128, 128, color.RGBA{0, 0, 0, 0xff}},
+		{"white", BT709, 235, 128, 128, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{"BT.709 red", BT709, 63, 102, 240, color.RGBA{0xff, 0, 0, 0xff}},
+		{"BT.601 red", BT601, 81, 90, 240, color.RGBA{0xff, 0, 0, 0xff}},
+		{"below black clamps", BT601, 0, 128, 128, color.RGBA{0, 0, 0, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NewNV12Image(image.Rect(0, 0, 2, 2), tt.m, true)
+			img.Y[0] = tt.y
+			img.UV[0], img.UV[1] = tt.u, tt.v
+			got := img.At(0, 0).(color.RGBA)
+			if absDiff8(got.R, tt.want.R) > 1 || absDiff8(got.G, tt.want.G) > 1 || absDiff8(got.B, tt.want.B) > 1 {
+				t.Errorf("At(0, 0) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNV12Image_WrapAndSubImage(t *testing.T) {
+	// A 4×2 frame with padded strides, as handed out by a decoder.
+	y := []uint8{
+		10, 20, 30, 40, 0, 0,
+		50, 60, 70, 80, 0, 0,
+	}
+	uv := []uint8{100, 110, 120, 130, 0, 0}
+	img := &NV12Image{Y: y, UV: uv, YStride: 6, UVStride: 6, Rect: image.Rect(0, 0, 4, 2)}
+
+	if yy, u, v := img.YUVAt(3, 1); yy != 80 || u != 120 || v != 130 {
+		t.Errorf("YUVAt(3, 1) = (%d, %d, %d), want (80, 120, 130)", yy, u, v)
+	}
+	sub := img.SubImage(image.Rect(1, 1, 4, 2)).(*NV12Image)
+	for x := 1; x < 4; x++ {
+		if got, want := sub.At(x, 1), img.At(x, 1); got != want {
+			t.Errorf("SubImage.At(%d, 1) = %v, want %v", x, got, want)
+		}
+	}
+	if got := sub.At(0, 1); got != (color.RGBA{}) {
+		t.Errorf("out of bounds At = %v, want zero", got)
+	}
+
+	g := img.YPlane()
+	if &g.Pix[0] != &y[0] || g.GrayAt(2, 1).Y != 70 {
+		t.Error("YPlane does not share the luma plane")
+	}
+	if rgba := img.ToRGBA(); rgba.RGBAAt(2, 0) != img.At(2, 0) {
+		t.Errorf("ToRGBA pixel = %v, want %v", rgba.RGBAAt(2, 0), img.At(2, 0))
+	}
+}
+
+func TestYUVImages_ToGray(t *testing.T) {
+	nv := NewNV12Image(image.Rect(0, 0, 2, 1), BT709, true)
+	nv.Y[0], nv.Y[1] = 16, 235
+	if g := nv.ToGray(); g.Pix[0] != 0 || g.Pix[1] != 0xff {
+		t.Errorf("limited ToGray = %v, want [0 255]", g.Pix)
+	}
+
+	i4 := NewI420Image(image.Rect(0, 0, 2, 1), BT601, false)
+	i4.Y[0], i4.Y[1] = 16, 235
+	if g := i4.ToGray(); g.Pix[0] != 16 || g.Pix[1] != 235 {
+		t.Errorf("full range ToGray = %v, want [16 235]", g.Pix)
+	}
+}